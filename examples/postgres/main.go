@@ -25,6 +25,7 @@ func main() {
 	config.CleanupEnabled = true
 	config.CleanupInterval = 1 * time.Hour
 	config.RecordTTL = 7 * 24 * time.Hour // 7 days
+	config.AutoMigrate = true             // Create or upgrade the schema on startup
 
 	storage, err := postgres.New(ctx, config)
 	if err != nil {
@@ -0,0 +1,81 @@
+package storagetest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mihaimyh/goquota/pkg/goquota"
+)
+
+// BatchStorage is a storage that implements goquota.BatchStorage
+type BatchStorage interface {
+	goquota.Storage
+	goquota.BatchStorage
+}
+
+// BatchReads checks that batch reads return the stored entitlements by user, omitting missing
+// ones, and the stored usages in query order, nil for missing ones
+func BatchReads(t *testing.T, storage BatchStorage) {
+	t.Helper()
+	ctx := context.Background()
+
+	now := time.Now().UTC()
+	for _, userID := range []string{"user1", "user2"} {
+		err := storage.SetEntitlement(ctx, &goquota.Entitlement{
+			UserID:                userID,
+			Tier:                  "pro",
+			SubscriptionStartDate: now,
+			UpdatedAt:             now,
+		})
+		if err != nil {
+			t.Fatalf("SetEntitlement failed: %v", err)
+		}
+	}
+
+	ents, err := storage.GetEntitlements(ctx, []string{"user1", "missing", "user2"})
+	if err != nil {
+		t.Fatalf("GetEntitlements failed: %v", err)
+	}
+	if len(ents) != 2 || ents["user1"].Tier != "pro" || ents["user2"].UserID != "user2" {
+		t.Errorf("Expected entitlements for user1 and user2, got %+v", ents)
+	}
+	if _, ok := ents["missing"]; ok {
+		t.Error("Expected users without an entitlement to be omitted")
+	}
+
+	period := goquota.Period{
+		Start: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		End:   time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC),
+		Type:  goquota.PeriodTypeMonthly,
+	}
+	for i, userID := range []string{"user1", "user2"} {
+		err := storage.SetUsage(ctx, userID, "api_calls", &goquota.Usage{
+			UserID: userID, Resource: "api_calls", Used: (i + 1) * 10, Limit: 100, Period: period, Tier: "pro",
+		}, period)
+		if err != nil {
+			t.Fatalf("SetUsage failed: %v", err)
+		}
+	}
+
+	usages, err := storage.GetUsages(ctx, []goquota.UsageQuery{
+		{UserID: "user2", Resource: "api_calls", Period: period},
+		{UserID: "user1", Resource: "other", Period: period},
+		{UserID: "user1", Resource: "api_calls", Period: period},
+	})
+	if err != nil {
+		t.Fatalf("GetUsages failed: %v", err)
+	}
+	if len(usages) != 3 {
+		t.Fatalf("Expected 3 results, got %d", len(usages))
+	}
+	if usages[0] == nil || usages[0].Used != 20 || usages[0].UserID != "user2" {
+		t.Errorf("Expected user2 usage of 20, got %+v", usages[0])
+	}
+	if usages[1] != nil {
+		t.Errorf("Expected nil for missing usage, got %+v", usages[1])
+	}
+	if usages[2] == nil || usages[2].Used != 10 || usages[2].Limit != 100 {
+		t.Errorf("Expected user1 usage of 10/100, got %+v", usages[2])
+	}
+}
@@ -0,0 +1,88 @@
+package storagetest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mihaimyh/goquota/pkg/goquota"
+)
+
+// HistoryStorage is a storage that implements goquota.UsageHistoryStorage
+type HistoryStorage interface {
+	goquota.Storage
+	goquota.UsageHistoryStorage
+}
+
+// UsageHistory checks that history queries return the user's periods of a resource and period
+// type in range, newest first, paged by offset and limit
+func UsageHistory(t *testing.T, storage HistoryStorage) {
+	t.Helper()
+	ctx := context.Background()
+
+	monthly := func(month int) goquota.Period {
+		start := time.Date(2026, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
+		return goquota.Period{Start: start, End: start.AddDate(0, 1, 0), Type: goquota.PeriodTypeMonthly}
+	}
+	for month := 1; month <= 4; month++ {
+		period := monthly(month)
+		err := storage.SetUsage(ctx, "user1", "api_calls", &goquota.Usage{
+			UserID: "user1", Resource: "api_calls", Used: month * 10, Limit: 100, Period: period, Tier: "free",
+		}, period)
+		if err != nil {
+			t.Fatalf("SetUsage failed: %v", err)
+		}
+	}
+
+	// Other users, resources and period types are excluded
+	daily := goquota.Period{
+		Start: time.Date(2026, 2, 15, 0, 0, 0, 0, time.UTC),
+		End:   time.Date(2026, 2, 16, 0, 0, 0, 0, time.UTC),
+		Type:  goquota.PeriodTypeDaily,
+	}
+	_ = storage.SetUsage(ctx, "user1", "api_calls", &goquota.Usage{
+		UserID: "user1", Resource: "api_calls", Used: 1, Limit: 10, Period: daily,
+	}, daily)
+	_ = storage.SetUsage(ctx, "user2", "api_calls", &goquota.Usage{
+		UserID: "user2", Resource: "api_calls", Used: 1, Limit: 10, Period: monthly(2),
+	}, monthly(2))
+
+	query := &goquota.UsageHistoryQuery{
+		UserID:     "user1",
+		Resource:   "api_calls",
+		PeriodType: goquota.PeriodTypeMonthly,
+		From:       monthly(1).Start,
+		To:         monthly(4).Start,
+	}
+	history, err := storage.GetUsageHistory(ctx, query)
+	if err != nil {
+		t.Fatalf("GetUsageHistory failed: %v", err)
+	}
+	if len(history) != 3 {
+		t.Fatalf("Expected 3 periods, got %d", len(history))
+	}
+	for i, want := range []int{30, 20, 10} {
+		if history[i].Used != want {
+			t.Errorf("Expected period %d used %d, got %d", i, want, history[i].Used)
+		}
+	}
+
+	query.Offset = 1
+	query.Limit = 1
+	history, err = storage.GetUsageHistory(ctx, query)
+	if err != nil {
+		t.Fatalf("GetUsageHistory failed: %v", err)
+	}
+	if len(history) != 1 || history[0].Used != 20 {
+		t.Errorf("Expected second newest period, got %+v", history)
+	}
+
+	query.Offset = 10
+	history, err = storage.GetUsageHistory(ctx, query)
+	if err != nil {
+		t.Fatalf("GetUsageHistory failed: %v", err)
+	}
+	if len(history) != 0 {
+		t.Errorf("Expected no periods past the end, got %d", len(history))
+	}
+}
@@ -0,0 +1,65 @@
+package storagetest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mihaimyh/goquota/pkg/goquota"
+)
+
+// OverrideStorage is a storage that implements goquota.OverrideStore
+type OverrideStorage interface {
+	goquota.Storage
+	goquota.OverrideStore
+}
+
+// Overrides tests saving, replacing, listing and deleting manual overrides
+func Overrides(t *testing.T, storage OverrideStorage) {
+	t.Helper()
+	ctx := context.Background()
+
+	created := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	overrides := []*goquota.Override{
+		{Mode: goquota.OverrideAllowAll, Reason: "incident", Actor: "alice", CreatedAt: created},
+		{Mode: goquota.OverrideEnforceTiers, Resource: "api_calls", EnforceTiers: []string{"free"},
+			CreatedAt: created, ExpiresAt: created.Add(time.Hour)},
+	}
+	for _, override := range overrides {
+		if err := storage.SaveOverride(ctx, override); err != nil {
+			t.Fatalf("SaveOverride failed: %v", err)
+		}
+	}
+	// Replaces the override with the same scope
+	if err := storage.SaveOverride(ctx, &goquota.Override{Mode: goquota.OverrideDenyAll, CreatedAt: created}); err != nil {
+		t.Fatalf("SaveOverride failed: %v", err)
+	}
+
+	stored, err := storage.ListOverrides(ctx)
+	if err != nil || len(stored) != 2 {
+		t.Fatalf("Expected 2 overrides, got %d (%v)", len(stored), err)
+	}
+	for _, override := range stored {
+		switch override.Resource {
+		case "":
+			if override.Mode != goquota.OverrideDenyAll {
+				t.Errorf("Expected the global override to be replaced, got %+v", override)
+			}
+		case "api_calls":
+			if len(override.EnforceTiers) != 1 || !override.ExpiresAt.Equal(created.Add(time.Hour)) {
+				t.Errorf("Expected the resource override to round-trip, got %+v", override)
+			}
+		}
+	}
+
+	deleted, err := storage.DeleteOverride(ctx, "api_calls", "")
+	if err != nil || !deleted {
+		t.Fatalf("Expected the override to be deleted, got %v (%v)", deleted, err)
+	}
+	if deleted, err := storage.DeleteOverride(ctx, "api_calls", ""); err != nil || deleted {
+		t.Errorf("Expected nothing to delete, got %v (%v)", deleted, err)
+	}
+	if stored, _ := storage.ListOverrides(ctx); len(stored) != 1 {
+		t.Errorf("Expected 1 override left, got %d", len(stored))
+	}
+}
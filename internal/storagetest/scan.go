@@ -0,0 +1,107 @@
+// Package storagetest holds the tests shared by the storage adapters.
+package storagetest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mihaimyh/goquota/pkg/goquota"
+)
+
+// ScanStorage is a storage that implements goquota.RecordScanner
+type ScanStorage interface {
+	goquota.Storage
+	goquota.RecordScanner
+}
+
+// ScanRecords checks that scanning with small pages visits every entitlement and usage record.
+// With exactlyOnce, each record must be returned once; otherwise it may be returned more than
+// once, as Redis SCAN does when keys are rehashed.
+func ScanRecords(t *testing.T, storage ScanStorage, exactlyOnce bool) {
+	t.Helper()
+	ctx := context.Background()
+
+	period := goquota.Period{
+		Start: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		End:   time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+		Type:  goquota.PeriodTypeMonthly,
+	}
+
+	for i := 0; i < 5; i++ {
+		userID := fmt.Sprintf("scan_user%d", i)
+		if err := storage.SetEntitlement(ctx, &goquota.Entitlement{
+			UserID:                userID,
+			Tier:                  "pro",
+			SubscriptionStartDate: period.Start,
+			UpdatedAt:             time.Now().UTC(),
+		}); err != nil {
+			t.Fatalf("SetEntitlement failed: %v", err)
+		}
+		for _, resource := range []string{"api_calls", "uploads"} {
+			if err := storage.SetUsage(ctx, userID, resource, &goquota.Usage{
+				UserID: userID, Resource: resource, Used: i, Limit: 100, Period: period, Tier: "pro",
+			}, period); err != nil {
+				t.Fatalf("SetUsage failed: %v", err)
+			}
+		}
+	}
+
+	users := make(map[string]int)
+	for cursor := ""; ; {
+		ents, next, err := storage.ScanEntitlements(ctx, cursor, 2)
+		if err != nil {
+			t.Fatalf("ScanEntitlements failed: %v", err)
+		}
+		for _, ent := range ents {
+			if strings.HasPrefix(ent.UserID, "scan_user") {
+				users[ent.UserID]++
+			}
+		}
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+	checkScanned(t, "entitlement", users, 5, exactlyOnce)
+
+	usage := make(map[string]int)
+	for cursor := ""; ; {
+		page, next, err := storage.ScanUsage(ctx, cursor, 3)
+		if err != nil {
+			t.Fatalf("ScanUsage failed: %v", err)
+		}
+		for _, u := range page {
+			if !strings.HasPrefix(u.UserID, "scan_user") {
+				continue
+			}
+			usage[u.UserID+"/"+u.Resource]++
+			if u.Limit != 100 || u.Period.Type != period.Type {
+				t.Errorf("Unexpected usage %+v", u)
+			}
+		}
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+	checkScanned(t, "usage", usage, 10, exactlyOnce)
+}
+
+// checkScanned checks that want records were scanned, each once if exactlyOnce
+func checkScanned(t *testing.T, kind string, scanned map[string]int, want int, exactlyOnce bool) {
+	t.Helper()
+	if len(scanned) != want {
+		t.Errorf("Expected %d %s records, got %d", want, kind, len(scanned))
+	}
+	if !exactlyOnce {
+		return
+	}
+	for key, n := range scanned {
+		if n != 1 {
+			t.Errorf("Expected %s %s once, got %d times", kind, key, n)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package storagetest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mihaimyh/goquota/pkg/goquota"
+)
+
+// SeedStorage is a storage that implements goquota.SeedStore
+type SeedStorage interface {
+	goquota.Storage
+	goquota.SeedStore
+}
+
+// Seed checks that seeding writes missing records only
+func Seed(t *testing.T, storage SeedStorage) {
+	t.Helper()
+	ctx := context.Background()
+
+	period := goquota.Period{
+		Start: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		End:   time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+		Type:  goquota.PeriodTypeMonthly,
+	}
+	usage := func(used int) *goquota.Usage {
+		return &goquota.Usage{
+			UserID: "seed_user", Resource: "api_calls", Used: used, Limit: 100, Period: period, Tier: "pro",
+		}
+	}
+
+	for i, want := range []bool{true, false} {
+		created, err := storage.SeedUsage(ctx, "seed_user", "api_calls", usage(10+i), period)
+		if err != nil {
+			t.Fatalf("SeedUsage failed: %v", err)
+		}
+		if created != want {
+			t.Errorf("Expected SeedUsage #%d to report %v, got %v", i+1, want, created)
+		}
+	}
+	got, err := storage.GetUsage(ctx, "seed_user", "api_calls", period)
+	if err != nil {
+		t.Fatalf("GetUsage failed: %v", err)
+	}
+	if got == nil || got.Used != 10 || got.Limit != 100 {
+		t.Errorf("Expected the first seeded usage, got %+v", got)
+	}
+
+	for i, tier := range []string{"pro", "free"} {
+		created, err := storage.SeedEntitlement(ctx, &goquota.Entitlement{
+			UserID: "seed_user", Tier: tier, SubscriptionStartDate: period.Start, UpdatedAt: time.Now().UTC(),
+		})
+		if err != nil {
+			t.Fatalf("SeedEntitlement failed: %v", err)
+		}
+		if created != (i == 0) {
+			t.Errorf("Expected SeedEntitlement #%d to report %v, got %v", i+1, i == 0, created)
+		}
+	}
+	ent, err := storage.GetEntitlement(ctx, "seed_user")
+	if err != nil {
+		t.Fatalf("GetEntitlement failed: %v", err)
+	}
+	if ent.Tier != "pro" {
+		t.Errorf("Expected the first seeded tier pro, got %s", ent.Tier)
+	}
+}
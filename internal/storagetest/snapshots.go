@@ -0,0 +1,74 @@
+package storagetest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mihaimyh/goquota/pkg/goquota"
+)
+
+// SnapshotStorage is a storage that implements goquota.UsageSnapshotStore
+type SnapshotStorage interface {
+	goquota.Storage
+	goquota.UsageSnapshotStore
+}
+
+// UsageSnapshots checks that snapshots keep the highest usage of each bucket, are listed in bucket
+// order and are scoped to their period
+func UsageSnapshots(t *testing.T, storage SnapshotStorage) {
+	t.Helper()
+	ctx := context.Background()
+
+	period := goquota.Period{
+		Start: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		End:   time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC),
+		Type:  goquota.PeriodTypeMonthly,
+	}
+	snap := func(hour, used int) *goquota.UsageSnapshot {
+		bucket := period.Start.Add(time.Duration(hour) * time.Hour)
+		return &goquota.UsageSnapshot{
+			UserID:     "user1",
+			Resource:   "api_calls",
+			Period:     period,
+			Bucket:     bucket,
+			Used:       used,
+			RecordedAt: bucket.Add(time.Duration(used) * time.Second),
+		}
+	}
+
+	// Recorded out of order; the second write to bucket 1 has higher usage and wins,
+	// the third has lower usage and is ignored
+	for _, s := range []*goquota.UsageSnapshot{snap(2, 20), snap(1, 5), snap(1, 10), snap(1, 7)} {
+		if err := storage.RecordUsageSnapshot(ctx, s); err != nil {
+			t.Fatalf("RecordUsageSnapshot failed: %v", err)
+		}
+	}
+
+	snapshots, err := storage.GetUsageSnapshots(ctx, "user1", "api_calls", period)
+	if err != nil {
+		t.Fatalf("GetUsageSnapshots failed: %v", err)
+	}
+	if len(snapshots) != 2 {
+		t.Fatalf("Expected 2 snapshots, got %d", len(snapshots))
+	}
+	if !snapshots[0].Bucket.Equal(snap(1, 0).Bucket) || snapshots[0].Used != 10 {
+		t.Errorf("Expected bucket 1 with used 10 first, got %v used %d", snapshots[0].Bucket, snapshots[0].Used)
+	}
+	if !snapshots[0].RecordedAt.Equal(snap(1, 10).RecordedAt) {
+		t.Errorf("Expected recorded time of the winning snapshot, got %v", snapshots[0].RecordedAt)
+	}
+	if snapshots[1].Used != 20 {
+		t.Errorf("Expected bucket 2 with used 20, got %d", snapshots[1].Used)
+	}
+
+	// Snapshots are scoped to their period
+	daily := goquota.Period{Start: period.Start, End: period.Start.Add(24 * time.Hour), Type: goquota.PeriodTypeDaily}
+	snapshots, err = storage.GetUsageSnapshots(ctx, "user1", "api_calls", daily)
+	if err != nil {
+		t.Fatalf("GetUsageSnapshots failed: %v", err)
+	}
+	if len(snapshots) != 0 {
+		t.Errorf("Expected no snapshots for daily period, got %d", len(snapshots))
+	}
+}
@@ -0,0 +1,84 @@
+package storagetest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mihaimyh/goquota/pkg/goquota"
+)
+
+// StatementStorage is a storage that implements goquota.StatementStore
+type StatementStorage interface {
+	goquota.Storage
+	goquota.StatementStore
+}
+
+// Statements checks that a statement is created once per period and that statements are listed
+// newest first, paged by limit and offset
+func Statements(t *testing.T, storage StatementStorage) {
+	t.Helper()
+	ctx := context.Background()
+
+	statement := func(month time.Month, used int) *goquota.Statement {
+		return &goquota.Statement{
+			UserID: "user1",
+			Tier:   "pro",
+			Period: goquota.Period{
+				Start: time.Date(2026, month, 15, 0, 0, 0, 0, time.UTC),
+				End:   time.Date(2026, month+1, 15, 0, 0, 0, 0, time.UTC),
+				Type:  goquota.PeriodTypeMonthly,
+			},
+			Lines: []goquota.StatementLine{
+				{Resource: "api_calls", Used: used, Limit: 1000},
+				{Resource: "images", Used: 0, Limit: -1},
+			},
+			ClosedAt: time.Date(2026, month+1, 15, 1, 0, 0, 0, time.UTC),
+		}
+	}
+
+	for i, month := range []time.Month{time.January, time.March, time.February} {
+		created, err := storage.CreateStatement(ctx, statement(month, 100*(i+1)))
+		if err != nil || !created {
+			t.Fatalf("Expected statement for %s to be created, got %v, %v", month, created, err)
+		}
+	}
+
+	// Statements are immutable: a second statement for the same period is rejected
+	created, err := storage.CreateStatement(ctx, statement(time.January, 999))
+	if err != nil || created {
+		t.Fatalf("Expected duplicate statement to be rejected, got %v, %v", created, err)
+	}
+
+	statements, err := storage.GetStatements(ctx, "user1", 0, 0)
+	if err != nil {
+		t.Fatalf("GetStatements failed: %v", err)
+	}
+	if len(statements) != 3 {
+		t.Fatalf("Expected 3 statements, got %d", len(statements))
+	}
+	for i, month := range []time.Month{time.March, time.February, time.January} {
+		if statements[i].Period.Start.Month() != month {
+			t.Errorf("Expected statement %d to be for %s, got %s", i, month, statements[i].Period.Start.Month())
+		}
+	}
+	if statements[2].Lines[0].Used != 100 || len(statements[2].Lines) != 2 {
+		t.Errorf("Expected January statement to be unchanged, got %+v", statements[2].Lines)
+	}
+
+	page, err := storage.GetStatements(ctx, "user1", 1, 1)
+	if err != nil {
+		t.Fatalf("GetStatements failed: %v", err)
+	}
+	if len(page) != 1 || page[0].Period.Start.Month() != time.February {
+		t.Errorf("Expected the February statement, got %+v", page)
+	}
+
+	other, err := storage.GetStatements(ctx, "user2", 0, 0)
+	if err != nil {
+		t.Fatalf("GetStatements failed: %v", err)
+	}
+	if len(other) != 0 {
+		t.Errorf("Expected no statements for user2, got %d", len(other))
+	}
+}
@@ -0,0 +1,78 @@
+package storagetest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mihaimyh/goquota/pkg/goquota"
+)
+
+// WarningStorage is a storage that implements goquota.WarningStateStore
+type WarningStorage interface {
+	goquota.Storage
+	goquota.WarningStateStore
+}
+
+// WarningState checks that each threshold is marked notified once per period, and that
+// notified thresholds can be listed and cleared
+func WarningState(t *testing.T, storage WarningStorage) {
+	t.Helper()
+	ctx := context.Background()
+
+	monthly := goquota.Period{
+		Start: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		End:   time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC),
+		Type:  goquota.PeriodTypeMonthly,
+	}
+	daily := goquota.Period{
+		Start: monthly.Start,
+		End:   monthly.Start.Add(24 * time.Hour),
+		Type:  goquota.PeriodTypeDaily,
+	}
+
+	marked, err := storage.MarkWarningNotified(ctx, "user1", "api_calls", monthly, 0.8)
+	if err != nil || !marked {
+		t.Fatalf("Expected first mark to succeed, got %v, %v", marked, err)
+	}
+	marked, err = storage.MarkWarningNotified(ctx, "user1", "api_calls", monthly, 0.8)
+	if err != nil || marked {
+		t.Fatalf("Expected second mark to be rejected, got %v, %v", marked, err)
+	}
+	if _, err := storage.MarkWarningNotified(ctx, "user1", "api_calls", monthly, 0.5); err != nil {
+		t.Fatalf("MarkWarningNotified failed: %v", err)
+	}
+
+	notified, err := storage.GetNotifiedWarnings(ctx, "user1", "api_calls", monthly)
+	if err != nil {
+		t.Fatalf("GetNotifiedWarnings failed: %v", err)
+	}
+	if len(notified) != 2 || notified[0] != 0.5 || notified[1] != 0.8 {
+		t.Errorf("Expected [0.5 0.8], got %v", notified)
+	}
+
+	// State is scoped to the period type, even when periods start at the same time
+	notified, err = storage.GetNotifiedWarnings(ctx, "user1", "api_calls", daily)
+	if err != nil {
+		t.Fatalf("GetNotifiedWarnings failed: %v", err)
+	}
+	if len(notified) != 0 {
+		t.Errorf("Expected no daily warnings, got %v", notified)
+	}
+
+	if err := storage.ClearNotifiedWarnings(ctx, "user1", "api_calls", monthly, []float64{0.8, 0.9}); err != nil {
+		t.Fatalf("ClearNotifiedWarnings failed: %v", err)
+	}
+	notified, err = storage.GetNotifiedWarnings(ctx, "user1", "api_calls", monthly)
+	if err != nil {
+		t.Fatalf("GetNotifiedWarnings failed: %v", err)
+	}
+	if len(notified) != 1 || notified[0] != 0.5 {
+		t.Errorf("Expected [0.5] after clearing 0.8, got %v", notified)
+	}
+
+	marked, err = storage.MarkWarningNotified(ctx, "user1", "api_calls", monthly, 0.8)
+	if err != nil || !marked {
+		t.Errorf("Expected re-armed threshold to be marked again, got %v, %v", marked, err)
+	}
+}
@@ -0,0 +1,108 @@
+package storagetest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mihaimyh/goquota/pkg/goquota"
+)
+
+// WebhookStorage is a storage that implements goquota.WebhookStore
+type WebhookStorage interface {
+	goquota.Storage
+	goquota.WebhookStore
+}
+
+// WebhookDeliveries checks that due deliveries are claimed oldest first and leased, and that
+// deliveries can be dead-lettered, listed by status and deleted
+func WebhookDeliveries(t *testing.T, storage WebhookStorage) {
+	t.Helper()
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Millisecond)
+
+	delivery := func(id string, next time.Time) *goquota.WebhookDelivery {
+		return &goquota.WebhookDelivery{
+			ID:            id,
+			EventID:       "evt_" + id,
+			EventType:     goquota.EventQuotaWarning,
+			UserID:        "user1",
+			URL:           "https://hooks.example.com",
+			Payload:       []byte(`{"id":"evt_` + id + `"}`),
+			Status:        goquota.WebhookDeliveryPending,
+			NextAttemptAt: next,
+			CreatedAt:     next,
+			UpdatedAt:     next,
+		}
+	}
+
+	for _, d := range []*goquota.WebhookDelivery{
+		delivery("a", now.Add(-2*time.Second)),
+		delivery("b", now.Add(-time.Second)),
+		delivery("c", now.Add(time.Minute)), // Not due yet
+	} {
+		if err := storage.SaveWebhookDelivery(ctx, d); err != nil {
+			t.Fatalf("SaveWebhookDelivery failed: %v", err)
+		}
+	}
+
+	claimed, err := storage.ClaimWebhookDeliveries(ctx, now, 10, time.Minute)
+	if err != nil {
+		t.Fatalf("ClaimWebhookDeliveries failed: %v", err)
+	}
+	if len(claimed) != 2 || claimed[0].ID != "a" || claimed[1].ID != "b" {
+		t.Fatalf("Expected deliveries a and b oldest first, got %v", claimed)
+	}
+	if string(claimed[0].Payload) != `{"id":"evt_a"}` {
+		t.Errorf("Unexpected payload %s", claimed[0].Payload)
+	}
+
+	// Claimed deliveries are leased
+	claimed, err = storage.ClaimWebhookDeliveries(ctx, now, 10, time.Minute)
+	if err != nil {
+		t.Fatalf("ClaimWebhookDeliveries failed: %v", err)
+	}
+	if len(claimed) != 0 {
+		t.Errorf("Expected no deliveries while leased, got %d", len(claimed))
+	}
+
+	// Dead-letter b, deliver a
+	dead := delivery("b", now)
+	dead.Status = goquota.WebhookDeliveryDead
+	dead.Attempts = 8
+	dead.LastError = "status 500"
+	if err := storage.SaveWebhookDelivery(ctx, dead); err != nil {
+		t.Fatalf("SaveWebhookDelivery failed: %v", err)
+	}
+	if err := storage.DeleteWebhookDelivery(ctx, "a"); err != nil {
+		t.Fatalf("DeleteWebhookDelivery failed: %v", err)
+	}
+	if err := storage.DeleteWebhookDelivery(ctx, "unknown"); err != nil {
+		t.Fatalf("Deleting an unknown delivery should not fail: %v", err)
+	}
+
+	deadLetters, err := storage.ListWebhookDeliveries(ctx, goquota.WebhookDeliveryDead, 0, 0)
+	if err != nil {
+		t.Fatalf("ListWebhookDeliveries failed: %v", err)
+	}
+	if len(deadLetters) != 1 || deadLetters[0].ID != "b" || deadLetters[0].Attempts != 8 {
+		t.Fatalf("Expected dead letter b, got %v", deadLetters)
+	}
+
+	pending, err := storage.ListWebhookDeliveries(ctx, goquota.WebhookDeliveryPending, 0, 0)
+	if err != nil {
+		t.Fatalf("ListWebhookDeliveries failed: %v", err)
+	}
+	if len(pending) != 1 || pending[0].ID != "c" {
+		t.Fatalf("Expected pending delivery c, got %v", pending)
+	}
+
+	got, err := storage.GetWebhookDelivery(ctx, "a")
+	if err != nil || got != nil {
+		t.Errorf("Expected deleted delivery to be nil, got %v (err %v)", got, err)
+	}
+	got, err = storage.GetWebhookDelivery(ctx, "b")
+	if err != nil || got == nil || got.LastError != "status 500" {
+		t.Errorf("Expected dead letter b, got %v (err %v)", got, err)
+	}
+}
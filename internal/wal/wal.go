@@ -0,0 +1,260 @@
+// Package wal implements the append-only files (write-ahead logs) behind the file-backed
+// queues of goquota, such as the optimistic journal and the tiered sync outbox.
+package wal
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// opRemove is the operation of records that remove an entry
+const opRemove = "remove"
+
+// minCompaction is the number of records below which the file is never compacted
+const minCompaction = 1024
+
+// Config configures a Log
+type Config[T any] struct {
+	// Name is used in error messages, e.g. "sync outbox"
+	Name string
+
+	// PutOp is the operation of records that add or replace an entry, e.g. "put"
+	PutOp string
+
+	// ID returns the ID of an entry
+	ID func(entry *T) string
+}
+
+// record is a line of a Log
+type record[T any] struct {
+	Op    string `json:"op"`
+	ID    string `json:"id,omitempty"`
+	Entry *T     `json:"entry,omitempty"`
+}
+
+// Log is a set of entries backed by an append-only file. Every change is written as a JSON
+// line and synced to disk before returning, so entries survive a crash or restart. The file
+// is compacted when it is opened and once it mostly holds superseded records, and truncated
+// whenever the log is empty.
+//
+// A Log is not safe for concurrent use: callers serialize access.
+type Log[T any] struct {
+	config  Config[T]
+	path    string
+	file    *os.File
+	entries map[string]*T
+	order   []string
+	// records is the number of lines in the file
+	records int
+	closed  bool
+}
+
+// Open opens (or creates) the log at path and loads its entries.
+// A truncated last line, as left by a crash during a write, is ignored.
+func Open[T any](path string, config Config[T]) (*Log[T], error) {
+	l := &Log[T]{config: config, path: path, entries: make(map[string]*T)}
+	if err := l.load(); err != nil {
+		return nil, err
+	}
+	if err := l.compact(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// load reads the entries from the file
+func (l *Log[T]) load() error {
+	f, err := os.Open(l.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", l.config.Name, err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		var rec record[T]
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			// Only the last line can be partially written
+			if scanner.Scan() {
+				return fmt.Errorf("%s %s: invalid record on line %d: %w", l.config.Name, l.path, line, err)
+			}
+			break
+		}
+		switch rec.Op {
+		case l.config.PutOp:
+			if rec.Entry != nil {
+				l.add(rec.Entry)
+			}
+		case opRemove:
+			delete(l.entries, rec.ID)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read %s: %w", l.config.Name, err)
+	}
+	return nil
+}
+
+// compact atomically rewrites the file with the current entries only and opens it for appending
+func (l *Log[T]) compact() error {
+	l.order = l.entryOrder()
+
+	tmpPath := l.path + ".tmp"
+	tmp, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to compact %s: %w", l.config.Name, err)
+	}
+	enc := json.NewEncoder(tmp)
+	for _, id := range l.order {
+		if err := enc.Encode(&record[T]{Op: l.config.PutOp, Entry: l.entries[id]}); err != nil {
+			_ = tmp.Close()
+			return fmt.Errorf("failed to compact %s: %w", l.config.Name, err)
+		}
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to compact %s: %w", l.config.Name, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to compact %s: %w", l.config.Name, err)
+	}
+	if l.file != nil {
+		_ = l.file.Close()
+	}
+	if err := os.Rename(tmpPath, l.path); err != nil {
+		return fmt.Errorf("failed to compact %s: %w", l.config.Name, err)
+	}
+	syncDir(filepath.Dir(l.path))
+
+	l.file, err = os.OpenFile(l.path, os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", l.config.Name, err)
+	}
+	l.records = len(l.order)
+	return nil
+}
+
+// syncDir makes a rename in dir durable. Errors are ignored: not every platform supports it.
+func syncDir(dir string) {
+	if d, err := os.Open(dir); err == nil {
+		_ = d.Sync()
+		_ = d.Close()
+	}
+}
+
+func (l *Log[T]) add(entry *T) {
+	id := l.config.ID(entry)
+	if _, ok := l.entries[id]; !ok {
+		l.order = append(l.order, id)
+	}
+	l.entries[id] = entry
+}
+
+// entryOrder returns the IDs of the entries, oldest first
+func (l *Log[T]) entryOrder() []string {
+	order := make([]string, 0, len(l.entries))
+	for _, id := range l.order {
+		if _, ok := l.entries[id]; ok {
+			order = append(order, id)
+		}
+	}
+	return order
+}
+
+// write appends a record to the file and syncs it to disk
+func (l *Log[T]) write(rec *record[T]) error {
+	if l.closed {
+		return fmt.Errorf("%s is closed", l.config.Name)
+	}
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	if _, err := l.file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write %s: %w", l.config.Name, err)
+	}
+	if err := l.file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync %s: %w", l.config.Name, err)
+	}
+	l.records++
+	return nil
+}
+
+// maybeCompact compacts the file once it mostly holds superseded records
+func (l *Log[T]) maybeCompact() error {
+	if l.records > minCompaction && l.records > 2*len(l.entries) {
+		return l.compact()
+	}
+	return nil
+}
+
+// Put adds an entry or replaces the entry with the same ID. The Log keeps entry: callers
+// must not modify it afterwards.
+func (l *Log[T]) Put(entry *T) error {
+	if err := l.write(&record[T]{Op: l.config.PutOp, Entry: entry}); err != nil {
+		return err
+	}
+	l.add(entry)
+	return l.maybeCompact()
+}
+
+// Remove removes an entry. Removing an unknown ID is not an error.
+func (l *Log[T]) Remove(id string) error {
+	if _, ok := l.entries[id]; !ok {
+		return nil
+	}
+	if err := l.write(&record[T]{Op: opRemove, ID: id}); err != nil {
+		return err
+	}
+	delete(l.entries, id)
+
+	if len(l.entries) == 0 {
+		// Nothing left: start over with an empty file
+		l.order = nil
+		l.records = 0
+		if err := l.file.Truncate(0); err != nil {
+			return fmt.Errorf("failed to truncate %s: %w", l.config.Name, err)
+		}
+		return nil
+	}
+	return l.maybeCompact()
+}
+
+// Get returns the entry with the given ID. Callers must not modify it.
+func (l *Log[T]) Get(id string) (*T, bool) {
+	entry, ok := l.entries[id]
+	return entry, ok
+}
+
+// Len returns the number of entries
+func (l *Log[T]) Len() int {
+	return len(l.entries)
+}
+
+// Entries returns the entries, oldest first. Callers must not modify them.
+func (l *Log[T]) Entries() []*T {
+	entries := make([]*T, 0, len(l.entries))
+	for _, id := range l.order {
+		if entry, ok := l.entries[id]; ok {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// Close closes the file. Entries are kept for the next Open.
+func (l *Log[T]) Close() error {
+	if l.closed {
+		return nil
+	}
+	l.closed = true
+	return l.file.Close()
+}
@@ -0,0 +1,84 @@
+package wal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testEntry struct {
+	ID    string `json:"id"`
+	Value int    `json:"value"`
+}
+
+func openTestLog(t *testing.T, path string) *Log[testEntry] {
+	t.Helper()
+	l, err := Open(path, Config[testEntry]{
+		Name:  "test log",
+		PutOp: "put",
+		ID:    func(entry *testEntry) string { return entry.ID },
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = l.Close() })
+	return l
+}
+
+func TestLog(t *testing.T) {
+	t.Run("entries survive reopening", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "test.wal")
+		l := openTestLog(t, path)
+		for _, id := range []string{"a", "b", "c"} {
+			require.NoError(t, l.Put(&testEntry{ID: id}))
+		}
+		require.NoError(t, l.Remove("b"))
+		require.NoError(t, l.Put(&testEntry{ID: "a", Value: 2}))
+		require.NoError(t, l.Close())
+
+		entries := openTestLog(t, path).Entries()
+		require.Len(t, entries, 2)
+		assert.Equal(t, testEntry{ID: "a", Value: 2}, *entries[0])
+		assert.Equal(t, "c", entries[1].ID)
+	})
+
+	t.Run("rejects a corrupt record before the last line", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "test.wal")
+		require.NoError(t, os.WriteFile(path, []byte("{\n"+`{"op":"put","entry":{"id":"a"}}`+"\n"), 0o600))
+
+		_, err := Open(path, Config[testEntry]{Name: "test log", PutOp: "put",
+			ID: func(entry *testEntry) string { return entry.ID }})
+		assert.ErrorContains(t, err, "invalid record on line 1")
+	})
+
+	t.Run("compacts superseded records", func(t *testing.T) {
+		l := openTestLog(t, filepath.Join(t.TempDir(), "test.wal"))
+		require.NoError(t, l.Put(&testEntry{ID: "kept"}))
+		for i := 0; i < 3*minCompaction; i++ {
+			require.NoError(t, l.Put(&testEntry{ID: "replaced", Value: i}))
+		}
+		assert.LessOrEqual(t, l.records, minCompaction+1)
+
+		entries := l.Entries()
+		require.Len(t, entries, 2)
+		assert.Equal(t, 3*minCompaction-1, entries[1].Value)
+	})
+
+	t.Run("truncates when empty", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "test.wal")
+		l := openTestLog(t, path)
+		require.NoError(t, l.Put(&testEntry{ID: "a"}))
+		require.NoError(t, l.Remove("a"))
+
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		assert.Zero(t, info.Size())
+	})
+
+	t.Run("rejects writes once closed", func(t *testing.T) {
+		l := openTestLog(t, filepath.Join(t.TempDir(), "test.wal"))
+		require.NoError(t, l.Close())
+		assert.ErrorContains(t, l.Put(&testEntry{ID: "a"}), "test log is closed")
+	})
+}
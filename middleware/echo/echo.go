@@ -80,6 +80,21 @@ type Config struct {
 	// as this will interfere with the actual request handler that runs after
 	// the middleware completes.
 	OnWarning func(c echo.Context, usage *goquota.Usage, threshold float64)
+
+	// OnWarningDetail is called for every warning kind (percentage, balance and rate limit)
+	// with the detailed warning payload, and takes precedence over OnWarning.
+	// If nil, percentage warnings go to OnWarning and other kinds add the default headers.
+	OnWarningDetail func(echo.Context, *goquota.Warning)
+
+	// HoldConcurrencySlot acquires a concurrency lease for the resource before consuming quota
+	// and holds it (with heartbeat) until the handler returns.
+	// Requires TierConfig.ConcurrencyLimits to be configured for the resource.
+	// Default: false
+	HoldConcurrencySlot bool
+
+	// OnConcurrencyLimitExceeded is called when the user already holds all concurrency slots
+	// If nil, uses default response: 429 JSON
+	OnConcurrencyLimitExceeded func(c echo.Context) error
 }
 
 // Middleware creates an Echo middleware that enforces quota limits
@@ -144,17 +159,40 @@ func Middleware(cfg Config) echo.MiddlewareFunc {
 			// Set up warning handler if needed
 			if cfg.OnWarning != nil {
 				ctx = goquota.WithWarningHandler(ctx, &warningHandler{
-					c: c,
-					f: cfg.OnWarning,
+					c:      c,
+					f:      cfg.OnWarning,
+					detail: cfg.OnWarningDetail,
 				})
 			} else {
 				// Default warning behavior: add headers
 				ctx = goquota.WithWarningHandler(ctx, &warningHandler{
-					c: c,
-					f: defaultWarningHandler,
+					c:      c,
+					f:      defaultWarningHandler,
+					detail: cfg.OnWarningDetail,
 				})
 			}
 
+			// Hold a concurrency slot for the lifetime of the request if enabled
+			if cfg.HoldConcurrencySlot {
+				lease, leaseErr := cfg.Manager.Acquire(ctx, userID, resource)
+				if leaseErr != nil {
+					if leaseErr == goquota.ErrConcurrencyLimitExceeded {
+						if cfg.OnConcurrencyLimitExceeded != nil {
+							return cfg.OnConcurrencyLimitExceeded(c)
+						}
+						return defaultConcurrencyLimitExceeded(c)
+					}
+					if cfg.OnError != nil {
+						return cfg.OnError(c, leaseErr)
+					}
+					return defaultError(c, leaseErr)
+				}
+				lease.StartHeartbeat(0)
+				defer func() {
+					_ = lease.Release(context.WithoutCancel(ctx))
+				}()
+			}
+
 			// Prepare consume options
 			opts := []goquota.ConsumeOption{}
 			if idempotencyKey != "" {
@@ -231,8 +269,9 @@ func addRateLimitHeadersOnSuccess(_ context.Context, _ echo.Context, _ *goquota.
 }
 
 type warningHandler struct {
-	c echo.Context
-	f func(echo.Context, *goquota.Usage, float64)
+	c      echo.Context
+	f      func(echo.Context, *goquota.Usage, float64)
+	detail func(echo.Context, *goquota.Warning)
 }
 
 func (h *warningHandler) OnWarning(_ context.Context, usage *goquota.Usage, threshold float64) {
@@ -241,6 +280,18 @@ func (h *warningHandler) OnWarning(_ context.Context, usage *goquota.Usage, thre
 	}
 }
 
+// OnWarningDetail implements goquota.DetailedWarningHandler
+func (h *warningHandler) OnWarningDetail(ctx context.Context, warning *goquota.Warning) {
+	switch {
+	case h.detail != nil:
+		h.detail(h.c, warning)
+	case warning.Kind == goquota.WarningKindPercentage:
+		h.OnWarning(ctx, warning.Usage, warning.Threshold)
+	default:
+		defaultWarningDetailHandler(h.c, warning)
+	}
+}
+
 // Default error handlers
 
 func defaultUnauthorized(c echo.Context) error {
@@ -254,6 +305,10 @@ func defaultRateLimitExceeded(c echo.Context, retryAfter time.Duration) error {
 	})
 }
 
+func defaultConcurrencyLimitExceeded(c echo.Context) error {
+	return c.JSON(http.StatusTooManyRequests, map[string]string{"error": "Concurrency limit exceeded"})
+}
+
 func defaultQuotaExceeded(c echo.Context, usage *goquota.Usage, statusCode int) error {
 	if usage != nil {
 		return c.JSON(statusCode, map[string]interface{}{
@@ -269,12 +324,31 @@ func defaultError(c echo.Context, _ error) error {
 	return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Internal Server Error"})
 }
 
-// DefaultWarningHandler is the default OnWarning implementation.
-// It adds X-Quota-Warning-Threshold, X-Quota-Warning-Used, and X-Quota-Warning-Limit headers.
+// defaultWarningHandler is the default OnWarning implementation.
+// It adds the defaultWarningDetailHandler headers for a percentage warning.
 func defaultWarningHandler(c echo.Context, usage *goquota.Usage, threshold float64) {
-	c.Response().Header().Set("X-Quota-Warning-Threshold", fmt.Sprintf("%.2f", threshold))
-	c.Response().Header().Set("X-Quota-Warning-Used", fmt.Sprintf("%d", usage.Used))
-	c.Response().Header().Set("X-Quota-Warning-Limit", fmt.Sprintf("%d", usage.Limit))
+	defaultWarningDetailHandler(c, &goquota.Warning{
+		Kind:      goquota.WarningKindPercentage,
+		Threshold: threshold,
+		Used:      usage.Used,
+		Limit:     usage.Limit,
+		Remaining: max(usage.Limit-usage.Used, 0),
+	})
+}
+
+// defaultWarningDetailHandler is the default warning implementation for every warning kind.
+// It adds X-Quota-Warning (the kind), X-Quota-Warning-Threshold, X-Quota-Warning-Used,
+// X-Quota-Warning-Limit and X-Quota-Warning-Remaining headers.
+func defaultWarningDetailHandler(c echo.Context, warning *goquota.Warning) {
+	threshold := fmt.Sprintf("%.2f", warning.Threshold)
+	if warning.Kind == goquota.WarningKindBalance {
+		threshold = fmt.Sprintf("%.0f", warning.Threshold)
+	}
+	c.Response().Header().Set("X-Quota-Warning", string(warning.Kind))
+	c.Response().Header().Set("X-Quota-Warning-Threshold", threshold)
+	c.Response().Header().Set("X-Quota-Warning-Used", fmt.Sprintf("%d", warning.Used))
+	c.Response().Header().Set("X-Quota-Warning-Limit", fmt.Sprintf("%d", warning.Limit))
+	c.Response().Header().Set("X-Quota-Warning-Remaining", fmt.Sprintf("%d", warning.Remaining))
 }
 
 // Convenience extractors for User ID
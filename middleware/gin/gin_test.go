@@ -703,6 +703,54 @@ func TestMiddleware_Warnings(t *testing.T) {
 	}
 }
 
+func TestMiddleware_BalanceWarningHeaders(t *testing.T) {
+	storage := memory.New()
+	config := goquota.Config{
+		DefaultTier: "free",
+		Tiers: map[string]goquota.TierConfig{
+			"free": {
+				Name:            "free",
+				BalanceWarnings: map[string][]int{"credits": {10}},
+			},
+		},
+	}
+	manager, err := goquota.NewManager(storage, &config)
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+	setupEntitlement(t, manager, "user1", "free")
+	if err := manager.TopUpLimit(context.Background(), "user1", "credits", 20); err != nil {
+		t.Fatalf("TopUpLimit failed: %v", err)
+	}
+
+	r := gin.New()
+	r.Use(Middleware(Config{
+		Manager:     manager,
+		GetUserID:   FromHeader("X-User-ID"),
+		GetResource: FixedResource("credits"),
+		GetAmount:   FixedAmount(15),
+		PeriodType:  goquota.PeriodTypeForever,
+	}))
+	r.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, "success")
+	})
+
+	req := httptest.NewRequest("GET", "/", http.NoBody)
+	req.Header.Set("X-User-ID", "user1")
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rec.Code)
+	}
+	if kind := rec.Header().Get("X-Quota-Warning"); kind != "balance" {
+		t.Errorf("Expected X-Quota-Warning: balance, got %s", kind)
+	}
+	if remaining := rec.Header().Get("X-Quota-Warning-Remaining"); remaining != "5" {
+		t.Errorf("Expected X-Quota-Warning-Remaining: 5, got %s", remaining)
+	}
+}
+
 func TestMiddleware_CustomWarningHandler(t *testing.T) {
 	storage := memory.New()
 	config := goquota.Config{
@@ -987,3 +1035,75 @@ func TestMiddleware_ConfigValidation_MissingGetAmount(t *testing.T) {
 		// GetAmount is nil
 	})
 }
+
+func TestMiddleware_HoldConcurrencySlot(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	config := goquota.Config{
+		DefaultTier: "free",
+		Tiers: map[string]goquota.TierConfig{
+			"free": {
+				Name:          "free",
+				MonthlyQuotas: map[string]int{"renders": 100},
+				ConcurrencyLimits: map[string]goquota.ConcurrencyLimitConfig{
+					"renders": {MaxConcurrent: 1},
+				},
+			},
+		},
+	}
+	manager, err := goquota.NewManager(memory.New(), &config)
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+
+	started := make(chan struct{})
+	unblock := make(chan struct{})
+
+	router := gin.New()
+	router.Use(Middleware(Config{
+		Manager:             manager,
+		GetUserID:           FromHeader("X-User-ID"),
+		GetResource:         FixedResource("renders"),
+		GetAmount:           FixedAmount(1),
+		HoldConcurrencySlot: true,
+	}))
+	router.POST("/slow", func(c *gin.Context) {
+		close(started)
+		<-unblock
+		c.Status(http.StatusOK)
+	})
+	router.POST("/fast", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	newRequest := func(path string) *http.Request {
+		req := httptest.NewRequest("POST", path, http.NoBody)
+		req.Header.Set("X-User-ID", "user1")
+		return req
+	}
+
+	done := make(chan int)
+	go func() {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, newRequest("/slow"))
+		done <- w.Code
+	}()
+	<-started
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newRequest("/fast"))
+	if w.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected status 429 while slot is held, got %d", w.Code)
+	}
+
+	close(unblock)
+	if code := <-done; code != http.StatusOK {
+		t.Errorf("Expected first request to succeed, got %d", code)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, newRequest("/fast"))
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status 200 after slot release, got %d", w.Code)
+	}
+}
@@ -60,6 +60,21 @@ type Config struct {
 	// Use this to add custom headers or log warnings.
 	// If nil, a default X-Quota-Warning header is added.
 	OnWarning func(w http.ResponseWriter, r *http.Request, usage *goquota.Usage, threshold float64)
+
+	// OnWarningDetail is called for every warning kind (percentage, balance and rate limit)
+	// with the detailed warning payload, and takes precedence over OnWarning.
+	// If nil, percentage warnings go to OnWarning and other kinds add the default headers.
+	OnWarningDetail func(http.ResponseWriter, *http.Request, *goquota.Warning)
+
+	// HoldConcurrencySlot acquires a concurrency lease for the resource before consuming quota
+	// and holds it (with heartbeat) until the handler returns.
+	// Requires TierConfig.ConcurrencyLimits to be configured for the resource.
+	// Default: false
+	HoldConcurrencySlot bool
+
+	// OnConcurrencyLimitExceeded is called when the user already holds all concurrency slots
+	// If nil, returns 429 Too Many Requests
+	OnConcurrencyLimitExceeded func(w http.ResponseWriter, r *http.Request)
 }
 
 // Middleware creates an HTTP middleware that enforces quota limits
@@ -105,19 +120,44 @@ func Middleware(config *Config) func(http.Handler) http.Handler {
 			// Set up warning handler if needed
 			if config.OnWarning != nil {
 				ctx = goquota.WithWarningHandler(ctx, &warningHandler{
-					w: w,
-					r: r,
-					f: config.OnWarning,
+					w:      w,
+					r:      r,
+					f:      config.OnWarning,
+					detail: config.OnWarningDetail,
 				})
 			} else {
 				// Default warning behavior: add headers
 				ctx = goquota.WithWarningHandler(ctx, &warningHandler{
-					w: w,
-					r: r,
-					f: DefaultWarningHandler,
+					w:      w,
+					r:      r,
+					f:      DefaultWarningHandler,
+					detail: config.OnWarningDetail,
 				})
 			}
 
+			// Hold a concurrency slot for the lifetime of the request if enabled
+			if config.HoldConcurrencySlot {
+				lease, leaseErr := config.Manager.Acquire(ctx, userID, resource)
+				if leaseErr != nil {
+					if leaseErr == goquota.ErrConcurrencyLimitExceeded {
+						if config.OnConcurrencyLimitExceeded != nil {
+							config.OnConcurrencyLimitExceeded(w, r)
+						} else {
+							http.Error(w, "Concurrency limit exceeded", http.StatusTooManyRequests)
+						}
+					} else if config.OnError != nil {
+						config.OnError(w, r, leaseErr)
+					} else {
+						http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+					}
+					return
+				}
+				lease.StartHeartbeat(0)
+				defer func() {
+					_ = lease.Release(context.WithoutCancel(ctx))
+				}()
+			}
+
 			_, err = config.Manager.Consume(ctx, userID, resource, amount, config.PeriodType)
 			if err != nil {
 				// Check for rate limit exceeded error
@@ -165,9 +205,10 @@ func Middleware(config *Config) func(http.Handler) http.Handler {
 }
 
 type warningHandler struct {
-	w http.ResponseWriter
-	r *http.Request
-	f func(http.ResponseWriter, *http.Request, *goquota.Usage, float64)
+	w      http.ResponseWriter
+	r      *http.Request
+	f      func(http.ResponseWriter, *http.Request, *goquota.Usage, float64)
+	detail func(http.ResponseWriter, *http.Request, *goquota.Warning)
 }
 
 func (h *warningHandler) OnWarning(_ context.Context, usage *goquota.Usage, threshold float64) {
@@ -176,12 +217,43 @@ func (h *warningHandler) OnWarning(_ context.Context, usage *goquota.Usage, thre
 	}
 }
 
+// OnWarningDetail implements goquota.DetailedWarningHandler
+func (h *warningHandler) OnWarningDetail(ctx context.Context, warning *goquota.Warning) {
+	switch {
+	case h.detail != nil:
+		h.detail(h.w, h.r, warning)
+	case warning.Kind == goquota.WarningKindPercentage:
+		h.OnWarning(ctx, warning.Usage, warning.Threshold)
+	default:
+		DefaultWarningDetailHandler(h.w, h.r, warning)
+	}
+}
+
 // DefaultWarningHandler is the default OnWarning implementation.
-// It adds X-Quota-Warning-Threshold and X-Quota-Warning-Used headers.
-func DefaultWarningHandler(w http.ResponseWriter, _ *http.Request, usage *goquota.Usage, threshold float64) {
-	w.Header().Add("X-Quota-Warning-Threshold", fmt.Sprintf("%.2f", threshold))
-	w.Header().Add("X-Quota-Warning-Used", fmt.Sprintf("%d", usage.Used))
-	w.Header().Add("X-Quota-Warning-Limit", fmt.Sprintf("%d", usage.Limit))
+// It adds the DefaultWarningDetailHandler headers for a percentage warning.
+func DefaultWarningHandler(w http.ResponseWriter, r *http.Request, usage *goquota.Usage, threshold float64) {
+	DefaultWarningDetailHandler(w, r, &goquota.Warning{
+		Kind:      goquota.WarningKindPercentage,
+		Threshold: threshold,
+		Used:      usage.Used,
+		Limit:     usage.Limit,
+		Remaining: max(usage.Limit-usage.Used, 0),
+	})
+}
+
+// DefaultWarningDetailHandler is the default warning implementation for every warning kind.
+// It adds X-Quota-Warning (the kind), X-Quota-Warning-Threshold, X-Quota-Warning-Used,
+// X-Quota-Warning-Limit and X-Quota-Warning-Remaining headers.
+func DefaultWarningDetailHandler(w http.ResponseWriter, _ *http.Request, warning *goquota.Warning) {
+	threshold := fmt.Sprintf("%.2f", warning.Threshold)
+	if warning.Kind == goquota.WarningKindBalance {
+		threshold = fmt.Sprintf("%.0f", warning.Threshold)
+	}
+	w.Header().Add("X-Quota-Warning", string(warning.Kind))
+	w.Header().Add("X-Quota-Warning-Threshold", threshold)
+	w.Header().Add("X-Quota-Warning-Used", fmt.Sprintf("%d", warning.Used))
+	w.Header().Add("X-Quota-Warning-Limit", fmt.Sprintf("%d", warning.Limit))
+	w.Header().Add("X-Quota-Warning-Remaining", fmt.Sprintf("%d", warning.Remaining))
 }
 
 // HandlerFunc creates an HTTP middleware that enforces quota limits (HandlerFunc version)
@@ -556,6 +556,56 @@ func TestMiddleware_Warnings(t *testing.T) {
 	}
 }
 
+func TestMiddleware_BalanceWarningHeaders(t *testing.T) {
+	storage := memory.New()
+	config := goquota.Config{
+		DefaultTier: "free",
+		Tiers: map[string]goquota.TierConfig{
+			"free": {
+				Name:            "free",
+				BalanceWarnings: map[string][]int{"credits": {10}},
+			},
+		},
+	}
+	manager, err := goquota.NewManager(storage, &config)
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+	setupEntitlement(t, manager, "user1", "free")
+	if err := manager.TopUpLimit(context.Background(), "user1", "credits", 20); err != nil {
+		t.Fatalf("TopUpLimit failed: %v", err)
+	}
+
+	mw := Middleware(&Config{
+		Manager:     manager,
+		GetUserID:   FromHeader("X-User-ID"),
+		GetResource: FixedResource("credits"),
+		GetAmount:   FixedAmount(15),
+		PeriodType:  goquota.PeriodTypeForever,
+	})
+	handler := mw(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest("GET", "/", http.NoBody)
+	req.Header.Set("X-User-ID", "user1")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rec.Code)
+	}
+	if kind := rec.Header().Get("X-Quota-Warning"); kind != "balance" {
+		t.Errorf("Expected X-Quota-Warning: balance, got %s", kind)
+	}
+	if threshold := rec.Header().Get("X-Quota-Warning-Threshold"); threshold != "10" {
+		t.Errorf("Expected X-Quota-Warning-Threshold: 10, got %s", threshold)
+	}
+	if remaining := rec.Header().Get("X-Quota-Warning-Remaining"); remaining != "5" {
+		t.Errorf("Expected X-Quota-Warning-Remaining: 5, got %s", remaining)
+	}
+}
+
 func TestJSONHelpers(t *testing.T) {
 	manager := setupTestManager(t)
 	setupEntitlement(t, manager, "user1", "pro")
@@ -639,3 +689,83 @@ func TestJSONHelpers(t *testing.T) {
 		})
 	}
 }
+
+func TestMiddleware_HoldConcurrencySlot(t *testing.T) {
+	config := goquota.Config{
+		DefaultTier: "free",
+		Tiers: map[string]goquota.TierConfig{
+			"free": {
+				Name:          "free",
+				MonthlyQuotas: map[string]int{"renders": 100},
+				ConcurrencyLimits: map[string]goquota.ConcurrencyLimitConfig{
+					"renders": {MaxConcurrent: 1},
+				},
+			},
+		},
+	}
+	manager, err := goquota.NewManager(memory.New(), &config)
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+
+	mw := Middleware(&Config{
+		Manager:             manager,
+		GetUserID:           FromHeader("X-User-ID"),
+		GetResource:         FixedResource("renders"),
+		GetAmount:           FixedAmount(1),
+		HoldConcurrencySlot: true,
+	})
+
+	started := make(chan struct{})
+	unblock := make(chan struct{})
+	blocking := mw(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		close(started)
+		<-unblock
+		w.WriteHeader(http.StatusOK)
+	}))
+	instant := mw(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	newRequest := func() *http.Request {
+		req := httptest.NewRequest("POST", "/render", http.NoBody)
+		req.Header.Set("X-User-ID", "user1")
+		return req
+	}
+
+	// First request holds the only slot while its handler runs
+	done := make(chan int)
+	go func() {
+		w := httptest.NewRecorder()
+		blocking.ServeHTTP(w, newRequest())
+		done <- w.Code
+	}()
+	<-started
+
+	w := httptest.NewRecorder()
+	instant.ServeHTTP(w, newRequest())
+	if w.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected status 429 while slot is held, got %d", w.Code)
+	}
+
+	close(unblock)
+	if code := <-done; code != http.StatusOK {
+		t.Errorf("Expected first request to succeed, got %d", code)
+	}
+
+	// Slot is released when the handler returns
+	w = httptest.NewRecorder()
+	instant.ServeHTTP(w, newRequest())
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status 200 after slot release, got %d", w.Code)
+	}
+
+	// Only requests that passed the concurrency check consumed quota
+	usage, err := manager.GetQuota(context.Background(), "user1", "renders", goquota.PeriodTypeMonthly)
+	if err != nil {
+		t.Fatalf("GetQuota failed: %v", err)
+	}
+	if usage.Used != 2 {
+		t.Errorf("Expected 2 units consumed, got %d", usage.Used)
+	}
+}
@@ -0,0 +1,217 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/mihaimyh/goquota/pkg/goquota"
+)
+
+// maxOverrideBodySize is the maximum size of an override request body
+const maxOverrideBodySize = 64 << 10
+
+// AdminConfig holds configuration for the admin API handler
+type AdminConfig struct {
+	// Manager is the quota manager instance (required)
+	Manager *goquota.Manager
+
+	// Authorize reports whether the request may manage overrides (required).
+	// Unauthorized requests are rejected with 403 Forbidden.
+	Authorize func(*http.Request) bool
+
+	// GetActor optionally returns the operator recorded as the actor of override changes
+	// If nil, changes are recorded with the "admin" actor
+	GetActor func(*http.Request) string
+
+	// OnError handles errors (auth, validation, etc.)
+	// If nil, uses default error handling
+	OnError func(http.ResponseWriter, *http.Request, error)
+}
+
+// Validate checks that the configuration is valid
+func (c *AdminConfig) Validate() error {
+	if c.Manager == nil {
+		return fmt.Errorf("manager is required")
+	}
+	if c.Authorize == nil {
+		return fmt.Errorf("authorize is required")
+	}
+	return nil
+}
+
+// AdminHandler provides HTTP endpoints for incident response.
+//
+// Overrides are held by each Manager instance. With OverrideConfig.Shared, a change made on one
+// instance reaches the others through storage; otherwise the endpoint must be called on every
+// instance. A change applied locally but not shared fails with 503 Service Unavailable and is
+// shared once storage recovers.
+type AdminHandler struct {
+	config AdminConfig
+}
+
+// NewAdminHandler creates a new admin API handler with the given configuration
+func NewAdminHandler(config AdminConfig) (*AdminHandler, error) {
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+	return &AdminHandler{
+		config: config,
+	}, nil
+}
+
+// ServeHTTP manages manual overrides:
+//   - GET lists the active overrides
+//   - PUT sets the override in the JSON body, replacing any override with the same scope
+//   - DELETE clears the override scoped by the resource and tier query parameters
+//     (both omitted for the global override)
+func (h *AdminHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !h.config.Authorize(r) {
+		h.handleError(w, r, fmt.Errorf("forbidden"), http.StatusForbidden)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		h.sendJSON(w, h.listOverrides())
+	case http.MethodPut:
+		h.setOverride(w, r)
+	case http.MethodDelete:
+		params := r.URL.Query()
+		err := h.config.Manager.ClearOverride(r.Context(), params.Get("resource"), params.Get("tier"), h.actor(r))
+		if errors.Is(err, goquota.ErrOverrideNotFound) {
+			h.handleError(w, r, err, http.StatusNotFound)
+			return
+		}
+		if errors.Is(err, goquota.ErrOverrideNotShared) {
+			h.handleError(w, r, err, http.StatusServiceUnavailable)
+			return
+		}
+		if err != nil {
+			h.handleError(w, r, err, http.StatusInternalServerError)
+			return
+		}
+		h.sendJSON(w, h.listOverrides())
+	default:
+		w.Header().Set("Allow", "GET, PUT, DELETE")
+		h.handleError(w, r, fmt.Errorf("method not allowed"), http.StatusMethodNotAllowed)
+	}
+}
+
+// GetOverrideJournal returns the consumptions allowed by "journal" overrides
+func (h *AdminHandler) GetOverrideJournal(w http.ResponseWriter, r *http.Request) {
+	if !h.config.Authorize(r) {
+		h.handleError(w, r, fmt.Errorf("forbidden"), http.StatusForbidden)
+		return
+	}
+
+	entries, err := h.config.Manager.OverrideJournalEntries(r.Context())
+	if err != nil {
+		h.handleError(w, r, fmt.Errorf("failed to get override journal: %w", err), http.StatusInternalServerError)
+		return
+	}
+
+	response := OverrideJournalResponse{Entries: make([]OverrideJournalEntry, 0, len(entries))}
+	for _, entry := range entries {
+		response.Entries = append(response.Entries, OverrideJournalEntry{
+			UserID:         entry.UserID,
+			Resource:       entry.Resource,
+			Tier:           entry.Tier,
+			Amount:         entry.Amount,
+			PeriodType:     string(entry.PeriodType),
+			IdempotencyKey: entry.IdempotencyKey,
+			Reason:         entry.Reason,
+			Timestamp:      entry.Timestamp,
+		})
+	}
+	h.sendJSON(w, response)
+}
+
+// setOverride decodes and sets an override
+func (h *AdminHandler) setOverride(w http.ResponseWriter, r *http.Request) {
+	var req Override
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxOverrideBodySize))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		h.handleError(w, r, fmt.Errorf("invalid override: %w", err), http.StatusBadRequest)
+		return
+	}
+
+	override := goquota.Override{
+		Mode:         goquota.OverrideMode(req.Mode),
+		Resource:     req.Resource,
+		Tier:         req.Tier,
+		EnforceTiers: req.EnforceTiers,
+		Reason:       req.Reason,
+	}
+	if req.ExpiresAt != nil {
+		override.ExpiresAt = *req.ExpiresAt
+	}
+	override.Actor = h.actor(r)
+	err := h.config.Manager.SetOverride(r.Context(), override)
+	if errors.Is(err, goquota.ErrOverrideNotShared) {
+		h.handleError(w, r, err, http.StatusServiceUnavailable)
+		return
+	}
+	if err != nil {
+		h.handleError(w, r, fmt.Errorf("invalid override: %w", err), http.StatusBadRequest)
+		return
+	}
+	h.sendJSON(w, h.listOverrides())
+}
+
+// actor returns the operator recorded as the actor of an override change
+func (h *AdminHandler) actor(r *http.Request) string {
+	if h.config.GetActor == nil {
+		return ""
+	}
+	return h.config.GetActor(r)
+}
+
+// listOverrides returns the active overrides of the manager
+func (h *AdminHandler) listOverrides() OverridesResponse {
+	overrides := h.config.Manager.Overrides()
+	response := OverridesResponse{Overrides: make([]Override, 0, len(overrides))}
+	for i := range overrides {
+		o := &overrides[i]
+		override := Override{
+			Mode:         string(o.Mode),
+			Resource:     o.Resource,
+			Tier:         o.Tier,
+			EnforceTiers: o.EnforceTiers,
+			Reason:       o.Reason,
+			Actor:        o.Actor,
+			CreatedAt:    &o.CreatedAt,
+		}
+		if !o.ExpiresAt.IsZero() {
+			override.ExpiresAt = &o.ExpiresAt
+		}
+		response.Overrides = append(response.Overrides, override)
+	}
+	return response
+}
+
+// sendJSON writes a 200 OK JSON response
+func (h *AdminHandler) sendJSON(w http.ResponseWriter, response interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		// Response already started; nothing more can be sent
+		_ = err
+	}
+}
+
+// handleError handles errors with appropriate HTTP status codes
+func (h *AdminHandler) handleError(w http.ResponseWriter, r *http.Request, err error, statusCode int) {
+	if h.config.OnError != nil {
+		h.config.OnError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	if encodeErr := json.NewEncoder(w).Encode(map[string]string{"error": err.Error()}); encodeErr != nil {
+		_ = encodeErr
+	}
+}
@@ -0,0 +1,180 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mihaimyh/goquota/pkg/goquota"
+	"github.com/mihaimyh/goquota/storage/memory"
+)
+
+func newTestAdminHandler(t *testing.T) *AdminHandler {
+	t.Helper()
+	handler, err := NewAdminHandler(AdminConfig{
+		Manager:   newTestManager(),
+		Authorize: func(r *http.Request) bool { return r.Header.Get("X-Admin-Token") == "secret" },
+		GetActor:  func(r *http.Request) string { return r.Header.Get("X-Operator") },
+	})
+	if err != nil {
+		t.Fatalf("Failed to create admin handler: %v", err)
+	}
+	return handler
+}
+
+func serveAdmin(handler http.Handler, method, target, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("X-Admin-Token", "secret")
+	req.Header.Set("X-Operator", "oncall")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	return w
+}
+
+func TestAdminHandler_Overrides(t *testing.T) {
+	handler := newTestAdminHandler(t)
+
+	w := serveAdmin(handler, http.MethodPut, "/admin/overrides",
+		`{"mode":"enforce_tiers","resource":"api_calls","enforce_tiers":["pro"],"reason":"INC-42"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = serveAdmin(handler, http.MethodGet, "/admin/overrides", "")
+	var response OverridesResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(response.Overrides) != 1 {
+		t.Fatalf("Expected 1 override, got %d", len(response.Overrides))
+	}
+	override := response.Overrides[0]
+	if override.Mode != "enforce_tiers" || override.Resource != testResource || override.Actor != "oncall" ||
+		len(override.EnforceTiers) != 1 || override.CreatedAt == nil || override.ExpiresAt != nil {
+		t.Errorf("Unexpected override: %+v", override)
+	}
+
+	// The override applies to consumption immediately
+	if _, err := handler.config.Manager.Consume(context.Background(), testUserID, testResource, 500,
+		goquota.PeriodTypeMonthly); err != nil {
+		t.Errorf("Expected consumption allowed by override, got %v", err)
+	}
+
+	w = serveAdmin(handler, http.MethodDelete, "/admin/overrides?resource=api_calls", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if overrides := handler.config.Manager.Overrides(); len(overrides) != 0 {
+		t.Errorf("Expected no overrides, got %+v", overrides)
+	}
+
+	w = serveAdmin(handler, http.MethodDelete, "/admin/overrides?resource=api_calls", "")
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", w.Code)
+	}
+}
+
+func TestAdminHandler_Errors(t *testing.T) {
+	handler := newTestAdminHandler(t)
+
+	tests := []struct {
+		name   string
+		method string
+		body   string
+		status int
+	}{
+		{"invalid mode", http.MethodPut, `{"mode":"maintenance"}`, http.StatusBadRequest},
+		{"unknown field", http.MethodPut, `{"mode":"deny_all","scope":"global"}`, http.StatusBadRequest},
+		{"malformed body", http.MethodPut, `{`, http.StatusBadRequest},
+		{"method not allowed", http.MethodPost, `{"mode":"deny_all"}`, http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serveAdmin(handler, tt.method, "/admin/overrides", tt.body)
+			if w.Code != tt.status {
+				t.Errorf("Expected status %d, got %d", tt.status, w.Code)
+			}
+		})
+	}
+
+	req := httptest.NewRequest(http.MethodPut, "/admin/overrides", strings.NewReader(`{"mode":"deny_all"}`))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403, got %d", w.Code)
+	}
+	if overrides := handler.config.Manager.Overrides(); len(overrides) != 0 {
+		t.Errorf("Expected unauthorized request to be ignored, got %+v", overrides)
+	}
+
+	if _, err := NewAdminHandler(AdminConfig{Manager: newTestManager()}); err == nil {
+		t.Error("Expected error for nil Authorize")
+	}
+}
+
+// downOverrideStorage fails to share overrides
+type downOverrideStorage struct {
+	*memory.Storage
+}
+
+func (s downOverrideStorage) SaveOverride(context.Context, *goquota.Override) error {
+	return goquota.ErrStorageUnavailable
+}
+
+func TestAdminHandler_OverrideNotShared(t *testing.T) {
+	manager, err := goquota.NewManager(downOverrideStorage{memory.New()}, &goquota.Config{
+		DefaultTier:    "free",
+		Tiers:          map[string]goquota.TierConfig{"free": {Name: "free"}},
+		OverrideConfig: &goquota.OverrideConfig{Shared: true},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+	defer func() { _ = manager.Close(context.Background()) }()
+	handler, err := NewAdminHandler(AdminConfig{
+		Manager:   manager,
+		Authorize: func(*http.Request) bool { return true },
+	})
+	if err != nil {
+		t.Fatalf("Failed to create admin handler: %v", err)
+	}
+
+	w := serveAdmin(handler, http.MethodPut, "/admin/overrides", `{"mode":"deny_all"}`)
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503, got %d: %s", w.Code, w.Body.String())
+	}
+	// Applied locally all the same
+	if overrides := manager.Overrides(); len(overrides) != 1 {
+		t.Errorf("Expected the override to apply locally, got %+v", overrides)
+	}
+}
+
+func TestAdminHandler_GetOverrideJournal(t *testing.T) {
+	handler := newTestAdminHandler(t)
+	serveAdmin(handler, http.MethodPut, "/admin/overrides", `{"mode":"journal","reason":"storage down"}`)
+
+	if _, err := handler.config.Manager.Consume(context.Background(), testUserID, testResource, 3,
+		goquota.PeriodTypeMonthly); err != nil {
+		t.Fatalf("Consume failed: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/overrides/journal", http.NoBody)
+	req.Header.Set("X-Admin-Token", "secret")
+	w := httptest.NewRecorder()
+	handler.GetOverrideJournal(w, req)
+
+	var response OverrideJournalResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(response.Entries) != 1 {
+		t.Fatalf("Expected 1 journal entry, got %d", len(response.Entries))
+	}
+	if entry := response.Entries[0]; entry.UserID != testUserID || entry.Amount != 3 ||
+		entry.PeriodType != "monthly" || entry.Reason != "storage down" {
+		t.Errorf("Unexpected journal entry: %+v", entry)
+	}
+}
@@ -26,6 +26,10 @@ type Config struct {
 	// If nil, only checks current tier config and InitialForeverCredits
 	KnownResources []string
 
+	// IncludeForecast adds a usage forecast (Manager.Forecast) to each resource.
+	// Forecast failures are ignored and the forecast is omitted.
+	IncludeForecast bool
+
 	// OnError handles errors (auth, internal, etc.)
 	// If nil, uses default error handling
 	OnError func(http.ResponseWriter, *http.Request, error)
@@ -314,9 +314,35 @@ func (h *Handler) buildResourceUsage(
 		Remaining: combined.Remaining,
 		ResetAt:   resetAt,
 		Breakdown: breakdown,
+		Forecast:  h.buildForecast(ctx, userID, resource),
 	}, nil
 }
 
+// buildForecast builds the forecast for a resource if enabled.
+// Returns nil if forecasts are disabled or cannot be computed.
+func (h *Handler) buildForecast(ctx context.Context, userID, resource string) *ResourceForecast {
+	if !h.config.IncludeForecast {
+		return nil
+	}
+
+	forecast, err := h.config.Manager.Forecast(ctx, userID, resource)
+	if err != nil || forecast.Limit == -1 {
+		return nil
+	}
+
+	return &ResourceForecast{
+		RatePerHour:        forecast.RatePerHour,
+		ProjectedUsed:      forecast.ProjectedUsed,
+		ProjectedUsedLow:   forecast.ProjectedUsedLow,
+		ProjectedUsedHigh:  forecast.ProjectedUsedHigh,
+		ExhaustsAt:         forecast.ExhaustsAt,
+		ExhaustsAtEarliest: forecast.ExhaustsAtEarliest,
+		ExhaustsAtLatest:   forecast.ExhaustsAtLatest,
+		WillExceed:         forecast.WillExceed,
+		Samples:            forecast.Samples,
+	}
+}
+
 // combinedQuota holds the calculated combined quota values
 type combinedQuota struct {
 	Limit     int
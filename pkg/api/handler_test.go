@@ -770,3 +770,161 @@ func TestHandler_GetUsage_InvalidUserID(t *testing.T) {
 		t.Errorf("Expected status 400, got %d: %s", w.Code, w.Body.String())
 	}
 }
+
+func TestHandler_GetUsage_IncludeForecast(t *testing.T) {
+	manager := newTestManager()
+	ctx := context.Background()
+	userID := testUserID
+
+	// Subscription started 10 days ago so the current cycle has history
+	_ = manager.SetEntitlement(ctx, &goquota.Entitlement{
+		UserID:                userID,
+		Tier:                  "free",
+		SubscriptionStartDate: time.Now().UTC().Add(-10 * 24 * time.Hour),
+		UpdatedAt:             time.Now().UTC(),
+	})
+
+	// 60 of 100 used in 10 days - projected to run out before the cycle resets
+	_, _ = manager.Consume(ctx, userID, "api_calls", 60, goquota.PeriodTypeMonthly)
+
+	for _, includeForecast := range []bool{false, true} {
+		handler, err := NewHandler(Config{
+			Manager:         manager,
+			GetUserID:       func(_ *http.Request) string { return userID },
+			KnownResources:  []string{testResource},
+			IncludeForecast: includeForecast,
+		})
+		if err != nil {
+			t.Fatalf("Failed to create handler: %v", err)
+		}
+
+		req := httptest.NewRequest("GET", "/usage", http.NoBody)
+		w := httptest.NewRecorder()
+		handler.GetUsage(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+
+		var response UsageResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+
+		forecast := response.Resources["api_calls"].Forecast
+		if !includeForecast {
+			if forecast != nil {
+				t.Errorf("Expected no forecast when IncludeForecast is false, got %+v", forecast)
+			}
+			continue
+		}
+
+		if forecast == nil {
+			t.Fatal("Expected forecast in response")
+		}
+		if !forecast.WillExceed {
+			t.Error("Expected forecast to project exhaustion before reset")
+		}
+		if forecast.ExhaustsAt == nil {
+			t.Error("Expected exhausts_at to be set")
+		}
+		if forecast.ProjectedUsed <= 100 {
+			t.Errorf("Expected projected usage above the limit, got %d", forecast.ProjectedUsed)
+		}
+		if forecast.ProjectedUsedLow > forecast.ProjectedUsed || forecast.ProjectedUsedHigh < forecast.ProjectedUsed {
+			t.Errorf("Expected projection inside band, got %d [%d, %d]",
+				forecast.ProjectedUsed, forecast.ProjectedUsedLow, forecast.ProjectedUsedHigh)
+		}
+	}
+}
+
+func TestHandler_GetUsageHistory(t *testing.T) {
+	storage := memory.New()
+	manager, err := goquota.NewManager(storage, &goquota.Config{
+		DefaultTier: "free",
+		Tiers: map[string]goquota.TierConfig{
+			"free": {Name: "free", MonthlyQuotas: map[string]int{testResource: 100}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+	ctx := context.Background()
+
+	// Three past monthly periods
+	thisMonth := time.Now().UTC().AddDate(0, 0, -time.Now().UTC().Day()+1).Truncate(24 * time.Hour)
+	for i := 1; i <= 3; i++ {
+		start := thisMonth.AddDate(0, -i, 0)
+		period := goquota.Period{Start: start, End: start.AddDate(0, 1, 0), Type: goquota.PeriodTypeMonthly}
+		err := storage.SetUsage(ctx, testUserID, testResource, &goquota.Usage{
+			UserID: testUserID, Resource: testResource, Used: i * 10, Limit: 100, Period: period, Tier: "free",
+		}, period)
+		if err != nil {
+			t.Fatalf("SetUsage failed: %v", err)
+		}
+	}
+
+	handler, err := NewHandler(Config{
+		Manager:   manager,
+		GetUserID: func(_ *http.Request) string { return testUserID },
+	})
+	if err != nil {
+		t.Fatalf("Failed to create handler: %v", err)
+	}
+
+	get := func(query string) (*httptest.ResponseRecorder, UsageHistoryResponse) {
+		req := httptest.NewRequest("GET", "/usage/history?"+query, http.NoBody)
+		w := httptest.NewRecorder()
+		handler.GetUsageHistory(w, req)
+
+		var response UsageHistoryResponse
+		if w.Code == http.StatusOK {
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Failed to unmarshal response: %v", err)
+			}
+		}
+		return w, response
+	}
+
+	// First page
+	w, response := get("resource=api_calls&limit=2")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if response.UserID != testUserID || response.Resource != testResource || response.PeriodType != "monthly" {
+		t.Errorf("Unexpected response metadata: %+v", response)
+	}
+	if len(response.Periods) != 2 {
+		t.Fatalf("Expected 2 periods, got %d", len(response.Periods))
+	}
+	if response.Periods[0].Used != 10 || response.Periods[1].Used != 20 {
+		t.Errorf("Expected newest periods first, got %+v", response.Periods)
+	}
+	if response.NextOffset == nil || *response.NextOffset != 2 {
+		t.Fatalf("Expected next_offset 2, got %v", response.NextOffset)
+	}
+
+	// Last page
+	_, response = get(fmt.Sprintf("resource=api_calls&limit=2&offset=%d", *response.NextOffset))
+	if len(response.Periods) != 1 || response.Periods[0].Used != 30 {
+		t.Errorf("Expected oldest period on last page, got %+v", response.Periods)
+	}
+	if response.NextOffset != nil {
+		t.Errorf("Expected no next_offset on last page, got %d", *response.NextOffset)
+	}
+
+	// Validation errors
+	for _, query := range []string{
+		"",
+		"resource=api_calls&period=forever",
+		"resource=api_calls&limit=0",
+		"resource=api_calls&limit=101",
+		"resource=api_calls&offset=-1",
+		"resource=api_calls&from=yesterday",
+		"resource=api_calls&from=2026-02-01T00:00:00Z&to=2026-01-01T00:00:00Z",
+	} {
+		if w, _ := get(query); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for %q, got %d", query, w.Code)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/mihaimyh/goquota/pkg/goquota"
+)
+
+const (
+	defaultHistoryLimit = 12
+	maxHistoryLimit     = 100
+)
+
+// GetUsageHistory returns a paginated JSON list of the user's usage in past periods.
+//
+// Query parameters:
+//   - resource: resource name (required)
+//   - period: "monthly" (default) or "daily"
+//   - from, to: RFC 3339 range of period starts (default: the year before now)
+//   - limit: page size (default 12, max 100)
+//   - offset: number of periods to skip (use next_offset from the previous page)
+func (h *Handler) GetUsageHistory(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	var errorType string
+	status := "success"
+
+	// Record metrics on exit
+	defer func() {
+		if h.config.Metrics != nil {
+			h.config.Metrics.RecordUsageAPIRequestDuration(time.Since(startTime))
+			h.config.Metrics.RecordUsageAPIRequest(status, errorType)
+		}
+	}()
+
+	userID, ok := h.validateUserID(w, r, &status, &errorType)
+	if !ok {
+		return
+	}
+
+	query, err := parseHistoryQuery(r)
+	if err != nil {
+		status = statusError
+		errorType = "validation_error"
+		h.handleError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	// Fetch one extra record to detect whether another page exists
+	records, err := h.config.Manager.GetUsageHistory(r.Context(), userID, query.Resource, query.PeriodType,
+		query.From, query.To, goquota.WithHistoryLimit(query.Limit+1), goquota.WithHistoryOffset(query.Offset))
+	if err != nil {
+		status = statusError
+		errorType = "storage_error"
+		h.handleError(w, r, fmt.Errorf("failed to get usage history: %w", err), http.StatusInternalServerError)
+		return
+	}
+
+	response := UsageHistoryResponse{
+		UserID:     userID,
+		Resource:   query.Resource,
+		PeriodType: string(query.PeriodType),
+		Periods:    make([]PeriodUsage, 0, len(records)),
+	}
+	if len(records) > query.Limit {
+		records = records[:query.Limit]
+		nextOffset := query.Offset + query.Limit
+		response.NextOffset = &nextOffset
+	}
+	for _, usage := range records {
+		response.Periods = append(response.Periods, PeriodUsage{
+			PeriodStart: usage.Period.Start,
+			PeriodEnd:   usage.Period.End,
+			Used:        usage.Used,
+			Limit:       usage.Limit,
+			Tier:        usage.Tier,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		status = statusError
+		errorType = "encoding_error"
+	}
+}
+
+// parseHistoryQuery parses and validates usage history query parameters
+func parseHistoryQuery(r *http.Request) (*goquota.UsageHistoryQuery, error) {
+	params := r.URL.Query()
+	query := &goquota.UsageHistoryQuery{
+		Resource:   params.Get("resource"),
+		PeriodType: goquota.PeriodTypeMonthly,
+		To:         time.Now().UTC(),
+		Limit:      defaultHistoryLimit,
+	}
+
+	if query.Resource == "" {
+		return nil, fmt.Errorf("resource is required")
+	}
+
+	switch period := params.Get("period"); period {
+	case "", string(goquota.PeriodTypeMonthly):
+	case string(goquota.PeriodTypeDaily):
+		query.PeriodType = goquota.PeriodTypeDaily
+	default:
+		return nil, fmt.Errorf("invalid period %q (must be monthly or daily)", period)
+	}
+
+	if v := params.Get("to"); v != "" {
+		to, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid to: %w", err)
+		}
+		query.To = to
+	}
+	query.From = query.To.AddDate(-1, 0, 0)
+	if v := params.Get("from"); v != "" {
+		from, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid from: %w", err)
+		}
+		query.From = from
+	}
+	if !query.From.Before(query.To) {
+		return nil, fmt.Errorf("from must be before to")
+	}
+
+	if v := params.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > maxHistoryLimit {
+			return nil, fmt.Errorf("invalid limit %q (must be between 1 and %d)", v, maxHistoryLimit)
+		}
+		query.Limit = limit
+	}
+	if v := params.Get("offset"); v != "" {
+		offset, err := strconv.Atoi(v)
+		if err != nil || offset < 0 {
+			return nil, fmt.Errorf("invalid offset %q", v)
+		}
+		query.Offset = offset
+	}
+
+	return query, nil
+}
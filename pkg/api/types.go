@@ -12,11 +12,26 @@ type UsageResponse struct {
 
 // ResourceUsage represents quota information for a single resource
 type ResourceUsage struct {
-	Limit     int              `json:"limit"`              // Combined limit (-1 for unlimited)
-	Used      int              `json:"used"`               // Combined used amount
-	Remaining int              `json:"remaining"`          // Combined remaining (-1 for unlimited)
-	ResetAt   *time.Time       `json:"reset_at,omitempty"` // Reset time for monthly quota
-	Breakdown []QuotaBreakdown `json:"breakdown"`          // Breakdown by source
+	Limit     int               `json:"limit"`              // Combined limit (-1 for unlimited)
+	Used      int               `json:"used"`               // Combined used amount
+	Remaining int               `json:"remaining"`          // Combined remaining (-1 for unlimited)
+	ResetAt   *time.Time        `json:"reset_at,omitempty"` // Reset time for monthly quota
+	Breakdown []QuotaBreakdown  `json:"breakdown"`          // Breakdown by source
+	Forecast  *ResourceForecast `json:"forecast,omitempty"` // Projection until reset (if IncludeForecast)
+}
+
+// ResourceForecast represents the projected usage of a resource until its period resets.
+// Projections cover the periodic quota only; forever credits are not included.
+type ResourceForecast struct {
+	RatePerHour        float64    `json:"rate_per_hour"`                  // Estimated consumption rate
+	ProjectedUsed      int        `json:"projected_used"`                 // Projected usage at reset
+	ProjectedUsedLow   int        `json:"projected_used_low"`             // Lower bound (95% confidence)
+	ProjectedUsedHigh  int        `json:"projected_used_high"`            // Upper bound (95% confidence)
+	ExhaustsAt         *time.Time `json:"exhausts_at,omitempty"`          // Projected exhaustion before reset
+	ExhaustsAtEarliest *time.Time `json:"exhausts_at_earliest,omitempty"` // Earliest exhaustion (95% confidence)
+	ExhaustsAtLatest   *time.Time `json:"exhausts_at_latest,omitempty"`   // Latest exhaustion (95% confidence)
+	WillExceed         bool       `json:"will_exceed"`                    // Projected to run out before reset
+	Samples            int        `json:"samples"`                        // Observations used for the projection
 }
 
 // QuotaBreakdown represents quota information from a specific source
@@ -26,3 +41,55 @@ type QuotaBreakdown struct {
 	Used    int    `json:"used,omitempty"`    // Used amount for this source
 	Balance int    `json:"balance,omitempty"` // Balance for forever credits (limit - used)
 }
+
+// UsageHistoryResponse represents a page of a user's usage in past periods
+type UsageHistoryResponse struct {
+	UserID     string        `json:"user_id"`
+	Resource   string        `json:"resource"`
+	PeriodType string        `json:"period_type"`           // "monthly" or "daily"
+	Periods    []PeriodUsage `json:"periods"`               // Newest first
+	NextOffset *int          `json:"next_offset,omitempty"` // Offset of the next page, if any
+}
+
+// PeriodUsage represents the usage of a resource in a single period
+type PeriodUsage struct {
+	PeriodStart time.Time `json:"period_start"`
+	PeriodEnd   time.Time `json:"period_end"`
+	Used        int       `json:"used"`
+	Limit       int       `json:"limit"`          // Limit at the end of the period (-1 for unlimited)
+	Tier        string    `json:"tier,omitempty"` // Tier the usage was recorded under
+}
+
+// Override represents a manual override of quota decisions
+type Override struct {
+	Mode         string     `json:"mode"`                    // "allow_all", "deny_all", "journal" or "enforce_tiers"
+	Resource     string     `json:"resource,omitempty"`      // Empty for all resources
+	Tier         string     `json:"tier,omitempty"`          // Empty for all tiers
+	EnforceTiers []string   `json:"enforce_tiers,omitempty"` // Tiers still enforced with "enforce_tiers"
+	Reason       string     `json:"reason,omitempty"`
+	Actor        string     `json:"actor,omitempty"`      // Set by the server
+	CreatedAt    *time.Time `json:"created_at,omitempty"` // Set by the server
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"` // Lifts the override automatically
+}
+
+// OverridesResponse represents the active manual overrides
+type OverridesResponse struct {
+	Overrides []Override `json:"overrides"`
+}
+
+// OverrideJournalResponse represents the consumptions allowed by "journal" overrides
+type OverrideJournalResponse struct {
+	Entries []OverrideJournalEntry `json:"entries"` // Oldest first
+}
+
+// OverrideJournalEntry represents a consumption allowed by a "journal" override
+type OverrideJournalEntry struct {
+	UserID         string    `json:"user_id"`
+	Resource       string    `json:"resource"`
+	Tier           string    `json:"tier,omitempty"`
+	Amount         int       `json:"amount"`
+	PeriodType     string    `json:"period_type"`
+	IdempotencyKey string    `json:"idempotency_key,omitempty"`
+	Reason         string    `json:"reason,omitempty"`
+	Timestamp      time.Time `json:"timestamp"`
+}
@@ -786,7 +786,8 @@ func startOfDayUTC(t time.Time) time.Time {
 	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
 }
 
-// invokeWebhookCallback calls the configured webhook callback if present
+// invokeWebhookCallback emits EventTierChanged through the manager when the tier changed
+// and calls the configured webhook callback if present
 func (p *Provider) invokeWebhookCallback(
 	ctx context.Context,
 	userID, previousTier, newTier, eventType string,
@@ -794,6 +795,20 @@ func (p *Provider) invokeWebhookCallback(
 	expiresAt *time.Time,
 	metadata map[string]interface{},
 ) error {
+	if previousTier != newTier {
+		// Failing the provider's webhook wouldn't help: the tier is applied, so a retry doesn't emit again
+		//nolint:errcheck // Deliveries that can't be persisted are logged and counted by the manager
+		_ = p.manager.Emit(ctx, &goquota.Event{
+			Type:         goquota.EventTierChanged,
+			Time:         eventTimestamp.UTC(),
+			Source:       providerName,
+			UserID:       userID,
+			Tier:         newTier,
+			PreviousTier: previousTier,
+			Metadata:     map[string]string{"provider_event": eventType},
+		})
+	}
+
 	if p.webhookCallback == nil {
 		return nil
 	}
@@ -12,6 +12,7 @@ import (
 
 	"github.com/mihaimyh/goquota/pkg/billing"
 	"github.com/mihaimyh/goquota/pkg/goquota"
+	"github.com/mihaimyh/goquota/storage/memory"
 )
 
 // TestWebhookCallback_SubscriptionCreated_Success verifies callback is invoked with correct parameters
@@ -553,3 +554,63 @@ func TestWebhookCallback_IdempotencySkipsRetry(t *testing.T) {
 		t.Errorf("Expected callback to remain at 1 call (idempotency skip), got %d", finalCallCount)
 	}
 }
+
+// TestWebhookCallback_EmitsTierChangedEvent verifies tier changes are published to the manager's event sinks
+func TestWebhookCallback_EmitsTierChangedEvent(t *testing.T) {
+	received := make(chan *goquota.Event, 2)
+	manager, err := goquota.NewManager(memory.New(), &goquota.Config{
+		DefaultTier: testTierExplorer,
+		Tiers: map[string]goquota.TierConfig{
+			testTierExplorer: {Name: testTierExplorer},
+			testTierPro:      {Name: testTierPro},
+		},
+		EventConfig: &goquota.EventConfig{
+			Sinks: []goquota.EventSink{goquota.EventSinkFunc(func(_ context.Context, event *goquota.Event) error {
+				received <- event
+				return nil
+			})},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+
+	provider, err := NewProvider(Config{
+		Config:              billing.Config{Manager: manager},
+		StripeAPIKey:        testStripeAPIKey,
+		StripeWebhookSecret: testStripeWebhookSecret,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create provider: %v", err)
+	}
+
+	ctx := context.Background()
+	now := time.Now()
+	if err := provider.invokeWebhookCallback(
+		ctx, testUserID, testTierPro, testTierPro, "customer.subscription.updated", now, nil, nil,
+	); err != nil {
+		t.Fatalf("invokeWebhookCallback failed: %v", err)
+	}
+	if err := provider.invokeWebhookCallback(
+		ctx, testUserID, testTierExplorer, testTierPro, "customer.subscription.created", now, nil, nil,
+	); err != nil {
+		t.Fatalf("invokeWebhookCallback failed: %v", err)
+	}
+	if err := manager.Close(ctx); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	if len(received) != 1 {
+		t.Fatalf("Expected 1 event (unchanged tier is not emitted), got %d", len(received))
+	}
+	event := <-received
+	if event.Type != goquota.EventTierChanged || event.Source != providerName {
+		t.Errorf("Expected %s from %s, got %s from %s", goquota.EventTierChanged, providerName, event.Type, event.Source)
+	}
+	if event.PreviousTier != testTierExplorer || event.Tier != testTierPro {
+		t.Errorf("Expected %s -> %s, got %s -> %s", testTierExplorer, testTierPro, event.PreviousTier, event.Tier)
+	}
+	if event.Metadata["provider_event"] != "customer.subscription.created" {
+		t.Errorf("Expected provider_event metadata, got %v", event.Metadata)
+	}
+}
@@ -0,0 +1,218 @@
+package goquota
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// QuotaQuery identifies a quota to check with Manager.GetQuotaMany
+type QuotaQuery struct {
+	UserID     string
+	Resource   string
+	PeriodType PeriodType
+}
+
+// QuotaResult is the outcome of a single QuotaQuery.
+// Either Usage or Err is set.
+type QuotaResult struct {
+	Usage *Usage
+	Err   error
+}
+
+// pendingUsage is a usage cache miss shared by one or more queries
+type pendingUsage struct {
+	cacheKey string
+	query    UsageQuery
+	tier     string
+	indexes  []int
+}
+
+// GetQuotaMany returns current usage and limits for many users and resources in one call.
+// Results are in the same order as the queries, with per-item errors.
+//
+// Entitlements and usage missing from the cache are read with a single storage call each
+// when the storage implements BatchStorage, and one at a time otherwise. As with GetQuota,
+// users whose entitlement cannot be read are checked against the default tier.
+func (m *Manager) GetQuotaMany(ctx context.Context, queries []QuotaQuery) ([]QuotaResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	defer func() {
+		for _, q := range queries {
+			m.metrics.RecordQuotaCheck(q.UserID, q.Resource, time.Since(start))
+		}
+	}()
+
+	results := make([]QuotaResult, len(queries))
+	if len(queries) == 0 {
+		return results, nil
+	}
+
+	ents := m.getEntitlementsMany(ctx, queries)
+	now := m.now(ctx)
+
+	var misses []*pendingUsage
+	missesByKey := make(map[string]*pendingUsage)
+	for i, q := range queries {
+		ent := ents[q.UserID]
+		tier := m.config.DefaultTier
+		if ent != nil {
+			tier = ent.Tier
+		}
+
+		period, err := m.quotaPeriod(ent, q.PeriodType, now)
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+
+		usageKey := q.UserID + ":" + q.Resource + ":" + period.Key()
+		if cached, found := m.cache.GetUsage(usageKey); found {
+			m.metrics.RecordCacheHit("usage")
+			results[i].Usage = m.completeUsage(cached, q.UserID, q.Resource, tier, period)
+			continue
+		}
+		m.metrics.RecordCacheMiss("usage")
+
+		// Deduplicate repeated queries
+		missKey := usageKey + ":" + string(period.Type)
+		if pending, ok := missesByKey[missKey]; ok {
+			pending.indexes = append(pending.indexes, i)
+			continue
+		}
+		pending := &pendingUsage{
+			cacheKey: usageKey,
+			query:    UsageQuery{UserID: q.UserID, Resource: q.Resource, Period: period},
+			tier:     tier,
+			indexes:  []int{i},
+		}
+		missesByKey[missKey] = pending
+		misses = append(misses, pending)
+	}
+
+	if len(misses) > 0 {
+		m.loadUsagesMany(ctx, misses, results)
+	}
+
+	return results, nil
+}
+
+// getEntitlementsMany returns the entitlements of the queried users, reading cache misses in one batch.
+// Users without an entitlement, or whose entitlement cannot be read, are omitted.
+func (m *Manager) getEntitlementsMany(ctx context.Context, queries []QuotaQuery) map[string]*Entitlement {
+	ents := make(map[string]*Entitlement)
+	seen := make(map[string]bool)
+	var misses []string
+	for _, q := range queries {
+		if seen[q.UserID] {
+			continue
+		}
+		seen[q.UserID] = true
+
+		if cached, found := m.cache.GetEntitlement(q.UserID); found {
+			m.metrics.RecordCacheHit("entitlement")
+			ents[q.UserID] = cached
+			continue
+		}
+		m.metrics.RecordCacheMiss("entitlement")
+		misses = append(misses, q.UserID)
+	}
+	if len(misses) == 0 {
+		return ents
+	}
+
+	start := time.Now()
+	loaded, err := getEntitlements(ctx, m.storage, misses)
+	m.metrics.RecordStorageOperation("GetEntitlements", time.Since(start), err)
+	if err != nil {
+		m.logger.Error("failed to get entitlements from storage",
+			Field{"users", len(misses)},
+			Field{"error", err},
+		)
+		for _, userID := range misses {
+			if fallbackEnt := m.tryFallbackEntitlement(ctx, userID, err); fallbackEnt != nil {
+				ents[userID] = fallbackEnt
+			}
+		}
+		return ents
+	}
+
+	ttl := m.entitlementCacheTTL()
+	for userID, ent := range loaded {
+		if ent == nil {
+			continue
+		}
+		m.cache.SetEntitlement(userID, ent, ttl)
+		ents[userID] = ent
+	}
+	return ents
+}
+
+// loadUsagesMany reads usage cache misses in one batch and fills in their results
+func (m *Manager) loadUsagesMany(ctx context.Context, misses []*pendingUsage, results []QuotaResult) {
+	queries := make([]UsageQuery, len(misses))
+	for i, pending := range misses {
+		queries[i] = pending.query
+	}
+
+	start := time.Now()
+	usages, err := getUsages(ctx, m.storage, queries)
+	if err == nil && len(usages) != len(queries) {
+		err = fmt.Errorf("storage returned %d usage records for %d queries", len(usages), len(queries))
+	}
+	m.metrics.RecordStorageOperation("GetUsages", time.Since(start), err)
+
+	if err != nil {
+		m.logger.Error("failed to get usage from storage",
+			Field{"queries", len(queries)},
+			Field{"error", err},
+		)
+	}
+
+	ttl := m.usageCacheTTL()
+	for i, pending := range misses {
+		q := pending.query
+		var usage *Usage
+		if err != nil {
+			usage = m.fallbackUsage(ctx, q, err)
+			if usage == nil {
+				for _, idx := range pending.indexes {
+					results[idx].Err = err
+				}
+				continue
+			}
+		} else {
+			usage = usages[i]
+			if usage != nil {
+				m.cache.SetUsage(pending.cacheKey, usage, ttl)
+			}
+		}
+
+		usage = m.completeUsage(usage, q.UserID, q.Resource, pending.tier, q.Period)
+		for n, idx := range pending.indexes {
+			if n > 0 {
+				// Give each duplicate query its own copy
+				usageCopy := *usage
+				results[idx].Usage = &usageCopy
+				continue
+			}
+			results[idx].Usage = usage
+		}
+	}
+}
+
+// fallbackUsage returns usage from the fallback strategy, or nil if unavailable
+func (m *Manager) fallbackUsage(ctx context.Context, q UsageQuery, err error) *Usage {
+	if m.fallbackStrategy == nil || !m.fallbackStrategy.ShouldFallback(err) {
+		return nil
+	}
+	m.enterFallback(ctx, "storage_error", q.UserID, q.Resource)
+	usage, fallbackErr := m.fallbackStrategy.GetFallbackUsage(ctx, q.UserID, q.Resource, q.Period)
+	if fallbackErr != nil || usage == nil {
+		return nil
+	}
+	return usage
+}
@@ -0,0 +1,205 @@
+package goquota_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mihaimyh/goquota/pkg/goquota"
+	"github.com/mihaimyh/goquota/storage/memory"
+)
+
+// batchCountingStorage counts single and batched reads and can fail them
+type batchCountingStorage struct {
+	*memory.Storage
+	singleReads atomic.Int32
+	batchReads  atomic.Int32
+	fail        atomic.Bool
+}
+
+var errBatchRead = errors.New("batch read failed")
+
+func (s *batchCountingStorage) GetEntitlement(ctx context.Context, userID string) (*goquota.Entitlement, error) {
+	s.singleReads.Add(1)
+	return s.Storage.GetEntitlement(ctx, userID)
+}
+
+func (s *batchCountingStorage) GetUsage(ctx context.Context, userID, resource string,
+	period goquota.Period) (*goquota.Usage, error) {
+	s.singleReads.Add(1)
+	return s.Storage.GetUsage(ctx, userID, resource, period)
+}
+
+func (s *batchCountingStorage) GetEntitlements(ctx context.Context,
+	userIDs []string) (map[string]*goquota.Entitlement, error) {
+	s.batchReads.Add(1)
+	if s.fail.Load() {
+		return nil, errBatchRead
+	}
+	return s.Storage.GetEntitlements(ctx, userIDs)
+}
+
+func (s *batchCountingStorage) GetUsages(ctx context.Context,
+	queries []goquota.UsageQuery) ([]*goquota.Usage, error) {
+	s.batchReads.Add(1)
+	if s.fail.Load() {
+		return nil, errBatchRead
+	}
+	return s.Storage.GetUsages(ctx, queries)
+}
+
+// withCache enables the cache of a newEventsTestManager
+func withCache(config *goquota.Config) {
+	config.CacheConfig = &goquota.CacheConfig{Enabled: true}
+}
+
+func TestManager_GetQuotaMany(t *testing.T) {
+	storage := &batchCountingStorage{Storage: memory.New()}
+	manager := newEventsTestManager(t, storage, nil, withCache)
+	ctx := context.Background()
+
+	_ = storage.Storage.SetEntitlement(ctx, &goquota.Entitlement{
+		UserID:                "pro_user",
+		Tier:                  "pro",
+		SubscriptionStartDate: time.Now().UTC().AddDate(0, 0, -3),
+		UpdatedAt:             time.Now().UTC(),
+	})
+	if _, err := manager.Consume(ctx, "pro_user", "api_calls", 40, goquota.PeriodTypeMonthly); err != nil {
+		t.Fatalf("Consume failed: %v", err)
+	}
+	if _, err := manager.Consume(ctx, "free_user", "api_calls", 1, goquota.PeriodTypeDaily); err != nil {
+		t.Fatalf("Consume failed: %v", err)
+	}
+
+	queries := []goquota.QuotaQuery{
+		{UserID: "pro_user", Resource: "api_calls", PeriodType: goquota.PeriodTypeMonthly},
+		{UserID: "free_user", Resource: "api_calls", PeriodType: goquota.PeriodTypeDaily},
+		{UserID: "free_user", Resource: "api_calls", PeriodType: goquota.PeriodTypeMonthly},
+		{UserID: "new_user", Resource: "api_calls", PeriodType: goquota.PeriodTypeMonthly},
+		{UserID: "new_user", Resource: "api_calls", PeriodType: "weekly"},
+		{UserID: "pro_user", Resource: "api_calls", PeriodType: goquota.PeriodTypeMonthly},
+	}
+
+	// Start from a cold cache so all reads hit storage
+	freshManager := newEventsTestManager(t, storage, nil, withCache)
+	storage.singleReads.Store(0)
+	storage.batchReads.Store(0)
+
+	results, err := freshManager.GetQuotaMany(ctx, queries)
+	if err != nil {
+		t.Fatalf("GetQuotaMany failed: %v", err)
+	}
+	batchReads, singleReads := storage.batchReads.Load(), storage.singleReads.Load()
+	if len(results) != len(queries) {
+		t.Fatalf("Expected %d results, got %d", len(queries), len(results))
+	}
+
+	// Results match GetQuota
+	for i, q := range queries {
+		expected, expectedErr := manager.GetQuota(ctx, q.UserID, q.Resource, q.PeriodType)
+		if expectedErr != nil {
+			if !errors.Is(results[i].Err, expectedErr) {
+				t.Errorf("Query %d: expected error %v, got %v", i, expectedErr, results[i].Err)
+			}
+			continue
+		}
+		if results[i].Err != nil {
+			t.Errorf("Query %d: unexpected error %v", i, results[i].Err)
+			continue
+		}
+		got := results[i].Usage
+		if got.Used != expected.Used || got.Limit != expected.Limit || got.Tier != expected.Tier ||
+			!got.Period.Start.Equal(expected.Period.Start) {
+			t.Errorf("Query %d: expected %+v, got %+v", i, expected, got)
+		}
+	}
+
+	if results[0].Usage.Used != 40 || results[0].Usage.Limit != 100 {
+		t.Errorf("Expected pro usage 40/100, got %d/%d", results[0].Usage.Used, results[0].Usage.Limit)
+	}
+	if results[1].Usage.Used != 1 || results[1].Usage.Limit != 2 {
+		t.Errorf("Expected daily usage 1/2, got %d/%d", results[1].Usage.Used, results[1].Usage.Limit)
+	}
+	if !errors.Is(results[4].Err, goquota.ErrInvalidPeriod) {
+		t.Errorf("Expected ErrInvalidPeriod for weekly period, got %v", results[4].Err)
+	}
+	if results[5].Usage == results[0].Usage {
+		t.Error("Expected duplicate queries to get separate Usage values")
+	}
+
+	// One batch for entitlements and one for usage, no single reads
+	if batchReads != 2 {
+		t.Errorf("Expected 2 batch reads, got %d", batchReads)
+	}
+	if singleReads != 0 {
+		t.Errorf("Expected no single reads, got %d", singleReads)
+	}
+
+	// Repeated queries are served from the cache
+	// (only users with an entitlement have it cached)
+	storage.batchReads.Store(0)
+	if _, err := freshManager.GetQuotaMany(ctx, queries[:1]); err != nil {
+		t.Fatalf("GetQuotaMany failed: %v", err)
+	}
+	if got := storage.batchReads.Load(); got != 0 {
+		t.Errorf("Expected cached results, got %d batch reads", got)
+	}
+}
+
+func TestManager_GetQuotaMany_StorageError(t *testing.T) {
+	storage := &batchCountingStorage{Storage: memory.New()}
+	manager := newEventsTestManager(t, storage, nil, withCache)
+	storage.fail.Store(true)
+
+	results, err := manager.GetQuotaMany(context.Background(), []goquota.QuotaQuery{
+		{UserID: "user1", Resource: "api_calls", PeriodType: goquota.PeriodTypeMonthly},
+		{UserID: "user2", Resource: "api_calls", PeriodType: goquota.PeriodTypeMonthly},
+	})
+	if err != nil {
+		t.Fatalf("GetQuotaMany failed: %v", err)
+	}
+	for i, result := range results {
+		if !errors.Is(result.Err, errBatchRead) || result.Usage != nil {
+			t.Errorf("Result %d: expected storage error, got %+v", i, result)
+		}
+	}
+}
+
+func TestManager_GetQuotaMany_WithoutBatchStorage(t *testing.T) {
+	storage := memory.New()
+	manager := newEventsTestManager(t, storageOnly{storage}, nil, withCache)
+	ctx := context.Background()
+
+	if _, err := manager.Consume(ctx, "user1", "api_calls", 7, goquota.PeriodTypeMonthly); err != nil {
+		t.Fatalf("Consume failed: %v", err)
+	}
+
+	results, err := manager.GetQuotaMany(ctx, []goquota.QuotaQuery{
+		{UserID: "user1", Resource: "api_calls", PeriodType: goquota.PeriodTypeMonthly},
+		{UserID: "user2", Resource: "api_calls", PeriodType: goquota.PeriodTypeMonthly},
+	})
+	if err != nil {
+		t.Fatalf("GetQuotaMany failed: %v", err)
+	}
+	if results[0].Err != nil || results[0].Usage.Used != 7 {
+		t.Errorf("Expected 7 used for user1, got %+v", results[0])
+	}
+	if results[1].Err != nil || results[1].Usage.Used != 0 || results[1].Usage.Limit != 10 {
+		t.Errorf("Expected zero usage for user2, got %+v", results[1])
+	}
+}
+
+func TestManager_GetQuotaMany_CanceledContext(t *testing.T) {
+	manager := newEventsTestManager(t, memory.New(), nil, withCache)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := manager.GetQuotaMany(ctx, []goquota.QuotaQuery{
+		{UserID: "user1", Resource: "api_calls", PeriodType: goquota.PeriodTypeMonthly},
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
@@ -1,7 +1,9 @@
 package goquota
 
 import (
+	"hash/maphash"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -45,18 +47,6 @@ type CacheStats struct {
 	Size              int
 }
 
-// cacheEntry wraps a cached value with expiration time and access time for LRU
-type cacheEntry struct {
-	value      interface{}
-	expiration time.Time
-	accessTime time.Time // For LRU eviction
-	sequence   int64     // For tiebreaking when access times are equal
-}
-
-func (e *cacheEntry) isExpired() bool {
-	return time.Now().After(e.expiration)
-}
-
 // NoopCache is a cache implementation that does nothing
 // Used when caching is disabled
 type NoopCache struct{}
@@ -88,57 +78,117 @@ func (c *NoopCache) Stats() CacheStats {
 	return CacheStats{}
 }
 
-// LRUCache implements Cache using an in-memory LRU cache with TTL support
+const (
+	defaultCacheMaxEntitlements = 1000
+	defaultCacheMaxUsage        = 10000
+
+	// maxCacheShards bounds the number of shards chosen by default
+	maxCacheShards = 64
+	// minCacheShardCapacity is the smallest shard chosen by default. Small caches use a single
+	// shard, so their eviction order is exactly LRU.
+	minCacheShardCapacity = 128
+)
+
+// LRUCacheConfig configures an LRUCache
+type LRUCacheConfig struct {
+	// MaxEntitlements is the maximum number of entitlements to cache (default: 1000)
+	MaxEntitlements int
+
+	// MaxUsage is the maximum number of usage records to cache (default: 10000)
+	MaxUsage int
+
+	// Shards is the number of independently locked shards per entry type, rounded down to
+	// a power of two and to at most one shard per entry (default: one shard per 128 entries,
+	// up to 64)
+	Shards int
+
+	// CleanupInterval is how often a background goroutine removes expired entries
+	// (0: expired entries are only removed when they are read or evicted). Call Close to stop it.
+	CleanupInterval time.Duration
+}
+
+// LRUCache implements Cache using an in-memory LRU cache with TTL support.
+//
+// Entries are spread over shards by key hash. Each shard has its own lock and keeps its
+// entries in a doubly-linked list ordered by use, so lookups, inserts and evictions are O(1).
+// Eviction is LRU within a shard: when a shard is full, its least recently used entry is evicted.
 type LRUCache struct {
-	entitlements    map[string]*cacheEntry
-	usage           map[string]*cacheEntry
-	maxEntitlements int
-	maxUsage        int
-	mu              sync.RWMutex
-	entitlementHits int64
-	entitlementMiss int64
-	usageHits       int64
-	usageMisses     int64
-	evictions       int64
-	sequence        int64 // For tiebreaking when access times are equal
+	entitlements *lruShards[*Entitlement]
+	usage        *lruShards[*Usage]
+
+	entitlementHits   atomic.Int64
+	entitlementMisses atomic.Int64
+	usageHits         atomic.Int64
+	usageMisses       atomic.Int64
+	evictions         atomic.Int64
+
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
 }
 
 // NewLRUCache creates a new LRU cache with specified maximum sizes
 func NewLRUCache(maxEntitlements, maxUsage int) *LRUCache {
-	if maxEntitlements <= 0 {
-		maxEntitlements = 1000 // default
+	return NewLRUCacheWithConfig(LRUCacheConfig{MaxEntitlements: maxEntitlements, MaxUsage: maxUsage})
+}
+
+// NewLRUCacheWithConfig creates a new LRU cache. With a CleanupInterval, call Close to stop
+// the background cleanup.
+func NewLRUCacheWithConfig(config LRUCacheConfig) *LRUCache {
+	if config.MaxEntitlements <= 0 {
+		config.MaxEntitlements = defaultCacheMaxEntitlements
 	}
-	if maxUsage <= 0 {
-		maxUsage = 10000 // default
+	if config.MaxUsage <= 0 {
+		config.MaxUsage = defaultCacheMaxUsage
 	}
 
-	return &LRUCache{
-		entitlements:    make(map[string]*cacheEntry, maxEntitlements),
-		usage:           make(map[string]*cacheEntry, maxUsage),
-		maxEntitlements: maxEntitlements,
-		maxUsage:        maxUsage,
+	c := &LRUCache{}
+	c.entitlements = newLRUShards[*Entitlement](config.MaxEntitlements, config.Shards, &c.evictions)
+	c.usage = newLRUShards[*Usage](config.MaxUsage, config.Shards, &c.evictions)
+	if config.CleanupInterval > 0 {
+		c.stop = make(chan struct{})
+		c.done = make(chan struct{})
+		go c.cleanup(config.CleanupInterval)
 	}
+	return c
 }
 
-func (c *LRUCache) GetEntitlement(userID string) (*Entitlement, bool) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	entry, exists := c.entitlements[userID]
-	if !exists || entry.isExpired() {
-		c.entitlementMiss++
-		return nil, false
+// cleanup periodically removes expired entries until Close is called
+func (c *LRUCache) cleanup(interval time.Duration) {
+	defer close(c.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.stop:
+			return
+		case now := <-ticker.C:
+			c.entitlements.removeExpired(now)
+			c.usage.removeExpired(now)
+		}
 	}
+}
 
-	// Update access time for LRU
-	entry.accessTime = time.Now()
+// Close stops the background cleanup. The cache remains usable.
+func (c *LRUCache) Close() error {
+	if c.stop != nil {
+		c.closeOnce.Do(func() {
+			close(c.stop)
+			<-c.done
+		})
+	}
+	return nil
+}
 
-	c.entitlementHits++
-	// Return a copy to prevent external modifications
-	ent, ok := entry.value.(*Entitlement)
+func (c *LRUCache) GetEntitlement(userID string) (*Entitlement, bool) {
+	ent, ok := c.entitlements.get(userID, time.Now())
 	if !ok {
+		c.entitlementMisses.Add(1)
 		return nil, false
 	}
+	c.entitlementHits.Add(1)
+
+	// Return a copy to prevent external modifications
 	return &Entitlement{
 		UserID:                ent.UserID,
 		Tier:                  ent.Tier,
@@ -149,69 +199,22 @@ func (c *LRUCache) GetEntitlement(userID string) (*Entitlement, bool) {
 }
 
 func (c *LRUCache) SetEntitlement(userID string, ent *Entitlement, ttl time.Duration) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	now := time.Now()
-	_, exists := c.entitlements[userID]
-
-	// Evict if at capacity and entry doesn't exist
-	if len(c.entitlements) >= c.maxEntitlements && !exists {
-		// Evict least recently used (oldest accessTime, then oldest sequence)
-		var oldestKey string
-		var oldestTime time.Time
-		var oldestSeq int64
-		first := true
-		for key, entry := range c.entitlements {
-			if first || entry.accessTime.Before(oldestTime) ||
-				(entry.accessTime.Equal(oldestTime) && entry.sequence < oldestSeq) {
-				oldestKey = key
-				oldestTime = entry.accessTime
-				oldestSeq = entry.sequence
-				first = false
-			}
-		}
-		if oldestKey != "" {
-			delete(c.entitlements, oldestKey)
-			c.evictions++
-		}
-	}
-
-	seq := c.sequence
-	c.sequence++
-	c.entitlements[userID] = &cacheEntry{
-		value:      ent,
-		expiration: now.Add(ttl),
-		accessTime: now,
-		sequence:   seq,
-	}
+	c.entitlements.set(userID, ent, time.Now().Add(ttl))
 }
 
 func (c *LRUCache) InvalidateEntitlement(userID string) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-	delete(c.entitlements, userID)
+	c.entitlements.remove(userID)
 }
 
 func (c *LRUCache) GetUsage(key string) (*Usage, bool) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	entry, exists := c.usage[key]
-	if !exists || entry.isExpired() {
-		c.usageMisses++
+	usage, ok := c.usage.get(key, time.Now())
+	if !ok {
+		c.usageMisses.Add(1)
 		return nil, false
 	}
+	c.usageHits.Add(1)
 
-	// Update access time for LRU
-	entry.accessTime = time.Now()
-
-	c.usageHits++
 	// Return a copy to prevent external modifications
-	usage, ok := entry.value.(*Usage)
-	if !ok {
-		return nil, false
-	}
 	return &Usage{
 		UserID:    usage.UserID,
 		Resource:  usage.Resource,
@@ -224,67 +227,220 @@ func (c *LRUCache) GetUsage(key string) (*Usage, bool) {
 }
 
 func (c *LRUCache) SetUsage(key string, usage *Usage, ttl time.Duration) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	now := time.Now()
-	_, exists := c.usage[key]
-
-	// Evict if at capacity and entry doesn't exist
-	if len(c.usage) >= c.maxUsage && !exists {
-		// Evict least recently used (oldest accessTime, then oldest sequence)
-		var oldestKey string
-		var oldestTime time.Time
-		var oldestSeq int64
-		first := true
-		for k, entry := range c.usage {
-			if first || entry.accessTime.Before(oldestTime) ||
-				(entry.accessTime.Equal(oldestTime) && entry.sequence < oldestSeq) {
-				oldestKey = k
-				oldestTime = entry.accessTime
-				oldestSeq = entry.sequence
-				first = false
-			}
-		}
-		if oldestKey != "" {
-			delete(c.usage, oldestKey)
-			c.evictions++
+	c.usage.set(key, usage, time.Now().Add(ttl))
+}
+
+func (c *LRUCache) InvalidateUsage(key string) {
+	c.usage.remove(key)
+}
+
+func (c *LRUCache) Clear() {
+	c.entitlements.clear()
+	c.usage.clear()
+}
+
+func (c *LRUCache) Stats() CacheStats {
+	return CacheStats{
+		EntitlementHits:   c.entitlementHits.Load(),
+		EntitlementMisses: c.entitlementMisses.Load(),
+		UsageHits:         c.usageHits.Load(),
+		UsageMisses:       c.usageMisses.Load(),
+		Evictions:         c.evictions.Load(),
+		Size:              c.entitlements.len() + c.usage.len(),
+	}
+}
+
+// lruNode is an entry of an lruShard
+type lruNode[V any] struct {
+	key        string
+	value      V
+	expiration time.Time
+	prev, next *lruNode[V]
+}
+
+// lruShard is an LRU map: a map for lookups and a circular doubly-linked list ordered
+// from most (root.next) to least (root.prev) recently used
+type lruShard[V any] struct {
+	mu       sync.Mutex
+	items    map[string]*lruNode[V]
+	root     lruNode[V]
+	capacity int
+}
+
+func newLRUShard[V any](capacity int) *lruShard[V] {
+	s := &lruShard[V]{items: make(map[string]*lruNode[V]), capacity: capacity}
+	s.root.next = &s.root
+	s.root.prev = &s.root
+	return s
+}
+
+func (s *lruShard[V]) unlink(node *lruNode[V]) {
+	node.prev.next = node.next
+	node.next.prev = node.prev
+	node.prev, node.next = nil, nil
+}
+
+func (s *lruShard[V]) pushFront(node *lruNode[V]) {
+	node.prev = &s.root
+	node.next = s.root.next
+	s.root.next.prev = node
+	s.root.next = node
+}
+
+func (s *lruShard[V]) get(key string, now time.Time) (V, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	node, ok := s.items[key]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	if now.After(node.expiration) {
+		s.unlink(node)
+		delete(s.items, key)
+		var zero V
+		return zero, false
+	}
+	s.unlink(node)
+	s.pushFront(node)
+	return node.value, true
+}
+
+// set stores a value, evicting the least recently used entry if the shard is full.
+// It reports whether an entry was evicted.
+func (s *lruShard[V]) set(key string, value V, expiration time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if node, ok := s.items[key]; ok {
+		node.value = value
+		node.expiration = expiration
+		s.unlink(node)
+		s.pushFront(node)
+		return false
+	}
+
+	evicted := false
+	if len(s.items) >= s.capacity {
+		oldest := s.root.prev
+		s.unlink(oldest)
+		delete(s.items, oldest.key)
+		evicted = true
+	}
+	node := &lruNode[V]{key: key, value: value, expiration: expiration}
+	s.items[key] = node
+	s.pushFront(node)
+	return evicted
+}
+
+func (s *lruShard[V]) remove(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if node, ok := s.items[key]; ok {
+		s.unlink(node)
+		delete(s.items, key)
+	}
+}
+
+func (s *lruShard[V]) removeExpired(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, node := range s.items {
+		if now.After(node.expiration) {
+			s.unlink(node)
+			delete(s.items, key)
 		}
 	}
+}
+
+func (s *lruShard[V]) clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.items = make(map[string]*lruNode[V])
+	s.root.next = &s.root
+	s.root.prev = &s.root
+}
+
+func (s *lruShard[V]) len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.items)
+}
+
+// lruShards spreads the entries of one type over lruShards by key hash
+type lruShards[V any] struct {
+	shards    []*lruShard[V]
+	mask      uint64
+	seed      maphash.Seed
+	evictions *atomic.Int64
+}
+
+func newLRUShards[V any](capacity, shards int, evictions *atomic.Int64) *lruShards[V] {
+	count := cacheShardCount(capacity, shards)
+	perShard := (capacity + count - 1) / count
+	s := &lruShards[V]{
+		shards:    make([]*lruShard[V], count),
+		mask:      uint64(count - 1),
+		seed:      maphash.MakeSeed(),
+		evictions: evictions,
+	}
+	for i := range s.shards {
+		s.shards[i] = newLRUShard[V](perShard)
+	}
+	return s
+}
 
-	seq := c.sequence
-	c.sequence++
-	c.usage[key] = &cacheEntry{
-		value:      usage,
-		expiration: now.Add(ttl),
-		accessTime: now,
-		sequence:   seq,
+// cacheShardCount returns the number of shards for capacity entries: a power of two, at most capacity
+func cacheShardCount(capacity, shards int) int {
+	if shards <= 0 {
+		shards = min(capacity/minCacheShardCapacity, maxCacheShards)
+	}
+	shards = min(shards, capacity)
+	count := 1
+	for count*2 <= shards {
+		count *= 2
 	}
+	return count
 }
 
-func (c *LRUCache) InvalidateUsage(key string) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-	delete(c.usage, key)
+func (s *lruShards[V]) shard(key string) *lruShard[V] {
+	return s.shards[maphash.String(s.seed, key)&s.mask]
 }
 
-func (c *LRUCache) Clear() {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-	c.entitlements = make(map[string]*cacheEntry, c.maxEntitlements)
-	c.usage = make(map[string]*cacheEntry, c.maxUsage)
+func (s *lruShards[V]) get(key string, now time.Time) (V, bool) {
+	return s.shard(key).get(key, now)
 }
 
-func (c *LRUCache) Stats() CacheStats {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
+func (s *lruShards[V]) set(key string, value V, expiration time.Time) {
+	if s.shard(key).set(key, value, expiration) {
+		s.evictions.Add(1)
+	}
+}
 
-	return CacheStats{
-		EntitlementHits:   c.entitlementHits,
-		EntitlementMisses: c.entitlementMiss,
-		UsageHits:         c.usageHits,
-		UsageMisses:       c.usageMisses,
-		Evictions:         c.evictions,
-		Size:              len(c.entitlements) + len(c.usage),
+func (s *lruShards[V]) remove(key string) {
+	s.shard(key).remove(key)
+}
+
+func (s *lruShards[V]) removeExpired(now time.Time) {
+	for _, shard := range s.shards {
+		shard.removeExpired(now)
+	}
+}
+
+func (s *lruShards[V]) clear() {
+	for _, shard := range s.shards {
+		shard.clear()
+	}
+}
+
+func (s *lruShards[V]) len() int {
+	n := 0
+	for _, shard := range s.shards {
+		n += shard.len()
 	}
+	return n
 }
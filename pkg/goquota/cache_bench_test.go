@@ -0,0 +1,137 @@
+package goquota
+
+import (
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// scanLRUCache is the previous LRUCache implementation, kept to benchmark against:
+// a single mutex and a full scan of the map to find the entry to evict.
+type scanLRUCache struct {
+	mu       sync.Mutex
+	usage    map[string]*scanCacheEntry
+	maxUsage int
+	sequence int64
+}
+
+type scanCacheEntry struct {
+	value      *Usage
+	expiration time.Time
+	accessTime time.Time
+	sequence   int64
+}
+
+func newScanLRUCache(maxUsage int) *scanLRUCache {
+	return &scanLRUCache{usage: make(map[string]*scanCacheEntry, maxUsage), maxUsage: maxUsage}
+}
+
+func (c *scanLRUCache) GetUsage(key string) (*Usage, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, exists := c.usage[key]
+	if !exists || time.Now().After(entry.expiration) {
+		return nil, false
+	}
+	entry.accessTime = time.Now()
+	usage := *entry.value
+	return &usage, true
+}
+
+func (c *scanLRUCache) SetUsage(key string, usage *Usage, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if _, exists := c.usage[key]; len(c.usage) >= c.maxUsage && !exists {
+		var oldestKey string
+		var oldestTime time.Time
+		var oldestSeq int64
+		first := true
+		for k, entry := range c.usage {
+			if first || entry.accessTime.Before(oldestTime) ||
+				(entry.accessTime.Equal(oldestTime) && entry.sequence < oldestSeq) {
+				oldestKey, oldestTime, oldestSeq, first = k, entry.accessTime, entry.sequence, false
+			}
+		}
+		delete(c.usage, oldestKey)
+	}
+	c.sequence++
+	c.usage[key] = &scanCacheEntry{value: usage, expiration: now.Add(ttl), accessTime: now, sequence: c.sequence}
+}
+
+// usageCache is the part of Cache the benchmarks exercise
+type usageCache interface {
+	GetUsage(key string) (*Usage, bool)
+	SetUsage(key string, usage *Usage, ttl time.Duration)
+}
+
+var usageCacheImplementations = []struct {
+	name string
+	new  func(capacity int) usageCache
+}{
+	{"scan", func(capacity int) usageCache { return newScanLRUCache(capacity) }},
+	{"sharded", func(capacity int) usageCache { return NewLRUCache(0, capacity) }},
+}
+
+func benchmarkKeys(n int) []string {
+	keys := make([]string, n)
+	for i := range keys {
+		keys[i] = "user" + strconv.Itoa(i) + ":api_calls:2026-10-01"
+	}
+	return keys
+}
+
+// BenchmarkLRUCache_SetAtCapacity inserts new keys into a full cache, so every insert evicts
+func BenchmarkLRUCache_SetAtCapacity(b *testing.B) {
+	for _, capacity := range []int{1000, 100000} {
+		keys := benchmarkKeys(2 * capacity)
+		for _, impl := range usageCacheImplementations {
+			b.Run(impl.name+"/"+strconv.Itoa(capacity), func(b *testing.B) {
+				cache := impl.new(capacity)
+				usage := &Usage{Used: 1}
+				for _, key := range keys[:capacity] {
+					cache.SetUsage(key, usage, time.Hour)
+				}
+
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					// The first capacity keys are cached: cycle through the others first
+					cache.SetUsage(keys[(capacity+i)%len(keys)], usage, time.Hour)
+				}
+			})
+		}
+	}
+}
+
+// BenchmarkLRUCache_Parallel mixes reads (90%) and inserts of new keys (10%) from all CPUs
+// on a full cache of 100k usage records
+func BenchmarkLRUCache_Parallel(b *testing.B) {
+	const capacity = 100000
+	keys := benchmarkKeys(2 * capacity)
+	for _, impl := range usageCacheImplementations {
+		b.Run(impl.name, func(b *testing.B) {
+			cache := impl.new(capacity)
+			usage := &Usage{Used: 1}
+			for _, key := range keys[:capacity] {
+				cache.SetUsage(key, usage, time.Hour)
+			}
+
+			var next atomic.Int64
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for i := int(next.Add(1)); pb.Next(); i += 7 {
+					key := keys[i%len(keys)]
+					if i%10 == 0 {
+						cache.SetUsage(key, usage, time.Hour)
+					} else {
+						cache.GetUsage(key)
+					}
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,338 @@
+package goquota
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const defaultSharedCacheTimeout = 100 * time.Millisecond
+
+// Kinds of cache invalidations
+const (
+	CacheInvalidationEntitlement = "entitlement"
+	CacheInvalidationUsage       = "usage"
+	CacheInvalidationClear       = "clear"
+	// CacheInvalidationOverrides tells Managers with OverrideConfig.Shared to reload overrides
+	CacheInvalidationOverrides = "overrides"
+	// CacheInvalidationQuotaLeaseDiscard tells Managers to drop their quota lease of a usage key
+	// whose usage was overwritten
+	CacheInvalidationQuotaLeaseDiscard = "quota_lease_discard"
+	// CacheInvalidationQuotaLeaseReturn tells Managers to return their quota lease of a usage key
+	// whose usage or limit changed, so that the next lease starts from storage
+	CacheInvalidationQuotaLeaseReturn = "quota_lease_return"
+)
+
+// Key prefixes of DistributedCache entries in the SharedCacheStore
+const (
+	sharedCacheEntitlementPrefix = "entitlement:"
+	sharedCacheUsagePrefix       = "usage:"
+)
+
+// CacheInvalidation is a cache invalidation broadcast between instances
+type CacheInvalidation struct {
+	// Source identifies the DistributedCache that published the invalidation
+	Source string `json:"source"`
+	// Kind is CacheInvalidationEntitlement, CacheInvalidationUsage, CacheInvalidationClear,
+	// CacheInvalidationOverrides or a CacheInvalidationQuotaLease kind
+	Kind string `json:"kind"`
+	// Key is the user ID of an entitlement or the key of a usage record or quota lease
+	Key string `json:"key,omitempty"`
+}
+
+// InvalidationBus broadcasts cache invalidations between instances, e.g. Redis pub/sub
+type InvalidationBus interface {
+	// Publish broadcasts an invalidation to every subscriber
+	Publish(ctx context.Context, invalidation *CacheInvalidation) error
+
+	// Subscribe calls handler for every published invalidation until unsubscribe is called
+	Subscribe(handler func(invalidation *CacheInvalidation)) (unsubscribe func(), err error)
+}
+
+// MemoryInvalidationBus is an in-process InvalidationBus, for tests and for several
+// Managers in one process. Handlers are called synchronously by Publish.
+type MemoryInvalidationBus struct {
+	mu       sync.RWMutex
+	handlers map[int]func(invalidation *CacheInvalidation)
+	nextID   int
+}
+
+// NewMemoryInvalidationBus creates an in-process invalidation bus
+func NewMemoryInvalidationBus() *MemoryInvalidationBus {
+	return &MemoryInvalidationBus{handlers: make(map[int]func(invalidation *CacheInvalidation))}
+}
+
+// Publish implements InvalidationBus
+func (b *MemoryInvalidationBus) Publish(_ context.Context, invalidation *CacheInvalidation) error {
+	b.mu.RLock()
+	handlers := make([]func(invalidation *CacheInvalidation), 0, len(b.handlers))
+	for _, handler := range b.handlers {
+		handlers = append(handlers, handler)
+	}
+	b.mu.RUnlock()
+
+	for _, handler := range handlers {
+		invalidationCopy := *invalidation
+		handler(&invalidationCopy)
+	}
+	return nil
+}
+
+// Subscribe implements InvalidationBus
+func (b *MemoryInvalidationBus) Subscribe(handler func(invalidation *CacheInvalidation)) (func(), error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	id := b.nextID
+	b.nextID++
+	b.handlers[id] = handler
+
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		delete(b.handlers, id)
+	}, nil
+}
+
+// DistributedCacheConfig configures a DistributedCache
+type DistributedCacheConfig struct {
+	// Local is the per-instance (L1) cache (default: NewLRUCache with default sizes)
+	Local Cache
+
+	// Shared is the cache tier shared by all instances (L2), e.g. Redis storage (optional)
+	Shared SharedCacheStore
+
+	// Bus broadcasts invalidations to the other instances (optional)
+	Bus InvalidationBus
+
+	// Timeout bounds each call to Shared and Bus (default: 100ms)
+	Timeout time.Duration
+
+	// Logger logs failures of Shared and Bus (optional)
+	Logger Logger
+}
+
+// sharedCacheEntry is the value of a DistributedCache entry in the SharedCacheStore
+type sharedCacheEntry struct {
+	ExpiresAt   time.Time    `json:"expires_at"`
+	Entitlement *Entitlement `json:"entitlement,omitempty"`
+	Usage       *Usage       `json:"usage,omitempty"`
+}
+
+// DistributedCache is a Cache with a per-instance L1 cache and an optional L2 cache shared
+// between instances. Invalidations are applied to both tiers and broadcast on the
+// InvalidationBus, so every instance drops the entry from its L1 cache, e.g. after
+// Manager.SetEntitlement on another instance.
+//
+// The shared tier and the bus are best effort: their failures are logged and the entry is
+// still cached locally until its TTL expires. Clear only clears the L1 caches.
+type DistributedCache struct {
+	local       Cache
+	shared      SharedCacheStore
+	bus         InvalidationBus
+	timeout     time.Duration
+	logger      Logger
+	source      string
+	unsubscribe func()
+}
+
+// NewDistributedCache creates a distributed cache and subscribes it to config.Bus.
+// Call Close to unsubscribe.
+func NewDistributedCache(config DistributedCacheConfig) (*DistributedCache, error) {
+	c := &DistributedCache{
+		local:   config.Local,
+		shared:  config.Shared,
+		bus:     config.Bus,
+		timeout: config.Timeout,
+		logger:  config.Logger,
+		source:  newEventID(),
+	}
+	if c.local == nil {
+		c.local = NewLRUCache(0, 0)
+	}
+	if c.timeout <= 0 {
+		c.timeout = defaultSharedCacheTimeout
+	}
+	if c.logger == nil {
+		c.logger = &NoopLogger{}
+	}
+	if c.bus != nil {
+		unsubscribe, err := c.bus.Subscribe(c.handleInvalidation)
+		if err != nil {
+			return nil, fmt.Errorf("failed to subscribe to cache invalidations: %w", err)
+		}
+		c.unsubscribe = unsubscribe
+	}
+	return c, nil
+}
+
+// Close unsubscribes the cache from the invalidation bus and closes the L1 cache
+func (c *DistributedCache) Close() error {
+	if c.unsubscribe != nil {
+		c.unsubscribe()
+	}
+	if local, ok := c.local.(interface{ Close() error }); ok {
+		return local.Close()
+	}
+	return nil
+}
+
+// handleInvalidation applies an invalidation published by another instance to the L1 cache
+func (c *DistributedCache) handleInvalidation(invalidation *CacheInvalidation) {
+	if invalidation.Source == c.source {
+		return
+	}
+	switch invalidation.Kind {
+	case CacheInvalidationEntitlement:
+		c.local.InvalidateEntitlement(invalidation.Key)
+	case CacheInvalidationUsage:
+		c.local.InvalidateUsage(invalidation.Key)
+	case CacheInvalidationClear:
+		c.local.Clear()
+	}
+}
+
+// GetEntitlement implements Cache
+func (c *DistributedCache) GetEntitlement(userID string) (*Entitlement, bool) {
+	if ent, ok := c.local.GetEntitlement(userID); ok {
+		return ent, true
+	}
+	entry, ok := c.getShared(sharedCacheEntitlementPrefix + userID)
+	if !ok || entry.Entitlement == nil {
+		return nil, false
+	}
+	c.local.SetEntitlement(userID, entry.Entitlement, time.Until(entry.ExpiresAt))
+	return entry.Entitlement, true
+}
+
+// SetEntitlement implements Cache
+func (c *DistributedCache) SetEntitlement(userID string, ent *Entitlement, ttl time.Duration) {
+	c.local.SetEntitlement(userID, ent, ttl)
+	c.setShared(sharedCacheEntitlementPrefix+userID, &sharedCacheEntry{
+		ExpiresAt:   time.Now().Add(ttl),
+		Entitlement: ent,
+	}, ttl)
+}
+
+// InvalidateEntitlement implements Cache
+func (c *DistributedCache) InvalidateEntitlement(userID string) {
+	c.local.InvalidateEntitlement(userID)
+	c.invalidate(CacheInvalidationEntitlement, userID, sharedCacheEntitlementPrefix+userID)
+}
+
+// GetUsage implements Cache
+func (c *DistributedCache) GetUsage(key string) (*Usage, bool) {
+	if usage, ok := c.local.GetUsage(key); ok {
+		return usage, true
+	}
+	entry, ok := c.getShared(sharedCacheUsagePrefix + key)
+	if !ok || entry.Usage == nil {
+		return nil, false
+	}
+	c.local.SetUsage(key, entry.Usage, time.Until(entry.ExpiresAt))
+	return entry.Usage, true
+}
+
+// SetUsage implements Cache
+func (c *DistributedCache) SetUsage(key string, usage *Usage, ttl time.Duration) {
+	c.local.SetUsage(key, usage, ttl)
+	c.setShared(sharedCacheUsagePrefix+key, &sharedCacheEntry{
+		ExpiresAt: time.Now().Add(ttl),
+		Usage:     usage,
+	}, ttl)
+}
+
+// InvalidateUsage implements Cache
+func (c *DistributedCache) InvalidateUsage(key string) {
+	c.local.InvalidateUsage(key)
+	c.invalidate(CacheInvalidationUsage, key, sharedCacheUsagePrefix+key)
+}
+
+// InvalidateLocalUsage removes usage from the L1 cache of this instance and from the shared
+// tier without broadcasting: other instances serve their cached copy until it expires.
+// The Manager uses it after consumption, which is too frequent to broadcast.
+func (c *DistributedCache) InvalidateLocalUsage(key string) {
+	c.local.InvalidateUsage(key)
+	if c.shared == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+	if err := c.shared.DeleteCacheEntries(ctx, sharedCacheUsagePrefix+key); err != nil {
+		c.logger.Warn("failed to invalidate shared cache", Field{"key", key}, Field{"error", err})
+	}
+}
+
+// Clear implements Cache. It clears the L1 cache of every instance; the shared tier
+// expires on its own.
+func (c *DistributedCache) Clear() {
+	c.local.Clear()
+	c.invalidate(CacheInvalidationClear, "", "")
+}
+
+// Stats implements Cache. It returns the statistics of the L1 cache.
+func (c *DistributedCache) Stats() CacheStats {
+	return c.local.Stats()
+}
+
+func (c *DistributedCache) getShared(key string) (*sharedCacheEntry, bool) {
+	if c.shared == nil {
+		return nil, false
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	data, ok, err := c.shared.GetCacheEntry(ctx, key)
+	if err != nil {
+		c.logger.Warn("failed to read shared cache", Field{"key", key}, Field{"error", err})
+		return nil, false
+	}
+	if !ok {
+		return nil, false
+	}
+	var entry sharedCacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		c.logger.Warn("invalid shared cache entry", Field{"key", key}, Field{"error", err})
+		return nil, false
+	}
+	if !time.Now().Before(entry.ExpiresAt) {
+		return nil, false
+	}
+	return &entry, true
+}
+
+func (c *DistributedCache) setShared(key string, entry *sharedCacheEntry, ttl time.Duration) {
+	if c.shared == nil || ttl <= 0 {
+		return
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		c.logger.Warn("failed to encode shared cache entry", Field{"key", key}, Field{"error", err})
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+	if err := c.shared.SetCacheEntry(ctx, key, data, ttl); err != nil {
+		c.logger.Warn("failed to write shared cache", Field{"key", key}, Field{"error", err})
+	}
+}
+
+// invalidate removes an entry from the shared tier and broadcasts the invalidation
+func (c *DistributedCache) invalidate(kind, key, sharedKey string) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	if c.shared != nil && sharedKey != "" {
+		if err := c.shared.DeleteCacheEntries(ctx, sharedKey); err != nil {
+			c.logger.Warn("failed to invalidate shared cache", Field{"key", sharedKey}, Field{"error", err})
+		}
+	}
+	if c.bus != nil {
+		err := c.bus.Publish(ctx, &CacheInvalidation{Source: c.source, Kind: kind, Key: key})
+		if err != nil {
+			c.logger.Warn("failed to publish cache invalidation",
+				Field{"kind", kind}, Field{"key", key}, Field{"error", err})
+		}
+	}
+}
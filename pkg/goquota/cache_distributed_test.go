@@ -0,0 +1,195 @@
+package goquota_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mihaimyh/goquota/pkg/goquota"
+	"github.com/mihaimyh/goquota/storage/memory"
+)
+
+// newDistributedCacheTestManager creates an instance with a warm-for-a-minute cache,
+// sharing storage, the shared cache tier and the invalidation bus with the other instances
+func newDistributedCacheTestManager(t *testing.T, storage goquota.Storage, shared goquota.SharedCacheStore,
+	bus goquota.InvalidationBus) *goquota.Manager {
+	t.Helper()
+	return newEventsTestManager(t, storage, nil, func(c *goquota.Config) {
+		c.CacheConfig = &goquota.CacheConfig{
+			Enabled:         true,
+			EntitlementTTL:  time.Minute,
+			UsageTTL:        time.Minute,
+			SharedStore:     shared,
+			InvalidationBus: bus,
+		}
+	})
+}
+
+func TestManager_DistributedCacheInvalidation(t *testing.T) {
+	ctx := context.Background()
+	storage := memory.New()
+	shared := memory.New()
+	bus := goquota.NewMemoryInvalidationBus()
+	podA := newDistributedCacheTestManager(t, storage, shared, bus)
+	podB := newDistributedCacheTestManager(t, storage, shared, bus)
+
+	getQuota := func(periodType goquota.PeriodType) *goquota.Usage {
+		t.Helper()
+		quota, err := podB.GetQuota(ctx, "user1", "api_calls", periodType)
+		if err != nil {
+			t.Fatalf("GetQuota failed: %v", err)
+		}
+		return quota
+	}
+
+	// SetEntitlement
+	start := time.Now().UTC().Add(-24 * time.Hour)
+	if err := podA.SetEntitlement(ctx, &goquota.Entitlement{
+		UserID: "user1", Tier: "free", SubscriptionStartDate: start, UpdatedAt: time.Now().UTC(),
+	}); err != nil {
+		t.Fatalf("SetEntitlement failed: %v", err)
+	}
+	if quota := getQuota(goquota.PeriodTypeMonthly); quota.Limit != 10 {
+		t.Fatalf("Expected free limit 10, got %d", quota.Limit)
+	}
+	if err := podA.SetEntitlement(ctx, &goquota.Entitlement{
+		UserID: "user1", Tier: "pro", SubscriptionStartDate: start, UpdatedAt: time.Now().UTC(),
+	}); err != nil {
+		t.Fatalf("SetEntitlement failed: %v", err)
+	}
+	if ent, err := podB.GetEntitlement(ctx, "user1"); err != nil || ent.Tier != "pro" {
+		t.Errorf("Expected pod B to see tier pro, got %+v, %v", ent, err)
+	}
+
+	// SetUsage
+	if err := podA.SetUsage(ctx, "user1", "api_calls", goquota.PeriodTypeMonthly, 3); err != nil {
+		t.Fatalf("SetUsage failed: %v", err)
+	}
+	if quota := getQuota(goquota.PeriodTypeMonthly); quota.Used != 3 {
+		t.Fatalf("Expected usage 3, got %d", quota.Used)
+	}
+	if err := podA.SetUsage(ctx, "user1", "api_calls", goquota.PeriodTypeMonthly, 7); err != nil {
+		t.Fatalf("SetUsage failed: %v", err)
+	}
+	if quota := getQuota(goquota.PeriodTypeMonthly); quota.Used != 7 {
+		t.Errorf("Expected pod B to see usage 7, got %d", quota.Used)
+	}
+
+	// ApplyTierChange
+	before := getQuota(goquota.PeriodTypeMonthly)
+	if err := podA.ApplyTierChange(ctx, "user1", "pro", "free", "api_calls"); err != nil {
+		t.Fatalf("ApplyTierChange failed: %v", err)
+	}
+	after := getQuota(goquota.PeriodTypeMonthly)
+	if after.Limit == before.Limit || after.Limit > 7+10 {
+		t.Errorf("Expected pod B to see the prorated free limit, got %d (was %d)", after.Limit, before.Limit)
+	}
+
+	// TopUpLimit
+	if err := podA.TopUpLimit(ctx, "user1", "api_calls", 5); err != nil {
+		t.Fatalf("TopUpLimit failed: %v", err)
+	}
+	if quota := getQuota(goquota.PeriodTypeForever); quota.Limit != 5 {
+		t.Fatalf("Expected forever limit 5, got %d", quota.Limit)
+	}
+	if err := podA.TopUpLimit(ctx, "user1", "api_calls", 5); err != nil {
+		t.Fatalf("TopUpLimit failed: %v", err)
+	}
+	if quota := getQuota(goquota.PeriodTypeForever); quota.Limit != 10 {
+		t.Errorf("Expected pod B to see forever limit 10, got %d", quota.Limit)
+	}
+}
+
+// countingBus counts the invalidations published by kind
+type countingBus struct {
+	*goquota.MemoryInvalidationBus
+	mu        sync.Mutex
+	published map[string]int
+}
+
+func (b *countingBus) Publish(ctx context.Context, invalidation *goquota.CacheInvalidation) error {
+	b.mu.Lock()
+	b.published[invalidation.Kind]++
+	b.mu.Unlock()
+	return b.MemoryInvalidationBus.Publish(ctx, invalidation)
+}
+
+func (b *countingBus) count(kind string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.published[kind]
+}
+
+func TestManager_DistributedCache_ConsumeIsNotBroadcast(t *testing.T) {
+	ctx := context.Background()
+	storage := memory.New()
+	shared := memory.New()
+	bus := &countingBus{MemoryInvalidationBus: goquota.NewMemoryInvalidationBus(), published: map[string]int{}}
+	podA := newDistributedCacheTestManager(t, storage, shared, bus)
+
+	if _, err := podA.GetQuota(ctx, "user1", "api_calls", goquota.PeriodTypeMonthly); err != nil {
+		t.Fatalf("GetQuota failed: %v", err)
+	}
+	for i := 0; i < 5; i++ {
+		if _, err := podA.Consume(ctx, "user1", "api_calls", 1, goquota.PeriodTypeMonthly); err != nil {
+			t.Fatalf("Consume failed: %v", err)
+		}
+	}
+	if n := bus.count(goquota.CacheInvalidationUsage); n != 0 {
+		t.Errorf("Expected consumption not to be broadcast, got %d invalidations", n)
+	}
+
+	// The consuming instance and the shared tier are invalidated all the same
+	quota, err := podA.GetQuota(ctx, "user1", "api_calls", goquota.PeriodTypeMonthly)
+	if err != nil || quota.Used != 5 {
+		t.Errorf("Expected usage 5, got %+v (%v)", quota, err)
+	}
+	podB := newDistributedCacheTestManager(t, storage, shared, bus)
+	if quota, err := podB.GetQuota(ctx, "user1", "api_calls", goquota.PeriodTypeMonthly); err != nil || quota.Used != 5 {
+		t.Errorf("Expected a new instance to see usage 5, got %+v (%v)", quota, err)
+	}
+
+	// Admin changes are broadcast
+	if err := podA.SetUsage(ctx, "user1", "api_calls", goquota.PeriodTypeMonthly, 0); err != nil {
+		t.Fatalf("SetUsage failed: %v", err)
+	}
+	if n := bus.count(goquota.CacheInvalidationUsage); n != 1 {
+		t.Errorf("Expected SetUsage to be broadcast once, got %d", n)
+	}
+}
+
+func TestDistributedCache_SharedTier(t *testing.T) {
+	shared := memory.New()
+	bus := goquota.NewMemoryInvalidationBus()
+	newCache := func() *goquota.DistributedCache {
+		cache, err := goquota.NewDistributedCache(goquota.DistributedCacheConfig{Shared: shared, Bus: bus})
+		if err != nil {
+			t.Fatalf("NewDistributedCache failed: %v", err)
+		}
+		t.Cleanup(func() { _ = cache.Close() })
+		return cache
+	}
+	podA, podB := newCache(), newCache()
+
+	podA.SetUsage("user1:api_calls:2026-10", &goquota.Usage{UserID: "user1", Used: 3, Limit: 10}, time.Minute)
+	usage, ok := podB.GetUsage("user1:api_calls:2026-10")
+	if !ok || usage.Used != 3 {
+		t.Fatalf("Expected pod B to read usage from the shared tier, got %+v, %v", usage, ok)
+	}
+	if stats := podB.Stats(); stats.Size != 1 {
+		t.Errorf("Expected the shared entry cached locally, got %+v", stats)
+	}
+
+	podA.InvalidateUsage("user1:api_calls:2026-10")
+	if _, ok := podB.GetUsage("user1:api_calls:2026-10"); ok {
+		t.Error("Expected usage invalidated on pod B")
+	}
+
+	// Expired entries are not served from the shared tier
+	podA.SetEntitlement("user1", &goquota.Entitlement{UserID: "user1", Tier: "pro"}, time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	if _, ok := podB.GetEntitlement("user1"); ok {
+		t.Error("Expected expired entitlement not served")
+	}
+}
@@ -3,6 +3,7 @@ package goquota_test
 import (
 	"context"
 	"fmt"
+	"runtime"
 	"testing"
 	"time"
 
@@ -564,3 +565,98 @@ func TestCache_ConcurrentInvalidation(t *testing.T) {
 		}
 	}
 }
+
+func TestLRUCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	cache := goquota.NewLRUCache(3, 3)
+	for i := 1; i <= 3; i++ {
+		cache.SetUsage(fmt.Sprintf("key%d", i), &goquota.Usage{Used: i}, time.Minute)
+	}
+
+	// Reading key1 makes key2 the least recently used
+	if _, found := cache.GetUsage("key1"); !found {
+		t.Fatal("Expected key1 to be cached")
+	}
+	cache.SetUsage("key4", &goquota.Usage{Used: 4}, time.Minute)
+
+	if _, found := cache.GetUsage("key2"); found {
+		t.Error("Expected key2 to be evicted")
+	}
+	for _, key := range []string{"key1", "key3", "key4"} {
+		if _, found := cache.GetUsage(key); !found {
+			t.Errorf("Expected %s to still be cached", key)
+		}
+	}
+}
+
+func TestLRUCache_Sharded(t *testing.T) {
+	const capacity = 4096
+	cache := goquota.NewLRUCacheWithConfig(goquota.LRUCacheConfig{
+		MaxEntitlements: capacity,
+		MaxUsage:        capacity,
+		Shards:          16,
+	})
+
+	const goroutines = 8
+	done := make(chan struct{})
+	for g := 0; g < goroutines; g++ {
+		go func(g int) {
+			defer func() { done <- struct{}{} }()
+			for i := 0; i < 2*capacity; i++ {
+				key := fmt.Sprintf("user%d-%d", g, i)
+				cache.SetUsage(key, &goquota.Usage{UserID: key, Used: i}, time.Minute)
+				if usage, found := cache.GetUsage(key); found && usage.UserID != key {
+					t.Errorf("Expected usage of %s, got %s", key, usage.UserID)
+				}
+			}
+		}(g)
+	}
+	for g := 0; g < goroutines; g++ {
+		<-done
+	}
+
+	stats := cache.Stats()
+	if stats.Size > capacity {
+		t.Errorf("Expected at most %d entries, got %d", capacity, stats.Size)
+	}
+	if stats.Size+int(stats.Evictions) != goroutines*2*capacity {
+		t.Errorf("Expected every entry cached or evicted, got size %d and %d evictions", stats.Size, stats.Evictions)
+	}
+}
+
+func TestLRUCache_BackgroundCleanup(t *testing.T) {
+	cache := goquota.NewLRUCacheWithConfig(goquota.LRUCacheConfig{CleanupInterval: 5 * time.Millisecond})
+	defer cache.Close()
+
+	cache.SetEntitlement("user1", &goquota.Entitlement{UserID: "user1"}, time.Millisecond)
+	cache.SetUsage("key1", &goquota.Usage{UserID: "user1"}, time.Millisecond)
+	cache.SetUsage("key2", &goquota.Usage{UserID: "user1"}, time.Minute)
+
+	// Expired entries are removed without being read
+	waitFor(t, "expired entries removed", func() bool {
+		return cache.Stats().Size == 1
+	})
+	if _, found := cache.GetUsage("key2"); !found {
+		t.Error("Expected unexpired entry to be kept")
+	}
+}
+
+func TestManager_CacheStartsNoGoroutineByDefault(t *testing.T) {
+	config := goquota.Config{
+		DefaultTier: testTierFree,
+		Tiers:       map[string]goquota.TierConfig{testTierFree: {Name: testTierFree}},
+		CacheConfig: &goquota.CacheConfig{Enabled: true},
+		CacheTTL:    time.Minute,
+	}
+
+	// Managers that are never closed must not leak a cleanup goroutine each
+	const managers = 20
+	before := runtime.NumGoroutine()
+	for i := 0; i < managers; i++ {
+		if _, err := goquota.NewManager(memory.New(), &config); err != nil {
+			t.Fatalf("Failed to create manager: %v", err)
+		}
+	}
+	if started := runtime.NumGoroutine() - before; started >= managers {
+		t.Errorf("Expected no cache cleanup goroutine per manager, %d goroutines started", started)
+	}
+}
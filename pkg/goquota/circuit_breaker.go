@@ -34,6 +34,8 @@ type CircuitBreaker interface {
 }
 
 // DefaultCircuitBreaker is a simple circuit breaker implementation.
+// It opens after failureThreshold consecutive failures; errors for which
+// IsCircuitBreakerFailure returns false count as successes.
 type DefaultCircuitBreaker struct {
 	mu sync.RWMutex
 
@@ -42,6 +44,8 @@ type DefaultCircuitBreaker struct {
 	resetTimeout        time.Duration
 	consecutiveFailures int
 	lastFailureTime     time.Time
+	// state changes to report once mu is released
+	changes []CircuitBreakerState
 
 	onStateChange func(state CircuitBreakerState)
 }
@@ -77,18 +81,13 @@ func (cb *DefaultCircuitBreaker) Execute(_ context.Context, fn func() error) err
 	}
 
 	err := fn()
-	if err != nil {
-		cb.Failure(err)
-		return err
-	}
-
-	cb.Success()
-	return nil
+	cb.Failure(err)
+	return err
 }
 
 func (cb *DefaultCircuitBreaker) Success() {
 	cb.mu.Lock()
-	defer cb.mu.Unlock()
+	defer cb.unlock()
 
 	if cb.state == StateHalfOpen || cb.state == StateOpen {
 		cb.changeState(StateClosed)
@@ -96,9 +95,15 @@ func (cb *DefaultCircuitBreaker) Success() {
 	cb.consecutiveFailures = 0
 }
 
-func (cb *DefaultCircuitBreaker) Failure(_ error) {
+// Failure records a failed execution, or a success if IsCircuitBreakerFailure(err) is false.
+func (cb *DefaultCircuitBreaker) Failure(err error) {
+	if !IsCircuitBreakerFailure(err) {
+		cb.Success()
+		return
+	}
+
 	cb.mu.Lock()
-	defer cb.mu.Unlock()
+	defer cb.unlock()
 
 	cb.consecutiveFailures++
 	cb.lastFailureTime = time.Now()
@@ -114,7 +119,17 @@ func (cb *DefaultCircuitBreaker) changeState(newState CircuitBreakerState) {
 	if cb.state != newState {
 		cb.state = newState
 		if cb.onStateChange != nil {
-			cb.onStateChange(newState)
+			cb.changes = append(cb.changes, newState)
 		}
 	}
 }
+
+// unlock releases mu, then reports the state changes made while holding it
+func (cb *DefaultCircuitBreaker) unlock() {
+	changes := cb.changes
+	cb.changes = nil
+	cb.mu.Unlock()
+	for _, state := range changes {
+		cb.onStateChange(state)
+	}
+}
@@ -0,0 +1,239 @@
+package goquota
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	defaultCircuitBreakerName         = "storage"
+	defaultCircuitBreakerMaxProbes    = 1
+	defaultCircuitBreakerSyncInterval = time.Second
+
+	// circuitBreakerStoreTimeout bounds calls to the coordination store that are not
+	// made on behalf of a request
+	circuitBreakerStoreTimeout = time.Second
+)
+
+// DistributedCircuitBreaker is a circuit breaker whose state and failure window are shared
+// between instances through a CircuitBreakerStore.
+//
+// Each instance caches the shared state for SyncInterval. Failures are recorded in the store
+// and a success resets them, so FailureThreshold counts consecutive failures; closed-state
+// successes only reach the store while failures are recorded. Errors for which
+// IsCircuitBreakerFailure returns false count as successes. Only MaxProbes instances probe a
+// half-open breaker at once. If the store is unavailable, the breaker falls back to a
+// per-instance DefaultCircuitBreaker.
+type DistributedCircuitBreaker struct {
+	store         CircuitBreakerStore
+	name          string
+	threshold     int
+	window        time.Duration
+	resetTimeout  time.Duration
+	maxProbes     int
+	syncInterval  time.Duration
+	local         *DefaultCircuitBreaker
+	onStateChange func(state CircuitBreakerState)
+
+	mu       sync.Mutex
+	status   *CircuitBreakerStatus
+	syncedAt time.Time
+	state    CircuitBreakerState
+}
+
+// NewDistributedCircuitBreaker creates a circuit breaker sharing its state through store.
+// Unset fields of config are defaulted like Config.CircuitBreakerConfig; Enabled is ignored.
+func NewDistributedCircuitBreaker(store CircuitBreakerStore, config CircuitBreakerConfig,
+	onStateChange func(state CircuitBreakerState)) *DistributedCircuitBreaker {
+	applyCircuitBreakerDefaults(&config)
+	return &DistributedCircuitBreaker{
+		store:         store,
+		name:          config.Name,
+		threshold:     config.FailureThreshold,
+		window:        config.FailureWindow,
+		resetTimeout:  config.ResetTimeout,
+		maxProbes:     config.MaxProbes,
+		syncInterval:  config.SyncInterval,
+		local:         NewDefaultCircuitBreaker(config.FailureThreshold, config.ResetTimeout, onStateChange),
+		onStateChange: onStateChange,
+		state:         StateClosed,
+	}
+}
+
+// applyCircuitBreakerDefaults sets default values for circuit breaker config fields
+func applyCircuitBreakerDefaults(config *CircuitBreakerConfig) {
+	if config.FailureThreshold == 0 {
+		config.FailureThreshold = 5
+	}
+	if config.ResetTimeout == 0 {
+		config.ResetTimeout = 30 * time.Second
+	}
+	if config.Name == "" {
+		config.Name = defaultCircuitBreakerName
+	}
+	if config.FailureWindow == 0 {
+		config.FailureWindow = config.ResetTimeout
+	}
+	if config.MaxProbes == 0 {
+		config.MaxProbes = defaultCircuitBreakerMaxProbes
+	}
+	if config.SyncInterval == 0 {
+		config.SyncInterval = defaultCircuitBreakerSyncInterval
+	}
+	if config.SlowCallDuration == 0 {
+		config.SlowCallDuration = defaultCircuitBreakerSlowCallDuration
+	}
+	if config.SlidingWindow == 0 {
+		config.SlidingWindow = defaultCircuitBreakerSlidingWindow
+	}
+	if config.MinimumRequests == 0 {
+		config.MinimumRequests = defaultCircuitBreakerMinimumRequests
+	}
+	if config.HalfOpenTrials == 0 {
+		config.HalfOpenTrials = defaultCircuitBreakerHalfOpenTrials
+	}
+}
+
+func (cb *DistributedCircuitBreaker) request(now time.Time) *CircuitBreakerRequest {
+	return &CircuitBreakerRequest{
+		Name:             cb.name,
+		Now:              now,
+		FailureThreshold: cb.threshold,
+		FailureWindow:    cb.window,
+		ResetTimeout:     cb.resetTimeout,
+		MaxProbes:        cb.maxProbes,
+	}
+}
+
+// Execute runs fn unless the shared breaker is open. While it is half-open, fn only runs
+// if this instance acquires a probe slot.
+func (cb *DistributedCircuitBreaker) Execute(ctx context.Context, fn func() error) error {
+	now := time.Now()
+	state, err := cb.sharedState(ctx, now)
+	if err != nil {
+		// Coordination store unavailable: fall back to this instance's breaker
+		return cb.local.Execute(ctx, fn)
+	}
+
+	switch state {
+	case StateOpen:
+		return ErrCircuitOpen
+	case StateHalfOpen:
+		acquired, err := cb.store.AcquireCircuitBreakerProbe(ctx, cb.request(now))
+		if err != nil {
+			return cb.local.Execute(ctx, fn)
+		}
+		if !acquired {
+			return ErrCircuitOpen
+		}
+	}
+
+	// Outcomes are recorded even if the request's context was canceled by then
+	storeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), circuitBreakerStoreTimeout)
+	defer cancel()
+	err = fn()
+	if IsCircuitBreakerFailure(err) {
+		cb.recordFailure(storeCtx, err)
+		return err
+	}
+	if state == StateHalfOpen || cb.hasFailures() {
+		cb.recordSuccess(storeCtx)
+	}
+	return err
+}
+
+// Success records a successful execution. It only reaches the store if the breaker is not
+// closed or failures are recorded.
+func (cb *DistributedCircuitBreaker) Success() {
+	if cb.State() == StateClosed && !cb.hasFailures() {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), circuitBreakerStoreTimeout)
+	defer cancel()
+	cb.recordSuccess(ctx)
+}
+
+// Failure records a failed execution. Errors for which IsCircuitBreakerFailure returns false
+// are recorded as successes.
+func (cb *DistributedCircuitBreaker) Failure(err error) {
+	if !IsCircuitBreakerFailure(err) {
+		cb.Success()
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), circuitBreakerStoreTimeout)
+	defer cancel()
+	cb.recordFailure(ctx, err)
+}
+
+// State returns the shared state, refreshed at most every SyncInterval.
+// Returns the per-instance state if the store is unavailable.
+func (cb *DistributedCircuitBreaker) State() CircuitBreakerState {
+	ctx, cancel := context.WithTimeout(context.Background(), circuitBreakerStoreTimeout)
+	defer cancel()
+	state, err := cb.sharedState(ctx, time.Now())
+	if err != nil {
+		return cb.local.State()
+	}
+	return state
+}
+
+func (cb *DistributedCircuitBreaker) recordFailure(ctx context.Context, err error) {
+	status, storeErr := cb.store.RecordCircuitBreakerFailure(ctx, cb.request(time.Now()))
+	if storeErr != nil {
+		cb.local.Failure(err)
+		return
+	}
+	cb.update(status, time.Now())
+}
+
+func (cb *DistributedCircuitBreaker) recordSuccess(ctx context.Context) {
+	status, err := cb.store.RecordCircuitBreakerSuccess(ctx, cb.request(time.Now()))
+	if err != nil {
+		cb.local.Success()
+		return
+	}
+	cb.update(status, time.Now())
+}
+
+// hasFailures reports whether the cached status holds failures a success would reset
+func (cb *DistributedCircuitBreaker) hasFailures() bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	return cb.status != nil && cb.status.Failures > 0
+}
+
+// sharedState returns the state at now of the cached status, refreshing it if it is stale
+func (cb *DistributedCircuitBreaker) sharedState(ctx context.Context, now time.Time) (CircuitBreakerState, error) {
+	cb.mu.Lock()
+	status, syncedAt := cb.status, cb.syncedAt
+	cb.mu.Unlock()
+
+	if status == nil || now.Sub(syncedAt) >= cb.syncInterval {
+		var err error
+		status, err = cb.store.GetCircuitBreakerStatus(ctx, cb.name)
+		if err != nil {
+			return "", err
+		}
+	}
+	return cb.update(status, now), nil
+}
+
+// update caches a status read from the store and reports state changes observed by this instance
+func (cb *DistributedCircuitBreaker) update(status *CircuitBreakerStatus, now time.Time) CircuitBreakerState {
+	state := status.StateAt(now)
+
+	cb.mu.Lock()
+	if cb.status != status {
+		cb.status = status
+		cb.syncedAt = now
+	}
+	changed := cb.state != state
+	cb.state = state
+	cb.mu.Unlock()
+
+	if changed && cb.onStateChange != nil {
+		cb.onStateChange(state)
+	}
+	return state
+}
@@ -0,0 +1,212 @@
+package goquota_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mihaimyh/goquota/pkg/goquota"
+	"github.com/mihaimyh/goquota/storage/memory"
+)
+
+// stateRecorder records the state changes reported by a circuit breaker
+type stateRecorder struct {
+	mu     sync.Mutex
+	states []goquota.CircuitBreakerState
+}
+
+func (r *stateRecorder) record(state goquota.CircuitBreakerState) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.states = append(r.states, state)
+}
+
+func (r *stateRecorder) recorded() []goquota.CircuitBreakerState {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]goquota.CircuitBreakerState(nil), r.states...)
+}
+
+func newSharedBreaker(store goquota.CircuitBreakerStore, recorder *stateRecorder) *goquota.DistributedCircuitBreaker {
+	return goquota.NewDistributedCircuitBreaker(store, goquota.CircuitBreakerConfig{
+		FailureThreshold: 3,
+		ResetTimeout:     50 * time.Millisecond,
+		FailureWindow:    time.Minute,
+		SyncInterval:     10 * time.Millisecond,
+	}, recorder.record)
+}
+
+func TestDistributedCircuitBreaker_SharedState(t *testing.T) {
+	store := memory.New()
+	recorderA, recorderB := &stateRecorder{}, &stateRecorder{}
+	a := newSharedBreaker(store, recorderA)
+	b := newSharedBreaker(store, recorderB)
+	ctx := context.Background()
+	fail := func() error { return errors.New("connection refused") }
+
+	// Failures of both instances count towards the shared threshold
+	_ = a.Execute(ctx, fail)
+	_ = a.Execute(ctx, fail)
+	_ = b.Execute(ctx, fail)
+	if state := b.State(); state != goquota.StateOpen {
+		t.Fatalf("Expected breaker opened by the third shared failure, got %s", state)
+	}
+
+	waitFor(t, "instance A to observe the open breaker", func() bool { return a.State() == goquota.StateOpen })
+	if err := a.Execute(ctx, func() error { return nil }); !errors.Is(err, goquota.ErrCircuitOpen) {
+		t.Errorf("Expected ErrCircuitOpen, got %v", err)
+	}
+
+	// Once half-open, only one instance probes at a time
+	waitFor(t, "half-open", func() bool { return a.State() == goquota.StateHalfOpen })
+	probing := make(chan struct{})
+	release := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- a.Execute(ctx, func() error {
+			close(probing)
+			<-release
+			return nil
+		})
+	}()
+	<-probing
+	if err := b.Execute(ctx, func() error { return nil }); !errors.Is(err, goquota.ErrCircuitOpen) {
+		t.Errorf("Expected ErrCircuitOpen while another instance probes, got %v", err)
+	}
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatalf("Probe failed: %v", err)
+	}
+
+	// The successful probe closes the breaker for every instance
+	if state := a.State(); state != goquota.StateClosed {
+		t.Errorf("Expected closed after successful probe, got %s", state)
+	}
+	waitFor(t, "instance B to observe the closed breaker", func() bool { return b.State() == goquota.StateClosed })
+
+	want := []goquota.CircuitBreakerState{goquota.StateOpen, goquota.StateHalfOpen, goquota.StateClosed}
+	for name, recorder := range map[string]*stateRecorder{"A": recorderA, "B": recorderB} {
+		if got := recorder.recorded(); !equalStates(got, want) {
+			t.Errorf("Instance %s: expected state changes %v, got %v", name, want, got)
+		}
+	}
+}
+
+func TestDistributedCircuitBreaker_FailedProbeReopens(t *testing.T) {
+	store := memory.New()
+	cb := newSharedBreaker(store, &stateRecorder{})
+	ctx := context.Background()
+	fail := func() error { return errors.New("timeout") }
+
+	for i := 0; i < 3; i++ {
+		_ = cb.Execute(ctx, fail)
+	}
+	waitFor(t, "half-open", func() bool { return cb.State() == goquota.StateHalfOpen })
+
+	if err := cb.Execute(ctx, fail); err == nil || errors.Is(err, goquota.ErrCircuitOpen) {
+		t.Fatalf("Expected the probe to run and fail, got %v", err)
+	}
+	if state := cb.State(); state != goquota.StateOpen {
+		t.Errorf("Expected breaker reopened after failed probe, got %s", state)
+	}
+}
+
+func TestDistributedCircuitBreaker_BusinessErrorsAndSuccesses(t *testing.T) {
+	store := memory.New()
+	cb := newSharedBreaker(store, &stateRecorder{})
+	ctx := context.Background()
+	fail := func() error { return errors.New("timeout") }
+
+	// The storage answered: quota errors never open the breaker
+	for i := 0; i < 10; i++ {
+		if err := cb.Execute(ctx, func() error { return goquota.ErrQuotaExceeded }); !errors.Is(err, goquota.ErrQuotaExceeded) {
+			t.Fatalf("Expected ErrQuotaExceeded, got %v", err)
+		}
+	}
+	cb.Failure(goquota.ErrQuotaExceeded)
+	if status, _ := store.GetCircuitBreakerStatus(ctx, "storage"); status.Open || status.Failures != 0 {
+		t.Fatalf("Expected no recorded failures, got %+v", status)
+	}
+
+	// A success resets the failures: the threshold counts consecutive failures
+	for i := 0; i < 5; i++ {
+		_ = cb.Execute(ctx, fail)
+		_ = cb.Execute(ctx, fail)
+		if err := cb.Execute(ctx, func() error { return nil }); err != nil {
+			t.Fatalf("Expected the breaker to stay closed, got %v", err)
+		}
+	}
+	if state := cb.State(); state != goquota.StateClosed {
+		t.Errorf("Expected breaker closed, got %s", state)
+	}
+}
+
+// unavailableBreakerStore fails every call, like an unreachable Redis
+type unavailableBreakerStore struct {
+	goquota.CircuitBreakerStore
+}
+
+func (unavailableBreakerStore) GetCircuitBreakerStatus(context.Context, string) (*goquota.CircuitBreakerStatus, error) {
+	return nil, goquota.ErrStorageUnavailable
+}
+
+func (unavailableBreakerStore) RecordCircuitBreakerFailure(
+	context.Context, *goquota.CircuitBreakerRequest) (*goquota.CircuitBreakerStatus, error) {
+	return nil, goquota.ErrStorageUnavailable
+}
+
+func TestDistributedCircuitBreaker_StoreUnavailable(t *testing.T) {
+	cb := newSharedBreaker(unavailableBreakerStore{}, &stateRecorder{})
+	ctx := context.Background()
+
+	// Falls back to a per-instance breaker
+	for i := 0; i < 3; i++ {
+		if err := cb.Execute(ctx, func() error { return errors.New("fail") }); errors.Is(err, goquota.ErrCircuitOpen) {
+			t.Fatalf("Expected call %d to run, got %v", i, err)
+		}
+	}
+	if err := cb.Execute(ctx, func() error { return nil }); !errors.Is(err, goquota.ErrCircuitOpen) {
+		t.Errorf("Expected local breaker to open, got %v", err)
+	}
+	if state := cb.State(); state != goquota.StateOpen {
+		t.Errorf("Expected local state open, got %s", state)
+	}
+}
+
+func TestConfig_Validate_DistributedCircuitBreaker(t *testing.T) {
+	config := &goquota.Config{
+		DefaultTier: "free",
+		Tiers:       map[string]goquota.TierConfig{"free": {Name: "free"}},
+		CircuitBreakerConfig: &goquota.CircuitBreakerConfig{
+			Enabled:       true,
+			Store:         memory.New(),
+			FailureWindow: -time.Second,
+			MaxProbes:     -1,
+			SyncInterval:  -time.Second,
+		},
+	}
+	err := config.Validate()
+	if err == nil {
+		t.Fatal("Expected validation error")
+	}
+	for _, want := range []string{"failureWindow", "maxProbes", "syncInterval"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error to mention %q, got %v", want, err)
+		}
+	}
+}
+
+func equalStates(a, b []goquota.CircuitBreakerState) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
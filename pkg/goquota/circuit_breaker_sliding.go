@@ -0,0 +1,253 @@
+package goquota
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+const (
+	defaultCircuitBreakerSlidingWindow    = 10 * time.Second
+	defaultCircuitBreakerSlowCallDuration = time.Second
+	defaultCircuitBreakerMinimumRequests  = 10
+	defaultCircuitBreakerHalfOpenTrials   = 1
+
+	// slidingWindowBuckets is the number of buckets the sliding window is divided into
+	slidingWindowBuckets = 10
+)
+
+// IsCircuitBreakerFailure reports whether err indicates an unhealthy storage. Business errors
+// such as ErrQuotaExceeded or ErrIdempotencyKeyExists mean the storage answered, so they are
+// not failures.
+func IsCircuitBreakerFailure(err error) bool {
+	return err != nil &&
+		!errors.Is(err, ErrQuotaExceeded) &&
+		!errors.Is(err, ErrIdempotencyKeyExists) &&
+		!errors.Is(err, ErrConcurrencyLimitExceeded) &&
+		!errors.Is(err, ErrLeaseExpired)
+}
+
+// slidingWindowBucket counts the calls of one slice of the sliding window
+type slidingWindowBucket struct {
+	index    int64
+	calls    int
+	failures int
+	slow     int
+}
+
+// SlidingWindowCircuitBreaker opens the circuit when the failure rate or the slow-call rate
+// of the calls in a sliding time window reaches a threshold, once the window holds at least
+// MinimumRequests calls. After ResetTimeout it lets HalfOpenTrials trial calls through: the
+// circuit closes once they all succeed and reopens on the first failed or slow trial.
+//
+// Errors for which IsCircuitBreakerFailure returns false count as successful calls.
+type SlidingWindowCircuitBreaker struct {
+	mu sync.Mutex
+
+	failureRateThreshold  float64
+	slowCallRateThreshold float64
+	slowCallDuration      time.Duration
+	bucketSize            time.Duration
+	minimumRequests       int
+	resetTimeout          time.Duration
+	halfOpenTrials        int
+
+	state      CircuitBreakerState
+	openedAt   time.Time
+	generation int
+	buckets    [slidingWindowBuckets]slidingWindowBucket
+	trials     int
+	successes  int
+	// state changes to report once mu is released
+	changes []CircuitBreakerState
+
+	onStateChange func(state CircuitBreakerState)
+}
+
+// NewSlidingWindowCircuitBreaker creates a failure-rate circuit breaker.
+// Unset fields of config are defaulted like Config.CircuitBreakerConfig; Enabled is ignored.
+func NewSlidingWindowCircuitBreaker(config CircuitBreakerConfig,
+	onStateChange func(state CircuitBreakerState)) *SlidingWindowCircuitBreaker {
+	applyCircuitBreakerDefaults(&config)
+	bucketSize := config.SlidingWindow / slidingWindowBuckets
+	if bucketSize <= 0 {
+		bucketSize = time.Millisecond
+	}
+	return &SlidingWindowCircuitBreaker{
+		failureRateThreshold:  config.FailureRateThreshold,
+		slowCallRateThreshold: config.SlowCallRateThreshold,
+		slowCallDuration:      config.SlowCallDuration,
+		bucketSize:            bucketSize,
+		minimumRequests:       config.MinimumRequests,
+		resetTimeout:          config.ResetTimeout,
+		halfOpenTrials:        config.HalfOpenTrials,
+		state:                 StateClosed,
+		onStateChange:         onStateChange,
+	}
+}
+
+// Execute runs fn unless the circuit is open, or half-open with all trial calls taken.
+func (cb *SlidingWindowCircuitBreaker) Execute(_ context.Context, fn func() error) error {
+	generation, err := cb.acquire(time.Now())
+	if err != nil {
+		return err
+	}
+
+	start := time.Now()
+	err = fn()
+	cb.record(generation, time.Now(), IsCircuitBreakerFailure(err), time.Since(start) >= cb.slowCallDuration)
+	return err
+}
+
+// Success records a successful call.
+func (cb *SlidingWindowCircuitBreaker) Success() {
+	cb.mu.Lock()
+	generation := cb.generation
+	cb.mu.Unlock()
+	cb.record(generation, time.Now(), false, false)
+}
+
+// Failure records a failed call.
+func (cb *SlidingWindowCircuitBreaker) Failure(err error) {
+	cb.mu.Lock()
+	generation := cb.generation
+	cb.mu.Unlock()
+	cb.record(generation, time.Now(), IsCircuitBreakerFailure(err), false)
+}
+
+// State returns the current state of the circuit breaker.
+func (cb *SlidingWindowCircuitBreaker) State() CircuitBreakerState {
+	cb.mu.Lock()
+	defer cb.unlock()
+	cb.advance(time.Now())
+	return cb.state
+}
+
+// acquire admits a call, returning the generation of the state it was admitted in
+func (cb *SlidingWindowCircuitBreaker) acquire(now time.Time) (int, error) {
+	cb.mu.Lock()
+	defer cb.unlock()
+
+	cb.advance(now)
+	switch cb.state {
+	case StateOpen:
+		return 0, ErrCircuitOpen
+	case StateHalfOpen:
+		if cb.trials >= cb.halfOpenTrials {
+			return 0, ErrCircuitOpen
+		}
+		cb.trials++
+	}
+	return cb.generation, nil
+}
+
+// record accounts for the outcome of a call. Outcomes of calls admitted before the last
+// state change are ignored.
+func (cb *SlidingWindowCircuitBreaker) record(generation int, now time.Time, failed, slow bool) {
+	cb.mu.Lock()
+	defer cb.unlock()
+
+	if generation != cb.generation {
+		return
+	}
+
+	if cb.state == StateHalfOpen {
+		switch {
+		case failed || slow:
+			cb.changeState(StateOpen, now)
+		case cb.successes+1 >= cb.halfOpenTrials:
+			cb.changeState(StateClosed, now)
+		default:
+			cb.successes++
+		}
+		return
+	}
+	if cb.state != StateClosed {
+		return
+	}
+
+	bucket := cb.bucket(now)
+	bucket.calls++
+	if failed {
+		bucket.failures++
+	}
+	if slow {
+		bucket.slow++
+	}
+
+	calls, failures, slowCalls := cb.totals(now)
+	if calls < cb.minimumRequests {
+		return
+	}
+	if exceedsRate(failures, calls, cb.failureRateThreshold) ||
+		exceedsRate(slowCalls, calls, cb.slowCallRateThreshold) {
+		cb.changeState(StateOpen, now)
+	}
+}
+
+// exceedsRate reports whether count is at least threshold percent of calls. A zero threshold is disabled.
+func exceedsRate(count, calls int, threshold float64) bool {
+	return threshold > 0 && float64(count)*100 >= threshold*float64(calls)
+}
+
+// advance moves an open circuit to half-open once ResetTimeout has passed
+func (cb *SlidingWindowCircuitBreaker) advance(now time.Time) {
+	if cb.state == StateOpen && now.Sub(cb.openedAt) >= cb.resetTimeout {
+		cb.changeState(StateHalfOpen, now)
+	}
+}
+
+// bucket returns the bucket of now, clearing it if it belonged to an earlier slice
+func (cb *SlidingWindowCircuitBreaker) bucket(now time.Time) *slidingWindowBucket {
+	index := now.UnixNano() / int64(cb.bucketSize)
+	bucket := &cb.buckets[index%slidingWindowBuckets]
+	if bucket.index != index {
+		*bucket = slidingWindowBucket{index: index}
+	}
+	return bucket
+}
+
+// totals sums the buckets inside the sliding window ending at now
+func (cb *SlidingWindowCircuitBreaker) totals(now time.Time) (calls, failures, slow int) {
+	index := now.UnixNano() / int64(cb.bucketSize)
+	for i := range cb.buckets {
+		bucket := &cb.buckets[i]
+		if index-bucket.index < slidingWindowBuckets {
+			calls += bucket.calls
+			failures += bucket.failures
+			slow += bucket.slow
+		}
+	}
+	return calls, failures, slow
+}
+
+func (cb *SlidingWindowCircuitBreaker) changeState(newState CircuitBreakerState, now time.Time) {
+	if cb.state == newState {
+		return
+	}
+	cb.state = newState
+	cb.generation++
+	cb.trials = 0
+	cb.successes = 0
+	switch newState {
+	case StateOpen:
+		cb.openedAt = now
+	case StateClosed:
+		cb.buckets = [slidingWindowBuckets]slidingWindowBucket{}
+	}
+	if cb.onStateChange != nil {
+		cb.changes = append(cb.changes, newState)
+	}
+}
+
+// unlock releases mu, then reports the state changes made while holding it, so that
+// onStateChange may call back into the breaker
+func (cb *SlidingWindowCircuitBreaker) unlock() {
+	changes := cb.changes
+	cb.changes = nil
+	cb.mu.Unlock()
+	for _, state := range changes {
+		cb.onStateChange(state)
+	}
+}
@@ -0,0 +1,188 @@
+package goquota
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestSlidingBreaker(config CircuitBreakerConfig, states *[]CircuitBreakerState) *SlidingWindowCircuitBreaker {
+	return NewSlidingWindowCircuitBreaker(config, func(state CircuitBreakerState) {
+		*states = append(*states, state)
+	})
+}
+
+func TestSlidingWindowCircuitBreaker_FailureRate(t *testing.T) {
+	var states []CircuitBreakerState
+	cb := newTestSlidingBreaker(CircuitBreakerConfig{
+		FailureRateThreshold: 20,
+		MinimumRequests:      10,
+		SlidingWindow:        time.Minute,
+		ResetTimeout:         time.Minute,
+	}, &states)
+	ctx := context.Background()
+	fail := func() error { return errors.New("fail") }
+	succeed := func() error { return nil }
+
+	// 2 failures out of 9 calls: below the minimum request volume
+	for i := 0; i < 7; i++ {
+		assert.NoError(t, cb.Execute(ctx, succeed))
+	}
+	assert.Error(t, cb.Execute(ctx, fail))
+	assert.Error(t, cb.Execute(ctx, fail))
+	assert.Equal(t, StateClosed, cb.State())
+
+	// The 10th call brings the failure rate to 20% without any consecutive failure streak
+	assert.Error(t, cb.Execute(ctx, fail))
+	assert.Equal(t, StateOpen, cb.State())
+	assert.ErrorIs(t, cb.Execute(ctx, succeed), ErrCircuitOpen)
+	assert.Equal(t, []CircuitBreakerState{StateOpen}, states)
+}
+
+func TestSlidingWindowCircuitBreaker_BusinessErrorsAreNotFailures(t *testing.T) {
+	var states []CircuitBreakerState
+	cb := newTestSlidingBreaker(CircuitBreakerConfig{
+		FailureRateThreshold: 50,
+		MinimumRequests:      2,
+	}, &states)
+	ctx := context.Background()
+
+	for i := 0; i < 5; i++ {
+		err := cb.Execute(ctx, func() error { return ErrQuotaExceeded })
+		assert.ErrorIs(t, err, ErrQuotaExceeded)
+	}
+	assert.Equal(t, StateClosed, cb.State())
+	assert.Empty(t, states)
+}
+
+func TestSlidingWindowCircuitBreaker_SlowCallRate(t *testing.T) {
+	var states []CircuitBreakerState
+	cb := newTestSlidingBreaker(CircuitBreakerConfig{
+		SlowCallRateThreshold: 50,
+		SlowCallDuration:      10 * time.Millisecond,
+		MinimumRequests:       2,
+		ResetTimeout:          time.Minute,
+	}, &states)
+	ctx := context.Background()
+
+	assert.NoError(t, cb.Execute(ctx, func() error { return nil }))
+	assert.NoError(t, cb.Execute(ctx, func() error {
+		time.Sleep(15 * time.Millisecond)
+		return nil
+	}))
+	assert.Equal(t, StateOpen, cb.State())
+}
+
+func TestSlidingWindowCircuitBreaker_WindowSlides(t *testing.T) {
+	var states []CircuitBreakerState
+	cb := newTestSlidingBreaker(CircuitBreakerConfig{
+		FailureRateThreshold: 50,
+		MinimumRequests:      3,
+		SlidingWindow:        50 * time.Millisecond,
+	}, &states)
+	ctx := context.Background()
+	fail := func() error { return errors.New("fail") }
+
+	assert.Error(t, cb.Execute(ctx, fail))
+	assert.Error(t, cb.Execute(ctx, fail))
+	time.Sleep(60 * time.Millisecond)
+
+	// The earlier failures left the window, so the volume is below the minimum again
+	assert.Error(t, cb.Execute(ctx, fail))
+	assert.Equal(t, StateClosed, cb.State())
+}
+
+func TestSlidingWindowCircuitBreaker_HalfOpenTrials(t *testing.T) {
+	var states []CircuitBreakerState
+	cb := newTestSlidingBreaker(CircuitBreakerConfig{
+		FailureRateThreshold: 50,
+		MinimumRequests:      1,
+		ResetTimeout:         20 * time.Millisecond,
+		HalfOpenTrials:       2,
+	}, &states)
+	ctx := context.Background()
+
+	assert.Error(t, cb.Execute(ctx, func() error { return errors.New("fail") }))
+	time.Sleep(30 * time.Millisecond)
+	assert.Equal(t, StateHalfOpen, cb.State())
+
+	// Two trials run concurrently; further calls are rejected until they complete
+	started := make(chan struct{}, 2)
+	release := make(chan struct{})
+	done := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			done <- cb.Execute(ctx, func() error {
+				started <- struct{}{}
+				<-release
+				return nil
+			})
+		}()
+	}
+	<-started
+	<-started
+	assert.ErrorIs(t, cb.Execute(ctx, func() error { return nil }), ErrCircuitOpen)
+	close(release)
+	assert.NoError(t, <-done)
+	assert.NoError(t, <-done)
+
+	assert.Equal(t, StateClosed, cb.State())
+	assert.Equal(t, []CircuitBreakerState{StateOpen, StateHalfOpen, StateClosed}, states)
+}
+
+func TestSlidingWindowCircuitBreaker_FailedTrialReopens(t *testing.T) {
+	var states []CircuitBreakerState
+	cb := newTestSlidingBreaker(CircuitBreakerConfig{
+		FailureRateThreshold: 50,
+		MinimumRequests:      1,
+		ResetTimeout:         20 * time.Millisecond,
+		HalfOpenTrials:       3,
+	}, &states)
+	ctx := context.Background()
+
+	assert.Error(t, cb.Execute(ctx, func() error { return errors.New("fail") }))
+	time.Sleep(30 * time.Millisecond)
+
+	assert.NoError(t, cb.Execute(ctx, func() error { return nil }))
+	assert.Error(t, cb.Execute(ctx, func() error { return errors.New("fail") }))
+	assert.Equal(t, StateOpen, cb.State())
+	assert.Equal(t, []CircuitBreakerState{StateOpen, StateHalfOpen, StateOpen}, states)
+}
+
+func TestSlidingWindowCircuitBreaker_StateChangeCallbackOutsideLock(t *testing.T) {
+	var cb *SlidingWindowCircuitBreaker
+	var observed []CircuitBreakerState
+	cb = NewSlidingWindowCircuitBreaker(CircuitBreakerConfig{
+		FailureRateThreshold: 50,
+		MinimumRequests:      1,
+		ResetTimeout:         time.Minute,
+	}, func(CircuitBreakerState) {
+		// Would deadlock if called while the breaker holds its lock
+		observed = append(observed, cb.State())
+	})
+
+	assert.Error(t, cb.Execute(context.Background(), func() error { return errors.New("fail") }))
+	assert.Equal(t, []CircuitBreakerState{StateOpen}, observed)
+}
+
+func TestConfig_Validate_SlidingWindowCircuitBreaker(t *testing.T) {
+	config := &Config{
+		DefaultTier: "free",
+		Tiers:       map[string]TierConfig{"free": {Name: "free"}},
+		CircuitBreakerConfig: &CircuitBreakerConfig{
+			Enabled:               true,
+			FailureRateThreshold:  150,
+			SlowCallRateThreshold: -1,
+			MinimumRequests:       -1,
+			HalfOpenTrials:        -1,
+		},
+	}
+	err := config.Validate()
+	assert.ErrorContains(t, err, "failureRateThreshold must be between 0 and 100")
+	assert.ErrorContains(t, err, "slowCallRateThreshold must be between 0 and 100")
+	assert.ErrorContains(t, err, "minimumRequests cannot be negative")
+	assert.ErrorContains(t, err, "halfOpenTrials cannot be negative")
+}
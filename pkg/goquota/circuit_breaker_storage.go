@@ -5,10 +5,18 @@ import (
 	"time"
 )
 
+// circuitBreakerOperations lists the operations that can be assigned to circuit breaker groups
+var circuitBreakerOperations = []string{
+	"GetEntitlement", "SetEntitlement", "GetUsage", "ConsumeQuota", "ApplyTierChange", "SetUsage",
+	"RefundQuota", "GetRefundRecord", "GetConsumptionRecord", "CheckRateLimit", "RecordRateLimitRequest",
+	"AddLimit", "SubtractLimit", "GetEntitlements", "GetUsages", "LogAuditEntry", "GetAuditLogs",
+}
+
 // CircuitBreakerStorage wraps a Storage implementation with circuit breaker protection.
 type CircuitBreakerStorage struct {
-	storage Storage
-	cb      CircuitBreaker
+	storage  Storage
+	cb       CircuitBreaker
+	breakers map[string]CircuitBreaker
 }
 
 // NewCircuitBreakerStorage creates a new storage wrapper with circuit breaker.
@@ -19,9 +27,40 @@ func NewCircuitBreakerStorage(storage Storage, cb CircuitBreaker) *CircuitBreake
 	}
 }
 
+// NewGroupedCircuitBreakerStorage creates a new storage wrapper with a circuit breaker per
+// operation. breakers is keyed by operation (Storage method name, e.g. "ConsumeQuota");
+// operations without an entry use cb. Operations may share a breaker to form a group.
+func NewGroupedCircuitBreakerStorage(storage Storage, cb CircuitBreaker,
+	breakers map[string]CircuitBreaker) *CircuitBreakerStorage {
+	return &CircuitBreakerStorage{
+		storage:  storage,
+		cb:       cb,
+		breakers: breakers,
+	}
+}
+
+// Breaker returns the circuit breaker protecting operation.
+func (s *CircuitBreakerStorage) Breaker(operation string) CircuitBreaker {
+	if cb, ok := s.breakers[operation]; ok {
+		return cb
+	}
+	return s.cb
+}
+
+// Execute runs fn within the circuit breaker of operation. It is used for the wrapped
+// storage's methods and for optional interfaces called through the Manager (e.g. "GetAuditLogs").
+func (s *CircuitBreakerStorage) Execute(ctx context.Context, operation string, fn func() error) error {
+	return s.Breaker(operation).Execute(ctx, fn)
+}
+
+// Unwrap returns the underlying storage.
+func (s *CircuitBreakerStorage) Unwrap() Storage {
+	return s.storage
+}
+
 func (s *CircuitBreakerStorage) GetEntitlement(ctx context.Context, userID string) (*Entitlement, error) {
 	var ent *Entitlement
-	err := s.cb.Execute(ctx, func() error {
+	err := s.Execute(ctx, "GetEntitlement", func() error {
 		var e error
 		ent, e = s.storage.GetEntitlement(ctx, userID)
 		return e
@@ -30,14 +69,14 @@ func (s *CircuitBreakerStorage) GetEntitlement(ctx context.Context, userID strin
 }
 
 func (s *CircuitBreakerStorage) SetEntitlement(ctx context.Context, ent *Entitlement) error {
-	return s.cb.Execute(ctx, func() error {
+	return s.Execute(ctx, "SetEntitlement", func() error {
 		return s.storage.SetEntitlement(ctx, ent)
 	})
 }
 
 func (s *CircuitBreakerStorage) GetUsage(ctx context.Context, userID, resource string, period Period) (*Usage, error) {
 	var usage *Usage
-	err := s.cb.Execute(ctx, func() error {
+	err := s.Execute(ctx, "GetUsage", func() error {
 		var e error
 		usage, e = s.storage.GetUsage(ctx, userID, resource, period)
 		return e
@@ -47,7 +86,7 @@ func (s *CircuitBreakerStorage) GetUsage(ctx context.Context, userID, resource s
 
 func (s *CircuitBreakerStorage) ConsumeQuota(ctx context.Context, req *ConsumeRequest) (int, error) {
 	var used int
-	err := s.cb.Execute(ctx, func() error {
+	err := s.Execute(ctx, "ConsumeQuota", func() error {
 		var e error
 		used, e = s.storage.ConsumeQuota(ctx, req)
 		return e
@@ -56,27 +95,27 @@ func (s *CircuitBreakerStorage) ConsumeQuota(ctx context.Context, req *ConsumeRe
 }
 
 func (s *CircuitBreakerStorage) ApplyTierChange(ctx context.Context, req *TierChangeRequest) error {
-	return s.cb.Execute(ctx, func() error {
+	return s.Execute(ctx, "ApplyTierChange", func() error {
 		return s.storage.ApplyTierChange(ctx, req)
 	})
 }
 
 func (s *CircuitBreakerStorage) SetUsage(ctx context.Context, userID, resource string,
 	usage *Usage, period Period) error {
-	return s.cb.Execute(ctx, func() error {
+	return s.Execute(ctx, "SetUsage", func() error {
 		return s.storage.SetUsage(ctx, userID, resource, usage, period)
 	})
 }
 
 func (s *CircuitBreakerStorage) RefundQuota(ctx context.Context, req *RefundRequest) error {
-	return s.cb.Execute(ctx, func() error {
+	return s.Execute(ctx, "RefundQuota", func() error {
 		return s.storage.RefundQuota(ctx, req)
 	})
 }
 
 func (s *CircuitBreakerStorage) GetRefundRecord(ctx context.Context, idempotencyKey string) (*RefundRecord, error) {
 	var record *RefundRecord
-	err := s.cb.Execute(ctx, func() error {
+	err := s.Execute(ctx, "GetRefundRecord", func() error {
 		var e error
 		record, e = s.storage.GetRefundRecord(ctx, idempotencyKey)
 		return e
@@ -87,7 +126,7 @@ func (s *CircuitBreakerStorage) GetRefundRecord(ctx context.Context, idempotency
 func (s *CircuitBreakerStorage) GetConsumptionRecord(ctx context.Context,
 	idempotencyKey string) (*ConsumptionRecord, error) {
 	var record *ConsumptionRecord
-	err := s.cb.Execute(ctx, func() error {
+	err := s.Execute(ctx, "GetConsumptionRecord", func() error {
 		var e error
 		record, e = s.storage.GetConsumptionRecord(ctx, idempotencyKey)
 		return e
@@ -102,7 +141,7 @@ func (s *CircuitBreakerStorage) CheckRateLimit(
 	var allowed bool
 	var remaining int
 	var resetTime time.Time
-	err := s.cb.Execute(ctx, func() error {
+	err := s.Execute(ctx, "CheckRateLimit", func() error {
 		var e error
 		allowed, remaining, resetTime, e = s.storage.CheckRateLimit(ctx, req)
 		return e
@@ -111,7 +150,7 @@ func (s *CircuitBreakerStorage) CheckRateLimit(
 }
 
 func (s *CircuitBreakerStorage) RecordRateLimitRequest(ctx context.Context, req *RateLimitRequest) error {
-	return s.cb.Execute(ctx, func() error {
+	return s.Execute(ctx, "RecordRateLimitRequest", func() error {
 		return s.storage.RecordRateLimitRequest(ctx, req)
 	})
 }
@@ -119,7 +158,7 @@ func (s *CircuitBreakerStorage) RecordRateLimitRequest(ctx context.Context, req
 func (s *CircuitBreakerStorage) AddLimit(
 	ctx context.Context, userID, resource string, amount int, period Period, idempotencyKey string,
 ) error {
-	return s.cb.Execute(ctx, func() error {
+	return s.Execute(ctx, "AddLimit", func() error {
 		return s.storage.AddLimit(ctx, userID, resource, amount, period, idempotencyKey)
 	})
 }
@@ -127,7 +166,31 @@ func (s *CircuitBreakerStorage) AddLimit(
 func (s *CircuitBreakerStorage) SubtractLimit(
 	ctx context.Context, userID, resource string, amount int, period Period, idempotencyKey string,
 ) error {
-	return s.cb.Execute(ctx, func() error {
+	return s.Execute(ctx, "SubtractLimit", func() error {
 		return s.storage.SubtractLimit(ctx, userID, resource, amount, period, idempotencyKey)
 	})
 }
+
+// GetEntitlements implements BatchStorage.
+// Falls back to individual reads if the wrapped storage does not support batching.
+func (s *CircuitBreakerStorage) GetEntitlements(ctx context.Context, userIDs []string) (map[string]*Entitlement, error) {
+	var ents map[string]*Entitlement
+	err := s.Execute(ctx, "GetEntitlements", func() error {
+		var e error
+		ents, e = getEntitlements(ctx, s.storage, userIDs)
+		return e
+	})
+	return ents, err
+}
+
+// GetUsages implements BatchStorage.
+// Falls back to individual reads if the wrapped storage does not support batching.
+func (s *CircuitBreakerStorage) GetUsages(ctx context.Context, queries []UsageQuery) ([]*Usage, error) {
+	var usages []*Usage
+	err := s.Execute(ctx, "GetUsages", func() error {
+		var e error
+		usages, e = getUsages(ctx, s.storage, queries)
+		return e
+	})
+	return usages, err
+}
@@ -324,3 +324,123 @@ func TestCircuitBreakerStorage_StateTransitions(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, StateClosed, cb.State())
 }
+
+func TestCircuitBreakerStorage_Batch_OpenCircuit(t *testing.T) {
+	ctx := context.Background()
+	cb := NewDefaultCircuitBreaker(2, 100*time.Millisecond, nil)
+
+	// Open the circuit
+	cb.Failure(errors.New("test error"))
+	cb.Failure(errors.New("test error"))
+
+	storage := NewCircuitBreakerStorage(&mockStorage{}, cb)
+
+	ents, err := storage.GetEntitlements(ctx, []string{"user1"})
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.Nil(t, ents)
+
+	usages, err := storage.GetUsages(ctx, []UsageQuery{{UserID: "user1", Resource: "api_calls"}})
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.Nil(t, usages)
+}
+
+func TestCircuitBreakerStorage_Batch_UnsupportedStorage(t *testing.T) {
+	ctx := context.Background()
+	cb := NewDefaultCircuitBreaker(2, 100*time.Millisecond, nil)
+
+	// mockStorage doesn't implement BatchStorage, so reads fall back to one at a time
+	storage := NewCircuitBreakerStorage(&mockStorage{}, cb)
+
+	ents, err := storage.GetEntitlements(ctx, []string{"user1", "user2"})
+	assert.NoError(t, err)
+	assert.Empty(t, ents)
+
+	usages, err := storage.GetUsages(ctx, []UsageQuery{
+		{UserID: "user1", Resource: "api_calls"},
+		{UserID: "user2", Resource: "api_calls"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []*Usage{nil, nil}, usages)
+}
+
+func TestGroupedCircuitBreakerStorage(t *testing.T) {
+	ctx := context.Background()
+	reads := NewDefaultCircuitBreaker(2, time.Minute, nil)
+	writes := NewDefaultCircuitBreaker(2, time.Minute, nil)
+	storage := NewGroupedCircuitBreakerStorage(&mockStorage{}, writes, map[string]CircuitBreaker{
+		"GetRefundRecord":      reads,
+		"GetConsumptionRecord": reads,
+	})
+
+	// Open the breaker of the reads group only
+	reads.Failure(errors.New("timeout"))
+	reads.Failure(errors.New("timeout"))
+
+	_, err := storage.GetRefundRecord(ctx, "key")
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	_, err = storage.GetConsumptionRecord(ctx, "key")
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+
+	_, err = storage.ConsumeQuota(ctx, &ConsumeRequest{UserID: "user1", Resource: "api_calls", Amount: 1})
+	assert.NoError(t, err)
+	assert.Same(t, writes, storage.Breaker("ConsumeQuota"))
+}
+
+// failingAuditStorage is a mockStorage whose audit log queries fail
+type failingAuditStorage struct {
+	mockStorage
+}
+
+func (s *failingAuditStorage) LogAuditEntry(_ context.Context, _ *AuditLogEntry) error {
+	return nil
+}
+
+func (s *failingAuditStorage) GetAuditLogs(_ context.Context, _ AuditLogFilter) ([]*AuditLogEntry, error) {
+	return nil, errors.New("query timeout")
+}
+
+func TestManager_CircuitBreakerGroups(t *testing.T) {
+	ctx := context.Background()
+	manager, err := NewManager(&failingAuditStorage{}, &Config{
+		DefaultTier: "free",
+		Tiers:       map[string]TierConfig{"free": {Name: "free"}},
+		CircuitBreakerConfig: &CircuitBreakerConfig{
+			Enabled:          true,
+			FailureThreshold: 2,
+			ResetTimeout:     time.Minute,
+			Groups:           map[string][]string{"audit": {"LogAuditEntry", "GetAuditLogs"}},
+		},
+	})
+	assert.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		_, err = manager.GetAuditLogs(ctx, AuditLogFilter{})
+		assert.EqualError(t, err, "query timeout")
+	}
+	_, err = manager.GetAuditLogs(ctx, AuditLogFilter{})
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+
+	// Other operations have their own breaker
+	cbStorage, ok := storageAs[*CircuitBreakerStorage](manager.storage)
+	assert.True(t, ok)
+	assert.Equal(t, StateClosed, cbStorage.Breaker("ConsumeQuota").State())
+	assert.Equal(t, StateOpen, cbStorage.Breaker("LogAuditEntry").State())
+}
+
+func TestConfig_Validate_CircuitBreakerGroups(t *testing.T) {
+	config := &Config{
+		DefaultTier: "free",
+		Tiers:       map[string]TierConfig{"free": {Name: "free"}},
+		CircuitBreakerConfig: &CircuitBreakerConfig{
+			Enabled: true,
+			Groups: map[string][]string{
+				"reads":  {"GetUsage", "GetEntitlement"},
+				"usage":  {"GetUsage"},
+				"unused": {"DeleteEverything"},
+			},
+		},
+	}
+	err := config.Validate()
+	assert.ErrorContains(t, err, `operation "GetUsage" is already in group "reads"`)
+	assert.ErrorContains(t, err, `unknown operation "DeleteEverything"`)
+}
@@ -80,6 +80,35 @@ func TestDefaultCircuitBreaker(t *testing.T) {
 	assert.Equal(t, StateOpen, cb.State())
 }
 
+func TestDefaultCircuitBreaker_BusinessErrorsAreNotFailures(t *testing.T) {
+	cb := NewDefaultCircuitBreaker(2, time.Minute, nil)
+	ctx := context.Background()
+
+	for i := 0; i < 10; i++ {
+		assert.ErrorIs(t, cb.Execute(ctx, func() error { return ErrQuotaExceeded }), ErrQuotaExceeded)
+	}
+	cb.Failure(ErrIdempotencyKeyExists)
+	assert.Equal(t, StateClosed, cb.State())
+
+	// A business error between failures resets the streak like a success
+	_ = cb.Execute(ctx, func() error { return errors.New("fail") })
+	_ = cb.Execute(ctx, func() error { return ErrQuotaExceeded })
+	_ = cb.Execute(ctx, func() error { return errors.New("fail") })
+	assert.Equal(t, StateClosed, cb.State())
+}
+
+func TestDefaultCircuitBreaker_StateChangeCallbackOutsideLock(t *testing.T) {
+	var cb *DefaultCircuitBreaker
+	var observed []CircuitBreakerState
+	cb = NewDefaultCircuitBreaker(1, time.Minute, func(CircuitBreakerState) {
+		// Would deadlock if called while the breaker holds its lock
+		observed = append(observed, cb.State())
+	})
+
+	cb.Failure(errors.New("fail"))
+	assert.Equal(t, []CircuitBreakerState{StateOpen}, observed)
+}
+
 // Phase 3.3: Circuit Breaker Concurrency Tests
 
 func TestCircuitBreaker_ConcurrentStateChanges(t *testing.T) {
@@ -0,0 +1,243 @@
+package goquota
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const defaultLeaseTTL = 30 * time.Second
+
+// Lease represents a held concurrency slot for a user and resource.
+// A lease must be released when the guarded operation finishes. Leases that are
+// neither renewed nor released expire after their TTL, so slots held by crashed
+// processes are eventually reclaimed.
+type Lease struct {
+	// ID uniquely identifies the lease
+	ID string
+
+	// UserID is the user holding the lease
+	UserID string
+
+	// Resource is the resource the lease guards
+	Resource string
+
+	manager   *Manager
+	limiter   ConcurrencyLimiter // nil for resources without a concurrency limit
+	limit     int
+	ttl       time.Duration
+	mu        sync.Mutex
+	expiresAt time.Time
+	released  bool
+	stop      chan struct{}
+}
+
+// Acquire obtains a concurrency lease for a resource.
+// Returns ErrConcurrencyLimitExceeded if the user already holds the maximum number of
+// leases configured in TierConfig.ConcurrencyLimits. Resources without a concurrency
+// limit always succeed and return a lease whose Renew and Release are no-ops.
+func (m *Manager) Acquire(ctx context.Context, userID, resource string) (*Lease, error) {
+	// Get entitlement to determine tier (uses cache)
+	ent, err := m.GetEntitlement(ctx, userID)
+	if err != nil && err != ErrEntitlementNotFound {
+		return nil, err
+	}
+	tier := m.config.DefaultTier
+	if err == nil && ent != nil {
+		tier = ent.Tier
+	}
+
+	lease := &Lease{
+		UserID:   userID,
+		Resource: resource,
+		manager:  m,
+		stop:     make(chan struct{}),
+	}
+
+	limitConfig, ok := m.getConcurrencyLimit(resource, tier)
+	if !ok || limitConfig.MaxConcurrent == -1 {
+		// No concurrency limit configured for this resource
+		return lease, nil
+	}
+
+	limiter, ok := storageAs[ConcurrencyLimiter](m.storage)
+	if !ok {
+		return nil, fmt.Errorf("storage does not implement ConcurrencyLimiter")
+	}
+
+	ttl := limitConfig.LeaseTTL
+	if ttl == 0 {
+		ttl = defaultLeaseTTL
+	}
+
+	leaseID, err := newLeaseID()
+	if err != nil {
+		return nil, err
+	}
+
+	now := m.now(ctx)
+	start := time.Now()
+	acquired, active, err := limiter.AcquireLease(ctx, &LeaseRequest{
+		UserID:        userID,
+		Resource:      resource,
+		LeaseID:       leaseID,
+		MaxConcurrent: limitConfig.MaxConcurrent,
+		TTL:           ttl,
+		Now:           now,
+	})
+	m.metrics.RecordStorageOperation("AcquireLease", time.Since(start), err)
+	if err != nil {
+		m.logger.Error("failed to acquire concurrency lease",
+			Field{"userId", userID},
+			Field{"resource", resource},
+			Field{"error", err},
+		)
+		return nil, err
+	}
+	if !acquired {
+		m.logger.Warn("concurrency limit exceeded for user",
+			Field{"userId", userID},
+			Field{"resource", resource},
+			Field{"tier", tier},
+			Field{"active", active},
+			Field{"limit", limitConfig.MaxConcurrent},
+		)
+		return nil, ErrConcurrencyLimitExceeded
+	}
+
+	lease.ID = leaseID
+	lease.limiter = limiter
+	lease.limit = limitConfig.MaxConcurrent
+	lease.ttl = ttl
+	lease.expiresAt = now.Add(ttl)
+	return lease, nil
+}
+
+// ExpiresAt returns when the lease expires unless renewed.
+// Returns the zero time for leases on resources without a concurrency limit.
+func (l *Lease) ExpiresAt() time.Time {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.expiresAt
+}
+
+// Renew extends the lease by its TTL.
+// Returns ErrLeaseExpired if the lease has already expired or been released.
+func (l *Lease) Renew(ctx context.Context) error {
+	if l.limiter == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.released {
+		return ErrLeaseExpired
+	}
+
+	now := l.manager.now(ctx)
+	renewed, err := l.limiter.RenewLease(ctx, &LeaseRequest{
+		UserID:        l.UserID,
+		Resource:      l.Resource,
+		LeaseID:       l.ID,
+		MaxConcurrent: l.limit,
+		TTL:           l.ttl,
+		Now:           now,
+	})
+	if err != nil {
+		return err
+	}
+	if !renewed {
+		return ErrLeaseExpired
+	}
+	l.expiresAt = now.Add(l.ttl)
+	return nil
+}
+
+// Release frees the concurrency slot and stops any running heartbeat.
+// Releasing a lease more than once is a no-op.
+func (l *Lease) Release(ctx context.Context) error {
+	l.mu.Lock()
+	if l.released {
+		l.mu.Unlock()
+		return nil
+	}
+	l.released = true
+	close(l.stop)
+	l.mu.Unlock()
+
+	if l.limiter == nil {
+		return nil
+	}
+
+	err := l.limiter.ReleaseLease(ctx, l.UserID, l.Resource, l.ID)
+	if err != nil {
+		l.manager.logger.Error("failed to release concurrency lease",
+			Field{"userId", l.UserID},
+			Field{"resource", l.Resource},
+			Field{"leaseId", l.ID},
+			Field{"error", err},
+		)
+	}
+	return err
+}
+
+// StartHeartbeat renews the lease in the background every interval until the lease
+// is released or can no longer be renewed. An interval of 0 defaults to a third of the lease TTL.
+// Use this for long-running operations that may outlive the lease TTL.
+func (l *Lease) StartHeartbeat(interval time.Duration) {
+	if l.limiter == nil {
+		return
+	}
+	if interval <= 0 {
+		interval = l.ttl / 3
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-l.stop:
+				return
+			case <-ticker.C:
+				if err := l.Renew(context.Background()); err != nil {
+					l.manager.logger.Warn("concurrency lease heartbeat failed",
+						Field{"userId", l.UserID},
+						Field{"resource", l.Resource},
+						Field{"leaseId", l.ID},
+						Field{"error", err},
+					)
+					if err == ErrLeaseExpired {
+						return
+					}
+				}
+			}
+		}
+	}()
+}
+
+// getConcurrencyLimit returns the concurrency limit for a resource based on tier
+func (m *Manager) getConcurrencyLimit(resource, tier string) (ConcurrencyLimitConfig, bool) {
+	tierConfig, ok := m.config.Tiers[tier]
+	if !ok {
+		// Fall back to default tier
+		tierConfig, ok = m.config.Tiers[m.config.DefaultTier]
+		if !ok {
+			return ConcurrencyLimitConfig{}, false
+		}
+	}
+	limit, ok := tierConfig.ConcurrencyLimits[resource]
+	return limit, ok
+}
+
+// newLeaseID generates a random lease identifier
+func newLeaseID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate lease id: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
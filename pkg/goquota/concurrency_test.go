@@ -0,0 +1,207 @@
+package goquota_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mihaimyh/goquota/pkg/goquota"
+	"github.com/mihaimyh/goquota/storage/memory"
+)
+
+func newConcurrencyTestManager(t *testing.T, ttl time.Duration) *goquota.Manager {
+	t.Helper()
+
+	config := goquota.Config{
+		DefaultTier: "free",
+		Tiers: map[string]goquota.TierConfig{
+			"free": {
+				Name:          "free",
+				MonthlyQuotas: map[string]int{"renders": 100},
+				ConcurrencyLimits: map[string]goquota.ConcurrencyLimitConfig{
+					"renders": {MaxConcurrent: 2, LeaseTTL: ttl},
+					"exports": {MaxConcurrent: -1},
+				},
+			},
+		},
+	}
+
+	manager, err := goquota.NewManager(memory.New(), &config)
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+	return manager
+}
+
+func TestManager_Acquire_EnforcesLimit(t *testing.T) {
+	manager := newConcurrencyTestManager(t, time.Minute)
+	ctx := context.Background()
+
+	lease1, err := manager.Acquire(ctx, "user1", "renders")
+	if err != nil {
+		t.Fatalf("Acquire 1 failed: %v", err)
+	}
+	lease2, err := manager.Acquire(ctx, "user1", "renders")
+	if err != nil {
+		t.Fatalf("Acquire 2 failed: %v", err)
+	}
+	if lease1.ID == lease2.ID {
+		t.Error("Expected distinct lease IDs")
+	}
+
+	_, err = manager.Acquire(ctx, "user1", "renders")
+	if !errors.Is(err, goquota.ErrConcurrencyLimitExceeded) {
+		t.Fatalf("Expected ErrConcurrencyLimitExceeded, got %v", err)
+	}
+
+	// Other users are not affected
+	if _, err := manager.Acquire(ctx, "user2", "renders"); err != nil {
+		t.Errorf("Acquire for other user failed: %v", err)
+	}
+
+	// Releasing frees a slot
+	if err := lease1.Release(ctx); err != nil {
+		t.Fatalf("Release failed: %v", err)
+	}
+	if _, err := manager.Acquire(ctx, "user1", "renders"); err != nil {
+		t.Errorf("Acquire after release failed: %v", err)
+	}
+
+	// Double release is a no-op
+	if err := lease1.Release(ctx); err != nil {
+		t.Errorf("Second release should be a no-op, got %v", err)
+	}
+}
+
+func TestManager_Acquire_LeaseExpires(t *testing.T) {
+	manager := newConcurrencyTestManager(t, 50*time.Millisecond)
+	ctx := context.Background()
+
+	lease1, err := manager.Acquire(ctx, "user1", "renders")
+	if err != nil {
+		t.Fatalf("Acquire failed: %v", err)
+	}
+	if _, err := manager.Acquire(ctx, "user1", "renders"); err != nil {
+		t.Fatalf("Acquire failed: %v", err)
+	}
+
+	// Simulate a crashed process: leases are neither renewed nor released
+	time.Sleep(80 * time.Millisecond)
+
+	if _, err := manager.Acquire(ctx, "user1", "renders"); err != nil {
+		t.Errorf("Expected expired leases to be reclaimed, got %v", err)
+	}
+
+	if err := lease1.Renew(ctx); !errors.Is(err, goquota.ErrLeaseExpired) {
+		t.Errorf("Expected ErrLeaseExpired when renewing expired lease, got %v", err)
+	}
+}
+
+func TestManager_Acquire_RenewExtendsLease(t *testing.T) {
+	manager := newConcurrencyTestManager(t, 60*time.Millisecond)
+	ctx := context.Background()
+
+	lease, err := manager.Acquire(ctx, "user1", "renders")
+	if err != nil {
+		t.Fatalf("Acquire failed: %v", err)
+	}
+	firstExpiry := lease.ExpiresAt()
+
+	time.Sleep(30 * time.Millisecond)
+	if err := lease.Renew(ctx); err != nil {
+		t.Fatalf("Renew failed: %v", err)
+	}
+	if !lease.ExpiresAt().After(firstExpiry) {
+		t.Error("Expected Renew to extend expiration")
+	}
+
+	time.Sleep(40 * time.Millisecond)
+	// Lease would have expired without renewal
+	if err := lease.Renew(ctx); err != nil {
+		t.Errorf("Expected renewed lease to still be valid, got %v", err)
+	}
+}
+
+func TestManager_Acquire_Heartbeat(t *testing.T) {
+	manager := newConcurrencyTestManager(t, 60*time.Millisecond)
+	ctx := context.Background()
+
+	lease, err := manager.Acquire(ctx, "user1", "renders")
+	if err != nil {
+		t.Fatalf("Acquire failed: %v", err)
+	}
+	lease.StartHeartbeat(15 * time.Millisecond)
+	defer func() { _ = lease.Release(ctx) }()
+
+	time.Sleep(150 * time.Millisecond)
+
+	if err := lease.Renew(ctx); err != nil {
+		t.Errorf("Expected heartbeat to keep lease alive, got %v", err)
+	}
+}
+
+func TestManager_Acquire_UnlimitedResource(t *testing.T) {
+	manager := newConcurrencyTestManager(t, time.Minute)
+	ctx := context.Background()
+
+	for i := 0; i < 10; i++ {
+		lease, err := manager.Acquire(ctx, "user1", "exports")
+		if err != nil {
+			t.Fatalf("Acquire %d failed: %v", i, err)
+		}
+		if err := lease.Renew(ctx); err != nil {
+			t.Errorf("Renew on unlimited lease should be a no-op, got %v", err)
+		}
+	}
+
+	// Resources without a concurrency limit are not restricted either
+	if _, err := manager.Acquire(ctx, "user1", "api_calls"); err != nil {
+		t.Errorf("Acquire for unconfigured resource failed: %v", err)
+	}
+}
+
+func TestManager_Acquire_ThroughCircuitBreaker(t *testing.T) {
+	config := goquota.Config{
+		DefaultTier: "free",
+		Tiers: map[string]goquota.TierConfig{
+			"free": {
+				Name: "free",
+				ConcurrencyLimits: map[string]goquota.ConcurrencyLimitConfig{
+					"renders": {MaxConcurrent: 1},
+				},
+			},
+		},
+		CircuitBreakerConfig: &goquota.CircuitBreakerConfig{Enabled: true},
+	}
+	manager, err := goquota.NewManager(memory.New(), &config)
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+
+	ctx := context.Background()
+	if _, err := manager.Acquire(ctx, "user1", "renders"); err != nil {
+		t.Fatalf("Acquire through circuit breaker storage failed: %v", err)
+	}
+	if _, err := manager.Acquire(ctx, "user1", "renders"); !errors.Is(err, goquota.ErrConcurrencyLimitExceeded) {
+		t.Errorf("Expected ErrConcurrencyLimitExceeded, got %v", err)
+	}
+}
+
+func TestConfig_Validate_ConcurrencyLimits(t *testing.T) {
+	config := goquota.Config{
+		DefaultTier: "free",
+		Tiers: map[string]goquota.TierConfig{
+			"free": {
+				Name: "free",
+				ConcurrencyLimits: map[string]goquota.ConcurrencyLimitConfig{
+					"renders": {MaxConcurrent: -2, LeaseTTL: -time.Second},
+				},
+			},
+		},
+	}
+
+	if err := config.Validate(); err == nil {
+		t.Error("Expected validation error for invalid concurrency limits")
+	}
+}
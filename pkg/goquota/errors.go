@@ -39,6 +39,26 @@ var (
 	// ErrIdempotencyKeyExists is returned when an idempotency key already exists
 	// indicating the operation was already processed
 	ErrIdempotencyKeyExists = errors.New("idempotency key already exists - operation already processed")
+
+	// ErrConcurrencyLimitExceeded is returned when a user already holds the maximum number of leases
+	ErrConcurrencyLimitExceeded = errors.New("concurrency limit exceeded")
+
+	// ErrLeaseExpired is returned when renewing a lease that has expired or was released
+	ErrLeaseExpired = errors.New("lease expired")
+
+	// ErrWebhookDeliveryNotFound is returned when replaying an unknown webhook delivery
+	ErrWebhookDeliveryNotFound = errors.New("webhook delivery not found")
+
+	// ErrInvalidWebhookSignature is returned when a webhook signature is missing, malformed,
+	// doesn't match the payload or is outside the tolerance window
+	ErrInvalidWebhookSignature = errors.New("invalid webhook signature")
+
+	// ErrOverrideNotFound is returned when clearing a manual override that is not set
+	ErrOverrideNotFound = errors.New("override not found")
+
+	// ErrOverrideNotShared is returned when an override change applied to this Manager instance
+	// could not be saved to the OverrideStore; it is saved again until storage recovers
+	ErrOverrideNotShared = errors.New("override not shared with other instances")
 )
 
 // RateLimitExceededError provides detailed information about a rate limit exceeded error
@@ -0,0 +1,293 @@
+package goquota
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+	"sync"
+	"time"
+)
+
+const (
+	defaultEventBufferSize     = 1000
+	defaultEventPublishTimeout = 5 * time.Second
+
+	// EventSourceManager is the Source of events emitted by the Manager
+	EventSourceManager = "goquota"
+)
+
+// EventType identifies the kind of lifecycle event
+type EventType string
+
+const (
+	// EventConsumeSucceeded is emitted when quota is consumed
+	EventConsumeSucceeded EventType = "consume.succeeded"
+	// EventConsumeDenied is emitted when a consumption is rejected because the quota is exceeded
+	EventConsumeDenied EventType = "consume.denied"
+	// EventQuotaExhausted is emitted when a consumption uses up the remaining quota
+	EventQuotaExhausted EventType = "quota.exhausted"
+	// EventPeriodReset is emitted when usage for a period is reset
+	EventPeriodReset EventType = "period.reset"
+	// EventTierChanged is emitted when a user moves to a different tier
+	EventTierChanged EventType = "tier.changed"
+	// EventTopUp is emitted when credits are added to a forever balance
+	EventTopUp EventType = "credits.topped_up"
+	// EventRefund is emitted when consumed quota or credits are refunded
+	EventRefund EventType = "quota.refunded"
+	// EventRateLimited is emitted when a request is rejected by a rate limit
+	EventRateLimited EventType = "rate_limit.exceeded"
+	// EventFallbackEntered is emitted when the Manager starts serving from fallback strategies
+	EventFallbackEntered EventType = "fallback.entered"
+	// EventQuotaWarning is emitted when usage crosses a warning threshold
+	EventQuotaWarning EventType = "quota.warning"
+)
+
+// Event is a quota lifecycle event delivered to EventSinks.
+// Fields that don't apply to an event type are left empty.
+type Event struct {
+	ID     string    `json:"id"`
+	Type   EventType `json:"type"`
+	Time   time.Time `json:"time"`
+	Source string    `json:"source"` // EventSourceManager or the billing provider name
+
+	UserID       string     `json:"user_id,omitempty"`
+	Resource     string     `json:"resource,omitempty"`
+	PeriodType   PeriodType `json:"period_type,omitempty"`
+	Tier         string     `json:"tier,omitempty"`
+	PreviousTier string     `json:"previous_tier,omitempty"`
+
+	// Amount is the amount consumed, refunded or topped up
+	Amount int `json:"amount,omitempty"`
+	// Used and Limit are the usage after the event and the limit (-1 for unlimited)
+	Used  int `json:"used,omitempty"`
+	Limit int `json:"limit,omitempty"`
+	// Threshold is the warning threshold that was crossed (e.g. 0.8)
+	Threshold float64 `json:"threshold,omitempty"`
+
+	// Reason explains denials, refunds, resets and fallbacks
+	Reason string `json:"reason,omitempty"`
+
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// EventSink receives lifecycle events.
+// Publish is called from a background goroutine, one event at a time per sink.
+// Sinks that implement io.Closer are closed when the Manager is closed.
+type EventSink interface {
+	Publish(ctx context.Context, event *Event) error
+}
+
+// EventSinkFunc adapts a function to an EventSink
+type EventSinkFunc func(ctx context.Context, event *Event) error
+
+// Publish implements EventSink
+func (f EventSinkFunc) Publish(ctx context.Context, event *Event) error {
+	return f(ctx, event)
+}
+
+// eventDispatcher delivers events to sinks asynchronously.
+// Each sink has its own bounded queue so a slow sink doesn't hold back the others;
+// events are dropped when a queue is full.
+type eventDispatcher struct {
+	queues  []chan *Event
+	sinks   []EventSink
+	timeout time.Duration
+	metrics Metrics
+	logger  Logger
+
+	mu     sync.RWMutex
+	closed bool
+	wg     sync.WaitGroup
+}
+
+func newEventDispatcher(config *EventConfig, metrics Metrics, logger Logger) *eventDispatcher {
+	d := &eventDispatcher{
+		sinks:   config.Sinks,
+		timeout: config.PublishTimeout,
+		metrics: metrics,
+		logger:  logger,
+	}
+	for _, sink := range config.Sinks {
+		queue := make(chan *Event, config.BufferSize)
+		d.queues = append(d.queues, queue)
+		d.wg.Add(1)
+		go d.run(sink, queue)
+	}
+	return d
+}
+
+// emit queues an event for every sink without blocking
+func (d *eventDispatcher) emit(event *Event) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if d.closed {
+		d.metrics.RecordEventDropped(string(event.Type))
+		return
+	}
+	for _, queue := range d.queues {
+		select {
+		case queue <- event:
+		default:
+			d.metrics.RecordEventDropped(string(event.Type))
+		}
+	}
+}
+
+func (d *eventDispatcher) run(sink EventSink, queue <-chan *Event) {
+	defer d.wg.Done()
+	for event := range queue {
+		ctx, cancel := context.WithTimeout(context.Background(), d.timeout)
+		err := sink.Publish(ctx, event)
+		cancel()
+		if err != nil {
+			d.metrics.RecordEventPublishError(string(event.Type))
+			d.logger.Warn("failed to publish event",
+				Field{"eventType", event.Type},
+				Field{"userId", event.UserID},
+				Field{"error", err},
+			)
+		}
+	}
+}
+
+// close stops accepting events, waits for queued events to be published
+// (or ctx to be done) and closes sinks that implement io.Closer
+func (d *eventDispatcher) close(ctx context.Context) error {
+	d.mu.Lock()
+	if d.closed {
+		d.mu.Unlock()
+		return nil
+	}
+	d.closed = true
+	for _, queue := range d.queues {
+		close(queue)
+	}
+	d.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	var errs []error
+	for _, sink := range d.sinks {
+		if closer, ok := sink.(io.Closer); ok {
+			if err := closer.Close(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Emit publishes an event to the configured EventSinks and persists a delivery of it for every
+// subscribed webhook endpoint. Sinks publish in the background; webhook deliveries are in storage
+// before Emit returns, and an error is returned for those that couldn't be persisted.
+// ID, Time and Source are filled in if empty. It is a no-op if neither is configured.
+// Billing providers use Emit to publish their own events alongside the Manager's.
+func (m *Manager) Emit(ctx context.Context, event *Event) error {
+	if m == nil || !m.eventsEnabled() || event == nil {
+		return nil
+	}
+	if event.ID == "" {
+		event.ID = newEventID()
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now().UTC()
+	}
+	if event.Source == "" {
+		event.Source = EventSourceManager
+	}
+	if m.events != nil {
+		m.events.emit(event)
+	}
+	if m.webhooks != nil {
+		return m.webhooks.persist(ctx, event)
+	}
+	return nil
+}
+
+// emit emits an event of the Manager about a change that was already applied: webhook deliveries
+// that couldn't be persisted are logged and counted as dropped events, but don't fail the change
+func (m *Manager) emit(ctx context.Context, event *Event) {
+	//nolint:errcheck // Logged and counted by the webhook dispatcher
+	_ = m.Emit(ctx, event)
+}
+
+// eventsEnabled reports whether events have anywhere to go
+func (m *Manager) eventsEnabled() bool {
+	return m.events != nil || m.webhooks != nil
+}
+
+// emitUsageEvent emits an event about a user's resource usage
+func (m *Manager) emitUsageEvent(ctx context.Context, eventType EventType, userID, resource, tier string,
+	periodType PeriodType, amount, used, limit int, reason string) {
+	if !m.eventsEnabled() {
+		return
+	}
+	m.emit(ctx, &Event{
+		Type:       eventType,
+		UserID:     userID,
+		Resource:   resource,
+		PeriodType: periodType,
+		Tier:       tier,
+		Amount:     amount,
+		Used:       used,
+		Limit:      limit,
+		Reason:     reason,
+	})
+}
+
+// emitConsumeEvents emits EventConsumeSucceeded, and EventQuotaExhausted if the
+// consumption used up the remaining quota
+func (m *Manager) emitConsumeEvents(ctx context.Context, userID, resource, tier string,
+	periodType PeriodType, amount, newUsed, limit int, metadata map[string]string) {
+	if !m.eventsEnabled() {
+		return
+	}
+	m.emit(ctx, &Event{
+		Type:       EventConsumeSucceeded,
+		UserID:     userID,
+		Resource:   resource,
+		PeriodType: periodType,
+		Tier:       tier,
+		Amount:     amount,
+		Used:       newUsed,
+		Limit:      limit,
+		Metadata:   metadata,
+	})
+	if limit > 0 && newUsed >= limit && newUsed-amount < limit {
+		m.emitUsageEvent(ctx, EventQuotaExhausted, userID, resource, tier, periodType, amount, newUsed, limit, "")
+	}
+}
+
+// enterFallback records fallback usage and emits EventFallbackEntered
+// the first time fallback is used after storage was last healthy
+func (m *Manager) enterFallback(ctx context.Context, trigger, userID, resource string) {
+	m.metrics.RecordFallbackUsage(trigger)
+	if m.inFallback.CompareAndSwap(false, true) {
+		m.emit(ctx, &Event{
+			Type:     EventFallbackEntered,
+			UserID:   userID,
+			Resource: resource,
+			Reason:   trigger,
+		})
+	}
+}
+
+// newEventID generates a random event identifier
+func newEventID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return time.Now().UTC().Format("20060102150405.000000000")
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,60 @@
+// Package events provides built-in goquota.EventSink implementations.
+package events
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/mihaimyh/goquota/pkg/goquota"
+)
+
+// ErrSinkClosed is returned when publishing to a closed sink.
+var ErrSinkClosed = errors.New("event sink is closed")
+
+// ChannelSink delivers events to an in-process channel.
+type ChannelSink struct {
+	ch     chan *goquota.Event
+	mu     sync.RWMutex
+	closed bool
+}
+
+// NewChannelSink creates a channel sink with the given channel buffer size.
+func NewChannelSink(buffer int) *ChannelSink {
+	if buffer < 0 {
+		buffer = 0
+	}
+	return &ChannelSink{ch: make(chan *goquota.Event, buffer)}
+}
+
+// Events returns the channel events are delivered to.
+// The channel is closed when the sink is closed.
+func (s *ChannelSink) Events() <-chan *goquota.Event {
+	return s.ch
+}
+
+// Publish sends the event to the channel, waiting until there is room or ctx is done.
+func (s *ChannelSink) Publish(ctx context.Context, event *goquota.Event) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return ErrSinkClosed
+	}
+	select {
+	case s.ch <- event:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close closes the events channel.
+func (s *ChannelSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.closed {
+		s.closed = true
+		close(s.ch)
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mihaimyh/goquota/pkg/goquota"
+)
+
+func TestChannelSink_Publish(t *testing.T) {
+	sink := NewChannelSink(1)
+
+	event := &goquota.Event{Type: goquota.EventConsumeSucceeded, UserID: "user1"}
+	if err := sink.Publish(context.Background(), event); err != nil {
+		t.Fatalf("Publish failed: %v", err)
+	}
+
+	select {
+	case got := <-sink.Events():
+		if got != event {
+			t.Errorf("Expected published event, got %+v", got)
+		}
+	default:
+		t.Fatal("Expected event on channel")
+	}
+}
+
+func TestChannelSink_PublishRespectsContext(t *testing.T) {
+	sink := NewChannelSink(0)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	err := sink.Publish(ctx, &goquota.Event{Type: goquota.EventTopUp})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestChannelSink_Close(t *testing.T) {
+	sink := NewChannelSink(1)
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Second Close failed: %v", err)
+	}
+
+	if _, ok := <-sink.Events(); ok {
+		t.Error("Expected events channel to be closed")
+	}
+	if err := sink.Publish(context.Background(), &goquota.Event{}); !errors.Is(err, ErrSinkClosed) {
+		t.Errorf("Expected ErrSinkClosed, got %v", err)
+	}
+}
@@ -0,0 +1,60 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/mihaimyh/goquota/pkg/goquota"
+)
+
+// FileSink writes events as newline-delimited JSON.
+type FileSink struct {
+	mu     sync.Mutex
+	w      io.Writer
+	enc    *json.Encoder
+	closer io.Closer
+	closed bool
+}
+
+// NewFileSink opens (or creates) the file at path and appends events to it.
+func NewFileSink(path string) (*FileSink, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	sink := NewWriterSink(f)
+	sink.closer = f
+	return sink, nil
+}
+
+// NewWriterSink writes events to w. Closing the sink doesn't close w.
+func NewWriterSink(w io.Writer) *FileSink {
+	return &FileSink{w: w, enc: json.NewEncoder(w)}
+}
+
+// Publish writes the event as a single JSON line.
+func (s *FileSink) Publish(_ context.Context, event *goquota.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return ErrSinkClosed
+	}
+	return s.enc.Encode(event)
+}
+
+// Close closes the underlying file if the sink was created with NewFileSink.
+func (s *FileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	if s.closer != nil {
+		return s.closer.Close()
+	}
+	return nil
+}
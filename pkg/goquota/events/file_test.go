@@ -0,0 +1,76 @@
+package events
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mihaimyh/goquota/pkg/goquota"
+)
+
+func TestWriterSink_WritesNDJSON(t *testing.T) {
+	var buf bytes.Buffer
+	sink := NewWriterSink(&buf)
+
+	ctx := context.Background()
+	events := []*goquota.Event{
+		{ID: "1", Type: goquota.EventConsumeSucceeded, UserID: "user1", Amount: 5},
+		{ID: "2", Type: goquota.EventQuotaExhausted, UserID: "user1", Used: 100, Limit: 100},
+	}
+	for _, event := range events {
+		if err := sink.Publish(ctx, event); err != nil {
+			t.Fatalf("Publish failed: %v", err)
+		}
+	}
+
+	scanner := bufio.NewScanner(&buf)
+	var lines int
+	for scanner.Scan() {
+		var got goquota.Event
+		if err := json.Unmarshal(scanner.Bytes(), &got); err != nil {
+			t.Fatalf("Line %d is not valid JSON: %v", lines, err)
+		}
+		if got.ID != events[lines].ID || got.Type != events[lines].Type {
+			t.Errorf("Line %d: expected %s/%s, got %s/%s",
+				lines, events[lines].ID, events[lines].Type, got.ID, got.Type)
+		}
+		lines++
+	}
+	if lines != len(events) {
+		t.Errorf("Expected %d lines, got %d", len(events), lines)
+	}
+}
+
+func TestFileSink_AppendsToFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.ndjson")
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		sink, err := NewFileSink(path)
+		if err != nil {
+			t.Fatalf("NewFileSink failed: %v", err)
+		}
+		if err := sink.Publish(ctx, &goquota.Event{Type: goquota.EventRefund}); err != nil {
+			t.Fatalf("Publish failed: %v", err)
+		}
+		if err := sink.Close(); err != nil {
+			t.Fatalf("Close failed: %v", err)
+		}
+		if err := sink.Publish(ctx, &goquota.Event{}); !errors.Is(err, ErrSinkClosed) {
+			t.Errorf("Expected ErrSinkClosed, got %v", err)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if n := bytes.Count(data, []byte("\n")); n != 2 {
+		t.Errorf("Expected 2 lines after reopening, got %d", n)
+	}
+}
@@ -0,0 +1,85 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/mihaimyh/goquota/pkg/goquota"
+)
+
+const defaultHTTPTimeout = 10 * time.Second
+
+// HTTPSink POSTs each event as JSON to a URL.
+// Responses with a non-2xx status code are treated as errors.
+type HTTPSink struct {
+	url     string
+	client  *http.Client
+	headers map[string]string
+}
+
+// HTTPSinkOption configures an HTTPSink.
+type HTTPSinkOption func(*HTTPSink)
+
+// WithHTTPClient sets the HTTP client used to deliver events.
+func WithHTTPClient(client *http.Client) HTTPSinkOption {
+	return func(s *HTTPSink) {
+		if client != nil {
+			s.client = client
+		}
+	}
+}
+
+// WithHeader adds a header to every request (e.g. Authorization).
+func WithHeader(key, value string) HTTPSinkOption {
+	return func(s *HTTPSink) {
+		s.headers[key] = value
+	}
+}
+
+// NewHTTPSink creates a sink that POSTs events to url.
+func NewHTTPSink(url string, opts ...HTTPSinkOption) *HTTPSink {
+	s := &HTTPSink{
+		url:     url,
+		client:  &http.Client{Timeout: defaultHTTPTimeout},
+		headers: make(map[string]string),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Publish POSTs the event.
+func (s *HTTPSink) Publish(ctx context.Context, event *goquota.Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range s.headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post event: %w", err)
+	}
+	defer resp.Body.Close()
+	//nolint:errcheck // Drain the body so the connection can be reused
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("event endpoint returned status %d", resp.StatusCode)
+	}
+	return nil
+}
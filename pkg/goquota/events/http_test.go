@@ -0,0 +1,56 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mihaimyh/goquota/pkg/goquota"
+)
+
+func TestHTTPSink_Publish(t *testing.T) {
+	var got goquota.Event
+	var auth, contentType string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("Expected POST, got %s", r.Method)
+		}
+		auth = r.Header.Get("Authorization")
+		contentType = r.Header.Get("Content-Type")
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("Failed to decode body: %v", err)
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	sink := NewHTTPSink(server.URL, WithHeader("Authorization", "Bearer secret"))
+	event := &goquota.Event{ID: "evt_1", Type: goquota.EventTierChanged, UserID: "user1", Tier: "pro"}
+	if err := sink.Publish(context.Background(), event); err != nil {
+		t.Fatalf("Publish failed: %v", err)
+	}
+
+	if got.ID != "evt_1" || got.Type != goquota.EventTierChanged || got.Tier != "pro" {
+		t.Errorf("Unexpected event received: %+v", got)
+	}
+	if auth != "Bearer secret" {
+		t.Errorf("Expected Authorization header, got %q", auth)
+	}
+	if contentType != "application/json" {
+		t.Errorf("Expected application/json content type, got %q", contentType)
+	}
+}
+
+func TestHTTPSink_NonSuccessStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	sink := NewHTTPSink(server.URL, WithHTTPClient(server.Client()))
+	if err := sink.Publish(context.Background(), &goquota.Event{Type: goquota.EventRefund}); err == nil {
+		t.Error("Expected error for non-2xx status")
+	}
+}
@@ -0,0 +1,446 @@
+package goquota_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mihaimyh/goquota/pkg/goquota"
+	"github.com/mihaimyh/goquota/storage/memory"
+)
+
+// recordingSink collects published events
+type recordingSink struct {
+	mu     sync.Mutex
+	events []*goquota.Event
+	closed bool
+}
+
+func (s *recordingSink) Publish(_ context.Context, event *goquota.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, event)
+	return nil
+}
+
+func (s *recordingSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	return nil
+}
+
+func (s *recordingSink) types() []goquota.EventType {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	types := make([]goquota.EventType, len(s.events))
+	for i, event := range s.events {
+		types[i] = event.Type
+	}
+	return types
+}
+
+// eventMetrics counts event metrics
+type eventMetrics struct {
+	*goquota.NoopMetrics
+	dropped       atomic.Int32
+	publishErrors atomic.Int32
+}
+
+func (m *eventMetrics) RecordEventDropped(_ string)      { m.dropped.Add(1) }
+func (m *eventMetrics) RecordEventPublishError(_ string) { m.publishErrors.Add(1) }
+
+// failingConsumeStorage fails ConsumeQuota while failing is set
+type failingConsumeStorage struct {
+	goquota.Storage
+	failing atomic.Bool
+}
+
+func (s *failingConsumeStorage) ConsumeQuota(ctx context.Context, req *goquota.ConsumeRequest) (int, error) {
+	if s.failing.Load() {
+		return 0, goquota.ErrStorageUnavailable
+	}
+	return s.Storage.ConsumeQuota(ctx, req)
+}
+
+func newEventsTestManager(t *testing.T, storage goquota.Storage, eventConfig *goquota.EventConfig,
+	modify func(*goquota.Config)) *goquota.Manager {
+	t.Helper()
+
+	config := goquota.Config{
+		DefaultTier: "free",
+		Tiers: map[string]goquota.TierConfig{
+			"free": {
+				Name:          "free",
+				MonthlyQuotas: map[string]int{"api_calls": 10},
+				DailyQuotas:   map[string]int{"api_calls": 2},
+			},
+			"pro": {
+				Name:          "pro",
+				MonthlyQuotas: map[string]int{"api_calls": 100},
+			},
+		},
+		EventConfig: eventConfig,
+	}
+	if modify != nil {
+		modify(&config)
+	}
+
+	manager, err := goquota.NewManager(storage, &config)
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := manager.Close(ctx); err != nil {
+			t.Errorf("Close failed: %v", err)
+		}
+	})
+	return manager
+}
+
+func assertEventTypes(t *testing.T, got, want []goquota.EventType) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("Expected events %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Event %d: expected %s, got %s (all: %v)", i, want[i], got[i], got)
+		}
+	}
+}
+
+func TestManager_Events_ConsumeLifecycle(t *testing.T) {
+	sink := &recordingSink{}
+	manager := newEventsTestManager(t, memory.New(), &goquota.EventConfig{Sinks: []goquota.EventSink{sink}}, nil)
+	ctx := context.Background()
+
+	if _, err := manager.Consume(ctx, "user1", "api_calls", 6, goquota.PeriodTypeMonthly); err != nil {
+		t.Fatalf("Consume failed: %v", err)
+	}
+	if _, err := manager.Consume(ctx, "user1", "api_calls", 4, goquota.PeriodTypeMonthly); err != nil {
+		t.Fatalf("Consume failed: %v", err)
+	}
+	_, err := manager.Consume(ctx, "user1", "api_calls", 1, goquota.PeriodTypeMonthly)
+	if !errors.Is(err, goquota.ErrQuotaExceeded) {
+		t.Fatalf("Expected ErrQuotaExceeded, got %v", err)
+	}
+	// Dry-runs don't emit events
+	if _, err := manager.Consume(ctx, "user1", "api_calls", 1, goquota.PeriodTypeMonthly,
+		goquota.WithDryRun(true)); err != nil {
+		t.Fatalf("Dry-run consume failed: %v", err)
+	}
+
+	if err := manager.Close(ctx); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	assertEventTypes(t, sink.types(), []goquota.EventType{
+		goquota.EventConsumeSucceeded,
+		goquota.EventConsumeSucceeded,
+		goquota.EventQuotaExhausted,
+		goquota.EventConsumeDenied,
+	})
+
+	exhausted := sink.events[2]
+	if exhausted.UserID != "user1" || exhausted.Resource != "api_calls" || exhausted.Tier != "free" {
+		t.Errorf("Unexpected exhausted event: %+v", exhausted)
+	}
+	if exhausted.Used != 10 || exhausted.Limit != 10 || exhausted.PeriodType != goquota.PeriodTypeMonthly {
+		t.Errorf("Expected used 10 of 10 monthly, got %d of %d %s", exhausted.Used, exhausted.Limit, exhausted.PeriodType)
+	}
+	if exhausted.ID == "" || exhausted.Time.IsZero() || exhausted.Source != goquota.EventSourceManager {
+		t.Errorf("Expected ID, Time and Source to be filled in, got %+v", exhausted)
+	}
+	if denied := sink.events[3]; denied.Reason != "quota_exceeded" || denied.Amount != 1 {
+		t.Errorf("Unexpected denied event: %+v", denied)
+	}
+	if !sink.closed {
+		t.Error("Expected sink to be closed")
+	}
+}
+
+func TestManager_Events_AutoPeriodDeniedOnce(t *testing.T) {
+	sink := &recordingSink{}
+	manager := newEventsTestManager(t, memory.New(), &goquota.EventConfig{Sinks: []goquota.EventSink{sink}},
+		func(c *goquota.Config) {
+			tier := c.Tiers["free"]
+			tier.MonthlyQuotas = map[string]int{"api_calls": 1}
+			tier.DailyQuotas = map[string]int{"api_calls": 1}
+			c.Tiers["free"] = tier
+		})
+	ctx := context.Background()
+
+	if _, err := manager.Consume(ctx, "user1", "api_calls", 5, goquota.PeriodTypeAuto); err != goquota.ErrQuotaExceeded {
+		t.Fatalf("Expected ErrQuotaExceeded, got %v", err)
+	}
+	if err := manager.Close(ctx); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	assertEventTypes(t, sink.types(), []goquota.EventType{goquota.EventConsumeDenied})
+	if sink.events[0].PeriodType != goquota.PeriodTypeAuto {
+		t.Errorf("Expected denial for %s, got %s", goquota.PeriodTypeAuto, sink.events[0].PeriodType)
+	}
+}
+
+func TestManager_Events_AdminOperations(t *testing.T) {
+	sink := &recordingSink{}
+	manager := newEventsTestManager(t, memory.New(), &goquota.EventConfig{Sinks: []goquota.EventSink{sink}}, nil)
+	ctx := context.Background()
+
+	if err := manager.SetEntitlement(ctx, &goquota.Entitlement{
+		UserID:                "user1",
+		Tier:                  "free",
+		SubscriptionStartDate: time.Now().UTC(),
+	}); err != nil {
+		t.Fatalf("SetEntitlement failed: %v", err)
+	}
+	if _, err := manager.Consume(ctx, "user1", "api_calls", 3, goquota.PeriodTypeMonthly); err != nil {
+		t.Fatalf("Consume failed: %v", err)
+	}
+	if err := manager.Refund(ctx, &goquota.RefundRequest{
+		UserID:     "user1",
+		Resource:   "api_calls",
+		Amount:     1,
+		PeriodType: goquota.PeriodTypeMonthly,
+		Reason:     "failed_request",
+	}); err != nil {
+		t.Fatalf("Refund failed: %v", err)
+	}
+	if err := manager.TopUpLimit(ctx, "user1", "credits", 50); err != nil {
+		t.Fatalf("TopUpLimit failed: %v", err)
+	}
+	if err := manager.RefundCredits(ctx, "user1", "credits", 20, "chargeback"); err != nil {
+		t.Fatalf("RefundCredits failed: %v", err)
+	}
+	if err := manager.ApplyTierChange(ctx, "user1", "free", "pro", "api_calls"); err != nil {
+		t.Fatalf("ApplyTierChange failed: %v", err)
+	}
+	if err := manager.ResetUsage(ctx, "user1", "api_calls", goquota.PeriodTypeMonthly); err != nil {
+		t.Fatalf("ResetUsage failed: %v", err)
+	}
+
+	if err := manager.Close(ctx); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	assertEventTypes(t, sink.types(), []goquota.EventType{
+		goquota.EventConsumeSucceeded,
+		goquota.EventRefund,
+		goquota.EventTopUp,
+		goquota.EventRefund,
+		goquota.EventTierChanged,
+		goquota.EventPeriodReset,
+	})
+
+	if refund := sink.events[1]; refund.Reason != "failed_request" || refund.Amount != 1 {
+		t.Errorf("Unexpected refund event: %+v", refund)
+	}
+	if topUp := sink.events[2]; topUp.Amount != 50 || topUp.PeriodType != goquota.PeriodTypeForever {
+		t.Errorf("Unexpected top-up event: %+v", topUp)
+	}
+	if creditRefund := sink.events[3]; creditRefund.Reason != "chargeback" || creditRefund.Amount != 20 {
+		t.Errorf("Unexpected credit refund event: %+v", creditRefund)
+	}
+	if tierChange := sink.events[4]; tierChange.PreviousTier != "free" || tierChange.Tier != "pro" {
+		t.Errorf("Expected free -> pro, got %s -> %s", tierChange.PreviousTier, tierChange.Tier)
+	}
+}
+
+func TestManager_Events_RateLimited(t *testing.T) {
+	sink := &recordingSink{}
+	manager := newEventsTestManager(t, memory.New(), &goquota.EventConfig{Sinks: []goquota.EventSink{sink}},
+		func(c *goquota.Config) {
+			tier := c.Tiers["free"]
+			tier.RateLimits = map[string]goquota.RateLimitConfig{
+				"api_calls": {Algorithm: "sliding_window", Rate: 1, Window: time.Minute},
+			}
+			c.Tiers["free"] = tier
+		})
+	ctx := context.Background()
+
+	if _, err := manager.Consume(ctx, "user1", "api_calls", 1, goquota.PeriodTypeMonthly); err != nil {
+		t.Fatalf("Consume failed: %v", err)
+	}
+	_, err := manager.Consume(ctx, "user1", "api_calls", 1, goquota.PeriodTypeMonthly)
+	var rateLimitErr *goquota.RateLimitExceededError
+	if !errors.As(err, &rateLimitErr) {
+		t.Fatalf("Expected RateLimitExceededError, got %v", err)
+	}
+
+	if err := manager.Close(ctx); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	assertEventTypes(t, sink.types(), []goquota.EventType{goquota.EventConsumeSucceeded, goquota.EventRateLimited})
+}
+
+func TestManager_Events_FallbackEntered(t *testing.T) {
+	sink := &recordingSink{}
+	storage := &failingConsumeStorage{Storage: memory.New()}
+	manager := newEventsTestManager(t, storage, &goquota.EventConfig{Sinks: []goquota.EventSink{sink}},
+		func(c *goquota.Config) {
+			c.FallbackConfig = &goquota.FallbackConfig{Enabled: true, FallbackToCache: true}
+		})
+	ctx := context.Background()
+
+	storage.failing.Store(true)
+	for i := 0; i < 2; i++ {
+		if _, err := manager.Consume(ctx, "user1", "api_calls", 1, goquota.PeriodTypeMonthly); err == nil {
+			t.Fatal("Expected consume to fail without cached usage")
+		}
+	}
+
+	// A successful consume leaves fallback, so the next failure emits again
+	storage.failing.Store(false)
+	if _, err := manager.Consume(ctx, "user1", "api_calls", 1, goquota.PeriodTypeMonthly); err != nil {
+		t.Fatalf("Consume failed: %v", err)
+	}
+	storage.failing.Store(true)
+	//nolint:errcheck // The failure itself is covered above
+	_, _ = manager.Consume(ctx, "user1", "api_calls", 1, goquota.PeriodTypeMonthly)
+
+	if err := manager.Close(ctx); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	assertEventTypes(t, sink.types(), []goquota.EventType{
+		goquota.EventFallbackEntered,
+		goquota.EventConsumeSucceeded,
+		goquota.EventFallbackEntered,
+	})
+	if sink.events[0].Reason != "storage_error" {
+		t.Errorf("Expected storage_error reason, got %q", sink.events[0].Reason)
+	}
+}
+
+func TestManager_Events_DropsWhenBufferFull(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{}, 1)
+	var published atomic.Int32
+	blocking := goquota.EventSinkFunc(func(_ context.Context, _ *goquota.Event) error {
+		select {
+		case started <- struct{}{}:
+		default:
+		}
+		<-release
+		published.Add(1)
+		return nil
+	})
+
+	metrics := &eventMetrics{NoopMetrics: &goquota.NoopMetrics{}}
+	manager := newEventsTestManager(t, memory.New(), &goquota.EventConfig{
+		Sinks:      []goquota.EventSink{blocking},
+		BufferSize: 1,
+	}, func(c *goquota.Config) {
+		c.Metrics = metrics
+	})
+	ctx := context.Background()
+
+	// The first event is picked up by the sink, which then blocks
+	manager.Emit(ctx, &goquota.Event{Type: goquota.EventTopUp})
+	<-started
+
+	// The second event fills the buffer; the rest are dropped without blocking
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 3; i++ {
+			if _, err := manager.Consume(ctx, "user1", "api_calls", 1, goquota.PeriodTypeMonthly); err != nil {
+				t.Errorf("Consume failed: %v", err)
+			}
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Consume blocked on a full event buffer")
+	}
+
+	if got := metrics.dropped.Load(); got != 2 {
+		t.Errorf("Expected 2 dropped events, got %d", got)
+	}
+
+	close(release)
+	if err := manager.Close(ctx); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if got := published.Load(); got != 2 {
+		t.Errorf("Expected 2 published events, got %d", got)
+	}
+
+	// Events emitted after Close are dropped
+	manager.Emit(ctx, &goquota.Event{Type: goquota.EventTopUp})
+	if got := metrics.dropped.Load(); got != 3 {
+		t.Errorf("Expected 3 dropped events after Close, got %d", got)
+	}
+}
+
+func TestManager_Events_PublishErrors(t *testing.T) {
+	failing := goquota.EventSinkFunc(func(_ context.Context, _ *goquota.Event) error {
+		return errors.New("sink unavailable")
+	})
+	other := &recordingSink{}
+
+	metrics := &eventMetrics{NoopMetrics: &goquota.NoopMetrics{}}
+	manager := newEventsTestManager(t, memory.New(), &goquota.EventConfig{
+		Sinks: []goquota.EventSink{failing, other},
+	}, func(c *goquota.Config) {
+		c.Metrics = metrics
+	})
+	ctx := context.Background()
+
+	manager.Emit(ctx, &goquota.Event{Type: goquota.EventTopUp})
+	if err := manager.Close(ctx); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	if got := metrics.publishErrors.Load(); got != 1 {
+		t.Errorf("Expected 1 publish error, got %d", got)
+	}
+	assertEventTypes(t, other.types(), []goquota.EventType{goquota.EventTopUp})
+}
+
+func TestManager_Events_NoSinks(t *testing.T) {
+	manager := newEventsTestManager(t, memory.New(), nil, nil)
+	ctx := context.Background()
+
+	manager.Emit(ctx, &goquota.Event{Type: goquota.EventTopUp})
+	if _, err := manager.Consume(ctx, "user1", "api_calls", 1, goquota.PeriodTypeMonthly); err != nil {
+		t.Fatalf("Consume failed: %v", err)
+	}
+	if err := manager.Close(ctx); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+}
+
+func TestConfig_Validate_EventConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  *goquota.EventConfig
+		wantErr bool
+	}{
+		{name: "valid", config: &goquota.EventConfig{Sinks: []goquota.EventSink{&recordingSink{}}}},
+		{name: "negative buffer", config: &goquota.EventConfig{BufferSize: -1}, wantErr: true},
+		{name: "negative timeout", config: &goquota.EventConfig{PublishTimeout: -time.Second}, wantErr: true},
+		{name: "nil sink", config: &goquota.EventConfig{Sinks: []goquota.EventSink{nil}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := goquota.Config{
+				DefaultTier: "free",
+				Tiers:       map[string]goquota.TierConfig{"free": {Name: "free"}},
+				EventConfig: tt.config,
+			}
+			err := config.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -234,6 +234,17 @@ func (s *OptimisticFallbackStrategy) ResetOptimisticUsage(key string) {
 	delete(s.optimisticUsage, key)
 }
 
+// releaseOptimisticUsage returns amount to the optimistic allowance of key once it reached storage
+func (s *OptimisticFallbackStrategy) releaseOptimisticUsage(key string, amount int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if remaining := s.optimisticUsage[key] - amount; remaining > 0 {
+		s.optimisticUsage[key] = remaining
+	} else {
+		delete(s.optimisticUsage, key)
+	}
+}
+
 // GetOptimisticUsage returns current optimistic usage for a key (for testing/reconciliation)
 func (s *OptimisticFallbackStrategy) GetOptimisticUsage(key string) int {
 	s.mu.RLock()
@@ -204,6 +204,16 @@ func (m *mockMetrics) RecordActiveUserByTier(_ string)
 func (m *mockMetrics) RecordUsersApproachingLimit(_, _, _ string)                {}
 func (m *mockMetrics) RecordResourceFilterQueriesSaved(_ int)                    {}
 func (m *mockMetrics) RecordResourceFilterEffectivenessRatio(_ float64)          {}
+func (m *mockMetrics) RecordEventDropped(_ string)                               {}
+func (m *mockMetrics) RecordEventPublishError(_ string)                          {}
+func (m *mockMetrics) RecordWebhookDelivery(_, _ string)                         {}
+func (m *mockMetrics) RecordStorageRetry(_ string)                               {}
+func (m *mockMetrics) RecordOverrideDecision(_, _, _ string)                     {}
+func (m *mockMetrics) RecordOptimisticReplay(_, _ string)                        {}
+func (m *mockMetrics) RecordQuotaLease(_, _ string, _ int)                       {}
+func (m *mockMetrics) RecordSyncOutbox(_, _ int, _ time.Duration)                {}
+func (m *mockMetrics) RecordSyncOutboxDelivery(_ string)                         {}
+func (m *mockMetrics) RecordSyncDrift(_, _ string)                               {}
 
 // mockLogger is a mock logger implementation for testing
 type mockLogger struct{}
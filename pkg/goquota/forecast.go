@@ -0,0 +1,360 @@
+package goquota
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+)
+
+const (
+	defaultSnapshotInterval = time.Hour
+	defaultMinSnapshots     = 3
+
+	// forecastZScore is the z-score of the two-sided 95% confidence band
+	forecastZScore = 1.96
+)
+
+// Forecast is a projection of a resource's usage until the end of the current period.
+// Projections assume consumption continues at the rate observed so far in the period.
+type Forecast struct {
+	UserID   string
+	Resource string
+	Tier     string
+	Period   Period
+
+	// Used and Limit are the current usage and limit (-1 for unlimited)
+	Used  int
+	Limit int
+
+	// GeneratedAt is when the forecast was computed
+	GeneratedAt time.Time
+
+	// Samples is the number of observations the forecast is based on
+	Samples int
+
+	// RatePerHour is the estimated consumption rate.
+	// RatePerHourLow and RatePerHourHigh bound the rate at 95% confidence.
+	RatePerHour     float64
+	RatePerHourLow  float64
+	RatePerHourHigh float64
+
+	// ProjectedUsed is the projected usage at the end of the period.
+	// ProjectedUsedLow and ProjectedUsedHigh bound the projection at 95% confidence.
+	ProjectedUsed     int
+	ProjectedUsedLow  int
+	ProjectedUsedHigh int
+
+	// ExhaustsAt is when usage is projected to reach the limit.
+	// Nil if the limit is not projected to be reached before the period resets.
+	// ExhaustsAtEarliest and ExhaustsAtLatest bound the estimate at 95% confidence.
+	ExhaustsAt         *time.Time
+	ExhaustsAtEarliest *time.Time
+	ExhaustsAtLatest   *time.Time
+
+	// WillExceed is true if usage is projected to reach the limit before the period resets
+	WillExceed bool
+}
+
+// forecastState tracks snapshot recording and forecast warnings for a user and resource
+type forecastState struct {
+	periodKey string
+	periodEnd time.Time
+	bucket    time.Time
+	warned    bool
+}
+
+// Forecast projects when a resource's quota will be exhausted and how much will be used
+// by the end of the current period. The monthly period is used unless the tier only
+// defines a daily quota for the resource.
+//
+// The projection is a least-squares fit over usage snapshots recorded during the period
+// (see ForecastConfig). Without snapshots it falls back to the average rate since the
+// period started, and the confidence band spans zero to twice that rate.
+func (m *Manager) Forecast(ctx context.Context, userID, resource string) (*Forecast, error) {
+	ent, err := m.GetEntitlement(ctx, userID)
+	if err != nil && err != ErrEntitlementNotFound {
+		return nil, err
+	}
+	tier := m.config.DefaultTier
+	if err == nil && ent != nil {
+		tier = ent.Tier
+	}
+
+	periodType := m.forecastPeriodType(resource, tier)
+	usage, err := m.GetQuota(ctx, userID, resource, periodType)
+	if err != nil {
+		return nil, err
+	}
+
+	var snapshots []*UsageSnapshot
+	if store, ok := storageAs[UsageSnapshotStore](m.storage); ok {
+		start := time.Now()
+		snapshots, err = store.GetUsageSnapshots(ctx, userID, resource, usage.Period)
+		m.metrics.RecordStorageOperation("GetUsageSnapshots", time.Since(start), err)
+		if err != nil {
+			m.logger.Warn("failed to get usage snapshots, forecasting from period average",
+				Field{"userId", userID},
+				Field{"resource", resource},
+				Field{"error", err},
+			)
+			snapshots = nil
+		}
+	}
+
+	forecast := forecastUsage(usage, snapshots, m.now(ctx))
+	forecast.UserID = userID
+	forecast.Resource = resource
+	forecast.Tier = tier
+	return forecast, nil
+}
+
+// forecastPeriodType returns the period type a resource is forecast against
+func (m *Manager) forecastPeriodType(resource, tier string) PeriodType {
+	if m.getLimitForResource(resource, tier, PeriodTypeMonthly) == 0 &&
+		m.getLimitForResource(resource, tier, PeriodTypeDaily) != 0 {
+		return PeriodTypeDaily
+	}
+	return PeriodTypeMonthly
+}
+
+// forecastUsage fits a linear model to the observed usage and projects it to the end of the period
+func forecastUsage(usage *Usage, snapshots []*UsageSnapshot, now time.Time) *Forecast {
+	period := usage.Period
+	forecast := &Forecast{
+		UserID:      usage.UserID,
+		Resource:    usage.Resource,
+		Tier:        usage.Tier,
+		Period:      period,
+		Used:        usage.Used,
+		Limit:       usage.Limit,
+		GeneratedAt: now,
+	}
+
+	// Observations as (hours since period start, used)
+	xs := make([]float64, 0, len(snapshots)+2)
+	ys := make([]float64, 0, len(snapshots)+2)
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].RecordedAt.Before(snapshots[j].RecordedAt)
+	})
+	for _, snap := range snapshots {
+		if snap.RecordedAt.Before(period.Start) || !snap.RecordedAt.Before(now) {
+			continue
+		}
+		xs = append(xs, snap.RecordedAt.Sub(period.Start).Hours())
+		ys = append(ys, float64(snap.Used))
+	}
+	if len(xs) == 0 {
+		// No history: assume usage started from zero at the beginning of the period
+		xs = append(xs, 0)
+		ys = append(ys, 0)
+	}
+	xs = append(xs, now.Sub(period.Start).Hours())
+	ys = append(ys, float64(usage.Used))
+	forecast.Samples = len(xs)
+
+	rate, stdErr := fitRate(xs, ys)
+	rate = math.Max(rate, 0)
+	forecast.RatePerHour = rate
+	if forecast.Samples < 3 {
+		forecast.RatePerHourLow = 0
+		forecast.RatePerHourHigh = 2 * rate
+	} else {
+		forecast.RatePerHourLow = math.Max(rate-forecastZScore*stdErr, 0)
+		forecast.RatePerHourHigh = rate + forecastZScore*stdErr
+	}
+
+	remaining := period.End.Sub(now).Hours()
+	if period.Type == PeriodTypeForever || remaining < 0 {
+		remaining = 0
+	}
+	forecast.ProjectedUsed = projectUsage(usage.Used, forecast.RatePerHour, remaining)
+	forecast.ProjectedUsedLow = projectUsage(usage.Used, forecast.RatePerHourLow, remaining)
+	forecast.ProjectedUsedHigh = projectUsage(usage.Used, forecast.RatePerHourHigh, remaining)
+
+	if usage.Limit > 0 {
+		forecast.ExhaustsAt = exhaustionTime(usage, forecast.RatePerHour, now)
+		forecast.ExhaustsAtEarliest = exhaustionTime(usage, forecast.RatePerHourHigh, now)
+		forecast.ExhaustsAtLatest = exhaustionTime(usage, forecast.RatePerHourLow, now)
+		forecast.WillExceed = forecast.ExhaustsAt != nil
+	}
+
+	return forecast
+}
+
+// fitRate returns the least-squares slope of ys over xs and its standard error
+func fitRate(xs, ys []float64) (slope, stdErr float64) {
+	n := float64(len(xs))
+	var meanX, meanY float64
+	for i := range xs {
+		meanX += xs[i]
+		meanY += ys[i]
+	}
+	meanX /= n
+	meanY /= n
+
+	var sxx, sxy float64
+	for i := range xs {
+		sxx += (xs[i] - meanX) * (xs[i] - meanX)
+		sxy += (xs[i] - meanX) * (ys[i] - meanY)
+	}
+	if sxx == 0 {
+		return 0, 0
+	}
+	slope = sxy / sxx
+
+	if len(xs) < 3 {
+		return slope, 0
+	}
+	intercept := meanY - slope*meanX
+	var sse float64
+	for i := range xs {
+		residual := ys[i] - (intercept + slope*xs[i])
+		sse += residual * residual
+	}
+	return slope, math.Sqrt(sse / (n - 2) / sxx)
+}
+
+// projectUsage projects usage forward by hours at the given rate
+func projectUsage(used int, ratePerHour, hours float64) int {
+	return used + int(math.Round(ratePerHour*hours))
+}
+
+// exhaustionTime returns when usage reaches the limit at the given rate,
+// or nil if that happens after the period resets
+func exhaustionTime(usage *Usage, ratePerHour float64, now time.Time) *time.Time {
+	if usage.Used >= usage.Limit {
+		t := now
+		return &t
+	}
+	if ratePerHour <= 0 {
+		return nil
+	}
+	hours := float64(usage.Limit-usage.Used) / ratePerHour
+	if hours > usage.Period.End.Sub(now).Hours() {
+		return nil
+	}
+	t := now.Add(time.Duration(hours * float64(time.Hour)))
+	return &t
+}
+
+// evictForecastStates removes, at most once per snapshot interval, the states of periods that
+// ended, so users and resources that stop consuming do not stay in memory.
+// The caller must hold forecastMu.
+func (m *Manager) evictForecastStates(now time.Time) {
+	if now.Before(m.forecastEvictAt) {
+		return
+	}
+	m.forecastEvictAt = now.Add(m.config.ForecastConfig.SnapshotInterval)
+	for key, state := range m.forecastStates {
+		if !now.Before(state.periodEnd) {
+			delete(m.forecastStates, key)
+		}
+	}
+}
+
+// recordUsageSnapshot records a usage snapshot once per bucket and, if configured,
+// emits a forecast warning when usage is projected to exceed the limit before reset.
+func (m *Manager) recordUsageSnapshot(ctx context.Context, userID, resource, tier string,
+	limit, used int, period Period) {
+	if m.config.ForecastConfig == nil || !m.config.ForecastConfig.Enabled || period.Type == PeriodTypeForever {
+		return
+	}
+	store, ok := storageAs[UsageSnapshotStore](m.storage)
+	if !ok {
+		return
+	}
+
+	now := m.now(ctx)
+	bucket := now.Truncate(m.config.ForecastConfig.SnapshotInterval)
+	stateKey := userID + ":" + resource + ":" + string(period.Type)
+
+	m.forecastMu.Lock()
+	m.evictForecastStates(now)
+	state, ok := m.forecastStates[stateKey]
+	if !ok || state.periodKey != period.Key() {
+		state = &forecastState{periodKey: period.Key(), periodEnd: period.End}
+		m.forecastStates[stateKey] = state
+	}
+	if state.bucket.Equal(bucket) {
+		m.forecastMu.Unlock()
+		return
+	}
+	state.bucket = bucket
+	checkForecast := m.config.ForecastConfig.WarnOnProjectedExhaustion && !state.warned && limit > 0
+	m.forecastMu.Unlock()
+
+	start := time.Now()
+	err := store.RecordUsageSnapshot(ctx, &UsageSnapshot{
+		UserID:     userID,
+		Resource:   resource,
+		Period:     period,
+		Bucket:     bucket,
+		Used:       used,
+		RecordedAt: now,
+	})
+	m.metrics.RecordStorageOperation("RecordUsageSnapshot", time.Since(start), err)
+	if err != nil {
+		m.logger.Warn("failed to record usage snapshot",
+			Field{"userId", userID},
+			Field{"resource", resource},
+			Field{"error", err},
+		)
+		return
+	}
+
+	if !checkForecast {
+		return
+	}
+
+	snapshots, err := store.GetUsageSnapshots(ctx, userID, resource, period)
+	if err != nil {
+		m.logger.Warn("failed to get usage snapshots for forecast warning",
+			Field{"userId", userID},
+			Field{"resource", resource},
+			Field{"error", err},
+		)
+		return
+	}
+	if len(snapshots) < m.config.ForecastConfig.MinSnapshots {
+		return
+	}
+	forecast := forecastUsage(&Usage{
+		UserID:   userID,
+		Resource: resource,
+		Used:     used,
+		Limit:    limit,
+		Period:   period,
+		Tier:     tier,
+	}, snapshots, now)
+	if !forecast.WillExceed || used >= limit {
+		return
+	}
+
+	m.forecastMu.Lock()
+	if state.warned {
+		m.forecastMu.Unlock()
+		return
+	}
+	state.warned = true
+	m.forecastMu.Unlock()
+
+	m.logger.Info("usage projected to exceed quota before reset",
+		Field{"userId", userID},
+		Field{"resource", resource},
+		Field{"tier", tier},
+		Field{"exhaustsAt", *forecast.ExhaustsAt},
+		Field{"projectedUsed", forecast.ProjectedUsed},
+		Field{"limit", limit},
+	)
+
+	// Call global handler
+	if handler, ok := m.config.WarningHandler.(ForecastWarningHandler); ok {
+		handler.OnForecastWarning(ctx, forecast)
+	}
+
+	// Call context handler if present
+	if ctxHandler, ok := ctx.Value(contextWarningKey{}).(ForecastWarningHandler); ok {
+		ctxHandler.OnForecastWarning(ctx, forecast)
+	}
+}
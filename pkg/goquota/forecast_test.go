@@ -0,0 +1,334 @@
+package goquota_test
+
+import (
+	"context"
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mihaimyh/goquota/pkg/goquota"
+	"github.com/mihaimyh/goquota/storage/memory"
+)
+
+// clockStorage is memory storage with a controllable TimeSource
+type clockStorage struct {
+	*memory.Storage
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (s *clockStorage) Now(_ context.Context) (time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.now, nil
+}
+
+func (s *clockStorage) set(t time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.now = t
+}
+
+// forecastRecorder records forecast warnings
+type forecastRecorder struct {
+	mu        sync.Mutex
+	forecasts []*goquota.Forecast
+}
+
+func (r *forecastRecorder) OnWarning(_ context.Context, _ *goquota.Usage, _ float64) {}
+
+func (r *forecastRecorder) OnForecastWarning(_ context.Context, forecast *goquota.Forecast) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.forecasts = append(r.forecasts, forecast)
+}
+
+func (r *forecastRecorder) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.forecasts)
+}
+
+var forecastPeriodStart = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// newForecastStorage returns memory storage whose clock is at the start of user1's free cycle
+func newForecastStorage(t *testing.T) *clockStorage {
+	t.Helper()
+	storage := &clockStorage{Storage: memory.New(), now: forecastPeriodStart}
+	err := storage.SetEntitlement(context.Background(), &goquota.Entitlement{
+		UserID:                "user1",
+		Tier:                  "free",
+		SubscriptionStartDate: forecastPeriodStart,
+		UpdatedAt:             forecastPeriodStart,
+	})
+	if err != nil {
+		t.Fatalf("Failed to set entitlement: %v", err)
+	}
+	return storage
+}
+
+// withForecast configures the free tier of a newEventsTestManager for forecasts
+func withForecast(forecastConfig *goquota.ForecastConfig, handler goquota.WarningHandler) func(*goquota.Config) {
+	return func(config *goquota.Config) {
+		config.Tiers["free"] = goquota.TierConfig{
+			Name:          "free",
+			MonthlyQuotas: map[string]int{"api_calls": 1000, "unlimited_calls": -1},
+			DailyQuotas:   map[string]int{"daily_calls": 100},
+		}
+		config.ForecastConfig = forecastConfig
+		config.WarningHandler = handler
+	}
+}
+
+func TestManager_Forecast_NoHistory(t *testing.T) {
+	storage := newForecastStorage(t)
+	manager := newEventsTestManager(t, storage, nil, withForecast(nil, nil))
+	ctx := context.Background()
+
+	// 100 units in the first 10 days of a 31-day cycle
+	storage.set(forecastPeriodStart.Add(10 * 24 * time.Hour))
+	if _, err := manager.Consume(ctx, "user1", "api_calls", 100, goquota.PeriodTypeMonthly); err != nil {
+		t.Fatalf("Consume failed: %v", err)
+	}
+
+	forecast, err := manager.Forecast(ctx, "user1", "api_calls")
+	if err != nil {
+		t.Fatalf("Forecast failed: %v", err)
+	}
+
+	if forecast.Period.Type != goquota.PeriodTypeMonthly {
+		t.Errorf("Expected monthly period, got %s", forecast.Period.Type)
+	}
+	if forecast.Samples != 2 {
+		t.Errorf("Expected 2 samples, got %d", forecast.Samples)
+	}
+	if math.Abs(forecast.RatePerHour-100.0/240.0) > 1e-9 {
+		t.Errorf("Expected average rate %f, got %f", 100.0/240.0, forecast.RatePerHour)
+	}
+	if forecast.ProjectedUsed != 310 {
+		t.Errorf("Expected projected usage 310, got %d", forecast.ProjectedUsed)
+	}
+	if forecast.ProjectedUsedLow != 100 || forecast.ProjectedUsedHigh != 520 {
+		t.Errorf("Expected wide band [100, 520] without history, got [%d, %d]",
+			forecast.ProjectedUsedLow, forecast.ProjectedUsedHigh)
+	}
+	if forecast.WillExceed || forecast.ExhaustsAt != nil {
+		t.Errorf("Expected no exhaustion before reset, got %v", forecast.ExhaustsAt)
+	}
+	if forecast.ExhaustsAtEarliest != nil {
+		t.Errorf("Expected no earliest exhaustion before reset, got %v", forecast.ExhaustsAtEarliest)
+	}
+}
+
+func TestManager_Forecast_WithSnapshots(t *testing.T) {
+	storage := newForecastStorage(t)
+	manager := newEventsTestManager(t, storage, nil, withForecast(&goquota.ForecastConfig{Enabled: true}, nil))
+	ctx := context.Background()
+
+	// Consume 2 units every hour for 10 hours
+	for h := 1; h <= 10; h++ {
+		storage.set(forecastPeriodStart.Add(time.Duration(h) * time.Hour))
+		if _, err := manager.Consume(ctx, "user1", "api_calls", 2, goquota.PeriodTypeMonthly); err != nil {
+			t.Fatalf("Consume failed: %v", err)
+		}
+	}
+
+	snapshots, err := storage.GetUsageSnapshots(ctx, "user1", "api_calls", goquota.Period{
+		Start: forecastPeriodStart,
+		End:   forecastPeriodStart.AddDate(0, 1, 0),
+		Type:  goquota.PeriodTypeMonthly,
+	})
+	if err != nil {
+		t.Fatalf("GetUsageSnapshots failed: %v", err)
+	}
+	if len(snapshots) != 10 {
+		t.Fatalf("Expected 10 snapshots (one per hour), got %d", len(snapshots))
+	}
+
+	forecast, err := manager.Forecast(ctx, "user1", "api_calls")
+	if err != nil {
+		t.Fatalf("Forecast failed: %v", err)
+	}
+
+	if math.Abs(forecast.RatePerHour-2) > 1e-9 {
+		t.Errorf("Expected rate 2/h, got %f", forecast.RatePerHour)
+	}
+	// 744h cycle, 734h remaining: 20 + 2*734
+	if forecast.ProjectedUsed != 1488 {
+		t.Errorf("Expected projected usage 1488, got %d", forecast.ProjectedUsed)
+	}
+	// Perfectly linear history has no uncertainty
+	if forecast.ProjectedUsedLow != 1488 || forecast.ProjectedUsedHigh != 1488 {
+		t.Errorf("Expected tight band, got [%d, %d]", forecast.ProjectedUsedLow, forecast.ProjectedUsedHigh)
+	}
+	if !forecast.WillExceed || forecast.ExhaustsAt == nil {
+		t.Fatal("Expected exhaustion before reset")
+	}
+	expected := forecastPeriodStart.Add(10*time.Hour + 490*time.Hour)
+	if !forecast.ExhaustsAt.Equal(expected) {
+		t.Errorf("Expected exhaustion at %v, got %v", expected, forecast.ExhaustsAt)
+	}
+}
+
+func TestManager_Forecast_ConfidenceBand(t *testing.T) {
+	storage := newForecastStorage(t)
+	manager := newEventsTestManager(t, storage, nil, withForecast(nil, nil))
+	ctx := context.Background()
+	period := goquota.Period{
+		Start: forecastPeriodStart,
+		End:   forecastPeriodStart.AddDate(0, 1, 0),
+		Type:  goquota.PeriodTypeMonthly,
+	}
+
+	// Noisy history around 1 unit per hour
+	noise := []int{0, 3, -2, 1, -1, 2, -3, 0}
+	for i, n := range noise {
+		h := (i + 1) * 10
+		err := storage.RecordUsageSnapshot(ctx, &goquota.UsageSnapshot{
+			UserID:     "user1",
+			Resource:   "api_calls",
+			Period:     period,
+			Bucket:     forecastPeriodStart.Add(time.Duration(h) * time.Hour),
+			Used:       h + n,
+			RecordedAt: forecastPeriodStart.Add(time.Duration(h) * time.Hour),
+		})
+		if err != nil {
+			t.Fatalf("RecordUsageSnapshot failed: %v", err)
+		}
+	}
+
+	storage.set(forecastPeriodStart.Add(90 * time.Hour))
+	if err := manager.SetUsage(ctx, "user1", "api_calls", goquota.PeriodTypeMonthly, 90); err != nil {
+		t.Fatalf("SetUsage failed: %v", err)
+	}
+
+	forecast, err := manager.Forecast(ctx, "user1", "api_calls")
+	if err != nil {
+		t.Fatalf("Forecast failed: %v", err)
+	}
+
+	if forecast.Samples != 9 {
+		t.Errorf("Expected 9 samples, got %d", forecast.Samples)
+	}
+	if math.Abs(forecast.RatePerHour-1) > 0.1 {
+		t.Errorf("Expected rate close to 1/h, got %f", forecast.RatePerHour)
+	}
+	if forecast.RatePerHourLow >= forecast.RatePerHour || forecast.RatePerHourHigh <= forecast.RatePerHour {
+		t.Errorf("Expected rate inside band, got %f [%f, %f]",
+			forecast.RatePerHour, forecast.RatePerHourLow, forecast.RatePerHourHigh)
+	}
+	if forecast.ProjectedUsedLow >= forecast.ProjectedUsed || forecast.ProjectedUsedHigh <= forecast.ProjectedUsed {
+		t.Errorf("Expected projection inside band, got %d [%d, %d]",
+			forecast.ProjectedUsed, forecast.ProjectedUsedLow, forecast.ProjectedUsedHigh)
+	}
+}
+
+func TestManager_Forecast_DailyAndUnlimited(t *testing.T) {
+	storage := newForecastStorage(t)
+	manager := newEventsTestManager(t, storage, nil, withForecast(nil, nil))
+	ctx := context.Background()
+	storage.set(forecastPeriodStart.Add(6 * time.Hour))
+
+	if _, err := manager.Consume(ctx, "user1", "daily_calls", 30, goquota.PeriodTypeDaily); err != nil {
+		t.Fatalf("Consume failed: %v", err)
+	}
+	forecast, err := manager.Forecast(ctx, "user1", "daily_calls")
+	if err != nil {
+		t.Fatalf("Forecast failed: %v", err)
+	}
+	if forecast.Period.Type != goquota.PeriodTypeDaily {
+		t.Errorf("Expected daily period for daily-only resource, got %s", forecast.Period.Type)
+	}
+	// 5/h for 18 remaining hours
+	if forecast.ProjectedUsed != 120 || !forecast.WillExceed {
+		t.Errorf("Expected projected usage 120 exceeding limit, got %d (willExceed=%v)",
+			forecast.ProjectedUsed, forecast.WillExceed)
+	}
+	if expected := forecastPeriodStart.Add(20 * time.Hour); !forecast.ExhaustsAt.Equal(expected) {
+		t.Errorf("Expected exhaustion at %v, got %v", expected, forecast.ExhaustsAt)
+	}
+
+	if _, err := manager.Consume(ctx, "user1", "unlimited_calls", 500, goquota.PeriodTypeMonthly); err != nil {
+		t.Fatalf("Consume failed: %v", err)
+	}
+	forecast, err = manager.Forecast(ctx, "user1", "unlimited_calls")
+	if err != nil {
+		t.Fatalf("Forecast failed: %v", err)
+	}
+	if forecast.WillExceed || forecast.ExhaustsAt != nil {
+		t.Error("Expected unlimited resource never to be exhausted")
+	}
+}
+
+func TestManager_Forecast_Warning(t *testing.T) {
+	handler := &forecastRecorder{}
+	storage := newForecastStorage(t)
+	manager := newEventsTestManager(t, storage, nil, withForecast(&goquota.ForecastConfig{
+		Enabled:                   true,
+		WarnOnProjectedExhaustion: true,
+	}, handler))
+	ctx := context.Background()
+
+	// Slow consumption: not projected to exceed
+	for h := 1; h <= 4; h++ {
+		storage.set(forecastPeriodStart.Add(time.Duration(h) * time.Hour))
+		if _, err := manager.Consume(ctx, "user1", "api_calls", 1, goquota.PeriodTypeMonthly); err != nil {
+			t.Fatalf("Consume failed: %v", err)
+		}
+	}
+	if handler.count() != 0 {
+		t.Fatalf("Expected no forecast warning at 1/h, got %d", handler.count())
+	}
+
+	// Consumption accelerates; repeated consumes within a bucket only snapshot once
+	for h := 5; h <= 12; h++ {
+		storage.set(forecastPeriodStart.Add(time.Duration(h) * time.Hour))
+		for i := 0; i < 3; i++ {
+			if _, err := manager.Consume(ctx, "user1", "api_calls", 10, goquota.PeriodTypeMonthly); err != nil {
+				t.Fatalf("Consume failed: %v", err)
+			}
+		}
+	}
+	if handler.count() != 1 {
+		t.Fatalf("Expected exactly one forecast warning per period, got %d", handler.count())
+	}
+
+	forecast := handler.forecasts[0]
+	if !forecast.WillExceed || forecast.ExhaustsAt == nil {
+		t.Error("Expected warning forecast to project exhaustion")
+	}
+	if forecast.UserID != "user1" || forecast.Resource != "api_calls" || forecast.Limit != 1000 {
+		t.Errorf("Unexpected forecast identity: %+v", forecast)
+	}
+
+	// Context handlers are notified as well
+	ctxHandler := &forecastRecorder{}
+	_, _ = manager.Consume(ctx, "user1", "daily_calls", 1, goquota.PeriodTypeDaily)
+	ctx = goquota.WithWarningHandler(ctx, ctxHandler)
+	for h := 13; h <= 16; h++ {
+		storage.set(forecastPeriodStart.Add(time.Duration(h) * time.Hour))
+		if _, err := manager.Consume(ctx, "user1", "daily_calls", 10, goquota.PeriodTypeDaily); err != nil {
+			t.Fatalf("Consume failed: %v", err)
+		}
+	}
+	if ctxHandler.count() != 1 {
+		t.Errorf("Expected context handler to receive one forecast warning, got %d", ctxHandler.count())
+	}
+}
+
+func TestConfig_Validate_ForecastConfig(t *testing.T) {
+	config := goquota.Config{
+		DefaultTier: "free",
+		Tiers: map[string]goquota.TierConfig{
+			"free": {Name: "free"},
+		},
+		ForecastConfig: &goquota.ForecastConfig{
+			SnapshotInterval: -time.Minute,
+			MinSnapshots:     -1,
+		},
+	}
+	if err := config.Validate(); err == nil {
+		t.Error("Expected validation error for negative forecast settings")
+	}
+}
@@ -0,0 +1,57 @@
+package goquota
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// GetUsageHistory returns usage records for periods of the given type that started within [from, to),
+// newest first. Use WithHistoryLimit and WithHistoryOffset to paginate.
+// Only daily and monthly periods have history; forever credits are a single record (see GetQuota).
+// Returns an error if storage doesn't implement UsageHistoryStorage.
+func (m *Manager) GetUsageHistory(ctx context.Context, userID, resource string, periodType PeriodType,
+	from, to time.Time, opts ...UsageHistoryOption) ([]*Usage, error) {
+	if periodType != PeriodTypeDaily && periodType != PeriodTypeMonthly {
+		return nil, ErrInvalidPeriod
+	}
+	if !from.Before(to) {
+		return nil, fmt.Errorf("invalid history range: from (%s) must be before to (%s)",
+			from.Format(time.RFC3339), to.Format(time.RFC3339))
+	}
+
+	historyOpts := &UsageHistoryOptions{}
+	for _, opt := range opts {
+		opt(historyOpts)
+	}
+	if historyOpts.Limit < 0 || historyOpts.Offset < 0 {
+		return nil, fmt.Errorf("history limit and offset cannot be negative")
+	}
+
+	history, ok := storageAs[UsageHistoryStorage](m.storage)
+	if !ok {
+		return nil, fmt.Errorf("storage does not implement UsageHistoryStorage")
+	}
+
+	start := time.Now()
+	records, err := history.GetUsageHistory(ctx, &UsageHistoryQuery{
+		UserID:     userID,
+		Resource:   resource,
+		PeriodType: periodType,
+		From:       from.UTC(),
+		To:         to.UTC(),
+		Limit:      historyOpts.Limit,
+		Offset:     historyOpts.Offset,
+	})
+	m.metrics.RecordStorageOperation("GetUsageHistory", time.Since(start), err)
+	if err != nil {
+		m.logger.Error("failed to get usage history",
+			Field{"userId", userID},
+			Field{"resource", resource},
+			Field{"error", err},
+		)
+		return nil, err
+	}
+
+	return records, nil
+}
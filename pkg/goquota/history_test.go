@@ -0,0 +1,114 @@
+package goquota_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mihaimyh/goquota/pkg/goquota"
+	"github.com/mihaimyh/goquota/storage/memory"
+)
+
+func TestManager_GetUsageHistory(t *testing.T) {
+	storage := newForecastStorage(t)
+	manager := newEventsTestManager(t, storage, nil, withForecast(nil, nil))
+	ctx := context.Background()
+
+	// Consume in three consecutive monthly cycles
+	for month := 0; month < 3; month++ {
+		storage.set(forecastPeriodStart.AddDate(0, month, 5))
+		if _, err := manager.Consume(ctx, "user1", "api_calls", 10*(month+1), goquota.PeriodTypeMonthly); err != nil {
+			t.Fatalf("Consume failed: %v", err)
+		}
+		if _, err := manager.Consume(ctx, "user1", "daily_calls", 1, goquota.PeriodTypeDaily); err != nil {
+			t.Fatalf("Consume failed: %v", err)
+		}
+	}
+
+	from := forecastPeriodStart
+	to := forecastPeriodStart.AddDate(1, 0, 0)
+	history, err := manager.GetUsageHistory(ctx, "user1", "api_calls", goquota.PeriodTypeMonthly, from, to)
+	if err != nil {
+		t.Fatalf("GetUsageHistory failed: %v", err)
+	}
+	if len(history) != 3 {
+		t.Fatalf("Expected 3 monthly periods, got %d", len(history))
+	}
+	for i, usage := range history {
+		month := 2 - i // Newest first
+		if !usage.Period.Start.Equal(forecastPeriodStart.AddDate(0, month, 0)) {
+			t.Errorf("Expected period %d to start %v, got %v", i, forecastPeriodStart.AddDate(0, month, 0), usage.Period.Start)
+		}
+		if usage.Used != 10*(month+1) || usage.Limit != 1000 {
+			t.Errorf("Expected used %d of 1000, got %d of %d", 10*(month+1), usage.Used, usage.Limit)
+		}
+	}
+
+	// Pagination
+	page, err := manager.GetUsageHistory(ctx, "user1", "api_calls", goquota.PeriodTypeMonthly, from, to,
+		goquota.WithHistoryLimit(2), goquota.WithHistoryOffset(1))
+	if err != nil {
+		t.Fatalf("GetUsageHistory failed: %v", err)
+	}
+	if len(page) != 2 || page[0].Used != 20 || page[1].Used != 10 {
+		t.Errorf("Expected second and third periods, got %+v", page)
+	}
+
+	// Range excludes periods starting at or after "to"
+	history, err = manager.GetUsageHistory(ctx, "user1", "api_calls", goquota.PeriodTypeMonthly,
+		from, forecastPeriodStart.AddDate(0, 2, 0))
+	if err != nil {
+		t.Fatalf("GetUsageHistory failed: %v", err)
+	}
+	if len(history) != 2 {
+		t.Errorf("Expected 2 periods before the third cycle, got %d", len(history))
+	}
+
+	// Daily periods are separate
+	history, err = manager.GetUsageHistory(ctx, "user1", "daily_calls", goquota.PeriodTypeDaily, from, to)
+	if err != nil {
+		t.Fatalf("GetUsageHistory failed: %v", err)
+	}
+	if len(history) != 3 || history[0].Period.Type != goquota.PeriodTypeDaily {
+		t.Errorf("Expected 3 daily periods, got %d", len(history))
+	}
+}
+
+func TestManager_GetUsageHistory_InvalidArguments(t *testing.T) {
+	manager := newEventsTestManager(t, newForecastStorage(t), nil, withForecast(nil, nil))
+	ctx := context.Background()
+	from := forecastPeriodStart
+	to := forecastPeriodStart.AddDate(0, 6, 0)
+
+	_, err := manager.GetUsageHistory(ctx, "user1", "api_calls", goquota.PeriodTypeForever, from, to)
+	if !errors.Is(err, goquota.ErrInvalidPeriod) {
+		t.Errorf("Expected ErrInvalidPeriod for forever history, got %v", err)
+	}
+
+	if _, err := manager.GetUsageHistory(ctx, "user1", "api_calls", goquota.PeriodTypeMonthly, to, from); err == nil {
+		t.Error("Expected error when from is after to")
+	}
+
+	_, err = manager.GetUsageHistory(ctx, "user1", "api_calls", goquota.PeriodTypeMonthly, from, to,
+		goquota.WithHistoryOffset(-1))
+	if err == nil {
+		t.Error("Expected error for negative offset")
+	}
+}
+
+// storageOnly hides optional interfaces implemented by the wrapped storage
+type storageOnly struct {
+	goquota.Storage
+}
+
+func TestManager_GetUsageHistory_Unsupported(t *testing.T) {
+	manager := newEventsTestManager(t, storageOnly{memory.New()}, nil, nil)
+
+	now := time.Now()
+	_, err := manager.GetUsageHistory(context.Background(), "user1", "api_calls", goquota.PeriodTypeMonthly,
+		now.AddDate(-1, 0, 0), now)
+	if err == nil {
+		t.Error("Expected error when storage does not implement UsageHistoryStorage")
+	}
+}
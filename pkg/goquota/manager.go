@@ -34,8 +34,11 @@ package goquota
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"golang.org/x/sync/singleflight"
@@ -62,6 +65,42 @@ type Manager struct {
 	// singleflight groups to prevent cache stampede
 	entitlementGroup singleflight.Group
 	usageGroup       singleflight.Group
+	// forecast snapshot and warning state keyed by user, resource and period type
+	forecastMu      sync.Mutex
+	forecastStates  map[string]*forecastState
+	forecastEvictAt time.Time
+	// rate limit warning state keyed by user, resource and warning index
+	rateWarningMu      sync.Mutex
+	rateWarningStates  map[string]*rateLimitWarningState
+	rateWarningEvictAt time.Time
+	// lifecycle event and webhook delivery (nil if not configured)
+	events     *eventDispatcher
+	webhooks   *webhookDispatcher
+	inFallback atomic.Bool
+	// statement store (nil if not configured) and the current cycle checked per user
+	statements       StatementStore
+	statementMu      sync.Mutex
+	statementChecked map[string]statementCheck
+	statementEvictAt time.Time
+	statementCloser  *statementCloser
+	statementSweeper *statementSweeper
+	// manual overrides keyed by resource and tier scope
+	overrideMu      sync.RWMutex
+	overrides       map[string]*Override
+	overrideJournal OverrideJournal
+	// shared override store (nil unless OverrideConfig.Shared), changes not saved to it yet
+	// (nil for a pending clear) and the reload worker
+	overrideStore     OverrideStore
+	overridePending   map[string]*Override
+	overrideRefresher *overrideRefresher
+	// journal of optimistic consumptions (nil if not configured) and its replay
+	optimisticJournal  OptimisticJournal
+	optimisticReplayMu sync.Mutex
+	optimisticReplayer *optimisticReplayer
+	// quota leased from storage for local consumption (nil if not configured)
+	quotaLeases *quotaLeaser
+	// identifies this Manager's messages on CacheConfig.InvalidationBus
+	busSource string
 }
 
 // NewManager creates a new quota manager with the given storage and configuration
@@ -79,11 +118,10 @@ func NewManager(storage Storage, config *Config) (*Manager, error) {
 	}
 
 	applyConfigDefaults(config)
-	cache := initializeCache(config)
 	metrics := initializeMetrics(config.Metrics)
 	logger := initializeLogger(config.Logger)
-	currentStorage := initializeCircuitBreaker(storage, config.CircuitBreakerConfig, metrics)
-	fallbackStrategy := initializeFallback(config, cache, metrics, logger)
+	currentStorage := initializeCircuitBreaker(initializeRetry(storage, config.RetryConfig, metrics),
+		config.CircuitBreakerConfig, metrics, logger)
 	rateLimiter := NewRateLimiter(currentStorage, false) // Use storage-backed rate limiter
 
 	// Check if storage implements TimeSource interface
@@ -95,16 +133,120 @@ func NewManager(storage Storage, config *Config) (*Manager, error) {
 		logger.Info("storage does not implement TimeSource, using application server time")
 	}
 
-	return &Manager{
-		storage:          currentStorage,
-		timeSource:       timeSource,
-		config:           *config,
-		cache:            cache,
-		metrics:          metrics,
-		logger:           logger,
-		fallbackStrategy: fallbackStrategy,
-		rateLimiter:      rateLimiter,
-	}, nil
+	var webhookStore WebhookStore
+	if config.WebhookConfig != nil {
+		store, ok := storageAs[WebhookStore](currentStorage)
+		if !ok {
+			return nil, fmt.Errorf("webhookConfig requires storage that implements WebhookStore")
+		}
+		webhookStore = store
+	}
+
+	var overrideStore OverrideStore
+	if config.OverrideConfig != nil && config.OverrideConfig.Shared {
+		store, ok := storageAs[OverrideStore](currentStorage)
+		if !ok {
+			return nil, fmt.Errorf("overrideConfig.shared requires storage that implements OverrideStore")
+		}
+		overrideStore = store
+	}
+
+	var statementStore StatementStore
+	if config.StatementConfig != nil {
+		store, ok := storageAs[StatementStore](currentStorage)
+		if !ok {
+			return nil, fmt.Errorf("statementConfig requires storage that implements StatementStore")
+		}
+		statementStore = store
+	}
+
+	// The cache may subscribe to invalidations: create it once nothing else can fail
+	cache, err := initializeCache(config, logger)
+	if err != nil {
+		return nil, err
+	}
+	fallbackStrategy := initializeFallback(config, cache, metrics, logger)
+	overrides, overrideJournal := initializeOverrides(config.OverrideConfig)
+
+	var events *eventDispatcher
+	if config.EventConfig != nil && len(config.EventConfig.Sinks) > 0 {
+		events = newEventDispatcher(config.EventConfig, metrics, logger)
+	}
+
+	m := &Manager{
+		storage:           currentStorage,
+		timeSource:        timeSource,
+		config:            *config,
+		cache:             cache,
+		metrics:           metrics,
+		logger:            logger,
+		fallbackStrategy:  fallbackStrategy,
+		rateLimiter:       rateLimiter,
+		forecastStates:    make(map[string]*forecastState),
+		rateWarningStates: make(map[string]*rateLimitWarningState),
+		events:            events,
+		statements:        statementStore,
+		statementChecked:  make(map[string]statementCheck),
+		overrides:         overrides,
+		overrideJournal:   overrideJournal,
+		busSource:         newEventID(),
+	}
+	if webhookStore != nil {
+		m.webhooks = newWebhookDispatcher(webhookStore, config.WebhookConfig, metrics, logger, m.now)
+	}
+	if statementStore != nil {
+		m.startStatementCloser()
+		if config.StatementConfig.SweepInterval > 0 {
+			m.startStatementSweeper()
+		}
+	}
+	if config.FallbackConfig != nil && config.FallbackConfig.Enabled && config.FallbackConfig.OptimisticJournal != nil {
+		m.optimisticJournal = config.FallbackConfig.OptimisticJournal
+		m.startOptimisticReplayer()
+	}
+	if config.QuotaLeaseConfig != nil {
+		m.startQuotaLeaser()
+	}
+	if overrideStore != nil {
+		m.overrideStore = overrideStore
+		m.overridePending = make(map[string]*Override)
+		m.startOverrideRefresher()
+	}
+	return m, nil
+}
+
+// Close stops the statement sweeper, closes the finished periods already queued, and stops event and
+// webhook delivery, waiting until queued events are published, in-flight webhooks are sent, or ctx is done.
+// Events emitted after Close are dropped; pending webhook deliveries stay in storage and are sent by the next Manager.
+// Unused leased quota is returned to storage. The cache stops its background cleanup and,
+// if distributed, receiving invalidations.
+func (m *Manager) Close(ctx context.Context) error {
+	var errs []error
+	if m.statementSweeper != nil {
+		errs = append(errs, m.statementSweeper.close(ctx))
+	}
+	if m.statementCloser != nil {
+		errs = append(errs, m.statementCloser.close(ctx, &m.statementMu))
+	}
+	if m.events != nil {
+		errs = append(errs, m.events.close(ctx))
+	}
+	if m.webhooks != nil {
+		errs = append(errs, m.webhooks.close(ctx))
+	}
+	if m.optimisticReplayer != nil {
+		errs = append(errs, m.optimisticReplayer.close(ctx))
+	}
+	if m.quotaLeases != nil {
+		errs = append(errs, m.quotaLeases.close(ctx, m))
+	}
+	if m.overrideRefresher != nil {
+		errs = append(errs, m.overrideRefresher.close(ctx))
+	}
+	if cache, ok := m.cache.(interface{ Close() error }); ok {
+		errs = append(errs, cache.Close())
+	}
+	return errors.Join(errs...)
 }
 
 // applyConfigDefaults sets default values for config fields
@@ -118,12 +260,71 @@ func applyConfigDefaults(config *Config) {
 	if config.IdempotencyKeyTTL == 0 {
 		config.IdempotencyKeyTTL = 24 * time.Hour
 	}
+	if config.ForecastConfig != nil {
+		if config.ForecastConfig.SnapshotInterval == 0 {
+			config.ForecastConfig.SnapshotInterval = defaultSnapshotInterval
+		}
+		if config.ForecastConfig.MinSnapshots == 0 {
+			config.ForecastConfig.MinSnapshots = defaultMinSnapshots
+		}
+	}
+	if config.EventConfig != nil {
+		if config.EventConfig.BufferSize == 0 {
+			config.EventConfig.BufferSize = defaultEventBufferSize
+		}
+		if config.EventConfig.PublishTimeout == 0 {
+			config.EventConfig.PublishTimeout = defaultEventPublishTimeout
+		}
+	}
+	if config.WebhookConfig != nil {
+		applyWebhookConfigDefaults(config.WebhookConfig)
+	}
+	if config.FallbackConfig != nil && config.FallbackConfig.ReplayInterval == 0 {
+		config.FallbackConfig.ReplayInterval = defaultOptimisticReplayInterval
+	}
+	if config.StatementConfig != nil && config.StatementConfig.MaxCatchUpPeriods == 0 {
+		config.StatementConfig.MaxCatchUpPeriods = defaultStatementMaxCatchUpPeriods
+	}
+	if config.QuotaLeaseConfig != nil {
+		applyQuotaLeaseDefaults(config.QuotaLeaseConfig)
+	}
+	if config.OverrideConfig != nil && config.OverrideConfig.RefreshInterval == 0 {
+		config.OverrideConfig.RefreshInterval = defaultOverrideRefreshInterval
+	}
+}
+
+// applyWebhookConfigDefaults sets default values for webhook config fields
+func applyWebhookConfigDefaults(wc *WebhookConfig) {
+	if wc.MaxAttempts == 0 {
+		wc.MaxAttempts = defaultWebhookMaxAttempts
+	}
+	if wc.InitialBackoff == 0 {
+		wc.InitialBackoff = defaultWebhookInitialBackoff
+	}
+	if wc.MaxBackoff == 0 {
+		wc.MaxBackoff = defaultWebhookMaxBackoff
+	}
+	if wc.MaxBackoff < wc.InitialBackoff {
+		wc.MaxBackoff = wc.InitialBackoff
+	}
+	if wc.PollInterval == 0 {
+		wc.PollInterval = defaultWebhookPollInterval
+	}
+	if wc.Timeout == 0 {
+		wc.Timeout = defaultWebhookTimeout
+	}
+	if wc.BatchSize == 0 {
+		wc.BatchSize = defaultWebhookBatchSize
+	}
+	if wc.Concurrency == 0 {
+		wc.Concurrency = defaultWebhookConcurrency
+	}
 }
 
 // initializeCache creates and configures the cache based on config
-func initializeCache(config *Config) Cache {
+func initializeCache(config *Config, logger Logger) (Cache, error) {
 	if config.CacheConfig == nil || !config.CacheConfig.Enabled {
-		return NewNoopCache()
+		return NewNoopCache(), nil
 	}
 
 	// Set cache defaults
@@ -141,7 +342,34 @@ func initializeCache(config *Config) Cache {
 		cacheConfig.MaxUsage = 10000
 	}
 
-	return NewLRUCache(cacheConfig.MaxEntitlements, cacheConfig.MaxUsage)
+	local := NewLRUCacheWithConfig(LRUCacheConfig{
+		MaxEntitlements: cacheConfig.MaxEntitlements,
+		MaxUsage:        cacheConfig.MaxUsage,
+		Shards:          cacheConfig.Shards,
+		CleanupInterval: cacheConfig.CleanupInterval,
+	})
+	if cacheConfig.SharedStore == nil && cacheConfig.InvalidationBus == nil {
+		return local, nil
+	}
+	cache, err := NewDistributedCache(DistributedCacheConfig{
+		Local:  local,
+		Shared: cacheConfig.SharedStore,
+		Bus:    cacheConfig.InvalidationBus,
+		Logger: logger,
+	})
+	if err != nil {
+		_ = local.Close()
+		return nil, err
+	}
+	return cache, nil
+}
+
+// invalidationBus returns the configured InvalidationBus, or nil
+func (m *Manager) invalidationBus() InvalidationBus {
+	if m.config.CacheConfig == nil {
+		return nil
+	}
+	return m.config.CacheConfig.InvalidationBus
 }
 
 // initializeMetrics returns the configured metrics or a no-op implementation
@@ -161,28 +389,55 @@ func initializeLogger(logger Logger) Logger {
 }
 
 // initializeCircuitBreaker wraps storage with circuit breaker if enabled
-func initializeCircuitBreaker(storage Storage, cbConfig *CircuitBreakerConfig, metrics Metrics) Storage {
+func initializeCircuitBreaker(storage Storage, cbConfig *CircuitBreakerConfig, metrics Metrics,
+	logger Logger) Storage {
 	if cbConfig == nil || !cbConfig.Enabled {
 		return storage
 	}
 
-	// Set circuit breaker defaults
-	if cbConfig.FailureThreshold == 0 {
-		cbConfig.FailureThreshold = 5
+	applyCircuitBreakerDefaults(cbConfig)
+	if len(cbConfig.Groups) == 0 {
+		return NewCircuitBreakerStorage(storage, newCircuitBreaker(*cbConfig, "", metrics, logger))
+	}
+
+	breakers := make(map[string]CircuitBreaker)
+	for group, operations := range cbConfig.Groups {
+		cb := newCircuitBreaker(*cbConfig, group, metrics, logger)
+		for _, operation := range operations {
+			breakers[operation] = cb
+		}
 	}
-	if cbConfig.ResetTimeout == 0 {
-		cbConfig.ResetTimeout = 30 * time.Second
+	return NewGroupedCircuitBreakerStorage(storage, newCircuitBreaker(*cbConfig, "", metrics, logger), breakers)
+}
+
+// newCircuitBreaker creates the breaker of a group ("" for operations in no group)
+func newCircuitBreaker(config CircuitBreakerConfig, group string, metrics Metrics, logger Logger) CircuitBreaker {
+	if group != "" {
+		config.Name += ":" + group
+	}
+	onStateChange := func(state CircuitBreakerState) {
+		metrics.RecordCircuitBreakerStateChange(string(state))
+		if group != "" {
+			logger.Info("circuit breaker state changed", Field{"group", group}, Field{"state", string(state)})
+		}
 	}
 
-	cb := NewDefaultCircuitBreaker(
-		cbConfig.FailureThreshold,
-		cbConfig.ResetTimeout,
-		func(state CircuitBreakerState) {
-			metrics.RecordCircuitBreakerStateChange(string(state))
-		},
-	)
+	switch {
+	case config.Store != nil:
+		return NewDistributedCircuitBreaker(config.Store, config, onStateChange)
+	case config.FailureRateThreshold > 0 || config.SlowCallRateThreshold > 0:
+		return NewSlidingWindowCircuitBreaker(config, onStateChange)
+	default:
+		return NewDefaultCircuitBreaker(config.FailureThreshold, config.ResetTimeout, onStateChange)
+	}
+}
 
-	return NewCircuitBreakerStorage(storage, cb)
+// initializeRetry wraps storage with RetryStorage if retries are configured
+func initializeRetry(storage Storage, retryConfig *RetryConfig, metrics Metrics) Storage {
+	if retryConfig == nil {
+		return storage
+	}
+	return NewRetryStorage(storage, *retryConfig, metrics)
 }
 
 // initializeFallback creates and configures fallback strategy based on config
@@ -265,42 +520,19 @@ func (m *Manager) GetQuota(ctx context.Context, userID, resource string, periodT
 	// Get entitlement to determine tier (uses cache)
 	ent, err := m.GetEntitlement(ctx, userID)
 	tier := m.config.DefaultTier
-	var period Period
-
 	if err == nil {
 		tier = ent.Tier
+	} else {
+		ent = nil
 	}
 
-	// Get current time (using TimeSource if available)
+	// Calculate period based on type (using TimeSource if available)
 	now := m.now(ctx)
-
-	// Calculate period based on type
-	switch periodType {
-	case PeriodTypeMonthly:
-		var start, end time.Time
-		if err == nil {
-			start, end = CurrentCycleForStart(ent.SubscriptionStartDate, now)
-		} else {
-			start, end = CurrentCycleForStart(startOfDayUTC(now), now)
-		}
-		period = Period{Start: start, End: end, Type: PeriodTypeMonthly}
-
-	case PeriodTypeDaily:
-		start := startOfDayUTC(now)
-		end := start.Add(24 * time.Hour)
-		period = Period{Start: start, End: end, Type: PeriodTypeDaily}
-
-	case PeriodTypeForever:
-		// Forever periods use a stable start time and sentinel end time
-		// The period key will be "forever" regardless of dates
-		start := startOfDayUTC(now)
-		// Use sentinel value for end (or NULL in storage)
-		end := time.Date(9999, 12, 31, 23, 59, 59, 0, time.UTC)
-		period = Period{Start: start, End: end, Type: PeriodTypeForever}
-
-	default:
-		return nil, ErrInvalidPeriod
+	period, err := m.quotaPeriod(ent, periodType, now)
+	if err != nil {
+		return nil, err
 	}
+	m.closePeriodsOnAccess(ent, now)
 
 	// Build cache key for usage
 	usageKey := userID + ":" + resource + ":" + period.Key()
@@ -339,7 +571,7 @@ func (m *Manager) GetQuota(ctx context.Context, userID, resource string, periodT
 
 			// Try fallback if available and error warrants it
 			if m.fallbackStrategy != nil && m.fallbackStrategy.ShouldFallback(err) {
-				m.metrics.RecordFallbackUsage("storage_error")
+				m.enterFallback(ctx, "storage_error", userID, resource)
 				fallbackUsage, fallbackErr := m.fallbackStrategy.GetFallbackUsage(ctx, userID, resource, period)
 				if fallbackErr == nil && fallbackUsage != nil {
 					// Ensure limit is set
@@ -353,13 +585,11 @@ func (m *Manager) GetQuota(ctx context.Context, userID, resource string, periodT
 			return nil, err
 		}
 
+		m.inFallback.Store(false)
+
 		// Cache the result if available
 		if usage != nil {
-			ttl := m.config.CacheTTL
-			if m.config.CacheConfig != nil && m.config.CacheConfig.UsageTTL > 0 {
-				ttl = m.config.CacheConfig.UsageTTL
-			}
-			m.cache.SetUsage(usageKey, usage, ttl)
+			m.cache.SetUsage(usageKey, usage, m.usageCacheTTL())
 		}
 
 		return usage, nil
@@ -374,6 +604,44 @@ func (m *Manager) GetQuota(ctx context.Context, userID, resource string, periodT
 		return nil, fmt.Errorf("unexpected type from usage fetch: %T", result)
 	}
 
+	return m.completeUsage(usage, userID, resource, tier, period), nil
+}
+
+// quotaPeriod returns the current period of the given type.
+// Monthly periods follow the entitlement's billing cycle (ent may be nil).
+func (m *Manager) quotaPeriod(ent *Entitlement, periodType PeriodType, now time.Time) (Period, error) {
+	switch periodType {
+	case PeriodTypeMonthly:
+		var start, end time.Time
+		if ent != nil {
+			start, end = CurrentCycleForStart(ent.SubscriptionStartDate, now)
+		} else {
+			start, end = CurrentCycleForStart(startOfDayUTC(now), now)
+		}
+		return Period{Start: start, End: end, Type: PeriodTypeMonthly}, nil
+
+	case PeriodTypeDaily:
+		start := startOfDayUTC(now)
+		end := start.Add(24 * time.Hour)
+		return Period{Start: start, End: end, Type: PeriodTypeDaily}, nil
+
+	case PeriodTypeForever:
+		// Forever periods use a stable start time and sentinel end time
+		// The period key will be "forever" regardless of dates
+		start := startOfDayUTC(now)
+		// Use sentinel value for end (or NULL in storage)
+		end := time.Date(9999, 12, 31, 23, 59, 59, 0, time.UTC)
+		return Period{Start: start, End: end, Type: PeriodTypeForever}, nil
+
+	default:
+		return Period{}, ErrInvalidPeriod
+	}
+}
+
+// completeUsage fills in the limit of usage read from storage, or returns zero usage
+// with the tier's limit if there is no usage yet
+func (m *Manager) completeUsage(usage *Usage, userID, resource, tier string, period Period) *Usage {
+	periodType := period.Type
 	// If no usage yet, return zero usage with calculated limit
 	if usage == nil {
 		limit := m.getLimitForResource(resource, tier, periodType)
@@ -396,7 +664,7 @@ func (m *Manager) GetQuota(ctx context.Context, userID, resource string, periodT
 			Limit:    limit,
 			Period:   period,
 			Tier:     tier,
-		}, nil
+		}
 	}
 
 	// Ensure limit is set (may be missing in old data)
@@ -410,12 +678,31 @@ func (m *Manager) GetQuota(ctx context.Context, userID, resource string, periodT
 		m.metrics.RecordForeverCreditsBalance(resource, tier, balance)
 	}
 
-	return usage, nil
+	return usage
+}
+
+// usageCacheTTL returns how long usage is cached
+func (m *Manager) usageCacheTTL() time.Duration {
+	if m.config.CacheConfig != nil && m.config.CacheConfig.UsageTTL > 0 {
+		return m.config.CacheConfig.UsageTTL
+	}
+	return m.config.CacheTTL
+}
+
+// entitlementCacheTTL returns how long entitlements are cached
+func (m *Manager) entitlementCacheTTL() time.Duration {
+	if m.config.CacheConfig != nil && m.config.CacheConfig.EntitlementTTL > 0 {
+		return m.config.CacheConfig.EntitlementTTL
+	}
+	return m.config.CacheTTL
 }
 
 // Consume consumes quota for a resource
 // Returns the new total used amount and any error
 //
+// While a manual override applies (see SetOverride), consumptions it allows return 0 without
+// consulting storage, and consumptions it denies return ErrQuotaExceeded.
+//
 //nolint:gocyclo // Complex function handles idempotency, period calculation, and error cases
 func (m *Manager) Consume(ctx context.Context, userID, resource string, amount int,
 	periodType PeriodType, opts ...ConsumeOption) (int, error) {
@@ -440,6 +727,13 @@ func (m *Manager) Consume(ctx context.Context, userID, resource string, amount i
 		opt(consumeOpts)
 	}
 
+	// Manual overrides decide before storage is consulted, so they work during storage incidents
+	if !consumeOpts.skipOverride {
+		if handled, err := m.applyOverride(ctx, userID, resource, amount, periodType, consumeOpts); handled {
+			return 0, err
+		}
+	}
+
 	// Check for duplicate consumption using idempotency key
 	if consumeOpts.IdempotencyKey != "" {
 		existing, err := m.storage.GetConsumptionRecord(ctx, consumeOpts.IdempotencyKey)
@@ -479,6 +773,9 @@ func (m *Manager) Consume(ctx context.Context, userID, resource string, amount i
 
 	// Get current time (using TimeSource if available)
 	now := m.now(ctx)
+	if err == nil {
+		m.closePeriodsOnAccess(ent, now)
+	}
 
 	// Handle cascading consumption for PeriodTypeAuto
 	if periodType == PeriodTypeAuto {
@@ -493,10 +790,12 @@ func (m *Manager) Consume(ctx context.Context, userID, resource string, amount i
 			consumptionOrder = tierConfig.ConsumptionOrder
 		}
 
-		// Try each period in order until one succeeds
+		// Try each period in order until one succeeds.
+		// Denials are only emitted once all periods are exhausted.
+		periodOpts := append(append([]ConsumeOption{}, opts...), withoutDeniedEvent(), withoutOverride())
 		var lastErr error
 		for _, pt := range consumptionOrder {
-			newUsed, err := m.Consume(ctx, userID, resource, amount, pt, opts...)
+			newUsed, err := m.Consume(ctx, userID, resource, amount, pt, periodOpts...)
 			if err == nil {
 				return newUsed, nil
 			}
@@ -509,6 +808,10 @@ func (m *Manager) Consume(ctx context.Context, userID, resource string, amount i
 		}
 
 		// All periods exhausted
+		if lastErr == ErrQuotaExceeded && !consumeOpts.skipDeniedEvent {
+			m.emitUsageEvent(ctx, EventConsumeDenied, userID, resource, tier, periodType,
+				amount, 0, 0, "quota_exceeded")
+		}
 		return 0, lastErr
 	}
 
@@ -556,6 +859,8 @@ func (m *Manager) Consume(ctx context.Context, userID, resource string, amount i
 		if retryAfter < 0 {
 			retryAfter = 0
 		}
+		m.emitUsageEvent(ctx, EventRateLimited, userID, resource, tier, periodType,
+			amount, 0, info.Limit, "rate_limit_exceeded")
 		return 0, &RateLimitExceededError{
 			Info:       info,
 			RetryAfter: retryAfter,
@@ -581,6 +886,10 @@ func (m *Manager) Consume(ctx context.Context, userID, resource string, amount i
 			m.metrics.RecordForeverCreditsBalance(resource, tier, balance)
 		} else {
 			// No forever credits yet
+			if !consumeOpts.DryRun && !consumeOpts.skipDeniedEvent {
+				m.emitUsageEvent(ctx, EventConsumeDenied, userID, resource, tier, periodType,
+					amount, 0, 0, "no_credits")
+			}
 			return 0, ErrQuotaExceeded
 		}
 	}
@@ -590,7 +899,12 @@ func (m *Manager) Consume(ctx context.Context, userID, resource string, amount i
 		// Unlimited quota - proceed without limit validation
 		// Storage layer will still track usage but won't enforce limits
 	} else if limit <= 0 {
-		return 0, ErrQuotaExceeded // No quota available for this tier
+		// No quota available for this tier
+		if !consumeOpts.DryRun && !consumeOpts.skipDeniedEvent {
+			m.emitUsageEvent(ctx, EventConsumeDenied, userID, resource, tier, periodType,
+				amount, 0, limit, "no_quota")
+		}
+		return 0, ErrQuotaExceeded
 	}
 
 	// Check if this is a dry-run (shadow mode)
@@ -640,9 +954,8 @@ func (m *Manager) Consume(ctx context.Context, userID, resource string, amount i
 		return currentUsed + amount, nil
 	}
 
-	// Consume via storage (transaction-safe)
-	cStart := time.Now()
-	newUsed, err := m.storage.ConsumeQuota(ctx, &ConsumeRequest{
+	// Consume via storage (transaction-safe), or from a quota lease
+	consumeReq := &ConsumeRequest{
 		UserID:            userID,
 		Resource:          resource,
 		Amount:            amount,
@@ -651,20 +964,23 @@ func (m *Manager) Consume(ctx context.Context, userID, resource string, amount i
 		Limit:             limit,
 		IdempotencyKey:    consumeOpts.IdempotencyKey,
 		IdempotencyKeyTTL: m.config.IdempotencyKeyTTL,
-	})
-	m.metrics.RecordStorageOperation("ConsumeQuota", time.Since(cStart), err)
+	}
+	newUsed, wroteStorage, err := m.consumeQuota(ctx, consumeReq)
 
 	// Handle storage failures with fallback
 	if err != nil && err != ErrQuotaExceeded {
 		// Check if we should use fallback
 		if m.fallbackStrategy != nil && m.fallbackStrategy.ShouldFallback(err) {
-			m.metrics.RecordFallbackUsage("storage_error")
+			m.enterFallback(ctx, "storage_error", userID, resource)
 
 			// Try to get current usage from fallback
 			fallbackUsage, fallbackErr := m.fallbackStrategy.GetFallbackUsage(ctx, userID, resource, period)
 			if fallbackErr == nil && fallbackUsage != nil {
 				// Check if optimistic consumption is allowed
 				if m.fallbackStrategy.AllowOptimisticConsumption(fallbackUsage, amount) {
+					// Journal the consumption so it is written back once storage recovers
+					m.journalOptimisticConsumption(ctx, consumeReq)
+
 					// Calculate new used amount optimistically
 					optimisticNewUsed := fallbackUsage.Used + amount
 					m.logger.Info("allowing optimistic consumption",
@@ -677,7 +993,7 @@ func (m *Manager) Consume(ctx context.Context, userID, resource string, amount i
 
 					// Invalidate cache since we're using optimistic data
 					usageKey := userID + ":" + resource + ":" + period.Key()
-					m.cache.InvalidateUsage(usageKey)
+					m.invalidateConsumedUsage(usageKey)
 					m.metrics.RecordConsumption(userID, resource, tier, amount, true)
 					if periodType == PeriodTypeForever {
 						m.metrics.RecordForeverCreditsConsumption(resource, tier, true)
@@ -686,6 +1002,8 @@ func (m *Manager) Consume(ctx context.Context, userID, resource string, amount i
 
 					// Check for warnings
 					m.checkWarnings(ctx, userID, resource, tier, limit, optimisticNewUsed, amount, period)
+					m.emitConsumeEvents(ctx, userID, resource, tier, periodType, amount, optimisticNewUsed, limit,
+						map[string]string{"optimistic": "true"})
 
 					return optimisticNewUsed, nil
 				}
@@ -705,10 +1023,12 @@ func (m *Manager) Consume(ctx context.Context, userID, resource string, amount i
 		return 0, err
 	}
 
-	// Invalidate usage cache on successful consumption
+	// Invalidate usage cache on successful consumption. Consumption from a lease leaves
+	// usage in storage unchanged.
 	if err == nil {
-		usageKey := userID + ":" + resource + ":" + period.Key()
-		m.cache.InvalidateUsage(usageKey)
+		if wroteStorage {
+			m.invalidateConsumedUsage(userID + ":" + resource + ":" + period.Key())
+		}
 		m.metrics.RecordConsumption(userID, resource, tier, amount, true)
 
 		// Record forever credits specific metrics
@@ -728,6 +1048,11 @@ func (m *Manager) Consume(ctx context.Context, userID, resource string, amount i
 
 		// Check for warnings
 		m.checkWarnings(ctx, userID, resource, tier, limit, newUsed, amount, period)
+		m.inFallback.Store(false)
+		m.emitConsumeEvents(ctx, userID, resource, tier, periodType, amount, newUsed, limit, nil)
+
+		// Record usage history for forecasting
+		m.recordUsageSnapshot(ctx, userID, resource, tier, limit, newUsed, period)
 	} else {
 		m.metrics.RecordConsumption(userID, resource, tier, amount, false)
 		if periodType == PeriodTypeForever {
@@ -741,12 +1066,26 @@ func (m *Manager) Consume(ctx context.Context, userID, resource string, amount i
 			)
 			// Record quota exhaustion
 			m.metrics.RecordQuotaExhaustion(resource, tier, periodType)
+			if !consumeOpts.skipDeniedEvent {
+				m.emitUsageEvent(ctx, EventConsumeDenied, userID, resource, tier, periodType,
+					amount, 0, limit, "quota_exceeded")
+			}
 		}
 	}
 
 	return newUsed, err
 }
 
+// invalidateConsumedUsage drops cached usage after a consumption. A DistributedCache doesn't
+// broadcast it: consumption is too frequent, so other instances rely on CacheConfig.UsageTTL.
+func (m *Manager) invalidateConsumedUsage(key string) {
+	if cache, ok := m.cache.(interface{ InvalidateLocalUsage(key string) }); ok {
+		cache.InvalidateLocalUsage(key)
+		return
+	}
+	m.cache.InvalidateUsage(key)
+}
+
 // tryConsumeZeroAmount handles the zero amount case for TryConsume
 func (m *Manager) tryConsumeZeroAmount(ctx context.Context, userID, resource string,
 	periodType PeriodType) (*TryConsumeResult, error) {
@@ -1007,9 +1346,25 @@ func (m *Manager) ApplyTierChange(ctx context.Context, userID, oldTier, newTier,
 			Field{"newTier", newTier},
 			Field{"error", err},
 		)
+		return err
 	}
-
-	return err
+	usageKey := userID + ":" + resource + ":" + period.Key()
+	m.cache.InvalidateUsage(usageKey)
+	// Blocks leased under the old limit are returned
+	m.refreshQuotaLeases(ctx, usageKey)
+	m.rearmWarnings(ctx, userID, resource, newTier, period)
+
+	m.emit(ctx, &Event{
+		Type:         EventTierChanged,
+		UserID:       userID,
+		Resource:     resource,
+		PeriodType:   PeriodTypeMonthly,
+		Tier:         newTier,
+		PreviousTier: oldTier,
+		Used:         currentUsed,
+		Limit:        adjustedLimit,
+	})
+	return nil
 }
 
 // SetEntitlement updates a user's entitlement
@@ -1082,11 +1437,7 @@ func (m *Manager) GetEntitlement(ctx context.Context, userID string) (*Entitleme
 
 		if err == nil && ent != nil {
 			// Cache the result
-			ttl := m.config.CacheTTL
-			if m.config.CacheConfig != nil && m.config.CacheConfig.EntitlementTTL > 0 {
-				ttl = m.config.CacheConfig.EntitlementTTL
-			}
-			m.cache.SetEntitlement(userID, ent, ttl)
+			m.cache.SetEntitlement(userID, ent, m.entitlementCacheTTL())
 		} else if err != nil && err != ErrEntitlementNotFound {
 			// Try fallback on storage errors
 			if fallbackEnt := m.tryFallbackEntitlement(ctx, userID, err); fallbackEnt != nil {
@@ -1120,7 +1471,7 @@ func (m *Manager) tryFallbackEntitlement(ctx context.Context, userID string, err
 		return nil
 	}
 
-	m.metrics.RecordFallbackUsage("storage_error")
+	m.enterFallback(ctx, "storage_error", userID, "")
 	fallbackEnt, fallbackErr := m.fallbackStrategy.GetFallbackEntitlement(ctx, userID)
 	if fallbackErr == nil && fallbackEnt != nil {
 		return fallbackEnt
@@ -1209,6 +1560,7 @@ func (m *Manager) Refund(ctx context.Context, req *RefundRequest) error {
 		// Invalidate usage cache on successful refund
 		usageKey := req.UserID + ":" + req.Resource + ":" + period.Key()
 		m.cache.InvalidateUsage(usageKey)
+		m.refreshQuotaLeases(ctx, usageKey)
 
 		// Record refund metrics
 		reason := req.Reason
@@ -1224,6 +1576,22 @@ func (m *Manager) Refund(ctx context.Context, req *RefundRequest) error {
 			Field{"amount", req.Amount},
 			Field{"reason", req.Reason},
 		)
+
+		tier := m.config.DefaultTier
+		if ent != nil {
+			tier = ent.Tier
+		}
+		m.rearmWarnings(ctx, req.UserID, req.Resource, tier, period)
+		m.emit(ctx, &Event{
+			Type:       EventRefund,
+			UserID:     req.UserID,
+			Resource:   req.Resource,
+			PeriodType: req.PeriodType,
+			Tier:       tier,
+			Amount:     req.Amount,
+			Reason:     req.Reason,
+			Metadata:   req.Metadata,
+		})
 	} else {
 		m.logger.Error("failed to refund quota",
 			Field{"userId", req.UserID},
@@ -1288,6 +1656,15 @@ func (m *Manager) TopUpLimit(ctx context.Context, userID, resource string, amoun
 		Field{"resource", resource},
 		Field{"amount", amount},
 	)
+	m.rearmWarnings(ctx, userID, resource, "", period)
+
+	m.emit(ctx, &Event{
+		Type:       EventTopUp,
+		UserID:     userID,
+		Resource:   resource,
+		PeriodType: PeriodTypeForever,
+		Amount:     amount,
+	})
 
 	return nil
 }
@@ -1348,6 +1725,15 @@ func (m *Manager) RefundCredits(
 		Field{"reason", reason},
 	)
 
+	m.emit(ctx, &Event{
+		Type:       EventRefund,
+		UserID:     userID,
+		Resource:   resource,
+		PeriodType: PeriodTypeForever,
+		Amount:     amount,
+		Reason:     reason,
+	})
+
 	return nil
 }
 
@@ -1381,6 +1767,9 @@ func (m *Manager) checkRateLimit(ctx context.Context, userID, resource, tier str
 	if !allowed {
 		m.metrics.RecordRateLimitExceeded(userID, resource)
 	}
+	if warnings := tierConfig.RateLimitWarnings[resource]; err == nil && info != nil && len(warnings) > 0 {
+		m.checkRateLimitWarnings(ctx, userID, resource, tier, rateLimitConfig, warnings, info)
+	}
 
 	return allowed, info, err
 }
@@ -1416,58 +1805,6 @@ func (m *Manager) getLimitForResource(resource, tier string, periodType PeriodTy
 	return 0
 }
 
-func (m *Manager) checkWarnings(ctx context.Context, userID, resource, tier string,
-	limit, currentUsed, amount int, period Period) {
-	thresholds := m.getWarningThresholds(resource, tier)
-	if len(thresholds) == 0 {
-		return
-	}
-
-	previousUsed := currentUsed - amount
-	for _, threshold := range thresholds {
-		boundary := float64(limit) * threshold
-		if float64(previousUsed) < boundary && float64(currentUsed) >= boundary {
-			// Threshold crossed
-			usage := &Usage{
-				UserID:    userID,
-				Resource:  resource,
-				Used:      currentUsed,
-				Limit:     limit,
-				Period:    period,
-				Tier:      tier,
-				UpdatedAt: m.now(ctx),
-			}
-
-			// Record warning metric
-			m.metrics.RecordQuotaWarning(resource, tier, threshold)
-
-			// Determine threshold range for users approaching limit
-			usagePercent := float64(currentUsed) / float64(limit)
-			var thresholdRange string
-			if usagePercent >= 0.9 {
-				thresholdRange = "90-100%"
-			} else if usagePercent >= 0.8 {
-				thresholdRange = "80-90%"
-			} else if usagePercent >= 0.5 {
-				thresholdRange = "50-80%"
-			}
-			if thresholdRange != "" {
-				m.metrics.RecordUsersApproachingLimit(resource, tier, thresholdRange)
-			}
-
-			// Call global handler
-			if m.config.WarningHandler != nil {
-				m.config.WarningHandler.OnWarning(ctx, usage, threshold)
-			}
-
-			// Call context handler if present
-			if ctxHandler, ok := ctx.Value(contextWarningKey{}).(WarningHandler); ok {
-				ctxHandler.OnWarning(ctx, usage, threshold)
-			}
-		}
-	}
-}
-
 func (m *Manager) getWarningThresholds(resource, tier string) []float64 {
 	if t, ok := m.config.Tiers[tier]; ok {
 		if thresholds, ok := t.WarningThresholds[resource]; ok {
@@ -1497,8 +1834,11 @@ func (m *Manager) now(ctx context.Context) time.Time {
 // logAuditEntry logs an audit entry if the storage implements AuditLogger.
 // This is a helper method that safely checks for AuditLogger implementation.
 func (m *Manager) logAuditEntry(ctx context.Context, entry *AuditLogEntry) {
-	if auditLogger, ok := m.storage.(AuditLogger); ok {
-		if err := auditLogger.LogAuditEntry(ctx, entry); err != nil {
+	if auditLogger, ok := storageAs[AuditLogger](m.storage); ok {
+		err := m.withCircuitBreaker(ctx, "LogAuditEntry", func() error {
+			return auditLogger.LogAuditEntry(ctx, entry)
+		})
+		if err != nil {
 			// Log error but don't fail the operation
 			m.logger.Warn("failed to log audit entry",
 				Field{"action", entry.Action},
@@ -1512,11 +1852,26 @@ func (m *Manager) logAuditEntry(ctx context.Context, entry *AuditLogEntry) {
 // GetAuditLogs retrieves audit logs if the storage implements AuditLogger.
 // Returns an error if storage doesn't implement AuditLogger or if query fails.
 func (m *Manager) GetAuditLogs(ctx context.Context, filter AuditLogFilter) ([]*AuditLogEntry, error) {
-	auditLogger, ok := m.storage.(AuditLogger)
+	auditLogger, ok := storageAs[AuditLogger](m.storage)
 	if !ok {
 		return nil, fmt.Errorf("storage does not implement AuditLogger")
 	}
-	return auditLogger.GetAuditLogs(ctx, filter)
+	var entries []*AuditLogEntry
+	err := m.withCircuitBreaker(ctx, "GetAuditLogs", func() error {
+		var e error
+		entries, e = auditLogger.GetAuditLogs(ctx, filter)
+		return e
+	})
+	return entries, err
+}
+
+// withCircuitBreaker runs fn within the circuit breaker of operation, if one is configured.
+// Used for optional storage interfaces, which CircuitBreakerStorage doesn't wrap.
+func (m *Manager) withCircuitBreaker(ctx context.Context, operation string, fn func() error) error {
+	if cbStorage, ok := storageAs[*CircuitBreakerStorage](m.storage); ok {
+		return cbStorage.Execute(ctx, operation, fn)
+	}
+	return fn()
 }
 
 // SetUsage manually sets the used amount for a specific resource and period.
@@ -1603,9 +1958,10 @@ func (m *Manager) SetUsage(ctx context.Context, userID, resource string, periodT
 		return err
 	}
 
-	// Invalidate cache
+	// Invalidate cache. Leased quota was overwritten with the usage.
 	usageKey := userID + ":" + resource + ":" + period.Key()
 	m.cache.InvalidateUsage(usageKey)
+	m.discardQuotaLeases(ctx, usageKey)
 
 	m.logger.Info("usage set successfully",
 		Field{"userId", userID},
@@ -1613,6 +1969,7 @@ func (m *Manager) SetUsage(ctx context.Context, userID, resource string, periodT
 		Field{"amount", amount},
 		Field{"periodType", periodType},
 	)
+	m.rearmWarnings(ctx, userID, resource, tier, period)
 
 	// Log audit entry
 	m.logAuditEntry(ctx, &AuditLogEntry{
@@ -1680,5 +2037,15 @@ func (m *Manager) GrantOneTimeCredit(ctx context.Context, userID, resource strin
 // ResetUsage resets the usage to zero for a specific resource and period.
 // This is a convenience method that calls SetUsage with amount 0.
 func (m *Manager) ResetUsage(ctx context.Context, userID, resource string, periodType PeriodType) error {
-	return m.SetUsage(ctx, userID, resource, periodType, 0)
+	if err := m.SetUsage(ctx, userID, resource, periodType, 0); err != nil {
+		return err
+	}
+	m.emit(ctx, &Event{
+		Type:       EventPeriodReset,
+		UserID:     userID,
+		Resource:   resource,
+		PeriodType: periodType,
+		Reason:     "admin_reset",
+	})
+	return nil
 }
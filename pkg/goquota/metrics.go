@@ -80,6 +80,45 @@ type Metrics interface {
 	RecordResourceFilterQueriesSaved(savedCount int)
 	// RecordResourceFilterEffectivenessRatio records the effectiveness ratio
 	RecordResourceFilterEffectivenessRatio(ratio float64)
+
+	// Event metrics
+	// RecordEventDropped records a lifecycle event dropped because a sink's or the webhook buffer was full
+	RecordEventDropped(eventType string)
+	// RecordEventPublishError records a failed EventSink.Publish call
+	RecordEventPublishError(eventType string)
+
+	// Webhook metrics
+	// RecordWebhookDelivery records a webhook delivery attempt outcome ("delivered", "retry", "dead_letter")
+	RecordWebhookDelivery(eventType, outcome string)
+
+	// Retry metrics
+	// RecordStorageRetry records a storage operation retried by RetryStorage
+	RecordStorageRetry(operation string)
+
+	// Override metrics
+	// RecordOverrideDecision records a consumption decided while a manual override applied.
+	// decision is "allowed", "denied", "journaled" or "enforced".
+	RecordOverrideDecision(resource, mode, decision string)
+
+	// Optimistic replay metrics
+	// RecordOptimisticReplay records the outcome of replaying an optimistic consumption to storage.
+	// outcome is "replayed", "conflict" or "failed".
+	RecordOptimisticReplay(resource, outcome string)
+
+	// Quota lease metrics
+	// RecordQuotaLease records quota leased from storage ("acquired"), returned to it ("returned")
+	// or consumed locally from a lease ("consumed").
+	RecordQuotaLease(resource, operation string, amount int)
+
+	// Tiered sync outbox metrics
+	// RecordSyncOutbox records the Cold writes queued in a tiered storage outbox: pending ones,
+	// parked ones and the age of the oldest pending one.
+	RecordSyncOutbox(pending, parked int, lag time.Duration)
+	// RecordSyncOutboxDelivery records a Cold write attempt from the outbox ("delivered", "retry", "parked")
+	RecordSyncOutboxDelivery(outcome string)
+	// RecordSyncDrift records a field on which tiered Hot and Cold stores disagree
+	// ("reported", "repaired", "repair_failed")
+	RecordSyncDrift(field, outcome string)
 }
 
 // NoopMetrics is a no-op implementation of the Metrics interface.
@@ -114,3 +153,13 @@ func (n *NoopMetrics) RecordActiveUserByTier(_ string)
 func (n *NoopMetrics) RecordUsersApproachingLimit(_, _, _ string)                {}
 func (n *NoopMetrics) RecordResourceFilterQueriesSaved(_ int)                    {}
 func (n *NoopMetrics) RecordResourceFilterEffectivenessRatio(_ float64)          {}
+func (n *NoopMetrics) RecordEventDropped(_ string)                               {}
+func (n *NoopMetrics) RecordEventPublishError(_ string)                          {}
+func (n *NoopMetrics) RecordWebhookDelivery(_, _ string)                         {}
+func (n *NoopMetrics) RecordStorageRetry(_ string)                               {}
+func (n *NoopMetrics) RecordOverrideDecision(_, _, _ string)                     {}
+func (n *NoopMetrics) RecordOptimisticReplay(_, _ string)                        {}
+func (n *NoopMetrics) RecordQuotaLease(_, _ string, _ int)                       {}
+func (n *NoopMetrics) RecordSyncOutbox(_, _ int, _ time.Duration)                {}
+func (n *NoopMetrics) RecordSyncOutboxDelivery(_ string)                         {}
+func (n *NoopMetrics) RecordSyncDrift(_, _ string)                               {}
@@ -52,6 +52,35 @@ type Metrics struct {
 	// Performance optimization metrics
 	resourceFilterQueriesSavedTotal  *prometheus.CounterVec
 	resourceFilterEffectivenessRatio *prometheus.GaugeVec
+
+	// Event metrics
+	eventsDroppedTotal      *prometheus.CounterVec
+	eventPublishErrorsTotal *prometheus.CounterVec
+
+	// Webhook metrics
+	webhookDeliveriesTotal *prometheus.CounterVec
+
+	// Retry metrics
+	storageRetriesTotal *prometheus.CounterVec
+
+	// Override metrics
+	overrideDecisionsTotal *prometheus.CounterVec
+
+	// Optimistic replay metrics
+	optimisticReplaysTotal *prometheus.CounterVec
+
+	// Quota lease metrics
+	quotaLeaseOperationsTotal *prometheus.CounterVec
+	quotaLeaseUnitsTotal      *prometheus.CounterVec
+
+	// Tiered sync outbox metrics
+	syncOutboxPending         prometheus.Gauge
+	syncOutboxParked          prometheus.Gauge
+	syncOutboxLagSeconds      prometheus.Gauge
+	syncOutboxDeliveriesTotal *prometheus.CounterVec
+
+	// Tiered drift metrics
+	syncDriftTotal *prometheus.CounterVec
 }
 
 // NewMetrics creates a new Prometheus metrics implementation.
@@ -255,6 +284,88 @@ func NewMetrics(reg prometheus.Registerer, namespace string) *Metrics {
 			Name:      "resource_filter_effectiveness_ratio",
 			Help:      "ResourceFilter effectiveness ratio (filtered/total).",
 		}, []string{}),
+
+		// Event metrics
+		eventsDroppedTotal: factory.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "events_dropped_total",
+			Help:      "Total number of lifecycle events dropped because a sink buffer was full.",
+		}, []string{"event_type"}),
+
+		eventPublishErrorsTotal: factory.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "event_publish_errors_total",
+			Help:      "Total number of failed lifecycle event publishes.",
+		}, []string{"event_type"}),
+
+		// Webhook metrics
+		webhookDeliveriesTotal: factory.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "webhook_deliveries_total",
+			Help:      "Total number of webhook delivery attempts by outcome (delivered, retry, dead_letter).",
+		}, []string{"event_type", "outcome"}),
+
+		// Retry metrics
+		storageRetriesTotal: factory.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "storage_retries_total",
+			Help:      "Total number of storage operations retried after a transient error.",
+		}, []string{"operation"}),
+
+		// Override metrics
+		overrideDecisionsTotal: factory.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "override_decisions_total",
+			Help:      "Total number of consumptions decided under a manual override by mode and decision.",
+		}, []string{"resource", "mode", "decision"}),
+
+		// Optimistic replay metrics
+		optimisticReplaysTotal: factory.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "optimistic_replays_total",
+			Help:      "Total number of optimistic consumptions replayed to storage by outcome.",
+		}, []string{"resource", "outcome"}),
+
+		// Quota lease metrics
+		quotaLeaseOperationsTotal: factory.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "quota_lease_operations_total",
+			Help:      "Total number of quota lease acquisitions, returns and local consumptions.",
+		}, []string{"resource", "operation"}),
+		quotaLeaseUnitsTotal: factory.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "quota_lease_units_total",
+			Help:      "Total quota leased from storage, returned to it and consumed locally.",
+		}, []string{"resource", "operation"}),
+
+		// Tiered sync outbox metrics
+		syncOutboxPending: factory.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "sync_outbox_pending",
+			Help:      "Number of Cold writes waiting in the tiered storage outbox.",
+		}),
+		syncOutboxParked: factory.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "sync_outbox_parked",
+			Help:      "Number of Cold writes parked in the tiered storage outbox after failing permanently.",
+		}),
+		syncOutboxLagSeconds: factory.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "sync_outbox_lag_seconds",
+			Help:      "Age of the oldest Cold write waiting in the tiered storage outbox.",
+		}),
+		syncOutboxDeliveriesTotal: factory.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "sync_outbox_deliveries_total",
+			Help:      "Total number of Cold write attempts from the tiered sync outbox by outcome.",
+		}, []string{"outcome"}),
+
+		// Tiered drift metrics
+		syncDriftTotal: factory.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "sync_drift_total",
+			Help:      "Total number of fields found to differ between tiered Hot and Cold stores by outcome.",
+		}, []string{"field", "outcome"}),
 	}
 }
 
@@ -397,6 +508,57 @@ func (m *Metrics) RecordResourceFilterEffectivenessRatio(ratio float64) {
 	m.resourceFilterEffectivenessRatio.WithLabelValues().Set(ratio)
 }
 
+// Event metrics
+func (m *Metrics) RecordEventDropped(eventType string) {
+	m.eventsDroppedTotal.WithLabelValues(eventType).Inc()
+}
+
+func (m *Metrics) RecordEventPublishError(eventType string) {
+	m.eventPublishErrorsTotal.WithLabelValues(eventType).Inc()
+}
+
+// Webhook metrics
+func (m *Metrics) RecordWebhookDelivery(eventType, outcome string) {
+	m.webhookDeliveriesTotal.WithLabelValues(eventType, outcome).Inc()
+}
+
+// Retry metrics
+func (m *Metrics) RecordStorageRetry(operation string) {
+	m.storageRetriesTotal.WithLabelValues(operation).Inc()
+}
+
+// Override metrics
+func (m *Metrics) RecordOverrideDecision(resource, mode, decision string) {
+	m.overrideDecisionsTotal.WithLabelValues(resource, mode, decision).Inc()
+}
+
+// Optimistic replay metrics
+func (m *Metrics) RecordOptimisticReplay(resource, outcome string) {
+	m.optimisticReplaysTotal.WithLabelValues(resource, outcome).Inc()
+}
+
+// Quota lease metrics
+func (m *Metrics) RecordQuotaLease(resource, operation string, amount int) {
+	m.quotaLeaseOperationsTotal.WithLabelValues(resource, operation).Inc()
+	m.quotaLeaseUnitsTotal.WithLabelValues(resource, operation).Add(float64(amount))
+}
+
+// Tiered sync outbox metrics
+func (m *Metrics) RecordSyncOutbox(pending, parked int, lag time.Duration) {
+	m.syncOutboxPending.Set(float64(pending))
+	m.syncOutboxParked.Set(float64(parked))
+	m.syncOutboxLagSeconds.Set(lag.Seconds())
+}
+
+func (m *Metrics) RecordSyncOutboxDelivery(outcome string) {
+	m.syncOutboxDeliveriesTotal.WithLabelValues(outcome).Inc()
+}
+
+// Tiered drift metrics
+func (m *Metrics) RecordSyncDrift(field, outcome string) {
+	m.syncDriftTotal.WithLabelValues(field, outcome).Inc()
+}
+
 // DefaultMetrics returns a Metrics implementation using the default Prometheus registerer.
 func DefaultMetrics(namespace string) *Metrics {
 	return NewMetrics(prometheus.DefaultRegisterer, namespace)
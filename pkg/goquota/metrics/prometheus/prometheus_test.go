@@ -209,3 +209,238 @@ func TestPrometheusMetrics_ConsumptionLabels(t *testing.T) {
 		t.Errorf("Expected at least 3 time series, got %d", len(consumptionMetric.Metric))
 	}
 }
+
+func TestPrometheusMetrics_RecordEventMetrics(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	metrics := NewMetrics(reg, "test")
+
+	metrics.RecordEventDropped("consume.succeeded")
+	metrics.RecordEventDropped("consume.succeeded")
+	metrics.RecordEventPublishError("tier.changed")
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Gather failed: %v", err)
+	}
+
+	found := map[string]float64{}
+	for _, family := range families {
+		for _, m := range family.GetMetric() {
+			found[family.GetName()] += m.GetCounter().GetValue()
+		}
+	}
+	if found["test_events_dropped_total"] != 2 {
+		t.Errorf("Expected 2 dropped events, got %v", found["test_events_dropped_total"])
+	}
+	if found["test_event_publish_errors_total"] != 1 {
+		t.Errorf("Expected 1 publish error, got %v", found["test_event_publish_errors_total"])
+	}
+}
+
+func TestPrometheusMetrics_RecordWebhookDelivery(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	metrics := NewMetrics(reg, "test")
+
+	metrics.RecordWebhookDelivery("quota.warning", "delivered")
+	metrics.RecordWebhookDelivery("quota.warning", "retry")
+	metrics.RecordWebhookDelivery("quota.exhausted", "dead_letter")
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Gather failed: %v", err)
+	}
+
+	var total float64
+	for _, family := range families {
+		if family.GetName() != "test_webhook_deliveries_total" {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			total += m.GetCounter().GetValue()
+		}
+	}
+	if total != 3 {
+		t.Errorf("Expected 3 webhook deliveries, got %v", total)
+	}
+}
+
+func TestPrometheusMetrics_RecordStorageRetry(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	metrics := NewMetrics(reg, "test")
+
+	metrics.RecordStorageRetry("GetUsage")
+	metrics.RecordStorageRetry("GetUsage")
+	metrics.RecordStorageRetry("ConsumeQuota")
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Gather failed: %v", err)
+	}
+
+	var total float64
+	for _, family := range families {
+		if family.GetName() != "test_storage_retries_total" {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			total += m.GetCounter().GetValue()
+		}
+	}
+	if total != 3 {
+		t.Errorf("Expected 3 storage retries, got %v", total)
+	}
+}
+
+func TestPrometheusMetrics_RecordOverrideDecision(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	metrics := NewMetrics(reg, "test")
+
+	metrics.RecordOverrideDecision("api_calls", "deny_all", "denied")
+	metrics.RecordOverrideDecision("api_calls", "deny_all", "denied")
+	metrics.RecordOverrideDecision("api_calls", "enforce_tiers", "allowed")
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Gather failed: %v", err)
+	}
+
+	var total float64
+	for _, family := range families {
+		if family.GetName() != "test_override_decisions_total" {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			total += m.GetCounter().GetValue()
+		}
+	}
+	if total != 3 {
+		t.Errorf("Expected 3 override decisions, got %v", total)
+	}
+}
+
+func TestPrometheusMetrics_RecordOptimisticReplay(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	metrics := NewMetrics(reg, "test")
+
+	metrics.RecordOptimisticReplay("api_calls", "replayed")
+	metrics.RecordOptimisticReplay("api_calls", "replayed")
+	metrics.RecordOptimisticReplay("api_calls", "conflict")
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Gather failed: %v", err)
+	}
+
+	var total float64
+	for _, family := range families {
+		if family.GetName() != "test_optimistic_replays_total" {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			total += m.GetCounter().GetValue()
+		}
+	}
+	if total != 3 {
+		t.Errorf("Expected 3 optimistic replays, got %v", total)
+	}
+}
+
+func TestPrometheusMetrics_RecordQuotaLease(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	metrics := NewMetrics(reg, "test")
+
+	metrics.RecordQuotaLease("api_calls", "acquired", 100)
+	metrics.RecordQuotaLease("api_calls", "consumed", 1)
+	metrics.RecordQuotaLease("api_calls", "consumed", 2)
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Gather failed: %v", err)
+	}
+
+	var operations, units float64
+	for _, family := range families {
+		for _, m := range family.GetMetric() {
+			switch family.GetName() {
+			case "test_quota_lease_operations_total":
+				operations += m.GetCounter().GetValue()
+			case "test_quota_lease_units_total":
+				units += m.GetCounter().GetValue()
+			}
+		}
+	}
+	if operations != 3 || units != 103 {
+		t.Errorf("Expected 3 operations and 103 units, got %v and %v", operations, units)
+	}
+}
+
+func TestPrometheusMetrics_RecordSyncOutbox(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	metrics := NewMetrics(reg, "test")
+
+	metrics.RecordSyncOutbox(3, 1, 2*time.Second)
+	metrics.RecordSyncOutboxDelivery("delivered")
+	metrics.RecordSyncOutboxDelivery("retry")
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Gather failed: %v", err)
+	}
+
+	values := make(map[string]float64)
+	for _, family := range families {
+		for _, m := range family.GetMetric() {
+			if family.GetType() == dto.MetricType_GAUGE {
+				values[family.GetName()] += m.GetGauge().GetValue()
+			} else {
+				values[family.GetName()] += m.GetCounter().GetValue()
+			}
+		}
+	}
+	if values["test_sync_outbox_pending"] != 3 || values["test_sync_outbox_parked"] != 1 {
+		t.Errorf("Expected 3 pending and 1 parked, got %v and %v",
+			values["test_sync_outbox_pending"], values["test_sync_outbox_parked"])
+	}
+	if values["test_sync_outbox_lag_seconds"] != 2 {
+		t.Errorf("Expected 2s lag, got %v", values["test_sync_outbox_lag_seconds"])
+	}
+	if values["test_sync_outbox_deliveries_total"] != 2 {
+		t.Errorf("Expected 2 deliveries, got %v", values["test_sync_outbox_deliveries_total"])
+	}
+}
+
+func TestPrometheusMetrics_RecordSyncDrift(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	metrics := NewMetrics(reg, "test")
+
+	metrics.RecordSyncDrift("used", "repaired")
+	metrics.RecordSyncDrift("used", "repaired")
+	metrics.RecordSyncDrift("tier", "reported")
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Gather failed: %v", err)
+	}
+
+	counts := make(map[string]float64)
+	for _, family := range families {
+		if family.GetName() != "test_sync_drift_total" {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			var field, outcome string
+			for _, label := range m.GetLabel() {
+				switch label.GetName() {
+				case "field":
+					field = label.GetValue()
+				case "outcome":
+					outcome = label.GetValue()
+				}
+			}
+			counts[field+"/"+outcome] = m.GetCounter().GetValue()
+		}
+	}
+	if counts["used/repaired"] != 2 || counts["tier/reported"] != 1 {
+		t.Errorf("Expected 2 repaired used and 1 reported tier drift, got %v", counts)
+	}
+}
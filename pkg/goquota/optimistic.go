@@ -0,0 +1,218 @@
+package goquota
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const defaultOptimisticReplayInterval = 10 * time.Second
+
+// Outcomes of replaying an optimistic consumption, recorded in metrics
+const (
+	optimisticReplayReplayed = "replayed"
+	optimisticReplayConflict = "conflict"
+	optimisticReplayFailed   = "failed"
+)
+
+// OptimisticConsumption is a consumption allowed by the optimistic fallback while storage was unavailable
+type OptimisticConsumption struct {
+	ID       string `json:"id"`
+	UserID   string `json:"user_id"`
+	Resource string `json:"resource"`
+	Tier     string `json:"tier"`
+	Amount   int    `json:"amount"`
+	Period   Period `json:"period"`
+	Limit    int    `json:"limit"`
+	// IdempotencyKey is the key the consumption is replayed with: the caller's key if it had one,
+	// so a retry that reached storage in the meantime isn't counted twice, or one derived from ID
+	IdempotencyKey string    `json:"idempotency_key"`
+	Timestamp      time.Time `json:"timestamp"`
+}
+
+// OptimisticJournal durably records optimistic consumptions until they are replayed to storage
+type OptimisticJournal interface {
+	// Append records a consumption
+	Append(ctx context.Context, entry *OptimisticConsumption) error
+
+	// Pending returns the consumptions not removed yet, oldest first
+	Pending(ctx context.Context) ([]*OptimisticConsumption, error)
+
+	// Remove removes a replayed consumption. Removing an unknown ID is not an error.
+	Remove(ctx context.Context, id string) error
+}
+
+// OptimisticReplayResult summarizes a replay of journaled optimistic consumptions
+type OptimisticReplayResult struct {
+	// Replayed is the number of consumptions written to storage
+	Replayed int
+
+	// Conflicts are the consumptions that would have exceeded the limit. They are removed from
+	// the journal without being applied.
+	Conflicts []*OptimisticConsumption
+}
+
+// optimisticReplayer periodically replays journaled optimistic consumptions
+type optimisticReplayer struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+	once   sync.Once
+}
+
+func (m *Manager) startOptimisticReplayer() {
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &optimisticReplayer{cancel: cancel, done: make(chan struct{})}
+	m.optimisticReplayer = r
+
+	go func() {
+		defer close(r.done)
+		ticker := time.NewTicker(m.config.FallbackConfig.ReplayInterval)
+		defer ticker.Stop()
+		for {
+			m.replayOptimisticInBackground(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// replayOptimisticInBackground replays pending consumptions unless the circuit is still open
+func (m *Manager) replayOptimisticInBackground(ctx context.Context) {
+	if cbStorage, ok := storageAs[*CircuitBreakerStorage](m.storage); ok &&
+		cbStorage.Breaker("ConsumeQuota").State() == StateOpen {
+		return
+	}
+	if _, err := m.ReplayOptimisticConsumptions(ctx); err != nil && ctx.Err() == nil {
+		m.logger.Warn("failed to replay optimistic consumptions", Field{"error", err})
+	}
+}
+
+func (r *optimisticReplayer) close(ctx context.Context) error {
+	r.once.Do(r.cancel)
+	select {
+	case <-r.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// journalOptimisticConsumption records a consumption allowed by the optimistic fallback
+func (m *Manager) journalOptimisticConsumption(ctx context.Context, req *ConsumeRequest) {
+	if m.optimisticJournal == nil {
+		return
+	}
+
+	entry := &OptimisticConsumption{
+		ID:             newEventID(),
+		UserID:         req.UserID,
+		Resource:       req.Resource,
+		Tier:           req.Tier,
+		Amount:         req.Amount,
+		Period:         req.Period,
+		Limit:          req.Limit,
+		IdempotencyKey: req.IdempotencyKey,
+		Timestamp:      time.Now().UTC(),
+	}
+	if entry.IdempotencyKey == "" {
+		entry.IdempotencyKey = "optimistic:" + entry.ID
+	}
+	if err := m.optimisticJournal.Append(ctx, entry); err != nil {
+		m.logger.Error("failed to journal optimistic consumption, it will not be replayed",
+			Field{"userId", req.UserID},
+			Field{"resource", req.Resource},
+			Field{"amount", req.Amount},
+			Field{"error", err},
+		)
+	}
+}
+
+// ReplayOptimisticConsumptions writes the consumptions allowed by the optimistic fallback back
+// to storage, oldest first, using their deterministic idempotency keys so a replay interrupted
+// by a crash never applies a consumption twice. Consumptions that would exceed the limit are
+// reported as conflicts (and to FallbackConfig.OnReplayConflict) and dropped.
+//
+// Replay stops at the first storage error; the remaining consumptions stay in the journal.
+// It runs automatically every FallbackConfig.ReplayInterval while the circuit is not open.
+// Returns an error if FallbackConfig.OptimisticJournal is not configured.
+func (m *Manager) ReplayOptimisticConsumptions(ctx context.Context) (*OptimisticReplayResult, error) {
+	if m.optimisticJournal == nil {
+		return nil, fmt.Errorf("fallbackConfig.optimisticJournal is not configured")
+	}
+
+	m.optimisticReplayMu.Lock()
+	defer m.optimisticReplayMu.Unlock()
+
+	entries, err := m.optimisticJournal.Pending(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read optimistic journal: %w", err)
+	}
+
+	result := &OptimisticReplayResult{}
+	for _, entry := range entries {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		_, err := m.storage.ConsumeQuota(ctx, &ConsumeRequest{
+			UserID:            entry.UserID,
+			Resource:          entry.Resource,
+			Amount:            entry.Amount,
+			Tier:              entry.Tier,
+			Period:            entry.Period,
+			Limit:             entry.Limit,
+			IdempotencyKey:    entry.IdempotencyKey,
+			IdempotencyKeyTTL: m.config.IdempotencyKeyTTL,
+		})
+		switch {
+		case err == nil || errors.Is(err, ErrIdempotencyKeyExists):
+			result.Replayed++
+			m.metrics.RecordOptimisticReplay(entry.Resource, optimisticReplayReplayed)
+		case errors.Is(err, ErrQuotaExceeded):
+			result.Conflicts = append(result.Conflicts, entry)
+			m.metrics.RecordOptimisticReplay(entry.Resource, optimisticReplayConflict)
+			m.logger.Warn("optimistic consumption exceeds the limit on replay",
+				Field{"userId", entry.UserID},
+				Field{"resource", entry.Resource},
+				Field{"amount", entry.Amount},
+				Field{"limit", entry.Limit},
+			)
+			if m.config.FallbackConfig.OnReplayConflict != nil {
+				m.config.FallbackConfig.OnReplayConflict(ctx, entry)
+			}
+		default:
+			m.metrics.RecordOptimisticReplay(entry.Resource, optimisticReplayFailed)
+			return result, fmt.Errorf("failed to replay optimistic consumption %s: %w", entry.ID, err)
+		}
+
+		if err := m.optimisticJournal.Remove(ctx, entry.ID); err != nil {
+			return result, fmt.Errorf("failed to remove replayed optimistic consumption %s: %w", entry.ID, err)
+		}
+		usageKey := entry.UserID + ":" + entry.Resource + ":" + entry.Period.Key()
+		m.cache.InvalidateUsage(usageKey)
+		if strategy := optimisticStrategy(m.fallbackStrategy); strategy != nil {
+			strategy.releaseOptimisticUsage(usageKey, entry.Amount)
+		}
+	}
+	return result, nil
+}
+
+// optimisticStrategy returns the optimistic strategy among the configured fallback strategies
+func optimisticStrategy(strategy FallbackStrategy) *OptimisticFallbackStrategy {
+	switch s := strategy.(type) {
+	case *OptimisticFallbackStrategy:
+		return s
+	case *CompositeFallbackStrategy:
+		for _, inner := range s.strategies {
+			if optimistic := optimisticStrategy(inner); optimistic != nil {
+				return optimistic
+			}
+		}
+	}
+	return nil
+}
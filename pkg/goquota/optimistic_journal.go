@@ -0,0 +1,68 @@
+package goquota
+
+import (
+	"context"
+	"sync"
+
+	"github.com/mihaimyh/goquota/internal/wal"
+)
+
+// FileOptimisticJournal is an OptimisticJournal backed by an append-only file (write-ahead log).
+// Every append and removal is written as a JSON line and synced to disk before returning, so
+// pending consumptions survive a crash or restart. The file is compacted when it is opened and
+// once it mostly holds superseded records, and truncated whenever no consumption is pending.
+type FileOptimisticJournal struct {
+	mu  sync.Mutex
+	log *wal.Log[OptimisticConsumption]
+}
+
+// NewFileOptimisticJournal opens (or creates) the journal at path and loads its pending
+// consumptions. A truncated last line, as left by a crash during a write, is ignored.
+func NewFileOptimisticJournal(path string) (*FileOptimisticJournal, error) {
+	log, err := wal.Open(path, wal.Config[OptimisticConsumption]{
+		Name:  "optimistic journal",
+		PutOp: "append",
+		ID:    func(entry *OptimisticConsumption) string { return entry.ID },
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &FileOptimisticJournal{log: log}, nil
+}
+
+// Append implements OptimisticJournal
+func (j *FileOptimisticJournal) Append(_ context.Context, entry *OptimisticConsumption) error {
+	entryCopy := *entry
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.log.Put(&entryCopy)
+}
+
+// Pending implements OptimisticJournal
+func (j *FileOptimisticJournal) Pending(_ context.Context) ([]*OptimisticConsumption, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	pending := j.log.Entries()
+	entries := make([]*OptimisticConsumption, 0, len(pending))
+	for _, entry := range pending {
+		entryCopy := *entry
+		entries = append(entries, &entryCopy)
+	}
+	return entries, nil
+}
+
+// Remove implements OptimisticJournal
+func (j *FileOptimisticJournal) Remove(_ context.Context, id string) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.log.Remove(id)
+}
+
+// Close closes the journal file. Pending consumptions are kept for the next NewFileOptimisticJournal.
+func (j *FileOptimisticJournal) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.log.Close()
+}
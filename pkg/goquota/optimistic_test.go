@@ -0,0 +1,247 @@
+package goquota_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mihaimyh/goquota/pkg/goquota"
+	"github.com/mihaimyh/goquota/storage/memory"
+)
+
+// outageStorage fails consumption while down, like storage during an incident
+type outageStorage struct {
+	*memory.Storage
+	down atomic.Bool
+}
+
+func (s *outageStorage) ConsumeQuota(ctx context.Context, req *goquota.ConsumeRequest) (int, error) {
+	if s.down.Load() {
+		return 0, goquota.ErrStorageUnavailable
+	}
+	return s.Storage.ConsumeQuota(ctx, req)
+}
+
+// conflictRecorder records the consumptions reported by FallbackConfig.OnReplayConflict
+type conflictRecorder struct {
+	mu        sync.Mutex
+	conflicts []*goquota.OptimisticConsumption
+}
+
+func (r *conflictRecorder) record(_ context.Context, entry *goquota.OptimisticConsumption) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.conflicts = append(r.conflicts, entry)
+}
+
+// startupJournal signals its first read, made by the replay the manager runs when it starts
+type startupJournal struct {
+	goquota.OptimisticJournal
+	once sync.Once
+	read chan struct{}
+}
+
+func (j *startupJournal) Pending(ctx context.Context) ([]*goquota.OptimisticConsumption, error) {
+	defer j.once.Do(func() { close(j.read) })
+	return j.OptimisticJournal.Pending(ctx)
+}
+
+// newOptimisticTestManager creates a manager journaling optimistic consumptions.
+// It returns once the startup replay has read the journal, so the replay cannot race the test.
+func newOptimisticTestManager(t *testing.T, storage goquota.Storage, journal goquota.OptimisticJournal,
+	conflicts *conflictRecorder) *goquota.Manager {
+	t.Helper()
+	startup := &startupJournal{OptimisticJournal: journal, read: make(chan struct{})}
+	manager := newEventsTestManager(t, storage, nil, func(c *goquota.Config) {
+		c.CacheConfig = &goquota.CacheConfig{Enabled: true, UsageTTL: time.Minute}
+		c.FallbackConfig = &goquota.FallbackConfig{
+			Enabled:                       true,
+			FallbackToCache:               true,
+			OptimisticAllowance:           true,
+			OptimisticAllowancePercentage: 50,
+			OptimisticJournal:             startup,
+			ReplayInterval:                time.Hour,
+			OnReplayConflict:              conflicts.record,
+		}
+	})
+	select {
+	case <-startup.read:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the startup replay")
+	}
+	return manager
+}
+
+func openTestJournal(t *testing.T, path string) *goquota.FileOptimisticJournal {
+	t.Helper()
+	journal, err := goquota.NewFileOptimisticJournal(path)
+	if err != nil {
+		t.Fatalf("Failed to open journal: %v", err)
+	}
+	t.Cleanup(func() { _ = journal.Close() })
+	return journal
+}
+
+// consumeDuringOutage consumes 1, then consumes amounts while consumption fails.
+// Usage reads keep working, so the usage cache the optimistic fallback needs is warm.
+func consumeDuringOutage(t *testing.T, manager *goquota.Manager, storage *outageStorage, amounts ...int) {
+	t.Helper()
+	ctx := context.Background()
+	if _, err := manager.Consume(ctx, "user1", "api_calls", 1, goquota.PeriodTypeMonthly); err != nil {
+		t.Fatalf("Consume failed: %v", err)
+	}
+	storage.down.Store(true)
+	for _, amount := range amounts {
+		if _, err := manager.GetQuota(ctx, "user1", "api_calls", goquota.PeriodTypeMonthly); err != nil {
+			t.Fatalf("GetQuota failed: %v", err)
+		}
+		if _, err := manager.Consume(ctx, "user1", "api_calls", amount, goquota.PeriodTypeMonthly); err != nil {
+			t.Fatalf("Expected optimistic consumption, got %v", err)
+		}
+	}
+	storage.down.Store(false)
+}
+
+func TestManager_ReplayOptimisticConsumptions(t *testing.T) {
+	ctx := context.Background()
+	storage := &outageStorage{Storage: memory.New()}
+	journal := openTestJournal(t, filepath.Join(t.TempDir(), "optimistic.wal"))
+	manager := newOptimisticTestManager(t, storage, journal, &conflictRecorder{})
+
+	consumeDuringOutage(t, manager, storage, 2, 3)
+	if pending, _ := journal.Pending(ctx); len(pending) != 2 {
+		t.Fatalf("Expected 2 journaled consumptions, got %d", len(pending))
+	}
+
+	result, err := manager.ReplayOptimisticConsumptions(ctx)
+	if err != nil {
+		t.Fatalf("Replay failed: %v", err)
+	}
+	if result.Replayed != 2 || len(result.Conflicts) != 0 {
+		t.Errorf("Unexpected replay result: %+v", result)
+	}
+	quota, err := manager.GetQuota(ctx, "user1", "api_calls", goquota.PeriodTypeMonthly)
+	if err != nil {
+		t.Fatalf("GetQuota failed: %v", err)
+	}
+	if quota.Used != 6 {
+		t.Errorf("Expected usage 6 after replay, got %d", quota.Used)
+	}
+
+	// Nothing is replayed twice
+	if pending, _ := journal.Pending(ctx); len(pending) != 0 {
+		t.Errorf("Expected empty journal, got %d entries", len(pending))
+	}
+	if result, err := manager.ReplayOptimisticConsumptions(ctx); err != nil || result.Replayed != 0 {
+		t.Errorf("Expected nothing to replay, got %+v, %v", result, err)
+	}
+}
+
+func TestManager_ReplayOptimisticConsumptions_Conflict(t *testing.T) {
+	ctx := context.Background()
+	storage := &outageStorage{Storage: memory.New()}
+	journal := openTestJournal(t, filepath.Join(t.TempDir(), "optimistic.wal"))
+	conflicts := &conflictRecorder{}
+	manager := newOptimisticTestManager(t, storage, journal, conflicts)
+
+	consumeDuringOutage(t, manager, storage, 4)
+
+	// Another instance consumed the quota in the meantime
+	period, err := manager.GetCurrentCycle(ctx, "user1")
+	if err != nil {
+		t.Fatalf("GetCurrentCycle failed: %v", err)
+	}
+	if _, err := storage.Storage.ConsumeQuota(ctx, &goquota.ConsumeRequest{
+		UserID: "user1", Resource: "api_calls", Amount: 8, Tier: "free", Limit: 10, Period: period,
+	}); err != nil {
+		t.Fatalf("ConsumeQuota failed: %v", err)
+	}
+
+	result, err := manager.ReplayOptimisticConsumptions(ctx)
+	if err != nil {
+		t.Fatalf("Replay failed: %v", err)
+	}
+	if result.Replayed != 0 || len(result.Conflicts) != 1 || result.Conflicts[0].Amount != 4 {
+		t.Errorf("Unexpected replay result: %+v", result)
+	}
+	if len(conflicts.conflicts) != 1 || conflicts.conflicts[0].UserID != "user1" {
+		t.Errorf("Expected conflict reported to OnReplayConflict, got %+v", conflicts.conflicts)
+	}
+	if pending, _ := journal.Pending(ctx); len(pending) != 0 {
+		t.Errorf("Expected conflicting consumption dropped from journal, got %d entries", len(pending))
+	}
+}
+
+func TestManager_ReplayOptimisticConsumptions_StorageStillDown(t *testing.T) {
+	ctx := context.Background()
+	storage := &outageStorage{Storage: memory.New()}
+	journal := openTestJournal(t, filepath.Join(t.TempDir(), "optimistic.wal"))
+	manager := newOptimisticTestManager(t, storage, journal, &conflictRecorder{})
+
+	consumeDuringOutage(t, manager, storage, 1)
+	storage.down.Store(true)
+
+	if _, err := manager.ReplayOptimisticConsumptions(ctx); err == nil {
+		t.Fatal("Expected replay error while storage is down")
+	}
+	if pending, _ := journal.Pending(ctx); len(pending) != 1 {
+		t.Errorf("Expected consumption kept in journal, got %d entries", len(pending))
+	}
+}
+
+func TestManager_ReplayOptimisticConsumptions_AfterRestart(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "optimistic.wal")
+	storage := &outageStorage{Storage: memory.New()}
+
+	journal, err := goquota.NewFileOptimisticJournal(path)
+	if err != nil {
+		t.Fatalf("Failed to open journal: %v", err)
+	}
+	manager := newOptimisticTestManager(t, storage, journal, &conflictRecorder{})
+	consumeDuringOutage(t, manager, storage, 3)
+	_ = manager.Close(ctx)
+	_ = journal.Close()
+
+	// Simulate a crash in the middle of writing a record
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		t.Fatalf("Failed to open journal file: %v", err)
+	}
+	_, _ = f.WriteString(`{"op":"append","entry":{"id":"tru`)
+	_ = f.Close()
+
+	// A new instance replays the journal when it starts
+	restarted := newOptimisticTestManager(t, storage, openTestJournal(t, path), &conflictRecorder{})
+	waitFor(t, "journal replay", func() bool {
+		quota, err := restarted.GetQuota(ctx, "user1", "api_calls", goquota.PeriodTypeMonthly)
+		return err == nil && quota.Used == 4
+	})
+}
+
+func TestConfig_Validate_OptimisticJournal(t *testing.T) {
+	journal := openTestJournal(t, filepath.Join(t.TempDir(), "optimistic.wal"))
+	config := &goquota.Config{
+		DefaultTier: "free",
+		Tiers:       map[string]goquota.TierConfig{"free": {Name: "free"}},
+		FallbackConfig: &goquota.FallbackConfig{
+			Enabled:           true,
+			OptimisticJournal: journal,
+			ReplayInterval:    -time.Second,
+		},
+	}
+	err := config.Validate()
+	if err == nil {
+		t.Fatal("Expected validation error")
+	}
+	for _, want := range []string{"optimisticJournal requires optimisticAllowance", "replayInterval cannot be negative"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error to mention %q, got %v", want, err)
+		}
+	}
+}
@@ -0,0 +1,575 @@
+package goquota
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultOverrideJournalMaxEntries = 10000
+	defaultOverrideRefreshInterval   = 5 * time.Second
+)
+
+// Override decisions recorded in metrics and logs
+const (
+	overrideDecisionAllowed   = "allowed"
+	overrideDecisionDenied    = "denied"
+	overrideDecisionJournaled = "journaled"
+	overrideDecisionEnforced  = "enforced"
+)
+
+// OverrideJournalEntry is a consumption allowed by OverrideAllowJournaled
+type OverrideJournalEntry struct {
+	UserID         string
+	Resource       string
+	Tier           string
+	Amount         int
+	PeriodType     PeriodType
+	IdempotencyKey string
+	// Reason is the reason of the override that allowed the consumption
+	Reason    string
+	Timestamp time.Time
+}
+
+// OverrideJournal records consumptions allowed by OverrideAllowJournaled while storage is not consulted
+type OverrideJournal interface {
+	// Append records a consumption
+	Append(ctx context.Context, entry *OverrideJournalEntry) error
+
+	// Entries returns the recorded consumptions, oldest first
+	Entries(ctx context.Context) ([]*OverrideJournalEntry, error)
+}
+
+// MemoryOverrideJournal is an in-memory OverrideJournal.
+// When full, the oldest entries are dropped.
+type MemoryOverrideJournal struct {
+	mu         sync.Mutex
+	entries    []*OverrideJournalEntry
+	maxEntries int
+}
+
+// NewMemoryOverrideJournal creates an in-memory journal holding up to maxEntries entries
+// (default: 10000 if maxEntries <= 0)
+func NewMemoryOverrideJournal(maxEntries int) *MemoryOverrideJournal {
+	if maxEntries <= 0 {
+		maxEntries = defaultOverrideJournalMaxEntries
+	}
+	return &MemoryOverrideJournal{maxEntries: maxEntries}
+}
+
+// Append implements OverrideJournal
+func (j *MemoryOverrideJournal) Append(_ context.Context, entry *OverrideJournalEntry) error {
+	entryCopy := *entry
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if len(j.entries) >= j.maxEntries {
+		j.entries = slices.Delete(j.entries, 0, len(j.entries)-j.maxEntries+1)
+	}
+	j.entries = append(j.entries, &entryCopy)
+	return nil
+}
+
+// Entries implements OverrideJournal
+func (j *MemoryOverrideJournal) Entries(_ context.Context) ([]*OverrideJournalEntry, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	entries := make([]*OverrideJournalEntry, 0, len(j.entries))
+	for _, entry := range j.entries {
+		entryCopy := *entry
+		entries = append(entries, &entryCopy)
+	}
+	return entries, nil
+}
+
+// overrideKey returns the key of an override scope
+func overrideKey(resource, tier string) string {
+	return resource + "\x00" + tier
+}
+
+// validateOverride checks the mode and tiers of an override
+func validateOverride(override *Override) error {
+	switch override.Mode {
+	case OverrideAllowAll, OverrideDenyAll, OverrideAllowJournaled:
+		if len(override.EnforceTiers) > 0 {
+			return fmt.Errorf("enforceTiers requires mode %q", OverrideEnforceTiers)
+		}
+	case OverrideEnforceTiers:
+		if len(override.EnforceTiers) == 0 {
+			return fmt.Errorf("mode %q requires enforceTiers", OverrideEnforceTiers)
+		}
+	default:
+		return fmt.Errorf("invalid override mode %q", override.Mode)
+	}
+	return nil
+}
+
+// initializeOverrides returns the initial overrides and journal of a config
+func initializeOverrides(config *OverrideConfig) (map[string]*Override, OverrideJournal) {
+	overrides := make(map[string]*Override)
+	if config == nil {
+		return overrides, NewMemoryOverrideJournal(0)
+	}
+
+	now := time.Now().UTC()
+	for i := range config.Overrides {
+		override := copyOverride(&config.Overrides[i])
+		if override.CreatedAt.IsZero() {
+			override.CreatedAt = now
+		}
+		overrides[overrideKey(override.Resource, override.Tier)] = override
+	}
+
+	journal := config.Journal
+	if journal == nil {
+		journal = NewMemoryOverrideJournal(0)
+	}
+	return overrides, journal
+}
+
+func copyOverride(override *Override) *Override {
+	overrideCopy := *override
+	overrideCopy.EnforceTiers = append([]string(nil), override.EnforceTiers...)
+	if overrideCopy.Actor == "" {
+		overrideCopy.Actor = "admin"
+	}
+	return &overrideCopy
+}
+
+// SetOverride activates a manual override, replacing any override with the same scope.
+// It takes effect immediately for this Manager instance and doesn't require storage.
+// With OverrideConfig.Shared, it is also saved for the other instances; if that fails,
+// ErrOverrideNotShared is returned and the override is saved again until storage recovers.
+func (m *Manager) SetOverride(ctx context.Context, override Override) error {
+	if err := validateOverride(&override); err != nil {
+		return err
+	}
+	if !override.ExpiresAt.IsZero() && !override.ExpiresAt.After(time.Now()) {
+		return fmt.Errorf("override expiresAt must be in the future")
+	}
+
+	o := copyOverride(&override)
+	if o.CreatedAt.IsZero() {
+		o.CreatedAt = time.Now().UTC()
+	}
+
+	key := overrideKey(o.Resource, o.Tier)
+	m.overrideMu.Lock()
+	m.overrides[key] = o
+	if m.overrideStore != nil {
+		// Kept by reloads until saved
+		m.overridePending[key] = o
+	}
+	m.overrideMu.Unlock()
+	shareErr := m.shareOverride(ctx, key, o)
+
+	m.logger.Warn("manual override set",
+		Field{"mode", o.Mode},
+		Field{"resource", o.Resource},
+		Field{"tier", o.Tier},
+		Field{"enforceTiers", o.EnforceTiers},
+		Field{"reason", o.Reason},
+		Field{"actor", o.Actor},
+		Field{"expiresAt", o.ExpiresAt},
+	)
+	metadata := map[string]string{"mode": string(o.Mode), "tier": o.Tier}
+	if !o.ExpiresAt.IsZero() {
+		metadata["expires_at"] = o.ExpiresAt.Format(time.RFC3339)
+	}
+	m.logAuditEntry(ctx, &AuditLogEntry{
+		ID:        newEventID(),
+		Resource:  o.Resource,
+		Action:    "override_set",
+		Timestamp: o.CreatedAt,
+		Actor:     o.Actor,
+		Reason:    o.Reason,
+		Metadata:  metadata,
+	})
+	return shareErr
+}
+
+// ClearOverride lifts the override with the given scope (empty resource and tier for the
+// global override), recording actor in the audit log (default: "admin").
+// Returns ErrOverrideNotFound if no override has that scope. With OverrideConfig.Shared, it is
+// also lifted for the other instances; if that fails, ErrOverrideNotShared is returned and the
+// override is deleted again until storage recovers.
+func (m *Manager) ClearOverride(ctx context.Context, resource, tier, actor string) error {
+	key := overrideKey(resource, tier)
+	if actor == "" {
+		actor = "admin"
+	}
+
+	m.overrideMu.Lock()
+	_, ok := m.overrides[key]
+	delete(m.overrides, key)
+	if m.overrideStore != nil {
+		m.overridePending[key] = nil
+	}
+	m.overrideMu.Unlock()
+	// Another instance may have set it since the last reload
+	shared, shareErr := m.unshareOverride(ctx, key, resource, tier)
+	if !ok && !shared && shareErr == nil {
+		return ErrOverrideNotFound
+	}
+
+	m.logger.Warn("manual override cleared",
+		Field{"resource", resource},
+		Field{"tier", tier},
+		Field{"actor", actor},
+	)
+	m.logAuditEntry(ctx, &AuditLogEntry{
+		ID:        newEventID(),
+		Resource:  resource,
+		Action:    "override_cleared",
+		Timestamp: time.Now().UTC(),
+		Actor:     actor,
+		Metadata:  map[string]string{"tier": tier},
+	})
+	return shareErr
+}
+
+// shareOverride saves an override set on this instance to the OverrideStore, if shared,
+// and tells the other instances to reload. If saving fails, the override stays pending and
+// is saved again by the next reload.
+func (m *Manager) shareOverride(ctx context.Context, key string, override *Override) error {
+	if m.overrideStore == nil {
+		return nil
+	}
+	if err := m.overrideStore.SaveOverride(ctx, override); err != nil {
+		m.logger.Error("failed to share manual override, retrying on reload",
+			Field{"resource", override.Resource},
+			Field{"tier", override.Tier},
+			Field{"error", err},
+		)
+		return fmt.Errorf("%w: %w", ErrOverrideNotShared, err)
+	}
+	m.savedOverride(key, override)
+	m.publishOverrideChange(ctx)
+	return nil
+}
+
+// unshareOverride deletes an override cleared on this instance from the OverrideStore, if
+// shared, and tells the other instances to reload. Returns whether it was stored. If deleting
+// fails, the clear stays pending and is deleted again by the next reload.
+func (m *Manager) unshareOverride(ctx context.Context, key, resource, tier string) (bool, error) {
+	if m.overrideStore == nil {
+		return false, nil
+	}
+	deleted, err := m.overrideStore.DeleteOverride(ctx, resource, tier)
+	if err != nil {
+		m.logger.Error("failed to clear shared manual override, retrying on reload",
+			Field{"resource", resource},
+			Field{"tier", tier},
+			Field{"error", err},
+		)
+		return false, fmt.Errorf("%w: %w", ErrOverrideNotShared, err)
+	}
+	m.savedOverride(key, nil)
+	if deleted {
+		m.publishOverrideChange(ctx)
+	}
+	return deleted, nil
+}
+
+// savedOverride stops keeping a change pending once saved, unless it was changed again since
+func (m *Manager) savedOverride(key string, override *Override) {
+	m.overrideMu.Lock()
+	defer m.overrideMu.Unlock()
+	if pending, ok := m.overridePending[key]; ok && pending == override {
+		delete(m.overridePending, key)
+	}
+}
+
+// publishOverrideChange tells the other instances to reload shared overrides, if they
+// share an InvalidationBus; otherwise they reload within OverrideConfig.RefreshInterval
+func (m *Manager) publishOverrideChange(ctx context.Context) {
+	bus := m.invalidationBus()
+	if bus == nil {
+		return
+	}
+	err := bus.Publish(ctx, &CacheInvalidation{Source: m.busSource, Kind: CacheInvalidationOverrides})
+	if err != nil {
+		m.logger.Warn("failed to publish manual override change", Field{"error", err})
+	}
+}
+
+// overrideRefresher reloads shared overrides periodically and when another instance changes them
+type overrideRefresher struct {
+	reload      chan struct{}
+	unsubscribe func()
+	cancel      context.CancelFunc
+	done        chan struct{}
+	once        sync.Once
+}
+
+func (m *Manager) startOverrideRefresher() {
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &overrideRefresher{
+		reload: make(chan struct{}, 1),
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	m.overrideRefresher = r
+
+	interval := m.config.OverrideConfig.RefreshInterval
+	loadCtx, loadCancel := context.WithTimeout(ctx, interval)
+	m.reloadOverrides(loadCtx)
+	loadCancel()
+
+	if bus := m.invalidationBus(); bus != nil {
+		unsubscribe, err := bus.Subscribe(func(invalidation *CacheInvalidation) {
+			// Notifications may have been missed on a clear, e.g. after a reconnect
+			if invalidation.Source == m.busSource ||
+				(invalidation.Kind != CacheInvalidationOverrides && invalidation.Kind != CacheInvalidationClear) {
+				return
+			}
+			select {
+			case r.reload <- struct{}{}:
+			default:
+			}
+		})
+		if err != nil {
+			m.logger.Warn("failed to subscribe to manual override changes, reloading periodically only",
+				Field{"error", err})
+		} else {
+			r.unsubscribe = unsubscribe
+		}
+	}
+
+	go func() {
+		defer close(r.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			case <-r.reload:
+			}
+			m.reloadOverrides(ctx)
+		}
+	}()
+}
+
+// reloadOverrides saves the pending changes of this instance, then replaces the overrides with
+// the stored ones. Changes still pending win; the current overrides are kept if storage fails.
+func (m *Manager) reloadOverrides(ctx context.Context) {
+	m.overrideMu.RLock()
+	pending := make(map[string]*Override, len(m.overridePending))
+	for key, override := range m.overridePending {
+		pending[key] = override
+	}
+	m.overrideMu.RUnlock()
+
+	for key, override := range pending {
+		var err error
+		if override != nil {
+			err = m.overrideStore.SaveOverride(ctx, override)
+		} else {
+			resource, tier, _ := strings.Cut(key, "\x00")
+			_, err = m.overrideStore.DeleteOverride(ctx, resource, tier)
+		}
+		if err != nil {
+			continue
+		}
+		m.savedOverride(key, override)
+		m.publishOverrideChange(ctx)
+	}
+
+	stored, err := m.overrideStore.ListOverrides(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			m.logger.Error("failed to reload shared manual overrides, keeping current ones",
+				Field{"error", err})
+		}
+		return
+	}
+	overrides := make(map[string]*Override, len(stored))
+	for _, override := range stored {
+		overrides[overrideKey(override.Resource, override.Tier)] = copyOverride(override)
+	}
+
+	m.overrideMu.Lock()
+	defer m.overrideMu.Unlock()
+	for key, override := range m.overridePending {
+		if override != nil {
+			overrides[key] = override
+		} else {
+			delete(overrides, key)
+		}
+	}
+	m.overrides = overrides
+}
+
+func (r *overrideRefresher) close(ctx context.Context) error {
+	r.once.Do(func() {
+		if r.unsubscribe != nil {
+			r.unsubscribe()
+		}
+		r.cancel()
+	})
+	select {
+	case <-r.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Overrides returns the active overrides, global first, then by resource and tier.
+// Expired overrides are not returned.
+func (m *Manager) Overrides() []Override {
+	now := time.Now()
+
+	m.overrideMu.RLock()
+	overrides := make([]Override, 0, len(m.overrides))
+	for _, override := range m.overrides {
+		if override.ExpiresAt.IsZero() || override.ExpiresAt.After(now) {
+			overrides = append(overrides, *copyOverride(override))
+		}
+	}
+	m.overrideMu.RUnlock()
+
+	sort.Slice(overrides, func(i, j int) bool {
+		if overrides[i].Resource != overrides[j].Resource {
+			return overrides[i].Resource < overrides[j].Resource
+		}
+		return overrides[i].Tier < overrides[j].Tier
+	})
+	return overrides
+}
+
+// OverrideJournalEntries returns the consumptions allowed by OverrideAllowJournaled, oldest first
+func (m *Manager) OverrideJournalEntries(ctx context.Context) ([]*OverrideJournalEntry, error) {
+	return m.overrideJournal.Entries(ctx)
+}
+
+// activeOverride returns the most specific unexpired override for a resource and tier
+// (empty tier if unknown, which only matches overrides without a tier)
+func (m *Manager) activeOverride(resource, tier string) *Override {
+	now := time.Now()
+
+	m.overrideMu.RLock()
+	defer m.overrideMu.RUnlock()
+	if len(m.overrides) == 0 {
+		return nil
+	}
+	for _, scope := range [][2]string{{resource, tier}, {resource, ""}, {"", tier}, {"", ""}} {
+		o, ok := m.overrides[overrideKey(scope[0], scope[1])]
+		if ok && (o.ExpiresAt.IsZero() || o.ExpiresAt.After(now)) {
+			return o
+		}
+	}
+	return nil
+}
+
+// hasTierOverride reports whether a tier-scoped override may apply to resource
+func (m *Manager) hasTierOverride(resource string) bool {
+	now := time.Now()
+
+	m.overrideMu.RLock()
+	defer m.overrideMu.RUnlock()
+	for _, o := range m.overrides {
+		if o.Tier != "" && (o.Resource == "" || o.Resource == resource) &&
+			(o.ExpiresAt.IsZero() || o.ExpiresAt.After(now)) {
+			return true
+		}
+	}
+	return false
+}
+
+// overrideTier returns the user's tier for override decisions, falling back to the default
+// tier if the entitlement can't be read
+func (m *Manager) overrideTier(ctx context.Context, userID string) string {
+	ent, err := m.GetEntitlement(ctx, userID)
+	if err == nil {
+		return ent.Tier
+	}
+	if err != ErrEntitlementNotFound {
+		m.logger.Warn("failed to get entitlement for manual override, using default tier",
+			Field{"userId", userID},
+			Field{"error", err},
+		)
+	}
+	return m.config.DefaultTier
+}
+
+// applyOverride decides a consumption under the active manual override, if any.
+// Returns handled=false if there is no override or the override enforces quotas for the user,
+// in which case the consumption proceeds normally.
+func (m *Manager) applyOverride(ctx context.Context, userID, resource string, amount int,
+	periodType PeriodType, opts *ConsumeOptions) (handled bool, err error) {
+	tier := ""
+	override := m.activeOverride(resource, "")
+	if (override != nil && override.Mode == OverrideEnforceTiers) || m.hasTierOverride(resource) {
+		tier = m.overrideTier(ctx, userID)
+		override = m.activeOverride(resource, tier)
+	}
+	if override == nil {
+		return false, nil
+	}
+
+	decision := overrideDecisionAllowed
+	switch override.Mode {
+	case OverrideDenyAll:
+		decision = overrideDecisionDenied
+		err = ErrQuotaExceeded
+	case OverrideAllowJournaled:
+		decision = overrideDecisionJournaled
+		if opts.DryRun {
+			// Dry runs consume nothing to reconcile
+			break
+		}
+		if journalErr := m.overrideJournal.Append(ctx, &OverrideJournalEntry{
+			UserID:         userID,
+			Resource:       resource,
+			Tier:           tier,
+			Amount:         amount,
+			PeriodType:     periodType,
+			IdempotencyKey: opts.IdempotencyKey,
+			Reason:         override.Reason,
+			Timestamp:      time.Now().UTC(),
+		}); journalErr != nil {
+			m.logger.Error("failed to record consumption in override journal",
+				Field{"userId", userID},
+				Field{"resource", resource},
+				Field{"amount", amount},
+				Field{"error", journalErr},
+			)
+		}
+	case OverrideEnforceTiers:
+		if slices.Contains(override.EnforceTiers, tier) {
+			decision = overrideDecisionEnforced
+		}
+	}
+
+	m.metrics.RecordOverrideDecision(resource, string(override.Mode), decision)
+	m.logger.Warn("consumption decided by manual override",
+		Field{"userId", userID},
+		Field{"resource", resource},
+		Field{"tier", tier},
+		Field{"amount", amount},
+		Field{"mode", override.Mode},
+		Field{"decision", decision},
+		Field{"reason", override.Reason},
+	)
+
+	switch {
+	case decision == overrideDecisionEnforced:
+		return false, nil
+	case opts.DryRun:
+		// Dry runs never block
+		return true, nil
+	case decision == overrideDecisionDenied && !opts.skipDeniedEvent:
+		m.emitUsageEvent(ctx, EventConsumeDenied, userID, resource, tier, periodType,
+			amount, 0, 0, "manual_override")
+	}
+	return true, err
+}
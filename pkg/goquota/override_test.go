@@ -0,0 +1,375 @@
+package goquota_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mihaimyh/goquota/pkg/goquota"
+	"github.com/mihaimyh/goquota/storage/memory"
+)
+
+// downStorage fails every quota operation, like storage during an incident
+type downStorage struct {
+	*memory.Storage
+}
+
+func (s downStorage) GetEntitlement(context.Context, string) (*goquota.Entitlement, error) {
+	return nil, goquota.ErrStorageUnavailable
+}
+
+func (s downStorage) GetConsumptionRecord(context.Context, string) (*goquota.ConsumptionRecord, error) {
+	return nil, goquota.ErrStorageUnavailable
+}
+
+func (s downStorage) ConsumeQuota(context.Context, *goquota.ConsumeRequest) (int, error) {
+	return 0, goquota.ErrStorageUnavailable
+}
+
+// overrideMetrics records override decisions
+type overrideMetrics struct {
+	goquota.NoopMetrics
+	mu        sync.Mutex
+	decisions []string
+}
+
+func (m *overrideMetrics) RecordOverrideDecision(resource, mode, decision string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.decisions = append(m.decisions, resource+":"+mode+":"+decision)
+}
+
+func (m *overrideMetrics) recorded() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]string(nil), m.decisions...)
+}
+
+func TestManager_Override_DuringStorageOutage(t *testing.T) {
+	sink := &recordingSink{}
+	metrics := &overrideMetrics{}
+	manager := newEventsTestManager(t, downStorage{memory.New()}, &goquota.EventConfig{Sinks: []goquota.EventSink{sink}},
+		func(c *goquota.Config) { c.Metrics = metrics })
+	ctx := context.Background()
+
+	if _, err := manager.Consume(ctx, "user1", "api_calls", 1, goquota.PeriodTypeMonthly); err == nil {
+		t.Fatal("Expected storage error without override")
+	}
+
+	if err := manager.SetOverride(ctx, goquota.Override{Mode: goquota.OverrideAllowAll, Reason: "INC-42"}); err != nil {
+		t.Fatalf("SetOverride failed: %v", err)
+	}
+	if _, err := manager.Consume(ctx, "user1", "api_calls", 1, goquota.PeriodTypeMonthly); err != nil {
+		t.Errorf("Expected consumption allowed by override, got %v", err)
+	}
+
+	// Replacing the global override switches to deny
+	if err := manager.SetOverride(ctx, goquota.Override{Mode: goquota.OverrideDenyAll}); err != nil {
+		t.Fatalf("SetOverride failed: %v", err)
+	}
+	_, err := manager.Consume(ctx, "user1", "api_calls", 1, goquota.PeriodTypeAuto)
+	if !errors.Is(err, goquota.ErrQuotaExceeded) {
+		t.Errorf("Expected ErrQuotaExceeded, got %v", err)
+	}
+	if overrides := manager.Overrides(); len(overrides) != 1 || overrides[0].Mode != goquota.OverrideDenyAll ||
+		overrides[0].Actor != "admin" || overrides[0].CreatedAt.IsZero() {
+		t.Errorf("Unexpected overrides: %+v", overrides)
+	}
+
+	want := []string{"api_calls:allow_all:allowed", "api_calls:deny_all:denied"}
+	if got := metrics.recorded(); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("Expected decisions %v, got %v", want, got)
+	}
+	waitFor(t, "denied event", func() bool { return len(sink.ofType(goquota.EventConsumeDenied)) == 1 })
+	if event := sink.ofType(goquota.EventConsumeDenied)[0]; event.Reason != "manual_override" {
+		t.Errorf("Expected manual_override reason, got %q", event.Reason)
+	}
+
+	if err := manager.ClearOverride(ctx, "", "", ""); err != nil {
+		t.Fatalf("ClearOverride failed: %v", err)
+	}
+	if err := manager.ClearOverride(ctx, "", "", ""); !errors.Is(err, goquota.ErrOverrideNotFound) {
+		t.Errorf("Expected ErrOverrideNotFound, got %v", err)
+	}
+	if _, err := manager.Consume(ctx, "user1", "api_calls", 1, goquota.PeriodTypeMonthly); err == nil {
+		t.Error("Expected storage error after clearing the override")
+	}
+}
+
+func TestManager_Override_Journal(t *testing.T) {
+	storage := memory.New()
+	manager := newEventsTestManager(t, storage, nil, func(c *goquota.Config) {
+		c.OverrideConfig = &goquota.OverrideConfig{
+			Overrides: []goquota.Override{{Mode: goquota.OverrideAllowJournaled, Resource: "api_calls", Reason: "bad limits"}},
+		}
+	})
+	ctx := context.Background()
+
+	for i := 0; i < 15; i++ {
+		if _, err := manager.Consume(ctx, "user1", "api_calls", 1, goquota.PeriodTypeMonthly,
+			goquota.WithIdempotencyKey(fmt.Sprintf("key%d", i))); err != nil {
+			t.Fatalf("Consume %d failed: %v", i, err)
+		}
+	}
+
+	entries, err := manager.OverrideJournalEntries(ctx)
+	if err != nil {
+		t.Fatalf("OverrideJournalEntries failed: %v", err)
+	}
+	if len(entries) != 15 {
+		t.Fatalf("Expected 15 journal entries, got %d", len(entries))
+	}
+
+	// Dry runs are not journaled: nothing was consumed
+	if _, err := manager.Consume(ctx, "user1", "api_calls", 1, goquota.PeriodTypeMonthly,
+		goquota.WithDryRun(true)); err != nil {
+		t.Fatalf("Dry run failed: %v", err)
+	}
+	if entries, _ := manager.OverrideJournalEntries(ctx); len(entries) != 15 {
+		t.Errorf("Expected dry runs not to be journaled, got %d entries", len(entries))
+	}
+	if entry := entries[0]; entry.UserID != "user1" || entry.Resource != "api_calls" || entry.Amount != 1 ||
+		entry.IdempotencyKey != "key0" || entry.Reason != "bad limits" || entry.Timestamp.IsZero() {
+		t.Errorf("Unexpected journal entry: %+v", entry)
+	}
+
+	// Journaled consumption doesn't reach storage, and other resources are not overridden
+	usage, err := manager.GetQuota(ctx, "user1", "api_calls", goquota.PeriodTypeMonthly)
+	if err != nil {
+		t.Fatalf("GetQuota failed: %v", err)
+	}
+	if usage.Used != 0 {
+		t.Errorf("Expected no usage in storage, got %d", usage.Used)
+	}
+	_, err = manager.Consume(ctx, "user1", "other", 1, goquota.PeriodTypeMonthly)
+	if !errors.Is(err, goquota.ErrQuotaExceeded) {
+		t.Errorf("Expected ErrQuotaExceeded for a resource without quota, got %v", err)
+	}
+}
+
+func TestManager_Override_AuditActor(t *testing.T) {
+	auditLogger := &MockAuditLogger{}
+	manager := newEventsTestManager(t, &AuditLoggerStorage{Storage: memory.New(), auditLogger: auditLogger}, nil, nil)
+	ctx := context.Background()
+
+	if err := manager.SetOverride(ctx, goquota.Override{Mode: goquota.OverrideAllowAll, Actor: "alice"}); err != nil {
+		t.Fatalf("SetOverride failed: %v", err)
+	}
+	if err := manager.ClearOverride(ctx, "", "", "bob"); err != nil {
+		t.Fatalf("ClearOverride failed: %v", err)
+	}
+
+	actors := map[string]string{}
+	for _, entry := range auditLogger.entries {
+		actors[entry.Action] = entry.Actor
+	}
+	if actors["override_set"] != "alice" || actors["override_cleared"] != "bob" {
+		t.Errorf("Expected override_set by alice and override_cleared by bob, got %v", actors)
+	}
+}
+
+func TestMemoryOverrideJournal_DropsOldest(t *testing.T) {
+	journal := goquota.NewMemoryOverrideJournal(2)
+	ctx := context.Background()
+	for _, userID := range []string{"a", "b", "c"} {
+		if err := journal.Append(ctx, &goquota.OverrideJournalEntry{UserID: userID}); err != nil {
+			t.Fatalf("Append failed: %v", err)
+		}
+	}
+
+	entries, err := journal.Entries(ctx)
+	if err != nil {
+		t.Fatalf("Entries failed: %v", err)
+	}
+	if len(entries) != 2 || entries[0].UserID != "b" || entries[1].UserID != "c" {
+		t.Errorf("Expected entries b and c, got %+v", entries)
+	}
+}
+
+func TestManager_Override_EnforceTiersAndScopes(t *testing.T) {
+	storage := memory.New()
+	manager := newEventsTestManager(t, storage, nil, nil)
+	ctx := context.Background()
+
+	if err := storage.SetEntitlement(ctx, &goquota.Entitlement{
+		UserID: "pro_user", Tier: "pro", SubscriptionStartDate: time.Now().UTC(), UpdatedAt: time.Now().UTC(),
+	}); err != nil {
+		t.Fatalf("SetEntitlement failed: %v", err)
+	}
+
+	if err := manager.SetOverride(ctx, goquota.Override{
+		Mode:         goquota.OverrideEnforceTiers,
+		EnforceTiers: []string{"pro"},
+	}); err != nil {
+		t.Fatalf("SetOverride failed: %v", err)
+	}
+
+	// Free users are not enforced; pro users are
+	if _, err := manager.Consume(ctx, "free_user", "api_calls", 50, goquota.PeriodTypeMonthly); err != nil {
+		t.Errorf("Expected free consumption allowed, got %v", err)
+	}
+	_, err := manager.Consume(ctx, "pro_user", "api_calls", 101, goquota.PeriodTypeMonthly)
+	if !errors.Is(err, goquota.ErrQuotaExceeded) {
+		t.Errorf("Expected ErrQuotaExceeded for pro user, got %v", err)
+	}
+	if used, err := manager.Consume(ctx, "pro_user", "api_calls", 5, goquota.PeriodTypeMonthly); err != nil || used != 5 {
+		t.Errorf("Expected enforced consumption to reach storage, got used=%d err=%v", used, err)
+	}
+
+	// A resource and tier override is more specific than the global one
+	if err := manager.SetOverride(ctx, goquota.Override{
+		Mode: goquota.OverrideDenyAll, Resource: "api_calls", Tier: "free",
+	}); err != nil {
+		t.Fatalf("SetOverride failed: %v", err)
+	}
+	_, err = manager.Consume(ctx, "free_user", "api_calls", 1, goquota.PeriodTypeMonthly)
+	if !errors.Is(err, goquota.ErrQuotaExceeded) {
+		t.Errorf("Expected ErrQuotaExceeded from tier override, got %v", err)
+	}
+	if overrides := manager.Overrides(); len(overrides) != 2 || overrides[0].Resource != "" ||
+		overrides[1].Tier != "free" {
+		t.Errorf("Expected global override first, got %+v", overrides)
+	}
+}
+
+func TestManager_Override_Expiry(t *testing.T) {
+	manager := newEventsTestManager(t, downStorage{memory.New()}, nil, nil)
+	ctx := context.Background()
+
+	if err := manager.SetOverride(ctx, goquota.Override{
+		Mode: goquota.OverrideAllowAll, ExpiresAt: time.Now().Add(-time.Second),
+	}); err == nil {
+		t.Error("Expected error for an override that already expired")
+	}
+
+	if err := manager.SetOverride(ctx, goquota.Override{
+		Mode: goquota.OverrideAllowAll, ExpiresAt: time.Now().Add(50 * time.Millisecond),
+	}); err != nil {
+		t.Fatalf("SetOverride failed: %v", err)
+	}
+	if _, err := manager.Consume(ctx, "user1", "api_calls", 1, goquota.PeriodTypeMonthly); err != nil {
+		t.Errorf("Expected consumption allowed by override, got %v", err)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	if overrides := manager.Overrides(); len(overrides) != 0 {
+		t.Errorf("Expected expired override to be lifted, got %+v", overrides)
+	}
+	if _, err := manager.Consume(ctx, "user1", "api_calls", 1, goquota.PeriodTypeMonthly); err == nil {
+		t.Error("Expected storage error after the override expired")
+	}
+}
+
+// unsharedStorage fails to save overrides while failing is set
+type unsharedStorage struct {
+	*memory.Storage
+	failing *atomic.Bool
+}
+
+func (s unsharedStorage) SaveOverride(ctx context.Context, override *goquota.Override) error {
+	if s.failing.Load() {
+		return goquota.ErrStorageUnavailable
+	}
+	return s.Storage.SaveOverride(ctx, override)
+}
+
+func TestManager_Override_Shared(t *testing.T) {
+	storage := memory.New()
+	bus := goquota.NewMemoryInvalidationBus()
+	newManager := func() *goquota.Manager {
+		return newEventsTestManager(t, storage, nil, func(c *goquota.Config) {
+			// Changes are broadcast: the refresh interval never elapses
+			c.OverrideConfig = &goquota.OverrideConfig{Shared: true, RefreshInterval: time.Hour}
+			c.CacheConfig = &goquota.CacheConfig{Enabled: true, InvalidationBus: bus}
+		})
+	}
+	first, second := newManager(), newManager()
+	ctx := context.Background()
+
+	if err := first.SetOverride(ctx, goquota.Override{Mode: goquota.OverrideDenyAll, Resource: "api_calls"}); err != nil {
+		t.Fatalf("SetOverride failed: %v", err)
+	}
+	waitFor(t, "the override on the second instance", func() bool { return len(second.Overrides()) == 1 })
+	if _, err := second.Consume(ctx, "user1", "api_calls", 1, goquota.PeriodTypeMonthly); !errors.Is(err, goquota.ErrQuotaExceeded) {
+		t.Errorf("Expected the shared override to deny consumption, got %v", err)
+	}
+
+	// Instances started later load the shared overrides
+	if overrides := newManager().Overrides(); len(overrides) != 1 || overrides[0].Mode != goquota.OverrideDenyAll {
+		t.Errorf("Expected the shared override at startup, got %+v", overrides)
+	}
+
+	// Cleared from any instance
+	if err := second.ClearOverride(ctx, "api_calls", "", "bob"); err != nil {
+		t.Fatalf("ClearOverride failed: %v", err)
+	}
+	waitFor(t, "the override to be cleared on the first instance", func() bool { return len(first.Overrides()) == 0 })
+	if err := first.ClearOverride(ctx, "api_calls", "", "bob"); !errors.Is(err, goquota.ErrOverrideNotFound) {
+		t.Errorf("Expected ErrOverrideNotFound, got %v", err)
+	}
+}
+
+func TestManager_Override_SharedRetriesUntilSaved(t *testing.T) {
+	storage := unsharedStorage{Storage: memory.New(), failing: &atomic.Bool{}}
+	storage.failing.Store(true)
+	manager := newEventsTestManager(t, storage, nil, func(c *goquota.Config) {
+		c.OverrideConfig = &goquota.OverrideConfig{Shared: true, RefreshInterval: 10 * time.Millisecond}
+	})
+	ctx := context.Background()
+
+	err := manager.SetOverride(ctx, goquota.Override{Mode: goquota.OverrideAllowAll})
+	if !errors.Is(err, goquota.ErrOverrideNotShared) {
+		t.Fatalf("Expected ErrOverrideNotShared, got %v", err)
+	}
+
+	// Applied locally and kept by reloads until saved
+	time.Sleep(50 * time.Millisecond)
+	if overrides := manager.Overrides(); len(overrides) != 1 {
+		t.Fatalf("Expected the override to apply locally, got %+v", overrides)
+	}
+
+	storage.failing.Store(false)
+	waitFor(t, "the override to be saved", func() bool {
+		stored, _ := storage.ListOverrides(ctx)
+		return len(stored) == 1
+	})
+	if overrides := manager.Overrides(); len(overrides) != 1 {
+		t.Errorf("Expected the saved override to stay active, got %+v", overrides)
+	}
+}
+
+func TestConfig_Validate_OverrideConfig(t *testing.T) {
+	config := &goquota.Config{
+		DefaultTier: "free",
+		Tiers:       map[string]goquota.TierConfig{"free": {Name: "free"}},
+		OverrideConfig: &goquota.OverrideConfig{
+			Overrides: []goquota.Override{
+				{Mode: "maintenance"},
+				{Mode: goquota.OverrideEnforceTiers, Resource: "api_calls"},
+				{Mode: goquota.OverrideDenyAll, Resource: "api_calls"},
+			},
+		},
+	}
+	err := config.Validate()
+	if err == nil {
+		t.Fatal("Expected validation error")
+	}
+	for _, want := range []string{"invalid override mode", "requires enforceTiers", "duplicate scope"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error to mention %q, got %v", want, err)
+		}
+	}
+
+	config.OverrideConfig = &goquota.OverrideConfig{
+		Shared:    true,
+		Overrides: []goquota.Override{{Mode: goquota.OverrideAllowAll}},
+	}
+	if err := config.Validate(); err == nil || !strings.Contains(err.Error(), "not allowed with shared") {
+		t.Errorf("Expected error for initial overrides with shared overrides, got %v", err)
+	}
+}
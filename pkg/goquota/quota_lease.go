@@ -0,0 +1,413 @@
+package goquota
+
+import (
+	"context"
+	"errors"
+	"math"
+	"slices"
+	"sync"
+	"time"
+)
+
+const (
+	defaultQuotaLeaseBlockPercentage = 1
+	defaultQuotaLeaseMinBlock        = 1
+	defaultQuotaLeaseTTL             = 30 * time.Second
+)
+
+// Quota lease operations, recorded in metrics
+const (
+	quotaLeaseAcquired = "acquired"
+	quotaLeaseReturned = "returned"
+	quotaLeaseConsumed = "consumed"
+)
+
+// QuotaLeaseConfig configures local quota leases: instead of consuming from storage on every
+// Consume, each Manager reserves a block of quota with one atomic ConsumeQuota and serves
+// consumptions from it in memory. Unused quota is returned with RefundQuota once the lease
+// has been idle for TTL, and on Close.
+//
+// Leased quota counts as used in storage (GetQuota includes it) until it is consumed or returned.
+// Blocks are a percentage of the remaining quota, so they shrink as it approaches zero: near
+// the limit each Consume goes to storage again and no instance can exceed the limit. Quota held
+// by other instances can deny a consumption early, by at most their blocks. A crashed instance
+// never returns its blocks. SetUsage and ResetUsage discard the blocks of the usage they reset,
+// and Refund and ApplyTierChange return them so the next block starts from storage; with
+// CacheConfig.InvalidationBus, other instances are told to do the same, otherwise their blocks
+// are discarded or returned when idle for TTL.
+type QuotaLeaseConfig struct {
+	// Resources are the resources consumed through leases. Consumptions with an idempotency key,
+	// of forever credits or of unlimited quotas always go to storage.
+	Resources []string
+
+	// BlockPercentage is the share of the remaining quota leased at a time (default: 1)
+	BlockPercentage float64
+
+	// MinBlock is the smallest block leased (default: 1)
+	MinBlock int
+
+	// MaxBlock is the largest block leased (0 for no maximum)
+	MaxBlock int
+
+	// TTL is how long a lease may stay idle before its unused quota is returned (default: 30 seconds)
+	TTL time.Duration
+}
+
+// quotaLease is quota reserved in storage for one user, resource and period
+type quotaLease struct {
+	mu       sync.Mutex
+	userID   string
+	resource string
+	period   Period
+	// remaining is the reserved quota not consumed yet
+	remaining int
+	// storageUsed is the usage in storage after the last acquisition, including remaining
+	storageUsed int
+	expiresAt   time.Time
+	// returnKey is the idempotency key of the refund returning remaining. It is generated when a
+	// block is acquired, so a retried return whose first refund committed is not refunded twice.
+	returnKey string
+	// returning is set once a return failed: the lease no longer changes until it is returned,
+	// so every retry refunds the same amount under the same key
+	returning bool
+	// closed is set once the lease is removed from the leaser
+	closed bool
+}
+
+// quotaLeaser holds the quota leases of a Manager and returns idle ones, and the ones
+// other instances asked to return
+type quotaLeaser struct {
+	config QuotaLeaseConfig
+	mu     sync.Mutex
+	leases map[string]*quotaLease
+	// usage keys whose leases other instances asked to return
+	stale       map[string]bool
+	wake        chan struct{}
+	unsubscribe func()
+	cancel      context.CancelFunc
+	done        chan struct{}
+	closeOnce   sync.Once
+}
+
+func applyQuotaLeaseDefaults(config *QuotaLeaseConfig) {
+	if config.BlockPercentage == 0 {
+		config.BlockPercentage = defaultQuotaLeaseBlockPercentage
+	}
+	if config.MinBlock == 0 {
+		config.MinBlock = defaultQuotaLeaseMinBlock
+	}
+	if config.TTL == 0 {
+		config.TTL = defaultQuotaLeaseTTL
+	}
+}
+
+func (m *Manager) startQuotaLeaser() {
+	ctx, cancel := context.WithCancel(context.Background())
+	l := &quotaLeaser{
+		config: *m.config.QuotaLeaseConfig,
+		leases: make(map[string]*quotaLease),
+		stale:  make(map[string]bool),
+		wake:   make(chan struct{}, 1),
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	m.quotaLeases = l
+
+	if bus := m.invalidationBus(); bus != nil {
+		unsubscribe, err := bus.Subscribe(m.handleQuotaLeaseInvalidation)
+		if err != nil {
+			m.logger.Warn("failed to subscribe to quota lease invalidations, returning leases when idle only",
+				Field{"error", err})
+		} else {
+			l.unsubscribe = unsubscribe
+		}
+	}
+
+	go func() {
+		defer close(l.done)
+		ticker := time.NewTicker(max(l.config.TTL/2, time.Millisecond))
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				m.returnQuotaLeases(ctx, func(_ string, lease *quotaLease) bool { return now.After(lease.expiresAt) })
+			case <-l.wake:
+				l.mu.Lock()
+				stale := l.stale
+				l.stale = make(map[string]bool)
+				l.mu.Unlock()
+				m.returnQuotaLeases(ctx, func(key string, _ *quotaLease) bool { return stale[key] })
+			}
+		}
+	}()
+}
+
+// handleQuotaLeaseInvalidation discards or returns the lease of a usage key changed by
+// another instance
+func (m *Manager) handleQuotaLeaseInvalidation(invalidation *CacheInvalidation) {
+	if invalidation.Source == m.busSource {
+		return
+	}
+	switch invalidation.Kind {
+	case CacheInvalidationQuotaLeaseDiscard:
+		m.discardQuotaLease(invalidation.Key)
+	case CacheInvalidationQuotaLeaseReturn:
+		// Returning calls storage: leave it to the leaser rather than the bus
+		l := m.quotaLeases
+		l.mu.Lock()
+		_, ok := l.leases[invalidation.Key]
+		if ok {
+			l.stale[invalidation.Key] = true
+		}
+		l.mu.Unlock()
+		if ok {
+			select {
+			case l.wake <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
+
+// close stops returning idle leases and returns all of them
+func (l *quotaLeaser) close(ctx context.Context, m *Manager) error {
+	l.closeOnce.Do(func() {
+		if l.unsubscribe != nil {
+			l.unsubscribe()
+		}
+		l.cancel()
+	})
+	select {
+	case <-l.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return m.returnQuotaLeases(ctx, func(string, *quotaLease) bool { return true })
+}
+
+// usesQuotaLease reports whether a consumption is served from a lease
+func (m *Manager) usesQuotaLease(req *ConsumeRequest) bool {
+	return m.quotaLeases != nil &&
+		req.IdempotencyKey == "" &&
+		req.Limit > 0 &&
+		req.Period.Type != PeriodTypeForever &&
+		slices.Contains(m.quotaLeases.config.Resources, req.Resource)
+}
+
+// consumeQuota consumes from a lease when configured, or from storage. It reports whether
+// storage was written, i.e. whether cached usage is stale.
+func (m *Manager) consumeQuota(ctx context.Context, req *ConsumeRequest) (newUsed int, wrote bool, err error) {
+	if !m.usesQuotaLease(req) {
+		start := time.Now()
+		newUsed, err = m.storage.ConsumeQuota(ctx, req)
+		m.metrics.RecordStorageOperation("ConsumeQuota", time.Since(start), err)
+		return newUsed, true, err
+	}
+	return m.consumeFromQuotaLease(ctx, req)
+}
+
+// leaseFor returns the open lease of a usage key, creating it if needed
+func (l *quotaLeaser) leaseFor(key string, req *ConsumeRequest) *quotaLease {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	lease, ok := l.leases[key]
+	if !ok {
+		lease = &quotaLease{userID: req.UserID, resource: req.Resource, period: req.Period}
+		l.leases[key] = lease
+	}
+	return lease
+}
+
+func (m *Manager) consumeFromQuotaLease(ctx context.Context, req *ConsumeRequest) (int, bool, error) {
+	key := req.UserID + ":" + req.Resource + ":" + req.Period.Key()
+	for {
+		lease := m.quotaLeases.leaseFor(key, req)
+		lease.mu.Lock()
+		if lease.closed {
+			// Returned concurrently: use a new lease
+			lease.mu.Unlock()
+			continue
+		}
+		newUsed, wrote, err := m.consumeFromLockedQuotaLease(ctx, lease, req)
+		lease.mu.Unlock()
+		return newUsed, wrote, err
+	}
+}
+
+func (m *Manager) consumeFromLockedQuotaLease(ctx context.Context, lease *quotaLease,
+	req *ConsumeRequest) (int, bool, error) {
+	config := &m.quotaLeases.config
+	if lease.returning {
+		// Frozen until its return succeeds: consume from storage meanwhile
+		start := time.Now()
+		newUsed, err := m.storage.ConsumeQuota(ctx, req)
+		m.metrics.RecordStorageOperation("ConsumeQuota", time.Since(start), err)
+		return newUsed, true, err
+	}
+	if lease.remaining >= req.Amount {
+		lease.remaining -= req.Amount
+		lease.expiresAt = time.Now().Add(config.TTL)
+		m.metrics.RecordQuotaLease(req.Resource, quotaLeaseConsumed, req.Amount)
+		return lease.storageUsed - lease.remaining, false, nil
+	}
+
+	block := quotaLeaseBlock(config, req.Limit-lease.storageUsed, req.Amount)
+	blockReq := *req
+	blockReq.Amount = block
+	start := time.Now()
+	newUsed, err := m.storage.ConsumeQuota(ctx, &blockReq)
+	m.metrics.RecordStorageOperation("ConsumeQuota", time.Since(start), err)
+	if errors.Is(err, ErrQuotaExceeded) && block > req.Amount {
+		// Not enough quota left for a block: consume exactly the amount
+		start = time.Now()
+		newUsed, err = m.storage.ConsumeQuota(ctx, req)
+		m.metrics.RecordStorageOperation("ConsumeQuota", time.Since(start), err)
+		block = req.Amount
+	}
+	if err != nil {
+		return 0, false, err
+	}
+
+	lease.remaining += block - req.Amount
+	lease.storageUsed = newUsed
+	lease.returnKey = "quota_lease:" + newEventID()
+	lease.expiresAt = time.Now().Add(config.TTL)
+	m.metrics.RecordQuotaLease(req.Resource, quotaLeaseAcquired, block)
+	m.metrics.RecordQuotaLease(req.Resource, quotaLeaseConsumed, req.Amount)
+	return newUsed - lease.remaining, true, nil
+}
+
+// quotaLeaseBlock returns the block to lease for a consumption of amount, given the
+// remaining quota as last seen in storage
+func quotaLeaseBlock(config *QuotaLeaseConfig, remaining, amount int) int {
+	block := int(math.Ceil(float64(max(remaining, 0)) * config.BlockPercentage / 100))
+	block = max(block, config.MinBlock)
+	if config.MaxBlock > 0 {
+		block = min(block, config.MaxBlock)
+	}
+	return max(block, amount)
+}
+
+// returnQuotaLeases returns the unused quota of the leases selected by shouldReturn
+// to storage and removes them
+func (m *Manager) returnQuotaLeases(ctx context.Context, shouldReturn func(key string, lease *quotaLease) bool) error {
+	m.quotaLeases.mu.Lock()
+	leases := make(map[string]*quotaLease, len(m.quotaLeases.leases))
+	for key, lease := range m.quotaLeases.leases {
+		leases[key] = lease
+	}
+	m.quotaLeases.mu.Unlock()
+
+	var errs []error
+	for key, lease := range leases {
+		lease.mu.Lock()
+		if lease.closed || !shouldReturn(key, lease) {
+			lease.mu.Unlock()
+			continue
+		}
+		if err := m.returnQuotaLease(ctx, lease); err != nil {
+			// Keep the lease: it is returned on the next attempt, under the same key
+			lease.returning = true
+			m.logger.Warn("failed to return leased quota",
+				Field{"userId", lease.userID},
+				Field{"resource", lease.resource},
+				Field{"amount", lease.remaining},
+				Field{"error", err},
+			)
+			errs = append(errs, err)
+			lease.mu.Unlock()
+			continue
+		}
+		lease.closed = true
+		lease.mu.Unlock()
+
+		m.quotaLeases.mu.Lock()
+		if m.quotaLeases.leases[key] == lease {
+			delete(m.quotaLeases.leases, key)
+		}
+		m.quotaLeases.mu.Unlock()
+	}
+	return errors.Join(errs...)
+}
+
+// returnQuotaLease refunds the unused quota of a locked lease
+func (m *Manager) returnQuotaLease(ctx context.Context, lease *quotaLease) error {
+	if lease.remaining == 0 {
+		return nil
+	}
+	err := m.storage.RefundQuota(ctx, &RefundRequest{
+		UserID:            lease.userID,
+		Resource:          lease.resource,
+		Amount:            lease.remaining,
+		PeriodType:        lease.period.Type,
+		Period:            lease.period,
+		IdempotencyKey:    lease.returnKey,
+		IdempotencyKeyTTL: m.config.IdempotencyKeyTTL,
+		Reason:            "quota_lease_return",
+	})
+	if err != nil {
+		return err
+	}
+	m.cache.InvalidateUsage(lease.userID + ":" + lease.resource + ":" + lease.period.Key())
+	m.metrics.RecordQuotaLease(lease.resource, quotaLeaseReturned, lease.remaining)
+	lease.remaining = 0
+	return nil
+}
+
+// refreshQuotaLeases returns the leases of a usage key whose usage or limit changed in storage,
+// on this instance and, through the InvalidationBus, on the others
+func (m *Manager) refreshQuotaLeases(ctx context.Context, usageKey string) {
+	if m.quotaLeases == nil {
+		return
+	}
+	err := m.returnQuotaLeases(ctx, func(key string, _ *quotaLease) bool { return key == usageKey })
+	if err != nil {
+		// Kept: returned when idle
+		m.logger.Warn("failed to return quota lease after a usage change",
+			Field{"key", usageKey}, Field{"error", err})
+	}
+	m.publishQuotaLeaseInvalidation(ctx, CacheInvalidationQuotaLeaseReturn, usageKey)
+}
+
+// discardQuotaLeases drops the leases of a usage key whose usage was overwritten in storage,
+// on this instance and, through the InvalidationBus, on the others
+func (m *Manager) discardQuotaLeases(ctx context.Context, usageKey string) {
+	if m.quotaLeases == nil {
+		return
+	}
+	m.discardQuotaLease(usageKey)
+	m.publishQuotaLeaseInvalidation(ctx, CacheInvalidationQuotaLeaseDiscard, usageKey)
+}
+
+func (m *Manager) publishQuotaLeaseInvalidation(ctx context.Context, kind, usageKey string) {
+	bus := m.invalidationBus()
+	if bus == nil {
+		return
+	}
+	if err := bus.Publish(ctx, &CacheInvalidation{Source: m.busSource, Kind: kind, Key: usageKey}); err != nil {
+		m.logger.Warn("failed to publish quota lease invalidation",
+			Field{"kind", kind}, Field{"key", usageKey}, Field{"error", err})
+	}
+}
+
+// discardQuotaLease drops the local lease of a usage key without returning it, after its
+// usage was overwritten in storage
+func (m *Manager) discardQuotaLease(usageKey string) {
+	if m.quotaLeases == nil {
+		return
+	}
+	m.quotaLeases.mu.Lock()
+	lease, ok := m.quotaLeases.leases[usageKey]
+	delete(m.quotaLeases.leases, usageKey)
+	m.quotaLeases.mu.Unlock()
+
+	if ok {
+		lease.mu.Lock()
+		lease.remaining = 0
+		lease.closed = true
+		lease.mu.Unlock()
+	}
+}
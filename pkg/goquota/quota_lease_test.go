@@ -0,0 +1,291 @@
+package goquota_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mihaimyh/goquota/pkg/goquota"
+	"github.com/mihaimyh/goquota/storage/memory"
+)
+
+// consumeCountingStorage counts the consumptions that reach storage
+type consumeCountingStorage struct {
+	*memory.Storage
+	consumes atomic.Int64
+}
+
+func (s *consumeCountingStorage) ConsumeQuota(ctx context.Context, req *goquota.ConsumeRequest) (int, error) {
+	s.consumes.Add(1)
+	return s.Storage.ConsumeQuota(ctx, req)
+}
+
+func newQuotaLeaseTestManager(t *testing.T, storage goquota.Storage, limit int,
+	leaseConfig *goquota.QuotaLeaseConfig) *goquota.Manager {
+	t.Helper()
+	return newEventsTestManager(t, storage, nil, func(c *goquota.Config) {
+		c.Tiers["free"] = goquota.TierConfig{Name: "free", MonthlyQuotas: map[string]int{"api_calls": limit}}
+		c.QuotaLeaseConfig = leaseConfig
+	})
+}
+
+func storedUsage(t *testing.T, manager *goquota.Manager) int {
+	t.Helper()
+	usage, err := manager.GetQuota(context.Background(), "user1", "api_calls", goquota.PeriodTypeMonthly)
+	if err != nil {
+		t.Fatalf("GetQuota failed: %v", err)
+	}
+	return usage.Used
+}
+
+func TestManager_QuotaLease(t *testing.T) {
+	ctx := context.Background()
+	storage := &consumeCountingStorage{Storage: memory.New()}
+	manager := newQuotaLeaseTestManager(t, storage, 10000, &goquota.QuotaLeaseConfig{
+		Resources:       []string{"api_calls"},
+		BlockPercentage: 10,
+		TTL:             time.Hour,
+	})
+
+	for i := 1; i <= 100; i++ {
+		newUsed, err := manager.Consume(ctx, "user1", "api_calls", 1, goquota.PeriodTypeMonthly)
+		if err != nil {
+			t.Fatalf("Consume failed: %v", err)
+		}
+		if newUsed != i {
+			t.Errorf("Expected used %d, got %d", i, newUsed)
+		}
+	}
+
+	// One block of 10% of the quota served all consumptions, and counts as used until returned
+	if got := storage.consumes.Load(); got != 1 {
+		t.Errorf("Expected 1 consumption in storage, got %d", got)
+	}
+	if used := storedUsage(t, manager); used != 1000 {
+		t.Errorf("Expected the leased block of 1000 in storage, got %d", used)
+	}
+
+	if err := manager.Close(ctx); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if used := storedUsage(t, manager); used != 100 {
+		t.Errorf("Expected unused quota returned on Close, got usage %d", used)
+	}
+}
+
+func TestManager_QuotaLease_NeverExceedsLimit(t *testing.T) {
+	ctx := context.Background()
+	storage := memory.New()
+	leaseConfig := &goquota.QuotaLeaseConfig{Resources: []string{"api_calls"}, BlockPercentage: 50, TTL: time.Hour}
+	instances := []*goquota.Manager{
+		newQuotaLeaseTestManager(t, storage, 100, leaseConfig),
+		newQuotaLeaseTestManager(t, storage, 100, leaseConfig),
+	}
+
+	// Blocks shrink as the remaining quota approaches zero, so the whole quota is usable
+	allowed := 0
+	for denied := 0; denied < len(instances); {
+		denied = 0
+		for _, manager := range instances {
+			_, err := manager.Consume(ctx, "user1", "api_calls", 1, goquota.PeriodTypeMonthly)
+			switch {
+			case err == nil:
+				allowed++
+			case errors.Is(err, goquota.ErrQuotaExceeded):
+				denied++
+			default:
+				t.Fatalf("Consume failed: %v", err)
+			}
+		}
+	}
+	if allowed != 100 {
+		t.Errorf("Expected exactly 100 consumptions allowed, got %d", allowed)
+	}
+}
+
+func TestManager_QuotaLease_ReturnsIdleLease(t *testing.T) {
+	ctx := context.Background()
+	storage := memory.New()
+	manager := newQuotaLeaseTestManager(t, storage, 1000, &goquota.QuotaLeaseConfig{
+		Resources:       []string{"api_calls"},
+		BlockPercentage: 10,
+		TTL:             20 * time.Millisecond,
+	})
+
+	if _, err := manager.Consume(ctx, "user1", "api_calls", 3, goquota.PeriodTypeMonthly); err != nil {
+		t.Fatalf("Consume failed: %v", err)
+	}
+	if used := storedUsage(t, manager); used != 100 {
+		t.Fatalf("Expected the leased block of 100 in storage, got %d", used)
+	}
+	waitFor(t, "idle lease returned", func() bool { return storedUsage(t, manager) == 3 })
+}
+
+// lostRefundStorage commits the first refunds but reports them as failed, like a refund
+// whose response was lost
+type lostRefundStorage struct {
+	*memory.Storage
+	lost    atomic.Int64
+	refunds atomic.Int64
+}
+
+func (s *lostRefundStorage) RefundQuota(ctx context.Context, req *goquota.RefundRequest) error {
+	s.refunds.Add(1)
+	if err := s.Storage.RefundQuota(ctx, req); err != nil {
+		return err
+	}
+	if s.lost.Add(-1) >= 0 {
+		return errors.New("refund timed out")
+	}
+	return nil
+}
+
+func TestManager_QuotaLease_RetriedReturnRefundsOnce(t *testing.T) {
+	ctx := context.Background()
+	storage := &lostRefundStorage{Storage: memory.New()}
+	storage.lost.Store(1)
+	manager := newQuotaLeaseTestManager(t, storage, 1000, &goquota.QuotaLeaseConfig{
+		Resources:       []string{"api_calls"},
+		BlockPercentage: 10,
+		TTL:             20 * time.Millisecond,
+	})
+
+	if _, err := manager.Consume(ctx, "user1", "api_calls", 3, goquota.PeriodTypeMonthly); err != nil {
+		t.Fatalf("Consume failed: %v", err)
+	}
+	waitFor(t, "lease return retried", func() bool { return storage.refunds.Load() >= 2 })
+
+	// The retry reuses the key of the committed refund
+	if used := storedUsage(t, manager); used != 3 {
+		t.Errorf("Expected the lease returned once, got usage %d", used)
+	}
+	if _, err := manager.Consume(ctx, "user1", "api_calls", 1, goquota.PeriodTypeMonthly); err != nil {
+		t.Fatalf("Consume failed: %v", err)
+	}
+	if err := manager.Close(ctx); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if used := storedUsage(t, manager); used != 4 {
+		t.Errorf("Expected usage 4 after Close, got %d", used)
+	}
+}
+
+func TestManager_QuotaLease_SetUsageDiscardsLease(t *testing.T) {
+	ctx := context.Background()
+	storage := memory.New()
+	manager := newQuotaLeaseTestManager(t, storage, 1000, &goquota.QuotaLeaseConfig{
+		Resources:       []string{"api_calls"},
+		BlockPercentage: 10,
+		TTL:             time.Hour,
+	})
+
+	if _, err := manager.Consume(ctx, "user1", "api_calls", 5, goquota.PeriodTypeMonthly); err != nil {
+		t.Fatalf("Consume failed: %v", err)
+	}
+	if err := manager.ResetUsage(ctx, "user1", "api_calls", goquota.PeriodTypeMonthly); err != nil {
+		t.Fatalf("ResetUsage failed: %v", err)
+	}
+
+	// The next consumption leases a new block, and Close has nothing to return
+	if _, err := manager.Consume(ctx, "user1", "api_calls", 1, goquota.PeriodTypeMonthly); err != nil {
+		t.Fatalf("Consume failed: %v", err)
+	}
+	if used := storedUsage(t, manager); used != 100 {
+		t.Errorf("Expected a new block of 100 after reset, got %d", used)
+	}
+	if err := manager.Close(ctx); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if used := storedUsage(t, manager); used != 1 {
+		t.Errorf("Expected usage 1 after Close, got %d", used)
+	}
+}
+
+func TestManager_QuotaLease_AcrossInstances(t *testing.T) {
+	ctx := context.Background()
+	storage := memory.New()
+	bus := goquota.NewMemoryInvalidationBus()
+	newInstance := func() *goquota.Manager {
+		return newEventsTestManager(t, storage, nil, func(c *goquota.Config) {
+			c.Tiers["free"] = goquota.TierConfig{Name: "free", MonthlyQuotas: map[string]int{"api_calls": 1000}}
+			c.QuotaLeaseConfig = &goquota.QuotaLeaseConfig{
+				Resources:       []string{"api_calls"},
+				BlockPercentage: 10,
+				TTL:             time.Hour,
+			}
+			c.CacheConfig = &goquota.CacheConfig{Enabled: true, InvalidationBus: bus}
+		})
+	}
+	podA, podB := newInstance(), newInstance()
+
+	// Read storage directly: the instances cache usage
+	period, err := podA.GetCurrentCycle(ctx, "user1")
+	if err != nil {
+		t.Fatalf("GetCurrentCycle failed: %v", err)
+	}
+	stored := func() int {
+		usage, err := storage.GetUsage(ctx, "user1", "api_calls", period)
+		if err != nil {
+			t.Fatalf("GetUsage failed: %v", err)
+		}
+		if usage == nil {
+			return 0
+		}
+		return usage.Used
+	}
+
+	// A reset on one instance discards the lease of the other
+	if _, err := podB.Consume(ctx, "user1", "api_calls", 5, goquota.PeriodTypeMonthly); err != nil {
+		t.Fatalf("Consume failed: %v", err)
+	}
+	if err := podA.ResetUsage(ctx, "user1", "api_calls", goquota.PeriodTypeMonthly); err != nil {
+		t.Fatalf("ResetUsage failed: %v", err)
+	}
+	if _, err := podB.Consume(ctx, "user1", "api_calls", 5, goquota.PeriodTypeMonthly); err != nil {
+		t.Fatalf("Consume failed: %v", err)
+	}
+	if used := stored(); used != 100 {
+		t.Fatalf("Expected a new block of 100 after reset, got %d", used)
+	}
+
+	// A refund on one instance makes the other return its lease
+	if err := podA.Refund(ctx, &goquota.RefundRequest{
+		UserID:     "user1",
+		Resource:   "api_calls",
+		Amount:     2,
+		PeriodType: goquota.PeriodTypeMonthly,
+	}); err != nil {
+		t.Fatalf("Refund failed: %v", err)
+	}
+	waitFor(t, "lease returned after refund", func() bool { return stored() == 3 })
+}
+
+func TestConfig_Validate_QuotaLeaseConfig(t *testing.T) {
+	config := &goquota.Config{
+		DefaultTier: "free",
+		Tiers:       map[string]goquota.TierConfig{"free": {Name: "free"}},
+		QuotaLeaseConfig: &goquota.QuotaLeaseConfig{
+			BlockPercentage: 150,
+			MinBlock:        10,
+			MaxBlock:        5,
+			TTL:             -time.Second,
+		},
+	}
+	err := config.Validate()
+	if err == nil {
+		t.Fatal("Expected validation error")
+	}
+	for _, want := range []string{
+		"resources must not be empty",
+		"blockPercentage must be between 0 and 100",
+		"maxBlock cannot be less than minBlock",
+		"ttl cannot be negative",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error to mention %q, got %v", want, err)
+		}
+	}
+}
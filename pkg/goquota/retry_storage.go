@@ -0,0 +1,344 @@
+package goquota
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"syscall"
+	"time"
+)
+
+const (
+	defaultRetryMaxAttempts    = 3
+	defaultRetryInitialBackoff = 50 * time.Millisecond
+	defaultRetryMaxBackoff     = time.Second
+
+	// autoIdempotencyKeyTTL is how long generated idempotency keys are kept.
+	// They only need to outlive the retries of a single call.
+	autoIdempotencyKeyTTL = 10 * time.Minute
+)
+
+// RetryStorage wraps a Storage implementation and retries operations that fail with
+// transient errors, using exponential backoff with jitter.
+//
+// Reads and writes that set absolute values are always retried. ConsumeQuota, RefundQuota,
+// AddLimit and SubtractLimit are only retried with an idempotency key (see
+// RetryConfig.AutoIdempotencyKeys). ApplyTierChange, CheckRateLimit and RecordRateLimitRequest
+// are never retried. Optional interfaces other than BatchStorage are not retried.
+type RetryStorage struct {
+	storage     Storage
+	config      RetryConfig
+	isRetryable func(err error) bool
+	metrics     Metrics
+}
+
+// NewRetryStorage creates a new storage wrapper that retries transient errors.
+// metrics may be nil.
+func NewRetryStorage(storage Storage, config RetryConfig, metrics Metrics) *RetryStorage {
+	isRetryable := config.IsRetryable
+	if isRetryable == nil {
+		if classifier, ok := storageAs[RetryClassifier](storage); ok {
+			isRetryable = classifier.IsRetryable
+		} else {
+			isRetryable = IsTransientError
+		}
+	}
+	if metrics == nil {
+		metrics = &NoopMetrics{}
+	}
+	return &RetryStorage{
+		storage:     storage,
+		config:      config,
+		isRetryable: isRetryable,
+		metrics:     metrics,
+	}
+}
+
+// IsTransientError reports whether err is a connection failure or timeout that may succeed
+// if retried. Context cancellation and business errors (e.g. ErrQuotaExceeded) are not transient.
+func IsTransientError(err error) bool {
+	if err == nil || isContextError(err) {
+		return false
+	}
+	if errors.Is(err, ErrStorageUnavailable) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.EPIPE) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// Unwrap returns the underlying storage.
+func (s *RetryStorage) Unwrap() Storage {
+	return s.storage
+}
+
+// policy returns the retry policy of an operation with defaults applied
+func (s *RetryStorage) policy(operation string) RetryPolicy {
+	policy, ok := s.config.Operations[operation]
+	if !ok {
+		policy = s.config.Default
+	}
+	if policy.MaxAttempts == 0 {
+		policy.MaxAttempts = defaultRetryMaxAttempts
+	}
+	if policy.InitialBackoff == 0 {
+		policy.InitialBackoff = defaultRetryInitialBackoff
+	}
+	if policy.MaxBackoff == 0 {
+		policy.MaxBackoff = defaultRetryMaxBackoff
+	}
+	if policy.MaxBackoff < policy.InitialBackoff {
+		policy.MaxBackoff = policy.InitialBackoff
+	}
+	return policy
+}
+
+// do calls fn until it succeeds, fails with a non-retryable error, runs out of attempts
+// or ctx is done. The last error is returned.
+func (s *RetryStorage) do(ctx context.Context, operation string, fn func() error) error {
+	policy := s.policy(operation)
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= policy.MaxAttempts || ctx.Err() != nil || !s.isRetryable(err) {
+			return err
+		}
+
+		s.metrics.RecordStorageRetry(operation)
+		timer := time.NewTimer(retryBackoff(policy, attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// retryBackoff returns the delay after the given attempt: doubling from InitialBackoff,
+// capped at MaxBackoff, with up to half of it randomized
+func retryBackoff(policy RetryPolicy, attempt int) time.Duration {
+	delay := policy.InitialBackoff
+	for i := 1; i < attempt && delay < policy.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > policy.MaxBackoff {
+		delay = policy.MaxBackoff
+	}
+	half := delay / 2
+	return half + time.Duration(rand.Int64N(int64(delay-half)+1))
+}
+
+// idempotencyKey returns the key to retry a non-idempotent call with, generating one if enabled.
+// Returns "" if the call must not be retried.
+func (s *RetryStorage) idempotencyKey(key string) string {
+	if key == "" && s.config.AutoIdempotencyKeys {
+		return "retry:" + newEventID()
+	}
+	return key
+}
+
+func (s *RetryStorage) GetEntitlement(ctx context.Context, userID string) (*Entitlement, error) {
+	var ent *Entitlement
+	err := s.do(ctx, "GetEntitlement", func() error {
+		var e error
+		ent, e = s.storage.GetEntitlement(ctx, userID)
+		return e
+	})
+	return ent, err
+}
+
+func (s *RetryStorage) SetEntitlement(ctx context.Context, ent *Entitlement) error {
+	return s.do(ctx, "SetEntitlement", func() error {
+		return s.storage.SetEntitlement(ctx, ent)
+	})
+}
+
+func (s *RetryStorage) GetUsage(ctx context.Context, userID, resource string, period Period) (*Usage, error) {
+	var usage *Usage
+	err := s.do(ctx, "GetUsage", func() error {
+		var e error
+		usage, e = s.storage.GetUsage(ctx, userID, resource, period)
+		return e
+	})
+	return usage, err
+}
+
+// doKeyed is do for a call retried with an idempotency key. If RetryStorage generated the key,
+// a retry failing with ErrIdempotencyKeyExists means an earlier attempt succeeded but its response
+// was lost: the caller passed no key, so it gets success instead. It reports whether that happened.
+func (s *RetryStorage) doKeyed(ctx context.Context, operation string, generated bool,
+	fn func() error) (bool, error) {
+	attempts := 0
+	processed := false
+	err := s.do(ctx, operation, func() error {
+		attempts++
+		err := fn()
+		if generated && attempts > 1 && errors.Is(err, ErrIdempotencyKeyExists) {
+			processed = true
+			return nil
+		}
+		return err
+	})
+	return processed, err
+}
+
+// ConsumeQuota retries only with an idempotency key, so that a consumption that succeeded
+// in storage but whose response was lost is not applied twice.
+func (s *RetryStorage) ConsumeQuota(ctx context.Context, req *ConsumeRequest) (int, error) {
+	key := s.idempotencyKey(req.IdempotencyKey)
+	if key == "" {
+		return s.storage.ConsumeQuota(ctx, req)
+	}
+	generated := key != req.IdempotencyKey
+	if generated {
+		reqCopy := *req
+		reqCopy.IdempotencyKey = key
+		reqCopy.IdempotencyKeyTTL = autoIdempotencyKeyTTL
+		req = &reqCopy
+	}
+
+	var used int
+	processed, err := s.doKeyed(ctx, "ConsumeQuota", generated, func() error {
+		var e error
+		used, e = s.storage.ConsumeQuota(ctx, req)
+		return e
+	})
+	if processed {
+		// The earlier attempt's usage is in its consumption record
+		record, err := s.GetConsumptionRecord(ctx, key)
+		if err != nil {
+			return 0, err
+		}
+		if record == nil {
+			return 0, fmt.Errorf("consumption record %s not found", key)
+		}
+		used = record.NewUsed
+	}
+	return used, err
+}
+
+// ApplyTierChange is never retried.
+func (s *RetryStorage) ApplyTierChange(ctx context.Context, req *TierChangeRequest) error {
+	return s.storage.ApplyTierChange(ctx, req)
+}
+
+func (s *RetryStorage) SetUsage(ctx context.Context, userID, resource string,
+	usage *Usage, period Period) error {
+	return s.do(ctx, "SetUsage", func() error {
+		return s.storage.SetUsage(ctx, userID, resource, usage, period)
+	})
+}
+
+// RefundQuota retries only with an idempotency key.
+func (s *RetryStorage) RefundQuota(ctx context.Context, req *RefundRequest) error {
+	key := s.idempotencyKey(req.IdempotencyKey)
+	if key == "" {
+		return s.storage.RefundQuota(ctx, req)
+	}
+	generated := key != req.IdempotencyKey
+	if generated {
+		reqCopy := *req
+		reqCopy.IdempotencyKey = key
+		req = &reqCopy
+	}
+
+	_, err := s.doKeyed(ctx, "RefundQuota", generated, func() error {
+		return s.storage.RefundQuota(ctx, req)
+	})
+	return err
+}
+
+func (s *RetryStorage) GetRefundRecord(ctx context.Context, idempotencyKey string) (*RefundRecord, error) {
+	var record *RefundRecord
+	err := s.do(ctx, "GetRefundRecord", func() error {
+		var e error
+		record, e = s.storage.GetRefundRecord(ctx, idempotencyKey)
+		return e
+	})
+	return record, err
+}
+
+func (s *RetryStorage) GetConsumptionRecord(ctx context.Context,
+	idempotencyKey string) (*ConsumptionRecord, error) {
+	var record *ConsumptionRecord
+	err := s.do(ctx, "GetConsumptionRecord", func() error {
+		var e error
+		record, e = s.storage.GetConsumptionRecord(ctx, idempotencyKey)
+		return e
+	})
+	return record, err
+}
+
+// CheckRateLimit is never retried, since a lost response may still have consumed a token.
+//
+//nolint:gocritic // Named return values would reduce readability here
+func (s *RetryStorage) CheckRateLimit(
+	ctx context.Context, req *RateLimitRequest,
+) (bool, int, time.Time, error) {
+	return s.storage.CheckRateLimit(ctx, req)
+}
+
+// RecordRateLimitRequest is never retried.
+func (s *RetryStorage) RecordRateLimitRequest(ctx context.Context, req *RateLimitRequest) error {
+	return s.storage.RecordRateLimitRequest(ctx, req)
+}
+
+// AddLimit retries only with an idempotency key. A retry that finds the caller's key already
+// processed returns ErrIdempotencyKeyExists; one that finds a generated key processed succeeds.
+func (s *RetryStorage) AddLimit(
+	ctx context.Context, userID, resource string, amount int, period Period, idempotencyKey string,
+) error {
+	key := s.idempotencyKey(idempotencyKey)
+	if key == "" {
+		return s.storage.AddLimit(ctx, userID, resource, amount, period, idempotencyKey)
+	}
+	_, err := s.doKeyed(ctx, "AddLimit", key != idempotencyKey, func() error {
+		return s.storage.AddLimit(ctx, userID, resource, amount, period, key)
+	})
+	return err
+}
+
+// SubtractLimit retries only with an idempotency key, like AddLimit.
+func (s *RetryStorage) SubtractLimit(
+	ctx context.Context, userID, resource string, amount int, period Period, idempotencyKey string,
+) error {
+	key := s.idempotencyKey(idempotencyKey)
+	if key == "" {
+		return s.storage.SubtractLimit(ctx, userID, resource, amount, period, idempotencyKey)
+	}
+	_, err := s.doKeyed(ctx, "SubtractLimit", key != idempotencyKey, func() error {
+		return s.storage.SubtractLimit(ctx, userID, resource, amount, period, key)
+	})
+	return err
+}
+
+// GetEntitlements implements BatchStorage.
+// Falls back to individual reads if the wrapped storage does not support batching.
+func (s *RetryStorage) GetEntitlements(ctx context.Context, userIDs []string) (map[string]*Entitlement, error) {
+	var ents map[string]*Entitlement
+	err := s.do(ctx, "GetEntitlements", func() error {
+		var e error
+		ents, e = getEntitlements(ctx, s.storage, userIDs)
+		return e
+	})
+	return ents, err
+}
+
+// GetUsages implements BatchStorage.
+// Falls back to individual reads if the wrapped storage does not support batching.
+func (s *RetryStorage) GetUsages(ctx context.Context, queries []UsageQuery) ([]*Usage, error) {
+	var usages []*Usage
+	err := s.do(ctx, "GetUsages", func() error {
+		var e error
+		usages, e = getUsages(ctx, s.storage, queries)
+		return e
+	})
+	return usages, err
+}
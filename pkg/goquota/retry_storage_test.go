@@ -0,0 +1,391 @@
+package goquota_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mihaimyh/goquota/pkg/goquota"
+	"github.com/mihaimyh/goquota/storage/memory"
+)
+
+// flakyStorage fails the first calls of GetUsage and ConsumeQuota with err.
+// Failed consumptions are applied before failing, like a response lost on the way back.
+type flakyStorage struct {
+	*memory.Storage
+	err               error
+	usageFailures     atomic.Int32
+	consumeFailures   atomic.Int32
+	usageCalls        atomic.Int32
+	consumeCalls      atomic.Int32
+	lastConsumeKey    atomic.Value
+	lastConsumeKeyTTL atomic.Int64
+}
+
+func (s *flakyStorage) GetUsage(ctx context.Context, userID, resource string,
+	period goquota.Period) (*goquota.Usage, error) {
+	s.usageCalls.Add(1)
+	if s.usageFailures.Add(-1) >= 0 {
+		return nil, s.err
+	}
+	return s.Storage.GetUsage(ctx, userID, resource, period)
+}
+
+func (s *flakyStorage) ConsumeQuota(ctx context.Context, req *goquota.ConsumeRequest) (int, error) {
+	s.consumeCalls.Add(1)
+	s.lastConsumeKey.Store(req.IdempotencyKey)
+	s.lastConsumeKeyTTL.Store(int64(req.IdempotencyKeyTTL))
+	used, err := s.Storage.ConsumeQuota(ctx, req)
+	if err == nil && s.consumeFailures.Add(-1) >= 0 {
+		return 0, s.err
+	}
+	return used, err
+}
+
+// lostAddLimitStorage applies the first AddLimit calls but reports them as timed out
+type lostAddLimitStorage struct {
+	*memory.Storage
+	failures atomic.Int32
+	calls    atomic.Int32
+}
+
+func (s *lostAddLimitStorage) AddLimit(ctx context.Context, userID, resource string, amount int,
+	period goquota.Period, idempotencyKey string) error {
+	s.calls.Add(1)
+	err := s.Storage.AddLimit(ctx, userID, resource, amount, period, idempotencyKey)
+	if err == nil && s.failures.Add(-1) >= 0 {
+		return goquota.ErrStorageUnavailable
+	}
+	return err
+}
+
+type retryMetrics struct {
+	*goquota.NoopMetrics
+	retries atomic.Int32
+}
+
+func (m *retryMetrics) RecordStorageRetry(_ string) { m.retries.Add(1) }
+
+var fastRetries = goquota.RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond}
+
+func monthlyPeriod() goquota.Period {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	return goquota.Period{Start: start, End: start.AddDate(0, 1, 0), Type: goquota.PeriodTypeMonthly}
+}
+
+func TestRetryStorage_RetriesTransientErrors(t *testing.T) {
+	flaky := &flakyStorage{Storage: memory.New(), err: goquota.ErrStorageUnavailable}
+	flaky.usageFailures.Store(2)
+	metrics := &retryMetrics{NoopMetrics: &goquota.NoopMetrics{}}
+	storage := goquota.NewRetryStorage(flaky, goquota.RetryConfig{Default: fastRetries}, metrics)
+
+	if _, err := storage.GetUsage(context.Background(), "user1", "api_calls", monthlyPeriod()); err != nil {
+		t.Fatalf("Expected GetUsage to succeed after retries, got %v", err)
+	}
+	if got := flaky.usageCalls.Load(); got != 3 {
+		t.Errorf("Expected 3 attempts, got %d", got)
+	}
+	if got := metrics.retries.Load(); got != 2 {
+		t.Errorf("Expected 2 recorded retries, got %d", got)
+	}
+}
+
+func TestRetryStorage_GivesUpAfterMaxAttempts(t *testing.T) {
+	flaky := &flakyStorage{Storage: memory.New(), err: goquota.ErrStorageUnavailable}
+	flaky.usageFailures.Store(5)
+	storage := goquota.NewRetryStorage(flaky, goquota.RetryConfig{Default: fastRetries}, nil)
+
+	_, err := storage.GetUsage(context.Background(), "user1", "api_calls", monthlyPeriod())
+	if !errors.Is(err, goquota.ErrStorageUnavailable) {
+		t.Fatalf("Expected ErrStorageUnavailable, got %v", err)
+	}
+	if got := flaky.usageCalls.Load(); got != 3 {
+		t.Errorf("Expected 3 attempts, got %d", got)
+	}
+}
+
+func TestRetryStorage_DoesNotRetryPermanentErrors(t *testing.T) {
+	flaky := &flakyStorage{Storage: memory.New(), err: fmt.Errorf("bad request: %w", goquota.ErrInvalidPeriod)}
+	flaky.usageFailures.Store(1)
+	storage := goquota.NewRetryStorage(flaky, goquota.RetryConfig{Default: fastRetries}, nil)
+
+	if _, err := storage.GetUsage(context.Background(), "user1", "api_calls", monthlyPeriod()); err == nil {
+		t.Fatal("Expected error")
+	}
+	if got := flaky.usageCalls.Load(); got != 1 {
+		t.Errorf("Expected 1 attempt, got %d", got)
+	}
+}
+
+func TestRetryStorage_PerOperationPolicy(t *testing.T) {
+	flaky := &flakyStorage{Storage: memory.New(), err: goquota.ErrStorageUnavailable}
+	flaky.usageFailures.Store(1)
+	storage := goquota.NewRetryStorage(flaky, goquota.RetryConfig{
+		Default:    fastRetries,
+		Operations: map[string]goquota.RetryPolicy{"GetUsage": {MaxAttempts: 1}},
+	}, nil)
+
+	if _, err := storage.GetUsage(context.Background(), "user1", "api_calls", monthlyPeriod()); err == nil {
+		t.Fatal("Expected GetUsage not to be retried")
+	}
+	if got := flaky.usageCalls.Load(); got != 1 {
+		t.Errorf("Expected 1 attempt, got %d", got)
+	}
+}
+
+func TestRetryStorage_CustomClassifier(t *testing.T) {
+	errFlaky := errors.New("flaky")
+	flaky := &flakyStorage{Storage: memory.New(), err: errFlaky}
+	flaky.usageFailures.Store(1)
+	storage := goquota.NewRetryStorage(flaky, goquota.RetryConfig{
+		Default:     fastRetries,
+		IsRetryable: func(err error) bool { return errors.Is(err, errFlaky) },
+	}, nil)
+
+	if _, err := storage.GetUsage(context.Background(), "user1", "api_calls", monthlyPeriod()); err != nil {
+		t.Fatalf("Expected GetUsage to succeed after retry, got %v", err)
+	}
+}
+
+func TestRetryStorage_StopsWhenContextDone(t *testing.T) {
+	flaky := &flakyStorage{Storage: memory.New(), err: goquota.ErrStorageUnavailable}
+	flaky.usageFailures.Store(10)
+	storage := goquota.NewRetryStorage(flaky, goquota.RetryConfig{
+		Default: goquota.RetryPolicy{MaxAttempts: 10, InitialBackoff: time.Hour, MaxBackoff: time.Hour},
+	}, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := storage.GetUsage(ctx, "user1", "api_calls", monthlyPeriod()); err == nil {
+		t.Fatal("Expected error")
+	}
+	if time.Since(start) > time.Second {
+		t.Error("Expected backoff to stop when the context is done")
+	}
+	if got := flaky.usageCalls.Load(); got != 1 {
+		t.Errorf("Expected 1 attempt, got %d", got)
+	}
+}
+
+func TestRetryStorage_ConsumeQuota(t *testing.T) {
+	req := func(key string) *goquota.ConsumeRequest {
+		return &goquota.ConsumeRequest{
+			UserID:         "user1",
+			Resource:       "api_calls",
+			Amount:         5,
+			Tier:           "free",
+			Period:         monthlyPeriod(),
+			Limit:          100,
+			IdempotencyKey: key,
+		}
+	}
+
+	t.Run("not retried without idempotency key", func(t *testing.T) {
+		flaky := &flakyStorage{Storage: memory.New(), err: goquota.ErrStorageUnavailable}
+		flaky.consumeFailures.Store(1)
+		storage := goquota.NewRetryStorage(flaky, goquota.RetryConfig{Default: fastRetries}, nil)
+
+		if _, err := storage.ConsumeQuota(context.Background(), req("")); err == nil {
+			t.Fatal("Expected error")
+		}
+		if got := flaky.consumeCalls.Load(); got != 1 {
+			t.Errorf("Expected 1 attempt, got %d", got)
+		}
+	})
+
+	t.Run("retried with caller idempotency key", func(t *testing.T) {
+		flaky := &flakyStorage{Storage: memory.New(), err: goquota.ErrStorageUnavailable}
+		flaky.consumeFailures.Store(1)
+		storage := goquota.NewRetryStorage(flaky, goquota.RetryConfig{Default: fastRetries}, nil)
+
+		used, err := storage.ConsumeQuota(context.Background(), req("key1"))
+		if err != nil {
+			t.Fatalf("ConsumeQuota failed: %v", err)
+		}
+		if used != 5 {
+			t.Errorf("Expected the lost consumption to be applied once, got used=%d", used)
+		}
+	})
+
+	t.Run("retried with generated idempotency key", func(t *testing.T) {
+		flaky := &flakyStorage{Storage: memory.New(), err: goquota.ErrStorageUnavailable}
+		flaky.consumeFailures.Store(1)
+		storage := goquota.NewRetryStorage(flaky, goquota.RetryConfig{
+			Default:             fastRetries,
+			AutoIdempotencyKeys: true,
+		}, nil)
+
+		request := req("")
+		used, err := storage.ConsumeQuota(context.Background(), request)
+		if err != nil {
+			t.Fatalf("ConsumeQuota failed: %v", err)
+		}
+		if used != 5 {
+			t.Errorf("Expected the lost consumption to be applied once, got used=%d", used)
+		}
+		if got := flaky.consumeCalls.Load(); got != 2 {
+			t.Errorf("Expected 2 attempts, got %d", got)
+		}
+		if key, _ := flaky.lastConsumeKey.Load().(string); key == "" {
+			t.Error("Expected a generated idempotency key")
+		}
+		if flaky.lastConsumeKeyTTL.Load() <= 0 {
+			t.Error("Expected generated keys to expire")
+		}
+		if request.IdempotencyKey != "" {
+			t.Error("Expected the caller's request not to be modified")
+		}
+	})
+
+	t.Run("generated key found processed", func(t *testing.T) {
+		flaky := &flakyStorage{Storage: memory.New(), err: goquota.ErrStorageUnavailable}
+		flaky.consumeFailures.Store(1)
+		storage := goquota.NewRetryStorage(&duplicateConsumeStorage{flakyStorage: flaky}, goquota.RetryConfig{
+			Default:             fastRetries,
+			AutoIdempotencyKeys: true,
+		}, nil)
+
+		used, err := storage.ConsumeQuota(context.Background(), req(""))
+		if err != nil {
+			t.Fatalf("Expected the lost consumption to succeed, got %v", err)
+		}
+		if used != 5 {
+			t.Errorf("Expected used from the consumption record, got %d", used)
+		}
+	})
+}
+
+// duplicateConsumeStorage rejects consumptions whose key was already processed, as some
+// adapters do for other keyed writes
+type duplicateConsumeStorage struct {
+	*flakyStorage
+}
+
+func (s *duplicateConsumeStorage) ConsumeQuota(ctx context.Context, req *goquota.ConsumeRequest) (int, error) {
+	if record, err := s.GetConsumptionRecord(ctx, req.IdempotencyKey); err == nil && record != nil {
+		return 0, goquota.ErrIdempotencyKeyExists
+	}
+	return s.flakyStorage.ConsumeQuota(ctx, req)
+}
+
+func TestRetryStorage_WithManager(t *testing.T) {
+	flaky := &flakyStorage{Storage: memory.New(), err: goquota.ErrStorageUnavailable}
+	flaky.consumeFailures.Store(1)
+	manager, err := goquota.NewManager(flaky, &goquota.Config{
+		DefaultTier: "free",
+		Tiers: map[string]goquota.TierConfig{
+			"free": {Name: "free", MonthlyQuotas: map[string]int{"api_calls": 100}},
+		},
+		RetryConfig: &goquota.RetryConfig{Default: fastRetries, AutoIdempotencyKeys: true},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+	ctx := context.Background()
+
+	used, err := manager.Consume(ctx, "user1", "api_calls", 5, goquota.PeriodTypeMonthly)
+	if err != nil {
+		t.Fatalf("Consume failed: %v", err)
+	}
+	if used != 5 {
+		t.Errorf("Expected used=5, got %d", used)
+	}
+
+	// Optional interfaces of the wrapped storage are still found
+	now := time.Now()
+	if _, err := manager.GetUsageHistory(ctx, "user1", "api_calls", goquota.PeriodTypeMonthly,
+		now.AddDate(0, -1, 0), now.AddDate(0, 1, 0)); err != nil {
+		t.Errorf("GetUsageHistory failed through RetryStorage: %v", err)
+	}
+}
+
+func TestRetryStorage_LostResponseWithGeneratedKey(t *testing.T) {
+	ctx := context.Background()
+	lost := &lostAddLimitStorage{Storage: memory.New()}
+	sink := &recordingSink{}
+	manager := newEventsTestManager(t, lost, &goquota.EventConfig{Sinks: []goquota.EventSink{sink}},
+		func(c *goquota.Config) {
+			c.RetryConfig = &goquota.RetryConfig{Default: fastRetries, AutoIdempotencyKeys: true}
+			c.CacheConfig = &goquota.CacheConfig{Enabled: true}
+			c.CacheTTL = time.Minute
+		})
+	defer manager.Close(ctx)
+
+	if err := manager.TopUpLimit(ctx, "user1", "credits", 50); err != nil {
+		t.Fatalf("TopUpLimit failed: %v", err)
+	}
+	if _, err := manager.GetQuota(ctx, "user1", "credits", goquota.PeriodTypeForever); err != nil {
+		t.Fatalf("GetQuota failed: %v", err)
+	}
+
+	// The first attempt succeeded but timed out: its retry finds the generated key processed,
+	// which is the caller's success, not a duplicate
+	lost.failures.Store(1)
+	if err := manager.TopUpLimit(ctx, "user1", "credits", 30); err != nil {
+		t.Fatalf("TopUpLimit failed: %v", err)
+	}
+	if got := lost.calls.Load(); got != 3 {
+		t.Errorf("Expected 3 AddLimit attempts, got %d", got)
+	}
+	usage, err := manager.GetQuota(ctx, "user1", "credits", goquota.PeriodTypeForever)
+	if err != nil {
+		t.Fatalf("GetQuota failed: %v", err)
+	}
+	if usage.Limit != 80 {
+		t.Errorf("Expected the top-up applied once and the cache invalidated, got limit %d", usage.Limit)
+	}
+	waitFor(t, "top-up events", func() bool {
+		return slices.Equal(sink.types(), []goquota.EventType{goquota.EventTopUp, goquota.EventTopUp})
+	})
+}
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestIsTransientError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"storage unavailable", fmt.Errorf("wrapped: %w", goquota.ErrStorageUnavailable), true},
+		{"network timeout", &net.OpError{Op: "read", Err: timeoutError{}}, true},
+		{"quota exceeded", goquota.ErrQuotaExceeded, false},
+		{"circuit open", goquota.ErrCircuitOpen, false},
+		{"context canceled", context.Canceled, false},
+		{"context deadline", fmt.Errorf("wrapped: %w", context.DeadlineExceeded), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := goquota.IsTransientError(tt.err); got != tt.want {
+				t.Errorf("IsTransientError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfig_Validate_RetryConfig(t *testing.T) {
+	config := &goquota.Config{
+		DefaultTier: "free",
+		Tiers:       map[string]goquota.TierConfig{"free": {Name: "free"}},
+		RetryConfig: &goquota.RetryConfig{
+			Default: goquota.RetryPolicy{MaxAttempts: -1},
+			Operations: map[string]goquota.RetryPolicy{
+				"GetUsage": {InitialBackoff: time.Second, MaxBackoff: time.Millisecond},
+			},
+		},
+	}
+	if err := config.Validate(); err == nil {
+		t.Fatal("Expected validation error")
+	}
+}
@@ -0,0 +1,419 @@
+package goquota
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	defaultStatementMaxCatchUpPeriods = 12
+
+	// statementQueueSize bounds the users waiting for their finished periods to be closed
+	statementQueueSize = 1000
+	// statementEvictInterval is how often checks of cycles that ended are evicted
+	statementEvictInterval = time.Hour
+)
+
+// statementCheck records that a user's finished periods were queued or closed for a cycle
+type statementCheck struct {
+	cycle  time.Time
+	end    time.Time
+	closed bool
+}
+
+// statementCloser closes, in the background, the finished periods of users queued on access
+type statementCloser struct {
+	queue  chan *Entitlement
+	closed bool
+	cancel context.CancelFunc
+	done   chan struct{}
+	once   sync.Once
+}
+
+func (m *Manager) startStatementCloser() {
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &statementCloser{
+		queue:  make(chan *Entitlement, statementQueueSize),
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	m.statementCloser = c
+
+	go func() {
+		defer close(c.done)
+		for ent := range c.queue {
+			if err := m.closeUserPeriods(ctx, ent, m.now(ctx)); err != nil && ctx.Err() == nil {
+				m.logger.Warn("failed to close finished periods",
+					Field{"userId", ent.UserID},
+					Field{"error", err},
+				)
+			}
+		}
+	}()
+}
+
+// close closes the queued users' periods, waiting until they are closed or ctx is done
+func (c *statementCloser) close(ctx context.Context, mu *sync.Mutex) error {
+	c.once.Do(func() {
+		mu.Lock()
+		c.closed = true
+		close(c.queue)
+		mu.Unlock()
+	})
+	select {
+	case <-c.done:
+		c.cancel()
+		return nil
+	case <-ctx.Done():
+		c.cancel()
+		return ctx.Err()
+	}
+}
+
+// statementSweeper periodically closes the finished periods of the configured users
+type statementSweeper struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+	once   sync.Once
+}
+
+func (m *Manager) startStatementSweeper() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &statementSweeper{cancel: cancel, done: make(chan struct{})}
+	m.statementSweeper = s
+
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(m.config.StatementConfig.SweepInterval)
+		defer ticker.Stop()
+		for {
+			m.sweepStatements(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (m *Manager) sweepStatements(ctx context.Context) {
+	userIDs, err := m.config.StatementConfig.Users.StatementUsers(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			m.logger.Error("failed to list users for statements", Field{"error", err})
+		}
+		return
+	}
+	if err := m.ClosePeriods(ctx, userIDs...); err != nil && ctx.Err() == nil {
+		m.logger.Error("failed to close finished periods", Field{"error", err})
+	}
+}
+
+func (s *statementSweeper) close(ctx context.Context) error {
+	s.once.Do(s.cancel)
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// ClosePeriods closes the finished billing periods of the given users, creating their statements
+// and emitting an EventPeriodReset for each newly closed period. Users without an entitlement
+// are skipped. Use it from a scheduled job, or configure StatementConfig.SweepInterval.
+// Returns an error if StatementConfig is not configured.
+func (m *Manager) ClosePeriods(ctx context.Context, userIDs ...string) error {
+	if m.statements == nil {
+		return fmt.Errorf("statementConfig is not configured")
+	}
+
+	var errs []error
+	for _, userID := range userIDs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		ent, err := m.storage.GetEntitlement(ctx, userID)
+		if err == ErrEntitlementNotFound {
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("user %s: %w", userID, err))
+			continue
+		}
+		if err := m.closeFinishedPeriods(ctx, ent, m.now(ctx)); err != nil {
+			errs = append(errs, fmt.Errorf("user %s: %w", userID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// GetStatements returns a user's usage statements, newest first.
+// Use WithHistoryLimit and WithHistoryOffset to paginate.
+// Returns an error if storage doesn't implement StatementStore.
+func (m *Manager) GetStatements(ctx context.Context, userID string, opts ...UsageHistoryOption) ([]*Statement, error) {
+	historyOpts := &UsageHistoryOptions{}
+	for _, opt := range opts {
+		opt(historyOpts)
+	}
+	if historyOpts.Limit < 0 || historyOpts.Offset < 0 {
+		return nil, fmt.Errorf("statement limit and offset cannot be negative")
+	}
+
+	store, ok := storageAs[StatementStore](m.storage)
+	if !ok {
+		return nil, fmt.Errorf("storage does not implement StatementStore")
+	}
+
+	start := time.Now()
+	statements, err := store.GetStatements(ctx, userID, historyOpts.Limit, historyOpts.Offset)
+	m.metrics.RecordStorageOperation("GetStatements", time.Since(start), err)
+	if err != nil {
+		m.logger.Error("failed to get statements",
+			Field{"userId", userID},
+			Field{"error", err},
+		)
+		return nil, err
+	}
+	return statements, nil
+}
+
+// closePeriodsOnAccess queues the user's finished periods to be closed on their first access
+// in a new cycle, so requests never wait for statements. Failures are logged and retried on
+// the next access; users are dropped while the queue is full.
+func (m *Manager) closePeriodsOnAccess(ent *Entitlement, now time.Time) {
+	if m.statementCloser == nil || ent == nil || ent.SubscriptionStartDate.IsZero() {
+		return
+	}
+	current, end := CurrentCycleForStart(ent.SubscriptionStartDate, now)
+
+	m.statementMu.Lock()
+	defer m.statementMu.Unlock()
+	m.evictStatementChecks(now)
+	if m.statementChecked[ent.UserID].cycle.Equal(current) || m.statementCloser.closed {
+		return
+	}
+	select {
+	case m.statementCloser.queue <- ent:
+		m.statementChecked[ent.UserID] = statementCheck{cycle: current, end: end}
+	default:
+		m.logger.Warn("statement queue full, closing finished periods on a later access",
+			Field{"userId", ent.UserID},
+		)
+	}
+}
+
+// evictStatementChecks removes, at most once per statementEvictInterval, the checks of cycles
+// that ended, so the map only holds users active in their current cycle.
+// The caller must hold statementMu.
+func (m *Manager) evictStatementChecks(now time.Time) {
+	if now.Before(m.statementEvictAt) {
+		return
+	}
+	m.statementEvictAt = now.Add(statementEvictInterval)
+	for userID, check := range m.statementChecked {
+		if !now.Before(check.end) {
+			delete(m.statementChecked, userID)
+		}
+	}
+}
+
+// closeFinishedPeriods creates statements for the user's periods that ended since the last
+// statement, unless this Manager instance already closed them in the current cycle
+func (m *Manager) closeFinishedPeriods(ctx context.Context, ent *Entitlement, now time.Time) error {
+	if ent.SubscriptionStartDate.IsZero() {
+		return nil
+	}
+	current, _ := CurrentCycleForStart(ent.SubscriptionStartDate, now)
+
+	m.statementMu.Lock()
+	check := m.statementChecked[ent.UserID]
+	m.statementMu.Unlock()
+	if check.closed && check.cycle.Equal(current) {
+		return nil
+	}
+	return m.closeUserPeriods(ctx, ent, now)
+}
+
+// closeUserPeriods creates statements for the user's periods that ended since the last
+// statement and records the check. On failure the check is removed so it is retried.
+func (m *Manager) closeUserPeriods(ctx context.Context, ent *Entitlement, now time.Time) error {
+	current, end := CurrentCycleForStart(ent.SubscriptionStartDate, now)
+
+	err := func() error {
+		periods, err := m.unclosedPeriods(ctx, ent, current)
+		if err != nil {
+			return err
+		}
+		for _, period := range periods {
+			if err := m.closePeriod(ctx, ent, period); err != nil {
+				return err
+			}
+		}
+		return nil
+	}()
+
+	m.statementMu.Lock()
+	defer m.statementMu.Unlock()
+	if err != nil {
+		delete(m.statementChecked, ent.UserID)
+		return err
+	}
+	m.statementChecked[ent.UserID] = statementCheck{cycle: current, end: end, closed: true}
+	return nil
+}
+
+// unclosedPeriods returns the monthly periods that ended after the user's last statement
+// and before current, oldest first and capped at MaxCatchUpPeriods
+func (m *Manager) unclosedPeriods(ctx context.Context, ent *Entitlement, current time.Time) ([]Period, error) {
+	start := time.Now()
+	latest, err := m.statements.GetStatements(ctx, ent.UserID, 1, 0)
+	m.metrics.RecordStorageOperation("GetStatements", time.Since(start), err)
+	if err != nil {
+		return nil, err
+	}
+
+	from := startOfDayUTC(ent.SubscriptionStartDate.UTC())
+	if len(latest) > 0 && latest[0].Period.End.After(from) {
+		from = latest[0].Period.End
+	}
+
+	var periods []Period
+	for t := from; ; {
+		cycleStart, cycleEnd := CurrentCycleForStart(ent.SubscriptionStartDate, t)
+		if cycleEnd.After(current) {
+			break
+		}
+		// Skip a cycle overlapping the last statement (the subscription start date changed)
+		if !cycleStart.Before(from) {
+			periods = append(periods, Period{Start: cycleStart, End: cycleEnd, Type: PeriodTypeMonthly})
+		}
+		t = cycleEnd
+	}
+
+	maxPeriods := m.config.StatementConfig.MaxCatchUpPeriods
+	if len(periods) > maxPeriods {
+		m.logger.Warn("skipping statements for old unclosed periods",
+			Field{"userId", ent.UserID},
+			Field{"skipped", len(periods) - maxPeriods},
+		)
+		periods = periods[len(periods)-maxPeriods:]
+	}
+	return periods, nil
+}
+
+// closePeriod records the final usage of a finished period and emits an EventPeriodReset
+// if this call created the statement. Lines and tier come from the usage stored for the period,
+// since the user may have changed tier since it ended.
+func (m *Manager) closePeriod(ctx context.Context, ent *Entitlement, period Period) error {
+	// The resources of every tier: the period may predate a tier change
+	resourceSet := make(map[string]bool)
+	for _, tierConfig := range m.config.Tiers {
+		for resource := range tierConfig.MonthlyQuotas {
+			resourceSet[resource] = true
+		}
+	}
+	resources := make([]string, 0, len(resourceSet))
+	for resource := range resourceSet {
+		resources = append(resources, resource)
+	}
+	sort.Strings(resources)
+
+	queries := make([]UsageQuery, len(resources))
+	for i, resource := range resources {
+		queries[i] = UsageQuery{UserID: ent.UserID, Resource: resource, Period: period}
+	}
+	start := time.Now()
+	usages, err := getUsages(ctx, m.storage, queries)
+	m.metrics.RecordStorageOperation("GetUsages", time.Since(start), err)
+	if err != nil {
+		return fmt.Errorf("failed to get usage: %w", err)
+	}
+
+	tier := periodTier(usages, ent.Tier)
+	tierConfig, ok := m.config.Tiers[tier]
+	if !ok {
+		tierConfig = m.config.Tiers[m.config.DefaultTier]
+	}
+	lines := make([]StatementLine, 0, len(resources))
+	for i, resource := range resources {
+		usage := usages[i]
+		if usage == nil {
+			// Unused during the period: listed if the period's tier has the resource
+			if _, ok := tierConfig.MonthlyQuotas[resource]; ok {
+				lines = append(lines, StatementLine{
+					Resource: resource,
+					Limit:    m.getLimitForResource(resource, tier, PeriodTypeMonthly),
+				})
+			}
+			continue
+		}
+		// The stored limit reflects prorated tier changes during the period
+		line := StatementLine{Resource: resource, Used: usage.Used, Limit: usage.Limit}
+		if line.Limit == 0 {
+			usageTier := usage.Tier
+			if usageTier == "" {
+				usageTier = tier
+			}
+			line.Limit = m.getLimitForResource(resource, usageTier, PeriodTypeMonthly)
+		}
+		lines = append(lines, line)
+	}
+
+	statement := &Statement{
+		UserID:   ent.UserID,
+		Tier:     tier,
+		Period:   period,
+		Lines:    lines,
+		ClosedAt: m.now(ctx),
+	}
+	start = time.Now()
+	created, err := m.statements.CreateStatement(ctx, statement)
+	m.metrics.RecordStorageOperation("CreateStatement", time.Since(start), err)
+	if err != nil {
+		return fmt.Errorf("failed to create statement: %w", err)
+	}
+	if !created {
+		return nil // Closed by another instance
+	}
+
+	m.logger.Info("closed billing period",
+		Field{"userId", ent.UserID},
+		Field{"periodStart", period.Start},
+		Field{"periodEnd", period.End},
+	)
+	if m.eventsEnabled() {
+		m.emit(ctx, &Event{
+			Type:       EventPeriodReset,
+			UserID:     ent.UserID,
+			PeriodType: PeriodTypeMonthly,
+			Tier:       tier,
+			Reason:     "period_closed",
+			Metadata: map[string]string{
+				"period_start": period.Start.Format(time.RFC3339),
+				"period_end":   period.End.Format(time.RFC3339),
+			},
+		})
+	}
+	return nil
+}
+
+// periodTier returns the tier of a period: the tier of its most recently updated usage,
+// or fallback if no usage records one
+func periodTier(usages []*Usage, fallback string) string {
+	var latest *Usage
+	for _, usage := range usages {
+		if usage != nil && usage.Tier != "" && (latest == nil || usage.UpdatedAt.After(latest.UpdatedAt)) {
+			latest = usage
+		}
+	}
+	if latest == nil {
+		return fallback
+	}
+	return latest.Tier
+}
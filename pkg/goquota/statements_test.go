@@ -0,0 +1,317 @@
+package goquota_test
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mihaimyh/goquota/pkg/goquota"
+	"github.com/mihaimyh/goquota/storage/memory"
+)
+
+// withStatements enables statements on a newEventsTestManager
+func withStatements(statementConfig *goquota.StatementConfig) func(*goquota.Config) {
+	return func(config *goquota.Config) {
+		config.StatementConfig = statementConfig
+	}
+}
+
+// sinkTo is an EventConfig publishing to sink
+func sinkTo(sink *recordingSink) *goquota.EventConfig {
+	return &goquota.EventConfig{Sinks: []goquota.EventSink{sink}}
+}
+
+// setupSubscription stores a pro entitlement that started the given number of days ago
+// and returns its first billing period
+func setupSubscription(t *testing.T, storage goquota.Storage, userID string, daysAgo int) goquota.Period {
+	t.Helper()
+	subStart := time.Now().UTC().AddDate(0, 0, -daysAgo)
+	if err := storage.SetEntitlement(context.Background(), &goquota.Entitlement{
+		UserID:                userID,
+		Tier:                  "pro",
+		SubscriptionStartDate: subStart,
+		UpdatedAt:             subStart,
+	}); err != nil {
+		t.Fatalf("SetEntitlement failed: %v", err)
+	}
+	start, end := goquota.CurrentCycleForStart(subStart, subStart)
+	return goquota.Period{Start: start, End: end, Type: goquota.PeriodTypeMonthly}
+}
+
+func (s *recordingSink) ofType(eventType goquota.EventType) []*goquota.Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var events []*goquota.Event
+	for _, event := range s.events {
+		if event.Type == eventType {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+func TestManager_Statements_ClosedOnFirstAccess(t *testing.T) {
+	storage := memory.New()
+	sink := &recordingSink{}
+	manager := newEventsTestManager(t, storage, sinkTo(sink), withStatements(&goquota.StatementConfig{}))
+	ctx := context.Background()
+
+	previous := setupSubscription(t, storage, "user1", 40)
+	if _, err := storage.ConsumeQuota(ctx, &goquota.ConsumeRequest{
+		UserID:   "user1",
+		Resource: "api_calls",
+		Amount:   42,
+		Tier:     "pro",
+		Period:   previous,
+		Limit:    100,
+	}); err != nil {
+		t.Fatalf("ConsumeQuota failed: %v", err)
+	}
+
+	usage, err := manager.GetQuota(ctx, "user1", "api_calls", goquota.PeriodTypeMonthly)
+	if err != nil {
+		t.Fatalf("GetQuota failed: %v", err)
+	}
+	if usage.Used != 0 {
+		t.Errorf("Expected a fresh period, got used=%d", usage.Used)
+	}
+
+	// The period is closed in the background
+	var statements []*goquota.Statement
+	waitFor(t, "statement", func() bool {
+		statements, err = manager.GetStatements(ctx, "user1")
+		return err == nil && len(statements) == 1
+	})
+	statement := statements[0]
+	if !statement.Period.Start.Equal(previous.Start) || !statement.Period.End.Equal(previous.End) {
+		t.Errorf("Expected statement for %v, got %v", previous, statement.Period)
+	}
+	if statement.Tier != "pro" || len(statement.Lines) != 1 {
+		t.Fatalf("Unexpected statement: %+v", statement)
+	}
+	if line := statement.Lines[0]; line.Resource != "api_calls" || line.Used != 42 || line.Limit != 100 {
+		t.Errorf("Expected api_calls 42/100, got %+v", line)
+	}
+
+	waitFor(t, "period reset event", func() bool { return len(sink.ofType(goquota.EventPeriodReset)) == 1 })
+	event := sink.ofType(goquota.EventPeriodReset)[0]
+	if event.UserID != "user1" || event.Reason != "period_closed" ||
+		event.Metadata["period_start"] != previous.Start.Format(time.RFC3339) {
+		t.Errorf("Unexpected period reset event: %+v", event)
+	}
+
+	// Further access and other instances don't close the period again
+	other := newEventsTestManager(t, storage, sinkTo(sink), withStatements(&goquota.StatementConfig{}))
+	if _, err := manager.Consume(ctx, "user1", "api_calls", 1, goquota.PeriodTypeMonthly); err != nil {
+		t.Fatalf("Consume failed: %v", err)
+	}
+	if _, err := other.Consume(ctx, "user1", "api_calls", 1, goquota.PeriodTypeMonthly); err != nil {
+		t.Fatalf("Consume failed: %v", err)
+	}
+	statements, err = other.GetStatements(ctx, "user1")
+	if err != nil {
+		t.Fatalf("GetStatements failed: %v", err)
+	}
+	if len(statements) != 1 {
+		t.Errorf("Expected 1 statement, got %d", len(statements))
+	}
+	time.Sleep(50 * time.Millisecond)
+	if got := len(sink.ofType(goquota.EventPeriodReset)); got != 1 {
+		t.Errorf("Expected 1 period reset event, got %d", got)
+	}
+}
+
+func TestManager_Statements_FirstCycleNotClosed(t *testing.T) {
+	storage := memory.New()
+	manager := newEventsTestManager(t, storage, nil, withStatements(&goquota.StatementConfig{}))
+	ctx := context.Background()
+
+	setupSubscription(t, storage, "user1", 3)
+	if _, err := manager.Consume(ctx, "user1", "api_calls", 1, goquota.PeriodTypeMonthly); err != nil {
+		t.Fatalf("Consume failed: %v", err)
+	}
+	// Close waits for the queued check
+	if err := manager.Close(ctx); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	statements, err := manager.GetStatements(ctx, "user1")
+	if err != nil {
+		t.Fatalf("GetStatements failed: %v", err)
+	}
+	if len(statements) != 0 {
+		t.Errorf("Expected no statements during the first cycle, got %d", len(statements))
+	}
+}
+
+func TestManager_Statements_CatchUp(t *testing.T) {
+	storage := memory.New()
+	manager := newEventsTestManager(t, storage, nil, withStatements(&goquota.StatementConfig{
+		MaxCatchUpPeriods: 2,
+	}))
+	ctx := context.Background()
+
+	// Three cycles ended while the user was inactive; only the last two are closed
+	first := setupSubscription(t, storage, "user1", 100)
+	if err := manager.ClosePeriods(ctx, "user1", "unknown"); err != nil {
+		t.Fatalf("ClosePeriods failed: %v", err)
+	}
+
+	statements, err := manager.GetStatements(ctx, "user1")
+	if err != nil {
+		t.Fatalf("GetStatements failed: %v", err)
+	}
+	if len(statements) != 2 {
+		t.Fatalf("Expected 2 statements, got %d", len(statements))
+	}
+	if !statements[0].Period.Start.After(statements[1].Period.Start) {
+		t.Errorf("Expected statements newest first")
+	}
+	if !statements[1].Period.Start.Equal(first.End) {
+		t.Errorf("Expected the oldest statement to start at %v, got %v", first.End, statements[1].Period.Start)
+	}
+	if statements[0].Period.End.After(time.Now()) {
+		t.Errorf("Expected only finished periods, got %v", statements[0].Period)
+	}
+
+	page, err := manager.GetStatements(ctx, "user1", goquota.WithHistoryLimit(1), goquota.WithHistoryOffset(1))
+	if err != nil {
+		t.Fatalf("GetStatements failed: %v", err)
+	}
+	if len(page) != 1 || !page[0].Period.Start.Equal(statements[1].Period.Start) {
+		t.Errorf("Expected the second statement, got %+v", page)
+	}
+}
+
+func TestManager_Statements_TierOfThePeriod(t *testing.T) {
+	storage := memory.New()
+	sink := &recordingSink{}
+	manager := newEventsTestManager(t, storage, sinkTo(sink), func(c *goquota.Config) {
+		c.StatementConfig = &goquota.StatementConfig{}
+		c.Tiers["pro"] = goquota.TierConfig{
+			Name:          "pro",
+			MonthlyQuotas: map[string]int{"api_calls": 100, "exports": 20},
+		}
+	})
+	ctx := context.Background()
+
+	// Two cycles on pro, with a prorated limit in the first one
+	first := setupSubscription(t, storage, "user1", 70)
+	secondStart, secondEnd := goquota.CurrentCycleForStart(first.Start, first.End)
+	second := goquota.Period{Start: secondStart, End: secondEnd, Type: goquota.PeriodTypeMonthly}
+	consume := func(period goquota.Period, resource string, amount, limit int) {
+		t.Helper()
+		if _, err := storage.ConsumeQuota(ctx, &goquota.ConsumeRequest{
+			UserID: "user1", Resource: resource, Amount: amount, Tier: "pro", Period: period, Limit: limit,
+		}); err != nil {
+			t.Fatalf("ConsumeQuota failed: %v", err)
+		}
+	}
+	consume(first, "api_calls", 42, 80)
+	consume(first, "exports", 3, 20)
+	consume(second, "api_calls", 7, 100)
+
+	// Downgraded to free, which has no exports, before the periods are closed
+	ent, err := storage.GetEntitlement(ctx, "user1")
+	if err != nil {
+		t.Fatalf("GetEntitlement failed: %v", err)
+	}
+	ent.Tier = "free"
+	if err := storage.SetEntitlement(ctx, ent); err != nil {
+		t.Fatalf("SetEntitlement failed: %v", err)
+	}
+	if err := manager.ClosePeriods(ctx, "user1", "downgrade"); err != nil {
+		t.Fatalf("ClosePeriods failed: %v", err)
+	}
+
+	statements, err := manager.GetStatements(ctx, "user1")
+	if err != nil {
+		t.Fatalf("GetStatements failed: %v", err)
+	}
+	if len(statements) != 2 {
+		t.Fatalf("Expected 2 statements, got %d", len(statements))
+	}
+	want := map[time.Time][]goquota.StatementLine{
+		first.Start:  {{Resource: "api_calls", Used: 42, Limit: 80}, {Resource: "exports", Used: 3, Limit: 20}},
+		second.Start: {{Resource: "api_calls", Used: 7, Limit: 100}, {Resource: "exports", Used: 0, Limit: 20}},
+	}
+	for _, statement := range statements {
+		if statement.Tier != "pro" {
+			t.Errorf("Expected the statement of %v on pro, got %s", statement.Period.Start, statement.Tier)
+		}
+		if !slices.Equal(statement.Lines, want[statement.Period.Start]) {
+			t.Errorf("Expected lines %+v for %v, got %+v",
+				want[statement.Period.Start], statement.Period.Start, statement.Lines)
+		}
+	}
+	waitFor(t, "period reset events", func() bool { return len(sink.ofType(goquota.EventPeriodReset)) == 2 })
+	for _, event := range sink.ofType(goquota.EventPeriodReset) {
+		if event.Tier != "pro" {
+			t.Errorf("Expected period reset events on pro, got %s", event.Tier)
+		}
+	}
+}
+
+type staticStatementUsers []string
+
+func (u staticStatementUsers) StatementUsers(context.Context) ([]string, error) {
+	return u, nil
+}
+
+func TestManager_Statements_Sweeper(t *testing.T) {
+	storage := memory.New()
+	setupSubscription(t, storage, "user1", 40)
+	sink := &recordingSink{}
+	manager := newEventsTestManager(t, storage, sinkTo(sink), withStatements(&goquota.StatementConfig{
+		SweepInterval: 10 * time.Millisecond,
+		Users:         staticStatementUsers{"user1"},
+	}))
+
+	waitFor(t, "statement", func() bool {
+		statements, err := manager.GetStatements(context.Background(), "user1")
+		return err == nil && len(statements) == 1
+	})
+	waitFor(t, "period reset event", func() bool { return len(sink.ofType(goquota.EventPeriodReset)) == 1 })
+}
+
+func TestManager_Statements_RequiresStatementStore(t *testing.T) {
+	config := &goquota.Config{
+		DefaultTier:     "free",
+		Tiers:           map[string]goquota.TierConfig{"free": {Name: "free"}},
+		StatementConfig: &goquota.StatementConfig{},
+	}
+	if _, err := goquota.NewManager(storageOnly{memory.New()}, config); err == nil ||
+		!strings.Contains(err.Error(), "StatementStore") {
+		t.Errorf("Expected StatementStore error, got %v", err)
+	}
+
+	manager := newEventsTestManager(t, storageOnly{memory.New()}, nil, nil)
+	if _, err := manager.GetStatements(context.Background(), "user1"); err == nil {
+		t.Error("Expected error from GetStatements without StatementStore")
+	}
+	if err := manager.ClosePeriods(context.Background(), "user1"); err == nil {
+		t.Error("Expected error from ClosePeriods without StatementConfig")
+	}
+}
+
+func TestConfig_Validate_StatementConfig(t *testing.T) {
+	config := &goquota.Config{
+		DefaultTier: "free",
+		Tiers:       map[string]goquota.TierConfig{"free": {Name: "free"}},
+		StatementConfig: &goquota.StatementConfig{
+			MaxCatchUpPeriods: -1,
+			SweepInterval:     time.Minute,
+		},
+	}
+	err := config.Validate()
+	if err == nil {
+		t.Fatal("Expected validation error")
+	}
+	for _, want := range []string{"maxCatchUpPeriods", "requires users"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error to mention %q, got %v", want, err)
+		}
+	}
+}
@@ -77,6 +77,357 @@ type TimeSource interface {
 	Now(ctx context.Context) (time.Time, error)
 }
 
+// ConcurrencyLimiter defines the interface for distributed concurrency leases.
+// Storage implementations can optionally implement this interface to support Manager.Acquire.
+type ConcurrencyLimiter interface {
+	// AcquireLease atomically registers a lease if the user holds fewer than MaxConcurrent
+	// unexpired leases for the resource. Expired leases are reclaimed before counting.
+	// Returns (acquired, active, error) where active is the number of leases held afterwards.
+	AcquireLease(ctx context.Context, req *LeaseRequest) (bool, int, error)
+
+	// RenewLease extends the expiration of an existing lease to req.Now + req.TTL.
+	// Returns false if the lease is unknown or has already expired.
+	RenewLease(ctx context.Context, req *LeaseRequest) (bool, error)
+
+	// ReleaseLease removes a lease. Releasing an unknown lease is not an error.
+	ReleaseLease(ctx context.Context, userID, resource, leaseID string) error
+}
+
+// SharedCacheStore defines the interface for the cache tier shared between instances (L2).
+// Storage implementations can optionally implement this interface to back a DistributedCache.
+type SharedCacheStore interface {
+	// GetCacheEntry returns the value of a cache entry and whether it exists
+	GetCacheEntry(ctx context.Context, key string) ([]byte, bool, error)
+
+	// SetCacheEntry stores a cache entry that expires after ttl
+	SetCacheEntry(ctx context.Context, key string, value []byte, ttl time.Duration) error
+
+	// DeleteCacheEntries removes cache entries. Deleting an unknown entry is not an error.
+	DeleteCacheEntries(ctx context.Context, keys ...string) error
+}
+
+// UsageDeltaStore defines the interface for applying pre-aggregated usage increments.
+// Storage implementations can optionally implement this interface to be the cold store of
+// a tiered storage that coalesces usage sync.
+type UsageDeltaStore interface {
+	// ApplyUsageDelta atomically adds req.Amount to the usage of a period, creating the record
+	// with req.Limit and req.Tier if it does not exist. Unlike ConsumeQuota the limit is not
+	// checked: the consumptions were already admitted elsewhere.
+	ApplyUsageDelta(ctx context.Context, req *UsageDeltaRequest) error
+}
+
+// SeedStore defines the interface for writing records only where none exist yet.
+// Storage implementations can optionally implement this interface so that the warm-up of
+// tiered storage never overwrites records written concurrently as the hot store.
+type SeedStore interface {
+	// SeedEntitlement stores ent unless the user already has an entitlement, and reports whether it did
+	SeedEntitlement(ctx context.Context, ent *Entitlement) (bool, error)
+
+	// SeedUsage stores usage unless the period already has a usage record, and reports whether it did
+	SeedUsage(ctx context.Context, userID, resource string, usage *Usage, period Period) (bool, error)
+}
+
+// RecordScanner defines the interface for enumerating all entitlements and usage records.
+// Storage implementations can optionally implement this interface to support the drift
+// reconciler of tiered storage.
+//
+// Scans are paginated with an opaque cursor: "" starts a scan and a returned "" ends it.
+// Pages may be shorter than limit, even empty, before the end. Records written during a
+// scan may be missed or returned twice.
+type RecordScanner interface {
+	// ScanEntitlements returns a page of at most limit entitlements and the cursor of the next page
+	ScanEntitlements(ctx context.Context, cursor string, limit int) ([]*Entitlement, string, error)
+
+	// ScanUsage returns a page of at most limit usage records and the cursor of the next page
+	ScanUsage(ctx context.Context, cursor string, limit int) ([]*Usage, string, error)
+}
+
+// CircuitBreakerStore defines the interface for circuit breaker state shared between instances.
+// Storage implementations can optionally implement this interface to back a DistributedCircuitBreaker.
+//
+// A breaker opens for ResetTimeout once FailureThreshold consecutive failures are recorded
+// within a FailureWindow. It is half-open once ResetTimeout has passed: up to MaxProbes callers may
+// then acquire a probe; a probe success closes it and a probe failure reopens it.
+type CircuitBreakerStore interface {
+	// GetCircuitBreakerStatus returns the shared status of a breaker (closed if unknown).
+	GetCircuitBreakerStatus(ctx context.Context, name string) (*CircuitBreakerStatus, error)
+
+	// RecordCircuitBreakerFailure atomically records a failure at req.Now, opening the breaker
+	// if the threshold is reached or if it is half-open. Returns the status afterwards.
+	RecordCircuitBreakerFailure(ctx context.Context, req *CircuitBreakerRequest) (*CircuitBreakerStatus, error)
+
+	// RecordCircuitBreakerSuccess closes the breaker if it is half-open at req.Now, and resets
+	// the failures of a closed breaker. Returns the status afterwards.
+	RecordCircuitBreakerSuccess(ctx context.Context, req *CircuitBreakerRequest) (*CircuitBreakerStatus, error)
+
+	// AcquireCircuitBreakerProbe reserves one of req.MaxProbes probe slots of a half-open breaker.
+	// Slots are released when the probe's outcome is recorded or after req.ResetTimeout.
+	// Returns true if the breaker is closed or a slot was reserved.
+	AcquireCircuitBreakerProbe(ctx context.Context, req *CircuitBreakerRequest) (bool, error)
+}
+
+// CircuitBreakerRequest represents an operation on a shared circuit breaker
+type CircuitBreakerRequest struct {
+	Name             string
+	Now              time.Time
+	FailureThreshold int
+	FailureWindow    time.Duration
+	ResetTimeout     time.Duration
+	MaxProbes        int
+}
+
+// CircuitBreakerStatus is the shared status of a circuit breaker
+type CircuitBreakerStatus struct {
+	// Open is true while the breaker is open or half-open
+	Open bool
+	// OpenUntil is when an open breaker becomes half-open
+	OpenUntil time.Time
+	// Failures is the number of failures in the current window of a closed breaker
+	Failures int
+}
+
+// StateAt returns the state of the breaker at the given time
+func (s *CircuitBreakerStatus) StateAt(now time.Time) CircuitBreakerState {
+	switch {
+	case !s.Open:
+		return StateClosed
+	case now.Before(s.OpenUntil):
+		return StateOpen
+	default:
+		return StateHalfOpen
+	}
+}
+
+// UsageSnapshotStore defines the interface for time-bucketed usage history.
+// Storage implementations can optionally implement this interface to improve Manager.Forecast.
+type UsageSnapshotStore interface {
+	// RecordUsageSnapshot stores a snapshot for its bucket. If a snapshot already exists
+	// for the bucket, the one with the higher Used value is kept.
+	RecordUsageSnapshot(ctx context.Context, snapshot *UsageSnapshot) error
+
+	// GetUsageSnapshots returns all snapshots recorded for a period ordered by Bucket ascending.
+	GetUsageSnapshots(ctx context.Context, userID, resource string, period Period) ([]*UsageSnapshot, error)
+}
+
+// UsageSnapshot records the usage of a resource observed during a time bucket.
+type UsageSnapshot struct {
+	UserID     string
+	Resource   string
+	Period     Period
+	Bucket     time.Time // Start of the time bucket
+	Used       int       // Usage observed at RecordedAt
+	RecordedAt time.Time
+}
+
+// UsageHistoryStorage defines the interface for querying usage of past periods.
+// Storage implementations can optionally implement this interface to support Manager.GetUsageHistory.
+type UsageHistoryStorage interface {
+	// GetUsageHistory returns usage records whose period starts within [From, To),
+	// ordered by period start descending (newest first).
+	GetUsageHistory(ctx context.Context, query *UsageHistoryQuery) ([]*Usage, error)
+}
+
+// UsageHistoryQuery represents a usage history query
+type UsageHistoryQuery struct {
+	UserID     string
+	Resource   string
+	PeriodType PeriodType
+	From       time.Time
+	To         time.Time
+	Limit      int // Maximum number of records to return (0 = no limit)
+	Offset     int // Number of records to skip
+}
+
+// BatchStorage defines the interface for reading many entitlements and usage records at once.
+// Storage implementations can optionally implement this interface to speed up Manager.GetQuotaMany.
+type BatchStorage interface {
+	// GetEntitlements returns the entitlements of the given users keyed by user ID.
+	// Users without an entitlement are omitted from the result.
+	GetEntitlements(ctx context.Context, userIDs []string) (map[string]*Entitlement, error)
+
+	// GetUsages returns the usage for each query in the same order as the queries.
+	// Entries are nil for queries with no usage yet.
+	GetUsages(ctx context.Context, queries []UsageQuery) ([]*Usage, error)
+}
+
+// UsageQuery identifies the usage of a resource in a specific period
+type UsageQuery struct {
+	UserID   string
+	Resource string
+	Period   Period
+}
+
+// WebhookStore defines the interface for persisting outbound webhook deliveries.
+// Storage implementations can optionally implement this interface to support WebhookConfig.
+type WebhookStore interface {
+	// SaveWebhookDelivery inserts a delivery or replaces the delivery with the same ID.
+	SaveWebhookDelivery(ctx context.Context, delivery *WebhookDelivery) error
+
+	// ClaimWebhookDeliveries returns up to limit pending deliveries with NextAttemptAt <= now,
+	// oldest first, and atomically postpones their NextAttemptAt to now + lease so that
+	// other instances don't deliver them at the same time.
+	ClaimWebhookDeliveries(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]*WebhookDelivery, error)
+
+	// GetWebhookDelivery retrieves a delivery by ID.
+	// Returns nil if no delivery found (not an error)
+	GetWebhookDelivery(ctx context.Context, id string) (*WebhookDelivery, error)
+
+	// ListWebhookDeliveries returns deliveries with the given status ordered by CreatedAt descending.
+	// A limit of 0 returns all deliveries.
+	ListWebhookDeliveries(ctx context.Context, status WebhookDeliveryStatus, limit, offset int) ([]*WebhookDelivery, error)
+
+	// DeleteWebhookDelivery removes a delivery. Deleting an unknown delivery is not an error.
+	DeleteWebhookDelivery(ctx context.Context, id string) error
+}
+
+// WebhookDeliveryStatus is the state of a webhook delivery
+type WebhookDeliveryStatus string
+
+const (
+	// WebhookDeliveryPending deliveries are waiting for their next attempt
+	WebhookDeliveryPending WebhookDeliveryStatus = "pending"
+	// WebhookDeliveryDead deliveries ran out of attempts and are kept for inspection and replay
+	WebhookDeliveryDead WebhookDeliveryStatus = "dead"
+)
+
+// WebhookDelivery is an event queued for delivery to a webhook endpoint.
+// Delivered webhooks are deleted; deliveries that exhaust their attempts are kept as dead letters.
+type WebhookDelivery struct {
+	ID            string
+	EventID       string
+	EventType     EventType
+	UserID        string
+	URL           string
+	Payload       []byte // JSON-encoded Event, signed on every attempt
+	Status        WebhookDeliveryStatus
+	Attempts      int
+	NextAttemptAt time.Time
+	LastError     string
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// WarningStateStore defines the interface for recording which warning thresholds were notified.
+// Storage implementations can optionally implement this interface so that each threshold fires
+// once per period across all Manager instances.
+type WarningStateStore interface {
+	// GetNotifiedWarnings returns the thresholds already notified for a resource in a period.
+	GetNotifiedWarnings(ctx context.Context, userID, resource string, period Period) ([]float64, error)
+
+	// MarkWarningNotified atomically records a threshold as notified.
+	// Returns false if it was already marked, in which case the warning must not fire again.
+	MarkWarningNotified(ctx context.Context, userID, resource string, period Period, threshold float64) (bool, error)
+
+	// ClearNotifiedWarnings re-arms thresholds so they fire again when reached.
+	// Clearing thresholds that aren't marked is not an error.
+	ClearNotifiedWarnings(ctx context.Context, userID, resource string, period Period, thresholds []float64) error
+}
+
+// RetryClassifier classifies storage errors for RetryStorage.
+// Storage implementations can optionally implement this interface to mark their
+// transient errors (e.g. timeouts, failovers, serialization failures) as retryable.
+type RetryClassifier interface {
+	// IsRetryable reports whether an operation that failed with err may succeed if retried
+	IsRetryable(err error) bool
+}
+
+// StatementStore defines the interface for persisting end-of-cycle usage statements.
+// Storage implementations can optionally implement this interface to support StatementConfig.
+type StatementStore interface {
+	// CreateStatement stores a statement unless one already exists for the same user and period.
+	// Returns false if it already existed; statements are never modified once created.
+	CreateStatement(ctx context.Context, statement *Statement) (bool, error)
+
+	// GetStatements returns a user's statements ordered by period start descending (newest first).
+	// A limit of 0 returns all statements.
+	GetStatements(ctx context.Context, userID string, limit, offset int) ([]*Statement, error)
+}
+
+// OverrideStore defines the interface for manual overrides shared between instances.
+// Storage implementations can optionally implement this interface to support OverrideConfig.Shared.
+type OverrideStore interface {
+	// SaveOverride inserts an override or replaces the override with the same resource and tier.
+	SaveOverride(ctx context.Context, override *Override) error
+
+	// DeleteOverride removes the override with the given resource and tier.
+	// Returns false if no override had that scope.
+	DeleteOverride(ctx context.Context, resource, tier string) (bool, error)
+
+	// ListOverrides returns every stored override, including expired ones.
+	ListOverrides(ctx context.Context) ([]*Override, error)
+}
+
+// Statement is the immutable record of a user's usage in a closed billing period
+type Statement struct {
+	UserID   string
+	Tier     string
+	Period   Period
+	Lines    []StatementLine // One per resource with a monthly quota, ordered by resource
+	ClosedAt time.Time
+}
+
+// StatementLine is the final usage of a resource in a statement
+type StatementLine struct {
+	Resource string
+	Used     int
+	Limit    int // -1 for unlimited
+}
+
+// getEntitlements reads entitlements in one batch if the storage supports it,
+// otherwise one at a time. Users without an entitlement are omitted.
+func getEntitlements(ctx context.Context, storage Storage, userIDs []string) (map[string]*Entitlement, error) {
+	if batch, ok := storageAs[BatchStorage](storage); ok {
+		return batch.GetEntitlements(ctx, userIDs)
+	}
+	result := make(map[string]*Entitlement, len(userIDs))
+	for _, userID := range userIDs {
+		ent, err := storage.GetEntitlement(ctx, userID)
+		if err == ErrEntitlementNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if ent != nil {
+			result[userID] = ent
+		}
+	}
+	return result, nil
+}
+
+// getUsages reads usage in one batch if the storage supports it, otherwise one at a time
+func getUsages(ctx context.Context, storage Storage, queries []UsageQuery) ([]*Usage, error) {
+	if batch, ok := storageAs[BatchStorage](storage); ok {
+		return batch.GetUsages(ctx, queries)
+	}
+	result := make([]*Usage, len(queries))
+	for i, q := range queries {
+		usage, err := storage.GetUsage(ctx, q.UserID, q.Resource, q.Period)
+		if err != nil {
+			return nil, err
+		}
+		result[i] = usage
+	}
+	return result, nil
+}
+
+// storageAs walks the chain of storage wrappers (e.g. CircuitBreakerStorage)
+// and returns the first storage implementing T.
+func storageAs[T any](storage Storage) (T, bool) {
+	for storage != nil {
+		if impl, ok := storage.(T); ok {
+			return impl, true
+		}
+		wrapper, ok := storage.(interface{ Unwrap() Storage })
+		if !ok {
+			break
+		}
+		storage = wrapper.Unwrap()
+	}
+	var zero T
+	return zero, false
+}
+
 // AuditLogEntry represents a single audit log entry for quota operations.
 type AuditLogEntry struct {
 	// ID is a unique identifier for this audit log entry
@@ -152,6 +503,16 @@ type ConsumeRequest struct {
 	IdempotencyKeyTTL time.Duration // TTL for idempotency key expiration
 }
 
+// UsageDeltaRequest represents an increment of usage aggregated from several consumptions
+type UsageDeltaRequest struct {
+	UserID   string
+	Resource string
+	Amount   int
+	Tier     string
+	Period   Period
+	Limit    int
+}
+
 // TierChangeRequest represents a tier change with proration
 // TierChangeRequest represents a tier change with proration
 type TierChangeRequest struct {
@@ -175,3 +536,13 @@ type RateLimitRequest struct {
 	Burst     int // for token bucket
 	Now       time.Time
 }
+
+// LeaseRequest represents a concurrency lease acquisition or renewal request
+type LeaseRequest struct {
+	UserID        string
+	Resource      string
+	LeaseID       string
+	MaxConcurrent int
+	TTL           time.Duration
+	Now           time.Time
+}
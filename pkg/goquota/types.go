@@ -3,6 +3,10 @@ package goquota
 import (
 	"context"
 	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
 	"strings"
 	"time"
 )
@@ -81,6 +85,15 @@ type TierConfig struct {
 	// that should trigger warnings.
 	WarningThresholds map[string][]float64
 
+	// BalanceWarnings maps resource names to absolute remaining balances (e.g., [100, 10])
+	// that trigger a warning once fewer units remain. Unlike WarningThresholds, these stay
+	// meaningful for PeriodTypeForever credits, whose limit grows with every top-up.
+	BalanceWarnings map[string][]int
+
+	// RateLimitWarnings maps resource names to warnings on the remaining rate limit capacity.
+	// The resource must have a RateLimits entry.
+	RateLimitWarnings map[string][]RateLimitWarning
+
 	// RateLimits maps resource names to rate limit configurations
 	// Rate limits enforce time-based request frequency (e.g., 10 requests/second)
 	// while quotas enforce total usage limits (e.g., 1000 requests/month)
@@ -96,6 +109,21 @@ type TierConfig struct {
 	// (if no forever credits exist yet). This is NOT a recurring quota - forever credits are
 	// dynamic (purchased via top-ups). Only applied once per user using deterministic idempotency key.
 	InitialForeverCredits map[string]int
+
+	// ConcurrencyLimits maps resource names to concurrency limit configurations
+	// Concurrency limits cap how many operations may run at the same time
+	// (e.g., max 3 concurrent renders), independently of quotas and rate limits
+	ConcurrencyLimits map[string]ConcurrencyLimitConfig
+}
+
+// ConcurrencyLimitConfig defines concurrency limiting configuration for a resource
+type ConcurrencyLimitConfig struct {
+	// MaxConcurrent is the maximum number of leases a user may hold at once (-1 for unlimited)
+	MaxConcurrent int
+
+	// LeaseTTL is how long a lease stays valid without being renewed (default: 30 seconds)
+	// Leases held by crashed processes are reclaimed once their TTL elapses
+	LeaseTTL time.Duration
 }
 
 // RateLimitConfig defines rate limiting configuration for a resource
@@ -115,6 +143,18 @@ type RateLimitConfig struct {
 	Burst int
 }
 
+// RateLimitWarning triggers a warning when the remaining rate limit capacity stays low
+type RateLimitWarning struct {
+	// Threshold is the fraction of capacity remaining (Burst for token bucket, Rate otherwise)
+	// below which the warning fires, in (0, 1]. For example 0.1 for "less than 10% of burst left".
+	Threshold float64
+
+	// For is how long remaining capacity must stay below Threshold before the warning fires
+	// (default: 0, fire on the first request below it). Capacity is sampled on each request;
+	// a user with no request for the longer of For and the rate limit Window starts over.
+	For time.Duration
+}
+
 // RateLimitInfo contains information about a rate limit check result
 type RateLimitInfo struct {
 	// Remaining is the number of requests remaining in the current window
@@ -143,6 +183,24 @@ type CacheConfig struct {
 
 	// MaxUsage is the maximum number of usage records to cache (default: 10000)
 	MaxUsage int
+
+	// Shards is the number of independently locked cache shards per entry type
+	// (default: one shard per 128 entries, up to 64). See LRUCacheConfig.
+	Shards int
+
+	// CleanupInterval is how often expired entries are removed in the background
+	// (default: 0, expired entries are only removed when they are read or evicted).
+	// With a CleanupInterval, call Manager.Close to stop the background cleanup.
+	CleanupInterval time.Duration
+
+	// SharedStore is a cache tier shared by all instances (optional), e.g. Redis storage.
+	// Entries missing from the local cache are read from it before storage.
+	SharedStore SharedCacheStore
+
+	// InvalidationBus broadcasts invalidations to all instances (optional), e.g. Redis pub/sub.
+	// Without it, other instances serve stale entitlements and usage until their TTL expires.
+	// With SharedStore or InvalidationBus, the Manager uses a DistributedCache.
+	InvalidationBus InvalidationBus
 }
 
 // CircuitBreakerConfig holds circuit breaker configuration
@@ -155,6 +213,86 @@ type CircuitBreakerConfig struct {
 
 	// ResetTimeout is the duration to wait before transitioning from Open to Half-Open (default: 30 seconds)
 	ResetTimeout time.Duration
+
+	// Store shares the breaker state between instances (optional), e.g. Redis storage.
+	// Failures of all instances then count towards FailureThreshold within FailureWindow,
+	// and all instances open and recover together. See DistributedCircuitBreaker.
+	Store CircuitBreakerStore
+
+	// Name identifies the shared breaker in Store (default: "storage")
+	Name string
+
+	// FailureWindow is the window shared failures are counted in (default: ResetTimeout)
+	FailureWindow time.Duration
+
+	// MaxProbes is the number of instances that may probe a half-open shared breaker at once (default: 1)
+	MaxProbes int
+
+	// SyncInterval is how often an instance refreshes the shared state (default: 1 second)
+	SyncInterval time.Duration
+
+	// FailureRateThreshold opens the circuit when at least this percentage (0-100) of the calls
+	// in SlidingWindow fail. Setting it or SlowCallRateThreshold uses a SlidingWindowCircuitBreaker
+	// instead of counting consecutive failures, and FailureThreshold is ignored.
+	FailureRateThreshold float64
+
+	// SlowCallRateThreshold opens the circuit when at least this percentage (0-100) of the calls
+	// in SlidingWindow take SlowCallDuration or longer (0 disables)
+	SlowCallRateThreshold float64
+
+	// SlowCallDuration is the duration from which a call counts as slow (default: 1 second)
+	SlowCallDuration time.Duration
+
+	// SlidingWindow is the window failure and slow-call rates are computed over (default: 10 seconds)
+	SlidingWindow time.Duration
+
+	// MinimumRequests is the number of calls in SlidingWindow before rates are evaluated (default: 10)
+	MinimumRequests int
+
+	// HalfOpenTrials is the number of trial calls let through while half-open. The circuit
+	// closes once they all succeed (default: 1)
+	HalfOpenTrials int
+
+	// Groups gives operations their own breakers, keyed by group name with storage operations
+	// (Storage method names, "LogAuditEntry" or "GetAuditLogs") as values, e.g.
+	// {"audit": {"LogAuditEntry", "GetAuditLogs"}}. Operations in no group share one breaker,
+	// so failures of one group don't open the circuit for the others. With Store, each group
+	// is shared under Name + ":" + group name.
+	Groups map[string][]string
+}
+
+// RetryConfig configures retries of transient storage errors (see RetryStorage)
+type RetryConfig struct {
+	// Default is the policy for operations without an entry in Operations
+	Default RetryPolicy
+
+	// Operations overrides the policy per storage operation, keyed by Storage method name
+	// (e.g. "GetUsage", "ConsumeQuota")
+	Operations map[string]RetryPolicy
+
+	// IsRetryable classifies errors as transient (optional). Defaults to the storage's
+	// RetryClassifier if it implements one, otherwise IsTransientError.
+	IsRetryable func(err error) bool
+
+	// AutoIdempotencyKeys generates an idempotency key for ConsumeQuota, RefundQuota, AddLimit
+	// and SubtractLimit calls without one, so that they can be retried safely. A retry that finds
+	// a generated key already processed succeeds instead of returning ErrIdempotencyKeyExists.
+	// Without it, these calls are only retried if the caller provided an idempotency key.
+	AutoIdempotencyKeys bool
+}
+
+// RetryPolicy configures how an operation is retried
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first (default: 3).
+	// Set to 1 to disable retries.
+	MaxAttempts int
+
+	// InitialBackoff is the delay before the first retry (default: 50 milliseconds).
+	// Each retry doubles the delay, with jitter, up to MaxBackoff.
+	InitialBackoff time.Duration
+
+	// MaxBackoff caps the delay between retries (default: 1 second)
+	MaxBackoff time.Duration
 }
 
 // FallbackConfig holds fallback strategy configuration
@@ -176,12 +314,212 @@ type FallbackConfig struct {
 	// If nil, secondary storage fallback is disabled
 	SecondaryStorage Storage
 
-	// ManualOverrideMode enables manual override mode (for future use)
+	// ManualOverrideMode has no effect.
+	//
+	// Deprecated: use Config.OverrideConfig or Manager.SetOverride.
 	ManualOverrideMode bool
 
 	// MaxStaleness is the maximum age of cached data to use for fallback (default: 5 minutes)
 	// Cached data older than this will not be used for fallback
 	MaxStaleness time.Duration
+
+	// OptimisticJournal durably records optimistic consumptions, which are replayed to storage
+	// once it recovers (optional), e.g. NewFileOptimisticJournal. Without it, optimistic usage is
+	// never written back. Requires OptimisticAllowance.
+	OptimisticJournal OptimisticJournal
+
+	// ReplayInterval is how often journaled consumptions are replayed while the circuit is not open
+	// (default: 10 seconds)
+	ReplayInterval time.Duration
+
+	// OnReplayConflict is called for each journaled consumption that would exceed the limit
+	// when replayed (optional). The consumption is dropped from the journal.
+	OnReplayConflict func(ctx context.Context, entry *OptimisticConsumption)
+}
+
+// ForecastConfig holds usage forecasting configuration
+type ForecastConfig struct {
+	// Enabled determines if usage snapshots are recorded on consumption.
+	// Snapshots improve the accuracy of Manager.Forecast; storage must implement UsageSnapshotStore.
+	Enabled bool
+
+	// SnapshotInterval is the width of the time buckets snapshots are recorded in (default: 1 hour).
+	// At most one snapshot is written per user, resource and bucket by each Manager instance.
+	SnapshotInterval time.Duration
+
+	// WarnOnProjectedExhaustion enables forecast warnings when usage is projected to
+	// exceed the limit before the period resets. The configured WarningHandler (or the
+	// context handler) is notified if it implements ForecastWarningHandler.
+	WarnOnProjectedExhaustion bool
+
+	// MinSnapshots is the minimum number of snapshots required before a forecast warning is emitted (default: 3)
+	MinSnapshots int
+}
+
+// EventConfig configures lifecycle event delivery
+type EventConfig struct {
+	// Sinks receive every event emitted by the Manager. Each sink is delivered to
+	// asynchronously from its own buffered queue.
+	Sinks []EventSink
+
+	// BufferSize is the number of events queued per sink (default: 1000).
+	// Events are dropped (and counted by Metrics.RecordEventDropped) when a queue is full.
+	BufferSize int
+
+	// PublishTimeout bounds each EventSink.Publish call (default: 5 seconds)
+	PublishTimeout time.Duration
+}
+
+// WebhookConfig configures signed outbound webhooks.
+// Deliveries are persisted before they are sent, so storage must implement WebhookStore.
+type WebhookConfig struct {
+	// Endpoints receive events for every user
+	Endpoints []WebhookEndpoint
+
+	// EndpointResolver returns additional, per-user endpoints (optional),
+	// e.g. URLs configured by your customers
+	EndpointResolver WebhookEndpointResolver
+
+	// ResolverEvents are the event types the EndpointResolver is called for
+	// (default: EventQuotaWarning and EventQuotaExhausted). Resolved endpoints only
+	// receive these event types, whatever their Events filter.
+	ResolverEvents []EventType
+
+	// MaxAttempts is the number of delivery attempts before a delivery is dead-lettered (default: 8)
+	MaxAttempts int
+
+	// InitialBackoff is the delay before the first retry (default: 1 second).
+	// Each retry doubles the delay, with jitter, up to MaxBackoff.
+	InitialBackoff time.Duration
+
+	// MaxBackoff caps the delay between retries (default: 1 hour)
+	MaxBackoff time.Duration
+
+	// PollInterval is how often pending deliveries are polled from storage (default: 1 second)
+	PollInterval time.Duration
+
+	// Timeout bounds each HTTP request (default: 10 seconds)
+	Timeout time.Duration
+
+	// BatchSize is the maximum number of deliveries claimed per poll (default: 100)
+	BatchSize int
+
+	// Concurrency is the number of deliveries sent in parallel (default: 4)
+	Concurrency int
+
+	// HTTPClient sends the requests (default: http.Client with Timeout)
+	HTTPClient *http.Client
+}
+
+// WebhookEndpoint is a URL that receives signed webhook deliveries
+type WebhookEndpoint struct {
+	URL string
+
+	// Secret signs the payload with HMAC-SHA256 (see VerifyWebhookSignature).
+	// Secrets are not persisted; they are looked up by URL when a delivery is sent.
+	Secret string
+
+	// Events filters the event types delivered to this endpoint
+	// (default: EventQuotaWarning and EventQuotaExhausted)
+	Events []EventType
+}
+
+// WebhookEndpointResolver returns the webhook endpoints configured for a user
+type WebhookEndpointResolver interface {
+	WebhookEndpoints(ctx context.Context, userID string) ([]WebhookEndpoint, error)
+}
+
+// StatementConfig configures end-of-cycle usage statements.
+// Storage must implement StatementStore.
+//
+// When a user's monthly billing cycle rolls over, the finished cycle is closed in the background
+// after the user's first quota check or consumption in the new cycle: the final usage of each
+// resource with a monthly quota is recorded in an immutable Statement and an EventPeriodReset is emitted.
+type StatementConfig struct {
+	// MaxCatchUpPeriods is the maximum number of finished periods closed at once for a user,
+	// e.g. after months of inactivity (default: 12). Older unclosed periods are skipped.
+	MaxCatchUpPeriods int
+
+	// SweepInterval enables a background sweeper that closes finished periods of the users
+	// returned by Users, so statements are created for users that don't come back (optional)
+	SweepInterval time.Duration
+
+	// Users lists the users closed by the sweeper (required with SweepInterval)
+	Users StatementUserLister
+}
+
+// StatementUserLister returns the users whose finished periods the statement sweeper closes
+type StatementUserLister interface {
+	StatementUsers(ctx context.Context) ([]string, error)
+}
+
+// OverrideMode determines how quota consumption is decided while a manual override is active
+type OverrideMode string
+
+const (
+	// OverrideAllowAll allows every consumption without consulting storage
+	OverrideAllowAll OverrideMode = "allow_all"
+	// OverrideDenyAll denies every consumption with ErrQuotaExceeded
+	OverrideDenyAll OverrideMode = "deny_all"
+	// OverrideAllowJournaled allows every consumption without consulting storage and records it
+	// in the override journal, so usage can be reconciled after the incident
+	OverrideAllowJournaled OverrideMode = "journal"
+	// OverrideEnforceTiers enforces quotas only for the users of Override.EnforceTiers
+	// and allows every other consumption without consulting storage
+	OverrideEnforceTiers OverrideMode = "enforce_tiers"
+)
+
+// Override is an operator switch that takes over quota decisions, e.g. during a storage
+// incident or after deploying bad limits.
+//
+// An override is scoped globally, to a resource, to a tier, or to a resource and tier.
+// When several overrides apply, the most specific one wins: resource and tier, then
+// resource, then tier, then global.
+type Override struct {
+	// Mode determines how consumption is decided (required)
+	Mode OverrideMode
+
+	// Resource limits the override to a resource (empty for all resources)
+	Resource string
+
+	// Tier limits the override to the users of a tier (empty for all tiers)
+	Tier string
+
+	// EnforceTiers lists the tiers whose quotas are still enforced (required with OverrideEnforceTiers)
+	EnforceTiers []string
+
+	// Reason describes why the override was set, e.g. an incident reference
+	Reason string
+
+	// Actor is who set the override (default: "admin")
+	Actor string
+
+	// CreatedAt is when the override was set (set by Manager.SetOverride if zero)
+	CreatedAt time.Time
+
+	// ExpiresAt lifts the override automatically (zero for no expiry)
+	ExpiresAt time.Time
+}
+
+// OverrideConfig holds manual override configuration.
+// Overrides are held in memory by each Manager instance, so they keep working while storage
+// is unavailable. Unless Shared is set, each instance of a multi-instance deployment must be
+// switched.
+type OverrideConfig struct {
+	// Overrides are active when the Manager is created (optional, not allowed with Shared)
+	Overrides []Override
+
+	// Shared saves overrides set on any instance to storage, which must implement OverrideStore.
+	// Every instance loads them at startup and reloads them every RefreshInterval, and right
+	// away when CacheConfig.InvalidationBus broadcasts a change.
+	Shared bool
+
+	// RefreshInterval is how often shared overrides are reloaded (default: 5 seconds)
+	RefreshInterval time.Duration
+
+	// Journal records consumptions allowed by OverrideAllowJournaled
+	// (default: an in-memory journal holding up to 10000 entries)
+	Journal OverrideJournal
 }
 
 // FallbackStrategy defines the interface for fallback strategies
@@ -232,11 +570,35 @@ type Config struct {
 	// CircuitBreakerConfig configures the circuit breaker
 	CircuitBreakerConfig *CircuitBreakerConfig
 
+	// RetryConfig retries transient storage errors (optional).
+	// Retries happen inside the circuit breaker, so a retried operation counts as one failure.
+	RetryConfig *RetryConfig
+
 	// IdempotencyKeyTTL is the TTL for idempotency keys (default: 24 hours)
 	IdempotencyKeyTTL time.Duration
 
 	// FallbackConfig configures fallback strategies for degraded mode operation
 	FallbackConfig *FallbackConfig
+
+	// ForecastConfig configures usage snapshots and forecast warnings (optional)
+	ForecastConfig *ForecastConfig
+
+	// EventConfig configures lifecycle event sinks (optional)
+	EventConfig *EventConfig
+
+	// WebhookConfig configures signed outbound webhooks for warnings and exhaustion (optional)
+	WebhookConfig *WebhookConfig
+
+	// StatementConfig configures end-of-cycle usage statements and period reset events (optional)
+	StatementConfig *StatementConfig
+
+	// OverrideConfig configures manual overrides of quota decisions (optional).
+	// Overrides can be set at runtime with Manager.SetOverride without this config.
+	OverrideConfig *OverrideConfig
+
+	// QuotaLeaseConfig serves consumptions of the listed resources from blocks of quota leased
+	// from storage, avoiding a storage round-trip per Consume (optional)
+	QuotaLeaseConfig *QuotaLeaseConfig
 }
 
 // Validate validates the configuration and returns an error if invalid.
@@ -257,8 +619,15 @@ func (c *Config) Validate() error {
 	// Validate other config sections
 	errs = append(errs, c.validateCacheConfig()...)
 	errs = append(errs, c.validateCircuitBreakerConfig()...)
+	errs = append(errs, c.validateRetryConfig()...)
 	errs = append(errs, c.validateFallbackConfig()...)
 	errs = append(errs, c.validateIdempotencyTTL()...)
+	errs = append(errs, c.validateForecastConfig()...)
+	errs = append(errs, c.validateEventConfig()...)
+	errs = append(errs, c.validateWebhookConfig()...)
+	errs = append(errs, c.validateStatementConfig()...)
+	errs = append(errs, c.validateOverrideConfig()...)
+	errs = append(errs, c.validateQuotaLeaseConfig()...)
 
 	// Combine errors
 	if len(errs) > 0 {
@@ -310,6 +679,9 @@ func (c *Config) validateTierConfig(tierName string, tierConfig TierConfig) []er
 	// Validate consumption order
 	errs = append(errs, c.validateConsumptionOrder(tierName, tierConfig)...)
 
+	// Validate concurrency limits
+	errs = append(errs, c.validateConcurrencyLimits(tierName, tierConfig)...)
+
 	return errs
 }
 
@@ -344,7 +716,7 @@ func (c *Config) validateQuotas(tierName string, tierConfig TierConfig) []error
 	return errs
 }
 
-// validateWarningThresholds validates warning thresholds are in [0, 1] range
+// validateWarningThresholds validates percentage, balance and rate limit warning thresholds
 func (c *Config) validateWarningThresholds(tierName string, tierConfig TierConfig) []error {
 	var errs []error
 
@@ -358,6 +730,35 @@ func (c *Config) validateWarningThresholds(tierName string, tierConfig TierConfi
 		}
 	}
 
+	for resource, balances := range tierConfig.BalanceWarnings {
+		for i, balance := range balances {
+			if balance <= 0 {
+				errs = append(errs, fmt.Errorf(
+					"tier '%s' resource '%s' balance warning[%d] must be positive: %d",
+					tierName, resource, i, balance))
+			}
+		}
+	}
+
+	for resource, warnings := range tierConfig.RateLimitWarnings {
+		if _, ok := tierConfig.RateLimits[resource]; !ok {
+			errs = append(errs, fmt.Errorf(
+				"tier '%s' resource '%s' has rate limit warnings but no rate limit", tierName, resource))
+		}
+		for i, warning := range warnings {
+			if warning.Threshold <= 0 || warning.Threshold > 1 {
+				errs = append(errs, fmt.Errorf(
+					"tier '%s' resource '%s' rate limit warning[%d] threshold is out of range (0, 1]: %f",
+					tierName, resource, i, warning.Threshold))
+			}
+			if warning.For < 0 {
+				errs = append(errs, fmt.Errorf(
+					"tier '%s' resource '%s' rate limit warning[%d] duration cannot be negative",
+					tierName, resource, i))
+			}
+		}
+	}
+
 	return errs
 }
 
@@ -411,6 +812,26 @@ func (c *Config) validateConsumptionOrder(tierName string, tierConfig TierConfig
 	return errs
 }
 
+// validateConcurrencyLimits validates concurrency limit configurations
+func (c *Config) validateConcurrencyLimits(tierName string, tierConfig TierConfig) []error {
+	var errs []error
+
+	for resource, limit := range tierConfig.ConcurrencyLimits {
+		if limit.MaxConcurrent < -1 {
+			errs = append(errs, fmt.Errorf(
+				"tier '%s' resource '%s' has negative concurrency limit: %d (use -1 for unlimited)",
+				tierName, resource, limit.MaxConcurrent))
+		}
+		if limit.LeaseTTL < 0 {
+			errs = append(errs, fmt.Errorf(
+				"tier '%s' resource '%s' has negative lease TTL: %v",
+				tierName, resource, limit.LeaseTTL))
+		}
+	}
+
+	return errs
+}
+
 // validateCacheConfig validates cache configuration
 func (c *Config) validateCacheConfig() []error {
 	var errs []error
@@ -428,6 +849,12 @@ func (c *Config) validateCacheConfig() []error {
 		if c.CacheConfig.MaxUsage < 0 {
 			errs = append(errs, fmt.Errorf("cacheConfig.maxUsage cannot be negative"))
 		}
+		if c.CacheConfig.Shards < 0 {
+			errs = append(errs, fmt.Errorf("cacheConfig.shards cannot be negative"))
+		}
+		if c.CacheConfig.CleanupInterval < 0 {
+			errs = append(errs, fmt.Errorf("cacheConfig.cleanupInterval cannot be negative"))
+		}
 	}
 
 	return errs
@@ -446,6 +873,84 @@ func (c *Config) validateCircuitBreakerConfig() []error {
 			errs = append(errs,
 				fmt.Errorf("circuitBreakerConfig.resetTimeout cannot be negative"))
 		}
+		if c.CircuitBreakerConfig.FailureWindow < 0 {
+			errs = append(errs,
+				fmt.Errorf("circuitBreakerConfig.failureWindow cannot be negative"))
+		}
+		if c.CircuitBreakerConfig.MaxProbes < 0 {
+			errs = append(errs,
+				fmt.Errorf("circuitBreakerConfig.maxProbes cannot be negative"))
+		}
+		if c.CircuitBreakerConfig.SyncInterval < 0 {
+			errs = append(errs,
+				fmt.Errorf("circuitBreakerConfig.syncInterval cannot be negative"))
+		}
+		errs = append(errs, validateCircuitBreakerRates(c.CircuitBreakerConfig)...)
+		errs = append(errs, validateCircuitBreakerGroups(c.CircuitBreakerConfig.Groups)...)
+	}
+
+	return errs
+}
+
+// validateCircuitBreakerRates validates the sliding window settings of a circuit breaker
+func validateCircuitBreakerRates(config *CircuitBreakerConfig) []error {
+	var errs []error
+
+	if config.FailureRateThreshold < 0 || config.FailureRateThreshold > 100 {
+		errs = append(errs,
+			fmt.Errorf("circuitBreakerConfig.failureRateThreshold must be between 0 and 100"))
+	}
+	if config.SlowCallRateThreshold < 0 || config.SlowCallRateThreshold > 100 {
+		errs = append(errs,
+			fmt.Errorf("circuitBreakerConfig.slowCallRateThreshold must be between 0 and 100"))
+	}
+	if config.SlowCallDuration < 0 {
+		errs = append(errs,
+			fmt.Errorf("circuitBreakerConfig.slowCallDuration cannot be negative"))
+	}
+	if config.SlidingWindow < 0 {
+		errs = append(errs,
+			fmt.Errorf("circuitBreakerConfig.slidingWindow cannot be negative"))
+	}
+	if config.MinimumRequests < 0 {
+		errs = append(errs,
+			fmt.Errorf("circuitBreakerConfig.minimumRequests cannot be negative"))
+	}
+	if config.HalfOpenTrials < 0 {
+		errs = append(errs,
+			fmt.Errorf("circuitBreakerConfig.halfOpenTrials cannot be negative"))
+	}
+	if config.Store != nil && (config.FailureRateThreshold > 0 || config.SlowCallRateThreshold > 0) {
+		errs = append(errs,
+			fmt.Errorf("circuitBreakerConfig.store does not support failure or slow-call rate thresholds"))
+	}
+
+	return errs
+}
+
+// validateCircuitBreakerGroups validates that each operation belongs to at most one breaker group
+func validateCircuitBreakerGroups(groups map[string][]string) []error {
+	var errs []error
+
+	groupOf := make(map[string]string)
+	for _, group := range slices.Sorted(maps.Keys(groups)) {
+		if group == "" {
+			errs = append(errs, fmt.Errorf("circuitBreakerConfig.groups: group name cannot be empty"))
+		}
+		for _, operation := range groups[group] {
+			if !slices.Contains(circuitBreakerOperations, operation) {
+				errs = append(errs,
+					fmt.Errorf("circuitBreakerConfig.groups[%q]: unknown operation %q", group, operation))
+				continue
+			}
+			if other, ok := groupOf[operation]; ok {
+				errs = append(errs,
+					fmt.Errorf("circuitBreakerConfig.groups[%q]: operation %q is already in group %q",
+						group, operation, other))
+				continue
+			}
+			groupOf[operation] = group
+		}
 	}
 
 	return errs
@@ -466,6 +971,14 @@ func (c *Config) validateFallbackConfig() []error {
 			errs = append(errs,
 				fmt.Errorf("fallbackConfig.maxStaleness cannot be negative"))
 		}
+		if c.FallbackConfig.ReplayInterval < 0 {
+			errs = append(errs,
+				fmt.Errorf("fallbackConfig.replayInterval cannot be negative"))
+		}
+		if c.FallbackConfig.OptimisticJournal != nil && !c.FallbackConfig.OptimisticAllowance {
+			errs = append(errs,
+				fmt.Errorf("fallbackConfig.optimisticJournal requires optimisticAllowance"))
+		}
 	}
 
 	return errs
@@ -482,11 +995,263 @@ func (c *Config) validateIdempotencyTTL() []error {
 	return errs
 }
 
+// validateForecastConfig validates forecast configuration
+func (c *Config) validateForecastConfig() []error {
+	var errs []error
+
+	if c.ForecastConfig != nil {
+		if c.ForecastConfig.SnapshotInterval < 0 {
+			errs = append(errs, fmt.Errorf("forecastConfig.snapshotInterval cannot be negative"))
+		}
+		if c.ForecastConfig.MinSnapshots < 0 {
+			errs = append(errs, fmt.Errorf("forecastConfig.minSnapshots cannot be negative"))
+		}
+	}
+
+	return errs
+}
+
+// validateEventConfig validates event configuration
+func (c *Config) validateEventConfig() []error {
+	var errs []error
+
+	if c.EventConfig != nil {
+		if c.EventConfig.BufferSize < 0 {
+			errs = append(errs, fmt.Errorf("eventConfig.bufferSize cannot be negative"))
+		}
+		if c.EventConfig.PublishTimeout < 0 {
+			errs = append(errs, fmt.Errorf("eventConfig.publishTimeout cannot be negative"))
+		}
+		for i, sink := range c.EventConfig.Sinks {
+			if sink == nil {
+				errs = append(errs, fmt.Errorf("eventConfig.sinks[%d] cannot be nil", i))
+			}
+		}
+	}
+
+	return errs
+}
+
+// validateWebhookConfig validates webhook configuration
+func (c *Config) validateWebhookConfig() []error {
+	var errs []error
+
+	if c.WebhookConfig == nil {
+		return errs
+	}
+
+	wc := c.WebhookConfig
+	if wc.MaxAttempts < 0 {
+		errs = append(errs, fmt.Errorf("webhookConfig.maxAttempts cannot be negative"))
+	}
+	if wc.InitialBackoff < 0 || wc.MaxBackoff < 0 {
+		errs = append(errs, fmt.Errorf("webhookConfig backoff cannot be negative"))
+	}
+	if wc.InitialBackoff > 0 && wc.MaxBackoff > 0 && wc.InitialBackoff > wc.MaxBackoff {
+		errs = append(errs, fmt.Errorf("webhookConfig.initialBackoff (%v) cannot exceed maxBackoff (%v)",
+			wc.InitialBackoff, wc.MaxBackoff))
+	}
+	if wc.PollInterval < 0 || wc.Timeout < 0 {
+		errs = append(errs, fmt.Errorf("webhookConfig.pollInterval and timeout cannot be negative"))
+	}
+	if wc.BatchSize < 0 || wc.Concurrency < 0 {
+		errs = append(errs, fmt.Errorf("webhookConfig.batchSize and concurrency cannot be negative"))
+	}
+	if len(wc.Endpoints) == 0 && wc.EndpointResolver == nil {
+		errs = append(errs, fmt.Errorf("webhookConfig requires endpoints or an endpointResolver"))
+	}
+	for i, endpoint := range wc.Endpoints {
+		if err := validateWebhookEndpoint(endpoint); err != nil {
+			errs = append(errs, fmt.Errorf("webhookConfig.endpoints[%d]: %w", i, err))
+		}
+	}
+
+	return errs
+}
+
+// validateRetryConfig validates the retry configuration
+func (c *Config) validateRetryConfig() []error {
+	var errs []error
+
+	if c.RetryConfig == nil {
+		return errs
+	}
+
+	if err := validateRetryPolicy(c.RetryConfig.Default); err != nil {
+		errs = append(errs, fmt.Errorf("retryConfig.default: %w", err))
+	}
+	for operation, policy := range c.RetryConfig.Operations {
+		if err := validateRetryPolicy(policy); err != nil {
+			errs = append(errs, fmt.Errorf("retryConfig.operations[%s]: %w", operation, err))
+		}
+	}
+
+	return errs
+}
+
+// validateRetryPolicy checks that a retry policy has no negative values
+func validateRetryPolicy(policy RetryPolicy) error {
+	if policy.MaxAttempts < 0 {
+		return fmt.Errorf("maxAttempts cannot be negative")
+	}
+	if policy.InitialBackoff < 0 || policy.MaxBackoff < 0 {
+		return fmt.Errorf("backoff cannot be negative")
+	}
+	if policy.InitialBackoff > 0 && policy.MaxBackoff > 0 && policy.InitialBackoff > policy.MaxBackoff {
+		return fmt.Errorf("initialBackoff (%v) cannot exceed maxBackoff (%v)", policy.InitialBackoff, policy.MaxBackoff)
+	}
+	return nil
+}
+
+// validateStatementConfig validates the statement configuration
+func (c *Config) validateStatementConfig() []error {
+	var errs []error
+
+	if c.StatementConfig == nil {
+		return errs
+	}
+
+	sc := c.StatementConfig
+	if sc.MaxCatchUpPeriods < 0 {
+		errs = append(errs, fmt.Errorf("statementConfig.maxCatchUpPeriods cannot be negative"))
+	}
+	if sc.SweepInterval < 0 {
+		errs = append(errs, fmt.Errorf("statementConfig.sweepInterval cannot be negative"))
+	}
+	if sc.SweepInterval > 0 && sc.Users == nil {
+		errs = append(errs, fmt.Errorf("statementConfig.sweepInterval requires users"))
+	}
+
+	return errs
+}
+
+// validateOverrideConfig validates the override configuration and initial overrides
+func (c *Config) validateOverrideConfig() []error {
+	var errs []error
+
+	if c.OverrideConfig == nil {
+		return errs
+	}
+
+	if c.OverrideConfig.RefreshInterval < 0 {
+		errs = append(errs, fmt.Errorf("overrideConfig.refreshInterval must be non-negative"))
+	}
+	if c.OverrideConfig.Shared && len(c.OverrideConfig.Overrides) > 0 {
+		errs = append(errs, fmt.Errorf("overrideConfig.overrides are not allowed with shared overrides"))
+	}
+
+	seen := make(map[string]bool)
+	for i := range c.OverrideConfig.Overrides {
+		override := &c.OverrideConfig.Overrides[i]
+		if err := validateOverride(override); err != nil {
+			errs = append(errs, fmt.Errorf("overrideConfig.overrides[%d]: %w", i, err))
+		}
+		key := overrideKey(override.Resource, override.Tier)
+		if seen[key] {
+			errs = append(errs, fmt.Errorf("overrideConfig.overrides[%d]: duplicate scope", i))
+		}
+		seen[key] = true
+	}
+
+	return errs
+}
+
+// validateQuotaLeaseConfig validates quota lease configuration
+func (c *Config) validateQuotaLeaseConfig() []error {
+	var errs []error
+
+	lc := c.QuotaLeaseConfig
+	if lc == nil {
+		return errs
+	}
+	if len(lc.Resources) == 0 {
+		errs = append(errs, fmt.Errorf("quotaLeaseConfig.resources must not be empty"))
+	}
+	if lc.BlockPercentage < 0 || lc.BlockPercentage > 100 {
+		errs = append(errs, fmt.Errorf("quotaLeaseConfig.blockPercentage must be between 0 and 100"))
+	}
+	if lc.MinBlock < 0 {
+		errs = append(errs, fmt.Errorf("quotaLeaseConfig.minBlock cannot be negative"))
+	}
+	if lc.MaxBlock < 0 {
+		errs = append(errs, fmt.Errorf("quotaLeaseConfig.maxBlock cannot be negative"))
+	}
+	if lc.MaxBlock > 0 && lc.MaxBlock < lc.MinBlock {
+		errs = append(errs, fmt.Errorf("quotaLeaseConfig.maxBlock cannot be less than minBlock"))
+	}
+	if lc.TTL < 0 {
+		errs = append(errs, fmt.Errorf("quotaLeaseConfig.ttl cannot be negative"))
+	}
+
+	return errs
+}
+
+// validateWebhookEndpoint checks that an endpoint has an absolute http(s) URL and a secret
+func validateWebhookEndpoint(endpoint WebhookEndpoint) error {
+	u, err := url.Parse(endpoint.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("url %q must be an absolute http(s) URL", endpoint.URL)
+	}
+	if endpoint.Secret == "" {
+		return fmt.Errorf("secret is required")
+	}
+	return nil
+}
+
 // WarningHandler is the interface for handling quota warnings
 type WarningHandler interface {
 	OnWarning(ctx context.Context, usage *Usage, threshold float64)
 }
 
+// WarningKind identifies what a warning threshold measures
+type WarningKind string
+
+const (
+	// WarningKindPercentage warnings come from WarningThresholds (fraction of the limit used)
+	WarningKindPercentage WarningKind = "percentage"
+	// WarningKindBalance warnings come from BalanceWarnings (units remaining)
+	WarningKindBalance WarningKind = "balance"
+	// WarningKindRateLimit warnings come from RateLimitWarnings (fraction of rate limit capacity remaining)
+	WarningKindRateLimit WarningKind = "rate_limit"
+)
+
+// Warning describes a warning threshold that was reached
+type Warning struct {
+	Kind       WarningKind
+	UserID     string
+	Resource   string
+	Tier       string
+	PeriodType PeriodType // Empty for rate limit warnings
+
+	// Threshold is the configured threshold: a fraction used for WarningKindPercentage,
+	// units remaining for WarningKindBalance and a fraction remaining for WarningKindRateLimit
+	Threshold float64
+
+	Used      int
+	Limit     int
+	Remaining int
+
+	// Usage is the quota usage that triggered the warning (nil for rate limit warnings)
+	Usage *Usage
+
+	// Sustained is how long rate limit capacity stayed below the threshold
+	Sustained time.Duration
+}
+
+// DetailedWarningHandler can optionally be implemented by a WarningHandler to receive every
+// warning kind with a detailed payload. When implemented, OnWarningDetail is called instead of
+// OnWarning; handlers that only implement OnWarning receive WarningKindPercentage warnings.
+type DetailedWarningHandler interface {
+	OnWarningDetail(ctx context.Context, warning *Warning)
+}
+
+// ForecastWarningHandler can optionally be implemented by a WarningHandler to be notified
+// when usage is projected to exceed the limit before the period resets.
+// Requires ForecastConfig.WarnOnProjectedExhaustion.
+type ForecastWarningHandler interface {
+	OnForecastWarning(ctx context.Context, forecast *Forecast)
+}
+
 // ConsumeOption represents an option for the Consume operation
 type ConsumeOption func(*ConsumeOptions)
 
@@ -494,6 +1259,13 @@ type ConsumeOption func(*ConsumeOptions)
 type ConsumeOptions struct {
 	IdempotencyKey string
 	DryRun         bool // If true, log violation but don't block
+
+	// skipDeniedEvent suppresses EventConsumeDenied for the per-period attempts of PeriodTypeAuto
+	skipDeniedEvent bool
+
+	// skipOverride skips manual overrides for the per-period attempts of PeriodTypeAuto,
+	// which were already decided
+	skipOverride bool
 }
 
 // WithIdempotencyKey sets the idempotency key for a consume operation
@@ -512,6 +1284,20 @@ func WithDryRun(dryRun bool) ConsumeOption {
 	}
 }
 
+// withoutDeniedEvent suppresses EventConsumeDenied for a consume operation
+func withoutDeniedEvent() ConsumeOption {
+	return func(opts *ConsumeOptions) {
+		opts.skipDeniedEvent = true
+	}
+}
+
+// withoutOverride skips manual overrides for a consume operation
+func withoutOverride() ConsumeOption {
+	return func(opts *ConsumeOptions) {
+		opts.skipOverride = true
+	}
+}
+
 // RefundRequest represents a quota refund request
 type RefundRequest struct {
 	UserID            string
@@ -590,6 +1376,29 @@ func WithTopUpIdempotencyKey(key string) TopUpOption {
 	}
 }
 
+// UsageHistoryOption represents an option for the GetUsageHistory and GetStatements operations
+type UsageHistoryOption func(*UsageHistoryOptions)
+
+// UsageHistoryOptions holds options for the GetUsageHistory and GetStatements operations
+type UsageHistoryOptions struct {
+	Limit  int
+	Offset int
+}
+
+// WithHistoryLimit limits the number of periods returned by GetUsageHistory
+func WithHistoryLimit(limit int) UsageHistoryOption {
+	return func(opts *UsageHistoryOptions) {
+		opts.Limit = limit
+	}
+}
+
+// WithHistoryOffset skips the given number of periods in GetUsageHistory (for pagination)
+func WithHistoryOffset(offset int) UsageHistoryOption {
+	return func(opts *UsageHistoryOptions) {
+		opts.Offset = offset
+	}
+}
+
 // RefundCreditsOption represents an option for the RefundCredits operation
 type RefundCreditsOption func(*RefundCreditsOptions)
 
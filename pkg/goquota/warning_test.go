@@ -2,6 +2,8 @@ package goquota_test
 
 import (
 	"context"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -170,3 +172,346 @@ func TestManager_ContextWarningHandler(t *testing.T) {
 		t.Errorf("Context handler not called")
 	}
 }
+
+// withWarnings gives the free tier of a newEventsTestManager warnings at 50% and 80% of its
+// monthly api_calls, reported to handler
+func withWarnings(handler goquota.WarningHandler) func(*goquota.Config) {
+	return func(config *goquota.Config) {
+		config.Tiers["free"] = goquota.TierConfig{
+			Name:              "free",
+			MonthlyQuotas:     map[string]int{"api_calls": 100},
+			WarningThresholds: map[string][]float64{"api_calls": {0.5, 0.8}},
+		}
+		config.WarningHandler = handler
+	}
+}
+
+func TestManager_Warnings_FireOncePerPeriodAcrossInstances(t *testing.T) {
+	storage := memory.New()
+	handlerA := &mockWarningHandler{}
+	handlerB := &mockWarningHandler{}
+	managerA := newEventsTestManager(t, storage, nil, withWarnings(handlerA))
+	managerB := newEventsTestManager(t, storage, nil, withWarnings(handlerB))
+	ctx := context.Background()
+
+	// SetUsage jumps past both thresholds without firing; the next consumption fires them
+	if err := managerA.SetUsage(ctx, "user1", "api_calls", goquota.PeriodTypeMonthly, 85); err != nil {
+		t.Fatalf("SetUsage failed: %v", err)
+	}
+	if len(handlerA.warnings) != 0 {
+		t.Fatalf("Expected SetUsage not to fire warnings, got %d", len(handlerA.warnings))
+	}
+	if _, err := managerA.Consume(ctx, "user1", "api_calls", 1, goquota.PeriodTypeMonthly); err != nil {
+		t.Fatalf("Consume failed: %v", err)
+	}
+	if len(handlerA.warnings) != 2 {
+		t.Fatalf("Expected both thresholds to fire once, got %d", len(handlerA.warnings))
+	}
+
+	// Other instances see the persisted state and don't fire again
+	for i := 0; i < 3; i++ {
+		if _, err := managerB.Consume(ctx, "user1", "api_calls", 1, goquota.PeriodTypeMonthly); err != nil {
+			t.Fatalf("Consume failed: %v", err)
+		}
+	}
+	if len(handlerA.warnings) != 2 || len(handlerB.warnings) != 0 {
+		t.Errorf("Expected no further warnings, got %d and %d", len(handlerA.warnings), len(handlerB.warnings))
+	}
+}
+
+func TestManager_Warnings_RearmAfterRefund(t *testing.T) {
+	handler := &mockWarningHandler{}
+	manager := newEventsTestManager(t, memory.New(), nil, withWarnings(handler))
+	ctx := context.Background()
+
+	if _, err := manager.Consume(ctx, "user1", "api_calls", 85, goquota.PeriodTypeMonthly); err != nil {
+		t.Fatalf("Consume failed: %v", err)
+	}
+	if len(handler.warnings) != 2 {
+		t.Fatalf("Expected 2 warnings, got %d", len(handler.warnings))
+	}
+
+	// Dropping below 80% re-arms that threshold only
+	if err := manager.Refund(ctx, &goquota.RefundRequest{
+		UserID:     "user1",
+		Resource:   "api_calls",
+		Amount:     10,
+		PeriodType: goquota.PeriodTypeMonthly,
+	}); err != nil {
+		t.Fatalf("Refund failed: %v", err)
+	}
+
+	// A single consumption jumping back over 80% fires it again
+	if _, err := manager.Consume(ctx, "user1", "api_calls", 10, goquota.PeriodTypeMonthly); err != nil {
+		t.Fatalf("Consume failed: %v", err)
+	}
+	if len(handler.warnings) != 3 {
+		t.Fatalf("Expected re-armed threshold to fire, got %d warnings", len(handler.warnings))
+	}
+	if handler.warnings[2].threshold != 0.8 {
+		t.Errorf("Expected 0.8 threshold, got %f", handler.warnings[2].threshold)
+	}
+
+	// ResetUsage re-arms everything
+	if err := manager.ResetUsage(ctx, "user1", "api_calls", goquota.PeriodTypeMonthly); err != nil {
+		t.Fatalf("ResetUsage failed: %v", err)
+	}
+	if _, err := manager.Consume(ctx, "user1", "api_calls", 60, goquota.PeriodTypeMonthly); err != nil {
+		t.Fatalf("Consume failed: %v", err)
+	}
+	if len(handler.warnings) != 4 || handler.warnings[3].threshold != 0.5 {
+		t.Errorf("Expected 0.5 threshold to fire after reset, got %d warnings", len(handler.warnings))
+	}
+}
+
+// warningStateCounter counts warning state reads
+type warningStateCounter struct {
+	*memory.Storage
+	reads atomic.Int32
+}
+
+func (s *warningStateCounter) GetNotifiedWarnings(ctx context.Context, userID, resource string,
+	period goquota.Period) ([]float64, error) {
+	s.reads.Add(1)
+	return s.Storage.GetNotifiedWarnings(ctx, userID, resource, period)
+}
+
+func TestManager_Warnings_NoStateReadBelowThresholds(t *testing.T) {
+	storage := &warningStateCounter{Storage: memory.New()}
+	handler := &mockWarningHandler{}
+	manager := newEventsTestManager(t, storage, nil, withWarnings(handler))
+	ctx := context.Background()
+
+	for i := 0; i < 49; i++ {
+		if _, err := manager.Consume(ctx, "user1", "api_calls", 1, goquota.PeriodTypeMonthly); err != nil {
+			t.Fatalf("Consume failed: %v", err)
+		}
+	}
+	if reads := storage.reads.Load(); reads != 0 {
+		t.Errorf("Expected no warning state reads below the first threshold, got %d", reads)
+	}
+
+	if _, err := manager.Consume(ctx, "user1", "api_calls", 1, goquota.PeriodTypeMonthly); err != nil {
+		t.Fatalf("Consume failed: %v", err)
+	}
+	if storage.reads.Load() != 1 || len(handler.warnings) != 1 {
+		t.Errorf("Expected the 0.5 threshold to fire from the stored state, got %d reads and %d warnings",
+			storage.reads.Load(), len(handler.warnings))
+	}
+}
+
+func TestManager_Warnings_WithoutWarningState(t *testing.T) {
+	// Storage without WarningStateStore fires thresholds when a consumption crosses them
+	handler := &mockWarningHandler{}
+	manager := newEventsTestManager(t, storageOnly{memory.New()}, nil, withWarnings(handler))
+	ctx := context.Background()
+
+	if _, err := manager.Consume(ctx, "user1", "api_calls", 85, goquota.PeriodTypeMonthly); err != nil {
+		t.Fatalf("Consume failed: %v", err)
+	}
+	if _, err := manager.Consume(ctx, "user1", "api_calls", 1, goquota.PeriodTypeMonthly); err != nil {
+		t.Fatalf("Consume failed: %v", err)
+	}
+	if len(handler.warnings) != 2 {
+		t.Errorf("Expected 2 warnings, got %d", len(handler.warnings))
+	}
+}
+
+// detailedWarningHandler records every warning kind
+type detailedWarningHandler struct {
+	mu       sync.Mutex
+	warnings []*goquota.Warning
+}
+
+func (h *detailedWarningHandler) OnWarning(_ context.Context, _ *goquota.Usage, _ float64) {
+	panic("OnWarning must not be called when OnWarningDetail is implemented")
+}
+
+func (h *detailedWarningHandler) OnWarningDetail(_ context.Context, warning *goquota.Warning) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.warnings = append(h.warnings, warning)
+}
+
+func (h *detailedWarningHandler) kinds() []goquota.WarningKind {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	kinds := make([]goquota.WarningKind, len(h.warnings))
+	for i, warning := range h.warnings {
+		kinds[i] = warning.Kind
+	}
+	return kinds
+}
+
+func TestManager_BalanceWarnings_ForeverCredits(t *testing.T) {
+	detailed := &detailedWarningHandler{}
+	plain := &mockWarningHandler{}
+	config := goquota.Config{
+		DefaultTier: "free",
+		Tiers: map[string]goquota.TierConfig{
+			"free": {
+				Name:            "free",
+				BalanceWarnings: map[string][]int{"credits": {100, 10}},
+			},
+		},
+		WarningHandler: detailed,
+	}
+	manager, err := goquota.NewManager(memory.New(), &config)
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+	ctx := goquota.WithWarningHandler(context.Background(), plain)
+
+	if err := manager.TopUpLimit(ctx, "user1", "credits", 500); err != nil {
+		t.Fatalf("TopUpLimit failed: %v", err)
+	}
+	// 500 -> 420 remaining: no warning
+	if _, err := manager.Consume(ctx, "user1", "credits", 80, goquota.PeriodTypeForever); err != nil {
+		t.Fatalf("Consume failed: %v", err)
+	}
+	if len(detailed.kinds()) != 0 {
+		t.Fatalf("Expected no warnings, got %v", detailed.kinds())
+	}
+
+	// 420 -> 50 remaining: below 100
+	if _, err := manager.Consume(ctx, "user1", "credits", 370, goquota.PeriodTypeForever); err != nil {
+		t.Fatalf("Consume failed: %v", err)
+	}
+	if len(detailed.warnings) != 1 {
+		t.Fatalf("Expected 1 balance warning, got %d", len(detailed.warnings))
+	}
+	warning := detailed.warnings[0]
+	if warning.Kind != goquota.WarningKindBalance || warning.Threshold != 100 || warning.Remaining != 50 {
+		t.Errorf("Expected balance warning at 100 with 50 remaining, got %+v", warning)
+	}
+	if warning.PeriodType != goquota.PeriodTypeForever || warning.Usage == nil {
+		t.Errorf("Expected forever usage in warning, got %+v", warning)
+	}
+
+	// Handlers without OnWarningDetail only receive percentage warnings
+	if len(plain.warnings) != 0 {
+		t.Errorf("Expected plain handler not to receive balance warnings, got %d", len(plain.warnings))
+	}
+
+	// A top-up raises the balance above 100 and re-arms the warning
+	if err := manager.TopUpLimit(ctx, "user1", "credits", 200); err != nil {
+		t.Fatalf("TopUpLimit failed: %v", err)
+	}
+	if _, err := manager.Consume(ctx, "user1", "credits", 160, goquota.PeriodTypeForever); err != nil {
+		t.Fatalf("Consume failed: %v", err)
+	}
+	if len(detailed.warnings) != 2 || detailed.warnings[1].Threshold != 100 {
+		t.Fatalf("Expected re-armed balance warning, got %d warnings", len(detailed.warnings))
+	}
+}
+
+func TestManager_DetailedWarningHandler_Percentage(t *testing.T) {
+	detailed := &detailedWarningHandler{}
+	manager := newEventsTestManager(t, memory.New(), nil, withWarnings(detailed))
+
+	if _, err := manager.Consume(context.Background(), "user1", "api_calls", 60,
+		goquota.PeriodTypeMonthly); err != nil {
+		t.Fatalf("Consume failed: %v", err)
+	}
+	if len(detailed.warnings) != 1 {
+		t.Fatalf("Expected 1 warning, got %d", len(detailed.warnings))
+	}
+	warning := detailed.warnings[0]
+	if warning.Kind != goquota.WarningKindPercentage || warning.Threshold != 0.5 ||
+		warning.Used != 60 || warning.Limit != 100 || warning.Remaining != 40 {
+		t.Errorf("Unexpected warning payload: %+v", warning)
+	}
+}
+
+func TestManager_RateLimitWarnings(t *testing.T) {
+	detailed := &detailedWarningHandler{}
+	config := goquota.Config{
+		DefaultTier: "free",
+		Tiers: map[string]goquota.TierConfig{
+			"free": {
+				Name:          "free",
+				MonthlyQuotas: map[string]int{"api_calls": 1000},
+				RateLimits: map[string]goquota.RateLimitConfig{
+					"api_calls": {Algorithm: "token_bucket", Rate: 1, Window: time.Hour, Burst: 10},
+				},
+				RateLimitWarnings: map[string][]goquota.RateLimitWarning{
+					"api_calls": {{Threshold: 0.5, For: 50 * time.Millisecond}},
+				},
+			},
+		},
+		WarningHandler: detailed,
+	}
+	storage := &clockStorage{Storage: memory.New(), now: forecastPeriodStart}
+	manager, err := goquota.NewManager(storage, &config)
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+	ctx := context.Background()
+
+	// Drain the burst below 50%; the warning waits until capacity stays low for 50ms
+	for i := 0; i < 7; i++ {
+		if _, err := manager.Consume(ctx, "user1", "api_calls", 1, goquota.PeriodTypeMonthly); err != nil {
+			t.Fatalf("Consume %d failed: %v", i, err)
+		}
+	}
+	if len(detailed.kinds()) != 0 {
+		t.Fatalf("Expected no warning before the duration elapsed, got %v", detailed.kinds())
+	}
+
+	storage.set(forecastPeriodStart.Add(60 * time.Millisecond))
+	for i := 0; i < 2; i++ {
+		if _, err := manager.Consume(ctx, "user1", "api_calls", 1, goquota.PeriodTypeMonthly); err != nil {
+			t.Fatalf("Consume failed: %v", err)
+		}
+	}
+	if len(detailed.warnings) != 1 {
+		t.Fatalf("Expected a single rate limit warning, got %d", len(detailed.warnings))
+	}
+	warning := detailed.warnings[0]
+	if warning.Kind != goquota.WarningKindRateLimit || warning.Threshold != 0.5 || warning.Limit != 10 {
+		t.Errorf("Unexpected warning payload: %+v", warning)
+	}
+	if warning.Sustained != 60*time.Millisecond {
+		t.Errorf("Expected sustained duration of 60ms on the Manager's clock, got %v", warning.Sustained)
+	}
+}
+
+func TestConfig_Validate_BalanceAndRateLimitWarnings(t *testing.T) {
+	tests := []struct {
+		name string
+		tier goquota.TierConfig
+	}{
+		{
+			name: "non-positive balance",
+			tier: goquota.TierConfig{Name: "free", BalanceWarnings: map[string][]int{"credits": {0}}},
+		},
+		{
+			name: "rate limit warning without rate limit",
+			tier: goquota.TierConfig{
+				Name:              "free",
+				RateLimitWarnings: map[string][]goquota.RateLimitWarning{"api_calls": {{Threshold: 0.1}}},
+			},
+		},
+		{
+			name: "rate limit warning threshold out of range",
+			tier: goquota.TierConfig{
+				Name: "free",
+				RateLimits: map[string]goquota.RateLimitConfig{
+					"api_calls": {Algorithm: "sliding_window", Rate: 10, Window: time.Second},
+				},
+				RateLimitWarnings: map[string][]goquota.RateLimitWarning{"api_calls": {{Threshold: 1.5}}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := goquota.Config{
+				DefaultTier: "free",
+				Tiers:       map[string]goquota.TierConfig{"free": tt.tier},
+			}
+			if err := config.Validate(); err == nil {
+				t.Error("Expected validation error")
+			}
+		})
+	}
+}
@@ -0,0 +1,350 @@
+package goquota
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// balanceWarningResource is the resource name under which notified balance warnings are
+// recorded in a WarningStateStore, keeping them apart from percentage thresholds.
+func balanceWarningResource(resource string) string {
+	return resource + ":balance"
+}
+
+// percentageReached reports whether used reaches a fraction of limit
+func percentageReached(limit, used int) func(float64) bool {
+	return func(threshold float64) bool {
+		return float64(used) >= float64(limit)*threshold
+	}
+}
+
+// balanceReached reports whether fewer than a number of units remain
+func balanceReached(limit, used int) func(float64) bool {
+	return func(threshold float64) bool {
+		return float64(limit-used) < threshold
+	}
+}
+
+// checkWarnings notifies percentage and balance warnings reached by consuming amount up to currentUsed
+func (m *Manager) checkWarnings(ctx context.Context, userID, resource, tier string,
+	limit, currentUsed, amount int, period Period) {
+	if limit <= 0 {
+		return
+	}
+	previousUsed := currentUsed - amount
+
+	if thresholds := m.getWarningThresholds(resource, tier); len(thresholds) > 0 {
+		reached := m.reachedWarnings(ctx, userID, resource, period, thresholds,
+			percentageReached(limit, currentUsed), percentageReached(limit, previousUsed))
+		for _, threshold := range reached {
+			m.metrics.RecordQuotaWarning(resource, tier, threshold)
+
+			// Determine threshold range for users approaching limit
+			usagePercent := float64(currentUsed) / float64(limit)
+			var thresholdRange string
+			if usagePercent >= 0.9 {
+				thresholdRange = "90-100%"
+			} else if usagePercent >= 0.8 {
+				thresholdRange = "80-90%"
+			} else if usagePercent >= 0.5 {
+				thresholdRange = "50-80%"
+			}
+			if thresholdRange != "" {
+				m.metrics.RecordUsersApproachingLimit(resource, tier, thresholdRange)
+			}
+
+			m.notifyWarning(ctx, m.usageWarning(ctx, WarningKindPercentage, userID, resource, tier,
+				limit, currentUsed, threshold, period), amount)
+		}
+	}
+
+	if balances := m.getBalanceWarnings(resource, tier); len(balances) > 0 {
+		reached := m.reachedWarnings(ctx, userID, balanceWarningResource(resource), period, balances,
+			balanceReached(limit, currentUsed), balanceReached(limit, previousUsed))
+		for _, threshold := range reached {
+			m.notifyWarning(ctx, m.usageWarning(ctx, WarningKindBalance, userID, resource, tier,
+				limit, currentUsed, threshold, period), amount)
+		}
+	}
+}
+
+func (m *Manager) usageWarning(ctx context.Context, kind WarningKind, userID, resource, tier string,
+	limit, used int, threshold float64, period Period) *Warning {
+	return &Warning{
+		Kind:       kind,
+		UserID:     userID,
+		Resource:   resource,
+		Tier:       tier,
+		PeriodType: period.Type,
+		Threshold:  threshold,
+		Used:       used,
+		Limit:      limit,
+		Remaining:  max(limit-used, 0),
+		Usage: &Usage{
+			UserID:    userID,
+			Resource:  resource,
+			Used:      used,
+			Limit:     limit,
+			Period:    period,
+			Tier:      tier,
+			UpdatedAt: m.now(ctx),
+		},
+	}
+}
+
+// notifyWarning calls the configured and context warning handlers and emits an EventQuotaWarning.
+// Handlers that don't implement DetailedWarningHandler only receive percentage warnings.
+func (m *Manager) notifyWarning(ctx context.Context, warning *Warning, amount int) {
+	handlers := []WarningHandler{m.config.WarningHandler}
+	if ctxHandler, ok := ctx.Value(contextWarningKey{}).(WarningHandler); ok {
+		handlers = append(handlers, ctxHandler)
+	}
+	for _, handler := range handlers {
+		if detailed, ok := handler.(DetailedWarningHandler); ok {
+			detailed.OnWarningDetail(ctx, warning)
+		} else if handler != nil && warning.Kind == WarningKindPercentage {
+			handler.OnWarning(ctx, warning.Usage, warning.Threshold)
+		}
+	}
+
+	if m.eventsEnabled() {
+		m.emit(ctx, &Event{
+			Type:       EventQuotaWarning,
+			UserID:     warning.UserID,
+			Resource:   warning.Resource,
+			PeriodType: warning.PeriodType,
+			Tier:       warning.Tier,
+			Amount:     amount,
+			Used:       warning.Used,
+			Limit:      warning.Limit,
+			Threshold:  warning.Threshold,
+			Reason:     string(warning.Kind),
+		})
+	}
+}
+
+func (m *Manager) getBalanceWarnings(resource, tier string) []float64 {
+	t, ok := m.config.Tiers[tier]
+	if !ok || len(t.BalanceWarnings[resource]) == 0 {
+		return nil
+	}
+	balances := make([]float64, len(t.BalanceWarnings[resource]))
+	for i, balance := range t.BalanceWarnings[resource] {
+		balances[i] = float64(balance)
+	}
+	return balances
+}
+
+// reachedWarnings returns the thresholds that fire now, given whether each threshold is reached
+// now and was reached before this consumption. With a WarningStateStore each reached threshold
+// fires once per period across instances, and notified thresholds no longer reached are re-armed.
+// Otherwise, or if the state can't be read, thresholds fire when this consumption crosses them.
+// Below every threshold the state is not read: consumption only raises usage, and operations
+// that lower it re-arm thresholds themselves (rearmWarnings).
+func (m *Manager) reachedWarnings(ctx context.Context, userID, stateResource string, period Period,
+	thresholds []float64, reachedNow, reachedBefore func(float64) bool) []float64 {
+	crossed := func(threshold float64) bool {
+		return reachedNow(threshold) && !reachedBefore(threshold)
+	}
+	if !slices.ContainsFunc(thresholds, reachedNow) {
+		return nil
+	}
+
+	store, ok := storageAs[WarningStateStore](m.storage)
+	if !ok {
+		return slices.DeleteFunc(slices.Clone(thresholds), func(t float64) bool { return !crossed(t) })
+	}
+
+	start := time.Now()
+	notified, err := store.GetNotifiedWarnings(ctx, userID, stateResource, period)
+	m.metrics.RecordStorageOperation("GetNotifiedWarnings", time.Since(start), err)
+	if err != nil {
+		m.logger.Warn("failed to get notified warnings, falling back to threshold crossing",
+			Field{"userId", userID},
+			Field{"resource", stateResource},
+			Field{"error", err},
+		)
+		return slices.DeleteFunc(slices.Clone(thresholds), func(t float64) bool { return !crossed(t) })
+	}
+
+	var fire, rearm []float64
+	for _, threshold := range thresholds {
+		reached := reachedNow(threshold)
+		wasNotified := slices.Contains(notified, threshold)
+		switch {
+		case reached && !wasNotified:
+			start := time.Now()
+			marked, err := store.MarkWarningNotified(ctx, userID, stateResource, period, threshold)
+			m.metrics.RecordStorageOperation("MarkWarningNotified", time.Since(start), err)
+			if err != nil {
+				m.logger.Warn("failed to mark warning as notified",
+					Field{"userId", userID},
+					Field{"resource", stateResource},
+					Field{"threshold", threshold},
+					Field{"error", err},
+				)
+				// A possible duplicate is better than a missed warning
+				marked = crossed(threshold)
+			}
+			if marked {
+				fire = append(fire, threshold)
+			}
+		case !reached && wasNotified:
+			rearm = append(rearm, threshold)
+		}
+	}
+
+	if len(rearm) > 0 {
+		m.clearNotifiedWarnings(ctx, store, userID, stateResource, period, rearm)
+	}
+	return fire
+}
+
+// rearmWarnings re-arms notified thresholds that are no longer reached after an operation
+// lowered usage or raised the limit (refunds, SetUsage, tier changes, top-ups).
+// An empty tier is resolved from the user's entitlement.
+func (m *Manager) rearmWarnings(ctx context.Context, userID, resource, tier string, period Period) {
+	store, ok := storageAs[WarningStateStore](m.storage)
+	if !ok {
+		return
+	}
+	if tier == "" {
+		tier = m.config.DefaultTier
+		if ent, err := m.GetEntitlement(ctx, userID); err == nil && ent != nil {
+			tier = ent.Tier
+		}
+	}
+	thresholds := m.getWarningThresholds(resource, tier)
+	balances := m.getBalanceWarnings(resource, tier)
+	if len(thresholds) == 0 && len(balances) == 0 {
+		return
+	}
+
+	usage, err := m.storage.GetUsage(ctx, userID, resource, period)
+	if err != nil {
+		m.logger.Warn("failed to get usage for re-arming warnings",
+			Field{"userId", userID},
+			Field{"resource", resource},
+			Field{"error", err},
+		)
+		return
+	}
+
+	// Use the same limit as Consume: configured, or the stored balance for forever credits
+	limit := m.getLimitForResource(resource, tier, period.Type)
+	used := 0
+	if usage != nil {
+		used = usage.Used
+		if period.Type == PeriodTypeForever && usage.Limit > 0 {
+			limit = usage.Limit
+		}
+	}
+	if limit <= 0 {
+		return
+	}
+
+	rearm := func(stateResource string, thresholds []float64, reached func(float64) bool) {
+		notReached := slices.DeleteFunc(slices.Clone(thresholds), reached)
+		if len(notReached) > 0 {
+			m.clearNotifiedWarnings(ctx, store, userID, stateResource, period, notReached)
+		}
+	}
+	rearm(resource, thresholds, percentageReached(limit, used))
+	rearm(balanceWarningResource(resource), balances, balanceReached(limit, used))
+}
+
+func (m *Manager) clearNotifiedWarnings(ctx context.Context, store WarningStateStore,
+	userID, resource string, period Period, thresholds []float64) {
+	start := time.Now()
+	err := store.ClearNotifiedWarnings(ctx, userID, resource, period, thresholds)
+	m.metrics.RecordStorageOperation("ClearNotifiedWarnings", time.Since(start), err)
+	if err != nil {
+		m.logger.Warn("failed to re-arm warnings",
+			Field{"userId", userID},
+			Field{"resource", resource},
+			Field{"error", err},
+		)
+	}
+}
+
+// rateWarningEvictInterval is how often rate limit warning states that expired are evicted
+const rateWarningEvictInterval = time.Minute
+
+// rateLimitWarningState tracks since when a user's rate limit capacity has been below a threshold
+type rateLimitWarningState struct {
+	since time.Time
+	fired bool
+	// expiresAt is when the state is dropped if no request of the user is seen meanwhile:
+	// by then capacity has recovered and the warning has been sustained for long enough
+	expiresAt time.Time
+}
+
+// evictRateWarningStates removes, at most once per rateWarningEvictInterval, the states of users
+// that stopped sending requests. Callers hold rateWarningMu.
+func (m *Manager) evictRateWarningStates(now time.Time) {
+	if now.Before(m.rateWarningEvictAt) {
+		return
+	}
+	m.rateWarningEvictAt = now.Add(rateWarningEvictInterval)
+	for key, state := range m.rateWarningStates {
+		if !now.Before(state.expiresAt) {
+			delete(m.rateWarningStates, key)
+		}
+	}
+}
+
+// checkRateLimitWarnings notifies rate limit warnings whose threshold the remaining capacity has
+// stayed below for their configured duration. Each warning fires once until capacity recovers.
+// The state is local to this Manager instance.
+func (m *Manager) checkRateLimitWarnings(ctx context.Context, userID, resource, tier string,
+	config RateLimitConfig, warnings []RateLimitWarning, info *RateLimitInfo) {
+	capacity := info.Limit
+	if config.Algorithm == algorithmTokenBucket && config.Burst > 0 {
+		capacity = config.Burst
+	}
+	if capacity <= 0 {
+		return
+	}
+	remaining := float64(info.Remaining) / float64(capacity)
+	now := m.now(ctx)
+
+	for i, warning := range warnings {
+		key := fmt.Sprintf("%s:%s:%d", userID, resource, i)
+
+		m.rateWarningMu.Lock()
+		m.evictRateWarningStates(now)
+		if remaining >= warning.Threshold {
+			delete(m.rateWarningStates, key)
+			m.rateWarningMu.Unlock()
+			continue
+		}
+		state, ok := m.rateWarningStates[key]
+		if !ok || !now.Before(state.expiresAt) {
+			state = &rateLimitWarningState{since: now}
+			m.rateWarningStates[key] = state
+		}
+		state.expiresAt = now.Add(max(warning.For, config.Window))
+		sustained := now.Sub(state.since)
+		fire := !state.fired && sustained >= warning.For
+		if fire {
+			state.fired = true
+		}
+		m.rateWarningMu.Unlock()
+
+		if fire {
+			m.notifyWarning(ctx, &Warning{
+				Kind:      WarningKindRateLimit,
+				UserID:    userID,
+				Resource:  resource,
+				Tier:      tier,
+				Threshold: warning.Threshold,
+				Used:      max(capacity-info.Remaining, 0),
+				Limit:     capacity,
+				Remaining: info.Remaining,
+				Sustained: sustained,
+			}, 0)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package goquota
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// fixedClock is a TimeSource returning a time set by the test
+type fixedClock struct {
+	now time.Time
+}
+
+func (c *fixedClock) Now(_ context.Context) (time.Time, error) {
+	return c.now, nil
+}
+
+func TestManager_RateLimitWarningStatesEvicted(t *testing.T) {
+	clock := &fixedClock{now: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	m := &Manager{timeSource: clock, rateWarningStates: make(map[string]*rateLimitWarningState)}
+	config := RateLimitConfig{Algorithm: algorithmSlidingWindow, Rate: 10, Window: time.Minute}
+	warnings := []RateLimitWarning{{Threshold: 0.5, For: time.Hour}}
+	throttled := &RateLimitInfo{Limit: 10, Remaining: 1}
+
+	// Throttled users that stop sending requests
+	for i := 0; i < 100; i++ {
+		m.checkRateLimitWarnings(context.Background(), fmt.Sprintf("user%d", i), "api", "free",
+			config, warnings, throttled)
+	}
+	if got := len(m.rateWarningStates); got != 100 {
+		t.Fatalf("Expected 100 warning states, got %d", got)
+	}
+
+	// Kept while the warning may still be sustained, evicted once it can no longer be
+	clock.now = clock.now.Add(30 * time.Minute)
+	m.checkRateLimitWarnings(context.Background(), "user0", "api", "free", config, warnings, throttled)
+	if got := len(m.rateWarningStates); got != 100 {
+		t.Fatalf("Expected 100 warning states after 30 minutes, got %d", got)
+	}
+	clock.now = clock.now.Add(time.Hour)
+	m.checkRateLimitWarnings(context.Background(), "user0", "api", "free", config, warnings,
+		&RateLimitInfo{Limit: 10, Remaining: 10})
+	if got := len(m.rateWarningStates); got != 0 {
+		t.Errorf("Expected idle warning states evicted, got %d", got)
+	}
+}
@@ -0,0 +1,510 @@
+package goquota
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultWebhookMaxAttempts    = 8
+	defaultWebhookInitialBackoff = time.Second
+	defaultWebhookMaxBackoff     = time.Hour
+	defaultWebhookPollInterval   = time.Second
+	defaultWebhookTimeout        = 10 * time.Second
+	defaultWebhookBatchSize      = 100
+	defaultWebhookConcurrency    = 4
+
+	// WebhookSignatureHeader carries the payload signature ("t=<unix>,v1=<hex>")
+	WebhookSignatureHeader = "X-Goquota-Signature"
+	// WebhookEventHeader carries the event type
+	WebhookEventHeader = "X-Goquota-Event"
+	// WebhookDeliveryHeader carries the delivery ID, which is stable across retries
+	WebhookDeliveryHeader = "X-Goquota-Delivery"
+)
+
+// defaultWebhookEvents are delivered to endpoints that don't filter events
+var defaultWebhookEvents = []EventType{EventQuotaWarning, EventQuotaExhausted}
+
+// errWebhookEndpointRemoved dead-letters deliveries whose endpoint is no longer configured
+var errWebhookEndpointRemoved = errors.New("webhook endpoint is no longer configured")
+
+// SignWebhookPayload returns the WebhookSignatureHeader value for a payload:
+// "t=<unix seconds>,v1=<hex HMAC-SHA256 of "<unix seconds>.<payload>">".
+func SignWebhookPayload(secret string, timestamp time.Time, payload []byte) string {
+	ts := strconv.FormatInt(timestamp.Unix(), 10)
+	return "t=" + ts + ",v1=" + webhookSignature(secret, ts, payload)
+}
+
+// VerifyWebhookSignature verifies a WebhookSignatureHeader value against the raw request body.
+// Signatures older than tolerance are rejected to prevent replays (0 disables the check).
+// Returns ErrInvalidWebhookSignature if verification fails.
+func VerifyWebhookSignature(secret, header string, payload []byte, tolerance time.Duration) error {
+	var ts string
+	var signatures []string
+	for _, part := range strings.Split(header, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "t":
+			ts = value
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+	if ts == "" || len(signatures) == 0 {
+		return ErrInvalidWebhookSignature
+	}
+
+	unix, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return ErrInvalidWebhookSignature
+	}
+	if tolerance > 0 {
+		age := time.Since(time.Unix(unix, 0))
+		if age > tolerance || age < -tolerance {
+			return ErrInvalidWebhookSignature
+		}
+	}
+
+	expected := []byte(webhookSignature(secret, ts, payload))
+	for _, signature := range signatures {
+		if hmac.Equal([]byte(signature), expected) {
+			return nil
+		}
+	}
+	return ErrInvalidWebhookSignature
+}
+
+func webhookSignature(secret, ts string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// webhookDispatcher persists webhook deliveries when events are emitted and sends them from a
+// background worker. Deliveries are in storage before Emit returns, so they survive crashes and
+// restarts: any instance polling the same storage picks them up.
+type webhookDispatcher struct {
+	store   WebhookStore
+	config  *WebhookConfig
+	client  *http.Client
+	metrics Metrics
+	logger  Logger
+	now     func(ctx context.Context) time.Time
+
+	// static are the event types a static endpoint subscribes to, resolved the event types
+	// looked up with the EndpointResolver
+	static   map[EventType]bool
+	resolved map[EventType]bool
+
+	wake   chan struct{}
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func newWebhookDispatcher(store WebhookStore, config *WebhookConfig, metrics Metrics, logger Logger,
+	now func(ctx context.Context) time.Time) *webhookDispatcher {
+	client := config.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: config.Timeout}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	d := &webhookDispatcher{
+		store:    store,
+		config:   config,
+		client:   client,
+		metrics:  metrics,
+		logger:   logger,
+		now:      now,
+		static:   make(map[EventType]bool),
+		resolved: make(map[EventType]bool),
+		wake:     make(chan struct{}, 1),
+		cancel:   cancel,
+		done:     make(chan struct{}),
+	}
+	for _, endpoint := range config.Endpoints {
+		events := endpoint.Events
+		if len(events) == 0 {
+			events = defaultWebhookEvents
+		}
+		for _, eventType := range events {
+			d.static[eventType] = true
+		}
+	}
+	if config.EndpointResolver != nil {
+		events := config.ResolverEvents
+		if len(events) == 0 {
+			events = defaultWebhookEvents
+		}
+		for _, eventType := range events {
+			d.resolved[eventType] = true
+		}
+	}
+	go d.run(ctx)
+	return d
+}
+
+// persist saves a delivery of the event for every endpoint subscribed to its type.
+// The deliveries that could not be saved are logged, counted as dropped events and returned as an
+// error. ctx bounds endpoint resolution and storage, but its cancellation doesn't: the event
+// reports a change that was already applied.
+func (d *webhookDispatcher) persist(ctx context.Context, event *Event) error {
+	if !d.static[event.Type] && !(d.resolved[event.Type] && event.UserID != "") {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), d.config.Timeout)
+	defer cancel()
+
+	var errs []error
+	endpoints := d.config.Endpoints
+	if d.resolved[event.Type] {
+		var err error
+		if endpoints, err = d.endpoints(ctx, event.UserID); err != nil {
+			d.metrics.RecordEventDropped(string(event.Type))
+			errs = append(errs, err)
+		}
+	}
+
+	var payload []byte
+	now := d.now(ctx)
+	queued := false
+	for _, endpoint := range endpoints {
+		if !webhookSubscribed(endpoint, event.Type) {
+			continue
+		}
+		if payload == nil {
+			var err error
+			if payload, err = json.Marshal(event); err != nil {
+				d.logger.Error("failed to marshal webhook event",
+					Field{"eventType", event.Type},
+					Field{"error", err},
+				)
+				d.metrics.RecordEventDropped(string(event.Type))
+				return fmt.Errorf("failed to marshal webhook event: %w", err)
+			}
+		}
+
+		delivery := &WebhookDelivery{
+			ID:            newEventID(),
+			EventID:       event.ID,
+			EventType:     event.Type,
+			UserID:        event.UserID,
+			URL:           endpoint.URL,
+			Payload:       payload,
+			Status:        WebhookDeliveryPending,
+			NextAttemptAt: now,
+			CreatedAt:     now,
+			UpdatedAt:     now,
+		}
+		start := time.Now()
+		err := d.store.SaveWebhookDelivery(ctx, delivery)
+		d.metrics.RecordStorageOperation("SaveWebhookDelivery", time.Since(start), err)
+		if err != nil {
+			d.logger.Error("failed to queue webhook delivery",
+				Field{"userId", event.UserID},
+				Field{"eventType", event.Type},
+				Field{"url", endpoint.URL},
+				Field{"error", err},
+			)
+			d.metrics.RecordEventDropped(string(event.Type))
+			errs = append(errs, fmt.Errorf("failed to queue webhook delivery to %s: %w", endpoint.URL, err))
+			continue
+		}
+		queued = true
+	}
+
+	if queued {
+		select {
+		case d.wake <- struct{}{}:
+		default:
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// endpoints returns the static endpoints followed by the user's resolved endpoints.
+// If they can't be resolved, it returns the static endpoints and the error.
+func (d *webhookDispatcher) endpoints(ctx context.Context, userID string) ([]WebhookEndpoint, error) {
+	endpoints := d.config.Endpoints
+	if d.config.EndpointResolver == nil || userID == "" {
+		return endpoints, nil
+	}
+	resolved, err := d.config.EndpointResolver.WebhookEndpoints(ctx, userID)
+	if err != nil {
+		d.logger.Error("failed to resolve webhook endpoints",
+			Field{"userId", userID},
+			Field{"error", err},
+		)
+		return endpoints, fmt.Errorf("failed to resolve webhook endpoints: %w", err)
+	}
+	return append(slices.Clip(endpoints), resolved...), nil
+}
+
+func webhookSubscribed(endpoint WebhookEndpoint, eventType EventType) bool {
+	if len(endpoint.Events) == 0 {
+		return slices.Contains(defaultWebhookEvents, eventType)
+	}
+	return slices.Contains(endpoint.Events, eventType)
+}
+
+func (d *webhookDispatcher) run(ctx context.Context) {
+	defer close(d.done)
+	ticker := time.NewTicker(d.config.PollInterval)
+	defer ticker.Stop()
+	for {
+		d.poll(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-d.wake:
+		}
+	}
+}
+
+// poll claims due deliveries and sends them, until no full batch is left
+func (d *webhookDispatcher) poll(ctx context.Context) {
+	// Claimed deliveries are hidden from other instances until the whole batch could have timed out
+	batches := (d.config.BatchSize + d.config.Concurrency - 1) / d.config.Concurrency
+	lease := d.config.Timeout * time.Duration(batches+1)
+
+	for ctx.Err() == nil {
+		start := time.Now()
+		deliveries, err := d.store.ClaimWebhookDeliveries(ctx, d.now(ctx), d.config.BatchSize, lease)
+		d.metrics.RecordStorageOperation("ClaimWebhookDeliveries", time.Since(start), err)
+		if err != nil {
+			if ctx.Err() == nil {
+				d.logger.Error("failed to claim webhook deliveries", Field{"error", err})
+			}
+			return
+		}
+
+		sem := make(chan struct{}, d.config.Concurrency)
+		var wg sync.WaitGroup
+		for _, delivery := range deliveries {
+			sem <- struct{}{}
+			wg.Add(1)
+			go func(delivery *WebhookDelivery) {
+				defer func() {
+					<-sem
+					wg.Done()
+				}()
+				d.deliver(ctx, delivery)
+			}(delivery)
+		}
+		wg.Wait()
+
+		if len(deliveries) < d.config.BatchSize {
+			return
+		}
+	}
+}
+
+// deliver sends a delivery and deletes it on success, or schedules a retry or dead-letters it
+func (d *webhookDispatcher) deliver(ctx context.Context, delivery *WebhookDelivery) {
+	secret, err := d.secret(ctx, delivery)
+	if err == nil {
+		err = d.send(ctx, delivery, secret)
+	}
+	if ctx.Err() != nil {
+		// Shutting down; the claim lease expires and the delivery is retried
+		return
+	}
+
+	// Storage writes use a fresh context so a slow endpoint doesn't leave the delivery claimed
+	storeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), d.config.Timeout)
+	defer cancel()
+
+	if err == nil {
+		start := time.Now()
+		deleteErr := d.store.DeleteWebhookDelivery(storeCtx, delivery.ID)
+		d.metrics.RecordStorageOperation("DeleteWebhookDelivery", time.Since(start), deleteErr)
+		d.metrics.RecordWebhookDelivery(string(delivery.EventType), "delivered")
+		if deleteErr != nil {
+			d.logger.Error("failed to delete delivered webhook",
+				Field{"deliveryId", delivery.ID},
+				Field{"error", deleteErr},
+			)
+		}
+		return
+	}
+
+	now := d.now(storeCtx)
+	delivery.Attempts++
+	delivery.LastError = err.Error()
+	delivery.UpdatedAt = now
+	outcome := "retry"
+	if errors.Is(err, errWebhookEndpointRemoved) || delivery.Attempts >= d.config.MaxAttempts {
+		delivery.Status = WebhookDeliveryDead
+		outcome = "dead_letter"
+		d.logger.Warn("webhook delivery dead-lettered",
+			Field{"deliveryId", delivery.ID},
+			Field{"userId", delivery.UserID},
+			Field{"url", delivery.URL},
+			Field{"attempts", delivery.Attempts},
+			Field{"error", err},
+		)
+	} else {
+		delivery.NextAttemptAt = now.Add(d.backoff(delivery.Attempts))
+	}
+	d.metrics.RecordWebhookDelivery(string(delivery.EventType), outcome)
+
+	start := time.Now()
+	saveErr := d.store.SaveWebhookDelivery(storeCtx, delivery)
+	d.metrics.RecordStorageOperation("SaveWebhookDelivery", time.Since(start), saveErr)
+	if saveErr != nil {
+		d.logger.Error("failed to reschedule webhook delivery",
+			Field{"deliveryId", delivery.ID},
+			Field{"error", saveErr},
+		)
+	}
+}
+
+// secret looks up the signing secret of the delivery's endpoint by URL.
+// Returns errWebhookEndpointRemoved if the endpoint is no longer configured, or the error of the
+// EndpointResolver, retried like a failed send, if it couldn't be looked up.
+func (d *webhookDispatcher) secret(ctx context.Context, delivery *WebhookDelivery) (string, error) {
+	endpoints, err := d.endpoints(ctx, delivery.UserID)
+	for _, endpoint := range endpoints {
+		if endpoint.URL == delivery.URL {
+			return endpoint.Secret, nil
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+	return "", errWebhookEndpointRemoved
+}
+
+func (d *webhookDispatcher) send(ctx context.Context, delivery *WebhookDelivery, secret string) error {
+	ctx, cancel := context.WithTimeout(ctx, d.config.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookEventHeader, string(delivery.EventType))
+	req.Header.Set(WebhookDeliveryHeader, delivery.ID)
+	req.Header.Set(WebhookSignatureHeader, SignWebhookPayload(secret, time.Now(), delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	//nolint:errcheck // Drain the body so the connection can be reused
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook endpoint returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// backoff returns the delay before retrying after the given number of attempts:
+// exponential from InitialBackoff, capped at MaxBackoff, with jitter in [delay/2, delay].
+func (d *webhookDispatcher) backoff(attempts int) time.Duration {
+	delay := d.config.InitialBackoff
+	for i := 1; i < attempts && delay < d.config.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > d.config.MaxBackoff {
+		delay = d.config.MaxBackoff
+	}
+	half := delay / 2
+	return half + time.Duration(rand.Int64N(int64(delay-half)+1))
+}
+
+// close stops the background worker, waiting for in-flight deliveries or ctx to be done
+func (d *webhookDispatcher) close(ctx context.Context) error {
+	d.cancel()
+	select {
+	case <-d.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// ListWebhookDeliveries returns webhook deliveries with the given status, newest first.
+// Use WebhookDeliveryDead to inspect deliveries that ran out of attempts.
+// Returns an error if storage doesn't implement WebhookStore.
+func (m *Manager) ListWebhookDeliveries(ctx context.Context, status WebhookDeliveryStatus,
+	limit, offset int) ([]*WebhookDelivery, error) {
+	if limit < 0 || offset < 0 {
+		return nil, fmt.Errorf("limit and offset cannot be negative")
+	}
+	store, ok := storageAs[WebhookStore](m.storage)
+	if !ok {
+		return nil, fmt.Errorf("storage does not implement WebhookStore")
+	}
+
+	start := time.Now()
+	deliveries, err := store.ListWebhookDeliveries(ctx, status, limit, offset)
+	m.metrics.RecordStorageOperation("ListWebhookDeliveries", time.Since(start), err)
+	return deliveries, err
+}
+
+// ReplayWebhookDelivery re-queues a delivery (typically a dead letter) with a fresh set of attempts.
+// It is sent by the next poll of any Manager with a WebhookConfig.
+// Returns ErrWebhookDeliveryNotFound if the delivery doesn't exist.
+func (m *Manager) ReplayWebhookDelivery(ctx context.Context, id string) error {
+	store, ok := storageAs[WebhookStore](m.storage)
+	if !ok {
+		return fmt.Errorf("storage does not implement WebhookStore")
+	}
+
+	delivery, err := store.GetWebhookDelivery(ctx, id)
+	if err != nil {
+		return err
+	}
+	if delivery == nil {
+		return ErrWebhookDeliveryNotFound
+	}
+
+	now := m.now(ctx)
+	delivery.Status = WebhookDeliveryPending
+	delivery.Attempts = 0
+	delivery.LastError = ""
+	delivery.NextAttemptAt = now
+	delivery.UpdatedAt = now
+
+	start := time.Now()
+	err = store.SaveWebhookDelivery(ctx, delivery)
+	m.metrics.RecordStorageOperation("SaveWebhookDelivery", time.Since(start), err)
+	if err != nil {
+		return err
+	}
+
+	m.logger.Info("webhook delivery replayed",
+		Field{"deliveryId", id},
+		Field{"userId", delivery.UserID},
+	)
+	if m.webhooks != nil {
+		select {
+		case m.webhooks.wake <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
@@ -0,0 +1,456 @@
+package goquota_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mihaimyh/goquota/pkg/goquota"
+	"github.com/mihaimyh/goquota/storage/memory"
+)
+
+const testWebhookSecret = "whsec_test"
+
+// webhookReceiver records signed webhook requests and fails the first failures requests
+type webhookReceiver struct {
+	mu       sync.Mutex
+	events   []*goquota.Event
+	failures atomic.Int32
+	requests atomic.Int32
+	badSigs  atomic.Int32
+}
+
+func (r *webhookReceiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.requests.Add(1)
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	header := req.Header.Get(goquota.WebhookSignatureHeader)
+	if err := goquota.VerifyWebhookSignature(testWebhookSecret, header, body, time.Minute); err != nil {
+		r.badSigs.Add(1)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	if r.failures.Add(-1) >= 0 {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	var event goquota.Event
+	if err := json.Unmarshal(body, &event); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if req.Header.Get(goquota.WebhookEventHeader) != string(event.Type) {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	r.mu.Lock()
+	r.events = append(r.events, &event)
+	r.mu.Unlock()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (r *webhookReceiver) received() []*goquota.Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*goquota.Event(nil), r.events...)
+}
+
+// withWebhooks configures the webhooks of a newEventsTestManager, with a warning at 50% of the
+// free tier's monthly api_calls
+func withWebhooks(webhookConfig *goquota.WebhookConfig) func(*goquota.Config) {
+	return func(config *goquota.Config) {
+		free := config.Tiers["free"]
+		free.WarningThresholds = map[string][]float64{"api_calls": {0.5}}
+		config.Tiers["free"] = free
+		config.WebhookConfig = webhookConfig
+	}
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestSignWebhookPayload_Verify(t *testing.T) {
+	payload := []byte(`{"type":"quota.warning"}`)
+	header := goquota.SignWebhookPayload("secret", time.Now(), payload)
+
+	if err := goquota.VerifyWebhookSignature("secret", header, payload, time.Minute); err != nil {
+		t.Errorf("Expected valid signature, got %v", err)
+	}
+	if err := goquota.VerifyWebhookSignature("other", header, payload, time.Minute); !errors.Is(err,
+		goquota.ErrInvalidWebhookSignature) {
+		t.Errorf("Expected ErrInvalidWebhookSignature for wrong secret, got %v", err)
+	}
+	if err := goquota.VerifyWebhookSignature("secret", header, []byte(`{"type":"quota.exhausted"}`),
+		time.Minute); !errors.Is(err, goquota.ErrInvalidWebhookSignature) {
+		t.Errorf("Expected ErrInvalidWebhookSignature for tampered payload, got %v", err)
+	}
+	if err := goquota.VerifyWebhookSignature("secret", "garbage", payload, 0); !errors.Is(err,
+		goquota.ErrInvalidWebhookSignature) {
+		t.Errorf("Expected ErrInvalidWebhookSignature for malformed header, got %v", err)
+	}
+
+	old := goquota.SignWebhookPayload("secret", time.Now().Add(-time.Hour), payload)
+	if err := goquota.VerifyWebhookSignature("secret", old, payload, 5*time.Minute); !errors.Is(err,
+		goquota.ErrInvalidWebhookSignature) {
+		t.Errorf("Expected ErrInvalidWebhookSignature for stale signature, got %v", err)
+	}
+	if err := goquota.VerifyWebhookSignature("secret", old, payload, 0); err != nil {
+		t.Errorf("Expected stale signature to pass without tolerance, got %v", err)
+	}
+}
+
+func TestManager_Webhooks_DeliversWarningAndExhausted(t *testing.T) {
+	receiver := &webhookReceiver{}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	storage := memory.New()
+	manager := newEventsTestManager(t, storage, nil, withWebhooks(&goquota.WebhookConfig{
+		Endpoints:    []goquota.WebhookEndpoint{{URL: server.URL, Secret: testWebhookSecret}},
+		PollInterval: 10 * time.Millisecond,
+	}))
+	ctx := context.Background()
+
+	// 5/10 crosses the 50% warning threshold, 10/10 exhausts the quota
+	if _, err := manager.Consume(ctx, "user1", "api_calls", 5, goquota.PeriodTypeMonthly); err != nil {
+		t.Fatalf("Consume failed: %v", err)
+	}
+	if _, err := manager.Consume(ctx, "user1", "api_calls", 5, goquota.PeriodTypeMonthly); err != nil {
+		t.Fatalf("Consume failed: %v", err)
+	}
+
+	waitFor(t, "webhook deliveries", func() bool { return len(receiver.received()) == 2 })
+
+	types := map[goquota.EventType]*goquota.Event{}
+	for _, event := range receiver.received() {
+		types[event.Type] = event
+	}
+	warning := types[goquota.EventQuotaWarning]
+	if warning == nil || warning.Threshold != 0.5 || warning.UserID != "user1" {
+		t.Errorf("Expected quota.warning at 0.5 for user1, got %+v", warning)
+	}
+	if types[goquota.EventQuotaExhausted] == nil {
+		t.Error("Expected quota.exhausted delivery")
+	}
+	if receiver.badSigs.Load() != 0 {
+		t.Errorf("Expected all signatures to verify, got %d failures", receiver.badSigs.Load())
+	}
+
+	// Delivered webhooks are removed from storage
+	waitFor(t, "delivered webhooks to be deleted", func() bool {
+		pending, err := manager.ListWebhookDeliveries(ctx, goquota.WebhookDeliveryPending, 0, 0)
+		return err == nil && len(pending) == 0
+	})
+}
+
+func TestManager_Webhooks_RetryThenDeadLetterAndReplay(t *testing.T) {
+	receiver := &webhookReceiver{}
+	receiver.failures.Store(2)
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	manager := newEventsTestManager(t, memory.New(), nil, withWebhooks(&goquota.WebhookConfig{
+		Endpoints: []goquota.WebhookEndpoint{{
+			URL:    server.URL,
+			Secret: testWebhookSecret,
+			Events: []goquota.EventType{goquota.EventQuotaWarning},
+		}},
+		MaxAttempts:    2,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     time.Millisecond,
+		PollInterval:   10 * time.Millisecond,
+	}))
+	ctx := context.Background()
+
+	if _, err := manager.Consume(ctx, "user1", "api_calls", 5, goquota.PeriodTypeMonthly); err != nil {
+		t.Fatalf("Consume failed: %v", err)
+	}
+
+	var dead []*goquota.WebhookDelivery
+	waitFor(t, "dead letter", func() bool {
+		var err error
+		dead, err = manager.ListWebhookDeliveries(ctx, goquota.WebhookDeliveryDead, 0, 0)
+		return err == nil && len(dead) == 1
+	})
+	if dead[0].Attempts != 2 {
+		t.Errorf("Expected 2 attempts, got %d", dead[0].Attempts)
+	}
+	if dead[0].LastError == "" {
+		t.Error("Expected LastError to be recorded")
+	}
+	if dead[0].EventType != goquota.EventQuotaWarning {
+		t.Errorf("Expected quota.warning, got %s", dead[0].EventType)
+	}
+	if len(receiver.received()) != 0 {
+		t.Fatalf("Expected no successful deliveries, got %d", len(receiver.received()))
+	}
+
+	// The endpoint recovers; replaying sends the dead letter again
+	if err := manager.ReplayWebhookDelivery(ctx, dead[0].ID); err != nil {
+		t.Fatalf("ReplayWebhookDelivery failed: %v", err)
+	}
+	waitFor(t, "replayed delivery", func() bool { return len(receiver.received()) == 1 })
+
+	dead, err := manager.ListWebhookDeliveries(ctx, goquota.WebhookDeliveryDead, 0, 0)
+	if err != nil {
+		t.Fatalf("ListWebhookDeliveries failed: %v", err)
+	}
+	if len(dead) != 0 {
+		t.Errorf("Expected dead letter to be replayed, got %d", len(dead))
+	}
+
+	if err := manager.ReplayWebhookDelivery(ctx, "missing"); !errors.Is(err, goquota.ErrWebhookDeliveryNotFound) {
+		t.Errorf("Expected ErrWebhookDeliveryNotFound, got %v", err)
+	}
+}
+
+// staticResolver returns endpoints for a single user
+type staticResolver struct {
+	userID    string
+	endpoints []goquota.WebhookEndpoint
+}
+
+func (r staticResolver) WebhookEndpoints(_ context.Context, userID string) ([]goquota.WebhookEndpoint, error) {
+	if userID != r.userID {
+		return nil, nil
+	}
+	return r.endpoints, nil
+}
+
+func TestManager_Webhooks_EndpointResolver(t *testing.T) {
+	receiver := &webhookReceiver{}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	manager := newEventsTestManager(t, memory.New(), nil, withWebhooks(&goquota.WebhookConfig{
+		EndpointResolver: staticResolver{
+			userID:    "user1",
+			endpoints: []goquota.WebhookEndpoint{{URL: server.URL, Secret: testWebhookSecret}},
+		},
+		PollInterval: 10 * time.Millisecond,
+	}))
+	ctx := context.Background()
+
+	// user2 has no endpoints, so only user1's warning is delivered
+	if _, err := manager.Consume(ctx, "user2", "api_calls", 5, goquota.PeriodTypeMonthly); err != nil {
+		t.Fatalf("Consume failed: %v", err)
+	}
+	if _, err := manager.Consume(ctx, "user1", "api_calls", 5, goquota.PeriodTypeMonthly); err != nil {
+		t.Fatalf("Consume failed: %v", err)
+	}
+
+	waitFor(t, "webhook delivery", func() bool { return len(receiver.received()) == 1 })
+	time.Sleep(50 * time.Millisecond)
+	events := receiver.received()
+	if len(events) != 1 || events[0].UserID != "user1" {
+		t.Errorf("Expected a single delivery for user1, got %+v", events)
+	}
+}
+
+func TestManager_Webhooks_RequiresWebhookStore(t *testing.T) {
+	config := goquota.Config{
+		DefaultTier: "free",
+		Tiers: map[string]goquota.TierConfig{
+			"free": {Name: "free", MonthlyQuotas: map[string]int{"api_calls": 100}},
+		},
+		WebhookConfig: &goquota.WebhookConfig{
+			Endpoints: []goquota.WebhookEndpoint{{URL: "https://example.com/hook", Secret: "secret"}},
+		},
+	}
+	if _, err := goquota.NewManager(storageOnly{memory.New()}, &config); err == nil {
+		t.Error("Expected error for storage without WebhookStore")
+	}
+
+	config.WebhookConfig = nil
+	manager, err := goquota.NewManager(storageOnly{memory.New()}, &config)
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+	if _, err := manager.ListWebhookDeliveries(context.Background(), goquota.WebhookDeliveryDead, 0, 0); err == nil {
+		t.Error("Expected error for storage without WebhookStore")
+	}
+}
+
+func TestWebhookConfig_Validation(t *testing.T) {
+	endpoint := goquota.WebhookEndpoint{URL: "https://example.com/hook", Secret: "secret"}
+	tests := []struct {
+		name    string
+		config  *goquota.WebhookConfig
+		wantErr bool
+	}{
+		{name: "valid", config: &goquota.WebhookConfig{Endpoints: []goquota.WebhookEndpoint{endpoint}}},
+		{name: "no endpoints", config: &goquota.WebhookConfig{}, wantErr: true},
+		{
+			name: "missing secret",
+			config: &goquota.WebhookConfig{
+				Endpoints: []goquota.WebhookEndpoint{{URL: "https://example.com/hook"}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "relative url",
+			config: &goquota.WebhookConfig{
+				Endpoints: []goquota.WebhookEndpoint{{URL: "/hook", Secret: "secret"}},
+			},
+			wantErr: true,
+		},
+		{
+			name:    "negative attempts",
+			config:  &goquota.WebhookConfig{Endpoints: []goquota.WebhookEndpoint{endpoint}, MaxAttempts: -1},
+			wantErr: true,
+		},
+		{
+			name: "initial backoff above max",
+			config: &goquota.WebhookConfig{
+				Endpoints:      []goquota.WebhookEndpoint{endpoint},
+				InitialBackoff: time.Minute,
+				MaxBackoff:     time.Second,
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := goquota.Config{
+				DefaultTier: "free",
+				Tiers: map[string]goquota.TierConfig{
+					"free": {Name: "free", MonthlyQuotas: map[string]int{"api_calls": 100}},
+				},
+				WebhookConfig: tt.config,
+			}
+			err := config.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// countingResolver counts endpoint lookups
+type countingResolver struct {
+	calls     atomic.Int32
+	endpoints []goquota.WebhookEndpoint
+}
+
+func (r *countingResolver) WebhookEndpoints(_ context.Context, _ string) ([]goquota.WebhookEndpoint, error) {
+	r.calls.Add(1)
+	return r.endpoints, nil
+}
+
+func TestManager_Webhooks_ResolvesSubscribedEventsOnly(t *testing.T) {
+	receiver := &webhookReceiver{}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	resolver := &countingResolver{
+		endpoints: []goquota.WebhookEndpoint{{URL: server.URL, Secret: testWebhookSecret}},
+	}
+	manager := newEventsTestManager(t, memory.New(), nil, withWebhooks(&goquota.WebhookConfig{
+		EndpointResolver: resolver,
+		ResolverEvents:   []goquota.EventType{goquota.EventQuotaWarning},
+		PollInterval:     10 * time.Millisecond,
+	}))
+	ctx := context.Background()
+
+	// consume.succeeded and quota.exhausted are not resolved: only the warning is looked up
+	for i := 0; i < 4; i++ {
+		if _, err := manager.Consume(ctx, "user1", "api_calls", 1, goquota.PeriodTypeMonthly); err != nil {
+			t.Fatalf("Consume failed: %v", err)
+		}
+	}
+	if calls := resolver.calls.Load(); calls != 0 {
+		t.Errorf("Expected no endpoint lookups below the warning threshold, got %d", calls)
+	}
+	for i := 0; i < 6; i++ {
+		if _, err := manager.Consume(ctx, "user1", "api_calls", 1, goquota.PeriodTypeMonthly); err != nil {
+			t.Fatalf("Consume failed: %v", err)
+		}
+	}
+
+	waitFor(t, "webhook delivery", func() bool { return len(receiver.received()) == 1 })
+	time.Sleep(50 * time.Millisecond)
+	events := receiver.received()
+	if len(events) != 1 || events[0].Type != goquota.EventQuotaWarning {
+		t.Errorf("Expected a single quota.warning delivery, got %+v", events)
+	}
+}
+
+// recordingWebhookStore counts saved webhook deliveries and fails to save them while failing is set
+type recordingWebhookStore struct {
+	*memory.Storage
+	saved   atomic.Int32
+	failing atomic.Bool
+}
+
+func (s *recordingWebhookStore) SaveWebhookDelivery(ctx context.Context, delivery *goquota.WebhookDelivery) error {
+	if s.failing.Load() {
+		return goquota.ErrStorageUnavailable
+	}
+	s.saved.Add(1)
+	return s.Storage.SaveWebhookDelivery(ctx, delivery)
+}
+
+func TestManager_Webhooks_EmitPersistsDeliveries(t *testing.T) {
+	receiver := &webhookReceiver{}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	store := &recordingWebhookStore{Storage: memory.New()}
+	metrics := &eventMetrics{NoopMetrics: &goquota.NoopMetrics{}}
+	manager := newEventsTestManager(t, store, nil, func(config *goquota.Config) {
+		withWebhooks(&goquota.WebhookConfig{
+			Endpoints:    []goquota.WebhookEndpoint{{URL: server.URL, Secret: testWebhookSecret}},
+			PollInterval: 10 * time.Millisecond,
+		})(config)
+		config.Metrics = metrics
+	})
+	ctx := context.Background()
+
+	// The delivery is in storage when Emit returns
+	if err := manager.Emit(ctx, &goquota.Event{Type: goquota.EventQuotaWarning, UserID: "user1"}); err != nil {
+		t.Fatalf("Emit failed: %v", err)
+	}
+	if saved := store.saved.Load(); saved != 1 {
+		t.Errorf("Expected the delivery saved before Emit returned, got %d", saved)
+	}
+	waitFor(t, "webhook delivery", func() bool { return len(receiver.received()) == 1 })
+
+	// A delivery that can't be saved is counted as dropped and returned by Emit
+	store.failing.Store(true)
+	if err := manager.Emit(ctx, &goquota.Event{Type: goquota.EventQuotaWarning, UserID: "user1"}); err == nil {
+		t.Error("Expected Emit to fail when the delivery can't be saved")
+	}
+	if dropped := metrics.dropped.Load(); dropped != 1 {
+		t.Errorf("Expected the unsaved delivery counted as dropped, got %d", dropped)
+	}
+
+	// The Manager's own events don't fail the change they report
+	for i := 0; i < 5; i++ {
+		if _, err := manager.Consume(ctx, "user1", "api_calls", 1, goquota.PeriodTypeMonthly); err != nil {
+			t.Fatalf("Consume failed: %v", err)
+		}
+	}
+	if dropped := metrics.dropped.Load(); dropped != 2 {
+		t.Errorf("Expected the unsaved warning counted as dropped, got %d", dropped)
+	}
+}
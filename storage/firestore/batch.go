@@ -0,0 +1,64 @@
+package firestore
+
+import (
+	"context"
+	"fmt"
+
+	"cloud.google.com/go/firestore"
+
+	"github.com/mihaimyh/goquota/pkg/goquota"
+)
+
+// GetEntitlements implements goquota.BatchStorage using a single GetAll call
+func (s *Storage) GetEntitlements(ctx context.Context, userIDs []string) (map[string]*goquota.Entitlement, error) {
+	result := make(map[string]*goquota.Entitlement, len(userIDs))
+	if len(userIDs) == 0 {
+		return result, nil
+	}
+
+	refs := make([]*firestore.DocumentRef, len(userIDs))
+	for i, userID := range userIDs {
+		refs[i] = s.client.Collection(s.entitlementsCollection).Doc(userID)
+	}
+
+	snaps, err := s.client.GetAll(ctx, refs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get entitlements: %w", err)
+	}
+
+	// GetAll returns snapshots in the same order as refs; missing documents don't exist
+	for i, snap := range snaps {
+		if !snap.Exists() {
+			continue
+		}
+		result[userIDs[i]] = entitlementFromData(userIDs[i], snap.Data())
+	}
+	return result, nil
+}
+
+// GetUsages implements goquota.BatchStorage using a single GetAll call
+func (s *Storage) GetUsages(ctx context.Context, queries []goquota.UsageQuery) ([]*goquota.Usage, error) {
+	result := make([]*goquota.Usage, len(queries))
+	if len(queries) == 0 {
+		return result, nil
+	}
+
+	refs := make([]*firestore.DocumentRef, len(queries))
+	for i, q := range queries {
+		refs[i] = s.usageDoc(q.UserID, q.Resource, q.Period)
+	}
+
+	snaps, err := s.client.GetAll(ctx, refs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get usages: %w", err)
+	}
+
+	for i, snap := range snaps {
+		if !snap.Exists() {
+			continue
+		}
+		q := queries[i]
+		result[i] = usageFromData(q.UserID, q.Resource, q.Period, snap.Data())
+	}
+	return result, nil
+}
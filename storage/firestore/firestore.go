@@ -138,7 +138,11 @@ func (s *Storage) GetEntitlement(ctx context.Context, userID string) (*goquota.E
 		return nil, goquota.ErrEntitlementNotFound
 	}
 
-	data := snap.Data()
+	return entitlementFromData(userID, snap.Data()), nil
+}
+
+// entitlementFromData builds an Entitlement from an entitlement document
+func entitlementFromData(userID string, data map[string]interface{}) *goquota.Entitlement {
 	ent := &goquota.Entitlement{
 		UserID:                userID,
 		Tier:                  getString(data, "tier"),
@@ -150,7 +154,7 @@ func (s *Storage) GetEntitlement(ctx context.Context, userID string) (*goquota.E
 		ent.ExpiresAt = &expiresAt
 	}
 
-	return ent, nil
+	return ent
 }
 
 // SetEntitlement implements goquota.Storage
@@ -195,7 +199,11 @@ func (s *Storage) GetUsage(ctx context.Context, userID, resource string,
 		return nil, nil
 	}
 
-	data := snap.Data()
+	return usageFromData(userID, resource, period, snap.Data()), nil
+}
+
+// usageFromData builds a Usage from a usage period document
+func usageFromData(userID, resource string, period goquota.Period, data map[string]interface{}) *goquota.Usage {
 	usage := &goquota.Usage{
 		UserID:    userID,
 		Resource:  resource,
@@ -214,7 +222,7 @@ func (s *Storage) GetUsage(ctx context.Context, userID, resource string,
 		usage.Period.End = time.Date(9999, 12, 31, 23, 59, 59, 0, time.UTC)
 	}
 
-	return usage, nil
+	return usage
 }
 
 // ConsumeQuota implements goquota.Storage with transaction-safe consumption
@@ -273,6 +281,7 @@ func (s *Storage) ConsumeQuota(ctx context.Context, req *goquota.ConsumeRequest)
 			"limit":      currentLimit,
 			"cycleStart": req.Period.Start,
 			"cycleEnd":   req.Period.End,
+			"periodType": string(req.Period.Type),
 			"tier":       req.Tier,
 			"resource":   req.Resource,
 			"updatedAt":  now,
@@ -338,6 +347,7 @@ func (s *Storage) ApplyTierChange(ctx context.Context, req *goquota.TierChangeRe
 			"used":          currentUsed,
 			"cycleStart":    req.Period.Start,
 			"cycleEnd":      req.Period.End,
+			"periodType":    string(req.Period.Type),
 			"tier":          req.NewTier,
 			"previousTier":  req.OldTier,
 			"tierChangedAt": now,
@@ -359,6 +369,7 @@ func (s *Storage) SetUsage(ctx context.Context, userID, resource string,
 		"used":       usage.Used,
 		"limit":      usage.Limit,
 		"cycleStart": period.Start,
+		"periodType": string(period.Type),
 		"tier":       usage.Tier,
 		"resource":   resource,
 		"updatedAt":  usage.UpdatedAt,
@@ -850,6 +861,7 @@ func (s *Storage) AddLimit(
 			"limit":      newLimit,
 			"used":       currentUsed, // Preserve existing used
 			"cycleStart": period.Start,
+			"periodType": string(period.Type),
 			"tier":       "default",
 			"resource":   resource,
 			"updatedAt":  time.Now().UTC(),
@@ -0,0 +1,26 @@
+package firestore
+
+import (
+	"testing"
+
+	"github.com/mihaimyh/goquota/internal/storagetest"
+)
+
+func TestFirestore_BatchReads(t *testing.T) {
+	client := setupFirestoreClient(t)
+	defer client.Close()
+
+	entColl, usageColl := getTestCollections("batch_reads")
+
+	storage, err := New(client, Config{
+		EntitlementsCollection: entColl,
+		UsageCollection:        usageColl,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+
+	defer cleanupFirestore(t, client, entColl, usageColl)
+
+	storagetest.BatchReads(t, storage)
+}
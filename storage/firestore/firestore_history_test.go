@@ -0,0 +1,26 @@
+package firestore
+
+import (
+	"testing"
+
+	"github.com/mihaimyh/goquota/internal/storagetest"
+)
+
+func TestFirestore_GetUsageHistory(t *testing.T) {
+	client := setupFirestoreClient(t)
+	defer client.Close()
+
+	entColl, usageColl := getTestCollections("usage_history")
+
+	storage, err := New(client, Config{
+		EntitlementsCollection: entColl,
+		UsageCollection:        usageColl,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+
+	defer cleanupFirestore(t, client, entColl, usageColl)
+
+	storagetest.UsageHistory(t, storage)
+}
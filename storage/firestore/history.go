@@ -0,0 +1,180 @@
+package firestore
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"cloud.google.com/go/firestore/apiv1/firestorepb"
+
+	"github.com/mihaimyh/goquota/pkg/goquota"
+)
+
+// legacyHistoryBatch is the number of legacy usage documents read per query
+const legacyHistoryBatch = 100
+
+// storedPeriodTypes are the period types usage documents are stored with
+var storedPeriodTypes = []string{
+	string(goquota.PeriodTypeDaily),
+	string(goquota.PeriodTypeMonthly),
+	string(goquota.PeriodTypeForever),
+}
+
+// GetUsageHistory implements goquota.UsageHistoryStorage
+// Queries the user's periods subcollection by resource, period type and cycle start, paged by
+// Firestore. This requires composite indexes on the "periods" collection: resource ASC,
+// periodType ASC, cycleStart DESC and ASC, and for legacy documents resource ASC, cycleStart DESC.
+//
+// Documents written before periodType was stored (legacy documents) cannot be filtered by it:
+// they are older than every document that has it, so they are listed after them, read in
+// batches and classified by their cycle length.
+func (s *Storage) GetUsageHistory(ctx context.Context, query *goquota.UsageHistoryQuery) ([]*goquota.Usage, error) {
+	if query == nil {
+		return nil, fmt.Errorf("usage history query is required")
+	}
+
+	inRange := s.client.Collection(s.usageCollection).
+		Doc(query.UserID).
+		Collection("periods").
+		Where("resource", "==", query.Resource).
+		Where("cycleStart", ">=", query.From).
+		Where("cycleStart", "<", query.To)
+
+	typed := inRange.Where("periodType", "==", string(query.PeriodType))
+	page := typed.OrderBy("cycleStart", firestore.Desc).Offset(query.Offset)
+	if query.Limit > 0 {
+		page = page.Limit(query.Limit)
+	}
+	docs, err := page.Documents(ctx).GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to query usage history: %w", err)
+	}
+	records := make([]*goquota.Usage, 0, len(docs))
+	for _, doc := range docs {
+		records = append(records, historyUsage(query, doc.Data(), query.PeriodType))
+	}
+	if query.Limit > 0 && len(records) == query.Limit {
+		return records, nil
+	}
+
+	// The typed documents are exhausted: the offset left applies to legacy documents
+	legacyOffset := 0
+	if query.Offset > 0 {
+		typedCount := query.Offset + len(records)
+		if len(records) == 0 {
+			// The offset may be past the typed documents: count them
+			if typedCount, err = countDocuments(ctx, typed); err != nil {
+				return nil, err
+			}
+		}
+		legacyOffset = max(query.Offset-typedCount, 0)
+	}
+	legacyLimit := 0
+	if query.Limit > 0 {
+		legacyLimit = query.Limit - len(records)
+	}
+	legacy, err := s.legacyUsageHistory(ctx, inRange, query, legacyOffset, legacyLimit)
+	if err != nil {
+		return nil, err
+	}
+	return append(records, legacy...), nil
+}
+
+// legacyUsageHistory returns the legacy documents of a history query, newest first.
+// Only documents older than the oldest document with a periodType are read.
+func (s *Storage) legacyUsageHistory(ctx context.Context, inRange firestore.Query,
+	query *goquota.UsageHistoryQuery, offset, limit int) ([]*goquota.Usage, error) {
+	oldest, err := inRange.Where("periodType", "in", storedPeriodTypes).
+		OrderBy("cycleStart", firestore.Asc).
+		Limit(1).
+		Documents(ctx).
+		GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to query usage history: %w", err)
+	}
+	legacy := inRange
+	if len(oldest) > 0 {
+		legacy = legacy.Where("cycleStart", "<", getTime(oldest[0].Data(), "cycleStart"))
+	}
+	legacy = legacy.OrderBy("cycleStart", firestore.Desc).Limit(legacyHistoryBatch)
+
+	records := []*goquota.Usage{}
+	skipped := 0
+	var last *firestore.DocumentSnapshot
+	for {
+		batch := legacy
+		if last != nil {
+			batch = batch.StartAfter(last)
+		}
+		docs, err := batch.Documents(ctx).GetAll()
+		if err != nil {
+			return nil, fmt.Errorf("failed to query usage history: %w", err)
+		}
+		for _, doc := range docs {
+			data := doc.Data()
+			periodType := usagePeriodType(data)
+			if periodType != query.PeriodType {
+				continue
+			}
+			if skipped < offset {
+				skipped++
+				continue
+			}
+			records = append(records, historyUsage(query, data, periodType))
+			if limit > 0 && len(records) == limit {
+				return records, nil
+			}
+		}
+		if len(docs) < legacyHistoryBatch {
+			return records, nil
+		}
+		last = docs[len(docs)-1]
+	}
+}
+
+// countDocuments returns the number of documents matching q
+func countDocuments(ctx context.Context, q firestore.Query) (int, error) {
+	result, err := q.NewAggregationQuery().WithCount("count").Get(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count usage history: %w", err)
+	}
+	count, ok := result["count"].(*firestorepb.Value)
+	if !ok {
+		return 0, fmt.Errorf("failed to count usage history: unexpected result %v", result["count"])
+	}
+	return int(count.GetIntegerValue()), nil
+}
+
+// historyUsage converts a usage document of a history query
+func historyUsage(query *goquota.UsageHistoryQuery, data map[string]interface{},
+	periodType goquota.PeriodType) *goquota.Usage {
+	return &goquota.Usage{
+		UserID:   query.UserID,
+		Resource: query.Resource,
+		Used:     getInt(data, "used"),
+		Limit:    getInt(data, "limit"),
+		Period: goquota.Period{
+			Start: getTime(data, "cycleStart"),
+			End:   getTime(data, "cycleEnd"),
+			Type:  periodType,
+		},
+		Tier:      getString(data, "tier"),
+		UpdatedAt: getTime(data, "updatedAt"),
+	}
+}
+
+// usagePeriodType returns the period type of a usage document
+func usagePeriodType(data map[string]interface{}) goquota.PeriodType {
+	if periodType := getString(data, "periodType"); periodType != "" {
+		return goquota.PeriodType(periodType)
+	}
+	cycleEnd := getTime(data, "cycleEnd")
+	if cycleEnd.IsZero() {
+		return goquota.PeriodTypeForever
+	}
+	if cycleEnd.Sub(getTime(data, "cycleStart")) <= 24*time.Hour {
+		return goquota.PeriodTypeDaily
+	}
+	return goquota.PeriodTypeMonthly
+}
@@ -0,0 +1,25 @@
+package firestore
+
+import (
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/mihaimyh/goquota/pkg/goquota"
+)
+
+// IsRetryable implements goquota.RetryClassifier
+// Unavailable, Aborted (transaction contention) and ResourceExhausted errors are retryable.
+func (s *Storage) IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	if goquota.IsTransientError(err) {
+		return true
+	}
+	switch status.Code(err) {
+	case codes.Unavailable, codes.Aborted, codes.ResourceExhausted:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,37 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/mihaimyh/goquota/pkg/goquota"
+)
+
+// GetEntitlements implements goquota.BatchStorage
+func (s *Storage) GetEntitlements(_ context.Context, userIDs []string) (map[string]*goquota.Entitlement, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make(map[string]*goquota.Entitlement, len(userIDs))
+	for _, userID := range userIDs {
+		if ent, ok := s.entitlements[userID]; ok {
+			entCopy := *ent
+			result[userID] = &entCopy
+		}
+	}
+	return result, nil
+}
+
+// GetUsages implements goquota.BatchStorage
+func (s *Storage) GetUsages(_ context.Context, queries []goquota.UsageQuery) ([]*goquota.Usage, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]*goquota.Usage, len(queries))
+	for i, q := range queries {
+		if usage, ok := s.usage[usageKey(q.UserID, q.Resource, q.Period)]; ok {
+			usageCopy := *usage
+			result[i] = &usageCopy
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,57 @@
+package memory
+
+import (
+	"context"
+	"time"
+)
+
+// cacheSweepInterval is how often SetCacheEntry removes expired cache entries
+const cacheSweepInterval = time.Minute
+
+// cacheEntry is an entry of the shared cache tier
+type cacheEntry struct {
+	value     []byte
+	expiresAt time.Time
+}
+
+// GetCacheEntry implements goquota.SharedCacheStore
+func (s *Storage) GetCacheEntry(_ context.Context, key string) ([]byte, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entry, ok := s.cacheEntries[key]
+	if !ok || !time.Now().Before(entry.expiresAt) {
+		return nil, false, nil
+	}
+	return append([]byte(nil), entry.value...), true, nil
+}
+
+// SetCacheEntry implements goquota.SharedCacheStore
+func (s *Storage) SetCacheEntry(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	// Drop expired entries now and then, so the map doesn't grow without bound
+	if now.Sub(s.cacheSweptAt) >= cacheSweepInterval {
+		s.cacheSweptAt = now
+		for k, entry := range s.cacheEntries {
+			if !now.Before(entry.expiresAt) {
+				delete(s.cacheEntries, k)
+			}
+		}
+	}
+	s.cacheEntries[key] = &cacheEntry{value: append([]byte(nil), value...), expiresAt: now.Add(ttl)}
+	return nil
+}
+
+// DeleteCacheEntries implements goquota.SharedCacheStore
+func (s *Storage) DeleteCacheEntries(_ context.Context, keys ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, key := range keys {
+		delete(s.cacheEntries, key)
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mihaimyh/goquota/pkg/goquota"
+)
+
+// circuitBreakerState is the shared state of a circuit breaker
+type circuitBreakerState struct {
+	open        bool
+	openUntil   time.Time
+	windowStart time.Time
+	failures    int
+	probes      int
+	probeUntil  time.Time
+}
+
+func (b *circuitBreakerState) status() *goquota.CircuitBreakerStatus {
+	return &goquota.CircuitBreakerStatus{Open: b.open, OpenUntil: b.openUntil, Failures: b.failures}
+}
+
+// GetCircuitBreakerStatus implements goquota.CircuitBreakerStore
+func (s *Storage) GetCircuitBreakerStatus(_ context.Context, name string) (*goquota.CircuitBreakerStatus, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	breaker, ok := s.breakers[name]
+	if !ok {
+		return &goquota.CircuitBreakerStatus{}, nil
+	}
+	return breaker.status(), nil
+}
+
+// RecordCircuitBreakerFailure implements goquota.CircuitBreakerStore
+func (s *Storage) RecordCircuitBreakerFailure(
+	_ context.Context, req *goquota.CircuitBreakerRequest,
+) (*goquota.CircuitBreakerStatus, error) {
+	if req == nil {
+		return nil, fmt.Errorf("circuit breaker request is required")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	breaker := s.breakers[req.Name]
+	if breaker == nil {
+		breaker = &circuitBreakerState{}
+		s.breakers[req.Name] = breaker
+	}
+
+	if breaker.open {
+		if !req.Now.Before(breaker.openUntil) {
+			// A half-open probe failed: reopen
+			breaker.openUntil = req.Now.Add(req.ResetTimeout)
+			breaker.probes = 0
+		}
+		return breaker.status(), nil
+	}
+
+	if req.Now.Sub(breaker.windowStart) >= req.FailureWindow {
+		breaker.windowStart = req.Now
+		breaker.failures = 0
+	}
+	breaker.failures++
+	if breaker.failures >= req.FailureThreshold {
+		breaker.open = true
+		breaker.openUntil = req.Now.Add(req.ResetTimeout)
+		breaker.failures = 0
+		breaker.probes = 0
+	}
+	return breaker.status(), nil
+}
+
+// RecordCircuitBreakerSuccess implements goquota.CircuitBreakerStore
+func (s *Storage) RecordCircuitBreakerSuccess(
+	_ context.Context, req *goquota.CircuitBreakerRequest,
+) (*goquota.CircuitBreakerStatus, error) {
+	if req == nil {
+		return nil, fmt.Errorf("circuit breaker request is required")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	breaker, ok := s.breakers[req.Name]
+	if !ok {
+		return &goquota.CircuitBreakerStatus{}, nil
+	}
+	if !breaker.open || !req.Now.Before(breaker.openUntil) {
+		// Closes a half-open breaker, or resets the failures of a closed one
+		delete(s.breakers, req.Name)
+		return &goquota.CircuitBreakerStatus{}, nil
+	}
+	return breaker.status(), nil
+}
+
+// AcquireCircuitBreakerProbe implements goquota.CircuitBreakerStore
+func (s *Storage) AcquireCircuitBreakerProbe(_ context.Context, req *goquota.CircuitBreakerRequest) (bool, error) {
+	if req == nil {
+		return false, fmt.Errorf("circuit breaker request is required")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	breaker, ok := s.breakers[req.Name]
+	if !ok || !breaker.open {
+		return true, nil
+	}
+	if req.Now.Before(breaker.openUntil) {
+		return false, nil
+	}
+	if !req.Now.Before(breaker.probeUntil) {
+		breaker.probes = 0
+	}
+	if breaker.probes >= req.MaxProbes {
+		return false, nil
+	}
+	breaker.probes++
+	breaker.probeUntil = req.Now.Add(req.ResetTimeout)
+	return true, nil
+}
@@ -0,0 +1,81 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mihaimyh/goquota/pkg/goquota"
+)
+
+// AcquireLease implements goquota.ConcurrencyLimiter
+func (s *Storage) AcquireLease(_ context.Context, req *goquota.LeaseRequest) (bool, int, error) {
+	if req == nil {
+		return false, 0, fmt.Errorf("lease request is required")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := rateLimitKey(req.UserID, req.Resource)
+	leases := s.pruneLeases(key, req.Now)
+
+	if _, exists := leases[req.LeaseID]; !exists && len(leases) >= req.MaxConcurrent {
+		return false, len(leases), nil
+	}
+
+	if leases == nil {
+		leases = make(map[string]time.Time)
+		s.leases[key] = leases
+	}
+	leases[req.LeaseID] = req.Now.Add(req.TTL)
+
+	return true, len(leases), nil
+}
+
+// RenewLease implements goquota.ConcurrencyLimiter
+func (s *Storage) RenewLease(_ context.Context, req *goquota.LeaseRequest) (bool, error) {
+	if req == nil {
+		return false, fmt.Errorf("lease request is required")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	leases := s.pruneLeases(rateLimitKey(req.UserID, req.Resource), req.Now)
+	if _, exists := leases[req.LeaseID]; !exists {
+		return false, nil
+	}
+	leases[req.LeaseID] = req.Now.Add(req.TTL)
+	return true, nil
+}
+
+// ReleaseLease implements goquota.ConcurrencyLimiter
+func (s *Storage) ReleaseLease(_ context.Context, userID, resource, leaseID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := rateLimitKey(userID, resource)
+	if leases, ok := s.leases[key]; ok {
+		delete(leases, leaseID)
+		if len(leases) == 0 {
+			delete(s.leases, key)
+		}
+	}
+	return nil
+}
+
+// pruneLeases removes expired leases for a key and returns the remaining ones.
+// Caller must hold s.mu.
+func (s *Storage) pruneLeases(key string, now time.Time) map[string]time.Time {
+	leases, ok := s.leases[key]
+	if !ok {
+		return nil
+	}
+	for id, expiresAt := range leases {
+		if !expiresAt.After(now) {
+			delete(leases, id)
+		}
+	}
+	return leases
+}
@@ -0,0 +1,50 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/mihaimyh/goquota/pkg/goquota"
+)
+
+// GetUsageHistory implements goquota.UsageHistoryStorage
+func (s *Storage) GetUsageHistory(_ context.Context, query *goquota.UsageHistoryQuery) ([]*goquota.Usage, error) {
+	if query == nil {
+		return nil, fmt.Errorf("usage history query is required")
+	}
+
+	s.mu.RLock()
+	var records []*goquota.Usage
+	for _, usage := range s.usage {
+		if usage.UserID != query.UserID || usage.Resource != query.Resource ||
+			usage.Period.Type != query.PeriodType {
+			continue
+		}
+		if usage.Period.Start.Before(query.From) || !usage.Period.Start.Before(query.To) {
+			continue
+		}
+		usageCopy := *usage
+		records = append(records, &usageCopy)
+	}
+	s.mu.RUnlock()
+
+	// Newest first
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].Period.Start.After(records[j].Period.Start)
+	})
+
+	return paginate(records, query.Offset, query.Limit), nil
+}
+
+// paginate applies offset and limit (0 = no limit) to a slice
+func paginate[T any](items []T, offset, limit int) []T {
+	if offset >= len(items) {
+		return []T{}
+	}
+	items = items[offset:]
+	if limit > 0 && limit < len(items) {
+		items = items[:limit]
+	}
+	return items
+}
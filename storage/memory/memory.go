@@ -39,6 +39,15 @@ type Storage struct {
 	topUps         map[string]bool                       // keyed by idempotency key (for idempotency checks)
 	tokenBuckets   map[string]*tokenBucketState          // keyed by userID:resource
 	slidingWindows map[string]*slidingWindowState        // keyed by userID:resource
+	leases         map[string]map[string]time.Time       // keyed by userID:resource, then lease ID
+	snapshots      map[string][]*goquota.UsageSnapshot   // keyed by userID:resource:period, ordered by bucket
+	webhooks       map[string]*goquota.WebhookDelivery   // keyed by delivery ID
+	warnings       map[string]map[float64]bool           // notified thresholds keyed by userID:resource:period
+	statements     map[string][]*goquota.Statement       // keyed by userID, ordered by period start descending
+	overrides      map[string]*goquota.Override          // keyed by resource and tier
+	breakers       map[string]*circuitBreakerState       // keyed by breaker name
+	cacheEntries   map[string]*cacheEntry                // shared cache entries keyed by cache key
+	cacheSweptAt   time.Time                             // last removal of expired cache entries
 }
 
 // Now returns the current time.
@@ -58,6 +67,14 @@ func New() *Storage {
 		topUps:         make(map[string]bool),
 		tokenBuckets:   make(map[string]*tokenBucketState),
 		slidingWindows: make(map[string]*slidingWindowState),
+		leases:         make(map[string]map[string]time.Time),
+		snapshots:      make(map[string][]*goquota.UsageSnapshot),
+		webhooks:       make(map[string]*goquota.WebhookDelivery),
+		warnings:       make(map[string]map[float64]bool),
+		statements:     make(map[string][]*goquota.Statement),
+		overrides:      make(map[string]*goquota.Override),
+		breakers:       make(map[string]*circuitBreakerState),
+		cacheEntries:   make(map[string]*cacheEntry),
 	}
 }
 
@@ -170,6 +187,37 @@ func (s *Storage) ConsumeQuota(_ context.Context, req *goquota.ConsumeRequest) (
 	return newUsed, nil
 }
 
+// ApplyUsageDelta implements goquota.UsageDeltaStore
+func (s *Storage) ApplyUsageDelta(_ context.Context, req *goquota.UsageDeltaRequest) error {
+	if req.Amount < 0 {
+		return goquota.ErrInvalidAmount
+	}
+	if req.Amount == 0 {
+		return nil // No-op
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := usageKey(req.UserID, req.Resource, req.Period)
+	if usage, ok := s.usage[key]; ok {
+		usage.Used += req.Amount
+		usage.UpdatedAt = time.Now().UTC()
+		return nil
+	}
+
+	s.usage[key] = &goquota.Usage{
+		UserID:    req.UserID,
+		Resource:  req.Resource,
+		Used:      req.Amount,
+		Limit:     req.Limit,
+		Period:    req.Period,
+		Tier:      req.Tier,
+		UpdatedAt: time.Now().UTC(),
+	}
+	return nil
+}
+
 // ApplyTierChange implements goquota.Storage
 func (s *Storage) ApplyTierChange(_ context.Context, req *goquota.TierChangeRequest) error {
 	s.mu.Lock()
@@ -222,6 +270,42 @@ func (s *Storage) SetUsage(_ context.Context, userID, resource string,
 	return nil
 }
 
+// SeedEntitlement implements goquota.SeedStore
+func (s *Storage) SeedEntitlement(_ context.Context, ent *goquota.Entitlement) (bool, error) {
+	if ent == nil || ent.UserID == "" {
+		return false, fmt.Errorf("invalid entitlement")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.entitlements[ent.UserID]; ok {
+		return false, nil
+	}
+	entCopy := *ent
+	s.entitlements[ent.UserID] = &entCopy
+	return true, nil
+}
+
+// SeedUsage implements goquota.SeedStore
+func (s *Storage) SeedUsage(_ context.Context, userID, resource string,
+	usage *goquota.Usage, period goquota.Period) (bool, error) {
+	if usage == nil {
+		return false, fmt.Errorf("usage is required")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := usageKey(userID, resource, period)
+	if _, ok := s.usage[key]; ok {
+		return false, nil
+	}
+	usageCopy := *usage
+	s.usage[key] = &usageCopy
+	return true, nil
+}
+
 // RefundQuota implements goquota.Storage
 func (s *Storage) RefundQuota(_ context.Context, req *goquota.RefundRequest) error {
 	if req.Amount < 0 {
@@ -497,6 +581,14 @@ func (s *Storage) Clear(_ context.Context) error {
 	s.topUps = make(map[string]bool)
 	s.tokenBuckets = make(map[string]*tokenBucketState)
 	s.slidingWindows = make(map[string]*slidingWindowState)
+	s.leases = make(map[string]map[string]time.Time)
+	s.snapshots = make(map[string][]*goquota.UsageSnapshot)
+	s.webhooks = make(map[string]*goquota.WebhookDelivery)
+	s.warnings = make(map[string]map[float64]bool)
+	s.statements = make(map[string][]*goquota.Statement)
+	s.overrides = make(map[string]*goquota.Override)
+	s.breakers = make(map[string]*circuitBreakerState)
+	s.cacheEntries = make(map[string]*cacheEntry)
 	return nil
 }
 
@@ -0,0 +1,40 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mihaimyh/goquota/pkg/goquota"
+)
+
+var _ goquota.SharedCacheStore = (*Storage)(nil)
+
+func TestStorage_CacheEntries(t *testing.T) {
+	storage := New()
+	ctx := context.Background()
+
+	if err := storage.SetCacheEntry(ctx, "usage:a", []byte("1"), time.Minute); err != nil {
+		t.Fatalf("SetCacheEntry failed: %v", err)
+	}
+	if err := storage.SetCacheEntry(ctx, "usage:b", []byte("2"), time.Millisecond); err != nil {
+		t.Fatalf("SetCacheEntry failed: %v", err)
+	}
+
+	value, ok, err := storage.GetCacheEntry(ctx, "usage:a")
+	if err != nil || !ok || string(value) != "1" {
+		t.Errorf("Expected cached value 1, got %q, %v, %v", value, ok, err)
+	}
+
+	time.Sleep(5 * time.Millisecond)
+	if _, ok, _ := storage.GetCacheEntry(ctx, "usage:b"); ok {
+		t.Error("Expected expired entry to be missing")
+	}
+
+	if err := storage.DeleteCacheEntries(ctx, "usage:a", "usage:missing"); err != nil {
+		t.Fatalf("DeleteCacheEntries failed: %v", err)
+	}
+	if _, ok, _ := storage.GetCacheEntry(ctx, "usage:a"); ok {
+		t.Error("Expected deleted entry to be missing")
+	}
+}
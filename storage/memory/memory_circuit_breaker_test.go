@@ -0,0 +1,103 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mihaimyh/goquota/pkg/goquota"
+)
+
+func TestStorage_CircuitBreaker(t *testing.T) {
+	storage := New()
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	req := func(at time.Time) *goquota.CircuitBreakerRequest {
+		return &goquota.CircuitBreakerRequest{
+			Name:             "storage",
+			Now:              at,
+			FailureThreshold: 3,
+			FailureWindow:    time.Minute,
+			ResetTimeout:     10 * time.Second,
+			MaxProbes:        2,
+		}
+	}
+
+	// Failures outside the window don't count towards the threshold
+	later := now.Add(2 * time.Minute)
+	for _, at := range []time.Time{now, now.Add(time.Second), later, later.Add(time.Second)} {
+		status, err := storage.RecordCircuitBreakerFailure(ctx, req(at))
+		if err != nil {
+			t.Fatalf("RecordCircuitBreakerFailure failed: %v", err)
+		}
+		if status.Open {
+			t.Fatalf("Expected breaker to stay closed, got %+v", status)
+		}
+	}
+
+	opened := later.Add(2 * time.Second)
+	status, err := storage.RecordCircuitBreakerFailure(ctx, req(opened))
+	if err != nil {
+		t.Fatalf("RecordCircuitBreakerFailure failed: %v", err)
+	}
+	if !status.Open || !status.OpenUntil.Equal(opened.Add(10*time.Second)) {
+		t.Fatalf("Expected breaker open until %v, got %+v", opened.Add(10*time.Second), status)
+	}
+
+	status, err = storage.GetCircuitBreakerStatus(ctx, "storage")
+	if err != nil {
+		t.Fatalf("GetCircuitBreakerStatus failed: %v", err)
+	}
+	if !status.Open || !status.OpenUntil.Equal(opened.Add(10*time.Second)) {
+		t.Errorf("Expected shared open status, got %+v", status)
+	}
+
+	// No probes while open; at most MaxProbes once half-open
+	if acquired, err := storage.AcquireCircuitBreakerProbe(ctx, req(opened.Add(time.Second))); err != nil || acquired {
+		t.Errorf("Expected no probe while open, got acquired=%v err=%v", acquired, err)
+	}
+	halfOpen := opened.Add(11 * time.Second)
+	for i, want := range []bool{true, true, false} {
+		acquired, err := storage.AcquireCircuitBreakerProbe(ctx, req(halfOpen))
+		if err != nil {
+			t.Fatalf("AcquireCircuitBreakerProbe failed: %v", err)
+		}
+		if acquired != want {
+			t.Errorf("Probe %d: expected acquired=%v, got %v", i, want, acquired)
+		}
+	}
+
+	// A failed probe reopens the breaker
+	status, err = storage.RecordCircuitBreakerFailure(ctx, req(halfOpen))
+	if err != nil {
+		t.Fatalf("RecordCircuitBreakerFailure failed: %v", err)
+	}
+	if !status.Open || !status.OpenUntil.Equal(halfOpen.Add(10*time.Second)) {
+		t.Errorf("Expected breaker reopened until %v, got %+v", halfOpen.Add(10*time.Second), status)
+	}
+
+	// A successful probe closes it
+	recovered := halfOpen.Add(11 * time.Second)
+	if acquired, err := storage.AcquireCircuitBreakerProbe(ctx, req(recovered)); err != nil || !acquired {
+		t.Fatalf("Expected probe after reopening, got acquired=%v err=%v", acquired, err)
+	}
+	status, err = storage.RecordCircuitBreakerSuccess(ctx, req(recovered))
+	if err != nil {
+		t.Fatalf("RecordCircuitBreakerSuccess failed: %v", err)
+	}
+	if status.Open || status.Failures != 0 {
+		t.Errorf("Expected breaker closed, got %+v", status)
+	}
+	if acquired, err := storage.AcquireCircuitBreakerProbe(ctx, req(recovered)); err != nil || !acquired {
+		t.Errorf("Expected closed breaker to allow calls, got acquired=%v err=%v", acquired, err)
+	}
+
+	// A success resets the failures of a closed breaker
+	if _, err := storage.RecordCircuitBreakerFailure(ctx, req(recovered)); err != nil {
+		t.Fatalf("RecordCircuitBreakerFailure failed: %v", err)
+	}
+	status, err = storage.RecordCircuitBreakerSuccess(ctx, req(recovered))
+	if err != nil || status.Open || status.Failures != 0 {
+		t.Errorf("Expected failures reset, got %+v (%v)", status, err)
+	}
+}
@@ -0,0 +1,104 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mihaimyh/goquota/pkg/goquota"
+)
+
+func TestStorage_AcquireLease(t *testing.T) {
+	storage := New()
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	req := func(id string, at time.Time) *goquota.LeaseRequest {
+		return &goquota.LeaseRequest{
+			UserID:        "user1",
+			Resource:      "renders",
+			LeaseID:       id,
+			MaxConcurrent: 2,
+			TTL:           time.Minute,
+			Now:           at,
+		}
+	}
+
+	for i, id := range []string{"a", "b"} {
+		acquired, active, err := storage.AcquireLease(ctx, req(id, now))
+		if err != nil {
+			t.Fatalf("AcquireLease failed: %v", err)
+		}
+		if !acquired || active != i+1 {
+			t.Errorf("Expected acquired with %d active, got acquired=%v active=%d", i+1, acquired, active)
+		}
+	}
+
+	acquired, active, err := storage.AcquireLease(ctx, req("c", now))
+	if err != nil {
+		t.Fatalf("AcquireLease failed: %v", err)
+	}
+	if acquired || active != 2 {
+		t.Errorf("Expected denial with 2 active, got acquired=%v active=%d", acquired, active)
+	}
+
+	// Re-acquiring an existing lease does not consume another slot
+	acquired, _, err = storage.AcquireLease(ctx, req("a", now))
+	if err != nil || !acquired {
+		t.Errorf("Expected existing lease to be re-acquired, got acquired=%v err=%v", acquired, err)
+	}
+
+	// Expired leases are reclaimed
+	acquired, active, err = storage.AcquireLease(ctx, req("c", now.Add(2*time.Minute)))
+	if err != nil {
+		t.Fatalf("AcquireLease failed: %v", err)
+	}
+	if !acquired || active != 1 {
+		t.Errorf("Expected expired leases to be reclaimed, got acquired=%v active=%d", acquired, active)
+	}
+}
+
+func TestStorage_RenewAndReleaseLease(t *testing.T) {
+	storage := New()
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	req := &goquota.LeaseRequest{
+		UserID:        "user1",
+		Resource:      "renders",
+		LeaseID:       "a",
+		MaxConcurrent: 1,
+		TTL:           time.Minute,
+		Now:           now,
+	}
+	if _, _, err := storage.AcquireLease(ctx, req); err != nil {
+		t.Fatalf("AcquireLease failed: %v", err)
+	}
+
+	renewReq := *req
+	renewReq.Now = now.Add(30 * time.Second)
+	renewed, err := storage.RenewLease(ctx, &renewReq)
+	if err != nil || !renewed {
+		t.Fatalf("Expected renewal, got renewed=%v err=%v", renewed, err)
+	}
+
+	// Past the original expiry but within the renewed one
+	renewReq.Now = now.Add(80 * time.Second)
+	renewed, err = storage.RenewLease(ctx, &renewReq)
+	if err != nil || !renewed {
+		t.Errorf("Expected renewed lease to still be valid, got renewed=%v err=%v", renewed, err)
+	}
+
+	if err := storage.ReleaseLease(ctx, "user1", "renders", "a"); err != nil {
+		t.Fatalf("ReleaseLease failed: %v", err)
+	}
+	renewed, err = storage.RenewLease(ctx, &renewReq)
+	if err != nil || renewed {
+		t.Errorf("Expected released lease not to renew, got renewed=%v err=%v", renewed, err)
+	}
+
+	// Releasing an unknown lease is not an error
+	if err := storage.ReleaseLease(ctx, "user1", "renders", "missing"); err != nil {
+		t.Errorf("Expected no error releasing unknown lease, got %v", err)
+	}
+}
@@ -0,0 +1,11 @@
+package memory
+
+import (
+	"testing"
+
+	"github.com/mihaimyh/goquota/internal/storagetest"
+)
+
+func TestStorage_GetUsageHistory(t *testing.T) {
+	storagetest.UsageHistory(t, New())
+}
@@ -0,0 +1,11 @@
+package memory
+
+import (
+	"testing"
+
+	"github.com/mihaimyh/goquota/internal/storagetest"
+)
+
+func TestStorage_UsageSnapshots(t *testing.T) {
+	storagetest.UsageSnapshots(t, New())
+}
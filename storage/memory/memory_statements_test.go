@@ -0,0 +1,11 @@
+package memory
+
+import (
+	"testing"
+
+	"github.com/mihaimyh/goquota/internal/storagetest"
+)
+
+func TestStorage_Statements(t *testing.T) {
+	storagetest.Statements(t, New())
+}
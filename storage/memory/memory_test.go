@@ -6,6 +6,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/mihaimyh/goquota/internal/storagetest"
 	"github.com/mihaimyh/goquota/pkg/goquota"
 )
 
@@ -229,6 +230,53 @@ func TestStorage_ConsumeQuota_NegativeAmount(t *testing.T) {
 	}
 }
 
+func TestStorage_ApplyUsageDelta(t *testing.T) {
+	storage := New()
+	ctx := context.Background()
+
+	period := goquota.Period{
+		Start: time.Now().UTC(),
+		End:   time.Now().UTC().Add(24 * time.Hour),
+		Type:  goquota.PeriodTypeDaily,
+	}
+
+	req := &goquota.UsageDeltaRequest{
+		UserID:   "user1",
+		Resource: "api_calls",
+		Amount:   60,
+		Tier:     "scholar",
+		Period:   period,
+		Limit:    100,
+	}
+
+	// Deltas are not checked against the limit
+	for i := 0; i < 2; i++ {
+		if err := storage.ApplyUsageDelta(ctx, req); err != nil {
+			t.Fatalf("ApplyUsageDelta failed: %v", err)
+		}
+	}
+
+	usage, err := storage.GetUsage(ctx, "user1", "api_calls", period)
+	if err != nil {
+		t.Fatalf("GetUsage failed: %v", err)
+	}
+	if usage.Used != 120 {
+		t.Errorf("Expected 120 used, got %d", usage.Used)
+	}
+	if usage.Limit != 100 || usage.Tier != "scholar" {
+		t.Errorf("Expected limit 100 and tier scholar, got %d and %s", usage.Limit, usage.Tier)
+	}
+
+	req.Amount = -1
+	if err := storage.ApplyUsageDelta(ctx, req); err != goquota.ErrInvalidAmount {
+		t.Errorf("Expected ErrInvalidAmount, got %v", err)
+	}
+}
+
+func TestStorage_Seed(t *testing.T) {
+	storagetest.Seed(t, New())
+}
+
 func TestStorage_ConsumeQuota_WithIdempotencyKey_Success(t *testing.T) {
 	storage := New()
 	ctx := context.Background()
@@ -1681,3 +1729,7 @@ func TestStorage_ConcurrentSameIdempotencyKey(t *testing.T) {
 		t.Errorf("Expected 1 used (idempotent), got %d", usage.Used)
 	}
 }
+
+func TestStorage_ScanRecords(t *testing.T) {
+	storagetest.ScanRecords(t, New(), true)
+}
@@ -0,0 +1,53 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mihaimyh/goquota/pkg/goquota"
+)
+
+// SaveOverride implements goquota.OverrideStore
+func (s *Storage) SaveOverride(_ context.Context, override *goquota.Override) error {
+	if override == nil {
+		return fmt.Errorf("override is required")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.overrides[overrideKey(override.Resource, override.Tier)] = copyOverride(override)
+	return nil
+}
+
+// DeleteOverride implements goquota.OverrideStore
+func (s *Storage) DeleteOverride(_ context.Context, resource, tier string) (bool, error) {
+	key := overrideKey(resource, tier)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.overrides[key]
+	delete(s.overrides, key)
+	return ok, nil
+}
+
+// ListOverrides implements goquota.OverrideStore
+func (s *Storage) ListOverrides(_ context.Context) ([]*goquota.Override, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	overrides := make([]*goquota.Override, 0, len(s.overrides))
+	for _, override := range s.overrides {
+		overrides = append(overrides, copyOverride(override))
+	}
+	return overrides, nil
+}
+
+func overrideKey(resource, tier string) string {
+	return resource + "\x00" + tier
+}
+
+func copyOverride(override *goquota.Override) *goquota.Override {
+	overrideCopy := *override
+	overrideCopy.EnforceTiers = append([]string(nil), override.EnforceTiers...)
+	return &overrideCopy
+}
@@ -0,0 +1,56 @@
+package memory
+
+import (
+	"context"
+	"slices"
+
+	"github.com/mihaimyh/goquota/pkg/goquota"
+)
+
+// ScanEntitlements implements goquota.RecordScanner.
+// Entitlements are returned in user ID order; the cursor is the last user ID returned.
+func (s *Storage) ScanEntitlements(
+	_ context.Context, cursor string, limit int,
+) ([]*goquota.Entitlement, string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	keys, next := scanPage(s.entitlements, cursor, limit)
+	result := make([]*goquota.Entitlement, len(keys))
+	for i, key := range keys {
+		entCopy := *s.entitlements[key]
+		result[i] = &entCopy
+	}
+	return result, next, nil
+}
+
+// ScanUsage implements goquota.RecordScanner.
+// Usage records are returned in key order; the cursor is the last key returned.
+func (s *Storage) ScanUsage(_ context.Context, cursor string, limit int) ([]*goquota.Usage, string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	keys, next := scanPage(s.usage, cursor, limit)
+	result := make([]*goquota.Usage, len(keys))
+	for i, key := range keys {
+		usageCopy := *s.usage[key]
+		result[i] = &usageCopy
+	}
+	return result, next, nil
+}
+
+// scanPage returns the first limit keys of m after cursor in sorted order,
+// and the cursor of the next page ("" if there is none)
+func scanPage[V any](m map[string]V, cursor string, limit int) (keys []string, next string) {
+	for key := range m {
+		if cursor == "" || key > cursor {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+	if limit <= 0 || len(keys) <= limit {
+		return keys, ""
+	}
+	keys = keys[:limit]
+	return keys, keys[limit-1]
+}
@@ -0,0 +1,59 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/mihaimyh/goquota/pkg/goquota"
+)
+
+// RecordUsageSnapshot implements goquota.UsageSnapshotStore
+func (s *Storage) RecordUsageSnapshot(_ context.Context, snapshot *goquota.UsageSnapshot) error {
+	if snapshot == nil {
+		return fmt.Errorf("usage snapshot is required")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := snapshotKey(snapshot.UserID, snapshot.Resource, snapshot.Period)
+	snapshots := s.snapshots[key]
+	for i, existing := range snapshots {
+		if existing.Bucket.Equal(snapshot.Bucket) {
+			if snapshot.Used >= existing.Used {
+				snapCopy := *snapshot
+				snapshots[i] = &snapCopy
+			}
+			return nil
+		}
+	}
+
+	snapCopy := *snapshot
+	snapshots = append(snapshots, &snapCopy)
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].Bucket.Before(snapshots[j].Bucket)
+	})
+	s.snapshots[key] = snapshots
+	return nil
+}
+
+// GetUsageSnapshots implements goquota.UsageSnapshotStore
+func (s *Storage) GetUsageSnapshots(
+	_ context.Context, userID, resource string, period goquota.Period,
+) ([]*goquota.UsageSnapshot, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	snapshots := s.snapshots[snapshotKey(userID, resource, period)]
+	result := make([]*goquota.UsageSnapshot, 0, len(snapshots))
+	for _, snap := range snapshots {
+		snapCopy := *snap
+		result = append(result, &snapCopy)
+	}
+	return result, nil
+}
+
+func snapshotKey(userID, resource string, period goquota.Period) string {
+	return fmt.Sprintf("%s:%s:%s:%s", userID, resource, period.Type, period.Key())
+}
@@ -0,0 +1,51 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/mihaimyh/goquota/pkg/goquota"
+)
+
+// CreateStatement implements goquota.StatementStore
+func (s *Storage) CreateStatement(_ context.Context, statement *goquota.Statement) (bool, error) {
+	if statement == nil {
+		return false, fmt.Errorf("statement is required")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	statements := s.statements[statement.UserID]
+	for _, existing := range statements {
+		if existing.Period.Type == statement.Period.Type && existing.Period.Start.Equal(statement.Period.Start) {
+			return false, nil
+		}
+	}
+
+	statements = append(statements, copyStatement(statement))
+	sort.Slice(statements, func(i, j int) bool {
+		return statements[i].Period.Start.After(statements[j].Period.Start)
+	})
+	s.statements[statement.UserID] = statements
+	return true, nil
+}
+
+// GetStatements implements goquota.StatementStore
+func (s *Storage) GetStatements(_ context.Context, userID string, limit, offset int) ([]*goquota.Statement, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	statements := make([]*goquota.Statement, 0, len(s.statements[userID]))
+	for _, statement := range s.statements[userID] {
+		statements = append(statements, copyStatement(statement))
+	}
+	return paginate(statements, offset, limit), nil
+}
+
+func copyStatement(statement *goquota.Statement) *goquota.Statement {
+	statementCopy := *statement
+	statementCopy.Lines = append([]goquota.StatementLine(nil), statement.Lines...)
+	return &statementCopy
+}
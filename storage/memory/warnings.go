@@ -0,0 +1,67 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/mihaimyh/goquota/pkg/goquota"
+)
+
+// GetNotifiedWarnings implements goquota.WarningStateStore
+func (s *Storage) GetNotifiedWarnings(
+	_ context.Context, userID, resource string, period goquota.Period,
+) ([]float64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	notified := s.warnings[warningKey(userID, resource, period)]
+	thresholds := make([]float64, 0, len(notified))
+	for threshold := range notified {
+		thresholds = append(thresholds, threshold)
+	}
+	sort.Float64s(thresholds)
+	return thresholds, nil
+}
+
+// MarkWarningNotified implements goquota.WarningStateStore
+func (s *Storage) MarkWarningNotified(
+	_ context.Context, userID, resource string, period goquota.Period, threshold float64,
+) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := warningKey(userID, resource, period)
+	notified := s.warnings[key]
+	if notified == nil {
+		notified = make(map[float64]bool)
+		s.warnings[key] = notified
+	}
+	if notified[threshold] {
+		return false, nil
+	}
+	notified[threshold] = true
+	return true, nil
+}
+
+// ClearNotifiedWarnings implements goquota.WarningStateStore
+func (s *Storage) ClearNotifiedWarnings(
+	_ context.Context, userID, resource string, period goquota.Period, thresholds []float64,
+) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := warningKey(userID, resource, period)
+	notified := s.warnings[key]
+	for _, threshold := range thresholds {
+		delete(notified, threshold)
+	}
+	if len(notified) == 0 {
+		delete(s.warnings, key)
+	}
+	return nil
+}
+
+func warningKey(userID, resource string, period goquota.Period) string {
+	return fmt.Sprintf("%s:%s:%s:%s", userID, resource, period.Type, period.Key())
+}
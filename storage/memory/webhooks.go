@@ -0,0 +1,95 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/mihaimyh/goquota/pkg/goquota"
+)
+
+// SaveWebhookDelivery implements goquota.WebhookStore
+func (s *Storage) SaveWebhookDelivery(_ context.Context, delivery *goquota.WebhookDelivery) error {
+	if delivery == nil {
+		return fmt.Errorf("webhook delivery is required")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.webhooks[delivery.ID] = copyWebhookDelivery(delivery)
+	return nil
+}
+
+// ClaimWebhookDeliveries implements goquota.WebhookStore
+func (s *Storage) ClaimWebhookDeliveries(_ context.Context, now time.Time, limit int,
+	lease time.Duration) ([]*goquota.WebhookDelivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var due []*goquota.WebhookDelivery
+	for _, delivery := range s.webhooks {
+		if delivery.Status == goquota.WebhookDeliveryPending && !delivery.NextAttemptAt.After(now) {
+			due = append(due, delivery)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool {
+		return due[i].NextAttemptAt.Before(due[j].NextAttemptAt)
+	})
+	if limit > 0 && len(due) > limit {
+		due = due[:limit]
+	}
+
+	claimed := make([]*goquota.WebhookDelivery, len(due))
+	for i, delivery := range due {
+		delivery.NextAttemptAt = now.Add(lease)
+		claimed[i] = copyWebhookDelivery(delivery)
+	}
+	return claimed, nil
+}
+
+// GetWebhookDelivery implements goquota.WebhookStore
+func (s *Storage) GetWebhookDelivery(_ context.Context, id string) (*goquota.WebhookDelivery, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	delivery, ok := s.webhooks[id]
+	if !ok {
+		return nil, nil
+	}
+	return copyWebhookDelivery(delivery), nil
+}
+
+// ListWebhookDeliveries implements goquota.WebhookStore
+func (s *Storage) ListWebhookDeliveries(_ context.Context, status goquota.WebhookDeliveryStatus,
+	limit, offset int) ([]*goquota.WebhookDelivery, error) {
+	s.mu.RLock()
+	var deliveries []*goquota.WebhookDelivery
+	for _, delivery := range s.webhooks {
+		if delivery.Status == status {
+			deliveries = append(deliveries, copyWebhookDelivery(delivery))
+		}
+	}
+	s.mu.RUnlock()
+
+	// Newest first
+	sort.Slice(deliveries, func(i, j int) bool {
+		return deliveries[i].CreatedAt.After(deliveries[j].CreatedAt)
+	})
+
+	return paginate(deliveries, offset, limit), nil
+}
+
+// DeleteWebhookDelivery implements goquota.WebhookStore
+func (s *Storage) DeleteWebhookDelivery(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.webhooks, id)
+	return nil
+}
+
+func copyWebhookDelivery(delivery *goquota.WebhookDelivery) *goquota.WebhookDelivery {
+	deliveryCopy := *delivery
+	deliveryCopy.Payload = append([]byte(nil), delivery.Payload...)
+	return &deliveryCopy
+}
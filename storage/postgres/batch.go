@@ -0,0 +1,111 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mihaimyh/goquota/pkg/goquota"
+)
+
+// GetEntitlements implements goquota.BatchStorage
+func (s *Storage) GetEntitlements(ctx context.Context, userIDs []string) (map[string]*goquota.Entitlement, error) {
+	result := make(map[string]*goquota.Entitlement, len(userIDs))
+	if len(userIDs) == 0 {
+		return result, nil
+	}
+
+	rows, err := s.pool.Query(ctx,
+		`SELECT user_id, tier_id, subscription_start, expires_at, updated_at
+			FROM entitlements WHERE user_id = ANY($1)`,
+		userIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get entitlements: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var ent goquota.Entitlement
+		var expiresAt *time.Time
+		if err := rows.Scan(
+			&ent.UserID,
+			&ent.Tier,
+			&ent.SubscriptionStartDate,
+			&expiresAt,
+			&ent.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan entitlement: %w", err)
+		}
+		ent.ExpiresAt = expiresAt
+		result[ent.UserID] = &ent
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate entitlements: %w", err)
+	}
+
+	return result, nil
+}
+
+// GetUsages implements goquota.BatchStorage
+// All usage rows are fetched in one query by joining against the unnested query keys.
+func (s *Storage) GetUsages(ctx context.Context, queries []goquota.UsageQuery) ([]*goquota.Usage, error) {
+	result := make([]*goquota.Usage, len(queries))
+	if len(queries) == 0 {
+		return result, nil
+	}
+
+	userIDs := make([]string, len(queries))
+	resources := make([]string, len(queries))
+	starts := make([]time.Time, len(queries))
+	for i, q := range queries {
+		userIDs[i] = q.UserID
+		resources[i] = q.Resource
+		starts[i] = q.Period.Start
+	}
+
+	rows, err := s.pool.Query(ctx,
+		`SELECT q.idx, u.user_id, u.resource, u.usage_amount, u.limit_amount,
+				u.period_start, u.period_end, u.period_type, u.tier, u.updated_at
+			FROM unnest($1::text[], $2::text[], $3::timestamptz[]) WITH ORDINALITY
+				AS q(user_id, resource, period_start, idx)
+			JOIN quota_usage u
+				ON u.user_id = q.user_id AND u.resource = q.resource AND u.period_start = q.period_start`,
+		userIDs, resources, starts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get usages: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var idx int64
+		var usage goquota.Usage
+		var periodEnd *time.Time
+		if err := rows.Scan(
+			&idx,
+			&usage.UserID,
+			&usage.Resource,
+			&usage.Used,
+			&usage.Limit,
+			&usage.Period.Start,
+			&periodEnd,
+			&usage.Period.Type,
+			&usage.Tier,
+			&usage.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan usage: %w", err)
+		}
+
+		// Handle NULL period_end for forever periods
+		if periodEnd != nil {
+			usage.Period.End = *periodEnd
+		} else {
+			usage.Period.End = time.Date(9999, 12, 31, 23, 59, 59, 0, time.UTC)
+		}
+		result[idx-1] = &usage // WITH ORDINALITY is 1-based
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate usages: %w", err)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,94 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mihaimyh/goquota/pkg/goquota"
+)
+
+// AcquireLease implements goquota.ConcurrencyLimiter
+// A transaction-scoped advisory lock serializes acquisitions per user and resource,
+// so the count and insert are atomic across instances.
+func (s *Storage) AcquireLease(ctx context.Context, req *goquota.LeaseRequest) (bool, int, error) {
+	if req == nil {
+		return false, 0, fmt.Errorf("lease request is required")
+	}
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return false, 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		//nolint:errcheck // Rollback error is safe to ignore if transaction was committed
+		_ = tx.Rollback(ctx)
+	}()
+
+	// 1. Serialize acquisitions for this user/resource
+	_, err = tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext($1 || ':' || $2))`, req.UserID, req.Resource)
+	if err != nil {
+		return false, 0, fmt.Errorf("failed to lock leases: %w", err)
+	}
+
+	// 2. Reclaim expired leases (e.g. from crashed processes)
+	_, err = tx.Exec(ctx, `
+		DELETE FROM concurrency_leases
+		WHERE user_id = $1 AND resource = $2 AND expires_at <= $3
+	`, req.UserID, req.Resource, req.Now)
+	if err != nil {
+		return false, 0, fmt.Errorf("failed to reclaim expired leases: %w", err)
+	}
+
+	// 3. Count active leases
+	var active int
+	err = tx.QueryRow(ctx, `
+		SELECT COUNT(*) FROM concurrency_leases WHERE user_id = $1 AND resource = $2
+	`, req.UserID, req.Resource).Scan(&active)
+	if err != nil {
+		return false, 0, fmt.Errorf("failed to count leases: %w", err)
+	}
+	if active >= req.MaxConcurrent {
+		return false, active, nil
+	}
+
+	// 4. Register the lease
+	_, err = tx.Exec(ctx, `
+		INSERT INTO concurrency_leases (lease_id, user_id, resource, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, NOW())
+	`, req.LeaseID, req.UserID, req.Resource, req.Now.Add(req.TTL))
+	if err != nil {
+		return false, 0, fmt.Errorf("failed to insert lease: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return true, active + 1, nil
+}
+
+// RenewLease implements goquota.ConcurrencyLimiter
+func (s *Storage) RenewLease(ctx context.Context, req *goquota.LeaseRequest) (bool, error) {
+	if req == nil {
+		return false, fmt.Errorf("lease request is required")
+	}
+
+	tag, err := s.pool.Exec(ctx, `
+		UPDATE concurrency_leases SET expires_at = $4
+		WHERE lease_id = $1 AND user_id = $2 AND resource = $3 AND expires_at > $5
+	`, req.LeaseID, req.UserID, req.Resource, req.Now.Add(req.TTL), req.Now)
+	if err != nil {
+		return false, fmt.Errorf("failed to renew lease: %w", err)
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// ReleaseLease implements goquota.ConcurrencyLimiter
+func (s *Storage) ReleaseLease(ctx context.Context, userID, resource, leaseID string) error {
+	_, err := s.pool.Exec(ctx, `
+		DELETE FROM concurrency_leases WHERE lease_id = $1 AND user_id = $2 AND resource = $3
+	`, leaseID, userID, resource)
+	if err != nil {
+		return fmt.Errorf("failed to release lease: %w", err)
+	}
+	return nil
+}
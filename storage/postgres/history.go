@@ -0,0 +1,58 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mihaimyh/goquota/pkg/goquota"
+)
+
+// GetUsageHistory implements goquota.UsageHistoryStorage
+func (s *Storage) GetUsageHistory(ctx context.Context, query *goquota.UsageHistoryQuery) ([]*goquota.Usage, error) {
+	if query == nil {
+		return nil, fmt.Errorf("usage history query is required")
+	}
+
+	// LIMIT NULL means no limit
+	rows, err := s.pool.Query(ctx,
+		`SELECT user_id, resource, usage_amount, limit_amount, period_start, period_end, period_type, tier, updated_at
+			FROM quota_usage
+			WHERE user_id = $1 AND resource = $2 AND period_type = $3
+				AND period_start >= $4 AND period_start < $5
+			ORDER BY period_start DESC
+			LIMIT NULLIF($6, 0) OFFSET $7`,
+		query.UserID, query.Resource, string(query.PeriodType), query.From, query.To, query.Limit, query.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query usage history: %w", err)
+	}
+	defer rows.Close()
+
+	records := []*goquota.Usage{}
+	for rows.Next() {
+		var usage goquota.Usage
+		var periodEnd *time.Time
+		if err := rows.Scan(
+			&usage.UserID,
+			&usage.Resource,
+			&usage.Used,
+			&usage.Limit,
+			&usage.Period.Start,
+			&periodEnd,
+			&usage.Period.Type,
+			&usage.Tier,
+			&usage.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan usage history: %w", err)
+		}
+		if periodEnd != nil {
+			usage.Period.End = *periodEnd
+		}
+		records = append(records, &usage)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate usage history: %w", err)
+	}
+
+	return records, nil
+}
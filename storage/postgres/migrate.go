@@ -0,0 +1,288 @@
+package postgres
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// schemaMigrationsTable records the applied migrations, one row per version
+const schemaMigrationsTable = "goquota_schema_migrations"
+
+// ErrSchemaVersionMismatch is returned by New when the database schema is not at the
+// version this adapter expects (see Migrate and Config.AutoMigrate)
+var ErrSchemaVersionMismatch = errors.New("postgres storage: schema version mismatch")
+
+// ErrSchemaNotBaselined is returned by New and Migrate when the database has goquota tables
+// but no recorded migrations, e.g. because the migration files were applied by hand.
+// Record them with Baseline first.
+var ErrSchemaNotBaselined = errors.New("postgres storage: schema has no recorded migrations")
+
+// baselineHint tells how to record hand-applied migrations
+const baselineHint = "if the migration files were applied by hand, run postgres.Baseline with the number " +
+	"of the last one applied, then postgres.Migrate"
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migration is a numbered schema change: NNN_name.sql applies it, NNN_name.down.sql reverts it
+type migration struct {
+	version int
+	name    string
+	up      string
+	down    string
+}
+
+// migrations are the embedded migrations, in version order
+var migrations = mustLoadMigrations()
+
+func mustLoadMigrations() []migration {
+	loaded, err := loadMigrations(migrationFiles)
+	if err != nil {
+		panic(err)
+	}
+	return loaded
+}
+
+// loadMigrations reads the migrations of fsys and checks that their versions are 1..N
+func loadMigrations(fsys fs.FS) ([]migration, error) {
+	paths, err := fs.Glob(fsys, "migrations/*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*migration)
+	for _, p := range paths {
+		base := path.Base(p)
+		down := strings.HasSuffix(base, ".down.sql")
+		stem := strings.TrimSuffix(strings.TrimSuffix(base, ".sql"), ".down")
+		prefix, name, ok := strings.Cut(stem, "_")
+		version, err := strconv.Atoi(prefix)
+		if !ok || err != nil || version <= 0 {
+			return nil, fmt.Errorf("invalid migration file name %q", base)
+		}
+		content, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return nil, err
+		}
+
+		m, exists := byVersion[version]
+		if !exists {
+			m = &migration{version: version}
+			byVersion[version] = m
+		}
+		if down {
+			m.down = string(content)
+		} else {
+			m.name = name
+			m.up = string(content)
+		}
+	}
+
+	loaded := make([]migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		loaded = append(loaded, *m)
+	}
+	sort.Slice(loaded, func(i, j int) bool { return loaded[i].version < loaded[j].version })
+	for i, m := range loaded {
+		if m.version != i+1 {
+			return nil, fmt.Errorf("missing migration %d", i+1)
+		}
+		if m.up == "" {
+			return nil, fmt.Errorf("migration %d has no up migration", m.version)
+		}
+	}
+	return loaded, nil
+}
+
+// LatestSchemaVersion returns the schema version this adapter expects: the last embedded migration
+func LatestSchemaVersion() int {
+	return len(migrations)
+}
+
+// Migrate applies the embedded migrations that are missing from the database.
+// An advisory lock serializes concurrent runners, e.g. instances starting at the same time,
+// and each migration is applied in its own transaction with its row in goquota_schema_migrations.
+func Migrate(ctx context.Context, pool *pgxpool.Pool) error {
+	return MigrateTo(ctx, pool, LatestSchemaVersion())
+}
+
+// MigrateTo applies up migrations or reverts down migrations until the schema is at version
+// (0 reverts all of them). Reverting drops tables, and their data, for good.
+func MigrateTo(ctx context.Context, pool *pgxpool.Pool, version int) error {
+	if version < 0 || version > LatestSchemaVersion() {
+		return fmt.Errorf("unknown schema version %d (latest: %d)", version, LatestSchemaVersion())
+	}
+
+	return withMigrationLock(ctx, pool, func(conn *pgxpool.Conn) error {
+		current, err := currentSchemaVersion(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if current == 0 && version > 0 {
+			// Applying migration 1 would fail on the existing tables
+			if err := checkNoUnrecordedSchema(ctx, conn); err != nil {
+				return err
+			}
+		}
+		for ; current < version; current++ {
+			m := migrations[current]
+			err := runMigration(ctx, conn, m.up, `INSERT INTO `+schemaMigrationsTable+` (version, name) VALUES ($1, $2)`,
+				m.version, m.name)
+			if err != nil {
+				return fmt.Errorf("failed to apply migration %d (%s): %w", m.version, m.name, err)
+			}
+		}
+		for ; current > version; current-- {
+			m := migrations[current-1]
+			if m.down == "" {
+				return fmt.Errorf("migration %d (%s) cannot be reverted", m.version, m.name)
+			}
+			err := runMigration(ctx, conn, m.down, `DELETE FROM `+schemaMigrationsTable+` WHERE version = $1`, m.version)
+			if err != nil {
+				return fmt.Errorf("failed to revert migration %d (%s): %w", m.version, m.name, err)
+			}
+		}
+		return nil
+	})
+}
+
+// Baseline records migrations 1..version as applied without running them, for databases
+// whose schema was created by hand from the migration files.
+func Baseline(ctx context.Context, pool *pgxpool.Pool, version int) error {
+	if version < 0 || version > LatestSchemaVersion() {
+		return fmt.Errorf("unknown schema version %d (latest: %d)", version, LatestSchemaVersion())
+	}
+
+	return withMigrationLock(ctx, pool, func(conn *pgxpool.Conn) error {
+		for _, m := range migrations[:version] {
+			_, err := conn.Exec(ctx, `
+				INSERT INTO `+schemaMigrationsTable+` (version, name) VALUES ($1, $2)
+				ON CONFLICT (version) DO NOTHING
+			`, m.version, m.name)
+			if err != nil {
+				return fmt.Errorf("failed to record migration %d: %w", m.version, err)
+			}
+		}
+		return nil
+	})
+}
+
+// SchemaVersion returns the version of the database schema (0 if no migration was recorded)
+func SchemaVersion(ctx context.Context, pool *pgxpool.Pool) (int, error) {
+	var exists bool
+	err := pool.QueryRow(ctx, `SELECT to_regclass($1) IS NOT NULL`, schemaMigrationsTable).Scan(&exists)
+	if err != nil {
+		return 0, fmt.Errorf("failed to check schema version: %w", err)
+	}
+	if !exists {
+		return 0, nil
+	}
+	var version int
+	err = pool.QueryRow(ctx, `SELECT COALESCE(MAX(version), 0) FROM `+schemaMigrationsTable).Scan(&version)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get schema version: %w", err)
+	}
+	return version, nil
+}
+
+// checkSchemaVersion fails if the database schema is not at LatestSchemaVersion
+func checkSchemaVersion(ctx context.Context, pool *pgxpool.Pool) error {
+	version, err := SchemaVersion(ctx, pool)
+	if err != nil {
+		return err
+	}
+	if version == 0 {
+		if err := checkNoUnrecordedSchema(ctx, pool); err != nil {
+			return err
+		}
+	}
+	if version != LatestSchemaVersion() {
+		return fmt.Errorf("%w: database is at version %d, expected %d (run postgres.Migrate or set Config.AutoMigrate)",
+			ErrSchemaVersionMismatch, version, LatestSchemaVersion())
+	}
+	return nil
+}
+
+// checkNoUnrecordedSchema fails with ErrSchemaNotBaselined if the tables of the first
+// migration exist while no migration is recorded
+func checkNoUnrecordedSchema(ctx context.Context, db querier) error {
+	var exists bool
+	if err := db.QueryRow(ctx, `SELECT to_regclass('entitlements') IS NOT NULL`).Scan(&exists); err != nil {
+		return fmt.Errorf("failed to check for an existing schema: %w", err)
+	}
+	if exists {
+		return fmt.Errorf("%w: %s", ErrSchemaNotBaselined, baselineHint)
+	}
+	return nil
+}
+
+// withMigrationLock runs fn on a connection holding the migration advisory lock,
+// after creating the schema migrations table if needed
+func withMigrationLock(ctx context.Context, pool *pgxpool.Pool, fn func(conn *pgxpool.Conn) error) error {
+	conn, err := pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	// Session-level lock: held across the migration transactions
+	if _, err := conn.Exec(ctx, `SELECT pg_advisory_lock(hashtext($1))`, schemaMigrationsTable); err != nil {
+		return fmt.Errorf("failed to lock schema migrations: %w", err)
+	}
+	defer func() {
+		//nolint:errcheck // The lock is released with the session if this fails
+		_, _ = conn.Exec(context.Background(), `SELECT pg_advisory_unlock(hashtext($1))`, schemaMigrationsTable)
+	}()
+
+	_, err = conn.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS `+schemaMigrationsTable+` (
+			version INTEGER PRIMARY KEY,
+			name VARCHAR(255) NOT NULL,
+			applied_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema migrations table: %w", err)
+	}
+	return fn(conn)
+}
+
+func currentSchemaVersion(ctx context.Context, conn *pgxpool.Conn) (int, error) {
+	var version int
+	err := conn.QueryRow(ctx, `SELECT COALESCE(MAX(version), 0) FROM `+schemaMigrationsTable).Scan(&version)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get schema version: %w", err)
+	}
+	return version, nil
+}
+
+// runMigration runs a migration script and records it in one transaction
+func runMigration(ctx context.Context, conn *pgxpool.Conn, script, record string, args ...any) error {
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		//nolint:errcheck // Rollback error is safe to ignore if transaction was committed
+		_ = tx.Rollback(ctx)
+	}()
+
+	// Scripts hold several statements: run them with the simple protocol
+	if _, err := tx.Exec(ctx, script, pgx.QueryExecModeSimpleProtocol); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, record, args...); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
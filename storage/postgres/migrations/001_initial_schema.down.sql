@@ -0,0 +1,6 @@
+-- Reverts 001_initial_schema.sql
+
+DROP TABLE IF EXISTS refund_records;
+DROP TABLE IF EXISTS consumption_records;
+DROP TABLE IF EXISTS quota_usage;
+DROP TABLE IF EXISTS entitlements;
@@ -1,6 +1,6 @@
 -- GoQuota PostgreSQL Storage Schema
 -- This schema supports quota tracking with ACID transactions
--- Rate limiting is handled in-memory (not stored in this schema)
+-- Rate limits are added by 008_rate_limits.sql
 
 -- User entitlements (subscription tiers)
 CREATE TABLE entitlements (
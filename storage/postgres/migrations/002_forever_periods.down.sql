@@ -0,0 +1,9 @@
+-- Reverts 002_forever_periods.sql
+-- Restoring NOT NULL fails while forever periods are stored: delete them first
+
+DROP INDEX IF EXISTS idx_quota_usage_period_type;
+DROP TABLE IF EXISTS top_up_records;
+
+ALTER TABLE refund_records ALTER COLUMN period_end SET NOT NULL;
+ALTER TABLE consumption_records ALTER COLUMN period_end SET NOT NULL;
+ALTER TABLE quota_usage ALTER COLUMN period_end SET NOT NULL;
@@ -0,0 +1,3 @@
+-- Reverts 003_concurrency_leases.sql
+
+DROP TABLE IF EXISTS concurrency_leases;
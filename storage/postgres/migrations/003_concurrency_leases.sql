@@ -0,0 +1,14 @@
+-- GoQuota PostgreSQL Storage Schema - Concurrency Leases
+-- This migration adds support for concurrency limits (Manager.Acquire)
+
+-- Active concurrency leases; expired rows are reclaimed on acquire and by cleanup
+CREATE TABLE concurrency_leases (
+    lease_id VARCHAR(64) PRIMARY KEY,
+    user_id VARCHAR(255) NOT NULL,
+    resource VARCHAR(50) NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_concurrency_leases_user_resource ON concurrency_leases(user_id, resource, expires_at);
+CREATE INDEX idx_concurrency_leases_expiry ON concurrency_leases(expires_at); -- For cleanup
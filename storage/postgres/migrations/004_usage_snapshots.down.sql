@@ -0,0 +1,3 @@
+-- Reverts 004_usage_snapshots.sql
+
+DROP TABLE IF EXISTS usage_snapshots;
@@ -0,0 +1,17 @@
+-- GoQuota PostgreSQL Storage Schema - Usage Snapshots
+-- This migration adds time-bucketed usage history for forecasting (Manager.Forecast)
+
+-- One row per user/resource/period and time bucket; the highest observed usage wins
+CREATE TABLE usage_snapshots (
+    user_id VARCHAR(255) NOT NULL,
+    resource VARCHAR(50) NOT NULL,
+    period_type VARCHAR(20) NOT NULL,
+    period_start TIMESTAMP WITH TIME ZONE NOT NULL,
+    period_end TIMESTAMP WITH TIME ZONE NOT NULL,
+    bucket TIMESTAMP WITH TIME ZONE NOT NULL,
+    used BIGINT NOT NULL,
+    recorded_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (user_id, resource, period_type, period_start, bucket)
+);
+
+CREATE INDEX idx_usage_snapshots_period_end ON usage_snapshots(period_end); -- For cleanup
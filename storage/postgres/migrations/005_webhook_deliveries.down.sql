@@ -0,0 +1,3 @@
+-- Reverts 005_webhook_deliveries.sql
+
+DROP TABLE IF EXISTS webhook_deliveries;
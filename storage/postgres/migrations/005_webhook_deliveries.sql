@@ -0,0 +1,22 @@
+-- GoQuota PostgreSQL Storage Schema - Webhook Deliveries
+-- This migration adds persisted outbound webhook deliveries (Config.WebhookConfig)
+
+-- Pending deliveries are claimed by next_attempt_at; delivered rows are deleted,
+-- rows that run out of attempts are kept with status 'dead' for inspection and replay
+CREATE TABLE webhook_deliveries (
+    id VARCHAR(64) PRIMARY KEY,
+    event_id VARCHAR(64) NOT NULL,
+    event_type VARCHAR(50) NOT NULL,
+    user_id VARCHAR(255) NOT NULL,
+    url TEXT NOT NULL,
+    payload BYTEA NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    last_error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX idx_webhook_deliveries_due ON webhook_deliveries(status, next_attempt_at);
+CREATE INDEX idx_webhook_deliveries_created ON webhook_deliveries(status, created_at DESC);
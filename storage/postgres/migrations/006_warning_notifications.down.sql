@@ -0,0 +1,3 @@
+-- Reverts 006_warning_notifications.sql
+
+DROP TABLE IF EXISTS warning_notifications;
@@ -0,0 +1,17 @@
+-- GoQuota PostgreSQL Storage Schema - Warning Notifications
+-- This migration records which warning thresholds were notified, so that each
+-- threshold fires once per period across all instances
+
+-- One row per user/resource/period and notified threshold
+CREATE TABLE warning_notifications (
+    user_id VARCHAR(255) NOT NULL,
+    resource VARCHAR(50) NOT NULL,
+    period_type VARCHAR(20) NOT NULL,
+    period_start TIMESTAMP WITH TIME ZONE NOT NULL,
+    period_end TIMESTAMP WITH TIME ZONE NOT NULL,
+    threshold DOUBLE PRECISION NOT NULL,
+    notified_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, resource, period_type, period_start, threshold)
+);
+
+CREATE INDEX idx_warning_notifications_period_end ON warning_notifications(period_end); -- For cleanup
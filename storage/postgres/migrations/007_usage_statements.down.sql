@@ -0,0 +1,3 @@
+-- Reverts 007_usage_statements.sql
+
+DROP TABLE IF EXISTS usage_statements;
@@ -0,0 +1,14 @@
+-- GoQuota PostgreSQL Storage Schema - Usage Statements
+-- This migration adds immutable end-of-cycle usage statements
+
+-- One row per user and closed period; lines hold the final usage of each resource
+CREATE TABLE usage_statements (
+    user_id VARCHAR(255) NOT NULL,
+    period_type VARCHAR(20) NOT NULL,
+    period_start TIMESTAMP WITH TIME ZONE NOT NULL,
+    period_end TIMESTAMP WITH TIME ZONE NOT NULL,
+    tier VARCHAR(50) NOT NULL,
+    lines JSONB NOT NULL,
+    closed_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (user_id, period_type, period_start)
+);
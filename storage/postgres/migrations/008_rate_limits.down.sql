@@ -0,0 +1,3 @@
+-- Reverts 008_rate_limits.sql
+
+DROP TABLE IF EXISTS rate_limits;
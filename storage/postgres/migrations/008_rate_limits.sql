@@ -0,0 +1,18 @@
+-- GoQuota PostgreSQL Storage Schema - Rate Limits
+-- This migration adds rate limit state shared by all instances (CheckRateLimit)
+
+-- One row per user, resource and algorithm. The table is UNLOGGED: rate limit state is
+-- short-lived, so it skips the WAL for speed and is emptied after a crash (limits reset).
+CREATE UNLOGGED TABLE rate_limits (
+    user_id VARCHAR(255) NOT NULL,
+    resource VARCHAR(50) NOT NULL,
+    algorithm VARCHAR(20) NOT NULL,
+    tokens INTEGER NOT NULL DEFAULT 0, -- Token bucket: tokens left
+    last_refill TIMESTAMP WITH TIME ZONE, -- Token bucket: time of the last refill
+    requests TIMESTAMP WITH TIME ZONE[] NOT NULL DEFAULT '{}', -- Sliding window: allowed requests in the window
+    allowed BOOLEAN NOT NULL, -- Outcome of the last check
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL, -- When the state is equivalent to no state
+    PRIMARY KEY (user_id, resource, algorithm)
+);
+
+CREATE INDEX idx_rate_limits_expiry ON rate_limits(expires_at); -- For cleanup
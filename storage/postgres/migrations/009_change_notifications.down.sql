@@ -0,0 +1,8 @@
+-- Reverts 009_change_notifications.sql
+
+DROP TRIGGER IF EXISTS goquota_quota_usage_notify_truncate ON quota_usage;
+DROP TRIGGER IF EXISTS goquota_quota_usage_notify ON quota_usage;
+DROP TRIGGER IF EXISTS goquota_entitlements_notify_truncate ON entitlements;
+DROP TRIGGER IF EXISTS goquota_entitlements_notify ON entitlements;
+DROP FUNCTION IF EXISTS goquota_notify_change();
+DROP FUNCTION IF EXISTS goquota_usage_cache_key(TEXT, TEXT, TEXT, TIMESTAMP WITH TIME ZONE);
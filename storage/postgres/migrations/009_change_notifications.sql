@@ -0,0 +1,78 @@
+-- GoQuota PostgreSQL Storage Schema - Change Notifications
+-- This migration adds triggers that publish cache invalidations with NOTIFY when entitlements or
+-- usage change, including changes made outside goquota (admin tools, SQL fixes).
+-- The triggers are created disabled: enable them with Config.ChangeNotifications or
+-- postgres.EnableChangeNotifications.
+
+-- Cache key of a usage row, as built by goquota.Manager: user_id:resource:period key
+CREATE FUNCTION goquota_usage_cache_key(
+    user_id TEXT, resource TEXT, period_type TEXT, period_start TIMESTAMP WITH TIME ZONE
+) RETURNS TEXT LANGUAGE sql STABLE AS $$
+    SELECT user_id || ':' || resource || ':' || CASE
+        WHEN period_type = 'forever' THEN 'forever'
+        ELSE to_char(period_start AT TIME ZONE 'UTC', 'YYYY-MM-DD')
+    END
+$$;
+
+-- Notifies the channel given as trigger argument with a goquota.CacheInvalidation (JSON)
+CREATE FUNCTION goquota_notify_change() RETURNS TRIGGER LANGUAGE plpgsql AS $$
+DECLARE
+    kind TEXT;
+    old_key TEXT;
+    new_key TEXT;
+BEGIN
+    IF TG_OP = 'TRUNCATE' THEN
+        PERFORM pg_notify(TG_ARGV[0], json_build_object('source', 'postgres', 'kind', 'clear')::TEXT);
+        RETURN NULL;
+    END IF;
+    IF TG_OP = 'UPDATE' AND OLD IS NOT DISTINCT FROM NEW THEN
+        RETURN NULL;
+    END IF;
+
+    IF TG_TABLE_NAME = 'entitlements' THEN
+        kind := 'entitlement';
+        IF TG_OP <> 'INSERT' THEN
+            old_key := OLD.user_id;
+        END IF;
+        IF TG_OP <> 'DELETE' THEN
+            new_key := NEW.user_id;
+        END IF;
+    ELSE
+        kind := 'usage';
+        IF TG_OP <> 'INSERT' THEN
+            old_key := goquota_usage_cache_key(OLD.user_id, OLD.resource, OLD.period_type, OLD.period_start);
+        END IF;
+        IF TG_OP <> 'DELETE' THEN
+            new_key := goquota_usage_cache_key(NEW.user_id, NEW.resource, NEW.period_type, NEW.period_start);
+        END IF;
+    END IF;
+
+    -- An update moving a row to another key invalidates both keys
+    IF old_key IS NOT NULL THEN
+        PERFORM pg_notify(TG_ARGV[0], json_build_object('source', 'postgres', 'kind', kind, 'key', old_key)::TEXT);
+    END IF;
+    IF new_key IS NOT NULL AND new_key IS DISTINCT FROM old_key THEN
+        PERFORM pg_notify(TG_ARGV[0], json_build_object('source', 'postgres', 'kind', kind, 'key', new_key)::TEXT);
+    END IF;
+    RETURN NULL;
+END;
+$$;
+
+CREATE TRIGGER goquota_entitlements_notify
+    AFTER INSERT OR UPDATE OR DELETE ON entitlements
+    FOR EACH ROW EXECUTE FUNCTION goquota_notify_change('goquota_cache_invalidations');
+CREATE TRIGGER goquota_entitlements_notify_truncate
+    AFTER TRUNCATE ON entitlements
+    FOR EACH STATEMENT EXECUTE FUNCTION goquota_notify_change('goquota_cache_invalidations');
+CREATE TRIGGER goquota_quota_usage_notify
+    AFTER INSERT OR UPDATE OR DELETE ON quota_usage
+    FOR EACH ROW EXECUTE FUNCTION goquota_notify_change('goquota_cache_invalidations');
+CREATE TRIGGER goquota_quota_usage_notify_truncate
+    AFTER TRUNCATE ON quota_usage
+    FOR EACH STATEMENT EXECUTE FUNCTION goquota_notify_change('goquota_cache_invalidations');
+
+-- Every write pays for the NOTIFY: the triggers are opt-in
+ALTER TABLE entitlements DISABLE TRIGGER goquota_entitlements_notify;
+ALTER TABLE entitlements DISABLE TRIGGER goquota_entitlements_notify_truncate;
+ALTER TABLE quota_usage DISABLE TRIGGER goquota_quota_usage_notify;
+ALTER TABLE quota_usage DISABLE TRIGGER goquota_quota_usage_notify_truncate;
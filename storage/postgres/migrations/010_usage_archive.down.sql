@@ -0,0 +1,3 @@
+-- Reverts 010_usage_archive.sql
+
+DROP TABLE IF EXISTS usage_archive;
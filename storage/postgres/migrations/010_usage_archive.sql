@@ -0,0 +1,20 @@
+-- GoQuota PostgreSQL Storage Schema - Usage Archive
+-- This migration adds the summary table that the retention job (Config.Retention) archives
+-- closed usage periods into
+
+-- One row per user, resource, period type and month (UTC) of the archived periods' start
+CREATE TABLE usage_archive (
+    user_id VARCHAR(255) NOT NULL,
+    resource VARCHAR(50) NOT NULL,
+    period_type VARCHAR(20) NOT NULL,
+    month DATE NOT NULL, -- First day of the month
+    periods BIGINT NOT NULL, -- Number of periods archived
+    usage_amount BIGINT NOT NULL, -- Total usage of the periods
+    max_usage BIGINT NOT NULL, -- Highest usage of a period
+    limit_amount BIGINT NOT NULL, -- Total limit of the periods (-1 if one was unlimited)
+    tier VARCHAR(50) NOT NULL, -- Tier of the last period
+    archived_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, resource, period_type, month)
+);
+
+CREATE INDEX idx_usage_archive_month ON usage_archive(month);
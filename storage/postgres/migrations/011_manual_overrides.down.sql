@@ -0,0 +1,3 @@
+-- Reverts 011_manual_overrides.sql
+
+DROP TABLE IF EXISTS manual_overrides;
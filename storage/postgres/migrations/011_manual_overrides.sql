@@ -0,0 +1,15 @@
+-- GoQuota PostgreSQL Storage Schema - Manual Overrides
+-- This migration adds manual overrides shared between instances
+
+-- One row per override scope; empty resource and tier for the global override
+CREATE TABLE manual_overrides (
+    resource VARCHAR(255) NOT NULL,
+    tier VARCHAR(50) NOT NULL,
+    mode VARCHAR(20) NOT NULL,
+    enforce_tiers TEXT[] NOT NULL DEFAULT '{}',
+    reason TEXT NOT NULL DEFAULT '',
+    actor VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE,
+    PRIMARY KEY (resource, tier)
+);
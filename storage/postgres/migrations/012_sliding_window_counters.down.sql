@@ -0,0 +1,9 @@
+-- Reverts 012_sliding_window_counters.sql
+
+DELETE FROM rate_limits WHERE algorithm = 'sliding_window';
+
+ALTER TABLE rate_limits
+    DROP COLUMN IF EXISTS previous_window_count,
+    DROP COLUMN IF EXISTS window_count,
+    DROP COLUMN IF EXISTS window_start,
+    ADD COLUMN requests TIMESTAMP WITH TIME ZONE[] NOT NULL DEFAULT '{}';
@@ -0,0 +1,12 @@
+-- GoQuota PostgreSQL Storage Schema - Sliding Window Counters
+-- This migration replaces the per-request timestamps of sliding window rate limits with the
+-- counts of the current and previous fixed windows, so each check updates a constant-size row
+
+-- Sliding window limits restart empty: the timestamps can't be converted to counts exactly
+DELETE FROM rate_limits WHERE algorithm = 'sliding_window';
+
+ALTER TABLE rate_limits
+    DROP COLUMN requests,
+    ADD COLUMN window_start TIMESTAMP WITH TIME ZONE, -- Sliding window: start of the current fixed window
+    ADD COLUMN window_count INTEGER NOT NULL DEFAULT 0, -- Sliding window: requests allowed in the current fixed window
+    ADD COLUMN previous_window_count INTEGER NOT NULL DEFAULT 0; -- Sliding window: requests allowed in the previous one
@@ -0,0 +1,20 @@
+-- Reverts 013_quiet_consumption.sql
+
+DO $$
+DECLARE
+    disabled BOOLEAN;
+BEGIN
+    SELECT tgenabled = 'D' INTO disabled FROM pg_trigger
+        WHERE tgrelid = 'quota_usage'::regclass AND tgname = 'goquota_quota_usage_notify';
+
+    DROP TRIGGER IF EXISTS goquota_quota_usage_notify_update ON quota_usage;
+    DROP TRIGGER goquota_quota_usage_notify ON quota_usage;
+    CREATE TRIGGER goquota_quota_usage_notify
+        AFTER INSERT OR UPDATE OR DELETE ON quota_usage
+        FOR EACH ROW EXECUTE FUNCTION goquota_notify_change('goquota_cache_invalidations');
+
+    IF disabled THEN
+        ALTER TABLE quota_usage DISABLE TRIGGER goquota_quota_usage_notify;
+    END IF;
+END;
+$$;
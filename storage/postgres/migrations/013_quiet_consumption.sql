@@ -0,0 +1,37 @@
+-- GoQuota PostgreSQL Storage Schema - Quiet Consumption
+-- This migration stops the change notification trigger of quota_usage from notifying each
+-- consumption. ConsumeQuota sets goquota.quiet_usage in its transaction: its updates, which only
+-- change usage_amount and updated_at, are not notified. Other updates, including every update made
+-- outside goquota, still are.
+
+-- WHEN can't refer to OLD in an INSERT trigger: updates move to their own trigger, which keeps the
+-- enabled state of goquota_quota_usage_notify
+DO $$
+DECLARE
+    disabled BOOLEAN;
+BEGIN
+    SELECT tgenabled = 'D' INTO disabled FROM pg_trigger
+        WHERE tgrelid = 'quota_usage'::regclass AND tgname = 'goquota_quota_usage_notify';
+
+    DROP TRIGGER goquota_quota_usage_notify ON quota_usage;
+    CREATE TRIGGER goquota_quota_usage_notify
+        AFTER INSERT OR DELETE ON quota_usage
+        FOR EACH ROW EXECUTE FUNCTION goquota_notify_change('goquota_cache_invalidations');
+    CREATE TRIGGER goquota_quota_usage_notify_update
+        AFTER UPDATE ON quota_usage
+        FOR EACH ROW
+        WHEN (
+            current_setting('goquota.quiet_usage', true) IS DISTINCT FROM 'on'
+            OR (OLD.id, OLD.user_id, OLD.resource, OLD.period_start, OLD.period_end, OLD.period_type,
+                OLD.limit_amount, OLD.tier)
+                IS DISTINCT FROM (NEW.id, NEW.user_id, NEW.resource, NEW.period_start, NEW.period_end,
+                NEW.period_type, NEW.limit_amount, NEW.tier)
+        )
+        EXECUTE FUNCTION goquota_notify_change('goquota_cache_invalidations');
+
+    IF disabled THEN
+        ALTER TABLE quota_usage DISABLE TRIGGER goquota_quota_usage_notify;
+        ALTER TABLE quota_usage DISABLE TRIGGER goquota_quota_usage_notify_update;
+    END IF;
+END;
+$$;
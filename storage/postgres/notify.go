@@ -0,0 +1,212 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/mihaimyh/goquota/pkg/goquota"
+)
+
+// ChangeNotificationChannel is the channel the change notification triggers NOTIFY on
+const ChangeNotificationChannel = "goquota_cache_invalidations"
+
+// changeNotificationSource is the goquota.CacheInvalidation source of the triggers and the listener
+const changeNotificationSource = "postgres"
+
+// Delays between reconnection attempts of a listener, doubled after every failed attempt
+const (
+	minReconnectDelay = 500 * time.Millisecond
+	maxReconnectDelay = 30 * time.Second
+)
+
+// changeNotificationTriggers are the triggers created by 009_change_notifications.sql and
+// 013_quiet_consumption.sql
+var changeNotificationTriggers = []struct{ table, name string }{
+	{"entitlements", "goquota_entitlements_notify"},
+	{"entitlements", "goquota_entitlements_notify_truncate"},
+	{"quota_usage", "goquota_quota_usage_notify"},
+	{"quota_usage", "goquota_quota_usage_notify_update"},
+	{"quota_usage", "goquota_quota_usage_notify_truncate"},
+}
+
+// EnableChangeNotifications enables the triggers that NOTIFY ChangeNotificationChannel when a row of
+// entitlements or quota_usage changes, whoever changes it. The setting is stored in the database:
+// it applies to every instance until DisableChangeNotifications is called.
+func EnableChangeNotifications(ctx context.Context, pool *pgxpool.Pool) error {
+	return setChangeNotifications(ctx, pool, true)
+}
+
+// DisableChangeNotifications disables the triggers enabled by EnableChangeNotifications
+func DisableChangeNotifications(ctx context.Context, pool *pgxpool.Pool) error {
+	return setChangeNotifications(ctx, pool, false)
+}
+
+// ChangeNotificationsEnabled reports whether the change notification triggers are enabled
+func ChangeNotificationsEnabled(ctx context.Context, pool *pgxpool.Pool) (bool, error) {
+	for _, trigger := range changeNotificationTriggers {
+		enabled, err := triggerEnabled(ctx, pool, trigger.table, trigger.name)
+		if err != nil {
+			return false, err
+		}
+		if !enabled {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func setChangeNotifications(ctx context.Context, pool *pgxpool.Pool, enable bool) error {
+	action := "DISABLE"
+	if enable {
+		action = "ENABLE"
+	}
+	for _, trigger := range changeNotificationTriggers {
+		enabled, err := triggerEnabled(ctx, pool, trigger.table, trigger.name)
+		if err != nil {
+			return err
+		}
+		// ALTER TABLE blocks writes to the table: skip it when there is nothing to change
+		if enabled == enable {
+			continue
+		}
+		_, err = pool.Exec(ctx, fmt.Sprintf(`ALTER TABLE %s %s TRIGGER %s`, trigger.table, action, trigger.name))
+		if err != nil {
+			return fmt.Errorf("failed to %s trigger %s: %w", action, trigger.name, err)
+		}
+	}
+	return nil
+}
+
+func triggerEnabled(ctx context.Context, pool *pgxpool.Pool, table, name string) (bool, error) {
+	var enabled bool
+	err := pool.QueryRow(ctx, `
+		SELECT tgenabled <> 'D' FROM pg_trigger WHERE tgrelid = to_regclass($1) AND tgname = $2
+	`, table, name).Scan(&enabled)
+	if err == pgx.ErrNoRows {
+		return false, fmt.Errorf("trigger %s not found (is the schema migrated?)", name)
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to check trigger %s: %w", name, err)
+	}
+	return enabled, nil
+}
+
+// InvalidationBus returns a goquota.InvalidationBus on ChangeNotificationChannel, for
+// goquota.CacheConfig.InvalidationBus. With change notifications enabled, it also delivers
+// the changes made directly in the database.
+func (s *Storage) InvalidationBus() *InvalidationBus {
+	return NewInvalidationBus(s.pool, ChangeNotificationChannel)
+}
+
+// InvalidationBus is a goquota.InvalidationBus on PostgreSQL LISTEN/NOTIFY. Each subscription
+// listens on a dedicated connection, outside the pool, and reconnects when it is lost.
+// Notifications sent while it is disconnected are lost, so after reconnecting the subscriber
+// receives a goquota.CacheInvalidationClear invalidation.
+type InvalidationBus struct {
+	pool    *pgxpool.Pool
+	channel string
+}
+
+// NewInvalidationBus creates an invalidation bus publishing on channel
+func NewInvalidationBus(pool *pgxpool.Pool, channel string) *InvalidationBus {
+	return &InvalidationBus{pool: pool, channel: channel}
+}
+
+// Publish implements goquota.InvalidationBus
+func (b *InvalidationBus) Publish(ctx context.Context, invalidation *goquota.CacheInvalidation) error {
+	payload, err := json.Marshal(invalidation)
+	if err != nil {
+		return fmt.Errorf("failed to encode cache invalidation: %w", err)
+	}
+	if _, err := b.pool.Exec(ctx, `SELECT pg_notify($1, $2)`, b.channel, string(payload)); err != nil {
+		return fmt.Errorf("failed to publish cache invalidation: %w", err)
+	}
+	return nil
+}
+
+// Subscribe implements goquota.InvalidationBus. It returns once the subscription is active.
+// Invalid messages are ignored.
+func (b *InvalidationBus) Subscribe(handler func(invalidation *goquota.CacheInvalidation)) (func(), error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	conn, err := b.listen(ctx)
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("failed to subscribe to cache invalidations: %w", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		b.receive(ctx, conn, handler)
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			cancel()
+			<-done
+		})
+	}, nil
+}
+
+// listen opens a connection with the pool's settings and listens on the channel
+func (b *InvalidationBus) listen(ctx context.Context) (*pgx.Conn, error) {
+	conn, err := pgx.ConnectConfig(ctx, b.pool.Config().ConnConfig.Copy())
+	if err != nil {
+		return nil, err
+	}
+	if _, err := conn.Exec(ctx, `LISTEN `+pgx.Identifier{b.channel}.Sanitize()); err != nil {
+		_ = conn.Close(context.Background())
+		return nil, err
+	}
+	return conn, nil
+}
+
+// receive delivers notifications to handler until ctx is canceled, reconnecting when the
+// connection is lost
+func (b *InvalidationBus) receive(
+	ctx context.Context, conn *pgx.Conn, handler func(invalidation *goquota.CacheInvalidation),
+) {
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			_ = conn.Close(context.Background())
+			if conn = b.reconnect(ctx); conn == nil {
+				return
+			}
+			// Changes made while disconnected are unknown: drop every cached entry
+			handler(&goquota.CacheInvalidation{Source: changeNotificationSource, Kind: goquota.CacheInvalidationClear})
+			continue
+		}
+
+		var invalidation goquota.CacheInvalidation
+		if err := json.Unmarshal([]byte(notification.Payload), &invalidation); err != nil {
+			continue
+		}
+		handler(&invalidation)
+	}
+}
+
+// reconnect listens again, with exponential backoff, until it succeeds or ctx is canceled (nil)
+func (b *InvalidationBus) reconnect(ctx context.Context) *pgx.Conn {
+	delay := minReconnectDelay
+	for ctx.Err() == nil {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(delay):
+		}
+		conn, err := b.listen(ctx)
+		if err == nil {
+			return conn
+		}
+		delay = min(delay*2, maxReconnectDelay)
+	}
+	return nil
+}
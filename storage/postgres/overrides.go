@@ -0,0 +1,84 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mihaimyh/goquota/pkg/goquota"
+)
+
+// SaveOverride implements goquota.OverrideStore
+func (s *Storage) SaveOverride(ctx context.Context, override *goquota.Override) error {
+	if override == nil {
+		return fmt.Errorf("override is required")
+	}
+
+	var expiresAt *time.Time
+	if !override.ExpiresAt.IsZero() {
+		expiresAt = &override.ExpiresAt
+	}
+	enforceTiers := override.EnforceTiers
+	if enforceTiers == nil {
+		enforceTiers = []string{}
+	}
+
+	_, err := s.pool.Exec(ctx, `
+		INSERT INTO manual_overrides
+			(resource, tier, mode, enforce_tiers, reason, actor, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (resource, tier) DO UPDATE SET
+			mode = EXCLUDED.mode,
+			enforce_tiers = EXCLUDED.enforce_tiers,
+			reason = EXCLUDED.reason,
+			actor = EXCLUDED.actor,
+			created_at = EXCLUDED.created_at,
+			expires_at = EXCLUDED.expires_at
+	`, override.Resource, override.Tier, string(override.Mode), enforceTiers, override.Reason,
+		override.Actor, override.CreatedAt, expiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to save override: %w", err)
+	}
+	return nil
+}
+
+// DeleteOverride implements goquota.OverrideStore
+func (s *Storage) DeleteOverride(ctx context.Context, resource, tier string) (bool, error) {
+	tag, err := s.pool.Exec(ctx, `DELETE FROM manual_overrides WHERE resource = $1 AND tier = $2`, resource, tier)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete override: %w", err)
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// ListOverrides implements goquota.OverrideStore
+func (s *Storage) ListOverrides(ctx context.Context) ([]*goquota.Override, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT resource, tier, mode, enforce_tiers, reason, actor, created_at, expires_at
+		FROM manual_overrides
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list overrides: %w", err)
+	}
+	defer rows.Close()
+
+	var overrides []*goquota.Override
+	for rows.Next() {
+		var override goquota.Override
+		var mode string
+		var expiresAt *time.Time
+		if err := rows.Scan(&override.Resource, &override.Tier, &mode, &override.EnforceTiers,
+			&override.Reason, &override.Actor, &override.CreatedAt, &expiresAt); err != nil {
+			return nil, fmt.Errorf("failed to scan override: %w", err)
+		}
+		override.Mode = goquota.OverrideMode(mode)
+		if expiresAt != nil {
+			override.ExpiresAt = *expiresAt
+		}
+		overrides = append(overrides, &override)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list overrides: %w", err)
+	}
+	return overrides, nil
+}
@@ -0,0 +1,428 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// partitionPremakeMonths is how many months after the current one have their partitions created ahead
+const partitionPremakeMonths = 3
+
+// maintenanceLock serializes partition maintenance and retention across instances
+const maintenanceLock = "goquota_maintenance"
+
+// partitionedTable describes how PartitionTables partitions a table by month
+type partitionedTable struct {
+	name string
+	// key is the partition key column
+	key string
+	// constraints replace the primary key and unique constraints, which must include the key
+	constraints []string
+	// indexes replace the unique constraints that cannot include the key
+	indexes []string
+}
+
+// partitionedTables are the tables PartitionTables partitions: usage by period start, and
+// consumption and refund records by expiry, so expired records are dropped with their partition
+var partitionedTables = []partitionedTable{
+	{
+		name:        "quota_usage",
+		key:         "period_start",
+		constraints: []string{`PRIMARY KEY (id, period_start)`, `UNIQUE (user_id, resource, period_start)`},
+	},
+	{
+		name:        "consumption_records",
+		key:         "expires_at",
+		constraints: []string{`PRIMARY KEY (id, expires_at)`},
+		indexes:     []string{`CREATE INDEX idx_consumption_user_key ON consumption_records(user_id, consumption_id)`},
+	},
+	{
+		name:        "refund_records",
+		key:         "expires_at",
+		constraints: []string{`PRIMARY KEY (id, expires_at)`},
+		indexes:     []string{`CREATE INDEX idx_refund_user_key ON refund_records(user_id, refund_id)`},
+	},
+}
+
+// querier is implemented by *pgxpool.Pool, *pgxpool.Conn and pgx.Tx
+type querier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// PartitionTables converts quota_usage, consumption_records and refund_records into tables
+// partitioned by month, with a default partition for rows outside the monthly partitions.
+// Rows, indexes and triggers are carried over; tables that are already partitioned are skipped.
+//
+// Each table is locked while its rows are copied, so run it during a maintenance window,
+// after Migrate. Partitions for the next months are then created by the cleanup job.
+// Requires PostgreSQL 13 or later.
+//
+// Partitioned consumption_records and refund_records lose their (user_id, key) unique constraints:
+// idempotency keys are then serialized by an advisory lock that only this version takes. Every
+// writer must run this version before the tables are partitioned, and running instances take the
+// lock from their next cleanup (or restart) on, so restart them if cleanup is disabled.
+func PartitionTables(ctx context.Context, pool *pgxpool.Pool) error {
+	return withMigrationLock(ctx, pool, func(conn *pgxpool.Conn) error {
+		version, err := currentSchemaVersion(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if version != LatestSchemaVersion() {
+			return fmt.Errorf("%w: database is at version %d, expected %d (run postgres.Migrate first)",
+				ErrSchemaVersionMismatch, version, LatestSchemaVersion())
+		}
+
+		now := time.Now().UTC()
+		for _, table := range partitionedTables {
+			partitioned, err := isPartitioned(ctx, conn, table.name)
+			if err != nil {
+				return err
+			}
+			if partitioned {
+				continue
+			}
+			if err := partitionTable(ctx, conn, table, now); err != nil {
+				return fmt.Errorf("failed to partition %s: %w", table.name, err)
+			}
+		}
+		return nil
+	})
+}
+
+// partitionTable replaces table with a partitioned copy in one transaction
+func partitionTable(ctx context.Context, conn *pgxpool.Conn, table partitionedTable, now time.Time) error {
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		//nolint:errcheck // Rollback error is safe to ignore if transaction was committed
+		_ = tx.Rollback(ctx)
+	}()
+
+	if _, err := tx.Exec(ctx, `LOCK TABLE `+table.name+` IN ACCESS EXCLUSIVE MODE`); err != nil {
+		return fmt.Errorf("failed to lock table: %w", err)
+	}
+
+	// Indexes and triggers added by migrations are recreated on the partitioned table:
+	// their definitions still name the table, which is renamed below
+	indexes, err := queryStrings(ctx, tx, `
+		SELECT pg_get_indexdef(i.indexrelid) FROM pg_index i
+		WHERE i.indrelid = to_regclass($1)
+			AND NOT EXISTS (SELECT 1 FROM pg_constraint c WHERE c.conrelid = i.indrelid AND c.conindid = i.indexrelid)
+	`, table.name)
+	if err != nil {
+		return fmt.Errorf("failed to get indexes: %w", err)
+	}
+	triggers, err := queryStrings(ctx, tx, `
+		SELECT pg_get_triggerdef(oid) FROM pg_trigger WHERE tgrelid = to_regclass($1) AND NOT tgisinternal
+	`, table.name)
+	if err != nil {
+		return fmt.Errorf("failed to get triggers: %w", err)
+	}
+	disabledTriggers, err := queryStrings(ctx, tx, `
+		SELECT tgname::text FROM pg_trigger WHERE tgrelid = to_regclass($1) AND NOT tgisinternal AND tgenabled = 'D'
+	`, table.name)
+	if err != nil {
+		return fmt.Errorf("failed to get triggers: %w", err)
+	}
+
+	old := table.name + "_unpartitioned"
+	if _, err := tx.Exec(ctx, `ALTER TABLE `+table.name+` RENAME TO `+old); err != nil {
+		return fmt.Errorf("failed to rename table: %w", err)
+	}
+	_, err = tx.Exec(ctx, fmt.Sprintf(`CREATE TABLE %s (LIKE %s INCLUDING DEFAULTS) PARTITION BY RANGE (%s)`,
+		table.name, old, table.key))
+	if err != nil {
+		return fmt.Errorf("failed to create partitioned table: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `CREATE TABLE `+table.name+`_default PARTITION OF `+table.name+` DEFAULT`); err != nil {
+		return fmt.Errorf("failed to create default partition: %w", err)
+	}
+
+	// Monthly partitions from the oldest row to the premade months
+	var oldest *time.Time
+	if err := tx.QueryRow(ctx, `SELECT MIN(`+table.key+`) FROM `+old).Scan(&oldest); err != nil {
+		return fmt.Errorf("failed to get oldest row: %w", err)
+	}
+	last := monthStart(now).AddDate(0, partitionPremakeMonths, 0)
+	month := monthStart(now)
+	if oldest != nil && oldest.Before(month) {
+		month = monthStart(*oldest)
+	}
+	for ; !month.After(last); month = month.AddDate(0, 1, 0) {
+		if err := createPartition(ctx, tx, table, month); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.Exec(ctx, `INSERT INTO `+table.name+` SELECT * FROM `+old); err != nil {
+		return fmt.Errorf("failed to copy rows: %w", err)
+	}
+
+	// The id sequence is owned by the old table: keep it when the old table is dropped
+	var sequence *string
+	if err := tx.QueryRow(ctx, `SELECT pg_get_serial_sequence($1, 'id')`, old).Scan(&sequence); err != nil {
+		return fmt.Errorf("failed to get id sequence: %w", err)
+	}
+	if sequence != nil {
+		if _, err := tx.Exec(ctx, `ALTER SEQUENCE `+*sequence+` OWNED BY `+table.name+`.id`); err != nil {
+			return fmt.Errorf("failed to move id sequence: %w", err)
+		}
+	}
+	if _, err := tx.Exec(ctx, `DROP TABLE `+old); err != nil {
+		return fmt.Errorf("failed to drop old table: %w", err)
+	}
+
+	for _, constraint := range table.constraints {
+		if _, err := tx.Exec(ctx, `ALTER TABLE `+table.name+` ADD `+constraint); err != nil {
+			return fmt.Errorf("failed to add constraint %q: %w", constraint, err)
+		}
+	}
+	for _, index := range append(indexes, table.indexes...) {
+		if _, err := tx.Exec(ctx, index); err != nil {
+			return fmt.Errorf("failed to create index %q: %w", index, err)
+		}
+	}
+	for _, trigger := range triggers {
+		if _, err := tx.Exec(ctx, trigger); err != nil {
+			return fmt.Errorf("failed to create trigger %q: %w", trigger, err)
+		}
+	}
+	for _, trigger := range disabledTriggers {
+		_, err := tx.Exec(ctx, `ALTER TABLE `+table.name+` DISABLE TRIGGER `+pgx.Identifier{trigger}.Sanitize())
+		if err != nil {
+			return fmt.Errorf("failed to disable trigger %s: %w", trigger, err)
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// createPartition creates the partition of month, moving its rows out of the default partition
+func createPartition(ctx context.Context, tx pgx.Tx, table partitionedTable, month time.Time) error {
+	name := partitionName(table.name, month)
+	if _, err := tx.Exec(ctx, `CREATE TABLE `+name+` (LIKE `+table.name+` INCLUDING DEFAULTS)`); err != nil {
+		return fmt.Errorf("failed to create partition %s: %w", name, err)
+	}
+	from, to := partitionBound(month), partitionBound(month.AddDate(0, 1, 0))
+	_, err := tx.Exec(ctx, fmt.Sprintf(`
+		WITH moved AS (
+			DELETE FROM %[1]s_default WHERE %[2]s >= '%[3]s' AND %[2]s < '%[4]s' RETURNING *
+		)
+		INSERT INTO %[5]s SELECT * FROM moved
+	`, table.name, table.key, from, to, name))
+	if err != nil {
+		return fmt.Errorf("failed to move rows to partition %s: %w", name, err)
+	}
+	_, err = tx.Exec(ctx, fmt.Sprintf(`ALTER TABLE %s ATTACH PARTITION %s FOR VALUES FROM ('%s') TO ('%s')`,
+		table.name, name, from, to))
+	if err != nil {
+		return fmt.Errorf("failed to attach partition %s: %w", name, err)
+	}
+	return nil
+}
+
+// maintainPartitions creates the partitions of the current and premade months of the partitioned tables
+func (s *Storage) maintainPartitions(ctx context.Context, now time.Time) error {
+	for _, table := range partitionedTables {
+		if !s.partitions.partitioned(table.name) {
+			continue
+		}
+		partitions, err := listPartitions(ctx, s.pool, table.name)
+		if err != nil {
+			return err
+		}
+		existing := make(map[time.Time]bool, len(partitions))
+		for _, p := range partitions {
+			existing[p.month] = true
+		}
+
+		last := monthStart(now).AddDate(0, partitionPremakeMonths, 0)
+		for month := monthStart(now); !month.After(last); month = month.AddDate(0, 1, 0) {
+			if existing[month] {
+				continue
+			}
+			err := pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+				return createPartition(ctx, tx, table, month)
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// partition is a monthly partition of a table
+type partition struct {
+	name  string
+	month time.Time
+}
+
+// listPartitions returns the monthly partitions of table, oldest first
+func listPartitions(ctx context.Context, q querier, table string) ([]partition, error) {
+	names, err := queryStrings(ctx, q, `
+		SELECT c.relname::text FROM pg_inherits i JOIN pg_class c ON c.oid = i.inhrelid
+		WHERE i.inhparent = to_regclass($1)
+	`, table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list partitions of %s: %w", table, err)
+	}
+	partitions := make([]partition, 0, len(names))
+	for _, name := range names {
+		if month, ok := parsePartitionMonth(table, name); ok {
+			partitions = append(partitions, partition{name: name, month: month})
+		}
+	}
+	sort.Slice(partitions, func(i, j int) bool { return partitions[i].month.Before(partitions[j].month) })
+	return partitions, nil
+}
+
+// isPartitioned reports whether table is a partitioned table
+func isPartitioned(ctx context.Context, q querier, table string) (bool, error) {
+	var partitioned bool
+	err := q.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM pg_class WHERE oid = to_regclass($1) AND relkind = 'p')`,
+		table).Scan(&partitioned)
+	if err != nil {
+		return false, fmt.Errorf("failed to check whether %s is partitioned: %w", table, err)
+	}
+	return partitioned, nil
+}
+
+// partitionCache caches which tables are partitioned, so writes and the cleanup job do not
+// query the catalog each time. Tables are never unpartitioned: a table seen partitioned is not
+// checked again.
+type partitionCache struct {
+	mu     sync.RWMutex
+	tables map[string]bool
+}
+
+// partitioned reports whether table was partitioned when last checked
+func (c *partitionCache) partitioned(table string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.tables[table]
+}
+
+// any reports whether any table was partitioned when last checked
+func (c *partitionCache) any() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.tables) > 0
+}
+
+func (c *partitionCache) set(tables []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.tables == nil {
+		c.tables = make(map[string]bool, len(partitionedTables))
+	}
+	for _, table := range tables {
+		c.tables[table] = true
+	}
+}
+
+// refreshPartitions checks which tables PartitionTables partitioned since the last check.
+// It runs in New and at the start of each cleanup, and queries nothing once every table is partitioned.
+func (s *Storage) refreshPartitions(ctx context.Context) error {
+	var unpartitioned []string
+	for _, table := range partitionedTables {
+		if !s.partitions.partitioned(table.name) {
+			unpartitioned = append(unpartitioned, table.name)
+		}
+	}
+	if len(unpartitioned) == 0 {
+		return nil
+	}
+	partitioned, err := queryStrings(ctx, s.pool, `
+		SELECT relname::text FROM pg_class
+		WHERE relkind = 'p' AND oid IN (SELECT to_regclass(name) FROM unnest($1::text[]) AS name)
+	`, unpartitioned)
+	if err != nil {
+		return fmt.Errorf("failed to check partitioned tables: %w", err)
+	}
+	s.partitions.set(partitioned)
+	return nil
+}
+
+// lockIdempotencyKey serializes the transactions using an idempotency key until they end.
+// Partitioned record tables cannot enforce (user_id, key) uniqueness, so the key is looked up
+// under this lock instead. Unpartitioned tables still have their unique constraint: no lock is taken.
+func (s *Storage) lockIdempotencyKey(ctx context.Context, tx pgx.Tx, table, userID, key string) error {
+	if !s.partitions.partitioned(table) {
+		return nil
+	}
+	_, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtextextended($1, 0))`, table+":"+userID+":"+key)
+	if err != nil {
+		return fmt.Errorf("failed to lock idempotency key: %w", err)
+	}
+	return nil
+}
+
+// withMaintenanceLock runs fn if no other instance is running maintenance, and reports whether it ran
+func withMaintenanceLock(ctx context.Context, pool *pgxpool.Pool, fn func() error) (bool, error) {
+	conn, err := pool.Acquire(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	var locked bool
+	if err := conn.QueryRow(ctx, `SELECT pg_try_advisory_lock(hashtext($1))`, maintenanceLock).Scan(&locked); err != nil {
+		return false, fmt.Errorf("failed to lock maintenance: %w", err)
+	}
+	if !locked {
+		return false, nil
+	}
+	defer func() {
+		//nolint:errcheck // The lock is released with the session if this fails
+		_, _ = conn.Exec(context.Background(), `SELECT pg_advisory_unlock(hashtext($1))`, maintenanceLock)
+	}()
+	return true, fn()
+}
+
+func queryStrings(ctx context.Context, q querier, sql string, args ...any) ([]string, error) {
+	rows, err := q.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowTo[string])
+}
+
+// monthStart returns the first instant of the month of t, in UTC
+func monthStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// partitionName returns the name of the partition of table holding month, e.g. quota_usage_p202601
+func partitionName(table string, month time.Time) string {
+	return table + "_p" + month.Format("200601")
+}
+
+// parsePartitionMonth returns the month of a partition named by partitionName
+func parsePartitionMonth(table, name string) (time.Time, bool) {
+	suffix, ok := strings.CutPrefix(name, table+"_p")
+	if !ok || len(suffix) != len("200601") {
+		return time.Time{}, false
+	}
+	month, err := time.Parse("200601", suffix)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return month, true
+}
+
+// partitionBound formats month as a timestamptz literal, independent of the session time zone
+func partitionBound(month time.Time) string {
+	return month.UTC().Format("2006-01-02 15:04:05") + "+00"
+}
@@ -1,6 +1,11 @@
 // Package postgres provides a PostgreSQL implementation of the goquota.Storage interface.
 // This implementation uses SQL transactions with SELECT FOR UPDATE for atomic quota operations.
-// Rate limiting is handled by an embedded memory storage adapter for performance.
+// Rate limits are kept in an UNLOGGED table and enforced across instances, or optionally
+// by an embedded memory storage adapter per instance (Config.LocalRateLimits).
+// Optional triggers publish entitlement and usage changes with NOTIFY, and InvalidationBus
+// delivers them to the caches of every goquota.Manager (Config.ChangeNotifications).
+// Usage and record tables can be partitioned by month (PartitionTables), and old usage
+// archived into a summary table by a retention policy (Config.Retention).
 package postgres
 
 import (
@@ -16,18 +21,19 @@ import (
 	"github.com/mihaimyh/goquota/storage/memory"
 )
 
-// Storage implements goquota.Storage using PostgreSQL for quotas
-// and embedded memory storage for rate limiting
+// Storage implements goquota.Storage using PostgreSQL for quotas and rate limits
 type Storage struct {
 	pool   *pgxpool.Pool
 	config Config
 
-	// Embedded memory adapter handles rate limiting transparently
-	// This satisfies CheckRateLimit and RecordRateLimitRequest
+	// Embedded memory adapter handles rate limiting with Config.LocalRateLimits
 	*memory.Storage
 
 	// stopCleanup cancels the background cleanup goroutine
 	stopCleanup func()
+
+	// partitions caches which tables PartitionTables partitioned
+	partitions partitionCache
 }
 
 // Now returns the current time from PostgreSQL server.
@@ -58,6 +64,27 @@ type Config struct {
 	CleanupEnabled  bool
 	CleanupInterval time.Duration // How often to run cleanup
 	RecordTTL       time.Duration // TTL for consumption/refund records
+
+	// LocalRateLimits checks rate limits in memory instead of in the rate_limits table.
+	// Limits are then per instance (N instances allow N× the rate) and reset on restart,
+	// but cost no database round trip. Default: false
+	LocalRateLimits bool
+
+	// AutoMigrate applies missing migrations in New (see Migrate). Default: false
+	AutoMigrate bool
+
+	// SkipSchemaCheck lets New connect to a database whose schema is not at LatestSchemaVersion,
+	// e.g. during a rolling upgrade. Default: false (New fails with ErrSchemaVersionMismatch)
+	SkipSchemaCheck bool
+	// ChangeNotifications enables, in New, the triggers that NOTIFY ChangeNotificationChannel when
+	// entitlements or usage change, so Storage.InvalidationBus also invalidates caches after
+	// changes made directly in the database. Each write then pays for a NOTIFY. Default: false
+	ChangeNotifications bool
+
+	// Retention archives old usage periods with the cleanup job, and exports expired partitions
+	// before they are dropped (see ApplyRetention and PartitionTables).
+	// Default: nil (usage history is kept forever, expired partitions are dropped without export)
+	Retention *RetentionConfig
 }
 
 // DefaultConfig returns a Config with sensible defaults
@@ -79,6 +106,10 @@ func New(ctx context.Context, config Config) (*Storage, error) {
 		return nil, fmt.Errorf("connection string is required")
 	}
 
+	if config.Retention != nil && config.Retention.ArchiveAfterMonths < 0 {
+		return nil, fmt.Errorf("retention ArchiveAfterMonths must not be negative")
+	}
+
 	// Parse connection string
 	poolConfig, err := pgxpool.ParseConfig(config.ConnectionString)
 	if err != nil {
@@ -111,7 +142,27 @@ func New(ctx context.Context, config Config) (*Storage, error) {
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	// Initialize embedded memory adapter for rate limiting
+	// Bring the schema up to date, or check that it is
+	if config.AutoMigrate {
+		if err := Migrate(ctx, pool); err != nil {
+			pool.Close()
+			return nil, fmt.Errorf("failed to migrate schema: %w", err)
+		}
+	}
+	if !config.SkipSchemaCheck {
+		if err := checkSchemaVersion(ctx, pool); err != nil {
+			pool.Close()
+			return nil, err
+		}
+	}
+	if config.ChangeNotifications {
+		if err := EnableChangeNotifications(ctx, pool); err != nil {
+			pool.Close()
+			return nil, fmt.Errorf("failed to enable change notifications: %w", err)
+		}
+	}
+
+	// Initialize embedded memory adapter for local rate limiting
 	memStorage := memory.New()
 
 	// Create context for background cleanup worker
@@ -120,9 +171,14 @@ func New(ctx context.Context, config Config) (*Storage, error) {
 	s := &Storage{
 		pool:        pool,
 		config:      config,
-		Storage:     memStorage, // Embedded - rate limit methods work automatically
+		Storage:     memStorage,
 		stopCleanup: cancel,
 	}
+	if err := s.refreshPartitions(ctx); err != nil {
+		cancel()
+		pool.Close()
+		return nil, err
+	}
 
 	// Start cleanup goroutine if enabled
 	if config.CleanupEnabled {
@@ -286,6 +342,9 @@ func (s *Storage) ConsumeQuota(ctx context.Context, req *goquota.ConsumeRequest)
 	// Check idempotency (scoped to user_id) with row-level lock
 	// This prevents race conditions where multiple transactions check simultaneously
 	if req.IdempotencyKey != "" {
+		if err := s.lockIdempotencyKey(ctx, tx, "consumption_records", req.UserID, req.IdempotencyKey); err != nil {
+			return 0, err
+		}
 		var existingNewUsed int64
 		err := tx.QueryRow(ctx,
 			`SELECT new_used FROM consumption_records 
@@ -341,10 +400,13 @@ func (s *Storage) ConsumeQuota(ctx context.Context, req *goquota.ConsumeRequest)
 		return int(currentUsed), goquota.ErrQuotaExceeded
 	}
 
-	// Update usage
+	// Update usage. goquota.quiet_usage, set for the transaction before the row is updated, keeps
+	// the change notification trigger from notifying it, as Managers don't broadcast consumptions
+	// either.
 	_, err = tx.Exec(ctx,
 		`UPDATE quota_usage 
 			SET usage_amount = $1, updated_at = NOW()
+			FROM set_config('goquota.quiet_usage', 'on', true)
 			WHERE user_id = $2 AND resource = $3 AND period_start = $4`,
 		newUsed, req.UserID, req.Resource, req.Period.Start)
 	if err != nil {
@@ -387,7 +449,7 @@ func (s *Storage) ConsumeQuota(ctx context.Context, req *goquota.ConsumeRequest)
 				(consumption_id, user_id, resource, amount, period_start, 
 				period_end, period_type, new_used, expires_at, metadata)
 				VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NULL)
-				ON CONFLICT (user_id, consumption_id) DO NOTHING`,
+				ON CONFLICT DO NOTHING`,
 			req.IdempotencyKey, req.UserID, req.Resource, req.Amount,
 			req.Period.Start, req.Period.End, string(req.Period.Type),
 			newUsed, expiresAt)
@@ -404,6 +466,30 @@ func (s *Storage) ConsumeQuota(ctx context.Context, req *goquota.ConsumeRequest)
 	return int(newUsed), nil
 }
 
+// ApplyUsageDelta implements goquota.UsageDeltaStore with a single UPSERT
+func (s *Storage) ApplyUsageDelta(ctx context.Context, req *goquota.UsageDeltaRequest) error {
+	if req.Amount < 0 {
+		return goquota.ErrInvalidAmount
+	}
+	if req.Amount == 0 {
+		return nil // No-op
+	}
+
+	_, err := s.pool.Exec(ctx,
+		`INSERT INTO quota_usage
+				(user_id, resource, period_start, period_end, period_type, usage_amount, limit_amount, tier, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NOW())
+			ON CONFLICT (user_id, resource, period_start)
+			DO UPDATE SET usage_amount = quota_usage.usage_amount + EXCLUDED.usage_amount, updated_at = NOW()`,
+		req.UserID, req.Resource, req.Period.Start, req.Period.End,
+		string(req.Period.Type), req.Amount, req.Limit, req.Tier,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to apply usage delta: %w", err)
+	}
+	return nil
+}
+
 // RefundQuota implements goquota.Storage
 //
 //nolint:gocyclo // Complex function handles transaction, idempotency, and period calculation
@@ -447,6 +533,9 @@ func (s *Storage) RefundQuota(ctx context.Context, req *goquota.RefundRequest) e
 
 	// Check idempotency (scoped to user_id)
 	if req.IdempotencyKey != "" {
+		if err := s.lockIdempotencyKey(ctx, tx, "refund_records", req.UserID, req.IdempotencyKey); err != nil {
+			return err
+		}
 		var exists bool
 		err := tx.QueryRow(ctx,
 			`SELECT EXISTS(SELECT 1 FROM refund_records 
@@ -500,7 +589,7 @@ func (s *Storage) RefundQuota(ctx context.Context, req *goquota.RefundRequest) e
 				 (refund_id, user_id, resource, amount, period_start, 
 				  period_end, period_type, expires_at, reason, metadata)
 				 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
-				 ON CONFLICT (user_id, refund_id) DO NOTHING`,
+				 ON CONFLICT DO NOTHING`,
 				req.IdempotencyKey, req.UserID, req.Resource, req.Amount,
 				period.Start, period.End, string(period.Type),
 				expiresAt, req.Reason, metadataVal)
@@ -558,7 +647,7 @@ func (s *Storage) RefundQuota(ctx context.Context, req *goquota.RefundRequest) e
 			 (refund_id, user_id, resource, amount, period_start, 
 			  period_end, period_type, expires_at, reason, metadata)
 			 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
-			 ON CONFLICT (user_id, refund_id) DO NOTHING`,
+			 ON CONFLICT DO NOTHING`,
 			req.IdempotencyKey, req.UserID, req.Resource, req.Amount,
 			period.Start, period.End, string(period.Type),
 			expiresAt, req.Reason, metadataVal)
@@ -696,9 +785,6 @@ func (s *Storage) GetConsumptionRecord(ctx context.Context, idempotencyKey strin
 	return &record, nil
 }
 
-// CheckRateLimit and RecordRateLimitRequest are automatically handled
-// by the embedded memory.Storage - no explicit implementation needed
-
 // startCleanup runs periodic cleanup of expired records
 // Tip 2: Uses a dedicated context that can be canceled via Close()
 func (s *Storage) startCleanup(ctx context.Context) {
@@ -719,25 +805,73 @@ func (s *Storage) startCleanup(ctx context.Context) {
 	}
 }
 
-// cleanupExpiredRecords deletes expired consumption and refund records
+// cleanupExpiredRecords deletes expired consumption and refund records, concurrency leases,
+// rate limit state, and usage snapshots and warning notifications of ended periods.
+// Once tables are partitioned, it creates upcoming partitions and drops the expired ones.
+// It then applies the retention policy (Config.Retention).
 func (s *Storage) cleanupExpiredRecords(ctx context.Context) error {
 	now := time.Now().UTC()
+	if err := s.refreshPartitions(ctx); err != nil {
+		return err
+	}
+
+	// Delete expired consumption and refund records, unless they are dropped (and exported)
+	// with their partition
+	for _, table := range []string{"consumption_records", "refund_records"} {
+		if s.partitions.partitioned(table) {
+			continue
+		}
+		_, err := s.pool.Exec(ctx, `DELETE FROM `+table+` WHERE expires_at < $1`, now)
+		if err != nil {
+			return fmt.Errorf("failed to cleanup %s: %w", table, err)
+		}
+	}
 
-	// Delete expired consumption records
+	// Delete expired concurrency leases
 	_, err := s.pool.Exec(ctx,
-		`DELETE FROM consumption_records WHERE expires_at < $1`, now)
+		`DELETE FROM concurrency_leases WHERE expires_at < $1`, now)
 	if err != nil {
-		return fmt.Errorf("failed to cleanup consumption records: %w", err)
+		return fmt.Errorf("failed to cleanup concurrency leases: %w", err)
 	}
 
-	// Delete expired refund records
+	// Delete rate limit state that no longer limits anything
 	_, err = s.pool.Exec(ctx,
-		`DELETE FROM refund_records WHERE expires_at < $1`, now)
+		`DELETE FROM rate_limits WHERE expires_at < $1`, now)
 	if err != nil {
-		return fmt.Errorf("failed to cleanup refund records: %w", err)
+		return fmt.Errorf("failed to cleanup rate limits: %w", err)
 	}
 
-	return nil
+	// Delete usage snapshots of periods that ended more than the retention ago
+	_, err = s.pool.Exec(ctx,
+		`DELETE FROM usage_snapshots WHERE period_end < $1`, now.Add(-snapshotRetention))
+	if err != nil {
+		return fmt.Errorf("failed to cleanup usage snapshots: %w", err)
+	}
+
+	// Delete warning notifications of periods that ended more than the retention ago
+	_, err = s.pool.Exec(ctx,
+		`DELETE FROM warning_notifications WHERE period_end < $1`, now.Add(-snapshotRetention))
+	if err != nil {
+		return fmt.Errorf("failed to cleanup warning notifications: %w", err)
+	}
+
+	if !s.partitions.any() && s.config.Retention == nil {
+		return nil
+	}
+
+	// Instances take turns: the others skip this while one runs it
+	_, err = withMaintenanceLock(ctx, s.pool, func() error {
+		if err := s.maintainPartitions(ctx, now); err != nil {
+			return err
+		}
+		if s.config.Retention == nil {
+			// Without a retention policy, usage history is kept but expired records still
+			// go with their partition
+			return s.dropExpiredRecordPartitions(ctx, RetentionConfig{}, now, &RetentionReport{})
+		}
+		return s.applyRetention(ctx, now, &RetentionReport{})
+	})
+	return err
 }
 
 // Cleanup can be called manually to clean up expired records
@@ -817,13 +951,28 @@ func (s *Storage) SubtractLimit(
 
 	// 1. Check idempotency INSIDE transaction (if key provided)
 	if idempotencyKey != "" {
+		if err := s.lockIdempotencyKey(ctx, tx, "refund_records", userID, idempotencyKey); err != nil {
+			return err
+		}
+		var exists bool
+		err := tx.QueryRow(ctx,
+			`SELECT EXISTS(SELECT 1 FROM refund_records WHERE user_id = $1 AND refund_id = $2)`,
+			userID, idempotencyKey).Scan(&exists)
+		if err != nil {
+			return fmt.Errorf("failed to check idempotency: %w", err)
+		}
+		if exists {
+			// Idempotency key already exists - operation already processed
+			return goquota.ErrIdempotencyKeyExists
+		}
+
 		var existingID string
-		err := tx.QueryRow(ctx, `
+		err = tx.QueryRow(ctx, `
 			INSERT INTO refund_records (
 				refund_id, user_id, resource, amount, period_start, period_end, period_type, timestamp, expires_at, reason, metadata
 			)
 			VALUES ($1, $2, $3, $4, $5, $6, $7, NOW(), NOW() + INTERVAL '24 hours', '', NULL)
-			ON CONFLICT (user_id, refund_id) DO NOTHING
+			ON CONFLICT DO NOTHING
 			RETURNING refund_id
 		`, idempotencyKey, userID, resource, amount, period.Start, period.End, string(period.Type)).Scan(&existingID)
 
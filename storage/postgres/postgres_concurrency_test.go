@@ -0,0 +1,65 @@
+//go:build integration
+// +build integration
+
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mihaimyh/goquota/pkg/goquota"
+)
+
+func TestStorage_ConcurrencyLeases(t *testing.T) {
+	storage := setupTestStorage(t)
+	defer storage.Close()
+	ctx := context.Background()
+
+	_, _ = storage.pool.Exec(ctx, "TRUNCATE TABLE concurrency_leases")
+
+	now := time.Now().UTC()
+	req := func(id string, at time.Time) *goquota.LeaseRequest {
+		return &goquota.LeaseRequest{
+			UserID:        "user1",
+			Resource:      "renders",
+			LeaseID:       id,
+			MaxConcurrent: 2,
+			TTL:           time.Minute,
+			Now:           at,
+		}
+	}
+
+	for _, id := range []string{"a", "b"} {
+		acquired, _, err := storage.AcquireLease(ctx, req(id, now))
+		if err != nil || !acquired {
+			t.Fatalf("Expected lease %s to be acquired, got acquired=%v err=%v", id, acquired, err)
+		}
+	}
+
+	acquired, active, err := storage.AcquireLease(ctx, req("c", now))
+	if err != nil {
+		t.Fatalf("AcquireLease failed: %v", err)
+	}
+	if acquired || active != 2 {
+		t.Errorf("Expected denial with 2 active, got acquired=%v active=%d", acquired, active)
+	}
+
+	renewed, err := storage.RenewLease(ctx, req("a", now.Add(30*time.Second)))
+	if err != nil || !renewed {
+		t.Errorf("Expected renewal, got renewed=%v err=%v", renewed, err)
+	}
+
+	if err := storage.ReleaseLease(ctx, "user1", "renders", "b"); err != nil {
+		t.Fatalf("ReleaseLease failed: %v", err)
+	}
+
+	// Only lease "a" (renewed to now+1m30s) is still held
+	acquired, active, err = storage.AcquireLease(ctx, req("c", now.Add(70*time.Second)))
+	if err != nil {
+		t.Fatalf("AcquireLease failed: %v", err)
+	}
+	if !acquired || active != 2 {
+		t.Errorf("Expected lease to be acquired with 2 active, got acquired=%v active=%d", acquired, active)
+	}
+}
@@ -0,0 +1,86 @@
+//go:build integration
+// +build integration
+
+package postgres
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestMigrate(t *testing.T) {
+	storage := setupTestStorage(t)
+	defer storage.Close()
+	ctx := context.Background()
+
+	version, err := SchemaVersion(ctx, storage.pool)
+	if err != nil {
+		t.Fatalf("SchemaVersion failed: %v", err)
+	}
+	if version != LatestSchemaVersion() {
+		t.Fatalf("Expected AutoMigrate to reach version %d, got %d", LatestSchemaVersion(), version)
+	}
+
+	// Revert the last migrations, then apply them again from concurrent runners
+	if err := MigrateTo(ctx, storage.pool, 6); err != nil {
+		t.Fatalf("MigrateTo failed: %v", err)
+	}
+	if version, _ := SchemaVersion(ctx, storage.pool); version != 6 {
+		t.Errorf("Expected version 6, got %d", version)
+	}
+
+	config := DefaultConfig()
+	config.ConnectionString = getTestConnectionString()
+	config.CleanupEnabled = false
+	if _, err := New(ctx, config); !errors.Is(err, ErrSchemaVersionMismatch) {
+		t.Errorf("Expected ErrSchemaVersionMismatch, got %v", err)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 3)
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- Migrate(ctx, storage.pool)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Errorf("Migrate failed: %v", err)
+		}
+	}
+
+	if version, _ := SchemaVersion(ctx, storage.pool); version != LatestSchemaVersion() {
+		t.Errorf("Expected version %d, got %d", LatestSchemaVersion(), version)
+	}
+	var tables int
+	err = storage.pool.QueryRow(ctx,
+		`SELECT COUNT(*) FROM pg_tables WHERE tablename IN ('usage_statements', 'rate_limits')`).Scan(&tables)
+	if err != nil || tables != 2 {
+		t.Errorf("Expected reapplied tables, got %d (err=%v)", tables, err)
+	}
+
+	// As if the migration files were applied by hand
+	if _, err := storage.pool.Exec(ctx, `DELETE FROM `+schemaMigrationsTable); err != nil {
+		t.Fatalf("Failed to clear schema migrations: %v", err)
+	}
+	if _, err := New(ctx, config); !errors.Is(err, ErrSchemaNotBaselined) {
+		t.Errorf("Expected ErrSchemaNotBaselined from New, got %v", err)
+	}
+	if err := Migrate(ctx, storage.pool); !errors.Is(err, ErrSchemaNotBaselined) {
+		t.Errorf("Expected ErrSchemaNotBaselined from Migrate, got %v", err)
+	}
+	if err := Baseline(ctx, storage.pool, LatestSchemaVersion()); err != nil {
+		t.Fatalf("Baseline failed: %v", err)
+	}
+	baselined, err := New(ctx, config)
+	if err != nil {
+		t.Fatalf("Expected New to succeed after Baseline, got %v", err)
+	}
+	baselined.Close()
+}
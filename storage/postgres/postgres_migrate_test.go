@@ -0,0 +1,78 @@
+package postgres
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestEmbeddedMigrations(t *testing.T) {
+	if LatestSchemaVersion() != 13 {
+		t.Errorf("Expected 13 embedded migrations, got %d", LatestSchemaVersion())
+	}
+	for i, m := range migrations {
+		if m.version != i+1 {
+			t.Errorf("Expected migration %d at index %d, got %d", i+1, i, m.version)
+		}
+		if m.name == "" || m.up == "" || m.down == "" {
+			t.Errorf("Expected migration %d to have a name, an up and a down script", m.version)
+		}
+	}
+	if migrations[0].name != "initial_schema" {
+		t.Errorf("Expected first migration initial_schema, got %s", migrations[0].name)
+	}
+}
+
+func TestLoadMigrations_Invalid(t *testing.T) {
+	file := func(content string) *fstest.MapFile { return &fstest.MapFile{Data: []byte(content)} }
+	tests := []struct {
+		name  string
+		files fstest.MapFS
+		err   string
+	}{
+		{"gap", fstest.MapFS{
+			"migrations/001_a.sql": file("CREATE TABLE a ();"),
+			"migrations/003_c.sql": file("CREATE TABLE c ();"),
+		}, "missing migration 2"},
+		{"down only", fstest.MapFS{
+			"migrations/001_a.down.sql": file("DROP TABLE a;"),
+		}, "no up migration"},
+		{"bad name", fstest.MapFS{
+			"migrations/initial.sql": file("CREATE TABLE a ();"),
+		}, "invalid migration file name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := loadMigrations(tt.files)
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("Expected error containing %q, got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestChangeNotificationTriggers(t *testing.T) {
+	m, quiet := migrations[8], migrations[12]
+	if m.name != "change_notifications" {
+		t.Fatalf("Expected migration 9 change_notifications, got %s", m.name)
+	}
+	if quiet.name != "quiet_consumption" {
+		t.Fatalf("Expected migration 13 quiet_consumption, got %s", quiet.name)
+	}
+	up, down := m.up+quiet.up, m.down+quiet.down
+	for _, trigger := range changeNotificationTriggers {
+		if !strings.Contains(up, "CREATE TRIGGER "+trigger.name+"\n") ||
+			!strings.Contains(up, "ON "+trigger.table+"\n") ||
+			!strings.Contains(down, "DROP TRIGGER IF EXISTS "+trigger.name+" ON "+trigger.table) {
+			t.Errorf("Expected migrations 9 and 13 to create and drop trigger %s on %s", trigger.name, trigger.table)
+		}
+		if !strings.Contains(up, "DISABLE TRIGGER "+trigger.name+";") {
+			t.Errorf("Expected trigger %s to be created disabled", trigger.name)
+		}
+	}
+	for _, migration := range []migration{m, quiet} {
+		if !strings.Contains(migration.up, "goquota_notify_change('"+ChangeNotificationChannel+"')") {
+			t.Errorf("Expected the triggers of migration %d to notify %s", migration.version, ChangeNotificationChannel)
+		}
+	}
+}
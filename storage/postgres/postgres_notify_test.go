@@ -0,0 +1,219 @@
+//go:build integration
+// +build integration
+
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mihaimyh/goquota/pkg/goquota"
+)
+
+// subscribeInvalidations subscribes to the storage's invalidation bus and returns the received invalidations
+func subscribeInvalidations(t *testing.T, storage *Storage) <-chan *goquota.CacheInvalidation {
+	received := make(chan *goquota.CacheInvalidation, 100)
+	unsubscribe, err := storage.InvalidationBus().Subscribe(func(invalidation *goquota.CacheInvalidation) {
+		received <- invalidation
+	})
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+	t.Cleanup(unsubscribe)
+	return received
+}
+
+// expectInvalidation waits for the next invalidation and checks its kind and key
+func expectInvalidation(t *testing.T, received <-chan *goquota.CacheInvalidation, kind, key string) {
+	t.Helper()
+	select {
+	case invalidation := <-received:
+		if invalidation.Kind != kind || invalidation.Key != key {
+			t.Errorf("Expected %s invalidation of %q, got %s of %q", kind, key, invalidation.Kind, invalidation.Key)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Timed out waiting for %s invalidation of %q", kind, key)
+	}
+}
+
+func TestInvalidationBus_PublishSubscribe(t *testing.T) {
+	storage := setupTestStorage(t)
+	defer storage.Close()
+	ctx := context.Background()
+
+	received := subscribeInvalidations(t, storage)
+
+	err := storage.InvalidationBus().Publish(ctx, &goquota.CacheInvalidation{
+		Source: "instance1", Kind: goquota.CacheInvalidationEntitlement, Key: "user1",
+	})
+	if err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+	expectInvalidation(t, received, goquota.CacheInvalidationEntitlement, "user1")
+}
+
+func TestInvalidationBus_ChangeNotifications(t *testing.T) {
+	storage := setupTestStorage(t)
+	defer storage.Close()
+	ctx := context.Background()
+
+	if err := EnableChangeNotifications(ctx, storage.pool); err != nil {
+		t.Fatalf("Failed to enable change notifications: %v", err)
+	}
+	defer func() { _ = DisableChangeNotifications(ctx, storage.pool) }()
+	if enabled, err := ChangeNotificationsEnabled(ctx, storage.pool); err != nil || !enabled {
+		t.Fatalf("Expected change notifications enabled, got %v (%v)", enabled, err)
+	}
+
+	received := subscribeInvalidations(t, storage)
+	now := time.Now().UTC()
+
+	// Written by goquota
+	if err := storage.SetEntitlement(ctx, &goquota.Entitlement{
+		UserID: "user1", Tier: "free", SubscriptionStartDate: now, UpdatedAt: now,
+	}); err != nil {
+		t.Fatalf("Failed to set entitlement: %v", err)
+	}
+	expectInvalidation(t, received, goquota.CacheInvalidationEntitlement, "user1")
+
+	// Written directly in the database, e.g. from an admin backoffice
+	if _, err := storage.pool.Exec(ctx, `UPDATE entitlements SET tier_id = 'pro' WHERE user_id = 'user1'`); err != nil {
+		t.Fatalf("Failed to update entitlement: %v", err)
+	}
+	expectInvalidation(t, received, goquota.CacheInvalidationEntitlement, "user1")
+
+	// Usage keys are the goquota.Manager cache keys
+	period := goquota.Period{
+		Start: time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC),
+		End:   time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC),
+		Type:  goquota.PeriodTypeDaily,
+	}
+	if err := storage.SetUsage(ctx, "user1", "api_calls", &goquota.Usage{
+		UserID: "user1", Resource: "api_calls", Used: 5, Limit: 100, Period: period, Tier: "pro",
+	}, period); err != nil {
+		t.Fatalf("Failed to set usage: %v", err)
+	}
+	expectInvalidation(t, received, goquota.CacheInvalidationUsage, "user1:api_calls:"+period.Key())
+
+	forever := goquota.Period{Start: period.Start, Type: goquota.PeriodTypeForever}
+	if err := storage.AddLimit(ctx, "user1", "credits", 50, forever, ""); err != nil {
+		t.Fatalf("Failed to add limit: %v", err)
+	}
+	expectInvalidation(t, received, goquota.CacheInvalidationUsage, "user1:credits:forever")
+
+	// Consumptions are not notified, other usage updates are
+	if _, err := storage.ConsumeQuota(ctx, &goquota.ConsumeRequest{
+		UserID: "user1", Resource: "api_calls", Amount: 1, Tier: "pro", Period: period, Limit: 100,
+	}); err != nil {
+		t.Fatalf("Failed to consume quota: %v", err)
+	}
+	select {
+	case invalidation := <-received:
+		t.Errorf("Expected no invalidation of a consumption, got %+v", invalidation)
+	case <-time.After(500 * time.Millisecond):
+	}
+	if err := storage.ApplyTierChange(ctx, &goquota.TierChangeRequest{
+		UserID: "user1", Resource: "api_calls", OldTier: "pro", NewTier: "free", Period: period, NewLimit: 10,
+	}); err != nil {
+		t.Fatalf("Failed to apply tier change: %v", err)
+	}
+	expectInvalidation(t, received, goquota.CacheInvalidationUsage, "user1:api_calls:"+period.Key())
+	if _, err := storage.pool.Exec(ctx, `UPDATE quota_usage SET usage_amount = 0 WHERE user_id = 'user1'`); err != nil {
+		t.Fatalf("Failed to update usage: %v", err)
+	}
+	expectInvalidation(t, received, goquota.CacheInvalidationUsage, "user1:api_calls:"+period.Key())
+
+	// Unchanged rows are not notified; truncating clears everything
+	if _, err := storage.pool.Exec(ctx, `UPDATE entitlements SET tier_id = tier_id`); err != nil {
+		t.Fatalf("Failed to update entitlements: %v", err)
+	}
+	if _, err := storage.pool.Exec(ctx, `TRUNCATE TABLE entitlements`); err != nil {
+		t.Fatalf("Failed to truncate entitlements: %v", err)
+	}
+	expectInvalidation(t, received, goquota.CacheInvalidationClear, "")
+
+	// Disabled triggers notify nothing
+	if err := DisableChangeNotifications(ctx, storage.pool); err != nil {
+		t.Fatalf("Failed to disable change notifications: %v", err)
+	}
+	if err := storage.SetEntitlement(ctx, &goquota.Entitlement{
+		UserID: "user2", Tier: "free", SubscriptionStartDate: now, UpdatedAt: now,
+	}); err != nil {
+		t.Fatalf("Failed to set entitlement: %v", err)
+	}
+	select {
+	case invalidation := <-received:
+		t.Errorf("Expected no invalidation with disabled triggers, got %+v", invalidation)
+	case <-time.After(500 * time.Millisecond):
+	}
+}
+
+func TestInvalidationBus_Reconnect(t *testing.T) {
+	storage := setupTestStorage(t)
+	defer storage.Close()
+	ctx := context.Background()
+
+	received := subscribeInvalidations(t, storage)
+
+	// Kill the listening connection
+	_, err := storage.pool.Exec(ctx, `
+		SELECT pg_terminate_backend(pid) FROM pg_stat_activity
+		WHERE query LIKE 'LISTEN %' AND pid <> pg_backend_pid()
+	`)
+	if err != nil {
+		t.Fatalf("Failed to terminate listener: %v", err)
+	}
+
+	// Notifications may have been missed: the subscriber is told to clear its cache
+	expectInvalidation(t, received, goquota.CacheInvalidationClear, "")
+
+	err = storage.InvalidationBus().Publish(ctx, &goquota.CacheInvalidation{
+		Source: "instance1", Kind: goquota.CacheInvalidationUsage, Key: "user1:api_calls:forever",
+	})
+	if err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+	expectInvalidation(t, received, goquota.CacheInvalidationUsage, "user1:api_calls:forever")
+}
+
+func TestInvalidationBus_InvalidatesDistributedCache(t *testing.T) {
+	storage := setupTestStorage(t)
+	defer storage.Close()
+	ctx := context.Background()
+
+	if err := EnableChangeNotifications(ctx, storage.pool); err != nil {
+		t.Fatalf("Failed to enable change notifications: %v", err)
+	}
+	defer func() { _ = DisableChangeNotifications(ctx, storage.pool) }()
+
+	cache, err := goquota.NewDistributedCache(goquota.DistributedCacheConfig{Bus: storage.InvalidationBus()})
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	defer cache.Close()
+
+	now := time.Now().UTC()
+	ent := &goquota.Entitlement{UserID: "user1", Tier: "free", SubscriptionStartDate: now, UpdatedAt: now}
+	if err := storage.SetEntitlement(ctx, ent); err != nil {
+		t.Fatalf("Failed to set entitlement: %v", err)
+	}
+	// Let the notification of SetEntitlement go by before caching
+	time.Sleep(200 * time.Millisecond)
+	cache.SetEntitlement("user1", ent, time.Hour)
+
+	if _, err := storage.pool.Exec(ctx, `UPDATE entitlements SET tier_id = 'pro' WHERE user_id = 'user1'`); err != nil {
+		t.Fatalf("Failed to update entitlement: %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, ok := cache.GetEntitlement("user1"); !ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the cached entitlement to be invalidated")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,19 @@
+//go:build integration
+// +build integration
+
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mihaimyh/goquota/internal/storagetest"
+)
+
+func TestStorage_Overrides(t *testing.T) {
+	storage := setupTestStorage(t)
+	defer storage.Close()
+
+	_, _ = storage.pool.Exec(context.Background(), "TRUNCATE TABLE manual_overrides")
+	storagetest.Overrides(t, storage)
+}
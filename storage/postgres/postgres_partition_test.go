@@ -0,0 +1,45 @@
+package postgres
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPartitionNames(t *testing.T) {
+	month := monthStart(time.Date(2026, 3, 17, 22, 30, 0, 0, time.FixedZone("UTC-5", -5*3600)))
+	if want := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC); !month.Equal(want) {
+		t.Fatalf("Expected month start %v, got %v", want, month)
+	}
+
+	name := partitionName("quota_usage", month)
+	if name != "quota_usage_p202603" {
+		t.Errorf("Expected quota_usage_p202603, got %s", name)
+	}
+	parsed, ok := parsePartitionMonth("quota_usage", name)
+	if !ok || !parsed.Equal(month) {
+		t.Errorf("Expected %s to parse to %v, got %v (%v)", name, month, parsed, ok)
+	}
+	if bound := partitionBound(month); bound != "2026-03-01 00:00:00+00" {
+		t.Errorf("Expected bound 2026-03-01 00:00:00+00, got %s", bound)
+	}
+
+	invalid := []string{"quota_usage_default", "quota_usage_p2026", "quota_usage_p202613", "refund_records_p202603"}
+	for _, name := range invalid {
+		if _, ok := parsePartitionMonth("quota_usage", name); ok {
+			t.Errorf("Expected %s not to be a monthly partition of quota_usage", name)
+		}
+	}
+}
+
+func TestNew_InvalidRetention(t *testing.T) {
+	config := DefaultConfig()
+	config.ConnectionString = "postgres://localhost:5432/goquota"
+	config.Retention = &RetentionConfig{ArchiveAfterMonths: -1}
+
+	_, err := New(context.Background(), config)
+	if err == nil || !strings.Contains(err.Error(), "ArchiveAfterMonths") {
+		t.Errorf("Expected an ArchiveAfterMonths error, got %v", err)
+	}
+}
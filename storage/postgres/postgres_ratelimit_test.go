@@ -0,0 +1,170 @@
+//go:build integration
+// +build integration
+
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mihaimyh/goquota/pkg/goquota"
+)
+
+func TestStorage_CheckRateLimit_TokenBucket(t *testing.T) {
+	storage := setupTestStorage(t)
+	defer storage.Close()
+	ctx := context.Background()
+
+	_, _ = storage.pool.Exec(ctx, "TRUNCATE TABLE rate_limits")
+
+	now := time.Now().UTC().Truncate(time.Second)
+	req := func(at time.Time) *goquota.RateLimitRequest {
+		return &goquota.RateLimitRequest{
+			UserID:    "user1",
+			Resource:  "api_calls",
+			Algorithm: "token_bucket",
+			Rate:      1,
+			Window:    time.Second,
+			Burst:     3,
+			Now:       at,
+		}
+	}
+
+	for i := 2; i >= 0; i-- {
+		allowed, remaining, _, err := storage.CheckRateLimit(ctx, req(now))
+		if err != nil {
+			t.Fatalf("CheckRateLimit failed: %v", err)
+		}
+		if !allowed || remaining != i {
+			t.Errorf("Expected allowed with %d remaining, got allowed=%v remaining=%d", i, allowed, remaining)
+		}
+	}
+
+	allowed, _, resetTime, err := storage.CheckRateLimit(ctx, req(now))
+	if err != nil {
+		t.Fatalf("CheckRateLimit failed: %v", err)
+	}
+	if allowed {
+		t.Error("Expected empty bucket to deny")
+	}
+	if !resetTime.Equal(now.Add(time.Second)) {
+		t.Errorf("Expected next token at %v, got %v", now.Add(time.Second), resetTime)
+	}
+
+	// Two seconds refill two tokens
+	for i := 1; i >= 0; i-- {
+		allowed, remaining, _, err := storage.CheckRateLimit(ctx, req(now.Add(2*time.Second)))
+		if err != nil {
+			t.Fatalf("CheckRateLimit failed: %v", err)
+		}
+		if !allowed || remaining != i {
+			t.Errorf("Expected allowed with %d remaining, got allowed=%v remaining=%d", i, allowed, remaining)
+		}
+	}
+}
+
+func TestStorage_CheckRateLimit_SlidingWindow(t *testing.T) {
+	storage := setupTestStorage(t)
+	defer storage.Close()
+	ctx := context.Background()
+
+	_, _ = storage.pool.Exec(ctx, "TRUNCATE TABLE rate_limits")
+
+	// Aligned on a fixed window
+	now := time.Now().UTC().Truncate(time.Minute)
+	req := func(at time.Time) *goquota.RateLimitRequest {
+		return &goquota.RateLimitRequest{
+			UserID:    "user1",
+			Resource:  "api_calls",
+			Algorithm: "sliding_window",
+			Rate:      2,
+			Window:    time.Minute,
+			Now:       at,
+		}
+	}
+
+	for i, at := range []time.Time{now, now.Add(10 * time.Second)} {
+		allowed, remaining, _, err := storage.CheckRateLimit(ctx, req(at))
+		if err != nil {
+			t.Fatalf("CheckRateLimit failed: %v", err)
+		}
+		if !allowed || remaining != 1-i {
+			t.Errorf("Expected allowed with %d remaining, got allowed=%v remaining=%d", 1-i, allowed, remaining)
+		}
+	}
+
+	allowed, _, resetTime, err := storage.CheckRateLimit(ctx, req(now.Add(20*time.Second)))
+	if err != nil {
+		t.Fatalf("CheckRateLimit failed: %v", err)
+	}
+	if allowed {
+		t.Error("Expected full window to deny")
+	}
+	if !resetTime.Equal(now.Add(time.Minute)) {
+		t.Errorf("Expected reset when the next fixed window starts, got %v", resetTime)
+	}
+
+	// One second into the next window, the previous one still counts for floor(2 * 59/60) = 1
+	allowed, remaining, _, err := storage.CheckRateLimit(ctx, req(now.Add(61*time.Second)))
+	if err != nil {
+		t.Fatalf("CheckRateLimit failed: %v", err)
+	}
+	if !allowed || remaining != 0 {
+		t.Errorf("Expected allowed with 0 remaining, got allowed=%v remaining=%d", allowed, remaining)
+	}
+	allowed, _, resetTime, err = storage.CheckRateLimit(ctx, req(now.Add(62*time.Second)))
+	if err != nil {
+		t.Fatalf("CheckRateLimit failed: %v", err)
+	}
+	if allowed {
+		t.Error("Expected full window to deny")
+	}
+	if !resetTime.Equal(now.Add(90 * time.Second)) {
+		t.Errorf("Expected reset once half of the previous window has left, got %v", resetTime)
+	}
+
+	// The previous window no longer counts
+	allowed, _, _, err = storage.CheckRateLimit(ctx, req(now.Add(91*time.Second)))
+	if err != nil {
+		t.Fatalf("CheckRateLimit failed: %v", err)
+	}
+	if !allowed {
+		t.Error("Expected allowed once the previous window's weight dropped")
+	}
+}
+
+func TestStorage_CheckRateLimit_SharedAcrossInstances(t *testing.T) {
+	first := setupTestStorage(t)
+	defer first.Close()
+	second := setupTestStorage(t)
+	defer second.Close()
+	ctx := context.Background()
+
+	_, _ = first.pool.Exec(ctx, "TRUNCATE TABLE rate_limits")
+
+	req := &goquota.RateLimitRequest{
+		UserID:    "user1",
+		Resource:  "api_calls",
+		Algorithm: "sliding_window",
+		Rate:      10,
+		Window:    time.Minute,
+		Now:       time.Now().UTC(),
+	}
+
+	allowedCount := 0
+	for i := 0; i < 10; i++ {
+		for _, storage := range []*Storage{first, second} {
+			allowed, _, _, err := storage.CheckRateLimit(ctx, req)
+			if err != nil {
+				t.Fatalf("CheckRateLimit failed: %v", err)
+			}
+			if allowed {
+				allowedCount++
+			}
+		}
+	}
+	if allowedCount != 10 {
+		t.Errorf("Expected 10 requests allowed across both instances, got %d", allowedCount)
+	}
+}
@@ -0,0 +1,255 @@
+//go:build integration
+// +build integration
+
+package postgres
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/mihaimyh/goquota/pkg/goquota"
+)
+
+// dailyPeriod returns the daily period starting at start
+func dailyPeriod(start time.Time) goquota.Period {
+	return goquota.Period{Start: start, End: start.Add(24 * time.Hour), Type: goquota.PeriodTypeDaily}
+}
+
+// setTestUsage stores usage of api_calls for userID in period
+func setTestUsage(t *testing.T, storage *Storage, userID string, period goquota.Period, used int) {
+	t.Helper()
+	err := storage.SetUsage(context.Background(), userID, "api_calls", &goquota.Usage{
+		UserID: userID, Resource: "api_calls", Used: used, Limit: 100, Period: period, Tier: "pro",
+	}, period)
+	if err != nil {
+		t.Fatalf("Failed to set usage: %v", err)
+	}
+}
+
+func TestPartitionTables(t *testing.T) {
+	storage := setupTestStorage(t)
+	defer storage.Close()
+	ctx := context.Background()
+
+	now := time.Now().UTC()
+	old := dailyPeriod(monthStart(now).AddDate(0, -2, 0))
+	current := dailyPeriod(time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC))
+	setTestUsage(t, storage, "user1", old, 7)
+	setTestUsage(t, storage, "user1", current, 3)
+
+	consume := &goquota.ConsumeRequest{
+		UserID: "user1", Resource: "api_calls", Amount: 2, Tier: "pro",
+		Period: current, Limit: 100, IdempotencyKey: "consume-1",
+	}
+	if _, err := storage.ConsumeQuota(ctx, consume); err != nil {
+		t.Fatalf("ConsumeQuota failed: %v", err)
+	}
+	expired := monthStart(now).AddDate(0, -2, 0)
+	_, err := storage.pool.Exec(ctx, `
+		INSERT INTO consumption_records
+			(consumption_id, user_id, resource, amount, period_start, period_end, period_type, new_used, expires_at)
+		VALUES ('expired-1', 'user1', 'api_calls', 1, $1, $2, 'daily', 1, $1)
+	`, expired, expired.Add(24*time.Hour))
+	if err != nil {
+		t.Fatalf("Failed to insert expired record: %v", err)
+	}
+
+	if err := PartitionTables(ctx, storage.pool); err != nil {
+		t.Fatalf("PartitionTables failed: %v", err)
+	}
+	// Already partitioned tables are skipped
+	if err := PartitionTables(ctx, storage.pool); err != nil {
+		t.Fatalf("Second PartitionTables failed: %v", err)
+	}
+
+	// The cleanup job notices the partitioned tables, then drops expired record partitions
+	// even without a retention policy
+	if err := storage.Cleanup(ctx); err != nil {
+		t.Fatalf("Cleanup failed: %v", err)
+	}
+	for _, table := range partitionedTables {
+		if !storage.partitions.partitioned(table.name) {
+			t.Errorf("Expected %s to be cached as partitioned", table.name)
+		}
+	}
+	records, err := listPartitions(ctx, storage.pool, "consumption_records")
+	if err != nil {
+		t.Fatalf("Failed to list partitions: %v", err)
+	}
+	for _, p := range records {
+		if !p.month.After(expired) {
+			t.Errorf("Expected expired partition %s to be dropped", p.name)
+		}
+	}
+
+	for _, table := range partitionedTables {
+		partitioned, err := isPartitioned(ctx, storage.pool, table.name)
+		if err != nil || !partitioned {
+			t.Fatalf("Expected %s to be partitioned, got %v (%v)", table.name, partitioned, err)
+		}
+		partitions, err := listPartitions(ctx, storage.pool, table.name)
+		if err != nil {
+			t.Fatalf("Failed to list partitions: %v", err)
+		}
+		last := partitions[len(partitions)-1]
+		if want := monthStart(now).AddDate(0, partitionPremakeMonths, 0); !last.month.Equal(want) {
+			t.Errorf("Expected %s partitions up to %v, got %v", table.name, want, last.month)
+		}
+	}
+
+	// Rows were carried over
+	usage, err := storage.GetUsage(ctx, "user1", "api_calls", old)
+	if err != nil || usage == nil || usage.Used != 7 {
+		t.Fatalf("Expected old usage of 7, got %+v (%v)", usage, err)
+	}
+
+	// Idempotency keys still hold without a unique constraint
+	used, err := storage.ConsumeQuota(ctx, consume)
+	if err != nil || used != 5 {
+		t.Errorf("Expected idempotent consumption to return 5, got %d (%v)", used, err)
+	}
+	if err := storage.SubtractLimit(ctx, "user1", "api_calls", 10, current, "subtract-1"); err != nil {
+		t.Fatalf("SubtractLimit failed: %v", err)
+	}
+	err = storage.SubtractLimit(ctx, "user1", "api_calls", 10, current, "subtract-1")
+	if !errors.Is(err, goquota.ErrIdempotencyKeyExists) {
+		t.Errorf("Expected ErrIdempotencyKeyExists, got %v", err)
+	}
+
+	// New rows go to their partition; the cleanup job keeps partitions ahead
+	setTestUsage(t, storage, "user2", dailyPeriod(monthStart(now).AddDate(0, 1, 0)), 1)
+	if err := storage.Cleanup(ctx); err != nil {
+		t.Fatalf("Cleanup failed: %v", err)
+	}
+}
+
+func TestApplyRetention(t *testing.T) {
+	storage := setupTestStorage(t)
+	defer storage.Close()
+	ctx := context.Background()
+
+	if err := PartitionTables(ctx, storage.pool); err != nil {
+		t.Fatalf("PartitionTables failed: %v", err)
+	}
+	if _, err := storage.pool.Exec(ctx, `TRUNCATE TABLE usage_archive`); err != nil {
+		t.Fatalf("Failed to truncate usage_archive: %v", err)
+	}
+
+	now := time.Now().UTC()
+	oldest := monthStart(now).AddDate(0, -14, 0)
+	older := monthStart(now).AddDate(0, -13, 0)
+	recent := monthStart(now).AddDate(0, -1, 0)
+	ensurePartitions := func(table partitionedTable, months ...time.Time) {
+		partitions, err := listPartitions(ctx, storage.pool, table.name)
+		if err != nil {
+			t.Fatalf("Failed to list partitions: %v", err)
+		}
+		existing := map[time.Time]bool{}
+		for _, p := range partitions {
+			existing[p.month] = true
+		}
+		for _, month := range months {
+			if existing[month] {
+				continue
+			}
+			err := pgx.BeginFunc(ctx, storage.pool, func(tx pgx.Tx) error {
+				return createPartition(ctx, tx, table, month)
+			})
+			if err != nil {
+				t.Fatalf("Failed to create partition: %v", err)
+			}
+		}
+	}
+	ensurePartitions(partitionedTables[0], oldest, older, recent)
+	ensurePartitions(partitionedTables[1], recent.AddDate(0, -1, 0))
+
+	// The oldest month only holds closed periods: its partition is archived and dropped
+	setTestUsage(t, storage, "user1", dailyPeriod(oldest), 10)
+	setTestUsage(t, storage, "user1", dailyPeriod(oldest.AddDate(0, 0, 1)), 30)
+	// The older month also holds forever credits: its closed periods are archived row by row
+	setTestUsage(t, storage, "user2", dailyPeriod(older), 5)
+	forever := goquota.Period{Start: older.AddDate(0, 0, 2), Type: goquota.PeriodTypeForever}
+	if err := storage.AddLimit(ctx, "user2", "credits", 500, forever, ""); err != nil {
+		t.Fatalf("AddLimit failed: %v", err)
+	}
+	// Recent periods are kept
+	setTestUsage(t, storage, "user3", dailyPeriod(recent), 1)
+	// Records that expired two months ago
+	_, err := storage.pool.Exec(ctx, `
+		INSERT INTO consumption_records
+			(consumption_id, user_id, resource, amount, period_start, period_end, period_type, new_used, expires_at)
+		VALUES ('expired-1', 'user1', 'api_calls', 1, $1, $2, 'daily', 1, $1)
+	`, recent.AddDate(0, -1, 0), recent.AddDate(0, -1, 1))
+	if err != nil {
+		t.Fatalf("Failed to insert consumption record: %v", err)
+	}
+
+	exported := map[string]string{}
+	storage.config.Retention = &RetentionConfig{
+		ArchiveAfterMonths: 12,
+		Export: func(_ context.Context, partition string, csv io.Reader) error {
+			data, err := io.ReadAll(csv)
+			exported[partition] = string(data)
+			return err
+		},
+	}
+	report, err := storage.ApplyRetention(ctx)
+	if err != nil {
+		t.Fatalf("ApplyRetention failed: %v", err)
+	}
+	if report.Skipped || report.Archived != 3 {
+		t.Errorf("Expected 3 archived periods, got %+v", report)
+	}
+
+	oldestPartition := partitionName("quota_usage", oldest)
+	recordPartition := partitionName("consumption_records", recent.AddDate(0, -1, 0))
+	for _, name := range []string{oldestPartition, recordPartition} {
+		if !strings.HasPrefix(exported[name], "id,") {
+			t.Errorf("Expected %s to be exported as CSV, got %q", name, exported[name])
+		}
+		dropped := false
+		for _, d := range report.Dropped {
+			dropped = dropped || d == name
+		}
+		if !dropped {
+			t.Errorf("Expected %s to be dropped, got %v", name, report.Dropped)
+		}
+	}
+	if !strings.Contains(exported[recordPartition], "expired-1") {
+		t.Errorf("Expected the expired record in the export, got %q", exported[recordPartition])
+	}
+
+	var periods, used, maxUsage int64
+	err = storage.pool.QueryRow(ctx, `
+		SELECT periods, usage_amount, max_usage FROM usage_archive
+		WHERE user_id = 'user1' AND resource = 'api_calls' AND period_type = 'daily' AND month = $1
+	`, oldest).Scan(&periods, &used, &maxUsage)
+	if err != nil {
+		t.Fatalf("Failed to read archive: %v", err)
+	}
+	if periods != 2 || used != 40 || maxUsage != 30 {
+		t.Errorf("Expected 2 periods with 40 used (max 30), got %d, %d (max %d)", periods, used, maxUsage)
+	}
+
+	if usage, err := storage.GetUsage(ctx, "user2", "api_calls", dailyPeriod(older)); err != nil || usage != nil {
+		t.Errorf("Expected archived usage to be removed, got %+v (%v)", usage, err)
+	}
+	if usage, err := storage.GetUsage(ctx, "user2", "credits", forever); err != nil || usage == nil || usage.Limit != 500 {
+		t.Errorf("Expected forever credits to be kept, got %+v (%v)", usage, err)
+	}
+	if usage, err := storage.GetUsage(ctx, "user3", "api_calls", dailyPeriod(recent)); err != nil || usage == nil {
+		t.Errorf("Expected recent usage to be kept, got %+v (%v)", usage, err)
+	}
+
+	// Nothing left to do
+	report, err = storage.ApplyRetention(ctx)
+	if err != nil || report.Archived != 0 || len(report.Dropped) != 0 {
+		t.Errorf("Expected an empty second run, got %+v (%v)", report, err)
+	}
+}
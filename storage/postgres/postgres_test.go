@@ -11,6 +11,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/mihaimyh/goquota/internal/storagetest"
 	"github.com/mihaimyh/goquota/pkg/goquota"
 )
 
@@ -30,6 +31,7 @@ func setupTestStorage(t *testing.T) *Storage {
 	config := DefaultConfig()
 	config.ConnectionString = getTestConnectionString()
 	config.CleanupEnabled = false // Disable cleanup in tests
+	config.AutoMigrate = true
 
 	storage, err := New(ctx, config)
 	if err != nil {
@@ -176,6 +178,45 @@ func TestStorage_ConsumeQuota_Exceeds(t *testing.T) {
 	}
 }
 
+func TestStorage_ApplyUsageDelta(t *testing.T) {
+	storage := setupTestStorage(t)
+	defer storage.Close()
+	ctx := context.Background()
+
+	period := goquota.Period{
+		Start: time.Now().UTC(),
+		End:   time.Now().UTC().Add(24 * time.Hour),
+		Type:  goquota.PeriodTypeDaily,
+	}
+
+	req := &goquota.UsageDeltaRequest{
+		UserID:   "user1",
+		Resource: "api_calls",
+		Amount:   60,
+		Tier:     "scholar",
+		Period:   period,
+		Limit:    100,
+	}
+
+	// Deltas are not checked against the limit
+	for i := 0; i < 2; i++ {
+		if err := storage.ApplyUsageDelta(ctx, req); err != nil {
+			t.Fatalf("ApplyUsageDelta failed: %v", err)
+		}
+	}
+
+	usage, err := storage.GetUsage(ctx, "user1", "api_calls", period)
+	if err != nil {
+		t.Fatalf("GetUsage failed: %v", err)
+	}
+	if usage.Used != 120 {
+		t.Errorf("Expected 120 used, got %d", usage.Used)
+	}
+	if usage.Limit != 100 {
+		t.Errorf("Expected limit 100, got %d", usage.Limit)
+	}
+}
+
 func TestStorage_ConsumeQuota_Idempotency(t *testing.T) {
 	storage := setupTestStorage(t)
 	defer storage.Close()
@@ -610,6 +651,7 @@ func TestStorage_GetRefundRecord(t *testing.T) {
 func TestStorage_RateLimiting_DelegatedToMemory(t *testing.T) {
 	storage := setupTestStorage(t)
 	defer storage.Close()
+	storage.config.LocalRateLimits = true
 	ctx := context.Background()
 
 	// Rate limiting should work via embedded memory.Storage
@@ -1511,3 +1553,9 @@ func TestStorage_MultipleUsers_Concurrent(t *testing.T) {
 		}
 	}
 }
+
+func TestStorage_ScanRecords(t *testing.T) {
+	storage := setupTestStorage(t)
+	defer storage.Close()
+	storagetest.ScanRecords(t, storage, true)
+}
@@ -0,0 +1,170 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mihaimyh/goquota/pkg/goquota"
+)
+
+// Token bucket refill since the last refill, and tokens available before this request.
+// They are evaluated on the locked row of an UPSERT, so concurrent checks never lose an update.
+const (
+	tokenBucketRefill = `FLOOR($4::float8
+		* GREATEST(EXTRACT(EPOCH FROM ($3::timestamptz - rate_limits.last_refill))::float8, 0)
+		/ $5::float8)::bigint`
+	tokenBucketAvailable = `CASE WHEN ` + tokenBucketRefill + ` > 0
+		THEN LEAST(rate_limits.tokens + ` + tokenBucketRefill + `, $6::int) ELSE rate_limits.tokens END`
+)
+
+// tokenBucketQuery creates a full bucket minus this request, or refills and takes a token from the existing one
+const tokenBucketQuery = `
+	INSERT INTO rate_limits (user_id, resource, algorithm, tokens, last_refill, allowed, expires_at)
+	VALUES ($1, $2, 'token_bucket', $6::int - 1, $3, true, $7)
+	ON CONFLICT (user_id, resource, algorithm) DO UPDATE SET
+		tokens = GREATEST(` + tokenBucketAvailable + ` - 1, 0),
+		last_refill = CASE WHEN ` + tokenBucketRefill + ` > 0 THEN $3 ELSE rate_limits.last_refill END,
+		allowed = ` + tokenBucketAvailable + ` > 0,
+		expires_at = $7
+	RETURNING allowed, tokens, last_refill`
+
+// Counts of the current ($3) and previous ($7) fixed windows before this request, and the
+// estimated number of requests in the sliding window: the previous count weighted by the
+// share of the previous window still in the sliding window ($5), plus the current count
+const (
+	slidingWindowPrevious = `CASE WHEN rate_limits.window_start = $3 THEN rate_limits.previous_window_count
+		WHEN rate_limits.window_start = $7 THEN rate_limits.window_count ELSE 0 END`
+	slidingWindowCurrent = `CASE WHEN rate_limits.window_start = $3 THEN rate_limits.window_count ELSE 0 END`
+	slidingWindowCount   = `FLOOR((` + slidingWindowPrevious + `) * $5::float8)::int + ` + slidingWindowCurrent
+)
+
+// slidingWindowQuery rolls the fixed windows over and counts this request if the sliding window isn't full
+const slidingWindowQuery = `
+	INSERT INTO rate_limits (user_id, resource, algorithm, window_start, window_count, allowed, expires_at)
+	VALUES ($1, $2, 'sliding_window', $3, 1, true, $6)
+	ON CONFLICT (user_id, resource, algorithm) DO UPDATE SET
+		previous_window_count = ` + slidingWindowPrevious + `,
+		window_count = ` + slidingWindowCurrent + ` + CASE WHEN ` + slidingWindowCount + ` < $4 THEN 1 ELSE 0 END,
+		window_start = $3,
+		allowed = ` + slidingWindowCount + ` < $4,
+		expires_at = $6
+	RETURNING allowed, window_count, previous_window_count`
+
+// CheckRateLimit implements goquota.Storage.
+// Rate limit state lives in the rate_limits table and is updated with a single atomic UPSERT,
+// so limits are enforced globally across instances. With Config.LocalRateLimits, the embedded
+// in-memory limiter is used instead.
+//
+//nolint:gocritic // Named return values would reduce readability here
+func (s *Storage) CheckRateLimit(ctx context.Context, req *goquota.RateLimitRequest) (bool, int, time.Time, error) {
+	if s.config.LocalRateLimits {
+		return s.Storage.CheckRateLimit(ctx, req)
+	}
+	if req == nil {
+		return false, 0, time.Time{}, fmt.Errorf("rate limit request is required")
+	}
+	if req.Window <= 0 {
+		return false, 0, time.Time{}, fmt.Errorf("rate limit window must be positive")
+	}
+	if req.Rate <= 0 {
+		// Nothing is ever allowed
+		return false, 0, req.Now.Add(req.Window), nil
+	}
+
+	switch req.Algorithm {
+	case "token_bucket":
+		return s.checkTokenBucket(ctx, req)
+	case "sliding_window":
+		return s.checkSlidingWindow(ctx, req)
+	default:
+		return false, 0, time.Time{}, fmt.Errorf("unknown rate limit algorithm: %s", req.Algorithm)
+	}
+}
+
+//nolint:gocritic // Named return values would reduce readability here
+func (s *Storage) checkTokenBucket(ctx context.Context, req *goquota.RateLimitRequest) (bool, int, time.Time, error) {
+	burst := req.Burst
+	if burst <= 0 {
+		burst = req.Rate
+	}
+	// Time to refill an empty bucket: past it, a missing row is the same as a full bucket
+	refillInterval := req.Window / time.Duration(req.Rate)
+	expiresAt := req.Now.Add(max(req.Window, refillInterval*time.Duration(burst+1)))
+
+	var allowed bool
+	var tokens int
+	var lastRefill time.Time
+	err := s.pool.QueryRow(ctx, tokenBucketQuery,
+		req.UserID, req.Resource, req.Now, float64(req.Rate), req.Window.Seconds(), burst, expiresAt,
+	).Scan(&allowed, &tokens, &lastRefill)
+	if err != nil {
+		return false, 0, time.Time{}, fmt.Errorf("failed to check token bucket: %w", err)
+	}
+
+	if !allowed {
+		// Calculate when next token will be available
+		nextTokenTime := lastRefill.Add(refillInterval)
+		if nextTokenTime.Before(req.Now) {
+			nextTokenTime = req.Now.Add(refillInterval)
+		}
+		return false, 0, nextTokenTime, nil
+	}
+
+	// Calculate reset time (when bucket will be full again)
+	resetTime := req.Now.Add(req.Window)
+	if tokens < burst {
+		timeToFull := time.Duration(float64(burst-tokens) * float64(req.Window) / float64(req.Rate))
+		resetTime = req.Now.Add(timeToFull)
+	}
+	return true, tokens, resetTime, nil
+}
+
+// checkSlidingWindow approximates a sliding window with the counts of two fixed windows,
+// aligned on multiples of the window, assuming the previous window's requests were evenly spread.
+//
+//nolint:gocritic // Named return values would reduce readability here
+func (s *Storage) checkSlidingWindow(ctx context.Context, req *goquota.RateLimitRequest) (bool, int, time.Time, error) {
+	start := req.Now.Truncate(req.Window)
+	previousWeight := 1 - float64(req.Now.Sub(start))/float64(req.Window)
+
+	var allowed bool
+	var current, previous int
+	err := s.pool.QueryRow(ctx, slidingWindowQuery,
+		req.UserID, req.Resource, start, req.Rate, previousWeight, start.Add(2*req.Window), start.Add(-req.Window),
+	).Scan(&allowed, &current, &previous)
+	if err != nil {
+		return false, 0, time.Time{}, fmt.Errorf("failed to check sliding window: %w", err)
+	}
+
+	if !allowed {
+		return false, 0, slidingWindowReset(start, req.Window, req.Rate, previous, current), nil
+	}
+	// Every counted request has left the sliding window once the next fixed window ends
+	count := int(float64(previous)*previousWeight) + current
+	return true, max(req.Rate-count, 0), start.Add(2 * req.Window), nil
+}
+
+// slidingWindowReset returns when the estimated count of a full sliding window drops below rate
+func slidingWindowReset(start time.Time, window time.Duration, rate, previous, current int) time.Time {
+	if current < rate {
+		// Once enough of the previous window has left the sliding window
+		share := float64(rate-current) / float64(previous)
+		return start.Add(time.Duration((1 - share) * float64(window)))
+	}
+	// Once enough of the current window has left the sliding window, in the next one
+	share := float64(rate) / float64(current)
+	return start.Add(window + time.Duration((1-share)*float64(window)))
+}
+
+// RecordRateLimitRequest implements goquota.Storage.
+// Requests are recorded by CheckRateLimit, so this is a no-op.
+func (s *Storage) RecordRateLimitRequest(ctx context.Context, req *goquota.RateLimitRequest) error {
+	if s.config.LocalRateLimits {
+		return s.Storage.RecordRateLimitRequest(ctx, req)
+	}
+	if req == nil {
+		return fmt.Errorf("rate limit request is required")
+	}
+	return nil
+}
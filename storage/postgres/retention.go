@@ -0,0 +1,260 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// defaultArchiveAfterMonths is how many whole months closed usage periods are kept by default
+const defaultArchiveAfterMonths = 12
+
+// closedUsage matches the usage rows of periods that ended by $1. Forever credits never end.
+const closedUsage = `period_type <> 'forever' AND period_end IS NOT NULL AND period_end <= $1`
+
+// archiveUsageInsert summarizes the usage rows of the archived CTE into usage_archive,
+// one row per user, resource, period type and month
+const archiveUsageInsert = `
+	INSERT INTO usage_archive
+		(user_id, resource, period_type, month, periods, usage_amount, max_usage, limit_amount, tier)
+	SELECT user_id, resource, period_type, date_trunc('month', period_start AT TIME ZONE 'UTC')::date,
+		COUNT(*), SUM(usage_amount), MAX(usage_amount),
+		CASE WHEN bool_or(limit_amount = -1) THEN -1 ELSE SUM(limit_amount) END,
+		(array_agg(tier ORDER BY period_start DESC))[1]
+	FROM archived
+	GROUP BY 1, 2, 3, 4
+	ON CONFLICT (user_id, resource, period_type, month) DO UPDATE SET
+		periods = usage_archive.periods + EXCLUDED.periods,
+		usage_amount = usage_archive.usage_amount + EXCLUDED.usage_amount,
+		max_usage = GREATEST(usage_archive.max_usage, EXCLUDED.max_usage),
+		limit_amount = CASE WHEN usage_archive.limit_amount = -1 OR EXCLUDED.limit_amount = -1 THEN -1
+			ELSE usage_archive.limit_amount + EXCLUDED.limit_amount END,
+		tier = EXCLUDED.tier,
+		archived_at = NOW()
+	RETURNING 1`
+
+// archivePartitionQuery archives every row of a usage partition (%s) and returns their count
+const archivePartitionQuery = `
+	WITH archived AS (SELECT * FROM %s), summarized AS (` + archiveUsageInsert + `)
+	SELECT COUNT(*) FROM archived`
+
+// archiveClosedUsageQuery deletes and archives the periods that started in [$2, $3) and ended by $1
+const archiveClosedUsageQuery = `
+	WITH archived AS (
+		DELETE FROM quota_usage WHERE period_start >= $2 AND period_start < $3 AND ` + closedUsage + `
+		RETURNING *
+	), summarized AS (` + archiveUsageInsert + `)
+	SELECT COUNT(*) FROM archived`
+
+// RetentionConfig configures the retention policy applied by the cleanup job (Config.Retention)
+type RetentionConfig struct {
+	// ArchiveAfterMonths is how many whole months closed usage periods stay in quota_usage
+	// before they are summarized into usage_archive (default: 12)
+	ArchiveAfterMonths int
+
+	// Export receives each expired partition as CSV with a header row before it is dropped.
+	// If it fails, the partition is kept and exported again on the next run.
+	// Optional: expired partitions are dropped without export.
+	Export func(ctx context.Context, partition string, csv io.Reader) error
+}
+
+// RetentionReport is the outcome of ApplyRetention
+type RetentionReport struct {
+	// Archived is the number of usage periods summarized into usage_archive
+	Archived int64
+	// Exported are the partitions passed to RetentionConfig.Export
+	Exported []string
+	// Dropped are the expired partitions dropped
+	Dropped []string
+	// Skipped is set when another instance was applying the policy
+	Skipped bool
+}
+
+// ApplyRetention applies the retention policy of Config.Retention (or its defaults):
+//   - closed usage periods older than ArchiveAfterMonths are summarized into usage_archive and
+//     removed from quota_usage, so usage history and statements no longer return them;
+//   - partitions of quota_usage holding only such periods are dropped after export, instead of
+//     deleting their rows;
+//   - partitions of consumption_records and refund_records whose records all expired are dropped
+//     after export.
+//
+// Partitions only exist after PartitionTables. Instances take turns: if another one is applying
+// the policy, ApplyRetention returns a skipped report.
+func (s *Storage) ApplyRetention(ctx context.Context) (*RetentionReport, error) {
+	report := &RetentionReport{}
+	if err := s.refreshPartitions(ctx); err != nil {
+		return report, err
+	}
+	ran, err := withMaintenanceLock(ctx, s.pool, func() error {
+		return s.applyRetention(ctx, time.Now().UTC(), report)
+	})
+	report.Skipped = !ran
+	return report, err
+}
+
+func (s *Storage) applyRetention(ctx context.Context, now time.Time, report *RetentionReport) error {
+	var config RetentionConfig
+	if s.config.Retention != nil {
+		config = *s.config.Retention
+	}
+	if config.ArchiveAfterMonths <= 0 {
+		config.ArchiveAfterMonths = defaultArchiveAfterMonths
+	}
+	cutoff := monthStart(now).AddDate(0, -config.ArchiveAfterMonths, 0)
+
+	if err := s.dropArchivedUsagePartitions(ctx, config, cutoff, report); err != nil {
+		return err
+	}
+
+	// Closed periods in partitions that cannot be dropped, e.g. next to forever credits,
+	// or in a table that is not partitioned
+	archived, err := s.archiveClosedUsage(ctx, cutoff)
+	report.Archived += archived
+	if err != nil {
+		return err
+	}
+
+	return s.dropExpiredRecordPartitions(ctx, config, now, report)
+}
+
+// dropArchivedUsagePartitions archives and drops the quota_usage partitions before cutoff
+// that only hold closed periods
+func (s *Storage) dropArchivedUsagePartitions(
+	ctx context.Context, config RetentionConfig, cutoff time.Time, report *RetentionReport,
+) error {
+	if !s.partitions.partitioned("quota_usage") {
+		return nil
+	}
+	partitions, err := listPartitions(ctx, s.pool, "quota_usage")
+	if err != nil {
+		return err
+	}
+
+	for _, p := range partitions {
+		if p.month.AddDate(0, 1, 0).After(cutoff) {
+			break
+		}
+		var open bool
+		err := s.pool.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM `+p.name+` WHERE NOT (`+closedUsage+`))`,
+			cutoff).Scan(&open)
+		if err != nil {
+			return fmt.Errorf("failed to check partition %s: %w", p.name, err)
+		}
+		if open {
+			continue
+		}
+		if err := s.exportPartition(ctx, config, p.name, report); err != nil {
+			return err
+		}
+
+		var archived int64
+		err = pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+			if err := tx.QueryRow(ctx, fmt.Sprintf(archivePartitionQuery, p.name)).Scan(&archived); err != nil {
+				return fmt.Errorf("failed to archive partition %s: %w", p.name, err)
+			}
+			if _, err := tx.Exec(ctx, `DROP TABLE `+p.name); err != nil {
+				return fmt.Errorf("failed to drop partition %s: %w", p.name, err)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		report.Archived += archived
+		report.Dropped = append(report.Dropped, p.name)
+	}
+	return nil
+}
+
+// archiveClosedUsage deletes and archives the periods that ended before cutoff, a month at a time
+func (s *Storage) archiveClosedUsage(ctx context.Context, cutoff time.Time) (int64, error) {
+	var oldest *time.Time
+	err := s.pool.QueryRow(ctx, `SELECT MIN(period_start) FROM quota_usage WHERE `+closedUsage, cutoff).Scan(&oldest)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get oldest closed period: %w", err)
+	}
+	if oldest == nil {
+		return 0, nil
+	}
+
+	var total int64
+	for month := monthStart(*oldest); month.Before(cutoff); month = month.AddDate(0, 1, 0) {
+		var archived int64
+		err := s.pool.QueryRow(ctx, archiveClosedUsageQuery, cutoff, month, month.AddDate(0, 1, 0)).Scan(&archived)
+		if err != nil {
+			return total, fmt.Errorf("failed to archive usage of %s: %w", month.Format("2006-01"), err)
+		}
+		total += archived
+	}
+	return total, nil
+}
+
+// dropExpiredRecordPartitions drops the partitions of consumption and refund records that all expired
+func (s *Storage) dropExpiredRecordPartitions(
+	ctx context.Context, config RetentionConfig, now time.Time, report *RetentionReport,
+) error {
+	for _, table := range partitionedTables {
+		if table.key != "expires_at" {
+			continue
+		}
+		if !s.partitions.partitioned(table.name) {
+			continue
+		}
+		partitions, err := listPartitions(ctx, s.pool, table.name)
+		if err != nil {
+			return err
+		}
+
+		for _, p := range partitions {
+			if p.month.AddDate(0, 1, 0).After(now) {
+				break
+			}
+			if err := s.exportPartition(ctx, config, p.name, report); err != nil {
+				return err
+			}
+			if _, err := s.pool.Exec(ctx, `DROP TABLE `+p.name); err != nil {
+				return fmt.Errorf("failed to drop partition %s: %w", p.name, err)
+			}
+			report.Dropped = append(report.Dropped, p.name)
+		}
+	}
+	return nil
+}
+
+// exportPartition streams a partition to config.Export as CSV, if set
+func (s *Storage) exportPartition(
+	ctx context.Context, config RetentionConfig, partition string, report *RetentionReport,
+) error {
+	if config.Export == nil {
+		return nil
+	}
+	conn, err := s.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	reader, writer := io.Pipe()
+	copied := make(chan error, 1)
+	go func() {
+		_, err := conn.Conn().PgConn().CopyTo(ctx, writer, `COPY `+partition+` TO STDOUT WITH (FORMAT csv, HEADER)`)
+		writer.CloseWithError(err)
+		copied <- err
+	}()
+
+	exportErr := config.Export(ctx, partition, reader)
+	// Stops the copy if Export returned before reading everything
+	_ = reader.Close()
+	copyErr := <-copied
+	if exportErr != nil {
+		return fmt.Errorf("failed to export partition %s: %w", partition, exportErr)
+	}
+	if copyErr != nil {
+		return fmt.Errorf("failed to copy partition %s: %w", partition, copyErr)
+	}
+	report.Exported = append(report.Exported, partition)
+	return nil
+}
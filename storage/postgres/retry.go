@@ -0,0 +1,37 @@
+package postgres
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/mihaimyh/goquota/pkg/goquota"
+)
+
+// IsRetryable implements goquota.RetryClassifier
+// Connection failures, timeouts, serialization failures, deadlocks and server shutdowns are retryable.
+func (s *Storage) IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	if goquota.IsTransientError(err) || pgconn.SafeToRetry(err) {
+		return true
+	}
+
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+	switch pgErr.Code {
+	case "40001", // serialization_failure
+		"40P01", // deadlock_detected
+		"53300", // too_many_connections
+		"57P01", // admin_shutdown
+		"57P02", // crash_shutdown
+		"57P03": // cannot_connect_now
+		return true
+	}
+	// Class 08: connection exception
+	return strings.HasPrefix(pgErr.Code, "08")
+}
@@ -0,0 +1,136 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/mihaimyh/goquota/pkg/goquota"
+)
+
+// usageCursorSep separates the primary key columns of a usage scan cursor
+const usageCursorSep = "\x1f"
+
+// ScanEntitlements implements goquota.RecordScanner with keyset pagination on user_id
+func (s *Storage) ScanEntitlements(
+	ctx context.Context, cursor string, limit int,
+) ([]*goquota.Entitlement, string, error) {
+	rows, err := s.pool.Query(ctx,
+		`SELECT user_id, tier_id, subscription_start, expires_at, updated_at
+			FROM entitlements
+			WHERE user_id > $1
+			ORDER BY user_id
+			LIMIT $2`,
+		cursor, limit)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to scan entitlements: %w", err)
+	}
+	defer rows.Close()
+
+	var result []*goquota.Entitlement
+	for rows.Next() {
+		var ent goquota.Entitlement
+		var expiresAt *time.Time
+		if err := rows.Scan(
+			&ent.UserID,
+			&ent.Tier,
+			&ent.SubscriptionStartDate,
+			&expiresAt,
+			&ent.UpdatedAt,
+		); err != nil {
+			return nil, "", fmt.Errorf("failed to scan entitlement: %w", err)
+		}
+		ent.ExpiresAt = expiresAt
+		result = append(result, &ent)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", fmt.Errorf("failed to iterate entitlements: %w", err)
+	}
+
+	if len(result) < limit {
+		return result, "", nil
+	}
+	return result, result[len(result)-1].UserID, nil
+}
+
+// ScanUsage implements goquota.RecordScanner with keyset pagination on the primary key
+// (user_id, resource, period_start)
+func (s *Storage) ScanUsage(ctx context.Context, cursor string, limit int) ([]*goquota.Usage, string, error) {
+	const columns = `SELECT user_id, resource, usage_amount, limit_amount, period_start, period_end,
+				period_type, tier, updated_at
+			FROM quota_usage`
+
+	var rows pgx.Rows
+	var err error
+	if cursor == "" {
+		rows, err = s.pool.Query(ctx,
+			columns+` ORDER BY user_id, resource, period_start LIMIT $1`,
+			limit)
+	} else {
+		userID, resource, start, parseErr := parseUsageCursor(cursor)
+		if parseErr != nil {
+			return nil, "", parseErr
+		}
+		rows, err = s.pool.Query(ctx,
+			columns+` WHERE (user_id, resource, period_start) > ($1, $2, $3)
+			ORDER BY user_id, resource, period_start LIMIT $4`,
+			userID, resource, start, limit)
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to scan usage: %w", err)
+	}
+	defer rows.Close()
+
+	var result []*goquota.Usage
+	for rows.Next() {
+		var usage goquota.Usage
+		var periodEnd *time.Time
+		if err := rows.Scan(
+			&usage.UserID,
+			&usage.Resource,
+			&usage.Used,
+			&usage.Limit,
+			&usage.Period.Start,
+			&periodEnd,
+			&usage.Period.Type,
+			&usage.Tier,
+			&usage.UpdatedAt,
+		); err != nil {
+			return nil, "", fmt.Errorf("failed to scan usage: %w", err)
+		}
+
+		// Handle NULL period_end for forever periods
+		if periodEnd != nil {
+			usage.Period.End = *periodEnd
+		} else {
+			usage.Period.End = time.Date(9999, 12, 31, 23, 59, 59, 0, time.UTC)
+		}
+		result = append(result, &usage)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", fmt.Errorf("failed to iterate usage: %w", err)
+	}
+
+	if len(result) < limit {
+		return result, "", nil
+	}
+	last := result[len(result)-1]
+	return result, strings.Join([]string{
+		last.UserID, last.Resource, last.Period.Start.UTC().Format(time.RFC3339Nano),
+	}, usageCursorSep), nil
+}
+
+func parseUsageCursor(cursor string) (userID, resource string, start time.Time, err error) {
+	parts := strings.Split(cursor, usageCursorSep)
+	if len(parts) != 3 {
+		return "", "", time.Time{}, fmt.Errorf("invalid usage scan cursor")
+	}
+	start, err = time.Parse(time.RFC3339Nano, parts[2])
+	if err != nil {
+		return "", "", time.Time{}, fmt.Errorf("invalid usage scan cursor: %w", err)
+	}
+	return parts[0], parts[1], start, nil
+}
@@ -0,0 +1,70 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mihaimyh/goquota/pkg/goquota"
+)
+
+// snapshotRetention is how long usage snapshots are kept after their period ends
+const snapshotRetention = 24 * time.Hour
+
+// RecordUsageSnapshot implements goquota.UsageSnapshotStore
+func (s *Storage) RecordUsageSnapshot(ctx context.Context, snapshot *goquota.UsageSnapshot) error {
+	if snapshot == nil {
+		return fmt.Errorf("usage snapshot is required")
+	}
+
+	_, err := s.pool.Exec(ctx, `
+		INSERT INTO usage_snapshots
+			(user_id, resource, period_type, period_start, period_end, bucket, used, recorded_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (user_id, resource, period_type, period_start, bucket)
+		DO UPDATE SET used = EXCLUDED.used, recorded_at = EXCLUDED.recorded_at
+		WHERE usage_snapshots.used <= EXCLUDED.used
+	`, snapshot.UserID, snapshot.Resource, string(snapshot.Period.Type),
+		snapshot.Period.Start, snapshot.Period.End, snapshot.Bucket, snapshot.Used, snapshot.RecordedAt)
+	if err != nil {
+		return fmt.Errorf("failed to record usage snapshot: %w", err)
+	}
+	return nil
+}
+
+// GetUsageSnapshots implements goquota.UsageSnapshotStore
+func (s *Storage) GetUsageSnapshots(
+	ctx context.Context, userID, resource string, period goquota.Period,
+) ([]*goquota.UsageSnapshot, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT bucket, used, recorded_at
+		FROM usage_snapshots
+		WHERE user_id = $1 AND resource = $2 AND period_type = $3 AND period_start = $4
+		ORDER BY bucket ASC
+	`, userID, resource, string(period.Type), period.Start)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query usage snapshots: %w", err)
+	}
+	defer rows.Close()
+
+	var snapshots []*goquota.UsageSnapshot
+	for rows.Next() {
+		var bucket, recordedAt time.Time
+		var used int64
+		if err := rows.Scan(&bucket, &used, &recordedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan usage snapshot: %w", err)
+		}
+		snapshots = append(snapshots, &goquota.UsageSnapshot{
+			UserID:     userID,
+			Resource:   resource,
+			Period:     period,
+			Bucket:     bucket.UTC(),
+			Used:       int(used),
+			RecordedAt: recordedAt.UTC(),
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate usage snapshots: %w", err)
+	}
+	return snapshots, nil
+}
@@ -0,0 +1,82 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/mihaimyh/goquota/pkg/goquota"
+)
+
+// CreateStatement implements goquota.StatementStore
+func (s *Storage) CreateStatement(ctx context.Context, statement *goquota.Statement) (bool, error) {
+	if statement == nil {
+		return false, fmt.Errorf("statement is required")
+	}
+
+	lines, err := json.Marshal(statement.Lines)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal statement lines: %w", err)
+	}
+
+	tag, err := s.pool.Exec(ctx, `
+		INSERT INTO usage_statements
+			(user_id, period_type, period_start, period_end, tier, lines, closed_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (user_id, period_type, period_start) DO NOTHING
+	`, statement.UserID, string(statement.Period.Type), statement.Period.Start, statement.Period.End,
+		statement.Tier, lines, statement.ClosedAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to create statement: %w", err)
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// GetStatements implements goquota.StatementStore
+func (s *Storage) GetStatements(ctx context.Context, userID string, limit, offset int) ([]*goquota.Statement, error) {
+	var limitArg interface{}
+	if limit > 0 {
+		limitArg = limit
+	}
+
+	rows, err := s.pool.Query(ctx, `
+		SELECT period_type, period_start, period_end, tier, lines, closed_at
+		FROM usage_statements
+		WHERE user_id = $1
+		ORDER BY period_start DESC
+		LIMIT $2 OFFSET $3
+	`, userID, limitArg, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query statements: %w", err)
+	}
+	defer rows.Close()
+
+	statements := []*goquota.Statement{}
+	for rows.Next() {
+		var periodType, tier string
+		var periodStart, periodEnd, closedAt time.Time
+		var lines []byte
+		if err := rows.Scan(&periodType, &periodStart, &periodEnd, &tier, &lines, &closedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan statement: %w", err)
+		}
+		statement := &goquota.Statement{
+			UserID: userID,
+			Tier:   tier,
+			Period: goquota.Period{
+				Start: periodStart.UTC(),
+				End:   periodEnd.UTC(),
+				Type:  goquota.PeriodType(periodType),
+			},
+			ClosedAt: closedAt.UTC(),
+		}
+		if err := json.Unmarshal(lines, &statement.Lines); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal statement lines: %w", err)
+		}
+		statements = append(statements, statement)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate statements: %w", err)
+	}
+	return statements, nil
+}
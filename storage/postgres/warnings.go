@@ -0,0 +1,70 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mihaimyh/goquota/pkg/goquota"
+)
+
+// GetNotifiedWarnings implements goquota.WarningStateStore
+func (s *Storage) GetNotifiedWarnings(
+	ctx context.Context, userID, resource string, period goquota.Period,
+) ([]float64, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT threshold
+		FROM warning_notifications
+		WHERE user_id = $1 AND resource = $2 AND period_type = $3 AND period_start = $4
+		ORDER BY threshold ASC
+	`, userID, resource, string(period.Type), period.Start)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query notified warnings: %w", err)
+	}
+	defer rows.Close()
+
+	var thresholds []float64
+	for rows.Next() {
+		var threshold float64
+		if err := rows.Scan(&threshold); err != nil {
+			return nil, fmt.Errorf("failed to scan notified warning: %w", err)
+		}
+		thresholds = append(thresholds, threshold)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate notified warnings: %w", err)
+	}
+	return thresholds, nil
+}
+
+// MarkWarningNotified implements goquota.WarningStateStore
+func (s *Storage) MarkWarningNotified(
+	ctx context.Context, userID, resource string, period goquota.Period, threshold float64,
+) (bool, error) {
+	tag, err := s.pool.Exec(ctx, `
+		INSERT INTO warning_notifications (user_id, resource, period_type, period_start, period_end, threshold)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (user_id, resource, period_type, period_start, threshold) DO NOTHING
+	`, userID, resource, string(period.Type), period.Start, period.End, threshold)
+	if err != nil {
+		return false, fmt.Errorf("failed to mark warning as notified: %w", err)
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// ClearNotifiedWarnings implements goquota.WarningStateStore
+func (s *Storage) ClearNotifiedWarnings(
+	ctx context.Context, userID, resource string, period goquota.Period, thresholds []float64,
+) error {
+	if len(thresholds) == 0 {
+		return nil
+	}
+	_, err := s.pool.Exec(ctx, `
+		DELETE FROM warning_notifications
+		WHERE user_id = $1 AND resource = $2 AND period_type = $3 AND period_start = $4
+			AND threshold = ANY($5)
+	`, userID, resource, string(period.Type), period.Start, thresholds)
+	if err != nil {
+		return fmt.Errorf("failed to clear notified warnings: %w", err)
+	}
+	return nil
+}
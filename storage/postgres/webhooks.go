@@ -0,0 +1,140 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/mihaimyh/goquota/pkg/goquota"
+)
+
+const webhookColumns = `id, event_id, event_type, user_id, url, payload, status, attempts,
+	next_attempt_at, last_error, created_at, updated_at`
+
+// SaveWebhookDelivery implements goquota.WebhookStore
+func (s *Storage) SaveWebhookDelivery(ctx context.Context, delivery *goquota.WebhookDelivery) error {
+	if delivery == nil {
+		return fmt.Errorf("webhook delivery is required")
+	}
+
+	_, err := s.pool.Exec(ctx, `
+		INSERT INTO webhook_deliveries (`+webhookColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		ON CONFLICT (id) DO UPDATE SET
+			status = EXCLUDED.status,
+			attempts = EXCLUDED.attempts,
+			next_attempt_at = EXCLUDED.next_attempt_at,
+			last_error = EXCLUDED.last_error,
+			updated_at = EXCLUDED.updated_at
+	`, delivery.ID, delivery.EventID, string(delivery.EventType), delivery.UserID, delivery.URL,
+		delivery.Payload, string(delivery.Status), delivery.Attempts, delivery.NextAttemptAt,
+		delivery.LastError, delivery.CreatedAt, delivery.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save webhook delivery: %w", err)
+	}
+	return nil
+}
+
+// ClaimWebhookDeliveries implements goquota.WebhookStore
+// FOR UPDATE SKIP LOCKED lets several instances claim disjoint batches concurrently.
+func (s *Storage) ClaimWebhookDeliveries(ctx context.Context, now time.Time, limit int,
+	lease time.Duration) ([]*goquota.WebhookDelivery, error) {
+	var limitArg interface{}
+	if limit > 0 {
+		limitArg = limit
+	}
+
+	rows, err := s.pool.Query(ctx, `
+		UPDATE webhook_deliveries SET next_attempt_at = $2
+		WHERE id IN (
+			SELECT id FROM webhook_deliveries
+			WHERE status = $3 AND next_attempt_at <= $1
+			ORDER BY next_attempt_at
+			LIMIT $4
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+webhookColumns,
+		now, now.Add(lease), string(goquota.WebhookDeliveryPending), limitArg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim webhook deliveries: %w", err)
+	}
+
+	deliveries, err := scanWebhookDeliveries(rows)
+	if err != nil {
+		return nil, err
+	}
+	// RETURNING doesn't preserve the subquery order
+	sort.Slice(deliveries, func(i, j int) bool {
+		return deliveries[i].CreatedAt.Before(deliveries[j].CreatedAt)
+	})
+	return deliveries, nil
+}
+
+// GetWebhookDelivery implements goquota.WebhookStore
+func (s *Storage) GetWebhookDelivery(ctx context.Context, id string) (*goquota.WebhookDelivery, error) {
+	rows, err := s.pool.Query(ctx, `SELECT `+webhookColumns+` FROM webhook_deliveries WHERE id = $1`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook delivery: %w", err)
+	}
+	deliveries, err := scanWebhookDeliveries(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(deliveries) == 0 {
+		return nil, nil
+	}
+	return deliveries[0], nil
+}
+
+// ListWebhookDeliveries implements goquota.WebhookStore
+func (s *Storage) ListWebhookDeliveries(ctx context.Context, status goquota.WebhookDeliveryStatus,
+	limit, offset int) ([]*goquota.WebhookDelivery, error) {
+	var limitArg interface{}
+	if limit > 0 {
+		limitArg = limit
+	}
+
+	rows, err := s.pool.Query(ctx, `
+		SELECT `+webhookColumns+`
+		FROM webhook_deliveries
+		WHERE status = $1
+		ORDER BY created_at DESC, id
+		LIMIT $2 OFFSET $3
+	`, string(status), limitArg, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+	return scanWebhookDeliveries(rows)
+}
+
+// DeleteWebhookDelivery implements goquota.WebhookStore
+func (s *Storage) DeleteWebhookDelivery(ctx context.Context, id string) error {
+	if _, err := s.pool.Exec(ctx, `DELETE FROM webhook_deliveries WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("failed to delete webhook delivery: %w", err)
+	}
+	return nil
+}
+
+func scanWebhookDeliveries(rows pgx.Rows) ([]*goquota.WebhookDelivery, error) {
+	defer rows.Close()
+
+	deliveries := []*goquota.WebhookDelivery{}
+	for rows.Next() {
+		var d goquota.WebhookDelivery
+		var eventType, status string
+		if err := rows.Scan(&d.ID, &d.EventID, &eventType, &d.UserID, &d.URL, &d.Payload, &status,
+			&d.Attempts, &d.NextAttemptAt, &d.LastError, &d.CreatedAt, &d.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan webhook delivery: %w", err)
+		}
+		d.EventType = goquota.EventType(eventType)
+		d.Status = goquota.WebhookDeliveryStatus(status)
+		deliveries = append(deliveries, &d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read webhook deliveries: %w", err)
+	}
+	return deliveries, nil
+}
@@ -0,0 +1,72 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/mihaimyh/goquota/pkg/goquota"
+)
+
+// GetEntitlements implements goquota.BatchStorage
+// Reads are pipelined rather than sent as one MGET so keys can live in different cluster slots.
+func (s *Storage) GetEntitlements(ctx context.Context, userIDs []string) (map[string]*goquota.Entitlement, error) {
+	result := make(map[string]*goquota.Entitlement, len(userIDs))
+	if len(userIDs) == 0 {
+		return result, nil
+	}
+
+	pipe := s.client.Pipeline()
+	cmds := make([]*redis.StringCmd, len(userIDs))
+	for i, userID := range userIDs {
+		cmds[i] = pipe.Get(ctx, s.entitlementKey(userID))
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("failed to get entitlements: %w", err)
+	}
+
+	for i, cmd := range cmds {
+		data, err := cmd.Bytes()
+		if err == redis.Nil {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get entitlement: %w", err)
+		}
+
+		var ent goquota.Entitlement
+		if err := json.Unmarshal(data, &ent); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal entitlement: %w", err)
+		}
+		result[userIDs[i]] = &ent
+	}
+	return result, nil
+}
+
+// GetUsages implements goquota.BatchStorage
+func (s *Storage) GetUsages(ctx context.Context, queries []goquota.UsageQuery) ([]*goquota.Usage, error) {
+	result := make([]*goquota.Usage, len(queries))
+	if len(queries) == 0 {
+		return result, nil
+	}
+
+	pipe := s.client.Pipeline()
+	cmds := make([]*redis.SliceCmd, len(queries))
+	for i, q := range queries {
+		cmds[i] = pipe.HMGet(ctx, s.usageKey(q.UserID, q.Resource, q.Period), "data", "used")
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("failed to get usages: %w", err)
+	}
+
+	for i, cmd := range cmds {
+		usage, err := parseUsage(cmd.Val())
+		if err != nil {
+			return nil, err
+		}
+		result[i] = usage
+	}
+	return result, nil
+}
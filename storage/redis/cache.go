@@ -0,0 +1,106 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/mihaimyh/goquota/pkg/goquota"
+)
+
+// GetCacheEntry implements goquota.SharedCacheStore
+func (s *Storage) GetCacheEntry(ctx context.Context, key string) ([]byte, bool, error) {
+	value, err := s.client.Get(ctx, s.cacheKey(key)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get cache entry: %w", err)
+	}
+	return value, true, nil
+}
+
+// SetCacheEntry implements goquota.SharedCacheStore
+func (s *Storage) SetCacheEntry(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if err := s.client.Set(ctx, s.cacheKey(key), value, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to set cache entry: %w", err)
+	}
+	return nil
+}
+
+// DeleteCacheEntries implements goquota.SharedCacheStore.
+// Keys are deleted one by one: in a cluster they may live in different slots.
+func (s *Storage) DeleteCacheEntries(ctx context.Context, keys ...string) error {
+	for _, key := range keys {
+		if err := s.client.Del(ctx, s.cacheKey(key)).Err(); err != nil {
+			return fmt.Errorf("failed to delete cache entry: %w", err)
+		}
+	}
+	return nil
+}
+
+// InvalidationBus returns a goquota.InvalidationBus publishing on the "cache_invalidations"
+// channel under the storage's key prefix, for goquota.CacheConfig.InvalidationBus
+func (s *Storage) InvalidationBus() *InvalidationBus {
+	return NewInvalidationBus(s.client, s.config.KeyPrefix+"cache_invalidations")
+}
+
+// InvalidationBus is a goquota.InvalidationBus on Redis pub/sub. Invalidations published while
+// an instance is disconnected are lost; cache TTLs bound how long it may serve stale entries.
+type InvalidationBus struct {
+	client  redis.UniversalClient
+	channel string
+}
+
+// NewInvalidationBus creates an invalidation bus publishing on channel
+func NewInvalidationBus(client redis.UniversalClient, channel string) *InvalidationBus {
+	return &InvalidationBus{client: client, channel: channel}
+}
+
+// Publish implements goquota.InvalidationBus
+func (b *InvalidationBus) Publish(ctx context.Context, invalidation *goquota.CacheInvalidation) error {
+	payload, err := json.Marshal(invalidation)
+	if err != nil {
+		return fmt.Errorf("failed to encode cache invalidation: %w", err)
+	}
+	if err := b.client.Publish(ctx, b.channel, payload).Err(); err != nil {
+		return fmt.Errorf("failed to publish cache invalidation: %w", err)
+	}
+	return nil
+}
+
+// Subscribe implements goquota.InvalidationBus. It returns once the subscription is active.
+// Invalid messages are ignored.
+func (b *InvalidationBus) Subscribe(handler func(invalidation *goquota.CacheInvalidation)) (func(), error) {
+	ctx := context.Background()
+	pubsub := b.client.Subscribe(ctx, b.channel)
+	if _, err := pubsub.Receive(ctx); err != nil {
+		_ = pubsub.Close()
+		return nil, fmt.Errorf("failed to subscribe to cache invalidations: %w", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for msg := range pubsub.Channel() {
+			var invalidation goquota.CacheInvalidation
+			if err := json.Unmarshal([]byte(msg.Payload), &invalidation); err != nil {
+				continue
+			}
+			handler(&invalidation)
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			_ = pubsub.Close()
+			<-done
+		})
+	}, nil
+}
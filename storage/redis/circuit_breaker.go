@@ -0,0 +1,135 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/mihaimyh/goquota/pkg/goquota"
+)
+
+// circuitBreakerTTL returns how long an idle breaker is kept; an expired breaker is closed
+func circuitBreakerTTL(req *goquota.CircuitBreakerRequest) int64 {
+	return (req.FailureWindow + 2*req.ResetTimeout).Milliseconds()
+}
+
+// GetCircuitBreakerStatus implements goquota.CircuitBreakerStore
+// Breakers are stored in a hash per name; times are in milliseconds.
+func (s *Storage) GetCircuitBreakerStatus(ctx context.Context, name string) (*goquota.CircuitBreakerStatus, error) {
+	fields, err := s.client.HMGet(ctx, s.circuitBreakerKey(name), "state", "open_until", "failures").Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get circuit breaker: %w", err)
+	}
+
+	status := &goquota.CircuitBreakerStatus{}
+	if failures, ok := fields[2].(string); ok {
+		if status.Failures, err = strconv.Atoi(failures); err != nil {
+			return nil, fmt.Errorf("invalid circuit breaker failures %q: %w", failures, err)
+		}
+	}
+	if state, ok := fields[0].(string); !ok || state != string(goquota.StateOpen) {
+		return status, nil
+	}
+	openUntil, _ := fields[1].(string)
+	// Lua may store large numbers in exponent notation
+	openUntilMillis, err := strconv.ParseFloat(openUntil, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid circuit breaker open_until %q: %w", openUntil, err)
+	}
+	status.Open = true
+	status.OpenUntil = time.UnixMilli(int64(openUntilMillis)).UTC()
+	return status, nil
+}
+
+// RecordCircuitBreakerFailure implements goquota.CircuitBreakerStore
+func (s *Storage) RecordCircuitBreakerFailure(
+	ctx context.Context, req *goquota.CircuitBreakerRequest,
+) (*goquota.CircuitBreakerStatus, error) {
+	if req == nil {
+		return nil, fmt.Errorf("circuit breaker request is required")
+	}
+
+	result, err := s.scripts["breakerFailure"].Run(
+		ctx,
+		s.client,
+		[]string{s.circuitBreakerKey(req.Name)},
+		req.Now.UnixMilli(),
+		req.FailureThreshold,
+		req.FailureWindow.Milliseconds(),
+		req.ResetTimeout.Milliseconds(),
+		circuitBreakerTTL(req),
+	).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute circuit breaker failure script: %w", err)
+	}
+	return parseCircuitBreakerStatus(result)
+}
+
+// RecordCircuitBreakerSuccess implements goquota.CircuitBreakerStore
+func (s *Storage) RecordCircuitBreakerSuccess(
+	ctx context.Context, req *goquota.CircuitBreakerRequest,
+) (*goquota.CircuitBreakerStatus, error) {
+	if req == nil {
+		return nil, fmt.Errorf("circuit breaker request is required")
+	}
+
+	result, err := s.scripts["breakerSuccess"].Run(
+		ctx,
+		s.client,
+		[]string{s.circuitBreakerKey(req.Name)},
+		req.Now.UnixMilli(),
+	).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute circuit breaker success script: %w", err)
+	}
+	return parseCircuitBreakerStatus(result)
+}
+
+// AcquireCircuitBreakerProbe implements goquota.CircuitBreakerStore
+func (s *Storage) AcquireCircuitBreakerProbe(ctx context.Context, req *goquota.CircuitBreakerRequest) (bool, error) {
+	if req == nil {
+		return false, fmt.Errorf("circuit breaker request is required")
+	}
+
+	acquired, err := s.scripts["breakerProbe"].Run(
+		ctx,
+		s.client,
+		[]string{s.circuitBreakerKey(req.Name)},
+		req.Now.UnixMilli(),
+		req.MaxProbes,
+		req.ResetTimeout.Milliseconds(),
+		circuitBreakerTTL(req),
+	).Int()
+	if err != nil {
+		return false, fmt.Errorf("failed to execute circuit breaker probe script: %w", err)
+	}
+	return acquired == 1, nil
+}
+
+// parseCircuitBreakerStatus parses the {state, open_until, failures} result of a breaker script
+func parseCircuitBreakerStatus(result interface{}) (*goquota.CircuitBreakerStatus, error) {
+	resultSlice, ok := result.([]interface{})
+	if !ok || len(resultSlice) != 3 {
+		return nil, fmt.Errorf("unexpected result from circuit breaker script: %v", result)
+	}
+	state, ok := resultSlice[0].(string)
+	if !ok {
+		return nil, fmt.Errorf("invalid circuit breaker state")
+	}
+	openUntil, ok := resultSlice[1].(int64)
+	if !ok {
+		return nil, fmt.Errorf("invalid circuit breaker open_until")
+	}
+	failures, ok := resultSlice[2].(int64)
+	if !ok {
+		return nil, fmt.Errorf("invalid circuit breaker failures")
+	}
+
+	status := &goquota.CircuitBreakerStatus{Failures: int(failures)}
+	if state == string(goquota.StateOpen) {
+		status.Open = true
+		status.OpenUntil = time.UnixMilli(openUntil).UTC()
+	}
+	return status, nil
+}
@@ -0,0 +1,77 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mihaimyh/goquota/pkg/goquota"
+)
+
+// AcquireLease implements goquota.ConcurrencyLimiter
+// Leases are stored in a sorted set per user and resource, scored by expiration time in milliseconds.
+func (s *Storage) AcquireLease(ctx context.Context, req *goquota.LeaseRequest) (bool, int, error) {
+	if req == nil {
+		return false, 0, fmt.Errorf("lease request is required")
+	}
+
+	now := req.Now.UnixMilli()
+	result, err := s.scripts["acquireLease"].Run(
+		ctx,
+		s.client,
+		[]string{s.leaseKey(req.UserID, req.Resource)},
+		req.LeaseID,
+		now,
+		now+req.TTL.Milliseconds(),
+		req.MaxConcurrent,
+		req.TTL.Milliseconds(),
+	).Result()
+	if err != nil {
+		return false, 0, fmt.Errorf("failed to execute acquire lease script: %w", err)
+	}
+
+	resultSlice, ok := result.([]interface{})
+	if !ok || len(resultSlice) != 2 {
+		return false, 0, fmt.Errorf("unexpected result from acquire lease script: %v", result)
+	}
+	acquired, ok := resultSlice[0].(int64)
+	if !ok {
+		return false, 0, fmt.Errorf("invalid acquired value")
+	}
+	active, ok := resultSlice[1].(int64)
+	if !ok {
+		return false, 0, fmt.Errorf("invalid active value")
+	}
+
+	return acquired == 1, int(active), nil
+}
+
+// RenewLease implements goquota.ConcurrencyLimiter
+func (s *Storage) RenewLease(ctx context.Context, req *goquota.LeaseRequest) (bool, error) {
+	if req == nil {
+		return false, fmt.Errorf("lease request is required")
+	}
+
+	now := req.Now.UnixMilli()
+	renewed, err := s.scripts["renewLease"].Run(
+		ctx,
+		s.client,
+		[]string{s.leaseKey(req.UserID, req.Resource)},
+		req.LeaseID,
+		now,
+		now+req.TTL.Milliseconds(),
+		req.TTL.Milliseconds(),
+	).Int()
+	if err != nil {
+		return false, fmt.Errorf("failed to execute renew lease script: %w", err)
+	}
+
+	return renewed == 1, nil
+}
+
+// ReleaseLease implements goquota.ConcurrencyLimiter
+func (s *Storage) ReleaseLease(ctx context.Context, userID, resource, leaseID string) error {
+	if err := s.client.ZRem(ctx, s.leaseKey(userID, resource), leaseID).Err(); err != nil {
+		return fmt.Errorf("failed to release lease: %w", err)
+	}
+	return nil
+}
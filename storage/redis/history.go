@@ -0,0 +1,128 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/mihaimyh/goquota/pkg/goquota"
+)
+
+// historyBatchSize is the minimum number of index members read per round trip
+const historyBatchSize = 100
+
+// GetUsageHistory implements goquota.UsageHistoryStorage
+// Periods are looked up through a sorted set index per user, resource and period type,
+// which is maintained whenever usage for a daily or monthly period is written.
+// Members whose usage expired (UsageTTL) are dropped from the index and do not count
+// towards Offset and Limit, so pages are only short at the end of the history.
+func (s *Storage) GetUsageHistory(ctx context.Context, query *goquota.UsageHistoryQuery) ([]*goquota.Usage, error) {
+	if query == nil {
+		return nil, fmt.Errorf("usage history query is required")
+	}
+
+	batch := query.Offset + query.Limit
+	if batch < historyBatchSize {
+		batch = historyBatchSize
+	}
+	indexKey := s.usageIndexKey(query.UserID, query.Resource, query.PeriodType)
+	records := []*goquota.Usage{}
+	skip := query.Offset
+	for start := int64(0); ; start += int64(batch) {
+		members, err := s.client.ZRevRangeByScoreWithScores(ctx, indexKey, &redis.ZRangeBy{
+			Min:    strconv.FormatInt(query.From.Unix(), 10),
+			Max:    "(" + strconv.FormatInt(query.To.Unix(), 10),
+			Offset: start,
+			Count:  int64(batch),
+		}).Result()
+		if err != nil {
+			return nil, fmt.Errorf("failed to query usage index: %w", err)
+		}
+
+		usages, stale, err := s.getIndexedUsage(ctx, query, members)
+		if err != nil {
+			return nil, err
+		}
+		for _, usage := range usages {
+			if skip > 0 {
+				skip--
+				continue
+			}
+			records = append(records, usage)
+			if query.Limit > 0 && len(records) == query.Limit {
+				break
+			}
+		}
+
+		if len(stale) > 0 {
+			//nolint:errcheck // Index cleanup is best-effort
+			_ = s.client.ZRem(ctx, indexKey, stale...).Err()
+			// Removed members shift the following ones back
+			start -= int64(len(stale))
+		}
+		if len(members) < batch || (query.Limit > 0 && len(records) == query.Limit) {
+			return records, nil
+		}
+	}
+}
+
+// getIndexedUsage reads the usage of index members, in order, and returns the members
+// whose usage expired
+func (s *Storage) getIndexedUsage(ctx context.Context, query *goquota.UsageHistoryQuery,
+	members []redis.Z) ([]*goquota.Usage, []interface{}, error) {
+	if len(members) == 0 {
+		return nil, nil, nil
+	}
+
+	pipe := s.client.Pipeline()
+	cmds := make([]*redis.SliceCmd, len(members))
+	for i, member := range members {
+		period := goquota.Period{
+			Start: time.Unix(int64(member.Score), 0).UTC(),
+			Type:  query.PeriodType,
+		}
+		cmds[i] = pipe.HMGet(ctx, s.usageKey(query.UserID, query.Resource, period), "data", "used")
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, nil, fmt.Errorf("failed to get usage history: %w", err)
+	}
+
+	usages := make([]*goquota.Usage, 0, len(members))
+	var stale []interface{}
+	for i, cmd := range cmds {
+		usage, err := parseUsage(cmd.Val())
+		if err != nil {
+			return nil, nil, err
+		}
+		if usage == nil {
+			// Usage key expired (UsageTTL); drop it from the index
+			stale = append(stale, members[i].Member)
+			continue
+		}
+		if usage.Period.Type != query.PeriodType {
+			continue
+		}
+		usages = append(usages, usage)
+	}
+	return usages, stale, nil
+}
+
+// indexUsagePeriod queues adding a daily or monthly period to the usage history index.
+// The index expires with the usage keys it was last written with (UsageTTL).
+func (s *Storage) indexUsagePeriod(ctx context.Context, pipe redis.Pipeliner, userID, resource string,
+	period goquota.Period) {
+	if period.Type == goquota.PeriodTypeForever {
+		return
+	}
+	indexKey := s.usageIndexKey(userID, resource, period.Type)
+	pipe.ZAdd(ctx, indexKey, redis.Z{
+		Score:  float64(period.Start.Unix()),
+		Member: period.Key(),
+	})
+	if s.config.UsageTTL > 0 {
+		pipe.Expire(ctx, indexKey, s.config.UsageTTL)
+	}
+}
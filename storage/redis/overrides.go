@@ -0,0 +1,58 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/mihaimyh/goquota/pkg/goquota"
+)
+
+// SaveOverride implements goquota.OverrideStore
+// Overrides are stored as JSON in a single hash, keyed by resource and tier.
+func (s *Storage) SaveOverride(ctx context.Context, override *goquota.Override) error {
+	if override == nil {
+		return fmt.Errorf("override is required")
+	}
+
+	data, err := json.Marshal(override)
+	if err != nil {
+		return fmt.Errorf("failed to marshal override: %w", err)
+	}
+	if err := s.client.HSet(ctx, s.overridesKey(), overrideField(override.Resource, override.Tier), data).Err(); err != nil {
+		return fmt.Errorf("failed to save override: %w", err)
+	}
+	return nil
+}
+
+// DeleteOverride implements goquota.OverrideStore
+func (s *Storage) DeleteOverride(ctx context.Context, resource, tier string) (bool, error) {
+	deleted, err := s.client.HDel(ctx, s.overridesKey(), overrideField(resource, tier)).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to delete override: %w", err)
+	}
+	return deleted > 0, nil
+}
+
+// ListOverrides implements goquota.OverrideStore
+func (s *Storage) ListOverrides(ctx context.Context) ([]*goquota.Override, error) {
+	values, err := s.client.HVals(ctx, s.overridesKey()).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list overrides: %w", err)
+	}
+
+	overrides := make([]*goquota.Override, 0, len(values))
+	for _, value := range values {
+		var override goquota.Override
+		if err := json.Unmarshal([]byte(value), &override); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal override: %w", err)
+		}
+		overrides = append(overrides, &override)
+	}
+	return overrides, nil
+}
+
+// overrideField is the hash field of an override scope
+func overrideField(resource, tier string) string {
+	return resource + "\x00" + tier
+}
@@ -99,6 +99,7 @@ func (s *Storage) loadScripts() {
 		local ttl = tonumber(ARGV[4])
 		local consumptionData = ARGV[5]
 		local consumptionTTL = tonumber(ARGV[6])
+		local indexKey = KEYS[3]
 		
 		-- Check idempotency
 		if consumptionKey ~= "" then
@@ -144,6 +145,14 @@ func (s *Storage) loadScripts() {
 			redis.call('EXPIRE', usageKey, ttl)
 		end
 		
+		-- Index the period for usage history queries
+		if indexKey ~= "" then
+			redis.call('ZADD', indexKey, ARGV[7], ARGV[8])
+			if ttl > 0 then
+				redis.call('EXPIRE', indexKey, ttl)
+			end
+		end
+		
 		-- Record consumption for idempotency
 		if consumptionKey ~= "" and consumptionData ~= "" then
 			redis.call('SET', consumptionKey, consumptionData)
@@ -334,6 +343,178 @@ func (s *Storage) loadScripts() {
 		
 		return {allowed, remaining, resetTime}
 	`)
+
+	// Acquire concurrency lease (sorted set scored by lease expiration)
+	s.scripts["acquireLease"] = redis.NewScript(`
+		local key = KEYS[1]
+		local leaseID = ARGV[1]
+		local now = tonumber(ARGV[2])
+		local expiresAt = tonumber(ARGV[3])
+		local maxConcurrent = tonumber(ARGV[4])
+		local ttl = tonumber(ARGV[5])
+		
+		-- Reclaim expired leases (e.g. from crashed processes)
+		redis.call('ZREMRANGEBYSCORE', key, '-inf', now)
+		
+		local count = redis.call('ZCARD', key)
+		local existing = redis.call('ZSCORE', key, leaseID)
+		if not existing and count >= maxConcurrent then
+			return {0, count}
+		end
+		
+		redis.call('ZADD', key, expiresAt, leaseID)
+		redis.call('PEXPIRE', key, ttl)
+		
+		return {1, redis.call('ZCARD', key)}
+	`)
+
+	// Renew concurrency lease if it has not expired
+	s.scripts["renewLease"] = redis.NewScript(`
+		local key = KEYS[1]
+		local leaseID = ARGV[1]
+		local now = tonumber(ARGV[2])
+		local expiresAt = tonumber(ARGV[3])
+		local ttl = tonumber(ARGV[4])
+		
+		local score = redis.call('ZSCORE', key, leaseID)
+		if not score or tonumber(score) <= now then
+			redis.call('ZREM', key, leaseID)
+			return 0
+		end
+		
+		redis.call('ZADD', key, 'XX', expiresAt, leaseID)
+		if redis.call('PTTL', key) < ttl then
+			redis.call('PEXPIRE', key, ttl)
+		end
+		
+		return 1
+	`)
+
+	// Record usage snapshot, keeping the highest usage seen for the bucket
+	s.scripts["recordSnapshot"] = redis.NewScript(`
+		local key = KEYS[1]
+		local bucket = ARGV[1]
+		local used = tonumber(ARGV[2])
+		local value = ARGV[3]
+		local expireAt = tonumber(ARGV[4])
+		
+		local existing = redis.call('HGET', key, bucket)
+		if existing then
+			local existingUsed = tonumber(string.match(existing, '^(-?%d+):'))
+			if existingUsed and existingUsed > used then
+				return 0
+			end
+		end
+		
+		redis.call('HSET', key, bucket, value)
+		redis.call('PEXPIREAT', key, expireAt)
+		return 1
+	`)
+
+	// Record a circuit breaker failure, opening the breaker at the threshold or if half-open
+	s.scripts["breakerFailure"] = redis.NewScript(`
+		local key = KEYS[1]
+		local now = tonumber(ARGV[1])
+		local threshold = tonumber(ARGV[2])
+		local window = tonumber(ARGV[3])
+		local resetTimeout = tonumber(ARGV[4])
+		local ttl = tonumber(ARGV[5])
+		
+		local fields = redis.call('HMGET', key, 'state', 'open_until', 'window_start', 'failures')
+		local state = fields[1] or 'closed'
+		local openUntil = tonumber(fields[2]) or 0
+		local windowStart = tonumber(fields[3]) or 0
+		local failures = tonumber(fields[4]) or 0
+		
+		if state == 'open' then
+			if now >= openUntil then
+				-- A half-open probe failed: reopen
+				openUntil = now + resetTimeout
+				redis.call('HSET', key, 'open_until', openUntil, 'probes', 0)
+				redis.call('PEXPIRE', key, ttl)
+			end
+			return {state, openUntil, failures}
+		end
+		
+		if now - windowStart >= window then
+			windowStart = now
+			failures = 0
+		end
+		failures = failures + 1
+		if failures >= threshold then
+			state = 'open'
+			openUntil = now + resetTimeout
+			failures = 0
+		end
+		
+		redis.call('HSET', key, 'state', state, 'open_until', openUntil,
+			'window_start', windowStart, 'failures', failures, 'probes', 0)
+		redis.call('PEXPIRE', key, ttl)
+		return {state, openUntil, failures}
+	`)
+
+	// Record a circuit breaker success, closing the breaker if half-open and resetting
+	// the failures if closed
+	s.scripts["breakerSuccess"] = redis.NewScript(`
+		local key = KEYS[1]
+		local now = tonumber(ARGV[1])
+		
+		local fields = redis.call('HMGET', key, 'state', 'open_until', 'failures')
+		local state = fields[1] or 'closed'
+		local openUntil = tonumber(fields[2]) or 0
+		local failures = tonumber(fields[3]) or 0
+		
+		if state ~= 'open' or now >= openUntil then
+			redis.call('DEL', key)
+			return {'closed', 0, 0}
+		end
+		return {state, openUntil, failures}
+	`)
+
+	// Reserve a probe slot of a half-open circuit breaker
+	s.scripts["breakerProbe"] = redis.NewScript(`
+		local key = KEYS[1]
+		local now = tonumber(ARGV[1])
+		local maxProbes = tonumber(ARGV[2])
+		local probeTimeout = tonumber(ARGV[3])
+		local ttl = tonumber(ARGV[4])
+		
+		local fields = redis.call('HMGET', key, 'state', 'open_until', 'probes', 'probe_until')
+		if fields[1] ~= 'open' then
+			return 1
+		end
+		local openUntil = tonumber(fields[2]) or 0
+		local probes = tonumber(fields[3]) or 0
+		local probeUntil = tonumber(fields[4]) or 0
+		
+		if now < openUntil then
+			return 0
+		end
+		if now >= probeUntil then
+			probes = 0
+		end
+		if probes >= maxProbes then
+			return 0
+		end
+		
+		redis.call('HSET', key, 'probes', probes + 1, 'probe_until', now + probeTimeout)
+		redis.call('PEXPIRE', key, ttl)
+		return 1
+	`)
+
+	// Claim due webhook deliveries by pushing their score (next attempt) to the end of the lease
+	s.scripts["claimWebhooks"] = redis.NewScript(`
+		local key = KEYS[1]
+		local now = ARGV[1]
+		local limit = tonumber(ARGV[2])
+		local leaseUntil = ARGV[3]
+		
+		local ids = redis.call('ZRANGEBYSCORE', key, '-inf', now, 'LIMIT', 0, limit)
+		for _, id in ipairs(ids) do
+			redis.call('ZADD', key, 'XX', leaseUntil, id)
+		end
+		return ids
+	`)
 }
 
 // GetEntitlement implements goquota.Storage
@@ -393,6 +574,12 @@ func (s *Storage) GetUsage(ctx context.Context, userID, resource string,
 		return nil, fmt.Errorf("failed to get usage: %w", err)
 	}
 
+	return parseUsage(results)
+}
+
+// parseUsage builds a Usage from the HMGET results of the "data" and "used" fields.
+// Returns nil if the usage key doesn't exist.
+func parseUsage(results []interface{}) (*goquota.Usage, error) {
 	if len(results) != 2 || results[0] == nil {
 		return nil, nil // No usage yet
 	}
@@ -553,17 +740,25 @@ func (s *Storage) ConsumeQuota(ctx context.Context, req *goquota.ConsumeRequest)
 		consumptionTTL = int64(req.IdempotencyKeyTTL.Seconds())
 	}
 
+	// Forever periods have a single record and are not indexed for history
+	indexKey := ""
+	if req.Period.Type != goquota.PeriodTypeForever {
+		indexKey = s.usageIndexKey(req.UserID, req.Resource, req.Period.Type)
+	}
+
 	// Execute Lua script for atomic consumption
 	result, err := s.scripts["consume"].Run(
 		ctx,
 		s.client,
-		[]string{usageKey, consumptionKey},
+		[]string{usageKey, consumptionKey, indexKey},
 		req.Amount,
 		req.Limit,
 		string(usageData),
 		ttl,
 		consumptionData,
 		consumptionTTL,
+		req.Period.Start.Unix(),
+		req.Period.Key(),
 	).Result()
 
 	if err != nil {
@@ -584,6 +779,45 @@ func (s *Storage) ConsumeQuota(ctx context.Context, req *goquota.ConsumeRequest)
 	return newUsed, nil
 }
 
+// ApplyUsageDelta implements goquota.UsageDeltaStore.
+// The "used" counter is incremented in a MULTI/EXEC block; "data" is only written if missing.
+func (s *Storage) ApplyUsageDelta(ctx context.Context, req *goquota.UsageDeltaRequest) error {
+	if req.Amount < 0 {
+		return goquota.ErrInvalidAmount
+	}
+	if req.Amount == 0 {
+		return nil // No-op
+	}
+
+	usageData, err := json.Marshal(&goquota.Usage{
+		UserID:    req.UserID,
+		Resource:  req.Resource,
+		Limit:     req.Limit,
+		Period:    req.Period,
+		Tier:      req.Tier,
+		UpdatedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal usage: %w", err)
+	}
+
+	key := s.usageKey(req.UserID, req.Resource, req.Period)
+	pipe := s.client.TxPipeline()
+	pipe.HIncrBy(ctx, key, "used", int64(req.Amount))
+	pipe.HSetNX(ctx, key, "data", string(usageData))
+	s.indexUsagePeriod(ctx, pipe, req.UserID, req.Resource, req.Period)
+
+	// For forever periods, never set TTL (no expiration)
+	if req.Period.Type != goquota.PeriodTypeForever && s.config.UsageTTL > 0 {
+		pipe.Expire(ctx, key, s.config.UsageTTL)
+	}
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to apply usage delta: %w", err)
+	}
+	return nil
+}
+
 // ApplyTierChange implements goquota.Storage
 func (s *Storage) ApplyTierChange(ctx context.Context, req *goquota.TierChangeRequest) error {
 	key := s.usageKey(req.UserID, "audio_seconds", req.Period)
@@ -623,6 +857,12 @@ func (s *Storage) ApplyTierChange(ctx context.Context, req *goquota.TierChangeRe
 		return fmt.Errorf("failed to execute tier change script: %w", err)
 	}
 
+	pipe := s.client.Pipeline()
+	s.indexUsagePeriod(ctx, pipe, req.UserID, "audio_seconds", req.Period)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to index usage period: %w", err)
+	}
+
 	return nil
 }
 
@@ -643,6 +883,7 @@ func (s *Storage) SetUsage(ctx context.Context, userID, resource string,
 	pipe := s.client.Pipeline()
 	pipe.HSet(ctx, key, "used", usage.Used)
 	pipe.HSet(ctx, key, "data", string(usageData))
+	s.indexUsagePeriod(ctx, pipe, userID, resource, period)
 
 	// For forever periods, never set TTL (no expiration)
 	if period.Type != goquota.PeriodTypeForever && s.config.UsageTTL > 0 {
@@ -657,6 +898,54 @@ func (s *Storage) SetUsage(ctx context.Context, userID, resource string,
 	return nil
 }
 
+// SeedEntitlement implements goquota.SeedStore with SET NX
+func (s *Storage) SeedEntitlement(ctx context.Context, ent *goquota.Entitlement) (bool, error) {
+	if ent == nil || ent.UserID == "" {
+		return false, fmt.Errorf("invalid entitlement")
+	}
+
+	data, err := json.Marshal(ent)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal entitlement: %w", err)
+	}
+
+	created, err := s.client.SetNX(ctx, s.entitlementKey(ent.UserID), data, s.config.EntitlementTTL).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to seed entitlement: %w", err)
+	}
+	return created, nil
+}
+
+// SeedUsage implements goquota.SeedStore.
+// The "used" and "data" fields are only written if missing, in a MULTI/EXEC block.
+func (s *Storage) SeedUsage(ctx context.Context, userID, resource string,
+	usage *goquota.Usage, period goquota.Period) (bool, error) {
+	if usage == nil {
+		return false, fmt.Errorf("usage is required")
+	}
+
+	usageData, err := json.Marshal(usage)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal usage: %w", err)
+	}
+
+	key := s.usageKey(userID, resource, period)
+	pipe := s.client.TxPipeline()
+	created := pipe.HSetNX(ctx, key, "used", usage.Used)
+	pipe.HSetNX(ctx, key, "data", string(usageData))
+	s.indexUsagePeriod(ctx, pipe, userID, resource, period)
+
+	// For forever periods, never set TTL (no expiration)
+	if period.Type != goquota.PeriodTypeForever && s.config.UsageTTL > 0 {
+		pipe.Expire(ctx, key, s.config.UsageTTL)
+	}
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return false, fmt.Errorf("failed to seed usage: %w", err)
+	}
+	return created.Val(), nil
+}
+
 // RefundQuota implements goquota.Storage
 func (s *Storage) RefundQuota(ctx context.Context, req *goquota.RefundRequest) error {
 	if req.Amount < 0 {
@@ -835,6 +1124,12 @@ func (s *Storage) usageKey(userID, resource string, period goquota.Period) strin
 	return fmt.Sprintf("%susage:%s:%s:%s", s.config.KeyPrefix, userID, resource, period.Key())
 }
 
+// usageIndexKey generates the Redis key for the sorted set indexing a user's usage periods,
+// scored by period start (Unix seconds) with period keys as members
+func (s *Storage) usageIndexKey(userID, resource string, periodType goquota.PeriodType) string {
+	return fmt.Sprintf("%susage_index:%s:%s:%s", s.config.KeyPrefix, userID, resource, periodType)
+}
+
 // refundKey generates the Redis key for refund records
 func (s *Storage) refundKey(idempotencyKey string) string {
 	return fmt.Sprintf("%srefund:%s", s.config.KeyPrefix, idempotencyKey)
@@ -850,6 +1145,52 @@ func (s *Storage) rateLimitKey(userID, resource string) string {
 	return fmt.Sprintf("%sratelimit:%s:%s", s.config.KeyPrefix, userID, resource)
 }
 
+// leaseKey generates the Redis key for concurrency leases
+func (s *Storage) leaseKey(userID, resource string) string {
+	return fmt.Sprintf("%sconcurrency:%s:%s", s.config.KeyPrefix, userID, resource)
+}
+
+// snapshotKey generates the Redis key for usage snapshots of a period
+func (s *Storage) snapshotKey(userID, resource string, period goquota.Period) string {
+	return fmt.Sprintf("%ssnapshots:%s:%s:%s:%s", s.config.KeyPrefix, userID, resource, period.Type, period.Key())
+}
+
+// warningKey generates the Redis key for the set of notified warning thresholds of a period
+func (s *Storage) warningKey(userID, resource string, period goquota.Period) string {
+	return fmt.Sprintf("%swarnings:%s:%s:%s:%s", s.config.KeyPrefix, userID, resource, period.Type, period.Key())
+}
+
+// webhookKey generates the Redis key for a webhook delivery
+func (s *Storage) webhookKey(id string) string {
+	return fmt.Sprintf("%swebhook:%s", s.config.KeyPrefix, id)
+}
+
+// webhookIndexKey generates the Redis key for the index of webhook deliveries with a status.
+// Pending deliveries are scored by next attempt, dead letters by creation time.
+func (s *Storage) webhookIndexKey(status goquota.WebhookDeliveryStatus) string {
+	return fmt.Sprintf("%swebhooks:%s", s.config.KeyPrefix, status)
+}
+
+// statementKey generates the Redis key for the hash of a user's statements
+func (s *Storage) statementKey(userID string) string {
+	return fmt.Sprintf("%sstatements:%s", s.config.KeyPrefix, userID)
+}
+
+// overridesKey generates the Redis key for the hash of manual overrides
+func (s *Storage) overridesKey() string {
+	return s.config.KeyPrefix + "overrides"
+}
+
+// circuitBreakerKey generates the Redis key for the hash of a shared circuit breaker
+func (s *Storage) circuitBreakerKey(name string) string {
+	return fmt.Sprintf("%scircuit_breaker:%s", s.config.KeyPrefix, name)
+}
+
+// cacheKey generates the Redis key for an entry of the shared cache tier
+func (s *Storage) cacheKey(key string) string {
+	return fmt.Sprintf("%scache:%s", s.config.KeyPrefix, key)
+}
+
 // topUpKey generates the Redis key for top-up idempotency records
 func (s *Storage) topUpKey(idempotencyKey string) string {
 	return fmt.Sprintf("%stopup:%s", s.config.KeyPrefix, idempotencyKey)
@@ -913,6 +1254,14 @@ func (s *Storage) AddLimit(
 		return goquota.ErrIdempotencyKeyExists
 	}
 
+	if period.Type != goquota.PeriodTypeForever {
+		pipe := s.client.Pipeline()
+		s.indexUsagePeriod(ctx, pipe, userID, resource, period)
+		if _, err := pipe.Exec(ctx); err != nil {
+			return fmt.Errorf("failed to index usage period: %w", err)
+		}
+	}
+
 	return nil
 }
 
@@ -0,0 +1,77 @@
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mihaimyh/goquota/pkg/goquota"
+)
+
+var (
+	_ goquota.SharedCacheStore = (*Storage)(nil)
+	_ goquota.InvalidationBus  = (*InvalidationBus)(nil)
+)
+
+func TestStorage_CacheEntries(t *testing.T) {
+	client := setupTestRedis(t)
+	defer client.Close()
+
+	storage, err := New(client, DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	ctx := context.Background()
+
+	if err := storage.SetCacheEntry(ctx, "usage:a", []byte("1"), time.Minute); err != nil {
+		t.Fatalf("SetCacheEntry failed: %v", err)
+	}
+	value, ok, err := storage.GetCacheEntry(ctx, "usage:a")
+	if err != nil || !ok || string(value) != "1" {
+		t.Errorf("Expected cached value 1, got %q, %v, %v", value, ok, err)
+	}
+	if ttl := client.PTTL(ctx, "goquota:cache:usage:a").Val(); ttl <= 0 || ttl > time.Minute {
+		t.Errorf("Expected entry to expire within a minute, got TTL %v", ttl)
+	}
+
+	if err := storage.DeleteCacheEntries(ctx, "usage:a", "usage:missing"); err != nil {
+		t.Fatalf("DeleteCacheEntries failed: %v", err)
+	}
+	if _, ok, err := storage.GetCacheEntry(ctx, "usage:a"); ok || err != nil {
+		t.Errorf("Expected deleted entry to be missing, got %v, %v", ok, err)
+	}
+}
+
+func TestInvalidationBus(t *testing.T) {
+	client := setupTestRedis(t)
+	defer client.Close()
+
+	storage, err := New(client, DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	bus := storage.InvalidationBus()
+
+	received := make(chan *goquota.CacheInvalidation, 1)
+	unsubscribe, err := bus.Subscribe(func(invalidation *goquota.CacheInvalidation) {
+		received <- invalidation
+	})
+	if err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+	defer unsubscribe()
+
+	want := &goquota.CacheInvalidation{Source: "pod-a", Kind: goquota.CacheInvalidationUsage, Key: "user1:api_calls"}
+	if err := bus.Publish(context.Background(), want); err != nil {
+		t.Fatalf("Publish failed: %v", err)
+	}
+
+	select {
+	case got := <-received:
+		if *got != *want {
+			t.Errorf("Expected %+v, got %+v", want, got)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timed out waiting for invalidation")
+	}
+}
@@ -0,0 +1,76 @@
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mihaimyh/goquota/internal/storagetest"
+	"github.com/mihaimyh/goquota/pkg/goquota"
+)
+
+func TestStorage_GetUsageHistory(t *testing.T) {
+	client := setupTestRedis(t)
+	defer client.Close()
+
+	storage, err := New(client, DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	storagetest.UsageHistory(t, storage)
+}
+
+func TestStorage_GetUsageHistory_ExpiredPeriods(t *testing.T) {
+	client := setupTestRedis(t)
+	defer client.Close()
+
+	config := DefaultConfig()
+	config.UsageTTL = time.Hour
+	storage, err := New(client, config)
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	ctx := context.Background()
+
+	periods := make([]goquota.Period, 6)
+	for i := range periods {
+		start := time.Date(2026, time.Month(i+1), 1, 0, 0, 0, 0, time.UTC)
+		periods[i] = goquota.Period{Start: start, End: start.AddDate(0, 1, 0), Type: goquota.PeriodTypeMonthly}
+		err := storage.SetUsage(ctx, "user1", "api_calls", &goquota.Usage{
+			UserID: "user1", Resource: "api_calls", Used: i + 1, Limit: 100, Period: periods[i], Tier: "free",
+		}, periods[i])
+		if err != nil {
+			t.Fatalf("SetUsage failed: %v", err)
+		}
+	}
+
+	indexKey := storage.usageIndexKey("user1", "api_calls", goquota.PeriodTypeMonthly)
+	if ttl := client.TTL(ctx, indexKey).Val(); ttl <= 0 || ttl > time.Hour {
+		t.Errorf("Expected the usage index to expire with the usage keys, got TTL %v", ttl)
+	}
+
+	// Simulate the expiry of the two newest periods
+	for _, period := range periods[4:] {
+		client.Del(ctx, storage.usageKey("user1", "api_calls", period))
+	}
+
+	// Expired periods do not count towards the page
+	history, err := storage.GetUsageHistory(ctx, &goquota.UsageHistoryQuery{
+		UserID:     "user1",
+		Resource:   "api_calls",
+		PeriodType: goquota.PeriodTypeMonthly,
+		From:       periods[0].Start,
+		To:         periods[5].End,
+		Offset:     1,
+		Limit:      2,
+	})
+	if err != nil {
+		t.Fatalf("GetUsageHistory failed: %v", err)
+	}
+	if len(history) != 2 || history[0].Used != 3 || history[1].Used != 2 {
+		t.Errorf("Expected periods 3 and 2, got %+v", history)
+	}
+	if count := client.ZCard(ctx, indexKey).Val(); count != 4 {
+		t.Errorf("Expected expired periods to be dropped from the index, got %d members", count)
+	}
+}
@@ -0,0 +1,37 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/mihaimyh/goquota/pkg/goquota"
+)
+
+func TestStorage_IsRetryable(t *testing.T) {
+	storage := &Storage{}
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"pool timeout", fmt.Errorf("failed to get usage: %w", redis.ErrPoolTimeout), true},
+		{"loading", errors.New("LOADING Redis is loading the dataset in memory"), true},
+		{"readonly", errors.New("READONLY You can't write against a read only replica."), true},
+		{"closed client", redis.ErrClosed, false},
+		{"missing key", redis.Nil, false},
+		{"quota exceeded", goquota.ErrQuotaExceeded, false},
+		{"context canceled", context.Canceled, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := storage.IsRetryable(tt.err); got != tt.want {
+				t.Errorf("IsRetryable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
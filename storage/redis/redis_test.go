@@ -8,6 +8,7 @@ import (
 
 	"github.com/redis/go-redis/v9"
 
+	"github.com/mihaimyh/goquota/internal/storagetest"
 	"github.com/mihaimyh/goquota/pkg/goquota"
 )
 
@@ -467,6 +468,64 @@ func TestStorage_GetConsumptionRecord(t *testing.T) {
 	})
 }
 
+func TestStorage_ApplyUsageDelta(t *testing.T) {
+	client := setupTestRedis(t)
+	defer client.Close()
+
+	storage, err := New(client, DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+
+	ctx := context.Background()
+	period := goquota.Period{
+		Start: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		End:   time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+		Type:  goquota.PeriodTypeMonthly,
+	}
+
+	req := &goquota.UsageDeltaRequest{
+		UserID:   "delta_user",
+		Resource: "api_calls",
+		Amount:   60,
+		Tier:     "pro",
+		Period:   period,
+		Limit:    100,
+	}
+
+	// Deltas are not checked against the limit
+	for i := 0; i < 2; i++ {
+		if err := storage.ApplyUsageDelta(ctx, req); err != nil {
+			t.Fatalf("ApplyUsageDelta failed: %v", err)
+		}
+	}
+
+	usage, err := storage.GetUsage(ctx, "delta_user", "api_calls", period)
+	if err != nil {
+		t.Fatalf("GetUsage failed: %v", err)
+	}
+	if usage == nil {
+		t.Fatal("Expected usage, got nil")
+	}
+	if usage.Used != 120 {
+		t.Errorf("Expected 120 used, got %d", usage.Used)
+	}
+	if usage.Limit != 100 || usage.Tier != "pro" {
+		t.Errorf("Expected limit 100 and tier pro, got %d and %s", usage.Limit, usage.Tier)
+	}
+}
+
+func TestStorage_Seed(t *testing.T) {
+	client := setupTestRedis(t)
+	defer client.Close()
+
+	storage, err := New(client, DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	storagetest.Seed(t, storage)
+}
+
 func TestStorage_ConsumeQuota_WithIdempotencyKey_Concurrent(t *testing.T) {
 	client := setupTestRedis(t)
 	defer client.Close()
@@ -1348,3 +1407,16 @@ func TestStorage_EdgeCases(t *testing.T) {
 		}
 	})
 }
+
+func TestStorage_ScanRecords(t *testing.T) {
+	client := setupTestRedis(t)
+	defer client.Close()
+
+	storage, err := New(client, DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+
+	// SCAN may return a key more than once
+	storagetest.ScanRecords(t, storage, false)
+}
@@ -0,0 +1,25 @@
+package redis
+
+import (
+	"errors"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/mihaimyh/goquota/pkg/goquota"
+)
+
+// IsRetryable implements goquota.RetryClassifier
+// Connection failures, timeouts, pool exhaustion and the errors Redis returns while loading
+// or failing over (LOADING, READONLY, TRYAGAIN, CLUSTERDOWN, MASTERDOWN) are retryable.
+func (s *Storage) IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, redis.Nil) || errors.Is(err, redis.ErrClosed) {
+		return false
+	}
+	return goquota.IsTransientError(err) ||
+		errors.Is(err, redis.ErrPoolTimeout) ||
+		redis.IsLoadingError(err) ||
+		redis.IsReadOnlyError(err) ||
+		redis.IsTryAgainError(err) ||
+		redis.IsClusterDownError(err) ||
+		redis.IsMasterDownError(err)
+}
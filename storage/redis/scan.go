@@ -0,0 +1,102 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/mihaimyh/goquota/pkg/goquota"
+)
+
+// errScanCluster is returned by scans on a Redis Cluster, where SCAN only walks one node
+var errScanCluster = errors.New("redis storage: scanning records is not supported on Redis Cluster")
+
+// ScanEntitlements implements goquota.RecordScanner with SCAN.
+// The cursor is the SCAN cursor, and limit is passed as its COUNT hint.
+func (s *Storage) ScanEntitlements(
+	ctx context.Context, cursor string, limit int,
+) ([]*goquota.Entitlement, string, error) {
+	prefix := s.entitlementKey("")
+	keys, next, err := s.scanKeys(ctx, cursor, prefix+"*", limit)
+	if err != nil {
+		return nil, "", err
+	}
+
+	userIDs := make([]string, len(keys))
+	for i, key := range keys {
+		userIDs[i] = strings.TrimPrefix(key, prefix)
+	}
+	ents, err := s.GetEntitlements(ctx, userIDs)
+	if err != nil {
+		return nil, "", err
+	}
+
+	result := make([]*goquota.Entitlement, 0, len(ents))
+	for _, userID := range userIDs {
+		if ent, ok := ents[userID]; ok {
+			result = append(result, ent)
+		}
+	}
+	return result, next, nil
+}
+
+// ScanUsage implements goquota.RecordScanner with SCAN.
+// The cursor is the SCAN cursor, and limit is passed as its COUNT hint.
+func (s *Storage) ScanUsage(ctx context.Context, cursor string, limit int) ([]*goquota.Usage, string, error) {
+	keys, next, err := s.scanKeys(ctx, cursor, s.config.KeyPrefix+"usage:*", limit)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(keys) == 0 {
+		return nil, next, nil
+	}
+
+	pipe := s.client.Pipeline()
+	cmds := make([]*redis.SliceCmd, len(keys))
+	for i, key := range keys {
+		cmds[i] = pipe.HMGet(ctx, key, "data", "used")
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, "", fmt.Errorf("failed to get usages: %w", err)
+	}
+
+	result := make([]*goquota.Usage, 0, len(keys))
+	for _, cmd := range cmds {
+		usage, err := parseUsage(cmd.Val())
+		if err != nil {
+			return nil, "", err
+		}
+		if usage != nil {
+			result = append(result, usage)
+		}
+	}
+	return result, next, nil
+}
+
+// scanKeys runs one SCAN iteration over the keys matching pattern
+func (s *Storage) scanKeys(ctx context.Context, cursor, pattern string, count int) ([]string, string, error) {
+	if _, ok := s.client.(*redis.ClusterClient); ok {
+		return nil, "", errScanCluster
+	}
+
+	var position uint64
+	if cursor != "" {
+		var err error
+		if position, err = strconv.ParseUint(cursor, 10, 64); err != nil {
+			return nil, "", fmt.Errorf("invalid scan cursor: %w", err)
+		}
+	}
+
+	keys, position, err := s.client.Scan(ctx, position, pattern, int64(count)).Result()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to scan keys: %w", err)
+	}
+	if position == 0 {
+		return keys, "", nil
+	}
+	return keys, strconv.FormatUint(position, 10), nil
+}
@@ -0,0 +1,82 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mihaimyh/goquota/pkg/goquota"
+)
+
+// snapshotRetention is how long usage snapshots are kept after their period ends
+const snapshotRetention = 24 * time.Hour
+
+// RecordUsageSnapshot implements goquota.UsageSnapshotStore
+// Snapshots are stored in a hash per period, keyed by bucket start in milliseconds,
+// with "used:recordedAtMillis" values. The hash expires shortly after the period ends.
+func (s *Storage) RecordUsageSnapshot(ctx context.Context, snapshot *goquota.UsageSnapshot) error {
+	if snapshot == nil {
+		return fmt.Errorf("usage snapshot is required")
+	}
+
+	err := s.scripts["recordSnapshot"].Run(
+		ctx,
+		s.client,
+		[]string{s.snapshotKey(snapshot.UserID, snapshot.Resource, snapshot.Period)},
+		snapshot.Bucket.UnixMilli(),
+		snapshot.Used,
+		fmt.Sprintf("%d:%d", snapshot.Used, snapshot.RecordedAt.UnixMilli()),
+		snapshot.Period.End.Add(snapshotRetention).UnixMilli(),
+	).Err()
+	if err != nil {
+		return fmt.Errorf("failed to execute record snapshot script: %w", err)
+	}
+	return nil
+}
+
+// GetUsageSnapshots implements goquota.UsageSnapshotStore
+func (s *Storage) GetUsageSnapshots(
+	ctx context.Context, userID, resource string, period goquota.Period,
+) ([]*goquota.UsageSnapshot, error) {
+	fields, err := s.client.HGetAll(ctx, s.snapshotKey(userID, resource, period)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get usage snapshots: %w", err)
+	}
+
+	snapshots := make([]*goquota.UsageSnapshot, 0, len(fields))
+	for bucketField, value := range fields {
+		bucketMillis, err := strconv.ParseInt(bucketField, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid snapshot bucket %q: %w", bucketField, err)
+		}
+		usedStr, recordedStr, ok := strings.Cut(value, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid snapshot value %q", value)
+		}
+		used, err := strconv.Atoi(usedStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid snapshot usage %q: %w", value, err)
+		}
+		recordedMillis, err := strconv.ParseInt(recordedStr, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid snapshot timestamp %q: %w", value, err)
+		}
+
+		snapshots = append(snapshots, &goquota.UsageSnapshot{
+			UserID:     userID,
+			Resource:   resource,
+			Period:     period,
+			Bucket:     time.UnixMilli(bucketMillis).UTC(),
+			Used:       used,
+			RecordedAt: time.UnixMilli(recordedMillis).UTC(),
+		})
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].Bucket.Before(snapshots[j].Bucket)
+	})
+	return snapshots, nil
+}
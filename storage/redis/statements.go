@@ -0,0 +1,61 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/mihaimyh/goquota/pkg/goquota"
+)
+
+// CreateStatement implements goquota.StatementStore
+// Statements are stored as JSON in a hash per user, keyed by period type and start,
+// so HSETNX keeps the first statement of each period.
+func (s *Storage) CreateStatement(ctx context.Context, statement *goquota.Statement) (bool, error) {
+	if statement == nil {
+		return false, fmt.Errorf("statement is required")
+	}
+
+	data, err := json.Marshal(statement)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal statement: %w", err)
+	}
+
+	field := fmt.Sprintf("%s:%d", statement.Period.Type, statement.Period.Start.UnixMilli())
+	created, err := s.client.HSetNX(ctx, s.statementKey(statement.UserID), field, data).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to create statement: %w", err)
+	}
+	return created, nil
+}
+
+// GetStatements implements goquota.StatementStore
+func (s *Storage) GetStatements(ctx context.Context, userID string, limit, offset int) ([]*goquota.Statement, error) {
+	values, err := s.client.HVals(ctx, s.statementKey(userID)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get statements: %w", err)
+	}
+
+	statements := make([]*goquota.Statement, 0, len(values))
+	for _, value := range values {
+		var statement goquota.Statement
+		if err := json.Unmarshal([]byte(value), &statement); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal statement: %w", err)
+		}
+		statements = append(statements, &statement)
+	}
+
+	// Newest first
+	sort.Slice(statements, func(i, j int) bool {
+		return statements[i].Period.Start.After(statements[j].Period.Start)
+	})
+	if offset >= len(statements) {
+		return []*goquota.Statement{}, nil
+	}
+	statements = statements[offset:]
+	if limit > 0 && limit < len(statements) {
+		statements = statements[:limit]
+	}
+	return statements, nil
+}
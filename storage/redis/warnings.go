@@ -0,0 +1,69 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/mihaimyh/goquota/pkg/goquota"
+)
+
+// GetNotifiedWarnings implements goquota.WarningStateStore
+func (s *Storage) GetNotifiedWarnings(
+	ctx context.Context, userID, resource string, period goquota.Period,
+) ([]float64, error) {
+	members, err := s.client.SMembers(ctx, s.warningKey(userID, resource, period)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notified warnings: %w", err)
+	}
+
+	thresholds := make([]float64, 0, len(members))
+	for _, member := range members {
+		threshold, err := strconv.ParseFloat(member, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid warning threshold %q: %w", member, err)
+		}
+		thresholds = append(thresholds, threshold)
+	}
+	sort.Float64s(thresholds)
+	return thresholds, nil
+}
+
+// MarkWarningNotified implements goquota.WarningStateStore
+// Thresholds are members of a set per period that expires shortly after the period ends.
+func (s *Storage) MarkWarningNotified(
+	ctx context.Context, userID, resource string, period goquota.Period, threshold float64,
+) (bool, error) {
+	key := s.warningKey(userID, resource, period)
+	pipe := s.client.TxPipeline()
+	added := pipe.SAdd(ctx, key, formatThreshold(threshold))
+	if period.Type != goquota.PeriodTypeForever {
+		pipe.ExpireAt(ctx, key, period.End.Add(snapshotRetention))
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return false, fmt.Errorf("failed to mark warning as notified: %w", err)
+	}
+	return added.Val() == 1, nil
+}
+
+// ClearNotifiedWarnings implements goquota.WarningStateStore
+func (s *Storage) ClearNotifiedWarnings(
+	ctx context.Context, userID, resource string, period goquota.Period, thresholds []float64,
+) error {
+	if len(thresholds) == 0 {
+		return nil
+	}
+	members := make([]interface{}, len(thresholds))
+	for i, threshold := range thresholds {
+		members[i] = formatThreshold(threshold)
+	}
+	if err := s.client.SRem(ctx, s.warningKey(userID, resource, period), members...).Err(); err != nil {
+		return fmt.Errorf("failed to clear notified warnings: %w", err)
+	}
+	return nil
+}
+
+func formatThreshold(threshold float64) string {
+	return strconv.FormatFloat(threshold, 'f', -1, 64)
+}
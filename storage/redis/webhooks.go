@@ -0,0 +1,177 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/mihaimyh/goquota/pkg/goquota"
+)
+
+// SaveWebhookDelivery implements goquota.WebhookStore
+// Deliveries are stored as JSON and indexed in a sorted set per status.
+func (s *Storage) SaveWebhookDelivery(ctx context.Context, delivery *goquota.WebhookDelivery) error {
+	if delivery == nil {
+		return fmt.Errorf("webhook delivery is required")
+	}
+
+	data, err := json.Marshal(delivery)
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook delivery: %w", err)
+	}
+
+	pendingKey := s.webhookIndexKey(goquota.WebhookDeliveryPending)
+	deadKey := s.webhookIndexKey(goquota.WebhookDeliveryDead)
+	_, err = s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, s.webhookKey(delivery.ID), data, 0)
+		if delivery.Status == goquota.WebhookDeliveryDead {
+			pipe.ZRem(ctx, pendingKey, delivery.ID)
+			pipe.ZAdd(ctx, deadKey, redis.Z{Score: float64(delivery.CreatedAt.UnixMilli()), Member: delivery.ID})
+		} else {
+			pipe.ZRem(ctx, deadKey, delivery.ID)
+			pipe.ZAdd(ctx, pendingKey, redis.Z{Score: float64(delivery.NextAttemptAt.UnixMilli()), Member: delivery.ID})
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save webhook delivery: %w", err)
+	}
+	return nil
+}
+
+// ClaimWebhookDeliveries implements goquota.WebhookStore
+func (s *Storage) ClaimWebhookDeliveries(ctx context.Context, now time.Time, limit int,
+	lease time.Duration) ([]*goquota.WebhookDelivery, error) {
+	if limit <= 0 {
+		limit = -1 // All due deliveries
+	}
+	leaseUntil := now.Add(lease)
+
+	ids, err := s.scripts["claimWebhooks"].Run(
+		ctx,
+		s.client,
+		[]string{s.webhookIndexKey(goquota.WebhookDeliveryPending)},
+		now.UnixMilli(),
+		limit,
+		leaseUntil.UnixMilli(),
+	).StringSlice()
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute claim webhooks script: %w", err)
+	}
+
+	deliveries, err := s.getWebhookDeliveries(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, delivery := range deliveries {
+		delivery.NextAttemptAt = leaseUntil
+	}
+	return deliveries, nil
+}
+
+// GetWebhookDelivery implements goquota.WebhookStore
+func (s *Storage) GetWebhookDelivery(ctx context.Context, id string) (*goquota.WebhookDelivery, error) {
+	data, err := s.client.Get(ctx, s.webhookKey(id)).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook delivery: %w", err)
+	}
+
+	var delivery goquota.WebhookDelivery
+	if err := json.Unmarshal(data, &delivery); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal webhook delivery: %w", err)
+	}
+	return &delivery, nil
+}
+
+// ListWebhookDeliveries implements goquota.WebhookStore
+// Pending deliveries are listed from the index by next attempt and then sorted by creation time.
+func (s *Storage) ListWebhookDeliveries(ctx context.Context, status goquota.WebhookDeliveryStatus,
+	limit, offset int) ([]*goquota.WebhookDelivery, error) {
+	key := s.webhookIndexKey(status)
+	if status == goquota.WebhookDeliveryPending {
+		ids, err := s.client.ZRange(ctx, key, 0, -1).Result()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list webhook deliveries: %w", err)
+		}
+		deliveries, err := s.getWebhookDeliveries(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+		// Newest first
+		sort.Slice(deliveries, func(i, j int) bool {
+			return deliveries[i].CreatedAt.After(deliveries[j].CreatedAt)
+		})
+		if offset >= len(deliveries) {
+			return []*goquota.WebhookDelivery{}, nil
+		}
+		deliveries = deliveries[offset:]
+		if limit > 0 && limit < len(deliveries) {
+			deliveries = deliveries[:limit]
+		}
+		return deliveries, nil
+	}
+
+	stop := int64(-1)
+	if limit > 0 {
+		stop = int64(offset + limit - 1)
+	}
+	ids, err := s.client.ZRevRange(ctx, key, int64(offset), stop).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+	return s.getWebhookDeliveries(ctx, ids)
+}
+
+// DeleteWebhookDelivery implements goquota.WebhookStore
+func (s *Storage) DeleteWebhookDelivery(ctx context.Context, id string) error {
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, s.webhookKey(id))
+		pipe.ZRem(ctx, s.webhookIndexKey(goquota.WebhookDeliveryPending), id)
+		pipe.ZRem(ctx, s.webhookIndexKey(goquota.WebhookDeliveryDead), id)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook delivery: %w", err)
+	}
+	return nil
+}
+
+// getWebhookDeliveries reads deliveries in one pipeline, preserving order and skipping missing ones
+func (s *Storage) getWebhookDeliveries(ctx context.Context, ids []string) ([]*goquota.WebhookDelivery, error) {
+	deliveries := make([]*goquota.WebhookDelivery, 0, len(ids))
+	if len(ids) == 0 {
+		return deliveries, nil
+	}
+
+	pipe := s.client.Pipeline()
+	cmds := make([]*redis.StringCmd, len(ids))
+	for i, id := range ids {
+		cmds[i] = pipe.Get(ctx, s.webhookKey(id))
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("failed to get webhook deliveries: %w", err)
+	}
+
+	for _, cmd := range cmds {
+		data, err := cmd.Bytes()
+		if err == redis.Nil {
+			continue // Deleted concurrently
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get webhook delivery: %w", err)
+		}
+		var delivery goquota.WebhookDelivery
+		if err := json.Unmarshal(data, &delivery); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal webhook delivery: %w", err)
+		}
+		deliveries = append(deliveries, &delivery)
+	}
+	return deliveries, nil
+}
@@ -0,0 +1,181 @@
+package tiered
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mihaimyh/goquota/pkg/goquota"
+)
+
+const (
+	defaultFlushInterval = time.Second
+	defaultFlushSize     = 1000
+)
+
+// usageDeltaKey identifies the usage record a delta applies to
+type usageDeltaKey struct {
+	userID     string
+	resource   string
+	periodType goquota.PeriodType
+	period     string
+}
+
+func deltaKey(userID, resource string, period goquota.Period) usageDeltaKey {
+	return usageDeltaKey{userID: userID, resource: resource, periodType: period.Type, period: period.Key()}
+}
+
+// usageCoalescer aggregates consumptions per user, resource and period and writes
+// each aggregate to the Cold store as a single delta.
+type usageCoalescer struct {
+	store     goquota.UsageDeltaStore
+	interval  time.Duration
+	size      int
+	onError   func(error)
+	mu        sync.Mutex
+	pending   map[usageDeltaKey]*goquota.UsageDeltaRequest
+	flushMu   sync.Mutex // serializes writes to the Cold store
+	trigger   chan struct{}
+	shutdown  chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func newUsageCoalescer(store goquota.UsageDeltaStore, config *Config) *usageCoalescer {
+	c := &usageCoalescer{
+		store:    store,
+		interval: config.FlushInterval,
+		size:     config.FlushSize,
+		onError:  config.AsyncErrorHandler,
+		pending:  make(map[usageDeltaKey]*goquota.UsageDeltaRequest),
+		trigger:  make(chan struct{}, 1),
+		shutdown: make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go c.run()
+	return c
+}
+
+// run flushes pending deltas every interval, or earlier once size deltas are pending
+func (c *usageCoalescer) run() {
+	defer close(c.done)
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.shutdown:
+			return
+		case <-ticker.C:
+		case <-c.trigger:
+		}
+		if err := c.flush(context.Background()); err != nil && c.onError != nil {
+			c.onError(fmt.Errorf("tiered usage flush failed: %w", err))
+		}
+	}
+}
+
+// add aggregates a consumption admitted by the Hot store
+func (c *usageCoalescer) add(req *goquota.ConsumeRequest) {
+	c.mu.Lock()
+	c.merge(deltaKey(req.UserID, req.Resource, req.Period), &goquota.UsageDeltaRequest{
+		UserID:   req.UserID,
+		Resource: req.Resource,
+		Amount:   req.Amount,
+		Tier:     req.Tier,
+		Period:   req.Period,
+		Limit:    req.Limit,
+	})
+	full := len(c.pending) >= c.size
+	c.mu.Unlock()
+
+	if full {
+		select {
+		case c.trigger <- struct{}{}:
+		default:
+			// A flush is already requested
+		}
+	}
+}
+
+// merge adds a delta to the pending one of its key; the latest limit and tier win. c.mu must be held.
+func (c *usageCoalescer) merge(key usageDeltaKey, delta *goquota.UsageDeltaRequest) {
+	if existing, ok := c.pending[key]; ok {
+		existing.Amount += delta.Amount
+		existing.Limit = delta.Limit
+		existing.Tier = delta.Tier
+		return
+	}
+	c.pending[key] = delta
+}
+
+// flush writes all pending deltas to the Cold store. Deltas that fail are kept for the next flush.
+func (c *usageCoalescer) flush(ctx context.Context) error {
+	c.flushMu.Lock()
+	defer c.flushMu.Unlock()
+
+	c.mu.Lock()
+	pending := c.pending
+	c.pending = make(map[usageDeltaKey]*goquota.UsageDeltaRequest, len(pending))
+	c.mu.Unlock()
+
+	var errs []error
+	for key, delta := range pending {
+		if err := c.store.ApplyUsageDelta(ctx, delta); err != nil {
+			errs = append(errs, err)
+			c.requeue(key, delta)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// flushKey writes the pending delta of one usage record to the Cold store
+func (c *usageCoalescer) flushKey(ctx context.Context, key usageDeltaKey) error {
+	c.flushMu.Lock()
+	defer c.flushMu.Unlock()
+
+	c.mu.Lock()
+	delta, ok := c.pending[key]
+	delete(c.pending, key)
+	c.mu.Unlock()
+	if !ok {
+		return nil
+	}
+
+	if err := c.store.ApplyUsageDelta(ctx, delta); err != nil {
+		c.requeue(key, delta)
+		return err
+	}
+	return nil
+}
+
+// requeue puts back a delta that failed to be written. It is older than anything
+// added since, so a newer pending delta keeps its limit and tier.
+func (c *usageCoalescer) requeue(key usageDeltaKey, delta *goquota.UsageDeltaRequest) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if existing, ok := c.pending[key]; ok {
+		existing.Amount += delta.Amount
+		return
+	}
+	c.pending[key] = delta
+}
+
+// discard drops the pending delta of a usage record that is being overwritten
+func (c *usageCoalescer) discard(key usageDeltaKey) {
+	// Wait for an in-flight flush so that it cannot apply the delta after the overwrite
+	c.flushMu.Lock()
+	defer c.flushMu.Unlock()
+
+	c.mu.Lock()
+	delete(c.pending, key)
+	c.mu.Unlock()
+}
+
+// close stops the periodic flushes and writes the remaining deltas
+func (c *usageCoalescer) close(ctx context.Context) error {
+	c.closeOnce.Do(func() { close(c.shutdown) })
+	<-c.done
+	return c.flush(ctx)
+}
@@ -0,0 +1,286 @@
+package tiered
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mihaimyh/goquota/pkg/goquota"
+)
+
+const (
+	defaultSyncMaxAttempts     = 10
+	defaultSyncRetryBackoff    = time.Second
+	defaultSyncMaxRetryBackoff = 5 * time.Minute
+)
+
+// Outcomes of sync outbox deliveries, recorded in metrics
+const (
+	syncDelivered = "delivered"
+	syncRetry     = "retry"
+	syncParked    = "parked"
+)
+
+// SyncEntry is a consumption waiting in a SyncOutbox to be written to the Cold store
+type SyncEntry struct {
+	ID string `json:"id"`
+
+	// Request is replayed to the Cold store as is. Its idempotency key is the caller's key,
+	// or one derived from ID, so a write that reached Cold before failing is not counted twice.
+	Request goquota.ConsumeRequest `json:"request"`
+
+	CreatedAt time.Time `json:"created_at"`
+
+	// Attempts is the number of failed writes so far
+	Attempts int `json:"attempts"`
+
+	// NextAttemptAt is when the write is retried
+	NextAttemptAt time.Time `json:"next_attempt_at"`
+
+	// LastError is the error of the last failed write
+	LastError string `json:"last_error,omitempty"`
+
+	// Parked entries are not retried until RetryParkedSync is called: their write failed
+	// with a permanent error or SyncMaxAttempts times.
+	Parked bool `json:"parked"`
+}
+
+// SyncOutbox durably holds the Cold writes of AsyncUsageSync until they succeed
+type SyncOutbox interface {
+	// Put adds an entry or replaces the entry with the same ID
+	Put(ctx context.Context, entry *SyncEntry) error
+
+	// Remove removes an entry. Removing an unknown ID is not an error.
+	Remove(ctx context.Context, id string) error
+
+	// Entries returns all entries, parked ones included, oldest first
+	Entries(ctx context.Context) ([]*SyncEntry, error)
+}
+
+// DueSyncOutbox is a SyncOutbox that indexes its entries by next attempt (optional), e.g.
+// FileSyncOutbox. Delivery passes then only read the entries that are due, instead of all
+// entries through Entries.
+type DueSyncOutbox interface {
+	SyncOutbox
+
+	// Due returns the unparked entries whose NextAttemptAt is not after now
+	Due(ctx context.Context, now time.Time) ([]*SyncEntry, error)
+
+	// Depth returns the number of unparked and parked entries, and the CreatedAt of the
+	// oldest unparked one (zero if there is none)
+	Depth(ctx context.Context) (pending, parked int, oldest time.Time, err error)
+}
+
+func newSyncID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return time.Now().UTC().Format("20060102150405.000000000")
+	}
+	return hex.EncodeToString(b)
+}
+
+// enqueueSync durably queues the Cold write of a consumption admitted by the Hot store
+func (s *Storage) enqueueSync(ctx context.Context, req *goquota.ConsumeRequest) {
+	now := time.Now().UTC()
+	entry := &SyncEntry{ID: newSyncID(), Request: *req, CreatedAt: now, NextAttemptAt: now}
+	if entry.Request.IdempotencyKey == "" {
+		entry.Request.IdempotencyKey = "tiered_sync:" + entry.ID
+	}
+
+	if err := s.conf.Outbox.Put(ctx, entry); err != nil {
+		s.reportSyncError(fmt.Errorf("tiered storage: failed to queue cold write: %w", err))
+		return
+	}
+	s.signalOutbox()
+}
+
+// signalOutbox requests a delivery pass
+func (s *Storage) signalOutbox() {
+	select {
+	case s.outboxSignal <- struct{}{}:
+	default:
+		// A delivery pass is already requested
+	}
+}
+
+// startOutboxWorker delivers outbox entries when they are queued and when their retry is due.
+// Entries left by a previous process are delivered right away, whatever their backoff.
+func (s *Storage) startOutboxWorker() {
+	poll := min(s.conf.SyncRetryBackoff, time.Second)
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(poll)
+		defer ticker.Stop()
+		s.deliverOutbox(context.Background(), true)
+		for {
+			select {
+			case <-s.shutdown:
+				// Last attempt for due entries; the others stay in the outbox
+				s.deliverOutbox(context.Background(), false)
+				return
+			case <-ticker.C:
+			case <-s.outboxSignal:
+			}
+			s.deliverOutbox(context.Background(), false)
+		}
+	}()
+}
+
+// deliverOutbox writes the due entries of the outbox to the Cold store, or all unparked ones
+func (s *Storage) deliverOutbox(ctx context.Context, all bool) {
+	if outbox, ok := s.conf.Outbox.(DueSyncOutbox); ok && !all {
+		s.deliverDueOutbox(ctx, outbox)
+		return
+	}
+
+	entries, err := s.conf.Outbox.Entries(ctx)
+	if err != nil {
+		s.reportSyncError(fmt.Errorf("tiered storage: failed to read sync outbox: %w", err))
+		return
+	}
+
+	now := time.Now().UTC()
+	s.recordOutboxDepth(entries, now)
+	for _, entry := range entries {
+		if entry.Parked || (!all && entry.NextAttemptAt.After(now)) {
+			continue
+		}
+		s.deliverSync(ctx, entry)
+	}
+}
+
+// deliverDueOutbox writes the due entries of an indexed outbox to the Cold store
+func (s *Storage) deliverDueOutbox(ctx context.Context, outbox DueSyncOutbox) {
+	now := time.Now().UTC()
+	pending, parked, oldest, err := outbox.Depth(ctx)
+	if err != nil {
+		s.reportSyncError(fmt.Errorf("tiered storage: failed to read sync outbox: %w", err))
+		return
+	}
+	var lag time.Duration
+	if !oldest.IsZero() {
+		lag = max(now.Sub(oldest), 0)
+	}
+	s.conf.Metrics.RecordSyncOutbox(pending, parked, lag)
+
+	entries, err := outbox.Due(ctx, now)
+	if err != nil {
+		s.reportSyncError(fmt.Errorf("tiered storage: failed to read sync outbox: %w", err))
+		return
+	}
+	for _, entry := range entries {
+		s.deliverSync(ctx, entry)
+	}
+}
+
+// recordOutboxDepth records the number of pending and parked entries, and the age of the oldest pending one
+func (s *Storage) recordOutboxDepth(entries []*SyncEntry, now time.Time) {
+	var pending, parked int
+	var lag time.Duration
+	for _, entry := range entries {
+		if entry.Parked {
+			parked++
+			continue
+		}
+		pending++
+		lag = max(lag, now.Sub(entry.CreatedAt))
+	}
+	s.conf.Metrics.RecordSyncOutbox(pending, parked, lag)
+}
+
+func (s *Storage) deliverSync(ctx context.Context, entry *SyncEntry) {
+	_, err := s.cold.ConsumeQuota(ctx, &entry.Request)
+	if err == nil {
+		if err := s.conf.Outbox.Remove(ctx, entry.ID); err != nil {
+			// Delivered again on the next pass, which the idempotency key makes harmless
+			s.reportSyncError(fmt.Errorf("tiered storage: failed to remove synced entry: %w", err))
+		}
+		s.conf.Metrics.RecordSyncOutboxDelivery(syncDelivered)
+		return
+	}
+
+	entry.Attempts++
+	entry.LastError = err.Error()
+	outcome := syncRetry
+	if isPermanentSyncError(err) || entry.Attempts >= s.conf.SyncMaxAttempts {
+		entry.Parked = true
+		outcome = syncParked
+		s.reportSyncError(fmt.Errorf("tiered storage: cold write parked after %d attempts: %w", entry.Attempts, err))
+	} else {
+		entry.NextAttemptAt = time.Now().UTC().Add(s.syncRetryDelay(entry.Attempts))
+		s.reportSyncError(fmt.Errorf("tiered sync failed: %w", err))
+	}
+	if err := s.conf.Outbox.Put(ctx, entry); err != nil {
+		s.reportSyncError(fmt.Errorf("tiered storage: failed to update sync outbox: %w", err))
+	}
+	s.conf.Metrics.RecordSyncOutboxDelivery(outcome)
+}
+
+// isPermanentSyncError reports whether retrying a Cold write cannot succeed
+func isPermanentSyncError(err error) bool {
+	return errors.Is(err, goquota.ErrQuotaExceeded) || errors.Is(err, goquota.ErrInvalidAmount)
+}
+
+// syncRetryDelay returns the delay before the next attempt: SyncRetryBackoff doubled for
+// each failed attempt, capped at SyncMaxRetryBackoff
+func (s *Storage) syncRetryDelay(attempts int) time.Duration {
+	delay := s.conf.SyncRetryBackoff
+	for i := 1; i < attempts && delay < s.conf.SyncMaxRetryBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, s.conf.SyncMaxRetryBackoff)
+}
+
+func (s *Storage) reportSyncError(err error) {
+	if s.conf.AsyncErrorHandler != nil {
+		s.conf.AsyncErrorHandler(err)
+	}
+}
+
+// ParkedSyncs returns the Cold writes that were parked (Outbox only)
+func (s *Storage) ParkedSyncs(ctx context.Context) ([]*SyncEntry, error) {
+	if s.conf.Outbox == nil {
+		return nil, errors.New("tiered storage: ParkedSyncs requires an Outbox")
+	}
+	entries, err := s.conf.Outbox.Entries(ctx)
+	if err != nil {
+		return nil, err
+	}
+	parked := make([]*SyncEntry, 0, len(entries))
+	for _, entry := range entries {
+		if entry.Parked {
+			parked = append(parked, entry)
+		}
+	}
+	return parked, nil
+}
+
+// RetryParkedSync queues a parked Cold write again, e.g. after fixing the Cold data it conflicted with.
+// Use the Outbox's Remove to give up on it instead.
+func (s *Storage) RetryParkedSync(ctx context.Context, id string) error {
+	if s.conf.Outbox == nil {
+		return errors.New("tiered storage: RetryParkedSync requires an Outbox")
+	}
+	entries, err := s.conf.Outbox.Entries(ctx)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if entry.ID != id || !entry.Parked {
+			continue
+		}
+		entry.Parked = false
+		entry.Attempts = 0
+		entry.NextAttemptAt = time.Now().UTC()
+		if err := s.conf.Outbox.Put(ctx, entry); err != nil {
+			return err
+		}
+		s.signalOutbox()
+		return nil
+	}
+	return fmt.Errorf("tiered storage: no parked sync with ID %q", id)
+}
@@ -0,0 +1,217 @@
+package tiered
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/mihaimyh/goquota/internal/wal"
+)
+
+// minSyncScheduleRebuild is the number of schedule items below which stale ones are never purged
+const minSyncScheduleRebuild = 1024
+
+// FileSyncOutbox is a SyncOutbox backed by an append-only file (write-ahead log) on the local disk.
+// Every change is written as a JSON line and synced to disk before returning, so queued Cold writes
+// survive a crash or restart. The file is compacted when it is opened and once it mostly holds
+// superseded records, and truncated whenever the outbox is empty.
+//
+// It implements DueSyncOutbox: entries are indexed by next attempt, so delivery passes only
+// read the entries that are due.
+type FileSyncOutbox struct {
+	mu  sync.Mutex
+	log *wal.Log[SyncEntry]
+
+	// due holds unparked entries by NextAttemptAt, oldest by CreatedAt
+	due    syncSchedule
+	oldest syncSchedule
+	parked int
+}
+
+// NewFileSyncOutbox opens (or creates) the outbox at path and loads its entries.
+// A truncated last line, as left by a crash during a write, is ignored.
+func NewFileSyncOutbox(path string) (*FileSyncOutbox, error) {
+	log, err := wal.Open(path, wal.Config[SyncEntry]{
+		Name:  "sync outbox",
+		PutOp: "put",
+		ID:    func(entry *SyncEntry) string { return entry.ID },
+	})
+	if err != nil {
+		return nil, err
+	}
+	o := &FileSyncOutbox{log: log}
+	o.reindex()
+	return o, nil
+}
+
+// reindex rebuilds the schedules from the entries, dropping stale items
+func (o *FileSyncOutbox) reindex() {
+	o.due, o.oldest, o.parked = nil, nil, 0
+	for _, entry := range o.log.Entries() {
+		if entry.Parked {
+			o.parked++
+			continue
+		}
+		o.due = append(o.due, syncScheduleItem{at: entry.NextAttemptAt, id: entry.ID})
+		o.oldest = append(o.oldest, syncScheduleItem{at: entry.CreatedAt, id: entry.ID})
+	}
+	heap.Init(&o.due)
+	heap.Init(&o.oldest)
+}
+
+// maybeReindex purges stale schedule items once they outnumber the entries
+func (o *FileSyncOutbox) maybeReindex() {
+	items := max(len(o.due), len(o.oldest))
+	if items > minSyncScheduleRebuild && items > 2*o.log.Len() {
+		o.reindex()
+	}
+}
+
+// isDue reports whether a schedule item is still the next attempt of an unparked entry
+func (o *FileSyncOutbox) isDue(item syncScheduleItem) bool {
+	entry, ok := o.log.Get(item.id)
+	return ok && !entry.Parked && entry.NextAttemptAt.Equal(item.at)
+}
+
+// isOldest reports whether a schedule item is still the creation of an unparked entry
+func (o *FileSyncOutbox) isOldest(item syncScheduleItem) bool {
+	entry, ok := o.log.Get(item.id)
+	return ok && !entry.Parked && entry.CreatedAt.Equal(item.at)
+}
+
+// Put implements SyncOutbox
+func (o *FileSyncOutbox) Put(_ context.Context, entry *SyncEntry) error {
+	entryCopy := *entry
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	prev, existed := o.log.Get(entry.ID)
+	var prevCopy SyncEntry
+	if existed {
+		prevCopy = *prev
+	}
+	if err := o.log.Put(&entryCopy); err != nil {
+		return err
+	}
+
+	wasPending := existed && !prevCopy.Parked
+	if existed && prevCopy.Parked {
+		o.parked--
+	}
+	if entryCopy.Parked {
+		o.parked++
+		return nil
+	}
+	if !wasPending || !prevCopy.NextAttemptAt.Equal(entryCopy.NextAttemptAt) {
+		heap.Push(&o.due, syncScheduleItem{at: entryCopy.NextAttemptAt, id: entryCopy.ID})
+	}
+	if !wasPending || !prevCopy.CreatedAt.Equal(entryCopy.CreatedAt) {
+		heap.Push(&o.oldest, syncScheduleItem{at: entryCopy.CreatedAt, id: entryCopy.ID})
+	}
+	o.maybeReindex()
+	return nil
+}
+
+// Remove implements SyncOutbox
+func (o *FileSyncOutbox) Remove(_ context.Context, id string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	entry, ok := o.log.Get(id)
+	if !ok {
+		return nil
+	}
+	parked := entry.Parked
+	if err := o.log.Remove(id); err != nil {
+		return err
+	}
+	if parked {
+		o.parked--
+	}
+	o.maybeReindex()
+	return nil
+}
+
+// Entries implements SyncOutbox
+func (o *FileSyncOutbox) Entries(_ context.Context) ([]*SyncEntry, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	logEntries := o.log.Entries()
+	entries := make([]*SyncEntry, 0, len(logEntries))
+	for _, entry := range logEntries {
+		entryCopy := *entry
+		entries = append(entries, &entryCopy)
+	}
+	return entries, nil
+}
+
+// Due implements DueSyncOutbox
+func (o *FileSyncOutbox) Due(_ context.Context, now time.Time) ([]*SyncEntry, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	var entries []*SyncEntry
+	var items []syncScheduleItem
+	seen := make(map[string]bool)
+	for len(o.due) > 0 && !o.due[0].at.After(now) {
+		item := heap.Pop(&o.due).(syncScheduleItem)
+		if !o.isDue(item) || seen[item.id] {
+			continue
+		}
+		seen[item.id] = true
+		items = append(items, item)
+		entry, _ := o.log.Get(item.id)
+		entryCopy := *entry
+		entries = append(entries, &entryCopy)
+	}
+	// Still due until they are removed or rescheduled
+	for _, item := range items {
+		heap.Push(&o.due, item)
+	}
+	return entries, nil
+}
+
+// Depth implements DueSyncOutbox
+func (o *FileSyncOutbox) Depth(_ context.Context) (pending, parked int, oldest time.Time, err error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	for len(o.oldest) > 0 && !o.isOldest(o.oldest[0]) {
+		heap.Pop(&o.oldest)
+	}
+	if len(o.oldest) > 0 {
+		oldest = o.oldest[0].at
+	}
+	return o.log.Len() - o.parked, o.parked, oldest, nil
+}
+
+// Close closes the outbox file. Entries are kept for the next NewFileSyncOutbox.
+func (o *FileSyncOutbox) Close() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.log.Close()
+}
+
+// syncScheduleItem is an entry ID scheduled at a time. It goes stale once the entry is
+// removed, parked or rescheduled, and is then skipped.
+type syncScheduleItem struct {
+	at time.Time
+	id string
+}
+
+// syncSchedule is a min-heap of schedule items by time
+type syncSchedule []syncScheduleItem
+
+func (s syncSchedule) Len() int           { return len(s) }
+func (s syncSchedule) Less(i, j int) bool { return s[i].at.Before(s[j].at) }
+func (s syncSchedule) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s *syncSchedule) Push(x any)        { *s = append(*s, x.(syncScheduleItem)) }
+func (s *syncSchedule) Pop() any {
+	old := *s
+	item := old[len(old)-1]
+	*s = old[:len(old)-1]
+	return item
+}
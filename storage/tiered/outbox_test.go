@@ -0,0 +1,362 @@
+package tiered
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mihaimyh/goquota/pkg/goquota"
+	"github.com/mihaimyh/goquota/storage/memory"
+)
+
+// flakyColdStorage fails ConsumeQuota with err until it is cleared
+type flakyColdStorage struct {
+	*memory.Storage
+	mu       sync.Mutex
+	err      error
+	attempts int
+}
+
+func (s *flakyColdStorage) ConsumeQuota(ctx context.Context, req *goquota.ConsumeRequest) (int, error) {
+	s.mu.Lock()
+	s.attempts++
+	err := s.err
+	s.mu.Unlock()
+	if err != nil {
+		return 0, err
+	}
+	return s.Storage.ConsumeQuota(ctx, req)
+}
+
+func (s *flakyColdStorage) setErr(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = err
+}
+
+func (s *flakyColdStorage) attemptCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.attempts
+}
+
+func newTestOutbox(t *testing.T) (*FileSyncOutbox, string) {
+	path := filepath.Join(t.TempDir(), "sync.wal")
+	outbox, err := NewFileSyncOutbox(path)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = outbox.Close() })
+	return outbox, path
+}
+
+func outboxTestRequest(amount int) *goquota.ConsumeRequest {
+	return &goquota.ConsumeRequest{
+		UserID:   "user1",
+		Resource: "api_calls",
+		Amount:   amount,
+		Tier:     "pro",
+		Period: goquota.Period{
+			Start: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			End:   time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+			Type:  goquota.PeriodTypeMonthly,
+		},
+		Limit: 100,
+	}
+}
+
+func coldUsed(t *testing.T, cold goquota.Storage) int {
+	req := outboxTestRequest(0)
+	usage, err := cold.GetUsage(context.Background(), req.UserID, req.Resource, req.Period)
+	require.NoError(t, err)
+	if usage == nil {
+		return 0
+	}
+	return usage.Used
+}
+
+func TestFileSyncOutbox(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("entries survive reopening", func(t *testing.T) {
+		outbox, path := newTestOutbox(t)
+		for _, id := range []string{"a", "b", "c"} {
+			require.NoError(t, outbox.Put(ctx, &SyncEntry{ID: id, Request: *outboxTestRequest(1)}))
+		}
+		require.NoError(t, outbox.Remove(ctx, "b"))
+		require.NoError(t, outbox.Put(ctx, &SyncEntry{ID: "a", Attempts: 2, Parked: true}))
+		require.NoError(t, outbox.Close())
+
+		reopened, err := NewFileSyncOutbox(path)
+		require.NoError(t, err)
+		defer reopened.Close()
+
+		entries, err := reopened.Entries(ctx)
+		require.NoError(t, err)
+		require.Len(t, entries, 2)
+		assert.Equal(t, "a", entries[0].ID)
+		assert.True(t, entries[0].Parked)
+		assert.Equal(t, 2, entries[0].Attempts)
+		assert.Equal(t, "c", entries[1].ID)
+		assert.Equal(t, "api_calls", entries[1].Request.Resource)
+	})
+
+	t.Run("ignores a truncated last line", func(t *testing.T) {
+		outbox, path := newTestOutbox(t)
+		require.NoError(t, outbox.Put(ctx, &SyncEntry{ID: "a"}))
+		require.NoError(t, outbox.Close())
+
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o600)
+		require.NoError(t, err)
+		_, err = f.WriteString(`{"op":"put","entry":{"id":"b"`)
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+
+		reopened, err := NewFileSyncOutbox(path)
+		require.NoError(t, err)
+		defer reopened.Close()
+		entries, err := reopened.Entries(ctx)
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.Equal(t, "a", entries[0].ID)
+	})
+
+	t.Run("lists due entries", func(t *testing.T) {
+		outbox, _ := newTestOutbox(t)
+		now := time.Now().UTC()
+		require.NoError(t, outbox.Put(ctx, &SyncEntry{ID: "a", CreatedAt: now.Add(-time.Minute), NextAttemptAt: now}))
+		require.NoError(t, outbox.Put(ctx, &SyncEntry{ID: "b", CreatedAt: now, NextAttemptAt: now.Add(time.Hour)}))
+		require.NoError(t, outbox.Put(ctx, &SyncEntry{ID: "c", CreatedAt: now, NextAttemptAt: now, Parked: true}))
+
+		due, err := outbox.Due(ctx, now)
+		require.NoError(t, err)
+		require.Len(t, due, 1)
+		assert.Equal(t, "a", due[0].ID)
+
+		pending, parked, oldest, err := outbox.Depth(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 2, pending)
+		assert.Equal(t, 1, parked)
+		assert.True(t, oldest.Equal(now.Add(-time.Minute)))
+
+		// Rescheduled, removed and unparked entries are indexed again
+		require.NoError(t, outbox.Put(ctx, &SyncEntry{ID: "a", CreatedAt: now.Add(-time.Minute),
+			NextAttemptAt: now.Add(time.Hour), Attempts: 1}))
+		require.NoError(t, outbox.Remove(ctx, "b"))
+		require.NoError(t, outbox.Put(ctx, &SyncEntry{ID: "c", CreatedAt: now, NextAttemptAt: now}))
+
+		due, err = outbox.Due(ctx, now)
+		require.NoError(t, err)
+		require.Len(t, due, 1)
+		assert.Equal(t, "c", due[0].ID)
+
+		due, err = outbox.Due(ctx, now.Add(2*time.Hour))
+		require.NoError(t, err)
+		assert.Len(t, due, 2)
+
+		pending, parked, _, err = outbox.Depth(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 2, pending)
+		assert.Zero(t, parked)
+	})
+
+	t.Run("truncates when empty", func(t *testing.T) {
+		outbox, path := newTestOutbox(t)
+		require.NoError(t, outbox.Put(ctx, &SyncEntry{ID: "a"}))
+		require.NoError(t, outbox.Remove(ctx, "a"))
+
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		assert.Zero(t, info.Size())
+	})
+}
+
+func TestStorage_ConsumeQuota_Outbox(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("requires async usage sync", func(t *testing.T) {
+		outbox, _ := newTestOutbox(t)
+		storage, err := New(Config{Hot: memory.New(), Cold: memory.New(), Outbox: outbox})
+		assert.Error(t, err)
+		assert.Nil(t, storage)
+	})
+
+	t.Run("rejects coalesced usage sync", func(t *testing.T) {
+		outbox, _ := newTestOutbox(t)
+		storage, err := New(Config{
+			Hot:               memory.New(),
+			Cold:              memory.New(),
+			AsyncUsageSync:    true,
+			CoalesceUsageSync: true,
+			Outbox:            outbox,
+		})
+		assert.Error(t, err)
+		assert.Nil(t, storage)
+	})
+
+	t.Run("delivers queued writes", func(t *testing.T) {
+		outbox, _ := newTestOutbox(t)
+		cold := memory.New()
+		storage, err := New(Config{Hot: memory.New(), Cold: cold, AsyncUsageSync: true, Outbox: outbox})
+		require.NoError(t, err)
+		defer storage.Close()
+
+		_, err = storage.ConsumeQuota(ctx, outboxTestRequest(5))
+		require.NoError(t, err)
+
+		assert.Eventually(t, func() bool {
+			entries, _ := outbox.Entries(ctx)
+			return len(entries) == 0
+		}, time.Second, 5*time.Millisecond)
+		assert.Equal(t, 5, coldUsed(t, cold))
+	})
+
+	t.Run("redelivery is applied once", func(t *testing.T) {
+		outbox, _ := newTestOutbox(t)
+		cold := memory.New()
+		storage, err := New(Config{Hot: memory.New(), Cold: cold, AsyncUsageSync: true, Outbox: outbox})
+		require.NoError(t, err)
+		defer storage.Close()
+
+		storage.enqueueSync(ctx, outboxTestRequest(5))
+		entries, err := outbox.Entries(ctx)
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.Contains(t, entries[0].Request.IdempotencyKey, entries[0].ID)
+
+		// As if the entry was delivered but not removed before a crash
+		storage.deliverSync(ctx, entries[0])
+		storage.deliverSync(ctx, entries[0])
+		assert.Equal(t, 5, coldUsed(t, cold))
+	})
+
+	t.Run("retries with backoff", func(t *testing.T) {
+		outbox, _ := newTestOutbox(t)
+		cold := &flakyColdStorage{Storage: memory.New(), err: errors.New("connection refused")}
+		var mu sync.Mutex
+		var reported []error
+		storage, err := New(Config{
+			Hot: memory.New(), Cold: cold, AsyncUsageSync: true, Outbox: outbox,
+			SyncRetryBackoff: 5 * time.Millisecond, SyncMaxRetryBackoff: 20 * time.Millisecond,
+			AsyncErrorHandler: func(err error) {
+				mu.Lock()
+				defer mu.Unlock()
+				reported = append(reported, err)
+			},
+		})
+		require.NoError(t, err)
+		defer storage.Close()
+
+		_, err = storage.ConsumeQuota(ctx, outboxTestRequest(5))
+		require.NoError(t, err)
+		assert.Eventually(t, func() bool { return cold.attemptCount() >= 3 }, time.Second, 5*time.Millisecond)
+
+		// The write is kept, never dropped
+		entries, err := outbox.Entries(ctx)
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.False(t, entries[0].Parked)
+		assert.Equal(t, "connection refused", entries[0].LastError)
+
+		cold.setErr(nil)
+		assert.Eventually(t, func() bool { return coldUsed(t, cold) == 5 }, time.Second, 5*time.Millisecond)
+
+		mu.Lock()
+		defer mu.Unlock()
+		assert.NotEmpty(t, reported)
+	})
+
+	t.Run("parks poison writes", func(t *testing.T) {
+		outbox, _ := newTestOutbox(t)
+		cold := &flakyColdStorage{Storage: memory.New(), err: goquota.ErrQuotaExceeded}
+		storage, err := New(Config{
+			Hot: memory.New(), Cold: cold, AsyncUsageSync: true, Outbox: outbox,
+			SyncRetryBackoff: 5 * time.Millisecond,
+		})
+		require.NoError(t, err)
+		defer storage.Close()
+
+		_, err = storage.ConsumeQuota(ctx, outboxTestRequest(5))
+		require.NoError(t, err)
+
+		var parked []*SyncEntry
+		assert.Eventually(t, func() bool {
+			parked, _ = storage.ParkedSyncs(ctx)
+			return len(parked) == 1
+		}, time.Second, 5*time.Millisecond)
+		assert.Equal(t, 1, parked[0].Attempts)
+		assert.Equal(t, 1, cold.attemptCount(), "permanent errors are not retried")
+
+		cold.setErr(nil)
+		require.NoError(t, storage.RetryParkedSync(ctx, parked[0].ID))
+		assert.Eventually(t, func() bool { return coldUsed(t, cold) == 5 }, time.Second, 5*time.Millisecond)
+		assert.Error(t, storage.RetryParkedSync(ctx, parked[0].ID))
+	})
+
+	t.Run("parks after max attempts", func(t *testing.T) {
+		outbox, _ := newTestOutbox(t)
+		cold := &flakyColdStorage{Storage: memory.New(), err: errors.New("timeout")}
+		storage, err := New(Config{
+			Hot: memory.New(), Cold: cold, AsyncUsageSync: true, Outbox: outbox,
+			SyncMaxAttempts: 3, SyncRetryBackoff: time.Millisecond,
+		})
+		require.NoError(t, err)
+		defer storage.Close()
+
+		_, err = storage.ConsumeQuota(ctx, outboxTestRequest(5))
+		require.NoError(t, err)
+		assert.Eventually(t, func() bool {
+			parked, _ := storage.ParkedSyncs(ctx)
+			return len(parked) == 1
+		}, time.Second, 5*time.Millisecond)
+		assert.Equal(t, 3, cold.attemptCount())
+	})
+
+	t.Run("delivers writes left by a previous process", func(t *testing.T) {
+		outbox, path := newTestOutbox(t)
+		cold := &flakyColdStorage{Storage: memory.New(), err: errors.New("connection refused")}
+		storage, err := New(Config{
+			Hot: memory.New(), Cold: cold, AsyncUsageSync: true, Outbox: outbox, SyncRetryBackoff: time.Hour,
+		})
+		require.NoError(t, err)
+		_, err = storage.ConsumeQuota(ctx, outboxTestRequest(5))
+		require.NoError(t, err)
+		require.NoError(t, storage.Close())
+		require.NoError(t, outbox.Close())
+
+		reopened, err := NewFileSyncOutbox(path)
+		require.NoError(t, err)
+		defer reopened.Close()
+		entries, err := reopened.Entries(ctx)
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.True(t, entries[0].NextAttemptAt.After(time.Now()))
+
+		// Delivered right away, regardless of the backoff scheduled by the previous process
+		cold.setErr(nil)
+		restarted, err := New(Config{Hot: memory.New(), Cold: cold, AsyncUsageSync: true, Outbox: reopened})
+		require.NoError(t, err)
+		defer restarted.Close()
+		assert.Eventually(t, func() bool { return coldUsed(t, cold) == 5 }, time.Second, 5*time.Millisecond)
+	})
+}
+
+func TestStorage_SyncRetryDelay(t *testing.T) {
+	storage, err := New(Config{
+		Hot: memory.New(), Cold: memory.New(),
+		SyncRetryBackoff: time.Second, SyncMaxRetryBackoff: 5 * time.Second,
+	})
+	require.NoError(t, err)
+	defer storage.Close()
+
+	assert.Equal(t, time.Second, storage.syncRetryDelay(1))
+	assert.Equal(t, 2*time.Second, storage.syncRetryDelay(2))
+	assert.Equal(t, 4*time.Second, storage.syncRetryDelay(3))
+	assert.Equal(t, 5*time.Second, storage.syncRetryDelay(4))
+	assert.Equal(t, 5*time.Second, storage.syncRetryDelay(100))
+}
@@ -0,0 +1,557 @@
+package tiered
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mihaimyh/goquota/pkg/goquota"
+)
+
+const (
+	defaultReconcileBatchSize  = 500
+	defaultReconcileSettleTime = time.Minute
+)
+
+// RepairPolicy decides which value wins when Hot and Cold disagree
+type RepairPolicy string
+
+const (
+	// RepairColdWins keeps the Cold value (the source of truth)
+	RepairColdWins RepairPolicy = "cold"
+	// RepairHotWins keeps the Hot value
+	RepairHotWins RepairPolicy = "hot"
+	// RepairMaxWins keeps the larger value (usage and limits only)
+	RepairMaxWins RepairPolicy = "max"
+)
+
+// Fields reported in a Drift
+const (
+	DriftFieldUsed              = "used"
+	DriftFieldLimit             = "limit"
+	DriftFieldTier              = "tier"
+	DriftFieldSubscriptionStart = "subscription_start"
+	DriftFieldExpiresAt         = "expires_at"
+	// DriftFieldMissingInCold is reported for records that only exist in Hot
+	DriftFieldMissingInCold = "missing_in_cold"
+)
+
+// Outcomes of detected drift, recorded in metrics
+const (
+	driftReported     = "reported"
+	driftRepaired     = "repaired"
+	driftRepairFailed = "repair_failed"
+)
+
+// ReconcileConfig configures the detection and repair of drift between Hot and Cold.
+// Cold must implement goquota.RecordScanner. Records only in Hot are found if Hot implements it too;
+// records only in Cold are not drift, they are read through on the next access.
+type ReconcileConfig struct {
+	// Interval runs Reconcile in the background (0: on demand only)
+	Interval time.Duration
+
+	// BatchSize is the number of records read per scan page (default: 500)
+	BatchSize int
+
+	// Repair writes the winning values to the store that drifted. Otherwise drift is only reported.
+	Repair bool
+
+	// UsagePolicy decides the used amount of drifted usage (default: RepairMaxWins, so that
+	// consumptions recorded by only one store are never forgiven)
+	UsagePolicy RepairPolicy
+
+	// LimitPolicy decides the limit of drifted usage (default: RepairColdWins)
+	LimitPolicy RepairPolicy
+
+	// EntitlementPolicy decides drifted entitlements (default: RepairColdWins). RepairMaxWins is not allowed.
+	EntitlementPolicy RepairPolicy
+
+	// SettleTime skips records updated in either store within it, whose sync may still be in flight.
+	// Usage with a pending write in the Outbox is always skipped. Default: 1 minute
+	SettleTime time.Duration
+
+	// OnReport is called with the report of each background run (optional)
+	OnReport func(report *DriftReport)
+}
+
+// Drift is a record on which Hot and Cold disagree
+type Drift struct {
+	UserID string `json:"user_id"`
+
+	// Resource and Period are set for usage drift
+	Resource string          `json:"resource,omitempty"`
+	Period   *goquota.Period `json:"period,omitempty"`
+
+	// Fields are the fields that differ (see the DriftField constants)
+	Fields []string `json:"fields"`
+
+	// HotUsage and ColdUsage are the usage records as read, for usage drift
+	HotUsage  *goquota.Usage `json:"hot_usage,omitempty"`
+	ColdUsage *goquota.Usage `json:"cold_usage,omitempty"`
+
+	// HotEntitlement and ColdEntitlement are the entitlements as read, for entitlement drift
+	HotEntitlement  *goquota.Entitlement `json:"hot_entitlement,omitempty"`
+	ColdEntitlement *goquota.Entitlement `json:"cold_entitlement,omitempty"`
+
+	// Repaired is set once both stores hold the winning values
+	Repaired bool `json:"repaired"`
+
+	// RepairError is the error of a failed repair
+	RepairError string `json:"repair_error,omitempty"`
+}
+
+// DriftReport is the result of a Reconcile run
+type DriftReport struct {
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+
+	// EntitlementsChecked and UsageChecked count the records compared in both stores
+	EntitlementsChecked int `json:"entitlements_checked"`
+	UsageChecked        int `json:"usage_checked"`
+
+	// Skipped counts the records left alone because they may still be syncing
+	Skipped int `json:"skipped"`
+
+	Drifts []*Drift `json:"drifts"`
+}
+
+// Repaired returns the number of drifts repaired
+func (r *DriftReport) Repaired() int {
+	repaired := 0
+	for _, drift := range r.Drifts {
+		if drift.Repaired {
+			repaired++
+		}
+	}
+	return repaired
+}
+
+func applyReconcileDefaults(config *ReconcileConfig) {
+	if config.BatchSize <= 0 {
+		config.BatchSize = defaultReconcileBatchSize
+	}
+	if config.UsagePolicy == "" {
+		config.UsagePolicy = RepairMaxWins
+	}
+	if config.LimitPolicy == "" {
+		config.LimitPolicy = RepairColdWins
+	}
+	if config.EntitlementPolicy == "" {
+		config.EntitlementPolicy = RepairColdWins
+	}
+	if config.SettleTime <= 0 {
+		config.SettleTime = defaultReconcileSettleTime
+	}
+}
+
+func validateReconcileConfig(config *ReconcileConfig) error {
+	for _, policy := range []RepairPolicy{config.UsagePolicy, config.LimitPolicy} {
+		switch policy {
+		case RepairColdWins, RepairHotWins, RepairMaxWins:
+		default:
+			return fmt.Errorf("tiered storage: invalid usage repair policy %q", policy)
+		}
+	}
+	switch config.EntitlementPolicy {
+	case RepairColdWins, RepairHotWins:
+	default:
+		return fmt.Errorf("tiered storage: invalid entitlement repair policy %q", config.EntitlementPolicy)
+	}
+	if config.Interval < 0 {
+		return errors.New("tiered storage: reconcile interval cannot be negative")
+	}
+	return nil
+}
+
+// reconciler runs Reconcile in the background
+type reconciler struct {
+	cancel    context.CancelFunc
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func (s *Storage) startReconciler() {
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &reconciler{cancel: cancel, done: make(chan struct{})}
+	s.reconciler = r
+
+	go func() {
+		defer close(r.done)
+		ticker := time.NewTicker(s.conf.Reconcile.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			report, err := s.Reconcile(ctx)
+			if err != nil {
+				if ctx.Err() == nil {
+					s.reportSyncError(fmt.Errorf("tiered storage: reconcile failed: %w", err))
+				}
+				continue
+			}
+			if s.conf.Reconcile.OnReport != nil {
+				s.conf.Reconcile.OnReport(report)
+			}
+		}
+	}()
+}
+
+// close stops the background runs, aborting one in progress
+func (r *reconciler) close() {
+	r.closeOnce.Do(r.cancel)
+	<-r.done
+}
+
+// reconcileRun holds the state of a Reconcile run
+type reconcileRun struct {
+	s        *Storage
+	config   *ReconcileConfig
+	hot      goquota.RecordScanner // nil if Hot can't be scanned
+	cold     goquota.RecordScanner
+	inFlight map[usageDeltaKey]bool
+	now      time.Time
+	report   *DriftReport
+}
+
+// Reconcile compares the entitlements and usage of Hot and Cold, reports the differences
+// and repairs them if ReconcileConfig.Repair is set (see ReconcileConfig for the policies).
+// Cold must implement goquota.RecordScanner. Without Config.Reconcile, drift is only reported.
+//
+// Repairs overwrite whole records, so a consumption landing between the read and the
+// repair of a record can be lost; SettleTime keeps busy records out of the way.
+func (s *Storage) Reconcile(ctx context.Context) (*DriftReport, error) {
+	config := ReconcileConfig{}
+	if s.conf.Reconcile != nil {
+		config = *s.conf.Reconcile
+	}
+	applyReconcileDefaults(&config)
+
+	cold, ok := s.cold.(goquota.RecordScanner)
+	if !ok {
+		return nil, errors.New("tiered storage: Reconcile requires cold storage that implements RecordScanner")
+	}
+	// Without it, records only in Hot are not found
+	hot, _ := s.hot.(goquota.RecordScanner)
+
+	// Coalesced usage is known to be missing from Cold: write it first
+	if err := s.Flush(ctx); err != nil {
+		return nil, fmt.Errorf("tiered storage: failed to flush coalesced usage: %w", err)
+	}
+	inFlight, err := s.pendingSyncs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	run := &reconcileRun{
+		s:        s,
+		config:   &config,
+		hot:      hot,
+		cold:     cold,
+		inFlight: inFlight,
+		now:      time.Now().UTC(),
+		report:   &DriftReport{StartedAt: time.Now().UTC()},
+	}
+	if err := run.usage(ctx); err != nil {
+		return nil, err
+	}
+	if err := run.entitlements(ctx); err != nil {
+		return nil, err
+	}
+	run.report.FinishedAt = time.Now().UTC()
+	return run.report, nil
+}
+
+// pendingSyncs returns the usage records with Cold writes waiting in the Outbox
+func (s *Storage) pendingSyncs(ctx context.Context) (map[usageDeltaKey]bool, error) {
+	pending := make(map[usageDeltaKey]bool)
+	if s.conf.Outbox == nil {
+		return pending, nil
+	}
+	entries, err := s.conf.Outbox.Entries(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("tiered storage: failed to read sync outbox: %w", err)
+	}
+	for _, entry := range entries {
+		pending[deltaKey(entry.Request.UserID, entry.Request.Resource, entry.Request.Period)] = true
+	}
+	return pending, nil
+}
+
+// settling reports whether a record was updated too recently to be compared
+func (r *reconcileRun) settling(updatedAt ...time.Time) bool {
+	for _, t := range updatedAt {
+		if r.now.Sub(t) < r.config.SettleTime {
+			return true
+		}
+	}
+	return false
+}
+
+// usage compares the usage records of Cold with Hot, then looks for records only in Hot
+func (r *reconcileRun) usage(ctx context.Context) error {
+	err := scanAll(ctx, r.cold.ScanUsage, r.config.BatchSize, func(page []*goquota.Usage) error {
+		hotUsages, err := getUsages(ctx, r.s.hot, usageQueries(page))
+		if err != nil {
+			return err
+		}
+		for i, cold := range page {
+			if hot := hotUsages[i]; hot != nil {
+				r.compareUsage(ctx, hot, cold)
+			}
+		}
+		return nil
+	})
+	if err != nil || r.hot == nil {
+		return err
+	}
+
+	return scanAll(ctx, r.hot.ScanUsage, r.config.BatchSize, func(page []*goquota.Usage) error {
+		coldUsages, err := getUsages(ctx, r.s.cold, usageQueries(page))
+		if err != nil {
+			return err
+		}
+		for i, hot := range page {
+			if coldUsages[i] == nil {
+				r.compareUsage(ctx, hot, nil)
+			}
+		}
+		return nil
+	})
+}
+
+func usageQueries(usages []*goquota.Usage) []goquota.UsageQuery {
+	queries := make([]goquota.UsageQuery, len(usages))
+	for i, usage := range usages {
+		queries[i] = goquota.UsageQuery{UserID: usage.UserID, Resource: usage.Resource, Period: usage.Period}
+	}
+	return queries
+}
+
+// compareUsage reports and repairs the drift of a usage record. cold is nil if it only exists in Hot.
+func (r *reconcileRun) compareUsage(ctx context.Context, hot, cold *goquota.Usage) {
+	r.report.UsageChecked++
+	key := deltaKey(hot.UserID, hot.Resource, hot.Period)
+	if r.inFlight[key] || r.settling(hot.UpdatedAt) || (cold != nil && r.settling(cold.UpdatedAt)) {
+		r.report.Skipped++
+		return
+	}
+
+	var fields []string
+	switch {
+	case cold == nil:
+		fields = []string{DriftFieldMissingInCold}
+	default:
+		if hot.Used != cold.Used {
+			fields = append(fields, DriftFieldUsed)
+		}
+		if hot.Limit != cold.Limit {
+			fields = append(fields, DriftFieldLimit)
+		}
+	}
+	if len(fields) == 0 {
+		return
+	}
+
+	period := hot.Period
+	drift := &Drift{
+		UserID:    hot.UserID,
+		Resource:  hot.Resource,
+		Period:    &period,
+		Fields:    fields,
+		HotUsage:  hot,
+		ColdUsage: cold,
+	}
+	r.report.Drifts = append(r.report.Drifts, drift)
+	if r.config.Repair {
+		r.repair(drift, r.repairUsage(ctx, hot, cold))
+	} else {
+		r.recordDrift(drift, driftReported)
+	}
+}
+
+// repairUsage writes the winning used amount and limit to the store(s) that differ from them
+func (r *reconcileRun) repairUsage(ctx context.Context, hot, cold *goquota.Usage) error {
+	if cold == nil {
+		// Records only in Hot can't be removed: copy them to Cold unless Cold wins
+		if r.config.UsagePolicy == RepairColdWins {
+			return errUnrepairable
+		}
+		target := *hot
+		return r.s.cold.SetUsage(ctx, hot.UserID, hot.Resource, &target, hot.Period)
+	}
+
+	target := *cold
+	target.Used = resolve(r.config.UsagePolicy, hot.Used, cold.Used)
+	target.Limit = resolve(r.config.LimitPolicy, hot.Limit, cold.Limit)
+	target.UpdatedAt = time.Now().UTC()
+	if cold.Used != target.Used || cold.Limit != target.Limit {
+		if err := r.s.cold.SetUsage(ctx, cold.UserID, cold.Resource, &target, cold.Period); err != nil {
+			return err
+		}
+	}
+	if hot.Used != target.Used || hot.Limit != target.Limit {
+		hotTarget := target
+		return r.s.hot.SetUsage(ctx, hot.UserID, hot.Resource, &hotTarget, hot.Period)
+	}
+	return nil
+}
+
+// errUnrepairable marks drift that the configured policies cannot repair
+var errUnrepairable = errors.New("not repairable with the configured policy")
+
+func resolve(policy RepairPolicy, hot, cold int) int {
+	switch policy {
+	case RepairHotWins:
+		return hot
+	case RepairMaxWins:
+		return max(hot, cold)
+	default:
+		return cold
+	}
+}
+
+// entitlements compares the entitlements of Cold with Hot, then looks for entitlements only in Hot
+func (r *reconcileRun) entitlements(ctx context.Context) error {
+	err := scanAll(ctx, r.cold.ScanEntitlements, r.config.BatchSize, func(page []*goquota.Entitlement) error {
+		hotEnts, err := getEntitlements(ctx, r.s.hot, entitlementUserIDs(page))
+		if err != nil {
+			return err
+		}
+		for _, cold := range page {
+			if hot, ok := hotEnts[cold.UserID]; ok {
+				r.compareEntitlement(ctx, hot, cold)
+			}
+		}
+		return nil
+	})
+	if err != nil || r.hot == nil {
+		return err
+	}
+
+	return scanAll(ctx, r.hot.ScanEntitlements, r.config.BatchSize, func(page []*goquota.Entitlement) error {
+		coldEnts, err := getEntitlements(ctx, r.s.cold, entitlementUserIDs(page))
+		if err != nil {
+			return err
+		}
+		for _, hot := range page {
+			if _, ok := coldEnts[hot.UserID]; !ok {
+				r.compareEntitlement(ctx, hot, nil)
+			}
+		}
+		return nil
+	})
+}
+
+func entitlementUserIDs(ents []*goquota.Entitlement) []string {
+	userIDs := make([]string, len(ents))
+	for i, ent := range ents {
+		userIDs[i] = ent.UserID
+	}
+	return userIDs
+}
+
+// compareEntitlement reports and repairs the drift of an entitlement. cold is nil if it only exists in Hot.
+func (r *reconcileRun) compareEntitlement(ctx context.Context, hot, cold *goquota.Entitlement) {
+	r.report.EntitlementsChecked++
+	if r.settling(hot.UpdatedAt) || (cold != nil && r.settling(cold.UpdatedAt)) {
+		r.report.Skipped++
+		return
+	}
+
+	var fields []string
+	switch {
+	case cold == nil:
+		fields = []string{DriftFieldMissingInCold}
+	default:
+		if hot.Tier != cold.Tier {
+			fields = append(fields, DriftFieldTier)
+		}
+		if !hot.SubscriptionStartDate.Equal(cold.SubscriptionStartDate) {
+			fields = append(fields, DriftFieldSubscriptionStart)
+		}
+		if !sameTime(hot.ExpiresAt, cold.ExpiresAt) {
+			fields = append(fields, DriftFieldExpiresAt)
+		}
+	}
+	if len(fields) == 0 {
+		return
+	}
+
+	drift := &Drift{UserID: hot.UserID, Fields: fields, HotEntitlement: hot, ColdEntitlement: cold}
+	r.report.Drifts = append(r.report.Drifts, drift)
+	if !r.config.Repair {
+		r.recordDrift(drift, driftReported)
+		return
+	}
+
+	var err error
+	switch {
+	case r.config.EntitlementPolicy == RepairHotWins:
+		err = r.s.cold.SetEntitlement(ctx, hot)
+	case cold == nil:
+		err = errUnrepairable
+	default:
+		err = r.s.hot.SetEntitlement(ctx, cold)
+	}
+	r.repair(drift, err)
+}
+
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+// repair records the outcome of repairing a drift
+func (r *reconcileRun) repair(drift *Drift, err error) {
+	switch {
+	case errors.Is(err, errUnrepairable):
+		r.recordDrift(drift, driftReported)
+	case err != nil:
+		drift.RepairError = err.Error()
+		r.recordDrift(drift, driftRepairFailed)
+	default:
+		drift.Repaired = true
+		r.recordDrift(drift, driftRepaired)
+	}
+}
+
+func (r *reconcileRun) recordDrift(drift *Drift, outcome string) {
+	for _, field := range drift.Fields {
+		r.s.conf.Metrics.RecordSyncDrift(field, outcome)
+	}
+}
+
+// scanAll calls fn with every page of a scan
+func scanAll[T any](
+	ctx context.Context,
+	scan func(ctx context.Context, cursor string, limit int) ([]T, string, error),
+	batchSize int,
+	fn func(page []T) error,
+) error {
+	cursor := ""
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		page, next, err := scan(ctx, cursor, batchSize)
+		if err != nil {
+			return err
+		}
+		if len(page) > 0 {
+			if err := fn(page); err != nil {
+				return err
+			}
+		}
+		if next == "" {
+			return nil
+		}
+		cursor = next
+	}
+}
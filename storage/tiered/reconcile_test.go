@@ -0,0 +1,239 @@
+package tiered
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mihaimyh/goquota/pkg/goquota"
+	"github.com/mihaimyh/goquota/storage/memory"
+)
+
+func TestStorage_Reconcile(t *testing.T) {
+	ctx := context.Background()
+	period := goquota.Period{
+		Start: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		End:   time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+		Type:  goquota.PeriodTypeMonthly,
+	}
+	settled := time.Now().UTC().Add(-time.Hour)
+	setUsage := func(store goquota.Storage, userID string, used, limit int, updatedAt time.Time) {
+		require.NoError(t, store.SetUsage(ctx, userID, "api_calls", &goquota.Usage{
+			UserID: userID, Resource: "api_calls", Used: used, Limit: limit,
+			Period: period, Tier: "pro", UpdatedAt: updatedAt,
+		}, period))
+	}
+	getUsage := func(store goquota.Storage, userID string) *goquota.Usage {
+		usage, err := store.GetUsage(ctx, userID, "api_calls", period)
+		require.NoError(t, err)
+		return usage
+	}
+
+	t.Run("requires record scanner", func(t *testing.T) {
+		storage, err := New(Config{
+			Hot: memory.New(), Cold: coldWithoutDeltas{memory.New()}, Reconcile: &ReconcileConfig{},
+		})
+		assert.Error(t, err)
+		assert.Nil(t, storage)
+		assert.Contains(t, err.Error(), "RecordScanner")
+
+		storage, err = New(Config{Hot: memory.New(), Cold: coldWithoutDeltas{memory.New()}})
+		require.NoError(t, err)
+		_, err = storage.Reconcile(ctx)
+		assert.ErrorContains(t, err, "RecordScanner")
+	})
+
+	t.Run("rejects invalid policies", func(t *testing.T) {
+		_, err := New(Config{
+			Hot: memory.New(), Cold: memory.New(), Reconcile: &ReconcileConfig{EntitlementPolicy: RepairMaxWins},
+		})
+		assert.ErrorContains(t, err, "entitlement repair policy")
+
+		_, err = New(Config{
+			Hot: memory.New(), Cold: memory.New(), Reconcile: &ReconcileConfig{UsagePolicy: "newest"},
+		})
+		assert.ErrorContains(t, err, "usage repair policy")
+	})
+
+	t.Run("reports drift without repair", func(t *testing.T) {
+		hot, cold := memory.New(), memory.New()
+		storage, err := New(Config{Hot: hot, Cold: cold})
+		require.NoError(t, err)
+
+		setUsage(hot, "user1", 12, 100, settled)
+		setUsage(cold, "user1", 10, 200, settled)
+		setUsage(hot, "user2", 5, 100, settled)
+		setUsage(cold, "user2", 5, 100, settled)
+		// Only in Cold: not cached yet, not drift
+		setUsage(cold, "user3", 1, 100, settled)
+
+		report, err := storage.Reconcile(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 2, report.UsageChecked)
+		require.Len(t, report.Drifts, 1)
+		assert.Equal(t, "user1", report.Drifts[0].UserID)
+		assert.Equal(t, []string{DriftFieldUsed, DriftFieldLimit}, report.Drifts[0].Fields)
+		assert.Equal(t, 0, report.Repaired())
+
+		assert.Equal(t, 12, getUsage(hot, "user1").Used)
+		assert.Equal(t, 10, getUsage(cold, "user1").Used)
+	})
+
+	t.Run("repairs usage with max and limits with cold", func(t *testing.T) {
+		hot, cold := memory.New(), memory.New()
+		storage, err := New(Config{Hot: hot, Cold: cold, Reconcile: &ReconcileConfig{Repair: true}})
+		require.NoError(t, err)
+
+		setUsage(hot, "user1", 12, 100, settled)
+		setUsage(cold, "user1", 10, 200, settled)
+
+		report, err := storage.Reconcile(ctx)
+		require.NoError(t, err)
+		require.Len(t, report.Drifts, 1)
+		assert.True(t, report.Drifts[0].Repaired)
+		assert.Equal(t, 1, report.Repaired())
+
+		for _, store := range []goquota.Storage{hot, cold} {
+			usage := getUsage(store, "user1")
+			assert.Equal(t, 12, usage.Used)
+			assert.Equal(t, 200, usage.Limit)
+		}
+	})
+
+	t.Run("repairs usage with hot wins", func(t *testing.T) {
+		hot, cold := memory.New(), memory.New()
+		storage, err := New(Config{Hot: hot, Cold: cold, Reconcile: &ReconcileConfig{
+			Repair: true, UsagePolicy: RepairHotWins, LimitPolicy: RepairHotWins,
+		}})
+		require.NoError(t, err)
+
+		setUsage(hot, "user1", 8, 100, settled)
+		setUsage(cold, "user1", 10, 200, settled)
+
+		_, err = storage.Reconcile(ctx)
+		require.NoError(t, err)
+		usage := getUsage(cold, "user1")
+		assert.Equal(t, 8, usage.Used)
+		assert.Equal(t, 100, usage.Limit)
+	})
+
+	t.Run("copies usage missing in cold", func(t *testing.T) {
+		hot, cold := memory.New(), memory.New()
+		storage, err := New(Config{Hot: hot, Cold: cold, Reconcile: &ReconcileConfig{Repair: true}})
+		require.NoError(t, err)
+
+		setUsage(hot, "user1", 7, 100, settled)
+
+		report, err := storage.Reconcile(ctx)
+		require.NoError(t, err)
+		require.Len(t, report.Drifts, 1)
+		assert.Equal(t, []string{DriftFieldMissingInCold}, report.Drifts[0].Fields)
+		assert.True(t, report.Drifts[0].Repaired)
+		assert.Equal(t, 7, getUsage(cold, "user1").Used)
+	})
+
+	t.Run("skips settling records", func(t *testing.T) {
+		hot, cold := memory.New(), memory.New()
+		storage, err := New(Config{Hot: hot, Cold: cold, Reconcile: &ReconcileConfig{Repair: true}})
+		require.NoError(t, err)
+
+		setUsage(hot, "user1", 12, 100, time.Now().UTC())
+		setUsage(cold, "user1", 10, 100, settled)
+
+		report, err := storage.Reconcile(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, report.Skipped)
+		assert.Empty(t, report.Drifts)
+		assert.Equal(t, 10, getUsage(cold, "user1").Used)
+	})
+
+	t.Run("skips usage pending in the outbox", func(t *testing.T) {
+		hot := memory.New()
+		cold := &flakyColdStorage{Storage: memory.New()}
+		cold.setErr(goquota.ErrStorageUnavailable)
+		outbox, err := NewFileSyncOutbox(t.TempDir() + "/outbox.log")
+		require.NoError(t, err)
+		defer outbox.Close()
+		storage, err := New(Config{
+			Hot: hot, Cold: cold, AsyncUsageSync: true, Outbox: outbox, SyncRetryBackoff: time.Hour,
+			Reconcile: &ReconcileConfig{Repair: true, SettleTime: time.Nanosecond},
+		})
+		require.NoError(t, err)
+		defer storage.Close()
+
+		setUsage(hot, "user1", 10, 100, settled)
+		setUsage(cold.Storage, "user1", 10, 100, settled)
+		_, err = storage.ConsumeQuota(ctx, &goquota.ConsumeRequest{
+			UserID: "user1", Resource: "api_calls", Amount: 1, Tier: "pro", Period: period, Limit: 100,
+		})
+		require.NoError(t, err)
+		assert.Eventually(t, func() bool {
+			entries, err := outbox.Entries(ctx)
+			return err == nil && len(entries) == 1 && entries[0].Attempts > 0
+		}, time.Second, 5*time.Millisecond)
+
+		report, err := storage.Reconcile(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, report.Skipped)
+		assert.Empty(t, report.Drifts)
+	})
+
+	t.Run("repairs entitlements", func(t *testing.T) {
+		hot, cold := memory.New(), memory.New()
+		storage, err := New(Config{Hot: hot, Cold: cold, Reconcile: &ReconcileConfig{Repair: true}})
+		require.NoError(t, err)
+
+		start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		require.NoError(t, hot.SetEntitlement(ctx, &goquota.Entitlement{
+			UserID: "user1", Tier: "free", SubscriptionStartDate: start, UpdatedAt: settled,
+		}))
+		require.NoError(t, cold.SetEntitlement(ctx, &goquota.Entitlement{
+			UserID: "user1", Tier: "pro", SubscriptionStartDate: start, UpdatedAt: settled,
+		}))
+		// Only in Hot: Cold wins, so it is reported but not copied
+		require.NoError(t, hot.SetEntitlement(ctx, &goquota.Entitlement{
+			UserID: "user2", Tier: "pro", SubscriptionStartDate: start, UpdatedAt: settled,
+		}))
+
+		report, err := storage.Reconcile(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 2, report.EntitlementsChecked)
+		require.Len(t, report.Drifts, 2)
+		assert.Equal(t, []string{DriftFieldTier}, report.Drifts[0].Fields)
+		assert.True(t, report.Drifts[0].Repaired)
+		assert.Equal(t, []string{DriftFieldMissingInCold}, report.Drifts[1].Fields)
+		assert.False(t, report.Drifts[1].Repaired)
+
+		ent, err := hot.GetEntitlement(ctx, "user1")
+		require.NoError(t, err)
+		assert.Equal(t, "pro", ent.Tier)
+		_, err = cold.GetEntitlement(ctx, "user2")
+		assert.ErrorIs(t, err, goquota.ErrEntitlementNotFound)
+	})
+
+	t.Run("runs in the background", func(t *testing.T) {
+		hot, cold := memory.New(), memory.New()
+		reports := make(chan *DriftReport, 10)
+		storage, err := New(Config{Hot: hot, Cold: cold, Reconcile: &ReconcileConfig{
+			Interval: 10 * time.Millisecond,
+			Repair:   true,
+			OnReport: func(report *DriftReport) { reports <- report },
+		}})
+		require.NoError(t, err)
+
+		setUsage(hot, "user1", 12, 100, settled)
+		setUsage(cold, "user1", 10, 100, settled)
+
+		select {
+		case report := <-reports:
+			assert.Equal(t, 1, report.Repaired())
+		case <-time.After(time.Second):
+			t.Fatal("no reconcile report")
+		}
+		require.NoError(t, storage.Close())
+		assert.Equal(t, 12, getUsage(cold, "user1").Used)
+	})
+}
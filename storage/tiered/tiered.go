@@ -10,9 +10,14 @@ import (
 	"sync"
 	"time"
 
+	"golang.org/x/sync/singleflight"
+
 	"github.com/mihaimyh/goquota/pkg/goquota"
 )
 
+// defaultColdReadTimeout bounds Cold reads shared between concurrent Hot misses
+const defaultColdReadTimeout = 10 * time.Second
+
 // Config configures the tiered storage behavior
 type Config struct {
 	// Hot is the L1 cache storage (e.g., Redis, Memory) for high-frequency operations
@@ -32,23 +37,83 @@ type Config struct {
 	// AsyncErrorHandler is called when an async operation fails.
 	// Essential for monitoring consistency drift.
 	AsyncErrorHandler func(error)
+
+	// CoalesceUsageSync aggregates consumptions without an idempotency key in memory and
+	// writes them to Cold as one delta per user, resource and period, instead of one
+	// ConsumeQuota per consumption. Cold must implement goquota.UsageDeltaStore.
+	// Consumptions with an idempotency key are still synced one by one.
+	// Pending deltas are lost if the process crashes; Close flushes them.
+	// Cannot be combined with Outbox, whose writes must never be lost.
+	CoalesceUsageSync bool
+
+	// FlushInterval is how often coalesced deltas are written to Cold.
+	// Default: 1 second
+	FlushInterval time.Duration
+
+	// FlushSize is the number of pending deltas that triggers a flush before FlushInterval.
+	// Default: 1000
+	FlushSize int
+
+	// Outbox durably queues the Cold writes of AsyncUsageSync (optional), e.g. NewFileSyncOutbox.
+	// Writes are then never dropped: they are retried with backoff until they succeed, or parked
+	// after SyncMaxAttempts or a permanent error (see ParkedSyncs). Delivery is at-least-once;
+	// consumptions without an idempotency key get one so that Cold applies each only once.
+	// Replaces SyncBufferSize. Requires AsyncUsageSync; cannot be combined with CoalesceUsageSync.
+	Outbox SyncOutbox
+
+	// SyncMaxAttempts is how many times a Cold write from the Outbox is attempted before it is parked.
+	// Default: 10
+	SyncMaxAttempts int
+
+	// SyncRetryBackoff is the delay before retrying a failed Cold write from the Outbox,
+	// doubled after each attempt up to SyncMaxRetryBackoff.
+	// Default: 1 second (max: 5 minutes)
+	SyncRetryBackoff    time.Duration
+	SyncMaxRetryBackoff time.Duration
+
+	// Metrics records the Outbox depth, lag and delivery outcomes, and detected drift (optional)
+	Metrics goquota.Metrics
+
+	// ColdReadTimeout bounds a Cold read shared between concurrent Hot misses of the same record.
+	// The read is not canceled with the caller that started it, as others may be waiting for it;
+	// each caller stops waiting when its own context is done.
+	// Default: 10 seconds
+	ColdReadTimeout time.Duration
+
+	// Reconcile configures drift detection and repair between Hot and Cold (optional, see Reconcile).
+	// Requires cold storage that implements goquota.RecordScanner.
+	Reconcile *ReconcileConfig
 }
 
 // Storage implements a Hot/Cold tiered storage architecture.
 // It orchestrates two storage backends with different strategies per operation type:
 // - Read-Through: Entitlements, Usage reads, Record retrieval (Hot → Cold)
 // - Write-Through: Entitlements, Usage writes, Tier changes, Limits, Refunds (Cold → Hot)
-// - Hot-Only: Rate limits (Hot only)
+// - Hot-Only: Rate limits, concurrency leases (Hot only)
+// - Cold-Only: Usage snapshots, usage history (Cold only)
 // - Hot-Primary/Async-Audit: Quota consumption (Hot atomic + async Cold sync)
 type Storage struct {
 	hot  goquota.Storage
 	cold goquota.Storage
 	conf Config
 
+	// Aggregated usage sync (CoalesceUsageSync only)
+	coalescer *usageCoalescer
+
 	// Channel for async synchronization
 	syncQueue chan func() error
 	shutdown  chan struct{}
 	wg        sync.WaitGroup
+
+	// Wakes the Outbox worker when a write is queued
+	outboxSignal chan struct{}
+
+	// Background drift reconciliation (Reconcile.Interval only)
+	reconciler *reconciler
+
+	// Share Cold reads between concurrent Hot misses on the same key
+	entitlementGroup singleflight.Group
+	usageGroup       singleflight.Group
 }
 
 // New creates a new tiered storage adapter.
@@ -60,24 +125,90 @@ func New(config Config) (*Storage, error) {
 	if config.SyncBufferSize <= 0 {
 		config.SyncBufferSize = 1000
 	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = defaultFlushInterval
+	}
+	if config.FlushSize <= 0 {
+		config.FlushSize = defaultFlushSize
+	}
+	if config.Outbox != nil && !config.AsyncUsageSync {
+		return nil, errors.New("tiered storage: Outbox requires AsyncUsageSync")
+	}
+	if config.Outbox != nil && config.CoalesceUsageSync {
+		// Coalesced deltas are held in memory, so they would bypass the Outbox's durability
+		return nil, errors.New("tiered storage: Outbox cannot be combined with CoalesceUsageSync")
+	}
+	if config.SyncMaxAttempts <= 0 {
+		config.SyncMaxAttempts = defaultSyncMaxAttempts
+	}
+	if config.SyncRetryBackoff <= 0 {
+		config.SyncRetryBackoff = defaultSyncRetryBackoff
+	}
+	if config.SyncMaxRetryBackoff <= 0 {
+		config.SyncMaxRetryBackoff = defaultSyncMaxRetryBackoff
+	}
+	if config.Metrics == nil {
+		config.Metrics = &goquota.NoopMetrics{}
+	}
+	if config.ColdReadTimeout <= 0 {
+		config.ColdReadTimeout = defaultColdReadTimeout
+	}
+
+	var deltaStore goquota.UsageDeltaStore
+	if config.CoalesceUsageSync {
+		store, ok := config.Cold.(goquota.UsageDeltaStore)
+		if !ok {
+			return nil, errors.New("tiered storage: CoalesceUsageSync requires cold storage that implements UsageDeltaStore")
+		}
+		deltaStore = store
+	}
+	if config.Reconcile != nil {
+		reconcile := *config.Reconcile
+		applyReconcileDefaults(&reconcile)
+		if err := validateReconcileConfig(&reconcile); err != nil {
+			return nil, err
+		}
+		if _, ok := config.Cold.(goquota.RecordScanner); !ok {
+			return nil, errors.New("tiered storage: Reconcile requires cold storage that implements RecordScanner")
+		}
+		config.Reconcile = &reconcile
+	}
 
 	s := &Storage{
-		hot:       config.Hot,
-		cold:      config.Cold,
-		conf:      config,
-		syncQueue: make(chan func() error, config.SyncBufferSize),
-		shutdown:  make(chan struct{}),
+		hot:          config.Hot,
+		cold:         config.Cold,
+		conf:         config,
+		syncQueue:    make(chan func() error, config.SyncBufferSize),
+		shutdown:     make(chan struct{}),
+		outboxSignal: make(chan struct{}, 1),
 	}
 
-	if config.AsyncUsageSync {
+	switch {
+	case config.Outbox != nil:
+		s.startOutboxWorker()
+	case config.AsyncUsageSync:
 		s.startWorker()
 	}
+	if deltaStore != nil {
+		s.coalescer = newUsageCoalescer(deltaStore, &s.conf)
+	}
+	if config.Reconcile != nil && config.Reconcile.Interval > 0 {
+		s.startReconciler()
+	}
 
 	return s, nil
 }
 
-// Close gracefully shuts down the async worker (if enabled).
+// Close gracefully shuts down the async worker (if enabled) and writes
+// pending coalesced usage to Cold.
 func (s *Storage) Close() error {
+	if s.reconciler != nil {
+		s.reconciler.close()
+	}
+	var err error
+	if s.coalescer != nil {
+		err = s.coalescer.close(context.Background())
+	}
 	if s.conf.AsyncUsageSync {
 		select {
 		case <-s.shutdown:
@@ -87,7 +218,15 @@ func (s *Storage) Close() error {
 			s.wg.Wait()
 		}
 	}
-	return nil
+	return err
+}
+
+// Flush writes pending coalesced usage to Cold (CoalesceUsageSync only).
+func (s *Storage) Flush(ctx context.Context) error {
+	if s.coalescer == nil {
+		return nil
+	}
+	return s.coalescer.flush(ctx)
 }
 
 // startWorker runs the background synchronization loop.
@@ -100,17 +239,16 @@ func (s *Storage) startWorker() {
 			select {
 			case job := <-s.syncQueue:
 				if err := job(); err != nil {
-					if s.conf.AsyncErrorHandler != nil {
-						s.conf.AsyncErrorHandler(fmt.Errorf("tiered sync failed: %w", err))
-					}
+					s.reportSyncError(fmt.Errorf("tiered sync failed: %w", err))
 				}
 			case <-s.shutdown:
-				// Drain queue on shutdown (best effort)
+				// Drain queue on shutdown (best effort, without retries)
 				for {
 					select {
 					case job := <-s.syncQueue:
-						// Best effort - ignore errors during shutdown
-						_ = job() //nolint:errcheck // Best effort during shutdown
+						if err := job(); err != nil {
+							s.reportSyncError(fmt.Errorf("tiered sync failed during shutdown: %w", err))
+						}
 					default:
 						return
 					}
@@ -130,16 +268,31 @@ func (s *Storage) GetEntitlement(ctx context.Context, userID string) (*goquota.E
 		return ent, nil
 	}
 
-	// 2. Try Cold (Source of Truth)
-	ent, err = s.cold.GetEntitlement(ctx, userID)
+	// 2. Try Cold (Source of Truth), sharing one query between concurrent readers of the same user
+	read := func(ctx context.Context) (interface{}, error) {
+		ent, err := s.cold.GetEntitlement(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+
+		// 3. Populate Hot (Read-Repair)
+		// We ignore errors here as it's just a cache fill
+		_ = s.hot.SetEntitlement(ctx, ent) //nolint:errcheck // Cache fill - errors are non-critical
+		return ent, nil
+	}
+	result, shared, err := s.sharedColdRead(ctx, &s.entitlementGroup, userID, read)
 	if err != nil {
 		return nil, err
 	}
-
-	// 3. Populate Hot (Read-Repair)
-	// We ignore errors here as it's just a cache fill
-	_ = s.hot.SetEntitlement(ctx, ent) //nolint:errcheck // Cache fill - errors are non-critical
-
+	ent, ok := result.(*goquota.Entitlement)
+	if !ok {
+		return nil, fmt.Errorf("tiered storage: unexpected type from entitlement fetch: %T", result)
+	}
+	if shared && ent != nil {
+		// Callers own the returned entitlement
+		entCopy := *ent
+		ent = &entCopy
+	}
 	return ent, nil
 }
 
@@ -155,18 +308,130 @@ func (s *Storage) GetUsage(
 		return usage, nil
 	}
 
+	// 2. Try Cold, sharing one query between concurrent readers of the same usage record
+	key := fmt.Sprintf("%s:%s:%s:%s", userID, resource, period.Type, period.Key())
+	result, shared, err := s.sharedColdRead(ctx, &s.usageGroup, key, func(ctx context.Context) (interface{}, error) {
+		usage, err := s.cold.GetUsage(ctx, userID, resource, period)
+		if err != nil {
+			return nil, err
+		}
+
+		// 3. Populate Hot
+		if usage != nil {
+			//nolint:errcheck // Cache fill - errors are non-critical
+			_ = s.hot.SetUsage(ctx, userID, resource, usage, period)
+		}
+		return usage, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	usage, ok := result.(*goquota.Usage)
+	if !ok {
+		return nil, fmt.Errorf("tiered storage: unexpected type from usage fetch: %T", result)
+	}
+	if shared && usage != nil {
+		// Callers own the returned usage
+		usageCopy := *usage
+		usage = &usageCopy
+	}
+	return usage, nil
+}
+
+// sharedColdRead runs read once for concurrent callers with the same key, detached from the
+// cancellation of the caller that starts it and bounded by ColdReadTimeout
+func (s *Storage) sharedColdRead(
+	ctx context.Context,
+	group *singleflight.Group,
+	key string,
+	read func(ctx context.Context) (interface{}, error),
+) (result interface{}, shared bool, err error) {
+	ch := group.DoChan(key, func() (interface{}, error) {
+		readCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), s.conf.ColdReadTimeout)
+		defer cancel()
+		return read(readCtx)
+	})
+	select {
+	case res := <-ch:
+		return res.Val, res.Shared, res.Err
+	case <-ctx.Done():
+		return nil, false, ctx.Err()
+	}
+}
+
+// GetEntitlements implements goquota.BatchStorage with read-through strategy.
+// Users missing from Hot are read from Cold in one batch and written back to Hot.
+func (s *Storage) GetEntitlements(ctx context.Context, userIDs []string) (map[string]*goquota.Entitlement, error) {
+	// 1. Try Hot
+	result, err := getEntitlements(ctx, s.hot, userIDs)
+	if err != nil {
+		result = make(map[string]*goquota.Entitlement, len(userIDs))
+	}
+	var misses []string
+	for _, userID := range userIDs {
+		if _, ok := result[userID]; !ok {
+			misses = append(misses, userID)
+		}
+	}
+	if len(misses) == 0 {
+		return result, nil
+	}
+
+	// 2. Try Cold (Source of Truth)
+	coldEnts, err := getEntitlements(ctx, s.cold, misses)
+	if err != nil {
+		return nil, err
+	}
+
+	// 3. Populate Hot (Read-Repair)
+	for userID, ent := range coldEnts {
+		_ = s.hot.SetEntitlement(ctx, ent) //nolint:errcheck // Cache fill - errors are non-critical
+		result[userID] = ent
+	}
+	return result, nil
+}
+
+// GetUsages implements goquota.BatchStorage with read-through strategy.
+// Usage missing from Hot is read from Cold in one batch and written back to Hot.
+func (s *Storage) GetUsages(ctx context.Context, queries []goquota.UsageQuery) ([]*goquota.Usage, error) {
+	// 1. Try Hot
+	result, err := getUsages(ctx, s.hot, queries)
+	if err != nil || len(result) != len(queries) {
+		result = make([]*goquota.Usage, len(queries))
+	}
+	var misses []goquota.UsageQuery
+	var missIndexes []int
+	for i, usage := range result {
+		if usage == nil {
+			misses = append(misses, queries[i])
+			missIndexes = append(missIndexes, i)
+		}
+	}
+	if len(misses) == 0 {
+		return result, nil
+	}
+
 	// 2. Try Cold
-	usage, err = s.cold.GetUsage(ctx, userID, resource, period)
+	coldUsages, err := getUsages(ctx, s.cold, misses)
 	if err != nil {
 		return nil, err
 	}
+	if len(coldUsages) != len(misses) {
+		return nil, fmt.Errorf("tiered storage: cold storage returned %d usage records for %d queries",
+			len(coldUsages), len(misses))
+	}
 
 	// 3. Populate Hot
-	if usage != nil {
-		_ = s.hot.SetUsage(ctx, userID, resource, usage, period) //nolint:errcheck // Cache fill - errors are non-critical
+	for i, usage := range coldUsages {
+		if usage == nil {
+			continue
+		}
+		q := misses[i]
+		//nolint:errcheck // Cache fill - errors are non-critical
+		_ = s.hot.SetUsage(ctx, q.UserID, q.Resource, usage, q.Period)
+		result[missIndexes[i]] = usage
 	}
-
-	return usage, nil
+	return result, nil
 }
 
 // GetRefundRecord implements goquota.Storage with read-through strategy.
@@ -217,6 +482,10 @@ func (s *Storage) SetUsage(
 	usage *goquota.Usage,
 	period goquota.Period,
 ) error {
+	// Coalesced consumptions not written yet are overwritten too
+	if s.coalescer != nil {
+		s.coalescer.discard(deltaKey(userID, resource, period))
+	}
 	// 1. Write Cold (Durability)
 	if err := s.cold.SetUsage(ctx, userID, resource, usage, period); err != nil {
 		return err
@@ -277,6 +546,12 @@ func (s *Storage) SubtractLimit(
 
 // RefundQuota implements goquota.Storage with write-through strategy.
 func (s *Storage) RefundQuota(ctx context.Context, req *goquota.RefundRequest) error {
+	// Cold must hold the consumptions being refunded, or the refund is clamped at zero
+	if s.coalescer != nil && !req.Period.Start.IsZero() {
+		if err := s.coalescer.flushKey(ctx, deltaKey(req.UserID, req.Resource, req.Period)); err != nil {
+			return fmt.Errorf("tiered storage: failed to flush coalesced usage: %w", err)
+		}
+	}
 	// 1. Write Cold (Financial record - durability first)
 	if err := s.cold.RefundQuota(ctx, req); err != nil {
 		return err
@@ -297,8 +572,17 @@ func (s *Storage) ConsumeQuota(ctx context.Context, req *goquota.ConsumeRequest)
 		return newUsed, err
 	}
 
+	// Coalesced mode: aggregated and written to Cold as one delta on the next flush
+	if s.coalescer != nil && req.IdempotencyKey == "" {
+		s.coalescer.add(req)
+		return newUsed, nil
+	}
+
 	// 2. Sync to Cold Store (Audit Trail)
-	if s.conf.AsyncUsageSync {
+	if s.conf.Outbox != nil {
+		// Durable: written to Cold by the Outbox worker, retried until it succeeds
+		s.enqueueSync(ctx, req)
+	} else if s.conf.AsyncUsageSync {
 		// Clone request to avoid race conditions if caller modifies it
 		reqClone := *req
 
@@ -310,9 +594,7 @@ func (s *Storage) ConsumeQuota(ctx context.Context, req *goquota.ConsumeRequest)
 			return err
 		}:
 		default:
-			if s.conf.AsyncErrorHandler != nil {
-				s.conf.AsyncErrorHandler(errors.New("tiered storage: sync queue full, dropping cold write"))
-			}
+			s.reportSyncError(errors.New("tiered storage: sync queue full, dropping cold write"))
 		}
 	} else {
 		// Synchronous fallback (safe mode)
@@ -345,6 +627,237 @@ func (s *Storage) RecordRateLimitRequest(ctx context.Context, req *goquota.RateL
 	return s.hot.RecordRateLimitRequest(ctx, req)
 }
 
+// AcquireLease implements goquota.ConcurrencyLimiter with hot-only strategy.
+// Leases are short-lived and reclaimed on expiry, so they never reach the Cold store.
+func (s *Storage) AcquireLease(ctx context.Context, req *goquota.LeaseRequest) (bool, int, error) {
+	limiter, ok := s.hot.(goquota.ConcurrencyLimiter)
+	if !ok {
+		return false, 0, errors.New("tiered storage: hot storage does not implement ConcurrencyLimiter")
+	}
+	return limiter.AcquireLease(ctx, req)
+}
+
+// RenewLease implements goquota.ConcurrencyLimiter with hot-only strategy.
+func (s *Storage) RenewLease(ctx context.Context, req *goquota.LeaseRequest) (bool, error) {
+	limiter, ok := s.hot.(goquota.ConcurrencyLimiter)
+	if !ok {
+		return false, errors.New("tiered storage: hot storage does not implement ConcurrencyLimiter")
+	}
+	return limiter.RenewLease(ctx, req)
+}
+
+// ReleaseLease implements goquota.ConcurrencyLimiter with hot-only strategy.
+func (s *Storage) ReleaseLease(ctx context.Context, userID, resource, leaseID string) error {
+	limiter, ok := s.hot.(goquota.ConcurrencyLimiter)
+	if !ok {
+		return errors.New("tiered storage: hot storage does not implement ConcurrencyLimiter")
+	}
+	return limiter.ReleaseLease(ctx, userID, resource, leaseID)
+}
+
+// GetNotifiedWarnings implements goquota.WarningStateStore with hot-only strategy.
+// Warning state is read on every consumption and only needed until the period ends.
+func (s *Storage) GetNotifiedWarnings(
+	ctx context.Context, userID, resource string, period goquota.Period,
+) ([]float64, error) {
+	store, ok := s.hot.(goquota.WarningStateStore)
+	if !ok {
+		return nil, errors.New("tiered storage: hot storage does not implement WarningStateStore")
+	}
+	return store.GetNotifiedWarnings(ctx, userID, resource, period)
+}
+
+// MarkWarningNotified implements goquota.WarningStateStore with hot-only strategy.
+func (s *Storage) MarkWarningNotified(
+	ctx context.Context, userID, resource string, period goquota.Period, threshold float64,
+) (bool, error) {
+	store, ok := s.hot.(goquota.WarningStateStore)
+	if !ok {
+		return false, errors.New("tiered storage: hot storage does not implement WarningStateStore")
+	}
+	return store.MarkWarningNotified(ctx, userID, resource, period, threshold)
+}
+
+// ClearNotifiedWarnings implements goquota.WarningStateStore with hot-only strategy.
+func (s *Storage) ClearNotifiedWarnings(
+	ctx context.Context, userID, resource string, period goquota.Period, thresholds []float64,
+) error {
+	store, ok := s.hot.(goquota.WarningStateStore)
+	if !ok {
+		return errors.New("tiered storage: hot storage does not implement WarningStateStore")
+	}
+	return store.ClearNotifiedWarnings(ctx, userID, resource, period, thresholds)
+}
+
+// --- Strategy: Cold-Only ---
+// Low-frequency history that belongs with the durable source of truth.
+
+// RecordUsageSnapshot implements goquota.UsageSnapshotStore with cold-only strategy.
+func (s *Storage) RecordUsageSnapshot(ctx context.Context, snapshot *goquota.UsageSnapshot) error {
+	store, ok := s.cold.(goquota.UsageSnapshotStore)
+	if !ok {
+		return errors.New("tiered storage: cold storage does not implement UsageSnapshotStore")
+	}
+	return store.RecordUsageSnapshot(ctx, snapshot)
+}
+
+// GetUsageSnapshots implements goquota.UsageSnapshotStore with cold-only strategy.
+func (s *Storage) GetUsageSnapshots(
+	ctx context.Context, userID, resource string, period goquota.Period,
+) ([]*goquota.UsageSnapshot, error) {
+	store, ok := s.cold.(goquota.UsageSnapshotStore)
+	if !ok {
+		return nil, errors.New("tiered storage: cold storage does not implement UsageSnapshotStore")
+	}
+	return store.GetUsageSnapshots(ctx, userID, resource, period)
+}
+
+// GetUsageHistory implements goquota.UsageHistoryStorage with cold-only strategy.
+// With AsyncUsageSync or CoalesceUsageSync the current period may lag behind the Hot store.
+func (s *Storage) GetUsageHistory(
+	ctx context.Context, query *goquota.UsageHistoryQuery,
+) ([]*goquota.Usage, error) {
+	history, ok := s.cold.(goquota.UsageHistoryStorage)
+	if !ok {
+		return nil, errors.New("tiered storage: cold storage does not implement UsageHistoryStorage")
+	}
+	return history.GetUsageHistory(ctx, query)
+}
+
+// webhookStore returns the Cold store as a WebhookStore. Pending deliveries are
+// durable state, so they live with the source of truth.
+func (s *Storage) webhookStore() (goquota.WebhookStore, error) {
+	store, ok := s.cold.(goquota.WebhookStore)
+	if !ok {
+		return nil, errors.New("tiered storage: cold storage does not implement WebhookStore")
+	}
+	return store, nil
+}
+
+// SaveWebhookDelivery implements goquota.WebhookStore with cold-only strategy.
+func (s *Storage) SaveWebhookDelivery(ctx context.Context, delivery *goquota.WebhookDelivery) error {
+	store, err := s.webhookStore()
+	if err != nil {
+		return err
+	}
+	return store.SaveWebhookDelivery(ctx, delivery)
+}
+
+// ClaimWebhookDeliveries implements goquota.WebhookStore with cold-only strategy.
+func (s *Storage) ClaimWebhookDeliveries(
+	ctx context.Context, now time.Time, limit int, lease time.Duration,
+) ([]*goquota.WebhookDelivery, error) {
+	store, err := s.webhookStore()
+	if err != nil {
+		return nil, err
+	}
+	return store.ClaimWebhookDeliveries(ctx, now, limit, lease)
+}
+
+// GetWebhookDelivery implements goquota.WebhookStore with cold-only strategy.
+func (s *Storage) GetWebhookDelivery(ctx context.Context, id string) (*goquota.WebhookDelivery, error) {
+	store, err := s.webhookStore()
+	if err != nil {
+		return nil, err
+	}
+	return store.GetWebhookDelivery(ctx, id)
+}
+
+// ListWebhookDeliveries implements goquota.WebhookStore with cold-only strategy.
+func (s *Storage) ListWebhookDeliveries(
+	ctx context.Context, status goquota.WebhookDeliveryStatus, limit, offset int,
+) ([]*goquota.WebhookDelivery, error) {
+	store, err := s.webhookStore()
+	if err != nil {
+		return nil, err
+	}
+	return store.ListWebhookDeliveries(ctx, status, limit, offset)
+}
+
+// DeleteWebhookDelivery implements goquota.WebhookStore with cold-only strategy.
+func (s *Storage) DeleteWebhookDelivery(ctx context.Context, id string) error {
+	store, err := s.webhookStore()
+	if err != nil {
+		return err
+	}
+	return store.DeleteWebhookDelivery(ctx, id)
+}
+
+// statementStore returns the Cold store as a StatementStore. Statements are
+// permanent billing records, so they live with the source of truth.
+func (s *Storage) statementStore() (goquota.StatementStore, error) {
+	store, ok := s.cold.(goquota.StatementStore)
+	if !ok {
+		return nil, errors.New("tiered storage: cold storage does not implement StatementStore")
+	}
+	return store, nil
+}
+
+// CreateStatement implements goquota.StatementStore with cold-only strategy.
+func (s *Storage) CreateStatement(ctx context.Context, statement *goquota.Statement) (bool, error) {
+	store, err := s.statementStore()
+	if err != nil {
+		return false, err
+	}
+	return store.CreateStatement(ctx, statement)
+}
+
+// GetStatements implements goquota.StatementStore with cold-only strategy.
+func (s *Storage) GetStatements(ctx context.Context, userID string, limit, offset int) ([]*goquota.Statement, error) {
+	store, err := s.statementStore()
+	if err != nil {
+		return nil, err
+	}
+	return store.GetStatements(ctx, userID, limit, offset)
+}
+
+// overrideStore returns the cold store as an OverrideStore
+func (s *Storage) overrideStore() (goquota.OverrideStore, error) {
+	store, ok := s.cold.(goquota.OverrideStore)
+	if !ok {
+		return nil, errors.New("tiered storage: cold storage does not implement OverrideStore")
+	}
+	return store, nil
+}
+
+// SaveOverride implements goquota.OverrideStore with cold-only strategy.
+func (s *Storage) SaveOverride(ctx context.Context, override *goquota.Override) error {
+	store, err := s.overrideStore()
+	if err != nil {
+		return err
+	}
+	return store.SaveOverride(ctx, override)
+}
+
+// DeleteOverride implements goquota.OverrideStore with cold-only strategy.
+func (s *Storage) DeleteOverride(ctx context.Context, resource, tier string) (bool, error) {
+	store, err := s.overrideStore()
+	if err != nil {
+		return false, err
+	}
+	return store.DeleteOverride(ctx, resource, tier)
+}
+
+// ListOverrides implements goquota.OverrideStore with cold-only strategy.
+func (s *Storage) ListOverrides(ctx context.Context) ([]*goquota.Override, error) {
+	store, err := s.overrideStore()
+	if err != nil {
+		return nil, err
+	}
+	return store.ListOverrides(ctx)
+}
+
+// IsRetryable implements goquota.RetryClassifier.
+// Errors are retryable if either store classifies them as retryable.
+func (s *Storage) IsRetryable(err error) bool {
+	for _, store := range []goquota.Storage{s.hot, s.cold} {
+		if classifier, ok := store.(goquota.RetryClassifier); ok && classifier.IsRetryable(err) {
+			return true
+		}
+	}
+	return goquota.IsTransientError(err)
+}
+
 // --- TimeSource Support ---
 
 // Now uses Hot store time for consistency (usually Redis TIME).
@@ -360,3 +873,43 @@ func (s *Storage) Now(ctx context.Context) (time.Time, error) {
 	}
 	return time.Now().UTC(), nil
 }
+
+// getEntitlements reads entitlements from a tier in one batch if it supports it,
+// otherwise one at a time. Users without an entitlement are omitted.
+func getEntitlements(
+	ctx context.Context, store goquota.Storage, userIDs []string,
+) (map[string]*goquota.Entitlement, error) {
+	if batch, ok := store.(goquota.BatchStorage); ok {
+		return batch.GetEntitlements(ctx, userIDs)
+	}
+	result := make(map[string]*goquota.Entitlement, len(userIDs))
+	for _, userID := range userIDs {
+		ent, err := store.GetEntitlement(ctx, userID)
+		if errors.Is(err, goquota.ErrEntitlementNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if ent != nil {
+			result[userID] = ent
+		}
+	}
+	return result, nil
+}
+
+// getUsages reads usage from a tier in one batch if it supports it, otherwise one at a time
+func getUsages(ctx context.Context, store goquota.Storage, queries []goquota.UsageQuery) ([]*goquota.Usage, error) {
+	if batch, ok := store.(goquota.BatchStorage); ok {
+		return batch.GetUsages(ctx, queries)
+	}
+	result := make([]*goquota.Usage, len(queries))
+	for i, q := range queries {
+		usage, err := store.GetUsage(ctx, q.UserID, q.Resource, q.Period)
+		if err != nil {
+			return nil, err
+		}
+		result[i] = usage
+	}
+	return result, nil
+}
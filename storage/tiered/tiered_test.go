@@ -2,7 +2,10 @@ package tiered
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -188,8 +191,176 @@ func TestStorage_GetUsage_ReadThrough(t *testing.T) {
 	})
 }
 
+func TestStorage_BatchReads_ReadThrough(t *testing.T) {
+	hot := memory.New()
+	cold := memory.New()
+	storage, _ := New(Config{Hot: hot, Cold: cold})
+	defer storage.Close()
+
+	ctx := context.Background()
+	period := goquota.Period{
+		Start: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		End:   time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+		Type:  goquota.PeriodTypeMonthly,
+	}
+	usage := func(userID string, used int) *goquota.Usage {
+		return &goquota.Usage{UserID: userID, Resource: "api_calls", Used: used, Limit: 100, Period: period}
+	}
+
+	// user1 is in Hot, user2 only in Cold
+	require.NoError(t, hot.SetEntitlement(ctx, &goquota.Entitlement{UserID: "user1", Tier: "pro"}))
+	require.NoError(t, cold.SetEntitlement(ctx, &goquota.Entitlement{UserID: "user2", Tier: "free"}))
+	require.NoError(t, hot.SetUsage(ctx, "user1", "api_calls", usage("user1", 10), period))
+	require.NoError(t, cold.SetUsage(ctx, "user2", "api_calls", usage("user2", 20), period))
+
+	ents, err := storage.GetEntitlements(ctx, []string{"user1", "user2", "user3"})
+	require.NoError(t, err)
+	assert.Len(t, ents, 2)
+	assert.Equal(t, "pro", ents["user1"].Tier)
+	assert.Equal(t, "free", ents["user2"].Tier)
+
+	usages, err := storage.GetUsages(ctx, []goquota.UsageQuery{
+		{UserID: "user1", Resource: "api_calls", Period: period},
+		{UserID: "user2", Resource: "api_calls", Period: period},
+		{UserID: "user3", Resource: "api_calls", Period: period},
+	})
+	require.NoError(t, err)
+	require.Len(t, usages, 3)
+	assert.Equal(t, 10, usages[0].Used)
+	assert.Equal(t, 20, usages[1].Used)
+	assert.Nil(t, usages[2])
+
+	// Cold hits are written back to Hot
+	hotEnt, err := hot.GetEntitlement(ctx, "user2")
+	assert.NoError(t, err)
+	assert.Equal(t, "free", hotEnt.Tier)
+	hotUsage, err := hot.GetUsage(ctx, "user2", "api_calls", period)
+	assert.NoError(t, err)
+	require.NotNil(t, hotUsage)
+	assert.Equal(t, 20, hotUsage.Used)
+}
+
 // --- Write-Through Strategy Tests ---
 
+// slowColdStorage counts reads and holds them until release is closed
+type slowColdStorage struct {
+	*memory.Storage
+	release chan struct{}
+	reads   atomic.Int32
+}
+
+func (s *slowColdStorage) GetEntitlement(ctx context.Context, userID string) (*goquota.Entitlement, error) {
+	if err := s.wait(ctx); err != nil {
+		return nil, err
+	}
+	return s.Storage.GetEntitlement(ctx, userID)
+}
+
+func (s *slowColdStorage) GetUsage(
+	ctx context.Context, userID, resource string, period goquota.Period,
+) (*goquota.Usage, error) {
+	if err := s.wait(ctx); err != nil {
+		return nil, err
+	}
+	return s.Storage.GetUsage(ctx, userID, resource, period)
+}
+
+// wait blocks a read until release is closed or ctx is done
+func (s *slowColdStorage) wait(ctx context.Context) error {
+	s.reads.Add(1)
+	select {
+	case <-s.release:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func TestStorage_ReadThrough_CoalescesColdMisses(t *testing.T) {
+	ctx := context.Background()
+	period := goquota.Period{
+		Start: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		End:   time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+		Type:  goquota.PeriodTypeMonthly,
+	}
+	cold := &slowColdStorage{Storage: memory.New(), release: make(chan struct{})}
+	require.NoError(t, cold.SetEntitlement(ctx, &goquota.Entitlement{UserID: "user1", Tier: "pro"}))
+	require.NoError(t, cold.SetUsage(ctx, "user1", "api_calls", &goquota.Usage{
+		UserID: "user1", Resource: "api_calls", Used: 50, Limit: 100, Period: period, Tier: "pro",
+	}, period))
+
+	storage, err := New(Config{Hot: memory.New(), Cold: cold})
+	require.NoError(t, err)
+	defer storage.Close()
+
+	const readers = 20
+	var wg sync.WaitGroup
+	usages := make([]*goquota.Usage, readers)
+	ents := make([]*goquota.Entitlement, readers)
+	for i := 0; i < readers; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			usages[i], _ = storage.GetUsage(ctx, "user1", "api_calls", period) //nolint:errcheck // checked below
+		}(i)
+		go func(i int) {
+			defer wg.Done()
+			ents[i], _ = storage.GetEntitlement(ctx, "user1") //nolint:errcheck // checked below
+		}(i)
+	}
+	// Let the readers pile up on the first Cold reads
+	require.Eventually(t, func() bool { return cold.reads.Load() == 2 }, time.Second, time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	close(cold.release)
+	wg.Wait()
+
+	assert.Equal(t, int32(2), cold.reads.Load())
+	for i := 0; i < readers; i++ {
+		require.NotNil(t, usages[i])
+		assert.Equal(t, 50, usages[i].Used)
+		require.NotNil(t, ents[i])
+		assert.Equal(t, "pro", ents[i].Tier)
+	}
+	// Each reader owns its record
+	usages[0].Used = 0
+	assert.Equal(t, 50, usages[1].Used)
+}
+
+func TestStorage_ReadThrough_SharedReadOutlivesCanceledCaller(t *testing.T) {
+	ctx := context.Background()
+	cold := &slowColdStorage{Storage: memory.New(), release: make(chan struct{})}
+	require.NoError(t, cold.SetEntitlement(ctx, &goquota.Entitlement{UserID: "user1", Tier: "pro"}))
+
+	storage, err := New(Config{Hot: memory.New(), Cold: cold})
+	require.NoError(t, err)
+	defer storage.Close()
+
+	// The first caller starts the Cold read and gives up
+	leaderCtx, cancel := context.WithCancel(ctx)
+	leaderErr := make(chan error, 1)
+	go func() {
+		_, err := storage.GetEntitlement(leaderCtx, "user1")
+		leaderErr <- err
+	}()
+	require.Eventually(t, func() bool { return cold.reads.Load() == 1 }, time.Second, time.Millisecond)
+
+	follower := make(chan *goquota.Entitlement, 1)
+	go func() {
+		ent, _ := storage.GetEntitlement(ctx, "user1") //nolint:errcheck // checked below
+		follower <- ent
+	}()
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	assert.ErrorIs(t, <-leaderErr, context.Canceled)
+
+	// The shared read is not canceled with it
+	close(cold.release)
+	ent := <-follower
+	require.NotNil(t, ent)
+	assert.Equal(t, "pro", ent.Tier)
+	assert.Equal(t, int32(1), cold.reads.Load())
+}
+
 func TestStorage_SetEntitlement_WriteThrough(t *testing.T) {
 	hot := memory.New()
 	cold := memory.New()
@@ -380,6 +551,74 @@ func TestStorage_RecordRateLimitRequest_HotOnly(t *testing.T) {
 	// Cold is not accessed (hot-only strategy)
 }
 
+func TestStorage_WebhookDeliveries_ColdOnly(t *testing.T) {
+	hot := memory.New()
+	cold := memory.New()
+	storage, _ := New(Config{Hot: hot, Cold: cold})
+	defer storage.Close()
+
+	ctx := context.Background()
+	now := time.Now().UTC()
+	delivery := &goquota.WebhookDelivery{
+		ID:            "d1",
+		EventType:     goquota.EventQuotaWarning,
+		URL:           "https://example.com/hook",
+		Payload:       []byte(`{}`),
+		Status:        goquota.WebhookDeliveryPending,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	require.NoError(t, storage.SaveWebhookDelivery(ctx, delivery))
+
+	// Deliveries are durable state and must only be written to Cold
+	inCold, err := cold.GetWebhookDelivery(ctx, "d1")
+	require.NoError(t, err)
+	require.NotNil(t, inCold)
+	inHot, err := hot.GetWebhookDelivery(ctx, "d1")
+	require.NoError(t, err)
+	assert.Nil(t, inHot)
+
+	claimed, err := storage.ClaimWebhookDeliveries(ctx, now, 10, time.Minute)
+	require.NoError(t, err)
+	assert.Len(t, claimed, 1)
+
+	require.NoError(t, storage.DeleteWebhookDelivery(ctx, "d1"))
+	list, err := storage.ListWebhookDeliveries(ctx, goquota.WebhookDeliveryPending, 0, 0)
+	require.NoError(t, err)
+	assert.Empty(t, list)
+}
+
+func TestStorage_WarningState_HotOnly(t *testing.T) {
+	hot := memory.New()
+	cold := memory.New()
+	storage, _ := New(Config{Hot: hot, Cold: cold})
+	defer storage.Close()
+
+	ctx := context.Background()
+	period := goquota.Period{
+		Start: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		End:   time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC),
+		Type:  goquota.PeriodTypeMonthly,
+	}
+
+	marked, err := storage.MarkWarningNotified(ctx, "user1", "api_calls", period, 0.8)
+	require.NoError(t, err)
+	assert.True(t, marked)
+
+	inHot, err := hot.GetNotifiedWarnings(ctx, "user1", "api_calls", period)
+	require.NoError(t, err)
+	assert.Equal(t, []float64{0.8}, inHot)
+	inCold, err := cold.GetNotifiedWarnings(ctx, "user1", "api_calls", period)
+	require.NoError(t, err)
+	assert.Empty(t, inCold)
+
+	require.NoError(t, storage.ClearNotifiedWarnings(ctx, "user1", "api_calls", period, []float64{0.8}))
+	notified, err := storage.GetNotifiedWarnings(ctx, "user1", "api_calls", period)
+	require.NoError(t, err)
+	assert.Empty(t, notified)
+}
+
 // --- Async Consumption Tests ---
 
 func TestStorage_ConsumeQuota_Async(t *testing.T) {
@@ -480,6 +719,212 @@ func TestStorage_ConsumeQuota_Sync(t *testing.T) {
 	assert.Equal(t, 10, coldUsage.Used)
 }
 
+// --- Coalesced Consumption Tests ---
+
+// deltaCountingStorage counts the deltas applied to a memory store
+type deltaCountingStorage struct {
+	*memory.Storage
+	mu     sync.Mutex
+	deltas int
+	err    error
+}
+
+func (s *deltaCountingStorage) ApplyUsageDelta(ctx context.Context, req *goquota.UsageDeltaRequest) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+	s.deltas++
+	return s.Storage.ApplyUsageDelta(ctx, req)
+}
+
+func (s *deltaCountingStorage) applied() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.deltas
+}
+
+func TestStorage_ConsumeQuota_Coalesced(t *testing.T) {
+	ctx := context.Background()
+	period := goquota.Period{
+		Start: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		End:   time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+		Type:  goquota.PeriodTypeMonthly,
+	}
+	consume := func(storage *Storage, userID string) {
+		_, err := storage.ConsumeQuota(ctx, &goquota.ConsumeRequest{
+			UserID:   userID,
+			Resource: "api_calls",
+			Amount:   1,
+			Tier:     "pro",
+			Period:   period,
+			Limit:    1000,
+		})
+		require.NoError(t, err)
+	}
+
+	t.Run("requires delta store", func(t *testing.T) {
+		storage, err := New(Config{Hot: memory.New(), Cold: coldWithoutDeltas{memory.New()}, CoalesceUsageSync: true})
+		assert.Error(t, err)
+		assert.Nil(t, storage)
+		assert.Contains(t, err.Error(), "UsageDeltaStore")
+	})
+
+	t.Run("one delta per usage record", func(t *testing.T) {
+		hot := memory.New()
+		cold := &deltaCountingStorage{Storage: memory.New()}
+		storage, err := New(Config{Hot: hot, Cold: cold, CoalesceUsageSync: true, FlushInterval: time.Hour})
+		require.NoError(t, err)
+		defer storage.Close()
+
+		for i := 0; i < 100; i++ {
+			consume(storage, "user1")
+		}
+		consume(storage, "user2")
+
+		// Nothing is written before the flush
+		coldUsage, err := cold.GetUsage(ctx, "user1", "api_calls", period)
+		require.NoError(t, err)
+		assert.Nil(t, coldUsage)
+
+		require.NoError(t, storage.Flush(ctx))
+		assert.Equal(t, 2, cold.applied())
+
+		coldUsage, err = cold.GetUsage(ctx, "user1", "api_calls", period)
+		require.NoError(t, err)
+		require.NotNil(t, coldUsage)
+		assert.Equal(t, 100, coldUsage.Used)
+		assert.Equal(t, 1000, coldUsage.Limit)
+
+		hotUsage, err := hot.GetUsage(ctx, "user1", "api_calls", period)
+		require.NoError(t, err)
+		assert.Equal(t, 100, hotUsage.Used)
+	})
+
+	t.Run("flushes on interval", func(t *testing.T) {
+		cold := &deltaCountingStorage{Storage: memory.New()}
+		storage, err := New(Config{
+			Hot: memory.New(), Cold: cold, CoalesceUsageSync: true, FlushInterval: 10 * time.Millisecond,
+		})
+		require.NoError(t, err)
+		defer storage.Close()
+
+		consume(storage, "user1")
+		assert.Eventually(t, func() bool { return cold.applied() == 1 }, time.Second, 5*time.Millisecond)
+	})
+
+	t.Run("flushes on size", func(t *testing.T) {
+		cold := &deltaCountingStorage{Storage: memory.New()}
+		storage, err := New(Config{
+			Hot: memory.New(), Cold: cold, CoalesceUsageSync: true, FlushInterval: time.Hour, FlushSize: 3,
+		})
+		require.NoError(t, err)
+		defer storage.Close()
+
+		consume(storage, "user1")
+		consume(storage, "user2")
+		assert.Equal(t, 0, cold.applied())
+		consume(storage, "user3")
+		assert.Eventually(t, func() bool { return cold.applied() == 3 }, time.Second, 5*time.Millisecond)
+	})
+
+	t.Run("flushes on close", func(t *testing.T) {
+		cold := &deltaCountingStorage{Storage: memory.New()}
+		storage, err := New(Config{Hot: memory.New(), Cold: cold, CoalesceUsageSync: true, FlushInterval: time.Hour})
+		require.NoError(t, err)
+
+		consume(storage, "user1")
+		consume(storage, "user1")
+		require.NoError(t, storage.Close())
+
+		coldUsage, err := cold.GetUsage(ctx, "user1", "api_calls", period)
+		require.NoError(t, err)
+		require.NotNil(t, coldUsage)
+		assert.Equal(t, 2, coldUsage.Used)
+	})
+
+	t.Run("keeps failed deltas", func(t *testing.T) {
+		cold := &deltaCountingStorage{Storage: memory.New(), err: errors.New("cold unavailable")}
+		storage, err := New(Config{Hot: memory.New(), Cold: cold, CoalesceUsageSync: true, FlushInterval: time.Hour})
+		require.NoError(t, err)
+		defer storage.Close()
+
+		consume(storage, "user1")
+		assert.Error(t, storage.Flush(ctx))
+		consume(storage, "user1")
+
+		cold.mu.Lock()
+		cold.err = nil
+		cold.mu.Unlock()
+		require.NoError(t, storage.Flush(ctx))
+
+		coldUsage, err := cold.GetUsage(ctx, "user1", "api_calls", period)
+		require.NoError(t, err)
+		require.NotNil(t, coldUsage)
+		assert.Equal(t, 2, coldUsage.Used)
+		assert.Equal(t, 1, cold.applied())
+	})
+
+	t.Run("set usage discards pending delta", func(t *testing.T) {
+		cold := &deltaCountingStorage{Storage: memory.New()}
+		storage, err := New(Config{Hot: memory.New(), Cold: cold, CoalesceUsageSync: true, FlushInterval: time.Hour})
+		require.NoError(t, err)
+		defer storage.Close()
+
+		consume(storage, "user1")
+		require.NoError(t, storage.SetUsage(ctx, "user1", "api_calls", &goquota.Usage{
+			UserID: "user1", Resource: "api_calls", Used: 0, Limit: 1000, Period: period, Tier: "pro",
+		}, period))
+		require.NoError(t, storage.Flush(ctx))
+
+		coldUsage, err := cold.GetUsage(ctx, "user1", "api_calls", period)
+		require.NoError(t, err)
+		assert.Equal(t, 0, coldUsage.Used)
+	})
+
+	t.Run("refund flushes pending delta", func(t *testing.T) {
+		cold := &deltaCountingStorage{Storage: memory.New()}
+		storage, err := New(Config{Hot: memory.New(), Cold: cold, CoalesceUsageSync: true, FlushInterval: time.Hour})
+		require.NoError(t, err)
+		defer storage.Close()
+
+		consume(storage, "user1")
+		consume(storage, "user1")
+		require.NoError(t, storage.RefundQuota(ctx, &goquota.RefundRequest{
+			UserID: "user1", Resource: "api_calls", Amount: 1, PeriodType: period.Type, Period: period,
+		}))
+
+		coldUsage, err := cold.GetUsage(ctx, "user1", "api_calls", period)
+		require.NoError(t, err)
+		require.NotNil(t, coldUsage)
+		assert.Equal(t, 1, coldUsage.Used)
+	})
+
+	t.Run("idempotent consumptions sync one by one", func(t *testing.T) {
+		cold := &deltaCountingStorage{Storage: memory.New()}
+		storage, err := New(Config{Hot: memory.New(), Cold: cold, CoalesceUsageSync: true, FlushInterval: time.Hour})
+		require.NoError(t, err)
+		defer storage.Close()
+
+		_, err = storage.ConsumeQuota(ctx, &goquota.ConsumeRequest{
+			UserID: "user1", Resource: "api_calls", Amount: 1, Tier: "pro", Period: period, Limit: 1000,
+			IdempotencyKey: "coalesce-1",
+		})
+		require.NoError(t, err)
+
+		record, err := cold.GetConsumptionRecord(ctx, "coalesce-1")
+		require.NoError(t, err)
+		assert.NotNil(t, record)
+		assert.Equal(t, 0, cold.applied())
+	})
+}
+
+// coldWithoutDeltas hides the UsageDeltaStore implementation of a memory store
+type coldWithoutDeltas struct {
+	goquota.Storage
+}
+
 // --- Record Retrieval Read-Through Tests (Critical for Idempotency) ---
 
 func TestStorage_GetConsumptionRecord_ReadThrough(t *testing.T) {
@@ -794,3 +1239,38 @@ func TestStorage_SubtractLimit_WriteThrough(t *testing.T) {
 	assert.NotNil(t, coldUsage)
 	assert.Equal(t, 70, coldUsage.Limit)
 }
+
+func TestStorage_Statements_ColdOnly(t *testing.T) {
+	hot := memory.New()
+	cold := memory.New()
+	storage, _ := New(Config{Hot: hot, Cold: cold})
+	defer storage.Close()
+
+	ctx := context.Background()
+	statement := &goquota.Statement{
+		UserID: "user1",
+		Tier:   "pro",
+		Period: goquota.Period{
+			Start: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+			End:   time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC),
+			Type:  goquota.PeriodTypeMonthly,
+		},
+		Lines:    []goquota.StatementLine{{Resource: "api_calls", Used: 42, Limit: 100}},
+		ClosedAt: time.Now().UTC(),
+	}
+	created, err := storage.CreateStatement(ctx, statement)
+	require.NoError(t, err)
+	assert.True(t, created)
+
+	// Statements are permanent records and must only be written to Cold
+	inCold, err := cold.GetStatements(ctx, "user1", 0, 0)
+	require.NoError(t, err)
+	assert.Len(t, inCold, 1)
+	inHot, err := hot.GetStatements(ctx, "user1", 0, 0)
+	require.NoError(t, err)
+	assert.Empty(t, inHot)
+
+	created, err = storage.CreateStatement(ctx, statement)
+	require.NoError(t, err)
+	assert.False(t, created)
+}
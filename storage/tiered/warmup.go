@@ -0,0 +1,228 @@
+package tiered
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/mihaimyh/goquota/pkg/goquota"
+)
+
+const (
+	defaultWarmUpBatchSize   = 500
+	defaultWarmUpConcurrency = 4
+)
+
+// WarmUpOptions configures a WarmUp
+type WarmUpOptions struct {
+	// UserIDs limits the warm-up to these users, whose entitlements are loaded even without usage.
+	// Default: all users with usage in the current period
+	UserIDs []string
+
+	// ActiveWithin only loads usage updated within it (default: all usage of the current period)
+	ActiveWithin time.Duration
+
+	// BatchSize is the number of records read from Cold and checked in Hot at once (default: 500)
+	BatchSize int
+
+	// Concurrency is the maximum number of batches loaded at the same time (default: 4)
+	Concurrency int
+}
+
+// WarmUpReport is the result of a WarmUp
+type WarmUpReport struct {
+	// Entitlements and Usage count the records copied to Hot
+	Entitlements int `json:"entitlements"`
+	Usage        int `json:"usage"`
+
+	// Skipped counts the records already in Hot, which are left as they are
+	Skipped int `json:"skipped"`
+
+	Duration time.Duration `json:"duration"`
+}
+
+// WarmUp preloads Hot with the entitlements and current-period usage of active users from Cold,
+// e.g. after a Redis flush or failover, so that traffic doesn't fall through to Cold all at once.
+// Records already in Hot are not overwritten. It is safe to run while serving traffic when
+// hot storage implements goquota.SeedStore (memory and Redis do): otherwise a record written
+// to Hot between the check and the copy is overwritten with the Cold record.
+// Requires cold storage that implements goquota.RecordScanner.
+func (s *Storage) WarmUp(ctx context.Context, opts WarmUpOptions) (*WarmUpReport, error) {
+	cold, ok := s.cold.(goquota.RecordScanner)
+	if !ok {
+		return nil, errors.New("tiered storage: WarmUp requires cold storage that implements RecordScanner")
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaultWarmUpBatchSize
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = defaultWarmUpConcurrency
+	}
+
+	w := &warmUp{s: s, opts: &opts, now: time.Now().UTC(), users: make(map[string]bool)}
+	if len(opts.UserIDs) > 0 {
+		w.only = make(map[string]bool, len(opts.UserIDs))
+		for _, userID := range opts.UserIDs {
+			w.only[userID] = true
+			w.users[userID] = true
+		}
+	}
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(opts.Concurrency)
+	err := scanAll(gctx, cold.ScanUsage, opts.BatchSize, func(page []*goquota.Usage) error {
+		usages := w.currentUsage(page)
+		if len(usages) > 0 {
+			g.Go(func() error { return w.loadUsage(gctx, usages) })
+		}
+		return nil
+	})
+	if waitErr := g.Wait(); err == nil {
+		err = waitErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("tiered storage: failed to warm up usage: %w", err)
+	}
+
+	userIDs := make([]string, 0, len(w.users))
+	for userID := range w.users {
+		userIDs = append(userIDs, userID)
+	}
+	g, gctx = errgroup.WithContext(ctx)
+	g.SetLimit(opts.Concurrency)
+	for start := 0; start < len(userIDs); start += opts.BatchSize {
+		batch := userIDs[start:min(start+opts.BatchSize, len(userIDs))]
+		g.Go(func() error { return w.loadEntitlements(gctx, batch) })
+	}
+	if err := g.Wait(); err != nil {
+		return nil, fmt.Errorf("tiered storage: failed to warm up entitlements: %w", err)
+	}
+
+	return &WarmUpReport{
+		Entitlements: int(w.entitlements.Load()),
+		Usage:        int(w.usage.Load()),
+		Skipped:      int(w.skipped.Load()),
+		Duration:     time.Since(w.now),
+	}, nil
+}
+
+// warmUp holds the state of a WarmUp
+type warmUp struct {
+	s    *Storage
+	opts *WarmUpOptions
+	now  time.Time
+	only map[string]bool // nil: all users
+
+	mu    sync.Mutex
+	users map[string]bool // users whose entitlements are loaded
+
+	entitlements atomic.Int64
+	usage        atomic.Int64
+	skipped      atomic.Int64
+}
+
+// currentUsage returns the usage records of a page to load, and records their users
+func (w *warmUp) currentUsage(page []*goquota.Usage) []*goquota.Usage {
+	usages := make([]*goquota.Usage, 0, len(page))
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, usage := range page {
+		if w.only != nil && !w.only[usage.UserID] {
+			continue
+		}
+		if !isCurrentPeriod(usage.Period, w.now) {
+			continue
+		}
+		if w.opts.ActiveWithin > 0 && w.now.Sub(usage.UpdatedAt) > w.opts.ActiveWithin {
+			continue
+		}
+		usages = append(usages, usage)
+		w.users[usage.UserID] = true
+	}
+	return usages
+}
+
+func isCurrentPeriod(period goquota.Period, now time.Time) bool {
+	if period.Type == goquota.PeriodTypeForever {
+		return true
+	}
+	return !now.Before(period.Start) && now.Before(period.End)
+}
+
+// loadUsage copies the usage records that are missing from Hot
+func (w *warmUp) loadUsage(ctx context.Context, usages []*goquota.Usage) error {
+	if seeder, ok := w.s.hot.(goquota.SeedStore); ok {
+		for _, usage := range usages {
+			created, err := seeder.SeedUsage(ctx, usage.UserID, usage.Resource, usage, usage.Period)
+			if err != nil {
+				return err
+			}
+			w.count(&w.usage, created)
+		}
+		return nil
+	}
+
+	hotUsages, err := getUsages(ctx, w.s.hot, usageQueries(usages))
+	if err != nil {
+		return err
+	}
+	for i, usage := range usages {
+		if hotUsages[i] != nil {
+			w.skipped.Add(1)
+			continue
+		}
+		if err := w.s.hot.SetUsage(ctx, usage.UserID, usage.Resource, usage, usage.Period); err != nil {
+			return err
+		}
+		w.usage.Add(1)
+	}
+	return nil
+}
+
+// loadEntitlements copies the entitlements of a batch of users that are missing from Hot
+func (w *warmUp) loadEntitlements(ctx context.Context, userIDs []string) error {
+	coldEnts, err := getEntitlements(ctx, w.s.cold, userIDs)
+	if err != nil || len(coldEnts) == 0 {
+		return err
+	}
+	if seeder, ok := w.s.hot.(goquota.SeedStore); ok {
+		for _, ent := range coldEnts {
+			created, err := seeder.SeedEntitlement(ctx, ent)
+			if err != nil {
+				return err
+			}
+			w.count(&w.entitlements, created)
+		}
+		return nil
+	}
+
+	hotEnts, err := getEntitlements(ctx, w.s.hot, userIDs)
+	if err != nil {
+		return err
+	}
+	for userID, ent := range coldEnts {
+		if _, ok := hotEnts[userID]; ok {
+			w.skipped.Add(1)
+			continue
+		}
+		if err := w.s.hot.SetEntitlement(ctx, ent); err != nil {
+			return err
+		}
+		w.entitlements.Add(1)
+	}
+	return nil
+}
+
+// count counts a record copied to Hot, or skipped if it was already there
+func (w *warmUp) count(copied *atomic.Int64, created bool) {
+	if created {
+		copied.Add(1)
+	} else {
+		w.skipped.Add(1)
+	}
+}
@@ -0,0 +1,108 @@
+package tiered
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mihaimyh/goquota/pkg/goquota"
+	"github.com/mihaimyh/goquota/storage/memory"
+)
+
+func TestStorage_WarmUp(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().UTC()
+	current := goquota.Period{
+		Start: now.Add(-time.Hour),
+		End:   now.Add(time.Hour),
+		Type:  goquota.PeriodTypeDaily,
+	}
+	previous := goquota.Period{
+		Start: now.Add(-25 * time.Hour),
+		End:   now.Add(-time.Hour),
+		Type:  goquota.PeriodTypeDaily,
+	}
+	seed := func(cold goquota.Storage, userID string, period goquota.Period, used int, updatedAt time.Time) {
+		require.NoError(t, cold.SetEntitlement(ctx, &goquota.Entitlement{
+			UserID: userID, Tier: "pro", SubscriptionStartDate: current.Start, UpdatedAt: updatedAt,
+		}))
+		require.NoError(t, cold.SetUsage(ctx, userID, "api_calls", &goquota.Usage{
+			UserID: userID, Resource: "api_calls", Used: used, Limit: 100,
+			Period: period, Tier: "pro", UpdatedAt: updatedAt,
+		}, period))
+	}
+	hotUsage := func(hot goquota.Storage, userID string) *goquota.Usage {
+		usage, err := hot.GetUsage(ctx, userID, "api_calls", current)
+		require.NoError(t, err)
+		return usage
+	}
+
+	t.Run("requires record scanner", func(t *testing.T) {
+		storage, err := New(Config{Hot: memory.New(), Cold: coldWithoutDeltas{memory.New()}})
+		require.NoError(t, err)
+		_, err = storage.WarmUp(ctx, WarmUpOptions{})
+		assert.ErrorContains(t, err, "RecordScanner")
+	})
+
+	// Hot storage that implements goquota.SeedStore, and one that doesn't
+	hotStores := map[string]func(*memory.Storage) goquota.Storage{
+		"with seeding":    func(mem *memory.Storage) goquota.Storage { return mem },
+		"without seeding": func(mem *memory.Storage) goquota.Storage { return coldWithoutDeltas{mem} },
+	}
+	for name, newHot := range hotStores {
+		t.Run("loads current usage and entitlements of active users "+name, func(t *testing.T) {
+			mem, cold := memory.New(), memory.New()
+			hot := newHot(mem)
+			storage, err := New(Config{Hot: hot, Cold: cold})
+			require.NoError(t, err)
+
+			for _, userID := range []string{"user1", "user2", "user3", "user4", "user5"} {
+				seed(cold, userID, current, 10, now)
+			}
+			// Inactive: only usage of a past period
+			seed(cold, "user6", previous, 10, now)
+			// Already in Hot with newer usage: left alone
+			require.NoError(t, hot.SetUsage(ctx, "user1", "api_calls", &goquota.Usage{
+				UserID: "user1", Resource: "api_calls", Used: 15, Limit: 100, Period: current, Tier: "pro",
+			}, current))
+
+			report, err := storage.WarmUp(ctx, WarmUpOptions{BatchSize: 2, Concurrency: 2})
+			require.NoError(t, err)
+			assert.Equal(t, 4, report.Usage)
+			assert.Equal(t, 5, report.Entitlements)
+			assert.Equal(t, 1, report.Skipped)
+
+			assert.Equal(t, 15, hotUsage(hot, "user1").Used)
+			assert.Equal(t, 10, hotUsage(hot, "user2").Used)
+			ent, err := hot.GetEntitlement(ctx, "user5")
+			require.NoError(t, err)
+			assert.Equal(t, "pro", ent.Tier)
+			_, err = hot.GetEntitlement(ctx, "user6")
+			assert.ErrorIs(t, err, goquota.ErrEntitlementNotFound)
+		})
+	}
+
+	t.Run("filters users and activity", func(t *testing.T) {
+		hot, cold := memory.New(), memory.New()
+		storage, err := New(Config{Hot: hot, Cold: cold})
+		require.NoError(t, err)
+
+		seed(cold, "user1", current, 10, now)
+		seed(cold, "user2", current, 10, now.Add(-30*time.Minute))
+		seed(cold, "user3", current, 10, now)
+
+		report, err := storage.WarmUp(ctx, WarmUpOptions{
+			UserIDs: []string{"user1", "user2"}, ActiveWithin: 10 * time.Minute,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 1, report.Usage)
+		assert.Equal(t, 2, report.Entitlements)
+
+		assert.NotNil(t, hotUsage(hot, "user1"))
+		assert.Nil(t, hotUsage(hot, "user2"))
+		assert.Nil(t, hotUsage(hot, "user3"))
+	})
+}